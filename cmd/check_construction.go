@@ -18,14 +18,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"time"
 
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
 	"github.com/coinbase/rosetta-cli/pkg/results"
 	"github.com/coinbase/rosetta-cli/pkg/tester"
 
-	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -42,6 +48,72 @@ are run to ensure that intermediate representations are correct (for example, do
 an unsigned transaction return a superset of operations provided during
 construction?).
 
+If construction.faucet is configured, funds are requested from the faucet
+automatically whenever the request_funds workflow is waiting on funds.
+Otherwise, you must fund the printed addresses yourself.
+
+If construction.dry_run is true, transactions are constructed and signed
+but never submitted to /construction/submit. Each signed payload is logged
+instead and end conditions are evaluated against workflows completed
+without broadcast.
+
+If construction.duration is set, check:construction fails if end conditions
+are not reached within that many seconds.
+
+Before any workflows are run, construction.offline_url is checked to be
+reachable. If construction.verify_offline_mode is true, it must also differ
+from online_url and must not serve /block.
+
+Every signing payload must be for an account in the intent of its
+transaction (or in construction.allowed_signers), and the signers parsed
+from each signed transaction must exactly match the signing payloads.
+
+If construction.minimum_balance_confirmations is greater than 1, funds
+deposited to an account are only used by find_balance (and find_coin) once
+the depositing transaction has that many confirmations. Pending and available
+balances are reported by the status endpoint.
+
+Each prefunded account must have a non-zero balance of its configured
+currency on online_url before any workflows are run (the balance of each
+is printed). A currency with the same symbol but different decimals (or
+metadata) is an error. Set construction.skip_prefunded_verification to skip
+this check.
+
+If construction.confirmation_depth is populated, it is used as the
+confirmation depth of every broadcast (overriding the confirmation_depth set
+by each workflow scenario) and must be less than construction.stale_depth.
+If construction.tip_delay is populated, it is used instead of the top-level
+tip_delay to determine if check:construction is at tip.
+
+If --data-dir is provided, it overrides data_directory. If
+--data-dir-per-network is provided, data is stored in a subdirectory of
+the data directory for the network that is checked (after any --blockchain
+and --network overrides), so that runs against different networks never
+share a data directory.
+
+If --baseline (or baseline.file) is provided, the findings of the run
+(the error it ended with, failed tests, and warnings) are compared with
+those in the results file of an accepted run and classified as new,
+persisting, or resolved. The run fails if (and only if) it has new
+findings unless baseline.report_only is set.
+
+Metrics are served on /metrics of the status port in the Prometheus text
+format: broadcasts created, confirmed, and failed, the current and tip block
+indexes, the size of the database, and a histogram of request durations (by
+URL path). Metric names are prefixed with rosetta_cli_ and documented in
+pkg/metrics.
+
+The random seed of a run (construction.random_seed, or a generated seed if
+it is not populated) is logged and included in the results file. It seeds
+the retry jitter of the run. Randomness in workflow actions (random_number,
+random_string, and account creation in find_balance) is generated by
+rosetta-sdk-go and is not derived from the seed.
+
+When the configuration file is loaded, a warning is printed for each
+combination of options that is valid but likely not intended (like
+construction.quiet with a debug log_level). These warnings are also included
+in the results file.
+
 Check out the https://github.com/coinbase/rosetta-cli/tree/master/examples
 directory for examples of how to configure this test for Bitcoin and
 Ethereum.
@@ -60,26 +132,50 @@ func runCheckConstructionCmd(_ *cobra.Command, _ []string) error {
 			nil,
 			nil,
 			errors.New("construction configuration is missing"),
+			nil,
+		)
+	}
+
+	if err := tester.EnsureDataDirectoryExists(Config); err != nil {
+		return results.ExitConstruction(
+			Config,
+			nil,
+			nil,
+			err,
+			nil,
 		)
 	}
 
-	ensureDataDirectoryExists()
 	ctx, cancel := context.WithCancel(Context)
 
-	fetcherOpts := []fetcher.Option{
-		fetcher.WithMaxConnections(Config.MaxOnlineConnections),
-		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime) * time.Second),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout) * time.Second),
-		fetcher.WithMaxRetries(Config.MaxRetries),
-	}
-	if Config.ForceRetry {
-		fetcherOpts = append(fetcherOpts, fetcher.WithForceRetry())
+	// The seed is stored in the configuration so that it is
+	// included in the results (and the run can be replayed).
+	if Config.Construction.RandomSeed == nil {
+		seed := time.Now().UnixNano()
+		Config.Construction.RandomSeed = &seed
 	}
+	color.Cyan("construction random seed: %d", *Config.Construction.RandomSeed)
 
-	fetcher := fetcher.New(
-		Config.OnlineURL,
-		fetcherOpts...,
+	// The construction timeout only applies to /construction/*
+	// requests so that syncing blocks uses the top-level timeout.
+	fetcher, err := clients.NewOnlineFetcher(
+		Config.HTTPTimeout,
+		httpclient.WithPathTimeout(
+			"/construction/",
+			time.Duration(Config.Construction.HTTPTimeout)*time.Second,
+		),
+		httpclient.WithRandomSeed(*Config.Construction.RandomSeed),
 	)
+	if err != nil {
+		cancel()
+		return results.ExitConstruction(
+			Config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize online fetcher", err),
+			nil,
+		)
+	}
 
 	_, _, fetchErr := fetcher.InitializeAsserter(ctx, Config.Network, Config.ValidationFile)
 	if fetchErr != nil {
@@ -89,10 +185,11 @@ func runCheckConstructionCmd(_ *cobra.Command, _ []string) error {
 			nil,
 			nil,
 			fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err),
+			nil,
 		)
 	}
 
-	_, err := utils.CheckNetworkSupported(ctx, Config.Network, fetcher)
+	networkStatus, err := utils.CheckNetworkSupported(ctx, Config.Network, fetcher)
 	if err != nil {
 		cancel()
 		return results.ExitConstruction(
@@ -100,6 +197,7 @@ func runCheckConstructionCmd(_ *cobra.Command, _ []string) error {
 			nil,
 			nil,
 			fmt.Errorf("%w: unable to confirm network is supported", err),
+			nil,
 		)
 	}
 
@@ -113,15 +211,76 @@ func runCheckConstructionCmd(_ *cobra.Command, _ []string) error {
 				nil,
 				nil,
 				err,
+				nil,
 			)
 		}
 	}
 
+	offlineFetcher, err := clients.NewOfflineFetcher(fetcher.Asserter)
+	if err != nil {
+		cancel()
+		return results.ExitConstruction(
+			Config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize offline fetcher", err),
+			nil,
+		)
+	}
+
+	if err := tester.VerifyOfflineNode(
+		ctx,
+		Config,
+		Config.Network,
+		offlineFetcher,
+		networkStatus.GenesisBlockIdentifier,
+	); err != nil {
+		cancel()
+		return results.ExitConstruction(
+			Config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to verify offline node", err),
+			nil,
+		)
+	}
+
+	if err := tester.VerifyPrefundedAccounts(
+		ctx,
+		Config,
+		Config.Network,
+		fetcher,
+	); err != nil {
+		cancel()
+		return results.ExitConstruction(
+			Config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to verify prefunded accounts", err),
+			nil,
+		)
+	}
+
+	skewMonitor, err := clients.InitializeSkewMonitor(ctx)
+	if err != nil {
+		cancel()
+		return results.ExitConstruction(
+			Config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize clock skew monitor", err),
+			nil,
+		)
+	}
+
 	constructionTester, err := tester.InitializeConstruction(
 		ctx,
 		Config,
 		Config.Network,
 		fetcher,
+		offlineFetcher,
+		skewMonitor,
+		newFaucetClient(),
 		cancel,
 		&SignalReceived,
 	)
@@ -131,6 +290,9 @@ func runCheckConstructionCmd(_ *cobra.Command, _ []string) error {
 			nil,
 			nil,
 			fmt.Errorf("%w: unable to initialize construction tester", err),
+			&results.ExitConstructionOptions{
+				ClockSkew: skewMonitor.Current(),
+			},
 		)
 	}
 
@@ -142,6 +304,9 @@ func runCheckConstructionCmd(_ *cobra.Command, _ []string) error {
 			nil,
 			nil,
 			fmt.Errorf("%w: unable to perform broadcasts", err),
+			&results.ExitConstructionOptions{
+				ClockSkew: skewMonitor.Current(),
+			},
 		)
 	}
 
@@ -163,16 +328,33 @@ func runCheckConstructionCmd(_ *cobra.Command, _ []string) error {
 	})
 
 	g.Go(func() error {
-		return tester.LogMemoryLoop(ctx)
+		return constructionTester.StartFaucet(ctx)
 	})
 
+	if Config.LogLevel.Enabled(zapcore.InfoLevel) {
+		g.Go(func() error {
+			return tester.LogMemoryLoop(ctx)
+		})
+	}
+
 	g.Go(func() error {
-		return tester.StartServer(
+		return skewMonitor.Start(ctx)
+	})
+
+	g.Go(func() error {
+		if err := tester.StartServer(
 			ctx,
 			"check:construction status",
-			constructionTester,
-			Config.Construction.StatusPort,
-		)
+			metrics.Handler(constructionTester, clients.RequestDurations(), constructionTester),
+			*Config.Construction.StatusPort,
+		); err != nil {
+			return fmt.Errorf(
+				"%w: set construction.status_port to a free port or 0 to disable the status server",
+				err,
+			)
+		}
+
+		return nil
 	})
 
 	sigListeners := []context.CancelFunc{cancel}
@@ -180,3 +362,47 @@ func runCheckConstructionCmd(_ *cobra.Command, _ []string) error {
 
 	return constructionTester.HandleErr(g.Wait(), &sigListeners)
 }
+
+// newFaucetClient returns the *http.Client used for faucet
+// requests or nil if no faucet is configured. All failed requests
+// are retried with backoff (using the default backoff if none is
+// configured). Headers for the implementation are not sent to the
+// faucet.
+func newFaucetClient() *http.Client {
+	if Config.Construction.Faucet == nil {
+		return nil
+	}
+
+	proxyURL, err := httpclient.ParseProxyURL(Config.ProxyURL)
+	if err != nil {
+		log.Fatalf("%s: unable to parse proxy url", err.Error())
+	}
+
+	retryBackoff := Config.RetryBackoff
+	if retryBackoff == nil {
+		jitter := configuration.DefaultRetryJitter
+		retryBackoff = &configuration.RetryBackoffConfiguration{
+			InitialIntervalMs: configuration.DefaultRetryInitialIntervalMs,
+			MaxIntervalMs:     configuration.DefaultRetryMaxIntervalMs,
+			Multiplier:        configuration.DefaultRetryMultiplier,
+			Jitter:            &jitter,
+		}
+	}
+
+	opts := []httpclient.Option{
+		httpclient.WithProxyURL(proxyURL),
+		httpclient.WithRetryBackoff(
+			clients.NewBackoff(retryBackoff, time.Duration(Config.RetryElapsedTime)*time.Second),
+			true,
+		),
+	}
+	if Config.Construction.RandomSeed != nil {
+		opts = append(opts, httpclient.WithRandomSeed(*Config.Construction.RandomSeed))
+	}
+
+	return httpclient.New(
+		time.Duration(Config.HTTPTimeout)*time.Second,
+		1,
+		opts...,
+	)
+}
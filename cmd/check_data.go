@@ -16,16 +16,12 @@ package cmd
 
 import (
 	"context"
-	"fmt"
-	"time"
+	"errors"
 
-	"github.com/coinbase/rosetta-cli/pkg/results"
 	"github.com/coinbase/rosetta-cli/pkg/tester"
 
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
-	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/spf13/cobra"
-	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -71,121 +67,39 @@ of what one of these files looks like.`,
 )
 
 func runCheckDataCmd(_ *cobra.Command, _ []string) error {
-	ensureDataDirectoryExists()
-	ctx, cancel := context.WithCancel(Context)
-
-	fetcherOpts := []fetcher.Option{
-		fetcher.WithMaxConnections(Config.MaxOnlineConnections),
-		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime) * time.Second),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout) * time.Second),
-		fetcher.WithMaxRetries(Config.MaxRetries),
-	}
-	if Config.ForceRetry {
-		fetcherOpts = append(fetcherOpts, fetcher.WithForceRetry())
+	if fresh && resume {
+		return errors.New("--fresh discards the checkpoint that --resume resumes from")
 	}
 
-	fetcher := fetcher.New(
-		Config.OnlineURL,
-		fetcherOpts...,
-	)
-
-	_, _, fetchErr := fetcher.InitializeAsserter(ctx, Config.Network, Config.ValidationFile)
-	if fetchErr != nil {
-		cancel()
-		return results.ExitData(
-			Config,
-			nil,
-			nil,
-			fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err),
-			"",
-			"",
-		)
-	}
+	ctx, cancel := context.WithCancel(Context)
 
-	networkStatus, err := utils.CheckNetworkSupported(ctx, Config.Network, fetcher)
-	if err != nil {
-		cancel()
-		return results.ExitData(
-			Config,
-			nil,
-			nil,
-			fmt.Errorf("%w: unable to confirm network", err),
-			"",
-			"",
-		)
+	// Signals are handled before initialization so that an
+	// interrupted run always stops cleanly and writes its
+	// results.
+	sigListeners := []context.CancelFunc{cancel}
+	handleSignals(&sigListeners)
+
+	Config.Data.AllowEndpointOverridesSuccess = allowOverridesSuccess
+	Config.Data.EstimateStorage = estimateStorage
+	Config.Data.Resume = resume
+	Config.Data.Fresh = fresh
+	Config.Data.StrictStorageEstimate = strictStorageEstimate
+
+	opts := &tester.CheckDataOptions{
+		SignalReceived:        &SignalReceived,
+		SigListeners:          &sigListeners,
+		ConfigurationFile:     configurationFile,
+		ConfigurationProfile:  configurationProfile,
+		ConfigurationSnapshot: configurationSnapshot,
 	}
-
 	if asserterConfigurationFile != "" {
-		if err := validateNetworkOptionsMatchesAsserterConfiguration(
-			ctx, fetcher, Config.Network, asserterConfigurationFile,
-		); err != nil {
-			cancel()
-			return results.ExitData(
-				Config,
-				nil,
-				nil,
-				err,
-				"",
-				"",
+		opts.ValidateNetworkOptions = func(ctx context.Context, f *fetcher.Fetcher) error {
+			return validateNetworkOptionsMatchesAsserterConfiguration(
+				ctx, f, Config.Network, asserterConfigurationFile,
 			)
 		}
 	}
 
-	dataTester := tester.InitializeData(
-		ctx,
-		Config,
-		Config.Network,
-		fetcher,
-		cancel,
-		networkStatus.GenesisBlockIdentifier,
-		nil, // only populated when doing recursive search
-		&SignalReceived,
-	)
-
-	defer dataTester.CloseDatabase(ctx)
-
-	g, ctx := errgroup.WithContext(ctx)
-	g.Go(func() error {
-		return dataTester.StartPeriodicLogger(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.StartReconciler(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.StartSyncing(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.StartPruning(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.WatchEndConditions(ctx)
-	})
-
-	g.Go(func() error {
-		return dataTester.StartReconcilerCountUpdater(ctx)
-	})
-
-	g.Go(func() error {
-		return tester.LogMemoryLoop(ctx)
-	})
-
-	g.Go(func() error {
-		return tester.StartServer(
-			ctx,
-			"check:data status",
-			dataTester,
-			Config.Data.StatusPort,
-		)
-	})
-
-	sigListeners := []context.CancelFunc{cancel}
-	go handleSignals(&sigListeners)
-
-	// HandleErr will exit if we should not attempt
-	// to find missing operations.
-	return dataTester.HandleErr(g.Wait(), &sigListeners)
+	_, err := tester.RunCheckData(ctx, cancel, Config, clients, opts)
+	return err
 }
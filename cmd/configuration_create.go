@@ -15,7 +15,13 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/coinbase/rosetta-cli/configuration"
 
@@ -27,13 +33,169 @@ var (
 	configurationCreateCmd = &cobra.Command{
 		Use:   "configuration:create",
 		Short: "Create a default configuration file at the provided path",
-		RunE:  runConfigurationCreateCmd,
-		Args:  cobra.ExactArgs(1),
+		Long: `Create a default configuration file at the provided path.
+
+If --construction is provided, you are prompted for the offline URL, the
+path of a Rosetta Constructor DSL file, and the number of times each workflow
+must complete before check:construction ends. The resulting construction
+configuration is validated (compiling the DSL file) before the configuration
+file is written.`,
+		RunE: runConfigurationCreateCmd,
+		Args: cobra.ExactArgs(1),
 	}
+
+	// configurationCreateConstruction prompts for a construction
+	// configuration when creating a configuration file.
+	configurationCreateConstruction bool
 )
 
+// promptLine writes question (and defaultValue, if populated) to w and
+// returns the next line read from scanner (or defaultValue if the line
+// is empty).
+func promptLine(
+	scanner *bufio.Scanner,
+	w io.Writer,
+	question string,
+	defaultValue string,
+) (string, error) {
+	if len(defaultValue) > 0 {
+		fmt.Fprintf(w, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(w, "%s: ", question)
+	}
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("%w: unable to read %s", err, question)
+		}
+
+		return "", fmt.Errorf("%w: no input provided for %s", io.ErrUnexpectedEOF, question)
+	}
+
+	answer := strings.TrimSpace(scanner.Text())
+	if len(answer) == 0 {
+		return defaultValue, nil
+	}
+
+	return answer, nil
+}
+
+// parseEndConditions parses a comma-separated list of
+// workflow=count end conditions.
+func parseEndConditions(input string) (map[string]int, error) {
+	if len(input) == 0 {
+		return nil, nil
+	}
+
+	endConditions := map[string]int{}
+	for _, entry := range strings.Split(input, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), "=")
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return nil, fmt.Errorf("end condition %s must be of the form workflow=count", entry)
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid count for end condition %s", err, entry)
+		}
+
+		endConditions[strings.TrimSpace(parts[0])] = count
+	}
+
+	return endConditions, nil
+}
+
+// promptConstructionConfiguration prompts (reading answers from r
+// and writing prompts to w) for the offline URL, constructor DSL
+// file, and end condition counts of a construction configuration
+// that will be written to a configuration file in configDir.
+func promptConstructionConfiguration(
+	ctx context.Context,
+	r io.Reader,
+	w io.Writer,
+	configDir string,
+) (*configuration.ConstructionConfiguration, error) {
+	scanner := bufio.NewScanner(r)
+	offlineURL, err := promptLine(scanner, w, "Offline URL", configuration.DefaultURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var dslFile string
+	for len(dslFile) == 0 {
+		dslFile, err = promptLine(scanner, w, "Constructor DSL file", "")
+		if err != nil {
+			return nil, err
+		}
+
+		if len(dslFile) == 0 {
+			fmt.Fprintln(w, "a constructor DSL file is required")
+		}
+	}
+
+	// constructor_dsl_file is loaded relative to the
+	// configuration file.
+	absDSLFile, err := filepath.Abs(dslFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to resolve %s", err, dslFile)
+	}
+
+	absConfigDir, err := filepath.Abs(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to resolve %s", err, configDir)
+	}
+
+	relDSLFile, err := filepath.Rel(absConfigDir, absDSLFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to make %s relative to %s", err, dslFile, configDir)
+	}
+
+	var endConditions map[string]int
+	for {
+		input, err := promptLine(
+			scanner,
+			w,
+			"End conditions (workflow=count, comma separated, empty for none)",
+			"",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		endConditions, err = parseEndConditions(input)
+		if err == nil {
+			break
+		}
+
+		fmt.Fprintln(w, err.Error())
+	}
+
+	return configuration.NewConstructionConfiguration(
+		ctx,
+		configDir,
+		offlineURL,
+		relDSLFile,
+		endConditions,
+	)
+}
+
 func runConfigurationCreateCmd(cmd *cobra.Command, args []string) error {
-	if err := utils.SerializeAndWrite(args[0], configuration.DefaultConfiguration()); err != nil {
+	config := configuration.DefaultConfiguration()
+	if configurationCreateConstruction {
+		construction, err := promptConstructionConfiguration(
+			Context,
+			cmd.InOrStdin(),
+			cmd.OutOrStdout(),
+			filepath.Dir(args[0]),
+		)
+		if err != nil {
+			return fmt.Errorf("%w: unable to create construction configuration", err)
+		}
+
+		config.Construction = construction
+	}
+
+	if err := utils.SerializeAndWrite(args[0], config); err != nil {
 		return fmt.Errorf("%w: unable to save configuration file to %s", err, args[0])
 	}
 
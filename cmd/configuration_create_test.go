@@ -0,0 +1,170 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testConstructorDSL = "create_account(1){\n  create{\n  }\n}\n\n" +
+		"request_funds(1){\n  fund{\n  }\n}\n\ntransfer(10){\n  transfer{\n  }\n}\n"
+	invalidConstructorDSL = "create_account(1){\n  create{\n    x = not_an_action({});\n  }\n}\n"
+)
+
+// writeConstructorDSL writes contents to workflows.ros
+// in dir and returns its path.
+func writeConstructorDSL(t *testing.T, dir string, contents string) string {
+	dslFile := path.Join(dir, "workflows.ros")
+	assert.NoError(t, ioutil.WriteFile(
+		dslFile,
+		[]byte(contents),
+		os.FileMode(utils.DefaultFilePermissions),
+	))
+
+	return dslFile
+}
+
+func TestPromptConstructionConfiguration(t *testing.T) {
+	var tests = map[string]struct {
+		dsl    string
+		script func(dslFile string) string
+
+		expectedOfflineURL    string
+		expectedEndConditions map[string]int
+		expectedOutput        string
+		expectedErr           error
+		expectedErrMessage    string
+	}{
+		"defaults": {
+			dsl: testConstructorDSL,
+			script: func(dslFile string) string {
+				return "\n" + dslFile + "\n\n"
+			},
+			expectedOfflineURL: configuration.DefaultURL,
+		},
+		"all fields": {
+			dsl: testConstructorDSL,
+			script: func(dslFile string) string {
+				return "http://offline:8080\n" + dslFile + "\ncreate_account=5, transfer=10\n"
+			},
+			expectedOfflineURL:    "http://offline:8080",
+			expectedEndConditions: map[string]int{"create_account": 5, "transfer": 10},
+		},
+		"reprompt": {
+			dsl: testConstructorDSL,
+			script: func(dslFile string) string {
+				return "\n\n" + dslFile + "\ntransfer\ntransfer=ten\ntransfer=1\n"
+			},
+			expectedOfflineURL:    configuration.DefaultURL,
+			expectedEndConditions: map[string]int{"transfer": 1},
+			expectedOutput:        "a constructor DSL file is required",
+		},
+		"unknown workflow": {
+			dsl: testConstructorDSL,
+			script: func(dslFile string) string {
+				return "\n" + dslFile + "\nreturn_funds=1\n"
+			},
+			expectedErrMessage: "end condition workflow return_funds is not defined",
+		},
+		"invalid dsl": {
+			dsl: invalidConstructorDSL,
+			script: func(dslFile string) string {
+				return "\n" + dslFile + "\n\n"
+			},
+			expectedErrMessage: "line 3",
+		},
+		"missing input": {
+			dsl: testConstructorDSL,
+			script: func(dslFile string) string {
+				return "\n"
+			},
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			dslFile := writeConstructorDSL(t, dir, test.dsl)
+			var output bytes.Buffer
+			config, err := promptConstructionConfiguration(
+				context.Background(),
+				strings.NewReader(test.script(dslFile)),
+				&output,
+				dir,
+			)
+			if test.expectedErr != nil || len(test.expectedErrMessage) > 0 {
+				assert.Error(t, err)
+				assert.Nil(t, config)
+				if test.expectedErr != nil {
+					assert.True(t, errors.Is(err, test.expectedErr))
+				}
+				assert.Contains(t, err.Error(), test.expectedErrMessage)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedOfflineURL, config.OfflineURL)
+			assert.Equal(t, "workflows.ros", config.ConstructorDSLFile)
+			assert.Equal(t, test.expectedEndConditions, config.EndConditions)
+			assert.Contains(t, output.String(), "Offline URL")
+			assert.Contains(t, output.String(), test.expectedOutput)
+		})
+	}
+}
+
+func TestRunConfigurationCreateConstruction(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	dslFile := writeConstructorDSL(t, dir, testConstructorDSL)
+	configFile := path.Join(dir, "config", "config.json")
+	assert.NoError(t, os.MkdirAll(path.Dir(configFile), os.FileMode(utils.AllFilePermissions)))
+
+	Context = ctx
+	configurationCreateConstruction = true
+	defer func() { configurationCreateConstruction = false }()
+
+	configurationCreateCmd.SetIn(strings.NewReader("\n" + dslFile + "\ntransfer=2\n"))
+	configurationCreateCmd.SetOut(ioutil.Discard)
+	assert.NoError(t, runConfigurationCreateCmd(configurationCreateCmd, []string{configFile}))
+
+	// The written configuration must load (resolving the
+	// DSL file relative to the configuration file).
+	config, err := configuration.LoadConfiguration(ctx, configFile)
+	assert.NoError(t, err)
+	assert.Equal(t, dslFile, config.Construction.ConstructorDSLFile)
+	assert.Len(t, config.Construction.Workflows, 3)
+	assert.Equal(t, map[string]int{"transfer": 2}, config.Construction.EndConditions)
+}
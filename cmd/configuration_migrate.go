@@ -0,0 +1,85 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configurationMigrateCmd = &cobra.Command{
+		Use:   "configuration:migrate",
+		Short: "Upgrade a configuration file to the latest config_version",
+		Long: `Upgrade the configuration file at the first provided path to the
+latest config_version and write it to the second provided path (which may be
+the same path). Configuration files with an older (or no) config_version are
+migrated automatically when loaded, so this is only needed to stop printing
+the migrated changes on every run.
+
+Each change made is printed. Keys of the upgraded file are sorted.`,
+		RunE: runConfigurationMigrateCmd,
+		Args: cobra.ExactArgs(2),
+	}
+)
+
+func runConfigurationMigrateCmd(cmd *cobra.Command, args []string) error {
+	b, err := ioutil.ReadFile(path.Clean(args[0]))
+	if err != nil {
+		return fmt.Errorf("%w: unable to open configuration file %s", err, args[0])
+	}
+
+	migrated, migration, err := configuration.Migrate(b)
+	if err != nil {
+		return fmt.Errorf("%w: unable to migrate configuration file %s", err, args[0])
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, migrated, "", " "); err != nil {
+		return fmt.Errorf("%w: unable to format configuration file", err)
+	}
+	indented.WriteString("\n")
+
+	err = ioutil.WriteFile(
+		args[1],
+		indented.Bytes(),
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to save configuration file to %s", err, args[1])
+	}
+
+	if migration == nil {
+		color.Green(
+			"Configuration file is already at config_version %d\n",
+			configuration.CurrentConfigVersion,
+		)
+		return nil
+	}
+
+	migration.Print(args[0])
+	color.Green("Migrated configuration file written to %s\n", args[1])
+	return nil
+}
@@ -33,7 +33,14 @@ var (
 )
 
 func runConfigurationValidateCmd(cmd *cobra.Command, args []string) error {
-	_, err := configuration.LoadConfiguration(Context, args[0])
+	config, err := configuration.LoadConfiguration(
+		Context,
+		args[0],
+		configuration.WithDefaultNetwork(allowDefaultNetwork),
+	)
+	if err == nil {
+		err = configuration.AssertNetwork(config)
+	}
 	if err != nil {
 		return fmt.Errorf("%w: configuration validation failed %s", err, args[0])
 	}
@@ -16,7 +16,9 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
@@ -25,8 +27,11 @@ import (
 	"syscall"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+	"github.com/coinbase/rosetta-cli/pkg/tester"
 
-	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -34,6 +39,11 @@ import (
 const (
 	// configEnvKey is an env variable name that sets a config file location
 	configEnvKey = "ROSETTA_CONFIGURATION_FILE"
+
+	// textOutput and jsonOutput are the supported
+	// output formats of the view commands.
+	textOutput = "text"
+	jsonOutput = "json"
 )
 
 var (
@@ -43,19 +53,37 @@ var (
 		PersistentPreRunE: rootPreRun,
 	}
 
-	configurationFile string
-	cpuProfile        string
-	memProfile        string
-	blockProfile      string
+	configurationFile    string
+	configurationProfile string
+	blockchainOverride   string
+	networkOverride      string
+	cpuProfile           string
+	memProfile           string
+	blockProfile         string
+	maxBlockSize         int64
+	dataDirectory        string
+	dataDirPerNetwork    bool
 
 	// Config is the populated *configuration.Configuration from
 	// the configurationFile. If none is provided, this is set
 	// to the default settings.
 	Config *configuration.Configuration
 
+	// configurationSnapshot is the Snapshot of Config as loaded
+	// from the configurationFile (before any flags are applied).
+	// It is nil if no configurationFile is provided.
+	configurationSnapshot tester.ConfigurationSnapshot
+
 	// Context is the context to use for this invocation of the cli.
 	Context context.Context
 
+	// RunID is a UUID that uniquely identifies this invocation of the cli.
+	RunID = httpclient.NewRunID()
+
+	// clients creates the *fetcher.Fetcher for all requests
+	// to the online and offline URLs of Config.
+	clients *tester.Clients
+
 	// SignalReceived is set to true when a signal causes us to exit. This makes
 	// determining the error message to show on exit much more easy.
 	SignalReceived = false
@@ -72,6 +100,10 @@ var (
 	// logged to the console.
 	OnlyChanges bool
 
+	// viewOutput is the output format of the view commands. When
+	// it is jsonOutput, only JSON is written to stdout.
+	viewOutput string
+
 	// If non-empty, used to validate that /network/options matches the contents of the file
 	// located at this path. The intended use case is someone previously ran
 	// utils:asserter-configuration `asserterConfigurationFile`, so the validation is being done
@@ -84,13 +116,60 @@ var (
 	// which has caused production incidents in the past. This can be used for both check:data
 	// and check:construction.
 	asserterConfigurationFile string
+
+	// baselineFile is the results file of an accepted run to
+	// compare the findings of check:data or check:construction with.
+	baselineFile string
+
+	// allowOverridesSuccess allows a check:data run with
+	// endpoint overrides to report success.
+	allowOverridesSuccess bool
+
+	// estimateStorage estimates the storage required by a
+	// check:data run before syncing. If strictStorageEstimate
+	// is set, the run is refused if the estimate exceeds
+	// the available disk space.
+	estimateStorage       bool
+	strictStorageEstimate bool
+
+	// resume resumes a check:data run from
+	// the checkpoint in the data directory.
+	resume bool
+
+	// fresh discards the checkpoint and data
+	// directory of previous check:data runs.
+	fresh bool
+
+	// allowDefaultNetwork populates a missing network
+	// with the deprecated configuration.EthereumNetwork.
+	allowDefaultNetwork bool
 )
 
-// rootPreRun is executed before the root command runs and sets up cpu
-// profiling.
+// rootPreRun is executed before the root command runs, applies
+// any network overrides, and sets up cpu profiling.
 //
 // Bassed on https://golang.org/pkg/runtime/pprof/#hdr-Profiling_a_Go_program
-func rootPreRun(*cobra.Command, []string) error {
+func rootPreRun(cmd *cobra.Command, _ []string) error {
+	if err := overrideNetwork(cmd); err != nil {
+		return err
+	}
+
+	if requiresNetwork(cmd) {
+		if err := configuration.AssertNetwork(Config); err != nil {
+			return fmt.Errorf("%w: invalid configuration", err)
+		}
+	}
+
+	overrideDataDirectory(cmd)
+
+	if err := loadBaseline(cmd); err != nil {
+		return err
+	}
+
+	if err := overrideMaxBlockSize(cmd); err != nil {
+		return err
+	}
+
 	if cpuProfile != "" {
 		f, err := os.Create(cpuProfile)
 		if err != nil {
@@ -132,6 +211,103 @@ func rootPreRun(*cobra.Command, []string) error {
 	return nil
 }
 
+// overrideNetwork applies the --blockchain and --network
+// flags of cmd (if provided) to Config.
+func overrideNetwork(cmd *cobra.Command) error {
+	var blockchain, network *string
+	if flag := cmd.Flags().Lookup("blockchain"); flag != nil && flag.Changed {
+		blockchain = &blockchainOverride
+	}
+
+	if flag := cmd.Flags().Lookup("network"); flag != nil && flag.Changed {
+		network = &networkOverride
+	}
+
+	return configuration.OverrideNetwork(Config, blockchain, network)
+}
+
+// requiresNetwork returns a boolean indicating if
+// cmd uses the configured network.
+func requiresNetwork(cmd *cobra.Command) bool {
+	switch cmd {
+	case checkDataCmd,
+		checkConstructionCmd,
+		viewBlockCmd,
+		viewAccountCmd,
+		utilsAccountLineageCmd,
+		utilsAsserterConfigurationCmd,
+		utilsReplayBlockCmd:
+		return true
+	default:
+		return false
+	}
+}
+
+// overrideDataDirectory applies the --data-dir and
+// --data-dir-per-network flags of cmd (if provided) to
+// Config. It must be called after overrideNetwork so
+// that the subdirectory is derived from the network
+// that is checked.
+func overrideDataDirectory(cmd *cobra.Command) {
+	if flag := cmd.Flags().Lookup("data-dir"); flag != nil && flag.Changed {
+		Config.DataDirectory = dataDirectory
+	}
+
+	flag := cmd.Flags().Lookup("data-dir-per-network")
+	if flag == nil || !dataDirPerNetwork {
+		return
+	}
+
+	Config.DataDirectory = configuration.NetworkDataDirectory(
+		Config.DataDirectory,
+		Config.Network,
+	)
+}
+
+// overrideMaxBlockSize applies the --max-block-size
+// flag of cmd (if provided) to Config.
+func overrideMaxBlockSize(cmd *cobra.Command) error {
+	flag := cmd.Flags().Lookup("max-block-size")
+	if flag == nil || !flag.Changed {
+		return nil
+	}
+
+	if maxBlockSize < 0 {
+		return fmt.Errorf("--max-block-size %d must be >= 0", maxBlockSize)
+	}
+
+	Config.MaxBlockSizeBytes = maxBlockSize
+	return nil
+}
+
+// loadBaseline applies the --baseline flag of cmd (if provided)
+// to Config and ensures the baseline can be used before the
+// (potentially very long) check is started.
+func loadBaseline(cmd *cobra.Command) error {
+	flag := cmd.Flags().Lookup("baseline")
+	if flag == nil {
+		return nil
+	}
+
+	if flag.Changed {
+		if Config.Baseline == nil {
+			Config.Baseline = &configuration.BaselineConfiguration{}
+		}
+
+		Config.Baseline.File = baselineFile
+	}
+
+	if Config.Baseline == nil {
+		return nil
+	}
+
+	if _, err := results.LoadBaseline(Config.Baseline.File); err != nil {
+		return fmt.Errorf("%w: invalid baseline", err)
+	}
+
+	return nil
+}
+
 // rootPostRun is executed after the root command runs and performs memory
 // profiling.
 func rootPostRun() {
@@ -184,6 +360,14 @@ with the defaults), run rosetta-cli configuration:create.
 
 Any fields not populated in the configuration file will be populated with
 default values.`,
+	)
+	rootFlags.BoolVar(
+		&allowDefaultNetwork,
+		"allow-default-network",
+		false,
+		`Use Ethereum Ropsten if no network is specified in the configuration
+file (deprecated: this flag will be removed in a future release, so
+specify the network explicitly instead)`,
 	)
 	rootFlags.StringVar(
 		&cpuProfile,
@@ -203,11 +387,27 @@ default values.`,
 		"",
 		`Save the pprof block profile in the specified file`,
 	)
+	rootFlags.Int64Var(
+		&maxBlockSize,
+		"max-block-size",
+		0,
+		`Maximum size (in bytes) of the response to a /block request.
+Larger blocks fail with an error instead of being read into memory.
+This overrides max_block_size_bytes in the configuration file (0
+means no limit).`,
+	)
 	rootCmd.AddCommand(versionCmd)
 
 	// Configuration Commands
+	configurationCreateCmd.Flags().BoolVar(
+		&configurationCreateConstruction,
+		"construction",
+		false,
+		`Prompt for (and validate) a construction configuration`,
+	)
 	rootCmd.AddCommand(configurationCreateCmd)
 	rootCmd.AddCommand(configurationValidateCmd)
+	rootCmd.AddCommand(configurationMigrateCmd)
 
 	// Check commands
 	checkDataCmd.Flags().StringVar(
@@ -216,6 +416,41 @@ default values.`,
 		"", // Default to skip validation
 		`Check that /network/options matches contents of file at this path`,
 	)
+	checkDataCmd.Flags().BoolVar(
+		&allowOverridesSuccess,
+		"allow-overrides-success",
+		false,
+		`Report success even if endpoints were served from fixtures by
+data.endpoint_overrides (results are not representative)`,
+	)
+	checkDataCmd.Flags().BoolVar(
+		&estimateStorage,
+		"estimate-storage",
+		false,
+		`Estimate the storage required by the run (from a sample of blocks)
+and compare it with the available disk space before syncing`,
+	)
+	checkDataCmd.Flags().BoolVar(
+		&strictStorageEstimate,
+		"strict",
+		false,
+		`Refuse to start if the storage estimate (--estimate-storage) exceeds
+the available disk space`,
+	)
+	checkDataCmd.Flags().BoolVar(
+		&resume,
+		"resume",
+		false,
+		`Resume syncing from the checkpoint written to the data directory
+by a previous run`,
+	)
+	checkDataCmd.Flags().BoolVar(
+		&fresh,
+		"fresh",
+		false,
+		`Discard the checkpoint and data directory of previous runs
+before starting`,
+	)
 	rootCmd.AddCommand(checkDataCmd)
 	checkConstructionCmd.Flags().StringVar(
 		&asserterConfigurationFile,
@@ -224,6 +459,61 @@ default values.`,
 		`Check that /network/options matches contents of file at this path`,
 	)
 	rootCmd.AddCommand(checkConstructionCmd)
+	for _, checkCmd := range []*cobra.Command{checkDataCmd, checkConstructionCmd} {
+		checkCmd.Flags().StringVar(
+			&configurationProfile,
+			"profile",
+			"",
+			`Name of the profile in the configuration file to overlay
+on the configuration before it is validated`,
+		)
+		checkCmd.Flags().StringVar(
+			&dataDirectory,
+			"data-dir",
+			"",
+			`Directory to store logs and data in (overrides data_directory
+in the configuration file)`,
+		)
+		checkCmd.Flags().BoolVar(
+			&dataDirPerNetwork,
+			"data-dir-per-network",
+			false,
+			`Store data in a subdirectory of the data directory for the
+network (<blockchain>/<network>[/<sub_network>]), so that runs
+against different networks never share a data directory`,
+		)
+		checkCmd.Flags().StringVar(
+			&baselineFile,
+			"baseline",
+			"",
+			`Results file of an accepted run to compare findings with
+(the run fails only on new findings unless baseline.report_only
+is set in the configuration file)`,
+		)
+	}
+
+	// view:transaction is not included because
+	// it is provided the network as an argument.
+	for _, networkCmd := range []*cobra.Command{
+		checkDataCmd,
+		checkConstructionCmd,
+		viewBlockCmd,
+		viewAccountCmd,
+	} {
+		networkCmd.Flags().StringVar(
+			&blockchainOverride,
+			"blockchain",
+			"",
+			`Override the blockchain of the configured network`,
+		)
+		networkCmd.Flags().StringVar(
+			&networkOverride,
+			"network",
+			"",
+			`Override the network of the configured network (for
+example, to run the same configuration against a testnet)`,
+		)
+	}
 
 	// View Commands
 	viewBlockCmd.Flags().BoolVar(
@@ -232,19 +522,105 @@ default values.`,
 		false,
 		`Only print balance changes for accounts in the block`,
 	)
+	viewBlockCmd.Flags().Int64Var(
+		&viewBlockStart,
+		"start",
+		-1,
+		`First block index to print (instead of providing an argument)`,
+	)
+	viewBlockCmd.Flags().Int64Var(
+		&viewBlockEnd,
+		"end",
+		-1,
+		`Last block index to print (inclusive, defaults to --start)`,
+	)
+	viewAccountCmd.Flags().BoolVar(
+		&viewComputeBalanceChanges,
+		"compute-balance-changes",
+		false,
+		`Print the balance changes of the account in the block at the
+provided height instead of its balance`,
+	)
+	for _, viewCmd := range []*cobra.Command{
+		viewBlockCmd,
+		viewTransactionCmd,
+		viewAccountCmd,
+		viewNetworksCmd,
+	} {
+		viewCmd.Flags().StringVar(
+			&viewOutput,
+			"output",
+			textOutput,
+			`Output format (text or json). The json format prints the
+fetched structures as compact JSON to stdout`,
+		)
+	}
 	rootCmd.AddCommand(viewBlockCmd)
+	rootCmd.AddCommand(viewTransactionCmd)
 	rootCmd.AddCommand(viewAccountCmd)
 	rootCmd.AddCommand(viewNetworksCmd)
 
 	// Utils
 	rootCmd.AddCommand(utilsAsserterConfigurationCmd)
 	rootCmd.AddCommand(utilsTrainZstdCmd)
+	utilsAccountLineageCmd.Flags().StringVar(
+		&lineageDataDirectory,
+		"data-directory",
+		"",
+		`Data directory of the check:construction run (defaults to
+the data_directory of the configuration file)`,
+	)
+	utilsAccountLineageCmd.Flags().StringVar(
+		&lineageAddress,
+		"address",
+		"",
+		`Only print the origin and ledger of accounts with this address`,
+	)
+	rootCmd.AddCommand(utilsAccountLineageCmd)
+	utilsReplayBlockCmd.Flags().StringVar(
+		&replayDataDirectory,
+		"data-directory",
+		"",
+		`Data directory of the check:data run (defaults to
+the data_directory of the configuration file)`,
+	)
+	utilsReplayBlockCmd.Flags().Int64Var(
+		&replayIndex,
+		"index",
+		-1,
+		`Index of the block to replay`,
+	)
+	utilsReplayBlockCmd.Flags().StringVar(
+		&viewOutput,
+		"output",
+		textOutput,
+		`Output format (text or json). The json format prints the
+replay as compact JSON to stdout`,
+	)
+	rootCmd.AddCommand(utilsReplayBlockCmd)
+	for _, backupCmd := range []*cobra.Command{utilsBackupCmd, utilsRestoreCmd} {
+		backupCmd.Flags().StringVar(
+			&backupDataDirectory,
+			"data-directory",
+			"",
+			`Data directory to backup or restore (defaults to the
+data_directory of the configuration file)`,
+		)
+		rootCmd.AddCommand(backupCmd)
+	}
 }
 
 func initConfig() {
 	Context = context.Background()
 	var err error
 
+	// JSON output is written to stdout, so everything
+	// else is written to stderr (without colors).
+	if viewOutput == jsonOutput {
+		color.NoColor = true
+		color.Output = os.Stderr
+	}
+
 	// Use path provided by the environment variable if config path arg is not set.
 	// Default configuration will be used if the env var is not
 	if len(configurationFile) == 0 {
@@ -252,26 +628,65 @@ func initConfig() {
 	}
 
 	if len(configurationFile) == 0 {
+		if len(configurationProfile) > 0 {
+			log.Fatalf("cannot select profile %s without a configuration file", configurationProfile)
+		}
+
+		// The network of the default configuration is only
+		// used if allowed, as it is for configuration files.
 		Config = configuration.DefaultConfiguration()
+		if !allowDefaultNetwork {
+			Config.Network = nil
+		}
 	} else {
-		Config, err = configuration.LoadConfiguration(Context, configurationFile)
+		Config, err = configuration.LoadConfigurationProfile(
+			Context,
+			configurationFile,
+			configurationProfile,
+			configuration.WithDefaultNetwork(allowDefaultNetwork),
+		)
+		if err == nil {
+			configurationSnapshot, err = tester.NewConfigurationSnapshot(Config)
+		}
 	}
 	if err != nil {
 		log.Fatalf("%s: unable to load configuration", err.Error())
 	}
+
+	Config.RunID = RunID
+	clients = tester.NewClients(Config, RunID, metrics.NewRequestDurations())
+	color.Cyan("run id: %s (user agent: %s)", RunID, clients.UserAgent())
+
+	if generator := clients.RequestIDGenerator(); generator != nil {
+		color.Cyan(
+			"attaching request ids to %s header with prefix %s",
+			generator.Header(),
+			generator.Prefix(),
+		)
+	}
 }
 
-func ensureDataDirectoryExists() {
-	// If data directory is not specified, we use a temporary directory
-	// and delete its contents when execution is complete.
-	if len(Config.DataDirectory) == 0 {
-		tmpDir, err := utils.CreateTempDir()
-		if err != nil {
-			log.Fatalf("%s: unable to create temporary directory", err.Error())
-		}
+// useJSONOutput returns a boolean indicating if
+// the view commands should print JSON.
+func useJSONOutput() (bool, error) {
+	switch viewOutput {
+	case textOutput:
+		return false, nil
+	case jsonOutput:
+		return true, nil
+	default:
+		return false, fmt.Errorf("output format %s is not supported", viewOutput)
+	}
+}
 
-		Config.DataDirectory = tmpDir
+// printJSON writes v to w as compact JSON
+// followed by a newline.
+func printJSON(w io.Writer, v interface{}) error {
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		return fmt.Errorf("%w: unable to encode json", err)
 	}
+
+	return nil
 }
 
 // handleSignals handles OS signals so we can ensure we close database
@@ -294,6 +709,6 @@ var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print rosetta-cli version",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("v0.7.3")
+		fmt.Println("v" + httpclient.Version)
 	},
 }
@@ -0,0 +1,80 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideDataDirectory(t *testing.T) {
+	network := &types.NetworkIdentifier{Blockchain: "Bitcoin", Network: "Testnet3"}
+	var tests = map[string]struct {
+		configured string
+		args       []string
+
+		expected string
+	}{
+		"no flags": {
+			configured: "configured",
+			expected:   "configured",
+		},
+		"data dir": {
+			configured: "configured",
+			args:       []string{"--data-dir", "override"},
+			expected:   "override",
+		},
+		"per network": {
+			configured: "configured",
+			args:       []string{"--data-dir-per-network"},
+			expected:   "configured/Bitcoin/Testnet3",
+		},
+		"data dir per network": {
+			configured: "configured",
+			args:       []string{"--data-dir", "override", "--data-dir-per-network"},
+			expected:   "override/Bitcoin/Testnet3",
+		},
+		"per network without data dir": {
+			args: []string{"--data-dir-per-network"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				Config = nil
+				dataDirectory = ""
+				dataDirPerNetwork = false
+			}()
+
+			Config = configuration.DefaultConfiguration()
+			Config.DataDirectory = test.configured
+			Config.Network = network
+
+			cmd := &cobra.Command{}
+			cmd.Flags().StringVar(&dataDirectory, "data-dir", "", "")
+			cmd.Flags().BoolVar(&dataDirPerNetwork, "data-dir-per-network", false, "")
+			assert.NoError(t, cmd.ParseFlags(test.args))
+
+			overrideDataDirectory(cmd)
+			assert.Equal(t, test.expected, Config.DataDirectory)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+	"github.com/coinbase/rosetta-cli/pkg/tester"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsAccountLineageCmd = &cobra.Command{
+		Use:   "utils:account-lineage",
+		Short: "Print the origin and funds ledger of accounts used by check:construction",
+		Long: `check:construction records the workflow and job that created each
+generated account and every confirmed transaction that moved funds into or
+out of an account it controls. This command prints that lineage from the
+check:construction data directory of a run that is no longer running.
+
+The data directory is read from --data-directory (or the data_directory
+of the configuration file if not provided). Like check:construction, data
+for the configured network is read from a subdirectory of it.
+
+If --address is provided, the creation context and a chronological ledger of
+funds are printed for all accounts with that address. Otherwise, the origin of
+every account and the funds flow of the run are printed.`,
+		RunE: runAccountLineageCmd,
+		Args: cobra.NoArgs,
+	}
+
+	lineageDataDirectory string
+	lineageAddress       string
+)
+
+func runAccountLineageCmd(_ *cobra.Command, _ []string) error {
+	dataDirectory := lineageDataDirectory
+	if len(dataDirectory) == 0 {
+		dataDirectory = Config.DataDirectory
+	}
+
+	if len(dataDirectory) == 0 {
+		return errors.New("--data-directory or data_directory must be provided")
+	}
+
+	dataPath := tester.ConstructionDataPath(dataDirectory, Config.Network)
+	if _, err := os.Stat(dataPath); err != nil {
+		return fmt.Errorf(
+			"%w: unable to find check:construction data for network %s",
+			err,
+			types.PrintStruct(Config.Network),
+		)
+	}
+
+	localStore, err := database.NewBadgerDatabase(Context, dataPath)
+	if err != nil {
+		return fmt.Errorf("%w: unable to open database", err)
+	}
+	defer func() {
+		if err := localStore.Close(Context); err != nil {
+			log.Printf("%s: error closing database\n", err.Error())
+		}
+	}()
+
+	lineageStorage := processor.NewLineageStorage(localStore, nil, nil)
+	origins, err := lineageStorage.Origins(Context)
+	if err != nil {
+		return err
+	}
+
+	if len(lineageAddress) == 0 {
+		color.Cyan("Accounts:")
+		for _, origin := range origins {
+			printOrigin(origin)
+		}
+
+		fundsFlow, err := lineageStorage.FundsFlow(Context)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n")
+		results.PrintFundsFlow(fundsFlow)
+		return nil
+	}
+
+	found := false
+	for _, origin := range origins {
+		if origin.Account.Address != lineageAddress {
+			continue
+		}
+
+		found = true
+		fmt.Printf("\n")
+		color.Cyan("Account:")
+		printOrigin(origin)
+
+		entries, err := lineageStorage.Ledger(Context, origin.Account)
+		if err != nil {
+			return err
+		}
+
+		color.Cyan("Ledger:")
+		for _, entry := range entries {
+			printEntry(entry)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("no lineage found for address %s", lineageAddress)
+	}
+
+	return nil
+}
+
+func printOrigin(origin *processor.LineageOrigin) {
+	if origin.Category != processor.GeneratedFunds {
+		fmt.Println(types.PrintStruct(origin.Account), "->", origin.Category)
+		return
+	}
+
+	fmt.Println(
+		types.PrintStruct(origin.Account),
+		"->",
+		origin.Category,
+		fmt.Sprintf(`by workflow "%s" (job %s)`, origin.Workflow, origin.JobIdentifier),
+	)
+}
+
+func printEntry(entry *processor.LineageEntry) {
+	amount, err := types.AmountValue(entry.Amount)
+	if err != nil {
+		color.Red("%s: unable to parse amount", err.Error())
+		return
+	}
+
+	createdBy := "external"
+	if len(entry.Workflow) > 0 {
+		createdBy = fmt.Sprintf(`workflow "%s" (job %s)`, entry.Workflow, entry.JobIdentifier)
+	}
+
+	fmt.Printf(
+		"block %d transaction %s (%s): %s\n",
+		entry.BlockIdentifier.Index,
+		entry.TransactionIdentifier.Hash,
+		createdBy,
+		utils.PrettyAmount(amount, entry.Amount.Currency),
+	)
+
+	for _, counterparty := range entry.Counterparties {
+		fmt.Println(
+			"  counterparty",
+			types.PrintStruct(counterparty.Account),
+			fmt.Sprintf("(%s)", counterparty.Category),
+		)
+	}
+}
@@ -16,12 +16,9 @@ package cmd
 
 import (
 	"fmt"
-	"sort"
-	"time"
 
-	"github.com/coinbase/rosetta-sdk-go/asserter"
-	"github.com/coinbase/rosetta-sdk-go/fetcher"
-	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/coinbase/rosetta-cli/pkg/tester"
+
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -37,7 +34,10 @@ from the node. This allows a client to error on new types/statuses that may
 have been added in an update instead of silently erroring.
 
 To use this command, simply provide an absolute path as the argument for where
-the configuration file should be saved (in JSON).`,
+the configuration file should be saved (in JSON).
+
+check:data can save the same file on startup by setting
+data.asserter_configuration_file.`,
 		RunE: runCreateConfigurationCmd,
 		Args: cobra.ExactArgs(1),
 	}
@@ -45,12 +45,10 @@ the configuration file should be saved (in JSON).`,
 
 func runCreateConfigurationCmd(cmd *cobra.Command, args []string) error {
 	// Create a new fetcher
-	newFetcher := fetcher.New(
-		Config.OnlineURL,
-		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime)*time.Second),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout)*time.Second),
-		fetcher.WithMaxRetries(Config.MaxRetries),
-	)
+	newFetcher, err := clients.NewOnlineFetcher(Config.HTTPTimeout)
+	if err != nil {
+		return fmt.Errorf("%w: unable to initialize online fetcher", err)
+	}
 
 	// Initialize the fetcher's asserter
 	_, _, fetchErr := newFetcher.InitializeAsserter(Context, Config.Network, Config.ValidationFile)
@@ -58,27 +56,10 @@ func runCreateConfigurationCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%w: failed to initialize asserter", fetchErr.Err)
 	}
 
-	configuration, err := newFetcher.Asserter.ClientConfiguration()
-	if err != nil {
-		return fmt.Errorf("%w: unable to generate spec", err)
-	}
-
-	sortArrayFieldsOnConfiguration(configuration)
-
-	if err := utils.SerializeAndWrite(args[0], configuration); err != nil {
-		return fmt.Errorf("%w: unable to serialize asserter configuration", err)
+	if err := tester.WriteAsserterConfiguration(newFetcher.Asserter, args[0]); err != nil {
+		return err
 	}
 
 	color.Green("Configuration file saved!")
 	return nil
 }
-
-func sortArrayFieldsOnConfiguration(configuration *asserter.Configuration) {
-	sort.Strings(configuration.AllowedOperationTypes)
-	sort.Slice(configuration.AllowedOperationStatuses, func(i, j int) bool {
-		return configuration.AllowedOperationStatuses[i].Status < configuration.AllowedOperationStatuses[j].Status
-	})
-	sort.Slice(configuration.AllowedErrors, func(i, j int) bool {
-		return configuration.AllowedErrors[i].Code < configuration.AllowedErrors[j].Code
-	})
-}
\ No newline at end of file
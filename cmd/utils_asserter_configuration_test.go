@@ -16,14 +16,6 @@ package cmd
 
 import "github.com/coinbase/rosetta-sdk-go/types"
 
-import (
-	"testing"
-
-	"github.com/stretchr/testify/assert"
-
-	"github.com/coinbase/rosetta-sdk-go/asserter"
-)
-
 var (
 	basicNetwork = &types.NetworkIdentifier{
 		Blockchain: "blockchain",
@@ -36,76 +28,4 @@ var (
 	}
 
 	allowedOperationTypes = []string{"OUTPUT", "INPUT", "TRANSFER"}
-
-	allowedOperationStatuses = []*types.OperationStatus{
-		{
-			Status:     "SUCCESS",
-			Successful: true,
-		},
-		{
-			Status:     "SKIPPED",
-			Successful: true,
-		},
-	}
-
-	allowedErrors = []*types.Error{
-		{
-			Code: 4,
-			Message: "Block not found",
-			Retriable: false,
-		},
-		{
-			Code: 0,
-			Message: "Endpoint not implemented",
-			Retriable: false,
-		},
-		{
-			Code: 3,
-			Message: "Bitcoind error",
-			Retriable: false,
-		},
-	}
-
-	timestampStartIndex = int64(6)
 )
-
-func TestSortArrayFields(t *testing.T) {
-	var clientConfiguration = &asserter.Configuration{
-		NetworkIdentifier:          basicNetwork,
-		GenesisBlockIdentifier:     basicBlock,
-		AllowedOperationTypes:      allowedOperationTypes,
-		AllowedOperationStatuses:   allowedOperationStatuses,
-		AllowedErrors:              allowedErrors,
-		AllowedTimestampStartIndex: timestampStartIndex,
-	}
-	var assert = assert.New(t)
-	sortArrayFieldsOnConfiguration(clientConfiguration)
-	assert.Equal([]string{"INPUT", "OUTPUT", "TRANSFER"}, clientConfiguration.AllowedOperationTypes)
-	assert.Equal([]*types.OperationStatus{
-		{
-			Status:     "SKIPPED",
-			Successful: true,
-		},
-		{
-			Status:     "SUCCESS",
-			Successful: true,
-		},
-	}, clientConfiguration.AllowedOperationStatuses)
-	assert.Equal([]*types.Error{
-		{
-			Code: 0,
-			Message: "Endpoint not implemented",
-			Retriable: false,
-		},
-		{
-			Code: 3,
-			Message: "Bitcoind error",
-			Retriable: false,
-		},
-		{
-			Code: 4,
-			Message: "Block not found",
-			Retriable: false,
-		},
-	}, clientConfiguration.AllowedErrors)
-}
@@ -0,0 +1,104 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/pkg/tester"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsBackupCmd = &cobra.Command{
+		Use:   "utils:backup <out.tar.zst>",
+		Short: "Archive the data directory to a zstd-compressed tar file",
+		Long: `This command archives the data directory (including the stores of
+check:data and check:construction for all networks) to a zstd-compressed tar
+file, so that a synced store can be restored with utils:restore instead of
+syncing from genesis (for example, in CI).
+
+The data directory is read from --data-directory (or the data_directory of
+the configuration file if not provided). Each store is locked while it is
+archived, so the backup fails if any check using the data directory is still
+running (stores must not be written while they are backed up).`,
+		RunE: runBackupCmd,
+		Args: cobra.ExactArgs(1),
+	}
+
+	utilsRestoreCmd = &cobra.Command{
+		Use:   "utils:restore <in.tar.zst>",
+		Short: "Restore the data directory from a file created by utils:backup",
+		Long: `This command restores the data directory from a file created by
+utils:backup. The data directory is read from --data-directory (or the
+data_directory of the configuration file if not provided) and must be empty
+or not exist, so an existing store is never overwritten.
+
+The file is extracted to a temporary directory next to the data directory
+first, so a failed restore never leaves a partially restored store.`,
+		RunE: runRestoreCmd,
+		Args: cobra.ExactArgs(1),
+	}
+
+	backupDataDirectory string
+)
+
+// backupDirectory returns the data directory
+// to backup or restore.
+func backupDirectory() (string, error) {
+	dataDirectory := backupDataDirectory
+	if len(dataDirectory) == 0 {
+		dataDirectory = Config.DataDirectory
+	}
+
+	if len(dataDirectory) == 0 {
+		return "", errors.New("--data-directory or data_directory must be provided")
+	}
+
+	return dataDirectory, nil
+}
+
+func runBackupCmd(_ *cobra.Command, args []string) error {
+	dataDirectory, err := backupDirectory()
+	if err != nil {
+		return err
+	}
+
+	count, err := tester.BackupDataDirectory(dataDirectory, args[0])
+	if err != nil {
+		return fmt.Errorf("%w: unable to backup %s", err, dataDirectory)
+	}
+
+	color.Green("Archived %d files from %s to %s", count, dataDirectory, args[0])
+	return nil
+}
+
+func runRestoreCmd(_ *cobra.Command, args []string) error {
+	dataDirectory, err := backupDirectory()
+	if err != nil {
+		return err
+	}
+
+	count, err := tester.RestoreDataDirectory(args[0], dataDirectory)
+	if err != nil {
+		return fmt.Errorf("%w: unable to restore %s", err, dataDirectory)
+	}
+
+	color.Green("Restored %d files from %s to %s", count, args[0], dataDirectory)
+	return nil
+}
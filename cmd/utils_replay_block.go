@@ -0,0 +1,167 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/coinbase/rosetta-cli/pkg/tester"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsReplayBlockCmd = &cobra.Command{
+		Use:   "utils:replay-block",
+		Short: "Replay the balance changes of a single block from check:data storage",
+		Long: `This command re-applies the balance changes of the block at --index
+to the balances stored by check:data at its parent block, printing the
+effect of each operation, and compares the result with the balances stored
+at --index. Any divergence is flagged.
+
+The check:data data directory is read from --data-directory (or the
+data_directory of the configuration file if not provided) and is opened
+read-only, so this can be run against a completed or crashed run (but not
+one that is still running). The block is loaded from storage or, if it is
+not stored, fetched from the node. The node is also used to determine which
+operation statuses are successful.
+
+Exempt accounts and transaction overrides are read from the data
+configuration, like check:data.
+
+Use --output json to print the replay as JSON.`,
+		RunE: runReplayBlockCmd,
+		Args: cobra.NoArgs,
+	}
+
+	replayDataDirectory string
+	replayIndex         int64
+)
+
+func runReplayBlockCmd(_ *cobra.Command, _ []string) error {
+	useJSON, err := useJSONOutput()
+	if err != nil {
+		return err
+	}
+
+	if replayIndex < 0 {
+		return errors.New("--index must be provided")
+	}
+
+	dataDirectory := replayDataDirectory
+	if len(dataDirectory) == 0 {
+		dataDirectory = Config.DataDirectory
+	}
+
+	if len(dataDirectory) == 0 {
+		return errors.New("--data-directory or data_directory must be provided")
+	}
+
+	dataPath := tester.DataPath(dataDirectory, Config.Network)
+	if _, err := os.Stat(dataPath); err != nil {
+		return fmt.Errorf(
+			"%w: unable to find check:data data for network %s",
+			err,
+			types.PrintStruct(Config.Network),
+		)
+	}
+
+	badgerOptions := database.DefaultBadgerOptions(dataPath)
+	if Config.MemoryLimitDisabled {
+		badgerOptions = database.PerformanceBadgerOptions(dataPath)
+	}
+	badgerOptions.ReadOnly = true
+
+	opts := []database.BadgerOption{database.WithCustomSettings(badgerOptions)}
+	if Config.CompressionDisabled {
+		opts = append(opts, database.WithoutCompression())
+	}
+
+	localStore, err := database.NewBadgerDatabase(Context, dataPath, opts...)
+	if err != nil {
+		return fmt.Errorf("%w: unable to open database read-only", err)
+	}
+	defer func() {
+		if err := localStore.Close(Context); err != nil {
+			log.Printf("%s: error closing database\n", err.Error())
+		}
+	}()
+
+	// Currencies exempt from reconciliation are still
+	// tracked, so only exempt accounts are replayed.
+	exemptAccounts, _, err := tester.ExemptAccounts(Config.Data)
+	if err != nil {
+		return fmt.Errorf("%w: unable to load exempt accounts", err)
+	}
+
+	transactionOverrides := []*tester.TransactionOverride{}
+	if len(Config.Data.TransactionOverrides) > 0 {
+		transactionOverrides, err = tester.LoadTransactionOverrides(Config.Data.TransactionOverrides)
+		if err != nil {
+			return fmt.Errorf("%w: unable to load transaction overrides", err)
+		}
+	}
+
+	newFetcher, err := clients.NewOnlineFetcher(Config.Data.HTTPTimeout)
+	if err != nil {
+		return fmt.Errorf("%w: unable to initialize online fetcher", err)
+	}
+
+	_, _, fetchErr := newFetcher.InitializeAsserter(Context, Config.Network, Config.ValidationFile)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err)
+	}
+
+	blockIdentifier := &types.PartialBlockIdentifier{Index: &replayIndex}
+	blockStorage := modules.NewBlockStorage(localStore, 1)
+	block, err := blockStorage.GetBlock(Context, blockIdentifier)
+	fetched := errors.Is(err, storageErrs.ErrBlockNotFound)
+	switch {
+	case fetched:
+		block, fetchErr = newFetcher.BlockRetry(Context, Config.Network, blockIdentifier)
+		if fetchErr != nil {
+			return fmt.Errorf("%w: unable to fetch block %d", fetchErr.Err, replayIndex)
+		}
+	case err != nil:
+		return fmt.Errorf("%w: unable to get block %d from storage", err, replayIndex)
+	}
+
+	result, err := tester.ReplayBlock(
+		Context,
+		block,
+		fetched,
+		newFetcher.Asserter,
+		modules.NewBalanceStorage(localStore),
+		exemptAccounts,
+		transactionOverrides,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to replay block %d", err, replayIndex)
+	}
+
+	if useJSON {
+		return printJSON(os.Stdout, result)
+	}
+
+	result.Print()
+	return nil
+}
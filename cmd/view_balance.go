@@ -15,16 +15,21 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"strconv"
-	"time"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
@@ -39,13 +44,137 @@ any account by providing a JSON representation of a types.AccountIdentifier
 
 For example, you could run view:balance '{"address":"interesting address"}' 1000
 to lookup the balance of an interesting address at block 1000. Allowing the
-address to specified as JSON allows for querying by SubAccountIdentifier.`,
+address to specified as JSON allows for querying by SubAccountIdentifier.
+
+Use --output json to print the balance as a JSON AccountBalanceResponse.
+
+When investigating a reconciliation failure, provide --compute-balance-changes
+(and a height) to print the balance changes of the account in the block at that
+height instead of its balance. Balance changes are computed the same way they
+are computed in check:data (using any balance exemptions returned in
+/network/options).`,
 		RunE: runViewBalanceCmd,
 		Args: cobra.MinimumNArgs(1),
 	}
+
+	// viewComputeBalanceChanges determines if view:balance
+	// prints the balance changes of an account in a block
+	// instead of its balance.
+	viewComputeBalanceChanges bool
 )
 
+// balanceFetcher is the subset of *fetcher.Fetcher
+// used to fetch balances.
+type balanceFetcher interface {
+	AccountBalanceRetry(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		account *types.AccountIdentifier,
+		block *types.PartialBlockIdentifier,
+		currencies []*types.Currency,
+	) (*types.BlockIdentifier, []*types.Amount, map[string]interface{}, *fetcher.Error)
+}
+
+// viewBalance fetches the balance of account and prints it. If
+// useJSON is true, the balance is written to w as JSON.
+func viewBalance(
+	ctx context.Context,
+	w io.Writer,
+	f balanceFetcher,
+	network *types.NetworkIdentifier,
+	account *types.AccountIdentifier,
+	lookupBlock *types.PartialBlockIdentifier,
+	useJSON bool,
+) error {
+	block, amounts, metadata, fetchErr := f.AccountBalanceRetry(
+		ctx,
+		network,
+		account,
+		lookupBlock,
+		nil,
+	)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to fetch account %+v", fetchErr.Err, account)
+	}
+
+	if useJSON {
+		return printJSON(w, &types.AccountBalanceResponse{
+			BlockIdentifier: block,
+			Balances:        amounts,
+			Metadata:        metadata,
+		})
+	}
+
+	log.Printf("Amounts: %s\n", types.PrettyPrintStruct(amounts))
+	log.Printf("Metadata: %s\n", types.PrettyPrintStruct(metadata))
+	log.Printf("Balance Fetched At: %s\n", types.PrettyPrintStruct(block))
+
+	return nil
+}
+
+// viewBalanceChanges fetches the block at index and prints the balance
+// changes of account in the block. If useJSON is true, the balance changes
+// are written to w as JSON.
+func viewBalanceChanges(
+	ctx context.Context,
+	w io.Writer,
+	f blockFetcher,
+	blockAsserter *asserter.Asserter,
+	balanceExemptions []*types.BalanceExemption,
+	network *types.NetworkIdentifier,
+	account *types.AccountIdentifier,
+	index int64,
+	useJSON bool,
+) error {
+	block, fetchErr := f.BlockRetry(
+		ctx,
+		network,
+		&types.PartialBlockIdentifier{
+			Index: &index,
+		},
+	)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to fetch block %d", fetchErr.Err, index)
+	}
+
+	// It's valid for a block to be omitted without triggering an error
+	if block == nil {
+		return fmt.Errorf("block %d not found, it might be omitted", index)
+	}
+
+	p := parser.New(blockAsserter, nil, balanceExemptions)
+	balanceChanges, err := p.BalanceChanges(ctx, block, false)
+	if err != nil {
+		return fmt.Errorf("%w: unable to calculate balance changes", err)
+	}
+
+	accountChanges := []*parser.BalanceChange{}
+	for _, balanceChange := range balanceChanges {
+		if types.Hash(balanceChange.Account) == types.Hash(account) {
+			accountChanges = append(accountChanges, balanceChange)
+		}
+	}
+
+	if useJSON {
+		return printJSON(w, accountChanges)
+	}
+
+	color.Cyan(
+		"Balance Changes of %s in block %s:",
+		types.PrintStruct(account),
+		types.PrintStruct(block.BlockIdentifier),
+	)
+	fmt.Fprintln(w, types.PrettyPrintStruct(accountChanges))
+
+	return nil
+}
+
 func runViewBalanceCmd(cmd *cobra.Command, args []string) error {
+	useJSON, err := useJSONOutput()
+	if err != nil {
+		return err
+	}
+
 	account := &types.AccountIdentifier{}
 	if err := json.Unmarshal([]byte(args[0]), account); err != nil {
 		return fmt.Errorf("%w: unable to unmarshal account %s", err, args[0])
@@ -56,28 +185,18 @@ func runViewBalanceCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create a new fetcher
-	fetcherOpts := []fetcher.Option{
-		fetcher.WithMaxConnections(Config.MaxOnlineConnections),
-		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime) * time.Second),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout) * time.Second),
-		fetcher.WithMaxRetries(Config.MaxRetries),
-	}
-	if Config.ForceRetry {
-		fetcherOpts = append(fetcherOpts, fetcher.WithForceRetry())
+	newFetcher, err := clients.NewOnlineFetcher(Config.HTTPTimeout)
+	if err != nil {
+		return fmt.Errorf("%w: unable to initialize online fetcher", err)
 	}
 
-	newFetcher := fetcher.New(
-		Config.OnlineURL,
-		fetcherOpts...,
-	)
-
 	// Initialize the fetcher's asserter
 	_, _, fetchErr := newFetcher.InitializeAsserter(Context, Config.Network, Config.ValidationFile)
 	if fetchErr != nil {
 		return fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err)
 	}
 
-	_, err := utils.CheckNetworkSupported(Context, Config.Network, newFetcher)
+	_, err = utils.CheckNetworkSupported(Context, Config.Network, newFetcher)
 	if err != nil {
 		return fmt.Errorf("%w: unable to confirm network is supported", err)
 	}
@@ -92,20 +211,36 @@ func runViewBalanceCmd(cmd *cobra.Command, args []string) error {
 		lookupBlock = &types.PartialBlockIdentifier{Index: &index}
 	}
 
-	block, amounts, metadata, fetchErr := newFetcher.AccountBalanceRetry(
+	if viewComputeBalanceChanges {
+		if lookupBlock == nil {
+			return errors.New("must provide a block index to compute balance changes")
+		}
+
+		networkOptions, fetchErr := newFetcher.NetworkOptionsRetry(Context, Config.Network, nil)
+		if fetchErr != nil {
+			return fmt.Errorf("%w: unable to get network options", fetchErr.Err)
+		}
+
+		return viewBalanceChanges(
+			Context,
+			os.Stdout,
+			newFetcher,
+			newFetcher.Asserter,
+			networkOptions.Allow.BalanceExemptions,
+			Config.Network,
+			account,
+			*lookupBlock.Index,
+			useJSON,
+		)
+	}
+
+	return viewBalance(
 		Context,
+		os.Stdout,
+		newFetcher,
 		Config.Network,
 		account,
 		lookupBlock,
-		nil,
+		useJSON,
 	)
-	if fetchErr != nil {
-		return fmt.Errorf("%w: unable to fetch account %+v", fetchErr.Err, account)
-	}
-
-	log.Printf("Amounts: %s\n", types.PrettyPrintStruct(amounts))
-	log.Printf("Metadata: %s\n", types.PrettyPrintStruct(metadata))
-	log.Printf("Balance Fetched At: %s\n", types.PrettyPrintStruct(block))
-
-	return nil
 }
@@ -0,0 +1,231 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBalanceFetcher struct {
+	err *fetcher.Error
+}
+
+func (m *mockBalanceFetcher) AccountBalanceRetry(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	account *types.AccountIdentifier,
+	block *types.PartialBlockIdentifier,
+	currencies []*types.Currency,
+) (*types.BlockIdentifier, []*types.Amount, map[string]interface{}, *fetcher.Error) {
+	if m.err != nil {
+		return nil, nil, nil, m.err
+	}
+
+	amounts := []*types.Amount{
+		{
+			Value:    "100",
+			Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+		},
+	}
+
+	return basicBlock, amounts, map[string]interface{}{"sequence": "1"}, nil
+}
+
+func TestViewBalanceJSON(t *testing.T) {
+	var tests = map[string]struct {
+		fetcher *mockBalanceFetcher
+
+		expected    *types.AccountBalanceResponse
+		expectedErr bool
+	}{
+		"balance": {
+			fetcher: &mockBalanceFetcher{},
+			expected: &types.AccountBalanceResponse{
+				BlockIdentifier: basicBlock,
+				Balances: []*types.Amount{
+					{
+						Value:    "100",
+						Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+					},
+				},
+				Metadata: map[string]interface{}{
+					"sequence": "1",
+				},
+			},
+		},
+		"fetch error": {
+			fetcher: &mockBalanceFetcher{
+				err: &fetcher.Error{Err: errors.New("unavailable")},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := viewBalance(
+				context.Background(),
+				&buf,
+				test.fetcher,
+				basicNetwork,
+				&types.AccountIdentifier{Address: "addr"},
+				nil,
+				true,
+			)
+			if test.expectedErr {
+				assert.Error(t, err)
+				assert.Empty(t, buf.String())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, json.Valid(buf.Bytes()))
+			assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+
+			var response types.AccountBalanceResponse
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &response))
+			assert.Equal(t, test.expected, &response)
+		})
+	}
+}
+
+func TestViewBalanceChanges(t *testing.T) {
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	account := &types.AccountIdentifier{Address: "addr1"}
+	other := &types.AccountIdentifier{Address: "addr2"}
+	block := &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: 9, Hash: "block 9"},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx 1"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "Transfer",
+						Status:              types.String("SUCCESS"),
+						Account:             account,
+						Amount:              &types.Amount{Value: "-100", Currency: btc},
+					},
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 1},
+						Type:                "Transfer",
+						Status:              types.String("SUCCESS"),
+						Account:             other,
+						Amount:              &types.Amount{Value: "100", Currency: btc},
+					},
+				},
+			},
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx 2"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "Transfer",
+						Status:              types.String("SUCCESS"),
+						Account:             account,
+						Amount:              &types.Amount{Value: "30", Currency: btc},
+					},
+				},
+			},
+		},
+	}
+
+	blockAsserter, err := asserter.NewClientWithOptions(
+		basicNetwork,
+		&types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		[]string{"Transfer"},
+		[]*types.OperationStatus{{Status: "SUCCESS", Successful: true}},
+		[]*types.Error{},
+		nil,
+		&asserter.Validations{},
+	)
+	assert.NoError(t, err)
+
+	var tests = map[string]struct {
+		block    *types.Block
+		fetchErr error
+		account  *types.AccountIdentifier
+
+		expected    []*parser.BalanceChange
+		expectedErr string
+	}{
+		"account changes": {
+			block:   block,
+			account: account,
+			expected: []*parser.BalanceChange{
+				{
+					Account:    account,
+					Currency:   btc,
+					Block:      block.BlockIdentifier,
+					Difference: "-70",
+				},
+			},
+		},
+		"no changes": {
+			block:    block,
+			account:  &types.AccountIdentifier{Address: "addr3"},
+			expected: []*parser.BalanceChange{},
+		},
+		"omitted block": {
+			account:     account,
+			expectedErr: "might be omitted",
+		},
+		"fetch error": {
+			fetchErr:    errors.New("block unavailable"),
+			account:     account,
+			expectedErr: "block unavailable",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := viewBalanceChanges(
+				context.Background(),
+				&buf,
+				&mockTransactionFetcher{block: test.block, err: test.fetchErr},
+				blockAsserter,
+				nil,
+				basicNetwork,
+				test.account,
+				10,
+				true,
+			)
+			if len(test.expectedErr) > 0 {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			var changes []*parser.BalanceChange
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &changes))
+			assert.Equal(t, test.expected, changes)
+		})
+	}
+}
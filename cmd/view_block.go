@@ -15,23 +15,29 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
 	"strconv"
-	"time"
+	"strings"
 
+	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/parser"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 var (
 	viewBlockCmd = &cobra.Command{
-		Use:   "view:block",
-		Short: "View a block",
+		Use:   "view:block [index | start:end]",
+		Short: "View a block or a range of blocks",
 		Long: `While debugging a Data API implementation, it can be very
 useful to inspect block contents. This command allows you to fetch any
 block by index to inspect its contents. It uses the
@@ -39,13 +45,36 @@ fetcher (https://github.com/coinbase/rosetta-sdk-go/tree/master/fetcher) package
 to automatically get all transactions in the block and assert the format
 of the block is correct before printing.
 
+To inspect a range of blocks, provide an inclusive range of indexes
+(i.e. 100:110) or the --start and --end flags. Blocks in the range are
+fetched concurrently (up to max_sync_concurrency) but are always printed
+in order.
+
+Use --output json to print each block as JSON (one block per line)
+instead of printing balance changes.
+
 If this command errors, it is likely because the block you are trying to
 fetch is formatted incorrectly.`,
 		RunE: runViewBlockCmd,
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MaximumNArgs(1),
 	}
+
+	// viewBlockStart and viewBlockEnd are the inclusive
+	// range of blocks to print when no argument is provided.
+	viewBlockStart int64
+	viewBlockEnd   int64
 )
 
+// blockFetcher is the subset of *fetcher.Fetcher
+// used to fetch blocks.
+type blockFetcher interface {
+	BlockRetry(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		blockIdentifier *types.PartialBlockIdentifier,
+	) (*types.Block, *fetcher.Error)
+}
+
 func printChanges(balanceChanges []*parser.BalanceChange) error {
 	for _, balanceChange := range balanceChanges {
 		parsedDiff, err := types.BigInt(balanceChange.Difference)
@@ -67,67 +96,150 @@ func printChanges(balanceChanges []*parser.BalanceChange) error {
 	return nil
 }
 
-func runViewBlockCmd(_ *cobra.Command, args []string) error {
-	index, err := strconv.ParseInt(args[0], 10, 64)
-	if err != nil {
-		return fmt.Errorf("%w: unable to parse index %s", err, args[0])
+// parseBlockRange returns the inclusive range of blocks to
+// view from the provided argument (index or start:end) or,
+// if there is no argument, from the --start and --end flags.
+func parseBlockRange(args []string, start int64, end int64) (int64, int64, error) {
+	if len(args) == 0 {
+		if start < 0 {
+			return -1, -1, errors.New("must provide a block index, a range, or --start")
+		}
+
+		if end < 0 {
+			end = start
+		}
+	} else {
+		if start >= 0 || end >= 0 {
+			return -1, -1, errors.New("cannot provide both an argument and --start/--end")
+		}
+
+		var err error
+		bounds := strings.SplitN(args[0], ":", 2)
+		start, err = strconv.ParseInt(bounds[0], 10, 64)
+		if err != nil {
+			return -1, -1, fmt.Errorf("%w: unable to parse index %s", err, bounds[0])
+		}
+
+		end = start
+		if len(bounds) == 2 {
+			end, err = strconv.ParseInt(bounds[1], 10, 64)
+			if err != nil {
+				return -1, -1, fmt.Errorf("%w: unable to parse index %s", err, bounds[1])
+			}
+		}
 	}
 
-	// Create a new fetcher
-	fetcherOpts := []fetcher.Option{
-		fetcher.WithMaxConnections(Config.MaxOnlineConnections),
-		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime) * time.Second),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout) * time.Second),
-		fetcher.WithMaxRetries(Config.MaxRetries),
+	if start < 0 {
+		return -1, -1, fmt.Errorf("start index %d cannot be negative", start)
 	}
-	if Config.ForceRetry {
-		fetcherOpts = append(fetcherOpts, fetcher.WithForceRetry())
+
+	if end < start {
+		return -1, -1, fmt.Errorf("end index %d is less than start index %d", end, start)
 	}
 
-	newFetcher := fetcher.New(
-		Config.OnlineURL,
-		fetcherOpts...,
-	)
+	return start, end, nil
+}
 
-	// Initialize the fetcher's asserter
-	//
-	// Behind the scenes this makes a call to get the
-	// network status and uses the response to inform
-	// the asserter what are valid responses.
-	_, _, fetchErr := newFetcher.InitializeAsserter(Context, Config.Network, Config.ValidationFile)
-	if fetchErr != nil {
-		return fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err)
+// fetchBlockRange fetches all blocks in [start, end] with up to
+// concurrency requests in flight and invokes handler on each block
+// in order. The block provided to handler is nil if it was omitted.
+func fetchBlockRange(
+	ctx context.Context,
+	f blockFetcher,
+	network *types.NetworkIdentifier,
+	start int64,
+	end int64,
+	concurrency int64,
+	handler func(index int64, block *types.Block) error,
+) error {
+	if concurrency < 1 {
+		concurrency = 1
 	}
 
-	_, err = utils.CheckNetworkSupported(Context, Config.Network, newFetcher)
-	if err != nil {
-		return fmt.Errorf("%w: unable to confirm network is supported", err)
-	}
+	g, ctx := errgroup.WithContext(ctx)
 
-	// Fetch the specified block with retries (automatically
-	// asserted for correctness)
-	//
-	// On another note, notice that fetcher.BlockRetry
-	// automatically fetches all transactions that are
-	// returned in BlockResponse.OtherTransactions. If you use
-	// the client directly, you will need to implement a mechanism
-	// to fully populate the block by fetching all these
-	// transactions.
-	block, fetchErr := newFetcher.BlockRetry(
-		Context,
-		Config.Network,
-		&types.PartialBlockIdentifier{
-			Index: &index,
-		},
-	)
-	if fetchErr != nil {
-		return fmt.Errorf("%w: unable to fetch block", fetchErr.Err)
+	if blocks := end - start + 1; concurrency > blocks {
+		concurrency = blocks
 	}
-	// It's valid for a block to be omitted without triggering an error
-	if block == nil {
-		return errors.New("block not found, it might be omitted")
+
+	// Each slot in the semaphore is held from the time a block is
+	// requested until it is handled so that we never buffer more
+	// than concurrency blocks while waiting on a slow request.
+	// Because of this, a block can't be requested until the block
+	// concurrency indexes before it is handled, so results can
+	// be delivered using a ring of concurrency channels.
+	semaphore := make(chan struct{}, concurrency)
+	results := make([]chan *types.Block, concurrency)
+	for i := range results {
+		results[i] = make(chan *types.Block, 1)
 	}
 
+	g.Go(func() error {
+		for index := start; index <= end; index++ {
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			index := index
+			g.Go(func() error {
+				// Fetch the specified block with retries (automatically
+				// asserted for correctness)
+				//
+				// On another note, notice that fetcher.BlockRetry
+				// automatically fetches all transactions that are
+				// returned in BlockResponse.OtherTransactions. If you use
+				// the client directly, you will need to implement a mechanism
+				// to fully populate the block by fetching all these
+				// transactions.
+				block, fetchErr := f.BlockRetry(
+					ctx,
+					network,
+					&types.PartialBlockIdentifier{
+						Index: &index,
+					},
+				)
+				if fetchErr != nil {
+					return fmt.Errorf("%w: unable to fetch block %d", fetchErr.Err, index)
+				}
+
+				results[(index-start)%concurrency] <- block
+				return nil
+			})
+		}
+
+		return nil
+	})
+
+	g.Go(func() error {
+		for index := start; index <= end; index++ {
+			var block *types.Block
+			select {
+			case block = <-results[(index-start)%concurrency]:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if err := handler(index, block); err != nil {
+				return err
+			}
+
+			<-semaphore
+		}
+
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// printBlock prints the contents and balance changes of a block.
+func printBlock(
+	ctx context.Context,
+	blockAsserter *asserter.Asserter,
+	block *types.Block,
+) error {
 	fmt.Printf("\n")
 	if !OnlyChanges {
 		color.Cyan("Current Block:")
@@ -137,8 +249,8 @@ func runViewBlockCmd(_ *cobra.Command, args []string) error {
 	// Print out all balance changes in a given block. This does NOT exempt
 	// any operations/accounts from parsing.
 	color.Cyan("Balance Changes:")
-	p := parser.New(newFetcher.Asserter, func(*types.Operation) bool { return false }, nil)
-	balanceChanges, err := p.BalanceChanges(Context, block, false)
+	p := parser.New(blockAsserter, func(*types.Operation) bool { return false }, nil)
+	balanceChanges, err := p.BalanceChanges(ctx, block, false)
 	if err != nil {
 		return fmt.Errorf("%w: unable to calculate balance changes", err)
 	}
@@ -156,7 +268,7 @@ func runViewBlockCmd(_ *cobra.Command, args []string) error {
 	// TODO: modify parser to allow for calculating balance
 	// changes for a single transaction.
 	for _, tx := range block.Transactions {
-		balanceChanges, err := p.BalanceChanges(Context, &types.Block{
+		balanceChanges, err := p.BalanceChanges(ctx, &types.Block{
 			Transactions: []*types.Transaction{
 				tx,
 			},
@@ -187,3 +299,80 @@ func runViewBlockCmd(_ *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// blockHandler returns a handler for fetchBlockRange that prints
+// each block. If useJSON is true, blocks are written to w as JSON
+// (one per line) instead of being printed with balance changes.
+func blockHandler(
+	ctx context.Context,
+	w io.Writer,
+	blockAsserter *asserter.Asserter,
+	single bool,
+	useJSON bool,
+) func(int64, *types.Block) error {
+	return func(index int64, block *types.Block) error {
+		// It's valid for a block to be omitted without triggering an error
+		if block == nil {
+			if single {
+				return errors.New("block not found, it might be omitted")
+			}
+
+			if useJSON {
+				log.Printf("block %d not found, it might be omitted\n", index)
+			} else {
+				color.Yellow("\nBlock %d not found, it might be omitted", index)
+			}
+
+			return nil
+		}
+
+		if useJSON {
+			return printJSON(w, block)
+		}
+
+		return printBlock(ctx, blockAsserter, block)
+	}
+}
+
+func runViewBlockCmd(_ *cobra.Command, args []string) error {
+	useJSON, err := useJSONOutput()
+	if err != nil {
+		return err
+	}
+
+	start, end, err := parseBlockRange(args, viewBlockStart, viewBlockEnd)
+	if err != nil {
+		return err
+	}
+
+	// Create a new fetcher
+	newFetcher, err := clients.NewOnlineFetcher(Config.HTTPTimeout)
+	if err != nil {
+		return fmt.Errorf("%w: unable to initialize online fetcher", err)
+	}
+
+	// Initialize the fetcher's asserter
+	//
+	// Behind the scenes this makes a call to get the
+	// network status and uses the response to inform
+	// the asserter what are valid responses.
+	_, _, fetchErr := newFetcher.InitializeAsserter(Context, Config.Network, Config.ValidationFile)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err)
+	}
+
+	_, err = utils.CheckNetworkSupported(Context, Config.Network, newFetcher)
+	if err != nil {
+		return fmt.Errorf("%w: unable to confirm network is supported", err)
+	}
+
+	return fetchBlockRange(
+		Context,
+		newFetcher,
+		Config.Network,
+		start,
+		end,
+		Config.MaxSyncConcurrency,
+		blockHandler(Context, os.Stdout, newFetcher.Asserter, start == end, useJSON),
+	)
+}
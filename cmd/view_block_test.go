@@ -0,0 +1,310 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockBlockFetcher returns blocks with a delay that decreases
+// with the index so that later blocks are fetched first.
+type mockBlockFetcher struct {
+	end     int64
+	omitted map[int64]bool
+	failed  map[int64]bool
+
+	mutex    sync.Mutex
+	inflight int
+	peak     int
+}
+
+func (m *mockBlockFetcher) BlockRetry(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, *fetcher.Error) {
+	m.mutex.Lock()
+	m.inflight++
+	if m.inflight > m.peak {
+		m.peak = m.inflight
+	}
+	m.mutex.Unlock()
+
+	defer func() {
+		m.mutex.Lock()
+		m.inflight--
+		m.mutex.Unlock()
+	}()
+
+	index := *blockIdentifier.Index
+	time.Sleep(time.Duration(m.end-index+1) * time.Millisecond)
+
+	if m.failed[index] {
+		return nil, &fetcher.Error{Err: errors.New("block unavailable")}
+	}
+
+	if m.omitted[index] {
+		return nil, nil
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		},
+	}, nil
+}
+
+func TestParseBlockRange(t *testing.T) {
+	var tests = map[string]struct {
+		args  []string
+		start int64
+		end   int64
+
+		expectedStart int64
+		expectedEnd   int64
+		expectedErr   bool
+	}{
+		"single index": {
+			args:          []string{"10"},
+			start:         -1,
+			end:           -1,
+			expectedStart: 10,
+			expectedEnd:   10,
+		},
+		"range argument": {
+			args:          []string{"10:15"},
+			start:         -1,
+			end:           -1,
+			expectedStart: 10,
+			expectedEnd:   15,
+		},
+		"flags": {
+			start:         3,
+			end:           7,
+			expectedStart: 3,
+			expectedEnd:   7,
+		},
+		"start flag only": {
+			start:         3,
+			end:           -1,
+			expectedStart: 3,
+			expectedEnd:   3,
+		},
+		"no index": {
+			start:       -1,
+			end:         -1,
+			expectedErr: true,
+		},
+		"argument and flags": {
+			args:        []string{"10"},
+			start:       3,
+			end:         -1,
+			expectedErr: true,
+		},
+		"invalid index": {
+			args:        []string{"10:hello"},
+			start:       -1,
+			end:         -1,
+			expectedErr: true,
+		},
+		"end before start": {
+			args:        []string{"15:10"},
+			start:       -1,
+			end:         -1,
+			expectedErr: true,
+		},
+		"negative index": {
+			args:        []string{"-1"},
+			start:       -1,
+			end:         -1,
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end, err := parseBlockRange(test.args, test.start, test.end)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedStart, start)
+			assert.Equal(t, test.expectedEnd, end)
+		})
+	}
+}
+
+func TestFetchBlockRange(t *testing.T) {
+	var tests = map[string]struct {
+		start       int64
+		end         int64
+		concurrency int64
+		omitted     map[int64]bool
+		failed      map[int64]bool
+
+		expectedIndexes []int64
+		expectedErr     bool
+	}{
+		"single block": {
+			start:           5,
+			end:             5,
+			concurrency:     4,
+			expectedIndexes: []int64{5},
+		},
+		"range": {
+			start:           5,
+			end:             14,
+			concurrency:     4,
+			expectedIndexes: []int64{5, 6, 7, 8, 9, 10, 11, 12, 13, 14},
+		},
+		"sequential": {
+			start:           0,
+			end:             3,
+			concurrency:     1,
+			expectedIndexes: []int64{0, 1, 2, 3},
+		},
+		"omitted block": {
+			start:           0,
+			end:             3,
+			concurrency:     2,
+			omitted:         map[int64]bool{2: true},
+			expectedIndexes: []int64{0, 1, 2, 3},
+		},
+		"failed block": {
+			start:       0,
+			end:         20,
+			concurrency: 2,
+			failed:      map[int64]bool{5: true},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &mockBlockFetcher{
+				end:     test.end,
+				omitted: test.omitted,
+				failed:  test.failed,
+			}
+
+			indexes := []int64{}
+			err := fetchBlockRange(
+				context.Background(),
+				f,
+				basicNetwork,
+				test.start,
+				test.end,
+				test.concurrency,
+				func(index int64, block *types.Block) error {
+					indexes = append(indexes, index)
+					if test.omitted[index] {
+						assert.Nil(t, block)
+					} else {
+						assert.Equal(t, index, block.BlockIdentifier.Index)
+					}
+
+					return nil
+				},
+			)
+			assert.LessOrEqual(t, f.peak, int(test.concurrency))
+			if test.expectedErr {
+				assert.Error(t, err)
+				assert.LessOrEqual(t, len(indexes), 5)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedIndexes, indexes)
+		})
+	}
+}
+
+func TestFetchBlockRangeLarge(t *testing.T) {
+	f := &mockBlockFetcher{}
+
+	// Memory used does not depend on the size
+	// of the range.
+	handled := int64(0)
+	stop := errors.New("stop")
+	err := fetchBlockRange(
+		context.Background(),
+		f,
+		basicNetwork,
+		0,
+		100000000000,
+		4,
+		func(index int64, block *types.Block) error {
+			assert.Equal(t, handled, index)
+			handled++
+			if handled == 100 {
+				return stop
+			}
+
+			return nil
+		},
+	)
+	assert.True(t, errors.Is(err, stop))
+	assert.Equal(t, int64(100), handled)
+}
+
+func TestBlockHandlerJSON(t *testing.T) {
+	f := &mockBlockFetcher{
+		end:     7,
+		omitted: map[int64]bool{6: true},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, fetchBlockRange(
+		context.Background(),
+		f,
+		basicNetwork,
+		5,
+		7,
+		2,
+		blockHandler(context.Background(), &buf, nil, false, true),
+	))
+
+	// Omitted blocks are skipped and each block is
+	// printed as compact JSON on its own line.
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	for i, index := range []int64{5, 7} {
+		assert.True(t, json.Valid([]byte(lines[i])))
+
+		var block types.Block
+		assert.NoError(t, json.Unmarshal([]byte(lines[i]), &block))
+		assert.Equal(t, index, block.BlockIdentifier.Index)
+	}
+
+	// An omitted block is still an error when
+	// viewing a single block.
+	err := blockHandler(context.Background(), &buf, nil, true, true)(6, nil)
+	assert.Error(t, err)
+}
@@ -15,10 +15,12 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
-	"time"
+	"os"
 
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/types"
@@ -35,29 +37,48 @@ useful to view network(s) status. This command fetches the network
 status from all available networks and prints it to the terminal.
 
 If this command errors, it is likely because the /network/* endpoints are
-not formatted correctly.`,
+not formatted correctly.
+
+Use --output json to print all networks as a JSON array.`,
 		RunE: runViewNetworksCmd,
 	}
 )
 
-func runViewNetworksCmd(cmd *cobra.Command, args []string) error {
-	fetcherOpts := []fetcher.Option{
-		fetcher.WithMaxConnections(Config.MaxOnlineConnections),
-		fetcher.WithRetryElapsedTime(time.Duration(Config.RetryElapsedTime) * time.Second),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout) * time.Second),
-		fetcher.WithMaxRetries(Config.MaxRetries),
-	}
-	if Config.ForceRetry {
-		fetcherOpts = append(fetcherOpts, fetcher.WithForceRetry())
-	}
+// networkFetcher is the subset of *fetcher.Fetcher
+// used to fetch network information.
+type networkFetcher interface {
+	NetworkListRetry(
+		ctx context.Context,
+		metadata map[string]interface{},
+	) (*types.NetworkListResponse, *fetcher.Error)
+
+	NetworkOptions(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		metadata map[string]interface{},
+	) (*types.NetworkOptionsResponse, *fetcher.Error)
 
-	f := fetcher.New(
-		Config.OnlineURL,
-		fetcherOpts...,
-	)
+	NetworkStatusRetry(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		metadata map[string]interface{},
+	) (*types.NetworkStatusResponse, *fetcher.Error)
+}
 
+// networkView contains the options and
+// status of a network.
+type networkView struct {
+	NetworkIdentifier *types.NetworkIdentifier      `json:"network_identifier"`
+	NetworkOptions    *types.NetworkOptionsResponse `json:"network_options"`
+	NetworkStatus     *types.NetworkStatusResponse  `json:"network_status"`
+}
+
+// viewNetworks fetches the options and status of all networks and
+// prints them. If useJSON is true, all networks are written to w
+// as a single JSON array.
+func viewNetworks(ctx context.Context, w io.Writer, f networkFetcher, useJSON bool) error {
 	// Attempt to fetch network list
-	networkList, fetchErr := f.NetworkListRetry(Context, nil)
+	networkList, fetchErr := f.NetworkListRetry(ctx, nil)
 	if fetchErr != nil {
 		return fmt.Errorf("%w: unable to fetch network list", fetchErr.Err)
 	}
@@ -66,10 +87,14 @@ func runViewNetworksCmd(cmd *cobra.Command, args []string) error {
 		return errors.New("no networks available")
 	}
 
+	networks := []*networkView{}
 	for _, network := range networkList.NetworkIdentifiers {
-		color.Cyan(types.PrettyPrintStruct(network))
+		if !useJSON {
+			color.Cyan(types.PrettyPrintStruct(network))
+		}
+
 		networkOptions, fetchErr := f.NetworkOptions(
-			Context,
+			ctx,
 			network,
 			nil,
 		)
@@ -77,10 +102,12 @@ func runViewNetworksCmd(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("%w: unable to get network options", fetchErr.Err)
 		}
 
-		log.Printf("Network options: %s\n", types.PrettyPrintStruct(networkOptions))
+		if !useJSON {
+			log.Printf("Network options: %s\n", types.PrettyPrintStruct(networkOptions))
+		}
 
 		networkStatus, fetchErr := f.NetworkStatusRetry(
-			Context,
+			ctx,
 			network,
 			nil,
 		)
@@ -88,8 +115,34 @@ func runViewNetworksCmd(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("%w: unable to get network status", fetchErr.Err)
 		}
 
-		log.Printf("Network status: %s\n", types.PrettyPrintStruct(networkStatus))
+		if !useJSON {
+			log.Printf("Network status: %s\n", types.PrettyPrintStruct(networkStatus))
+		}
+
+		networks = append(networks, &networkView{
+			NetworkIdentifier: network,
+			NetworkOptions:    networkOptions,
+			NetworkStatus:     networkStatus,
+		})
+	}
+
+	if useJSON {
+		return printJSON(w, networks)
 	}
 
 	return nil
 }
+
+func runViewNetworksCmd(cmd *cobra.Command, args []string) error {
+	useJSON, err := useJSONOutput()
+	if err != nil {
+		return err
+	}
+
+	f, err := clients.NewOnlineFetcher(Config.HTTPTimeout)
+	if err != nil {
+		return fmt.Errorf("%w: unable to initialize online fetcher", err)
+	}
+
+	return viewNetworks(Context, os.Stdout, f, useJSON)
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNetworkFetcher struct {
+	networks []*types.NetworkIdentifier
+}
+
+func (m *mockNetworkFetcher) NetworkListRetry(
+	ctx context.Context,
+	metadata map[string]interface{},
+) (*types.NetworkListResponse, *fetcher.Error) {
+	return &types.NetworkListResponse{NetworkIdentifiers: m.networks}, nil
+}
+
+func (m *mockNetworkFetcher) NetworkOptions(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	metadata map[string]interface{},
+) (*types.NetworkOptionsResponse, *fetcher.Error) {
+	return &types.NetworkOptionsResponse{
+		Version: &types.Version{
+			RosettaVersion: "1.4.10",
+			NodeVersion:    network.Network,
+		},
+		Allow: &types.Allow{
+			OperationTypes: allowedOperationTypes,
+		},
+	}, nil
+}
+
+func (m *mockNetworkFetcher) NetworkStatusRetry(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	metadata map[string]interface{},
+) (*types.NetworkStatusResponse, *fetcher.Error) {
+	return &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: basicBlock,
+		CurrentBlockTimestamp:  1600000000000,
+		GenesisBlockIdentifier: basicBlock,
+	}, nil
+}
+
+func TestViewNetworksJSON(t *testing.T) {
+	var tests = map[string]struct {
+		networks []*types.NetworkIdentifier
+
+		expectedErr bool
+	}{
+		"single network": {
+			networks: []*types.NetworkIdentifier{basicNetwork},
+		},
+		"multiple networks": {
+			networks: []*types.NetworkIdentifier{
+				basicNetwork,
+				{
+					Blockchain: "blockchain",
+					Network:    "testnet",
+				},
+			},
+		},
+		"no networks": {
+			networks:    []*types.NetworkIdentifier{},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := viewNetworks(
+				context.Background(),
+				&buf,
+				&mockNetworkFetcher{networks: test.networks},
+				true,
+			)
+			if test.expectedErr {
+				assert.Error(t, err)
+				assert.Empty(t, buf.String())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, json.Valid(buf.Bytes()))
+			assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+
+			var networks []*networkView
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &networks))
+			assert.Len(t, networks, len(test.networks))
+			for i, network := range networks {
+				assert.Equal(t, test.networks[i], network.NetworkIdentifier)
+				assert.Equal(t, test.networks[i].Network, network.NetworkOptions.Version.NodeVersion)
+				assert.Equal(t, basicBlock, network.NetworkStatus.CurrentBlockIdentifier)
+			}
+		})
+	}
+}
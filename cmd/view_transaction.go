@@ -0,0 +1,163 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	viewTransactionCmd = &cobra.Command{
+		Use:   "view:transaction <network> <block-index> <tx-hash>",
+		Short: "View a single transaction in a block",
+		Long: `While debugging, it is often useful to inspect a single
+transaction (i.e. from a user report) without sifting through the rest
+of its block. This command fetches the block at the provided index and
+prints only the operations and metadata of the transaction with the
+provided hash.
+
+The network is provided as a JSON representation of a
+types.NetworkIdentifier. For example, you could run
+view:transaction '{"blockchain":"Bitcoin","network":"Mainnet"}' 1000 <tx-hash>
+to inspect a transaction in block 1000.
+
+Use --output json to print the transaction as JSON.
+
+If the transaction is not in the block, this command errors.`,
+		RunE: runViewTransactionCmd,
+		Args: cobra.ExactArgs(3),
+	}
+)
+
+// findTransaction returns the transaction in
+// block with the provided hash, if it exists.
+func findTransaction(block *types.Block, hash string) *types.Transaction {
+	for _, tx := range block.Transactions {
+		if tx.TransactionIdentifier.Hash == hash {
+			return tx
+		}
+	}
+
+	return nil
+}
+
+// viewTransaction fetches the block at index and prints the
+// transaction with the provided hash. If useJSON is true, the
+// transaction is written to w as JSON.
+func viewTransaction(
+	ctx context.Context,
+	w io.Writer,
+	f blockFetcher,
+	network *types.NetworkIdentifier,
+	index int64,
+	hash string,
+	useJSON bool,
+) error {
+	block, fetchErr := f.BlockRetry(
+		ctx,
+		network,
+		&types.PartialBlockIdentifier{
+			Index: &index,
+		},
+	)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to fetch block %d", fetchErr.Err, index)
+	}
+
+	// It's valid for a block to be omitted without triggering an error
+	if block == nil {
+		return fmt.Errorf("block %d not found, it might be omitted", index)
+	}
+
+	tx := findTransaction(block, hash)
+	if tx == nil {
+		return fmt.Errorf(
+			"transaction %s not found in block %s",
+			hash,
+			types.PrintStruct(block.BlockIdentifier),
+		)
+	}
+
+	if useJSON {
+		return printJSON(w, tx)
+	}
+
+	fmt.Fprintln(w, color.CyanString(
+		"Transaction %s in block %s:",
+		hash,
+		types.PrintStruct(block.BlockIdentifier),
+	))
+	fmt.Fprintf(w, "Operations: %s\n", types.PrettyPrintStruct(tx.Operations))
+	fmt.Fprintf(w, "Operation Groups: %s\n", types.PrettyPrintStruct(parser.GroupOperations(tx)))
+	fmt.Fprintf(w, "Metadata: %s\n", types.PrettyPrintStruct(tx.Metadata))
+
+	return nil
+}
+
+func runViewTransactionCmd(_ *cobra.Command, args []string) error {
+	useJSON, err := useJSONOutput()
+	if err != nil {
+		return err
+	}
+
+	network := &types.NetworkIdentifier{}
+	if err := json.Unmarshal([]byte(args[0]), network); err != nil {
+		return fmt.Errorf("%w: unable to unmarshal network %s", err, args[0])
+	}
+
+	if err := asserter.NetworkIdentifier(network); err != nil {
+		return fmt.Errorf("%w: invalid network identifier %s", err, types.PrintStruct(network))
+	}
+
+	index, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: unable to parse index %s", err, args[1])
+	}
+
+	if index < 0 {
+		return fmt.Errorf("index %d cannot be negative", index)
+	}
+
+	// Create a new fetcher
+	newFetcher, err := clients.NewOnlineFetcher(Config.HTTPTimeout)
+	if err != nil {
+		return fmt.Errorf("%w: unable to initialize online fetcher", err)
+	}
+
+	// Initialize the fetcher's asserter
+	_, _, fetchErr := newFetcher.InitializeAsserter(Context, network, Config.ValidationFile)
+	if fetchErr != nil {
+		return fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err)
+	}
+
+	_, err = utils.CheckNetworkSupported(Context, network, newFetcher)
+	if err != nil {
+		return fmt.Errorf("%w: unable to confirm network is supported", err)
+	}
+
+	return viewTransaction(Context, os.Stdout, newFetcher, network, index, args[2], useJSON)
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTransactionFetcher returns block, or
+// err if it is set.
+type mockTransactionFetcher struct {
+	block *types.Block
+	err   error
+}
+
+func (m *mockTransactionFetcher) BlockRetry(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, *fetcher.Error) {
+	if m.err != nil {
+		return nil, &fetcher.Error{Err: m.err}
+	}
+
+	return m.block, nil
+}
+
+func TestViewTransaction(t *testing.T) {
+	tx := &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx 2"},
+		Operations: []*types.Operation{
+			{
+				OperationIdentifier: &types.OperationIdentifier{Index: 0},
+				Type:                "Transfer",
+			},
+		},
+		Metadata: map[string]interface{}{"memo": "hello"},
+	}
+	block := &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		Transactions: []*types.Transaction{
+			{TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx 1"}},
+			tx,
+		},
+	}
+
+	var tests = map[string]struct {
+		block    *types.Block
+		fetchErr error
+		hash     string
+		useJSON  bool
+
+		expectedErr string
+	}{
+		"text": {
+			block: block,
+			hash:  "tx 2",
+		},
+		"json": {
+			block:   block,
+			hash:    "tx 2",
+			useJSON: true,
+		},
+		"not found in block": {
+			block:       block,
+			hash:        "tx 3",
+			expectedErr: "not found in block",
+		},
+		"omitted block": {
+			hash:        "tx 2",
+			expectedErr: "might be omitted",
+		},
+		"fetch error": {
+			fetchErr:    errors.New("block unavailable"),
+			hash:        "tx 2",
+			expectedErr: "block unavailable",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := viewTransaction(
+				context.Background(),
+				&buf,
+				&mockTransactionFetcher{block: test.block, err: test.fetchErr},
+				basicNetwork,
+				10,
+				test.hash,
+				test.useJSON,
+			)
+			if len(test.expectedErr) > 0 {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			if test.useJSON {
+				var printed types.Transaction
+				assert.NoError(t, json.Unmarshal(buf.Bytes(), &printed))
+				assert.Equal(t, tx, &printed)
+				assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+				return
+			}
+
+			assert.Contains(t, buf.String(), "Transaction tx 2 in block")
+			assert.Contains(t, buf.String(), "Transfer")
+			assert.Contains(t, buf.String(), "hello")
+			assert.NotContains(t, buf.String(), "tx 1")
+		})
+	}
+}
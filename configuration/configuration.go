@@ -15,58 +15,122 @@
 package configuration
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"math/big"
+	"net/url"
+	"os"
 	"path"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
+
 	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/constructor/dsl"
 	"github.com/coinbase/rosetta-sdk-go/constructor/job"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
 	"github.com/coinbase/rosetta-sdk-go/types"
-	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// redactedValue replaces the value of sensitive
+	// headers when logging the configuration.
+	redactedValue = "[REDACTED]"
+)
+
+var (
+	// ErrProfileNotFound is returned when the selected
+	// profile is not in the configuration file.
+	ErrProfileNotFound = errors.New("profile not found")
+
+	// ErrMissingNetwork is returned when no network is specified
+	// in the configuration file or with --blockchain and --network.
+	ErrMissingNetwork = errors.New(
+		"network must be specified (run configuration:create to generate a configuration, " +
+			"pass --blockchain and --network, or pass --allow-default-network to use the " +
+			"deprecated Ethereum Ropsten default)",
+	)
+
+	// sensitiveHeaderFragments are substrings of header names
+	// (in lower case) that likely contain credentials.
+	sensitiveHeaderFragments = []string{
+		"auth",
+		"token",
+		"secret",
+		"key",
+		"cookie",
+		"password",
+		"session",
+	}
 )
 
 // DefaultDataConfiguration returns the default *DataConfiguration
 // for running `check:data`.
 func DefaultDataConfiguration() *DataConfiguration {
 	return &DataConfiguration{
+		HTTPTimeout:                       DefaultTimeout,
 		ActiveReconciliationConcurrency:   DefaultActiveReconciliationConcurrency,
 		InactiveReconciliationConcurrency: DefaultInactiveReconciliationConcurrency,
 		InactiveReconciliationFrequency:   DefaultInactiveReconciliationFrequency,
-		StatusPort:                        DefaultStatusPort,
+		BootstrapBalancesConcurrency:      DefaultBootstrapBalancesConcurrency,
+		StatusPort:                        defaultStatusPort(),
+		ProgressLogInterval:               DefaultProgressLogInterval,
+		FetchRetrySoftLimit:               DefaultFetchRetrySoftLimit,
 	}
 }
 
+// defaultStatusPort returns a pointer to DefaultStatusPort
+// (a fresh pointer so that callers can't modify the
+// port of other configurations).
+func defaultStatusPort() *uint {
+	port := uint(DefaultStatusPort)
+	return &port
+}
+
 // DefaultConfiguration returns a *Configuration with the
 // EthereumNetwork, DefaultURL, DefaultTimeout,
 // DefaultConstructionConfiguration and DefaultDataConfiguration.
 func DefaultConfiguration() *Configuration {
 	return &Configuration{
-		Network:              EthereumNetwork,
-		OnlineURL:            DefaultURL,
-		MaxOnlineConnections: DefaultMaxOnlineConnections,
-		HTTPTimeout:          DefaultTimeout,
-		MaxRetries:           DefaultMaxRetries,
-		MaxSyncConcurrency:   DefaultMaxSyncConcurrency,
-		TipDelay:             DefaultTipDelay,
-		MaxReorgDepth:        DefaultMaxReorgDepth,
-		Data:                 DefaultDataConfiguration(),
+		ConfigVersion:             CurrentConfigVersion,
+		Network:                   EthereumNetwork,
+		OnlineURL:                 DefaultURL,
+		MaxOnlineConnections:      DefaultMaxOnlineConnections,
+		HTTPTimeout:               DefaultTimeout,
+		MaxRetries:                DefaultMaxRetries,
+		MaxSyncConcurrency:        DefaultMaxSyncConcurrency,
+		TipDelay:                  DefaultTipDelay,
+		MaxReorgDepth:             DefaultMaxReorgDepth,
+		ClockSkewWarningThreshold: DefaultClockSkewWarningThreshold,
+		TipPollInterval:           DefaultTipPollInterval,
+		Data:                      DefaultDataConfiguration(),
 	}
 }
 
 func populateConstructionMissingFields(
 	constructionConfig *ConstructionConfiguration,
+	httpTimeout uint64,
+	tipDelay int64,
 ) *ConstructionConfiguration {
 	if constructionConfig == nil {
 		return nil
 	}
 
+	if constructionConfig.HTTPTimeout == 0 {
+		constructionConfig.HTTPTimeout = httpTimeout
+	}
+
 	if len(constructionConfig.OfflineURL) == 0 {
 		constructionConfig.OfflineURL = DefaultURL
 	}
@@ -79,6 +143,10 @@ func populateConstructionMissingFields(
 		constructionConfig.StaleDepth = DefaultStaleDepth
 	}
 
+	if constructionConfig.TipDelay == 0 {
+		constructionConfig.TipDelay = tipDelay
+	}
+
 	if constructionConfig.BroadcastLimit == 0 {
 		constructionConfig.BroadcastLimit = DefaultBroadcastLimit
 	}
@@ -87,16 +155,30 @@ func populateConstructionMissingFields(
 		constructionConfig.BlockBroadcastLimit = DefaultBlockBroadcastLimit
 	}
 
-	if constructionConfig.StatusPort == 0 {
-		constructionConfig.StatusPort = DefaultStatusPort
+	if constructionConfig.StatusPort == nil {
+		constructionConfig.StatusPort = defaultStatusPort()
+	}
+
+	if constructionConfig.Faucet != nil && constructionConfig.Faucet.Cooldown == 0 {
+		constructionConfig.Faucet.Cooldown = DefaultFaucetCooldown
 	}
 
 	return constructionConfig
 }
 
-func populateDataMissingFields(dataConfig *DataConfiguration) *DataConfiguration {
+func populateDataMissingFields(
+	dataConfig *DataConfiguration,
+	httpTimeout uint64,
+) *DataConfiguration {
 	if dataConfig == nil {
-		return DefaultDataConfiguration()
+		dataConfig = DefaultDataConfiguration()
+		dataConfig.HTTPTimeout = httpTimeout
+
+		return dataConfig
+	}
+
+	if dataConfig.HTTPTimeout == 0 {
+		dataConfig.HTTPTimeout = httpTimeout
 	}
 
 	if dataConfig.ActiveReconciliationConcurrency == 0 {
@@ -111,19 +193,82 @@ func populateDataMissingFields(dataConfig *DataConfiguration) *DataConfiguration
 		dataConfig.InactiveReconciliationFrequency = DefaultInactiveReconciliationFrequency
 	}
 
-	if dataConfig.StatusPort == 0 {
-		dataConfig.StatusPort = DefaultStatusPort
+	if dataConfig.BootstrapBalancesConcurrency == 0 {
+		dataConfig.BootstrapBalancesConcurrency = DefaultBootstrapBalancesConcurrency
+	}
+
+	if dataConfig.StatusPort == nil {
+		dataConfig.StatusPort = defaultStatusPort()
+	}
+
+	if dataConfig.ProgressLogInterval == 0 {
+		dataConfig.ProgressLogInterval = DefaultProgressLogInterval
+	}
+
+	if dataConfig.FetchRetrySoftLimit == 0 {
+		dataConfig.FetchRetrySoftLimit = DefaultFetchRetrySoftLimit
+	}
+
+	if dataConfig.TipLongPoll {
+		if dataConfig.TipLongPollDelayMs == 0 {
+			dataConfig.TipLongPollDelayMs = DefaultTipLongPollDelayMs
+		}
+
+		if dataConfig.TipLongPollTimeout == 0 {
+			dataConfig.TipLongPollTimeout = DefaultTipLongPollTimeout
+		}
+	}
+
+	if hook := dataConfig.ReconciliationFailureHook; hook != nil {
+		if hook.Timeout == 0 {
+			hook.Timeout = DefaultReconciliationFailureHookTimeout
+		}
+
+		if hook.MaxConcurrency == 0 {
+			hook.MaxConcurrency = DefaultReconciliationFailureHookMaxConcurrency
+		}
 	}
 
 	return dataConfig
 }
 
-func populateMissingFields(config *Configuration) *Configuration {
+func populateRetryBackoffMissingFields(
+	retryBackoff *RetryBackoffConfiguration,
+) *RetryBackoffConfiguration {
+	if retryBackoff == nil {
+		return nil
+	}
+
+	if retryBackoff.InitialIntervalMs == 0 {
+		retryBackoff.InitialIntervalMs = DefaultRetryInitialIntervalMs
+	}
+
+	if retryBackoff.MaxIntervalMs == 0 {
+		retryBackoff.MaxIntervalMs = DefaultRetryMaxIntervalMs
+	}
+
+	if retryBackoff.Multiplier == 0 {
+		retryBackoff.Multiplier = DefaultRetryMultiplier
+	}
+
+	if retryBackoff.Jitter == nil {
+		jitter := DefaultRetryJitter
+		retryBackoff.Jitter = &jitter
+	}
+
+	return retryBackoff
+}
+
+func populateMissingFields(config *Configuration, defaultNetwork bool) *Configuration {
 	if config == nil {
 		return DefaultConfiguration()
 	}
 
-	if config.Network == nil {
+	if config.Network == nil && defaultNetwork {
+		color.Yellow(
+			"no network specified, defaulting to %s (deprecated)\n",
+			types.PrintStruct(EthereumNetwork),
+		)
 		config.Network = EthereumNetwork
 	}
 
@@ -147,6 +292,13 @@ func populateMissingFields(config *Configuration) *Configuration {
 		config.MaxSyncConcurrency = DefaultMaxSyncConcurrency
 	}
 
+	config.RetryBackoff = populateRetryBackoffMissingFields(config.RetryBackoff)
+	config.Storage = populateStorageMissingFields(config.Storage)
+
+	if config.RequestIDHeader != nil && len(config.RequestIDHeader.Prefix) == 0 {
+		config.RequestIDHeader.Prefix = DefaultRequestIDPrefix
+	}
+
 	if config.TipDelay == 0 {
 		config.TipDelay = DefaultTipDelay
 	}
@@ -155,6 +307,14 @@ func populateMissingFields(config *Configuration) *Configuration {
 		config.MaxReorgDepth = DefaultMaxReorgDepth
 	}
 
+	if config.ClockSkewWarningThreshold == 0 {
+		config.ClockSkewWarningThreshold = DefaultClockSkewWarningThreshold
+	}
+
+	if config.TipPollInterval == 0 {
+		config.TipPollInterval = DefaultTipPollInterval
+	}
+
 	numCPU := runtime.NumCPU()
 	if config.SeenBlockWorkers == 0 {
 		config.SeenBlockWorkers = numCPU
@@ -168,36 +328,353 @@ func populateMissingFields(config *Configuration) *Configuration {
 		config.ValidationFile = ""
 	}
 
-	config.Construction = populateConstructionMissingFields(config.Construction)
-	config.Data = populateDataMissingFields(config.Data)
+	config.Construction = populateConstructionMissingFields(
+		config.Construction,
+		config.HTTPTimeout,
+		config.TipDelay,
+	)
+	config.Data = populateDataMissingFields(config.Data, config.HTTPTimeout)
+
+	populateTLSShorthand(config)
+
+	// The construction configuration inherits the top-level TLS
+	// settings if no override is provided. We copy the settings so
+	// that file paths are only modified once.
+	if config.Construction != nil && config.Construction.TLS == nil && config.TLS != nil {
+		tlsConfig := *config.TLS
+		config.Construction.TLS = &tlsConfig
+	}
 
 	return config
 }
 
+// DataLogCategories returns the check:data logging categories
+// enabled by the data logging booleans of config or implied by
+// its log_level. Only "debug" implies any logging, as "info" is
+// the default level (and configurations that don't enable any
+// data logging booleans must not log).
+func DataLogCategories(config *Configuration) *LogCategories {
+	debug := config.LogLevel.Enabled(zapcore.DebugLevel)
+	categories := &LogCategories{
+		Blocks:         debug,
+		Transactions:   debug,
+		BalanceChanges: debug,
+	}
+	if config.Data == nil {
+		return categories
+	}
+
+	categories.Blocks = categories.Blocks || config.Data.LogBlocks
+	categories.Transactions = categories.Transactions || config.Data.LogTransactions
+	categories.BalanceChanges = categories.BalanceChanges || config.Data.LogBalanceChanges
+	categories.Reconciliations = config.Data.LogReconciliations
+
+	return categories
+}
+
+// populateTLSShorthand copies the top-level TLS shorthand
+// fields into the TLS configuration if the corresponding
+// TLS fields are not populated.
+func populateTLSShorthand(config *Configuration) {
+	if len(config.TLSClientCertFile) == 0 &&
+		len(config.TLSClientKeyFile) == 0 &&
+		len(config.TLSCAFile) == 0 {
+		return
+	}
+
+	if config.TLS == nil {
+		config.TLS = &TLSConfiguration{}
+	}
+
+	if len(config.TLS.CertFile) == 0 {
+		config.TLS.CertFile = config.TLSClientCertFile
+	}
+
+	if len(config.TLS.KeyFile) == 0 {
+		config.TLS.KeyFile = config.TLSClientKeyFile
+	}
+
+	if len(config.TLS.CAFile) == 0 {
+		config.TLS.CAFile = config.TLSCAFile
+	}
+}
+
+// assertTLSShorthand ensures the top-level TLS shorthand fields
+// do not conflict with the TLS configuration.
+func assertTLSShorthand(config *Configuration) error {
+	if config.TLS == nil {
+		return nil
+	}
+
+	shorthand := []struct {
+		name     string
+		value    string
+		tlsValue string
+		tlsField string
+	}{
+		{"tls_client_cert_file", config.TLSClientCertFile, config.TLS.CertFile, "tls.cert_file"},
+		{"tls_client_key_file", config.TLSClientKeyFile, config.TLS.KeyFile, "tls.key_file"},
+		{"tls_ca_file", config.TLSCAFile, config.TLS.CAFile, "tls.ca_file"},
+	}
+	for _, field := range shorthand {
+		if len(field.value) > 0 && field.value != field.tlsValue {
+			return fmt.Errorf(
+				"%s %s conflicts with %s %s",
+				field.name,
+				field.value,
+				field.tlsField,
+				field.tlsValue,
+			)
+		}
+	}
+
+	return nil
+}
+
+func populateStorageMissingFields(
+	storageConfig *StorageConfiguration,
+) *StorageConfiguration {
+	if storageConfig == nil {
+		return nil
+	}
+
+	if storageConfig.CommitLatencyThresholdMs == 0 {
+		storageConfig.CommitLatencyThresholdMs = DefaultCommitLatencyThresholdMs
+	}
+
+	return storageConfig
+}
+
+func assertStorageConfiguration(config *StorageConfiguration) error {
+	if config == nil {
+		return nil
+	}
+
+	if config.MaxCommitBatchBlocks < 0 {
+		return errors.New("max_commit_batch_blocks must be >= 0")
+	}
+
+	return nil
+}
+
+func assertRetryBackoffConfiguration(config *RetryBackoffConfiguration) error {
+	if config == nil {
+		return nil
+	}
+
+	if config.MaxIntervalMs < config.InitialIntervalMs {
+		return fmt.Errorf(
+			"max_interval_ms %d must be >= initial_interval_ms %d",
+			config.MaxIntervalMs,
+			config.InitialIntervalMs,
+		)
+	}
+
+	if config.Multiplier < 1 {
+		return fmt.Errorf("multiplier %f must be >= 1", config.Multiplier)
+	}
+
+	if *config.Jitter < 0 || *config.Jitter > 1 {
+		return fmt.Errorf("jitter %f must be in [0, 1]", *config.Jitter)
+	}
+
+	return nil
+}
+
+func assertTLSConfiguration(config *TLSConfiguration) error {
+	if config == nil {
+		return nil
+	}
+
+	_, err := httpclient.LoadTLSConfig(
+		config.CAFile,
+		config.CertFile,
+		config.KeyFile,
+		config.InsecureSkipVerify,
+	)
+
+	return err
+}
+
+// compileConstructorDSLFile compiles the Rosetta Constructor
+// DSL file at filePath. Compilation errors include the line
+// where the error occurred (the DSL compiler does not report
+// columns).
+func compileConstructorDSLFile(ctx context.Context, filePath string) ([]*job.Workflow, error) {
+	workflows, err := dsl.Parse(ctx, filePath)
+	if err != nil {
+		err.Log()
+		if err.Line > 0 {
+			return nil, fmt.Errorf(
+				"%w: compilation failed at line %d: %s",
+				err.Err,
+				err.Line,
+				strings.TrimSpace(err.LineContents),
+			)
+		}
+
+		return nil, fmt.Errorf("%w: compilation failed", err.Err)
+	}
+
+	return workflows, nil
+}
+
+// compileConstructorDSL compiles the contents of a Rosetta
+// Constructor DSL file. The DSL compiler only accepts files, so
+// the contents are written to a temporary file first.
+func compileConstructorDSL(ctx context.Context, contents string) ([]*job.Workflow, error) {
+	dir, err := ioutil.TempDir("", "constructor_dsl")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filePath := path.Join(dir, "constructor_dsl.ros")
+	if err := ioutil.WriteFile(filePath, []byte(contents), 0600); err != nil {
+		return nil, fmt.Errorf("%w: unable to write constructor_dsl", err)
+	}
+
+	return compileConstructorDSLFile(ctx, filePath)
+}
+
+// applyWorkflowConcurrency overrides the concurrency of
+// each workflow in config.WorkflowConcurrency.
+func applyWorkflowConcurrency(config *ConstructionConfiguration) error {
+	workflows := map[string]*job.Workflow{}
+	for _, workflow := range config.Workflows {
+		workflows[workflow.Name] = workflow
+	}
+
+	for name, concurrency := range config.WorkflowConcurrency {
+		workflow, ok := workflows[name]
+		if !ok {
+			return fmt.Errorf("workflow %s does not exist", name)
+		}
+
+		if concurrency <= 0 {
+			return fmt.Errorf("concurrency %d of workflow %s must be > 0", concurrency, name)
+		}
+
+		workflow.Concurrency = concurrency
+	}
+
+	return nil
+}
+
+// applyConfirmationDepth sets the confirmation_depth of each
+// workflow scenario to config.ConfirmationDepth (overriding any
+// confirmation_depth set by the scenario itself).
+func applyConfirmationDepth(config *ConstructionConfiguration) {
+	if config.ConfirmationDepth == 0 {
+		return
+	}
+
+	for _, workflow := range config.Workflows {
+		for _, scenario := range workflow.Scenarios {
+			scenario.Actions = append(scenario.Actions, &job.Action{
+				Type:       job.SetVariable,
+				Input:      fmt.Sprintf(`"%d"`, config.ConfirmationDepth),
+				OutputPath: fmt.Sprintf("%s.%s", scenario.Name, job.ConfirmationDepth),
+			})
+		}
+	}
+}
+
+func assertResultsOutputFormat(format ResultsOutputFormat) error {
+	switch format {
+	case "", JSONResultsOutputFormat, YAMLResultsOutputFormat, TextResultsOutputFormat:
+		return nil
+	default:
+		return fmt.Errorf(
+			"results_output_format %s must be %s, %s, or %s",
+			format,
+			JSONResultsOutputFormat,
+			YAMLResultsOutputFormat,
+			TextResultsOutputFormat,
+		)
+	}
+}
+
 func assertConstructionConfiguration(ctx context.Context, config *ConstructionConfiguration) error {
 	if config == nil {
 		return nil
 	}
 
-	if len(config.Workflows) > 0 && len(config.ConstructorDSLFile) > 0 {
-		return errors.New("cannot populate both workflows and DSL file path")
+	if err := assertResultsOutputFormat(config.ResultsOutputFormat); err != nil {
+		return err
+	}
+
+	if err := assertTLSConfiguration(config.TLS); err != nil {
+		return fmt.Errorf("%w: invalid tls configuration", err)
+	}
+
+	if config.BroadcastTPS < 0 {
+		return fmt.Errorf("broadcast_tps %f must be >= 0", config.BroadcastTPS)
+	}
+
+	if config.ConfirmationDepth < 0 {
+		return fmt.Errorf("confirmation_depth %d must be >= 0", config.ConfirmationDepth)
+	}
+
+	if config.ConfirmationDepth > 0 && config.StaleDepth <= config.ConfirmationDepth {
+		return fmt.Errorf(
+			"stale_depth %d must be > confirmation_depth %d",
+			config.StaleDepth,
+			config.ConfirmationDepth,
+		)
+	}
+
+	if config.TipDelay < 0 {
+		return fmt.Errorf("tip_delay %d must be >= 0", config.TipDelay)
+	}
+
+	sources := 0
+	for _, populated := range []bool{
+		len(config.Workflows) > 0,
+		len(config.ConstructorDSLFile) > 0,
+		len(config.ConstructorDSL) > 0,
+	} {
+		if populated {
+			sources++
+		}
+	}
+
+	if sources > 1 {
+		return errors.New(
+			"only one of workflows, constructor_dsl_file, and constructor_dsl can be populated",
+		)
 	}
 
-	if len(config.Workflows) == 0 && len(config.ConstructorDSLFile) == 0 {
-		return errors.New("both workflows and DSL file path are empty")
+	if sources == 0 {
+		return errors.New("workflows, constructor_dsl_file, and constructor_dsl are empty")
 	}
 
 	// Compile ConstructorDSLFile and save to Workflows
 	if len(config.ConstructorDSLFile) > 0 {
-		compiledWorkflows, err := dsl.Parse(ctx, config.ConstructorDSLFile)
+		compiledWorkflows, err := compileConstructorDSLFile(ctx, config.ConstructorDSLFile)
+		if err != nil {
+			return err
+		}
+
+		config.Workflows = compiledWorkflows
+	}
+
+	// Compile ConstructorDSL and save to Workflows
+	if len(config.ConstructorDSL) > 0 {
+		compiledWorkflows, err := compileConstructorDSL(ctx, config.ConstructorDSL)
 		if err != nil {
-			err.Log()
-			return fmt.Errorf("%w: compilation failed", err.Err)
+			return err
 		}
 
 		config.Workflows = compiledWorkflows
 	}
 
+	if err := applyWorkflowConcurrency(config); err != nil {
+		return fmt.Errorf("%w: invalid workflow_concurrency", err)
+	}
+
+	applyConfirmationDepth(config)
+
 	// Parse provided Workflows
 	for _, workflow := range config.Workflows {
 		if workflow.Name == string(job.CreateAccount) || workflow.Name == string(job.RequestFunds) {
@@ -211,14 +688,31 @@ func assertConstructionConfiguration(ctx context.Context, config *ConstructionCo
 		}
 	}
 
+	if err := assertFaucetConfiguration(config.Faucet); err != nil {
+		return fmt.Errorf("%w: invalid faucet configuration", err)
+	}
+
+	if config.MinimumBalanceConfirmations < 0 {
+		return fmt.Errorf(
+			"minimum_balance_confirmations %d must not be negative",
+			config.MinimumBalanceConfirmations,
+		)
+	}
+
+	for _, account := range config.AllowedSigners {
+		if err := asserter.AccountIdentifier(account); err != nil {
+			return fmt.Errorf("%w: invalid allowed signer", err)
+		}
+	}
+
 	for _, account := range config.PrefundedAccounts {
 		// Checks that privkey is hex encoded
 		_, err := hex.DecodeString(account.PrivateKeyHex)
 		if err != nil {
 			return fmt.Errorf(
-				"%w: private key %s is not hex encoded for prefunded account",
+				"%w: private key is not hex encoded for prefunded account %s",
 				err,
-				account.PrivateKeyHex,
+				prefundedAccountAddress(account),
 			)
 		}
 
@@ -242,80 +736,638 @@ func assertConstructionConfiguration(ctx context.Context, config *ConstructionCo
 	return nil
 }
 
-func assertDataConfiguration(config *DataConfiguration) error { // nolint:gocognit
-	if config.StartIndex != nil && *config.StartIndex < 0 {
-		return fmt.Errorf("start index %d cannot be negative", *config.StartIndex)
+// NewConstructionConfiguration returns a *ConstructionConfiguration
+// (with default values populated) that runs the workflows in
+// constructorDSLFile (relative to configDir, the directory of the
+// configuration file it will be written to) against offlineURL until
+// each workflow in endConditions completes the provided number of
+// times. The configuration is validated (compiling the DSL file)
+// before it is returned.
+func NewConstructionConfiguration(
+	ctx context.Context,
+	configDir string,
+	offlineURL string,
+	constructorDSLFile string,
+	endConditions map[string]int,
+) (*ConstructionConfiguration, error) {
+	config := populateConstructionMissingFields(
+		&ConstructionConfiguration{
+			OfflineURL:         offlineURL,
+			ConstructorDSLFile: constructorDSLFile,
+			EndConditions:      endConditions,
+		},
+		DefaultTimeout,
+		0, // inherit the top-level tip_delay
+	)
+
+	// Validation compiles the DSL file into Workflows, so we
+	// validate a copy to keep the returned configuration loadable.
+	validated := *config
+	validated.ConstructorDSLFile = path.Join(configDir, constructorDSLFile)
+	if err := assertConstructionConfiguration(ctx, &validated); err != nil {
+		return nil, fmt.Errorf("%w: invalid construction configuration", err)
 	}
 
-	if !config.ReconciliationDisabled && config.BalanceTrackingDisabled {
-		return errors.New("balance tracking must be enabled to perform reconciliation")
+	workflows := map[string]struct{}{}
+	for _, workflow := range validated.Workflows {
+		workflows[workflow.Name] = struct{}{}
 	}
 
-	if config.EndConditions == nil {
+	for workflow, count := range endConditions {
+		if _, ok := workflows[workflow]; !ok {
+			return nil, fmt.Errorf(
+				"end condition workflow %s is not defined in %s",
+				workflow,
+				constructorDSLFile,
+			)
+		}
+
+		if count <= 0 {
+			return nil, fmt.Errorf("end condition count for %s must be > 0", workflow)
+		}
+	}
+
+	return config, nil
+}
+
+func assertFaucetConfiguration(config *FaucetConfiguration) error {
+	if config == nil {
 		return nil
 	}
 
-	if config.EndConditions.Index != nil {
-		if *config.EndConditions.Index < 0 {
-			return fmt.Errorf("end index %d cannot be negative", *config.EndConditions.Index)
+	if len(config.URL) == 0 {
+		return errors.New("url must be populated")
+	}
+
+	if _, err := url.ParseRequestURI(config.URL); err != nil {
+		return fmt.Errorf("%w: unable to parse url %s", err, config.URL)
+	}
+
+	if _, err := regexp.Compile(config.ResponseMatcher); err != nil {
+		return fmt.Errorf("%w: unable to compile response_matcher", err)
+	}
+
+	if config.Amount != nil {
+		if err := asserter.Amount(config.Amount); err != nil {
+			return fmt.Errorf("%w: invalid amount", err)
 		}
 	}
 
-	if config.EndConditions.ReconciliationCoverage != nil {
-		coverage := config.EndConditions.ReconciliationCoverage.Coverage
-		if coverage < 0 || coverage > 1 {
-			return fmt.Errorf("reconciliation coverage %f must be [0.0,1.0]", coverage)
+	return nil
+}
+
+// AssertAccounts ensures all accounts and currencies
+// in accounts are valid.
+func AssertAccounts(accounts []*types.AccountCurrency) error {
+	for _, account := range accounts {
+		if account == nil {
+			return errors.New("account cannot be nil")
 		}
 
-		index := config.EndConditions.ReconciliationCoverage.Index
-		if index != nil && *index < 0 {
-			return fmt.Errorf("reconciliation coverage height %d must be >= 0", *index)
+		if err := asserter.AccountIdentifier(account.Account); err != nil {
+			return fmt.Errorf("%w: invalid account %s", err, types.PrintStruct(account))
 		}
 
-		accountCount := config.EndConditions.ReconciliationCoverage.AccountCount
-		if accountCount != nil && *accountCount < 0 {
-			return fmt.Errorf(
-				"reconciliation coverage account count %d must be >= 0",
-				*accountCount,
-			)
+		if err := asserter.Currency(account.Currency); err != nil {
+			return fmt.Errorf("%w: invalid currency %s", err, types.PrintStruct(account))
 		}
+	}
 
-		if config.BalanceTrackingDisabled {
-			return errors.New(
-				"balance tracking must be enabled for reconciliation coverage end condition",
-			)
+	return nil
+}
+
+// AssertExemptAccounts ensures all entries in exemptAccounts
+// have a valid currency and, if populated, a valid account
+// (entries without an account exempt a currency on all accounts).
+func AssertExemptAccounts(exemptAccounts []*types.AccountCurrency) error {
+	for _, account := range exemptAccounts {
+		if account == nil {
+			return errors.New("account cannot be nil")
 		}
 
-		if config.IgnoreReconciliationError {
-			return errors.New(
-				"reconciliation errors cannot be ignored for reconciliation coverage end condition",
-			)
+		if account.Account != nil {
+			if err := AssertAccounts([]*types.AccountCurrency{account}); err != nil {
+				return err
+			}
+
+			continue
 		}
 
-		if config.ReconciliationDisabled {
-			return errors.New(
-				"reconciliation cannot be disabled for reconciliation coverage end condition",
-			)
+		if err := asserter.Currency(account.Currency); err != nil {
+			return fmt.Errorf("%w: invalid currency %s", err, types.PrintStruct(account))
 		}
 	}
 
 	return nil
 }
 
-func assertConfiguration(ctx context.Context, config *Configuration) error {
-	if err := asserter.NetworkIdentifier(config.Network); err != nil {
-		return fmt.Errorf("%w: invalid network identifier", err)
+// assertReconciliationFailureThresholds ensures the reconciliation
+// failure thresholds are valid and that failures halt check:data.
+func assertReconciliationFailureThresholds(config *DataConfiguration) error {
+	if config.ReconciliationFailureThreshold < 0 {
+		return fmt.Errorf(
+			"reconciliation_failure_threshold %d must be >= 0",
+			config.ReconciliationFailureThreshold,
+		)
 	}
 
-	if config.SeenBlockWorkers <= 0 {
-		return errors.New("seen_block_workers must be > 0")
+	if config.ReconciliationFailureRate < 0 || config.ReconciliationFailureRate >= 1 {
+		return fmt.Errorf(
+			"reconciliation_failure_rate %f must be in [0, 1)",
+			config.ReconciliationFailureRate,
+		)
 	}
 
-	if config.SerialBlockWorkers <= 0 {
-		return errors.New("serial_block_workers must be > 0")
+	if (config.ReconciliationFailureThreshold > 0 || config.ReconciliationFailureRate > 0) &&
+		config.IgnoreReconciliationError {
+		return errors.New(
+			"reconciliation_failure_threshold and reconciliation_failure_rate cannot be " +
+				"populated if ignore_reconciliation_error is true",
+		)
 	}
 
-	if err := assertDataConfiguration(config.Data); err != nil {
+	return nil
+}
+
+// assertBalanceExemptions ensures all balance exemptions have
+// a valid exemption type and match a subset of accounts.
+func assertBalanceExemptions(exemptions []*BalanceExemption) error {
+	for _, exemption := range exemptions {
+		if exemption == nil {
+			return errors.New("balance exemption cannot be nil")
+		}
+
+		if exemption.Account == nil && exemption.SubAccountAddress == nil &&
+			exemption.Currency == nil {
+			return fmt.Errorf(
+				"balance exemption must populate an account, sub-account address, or currency %s",
+				types.PrintStruct(exemption),
+			)
+		}
+
+		if exemption.Account != nil {
+			if err := asserter.AccountIdentifier(exemption.Account); err != nil {
+				return fmt.Errorf("%w: invalid account %s", err, types.PrintStruct(exemption))
+			}
+		}
+
+		if exemption.SubAccountAddress != nil && len(*exemption.SubAccountAddress) == 0 {
+			return fmt.Errorf("sub_account_address cannot be empty %s", types.PrintStruct(exemption))
+		}
+
+		if exemption.Currency != nil {
+			if err := asserter.Currency(exemption.Currency); err != nil {
+				return fmt.Errorf("%w: invalid currency %s", err, types.PrintStruct(exemption))
+			}
+		}
+
+		switch exemption.ExemptionType {
+		case types.BalanceGreaterOrEqual, types.BalanceLessOrEqual, types.BalanceDynamic:
+		default:
+			return fmt.Errorf(
+				"invalid exemption_type %s (must be %s, %s, or %s)",
+				exemption.ExemptionType,
+				types.BalanceGreaterOrEqual,
+				types.BalanceLessOrEqual,
+				types.BalanceDynamic,
+			)
+		}
+	}
+
+	return nil
+}
+
+// assertAcceptableDiscrepancies ensures all acceptable discrepancies
+// have a valid account, currency, and non-negative max_delta.
+func assertAcceptableDiscrepancies(discrepancies []*AcceptableDiscrepancy) error {
+	for _, discrepancy := range discrepancies {
+		if discrepancy == nil {
+			return errors.New("acceptable discrepancy cannot be nil")
+		}
+
+		if err := asserter.AccountIdentifier(discrepancy.Account); err != nil {
+			return fmt.Errorf("%w: invalid account %s", err, types.PrintStruct(discrepancy))
+		}
+
+		if err := asserter.Currency(discrepancy.Currency); err != nil {
+			return fmt.Errorf("%w: invalid currency %s", err, types.PrintStruct(discrepancy))
+		}
+
+		maxDelta, err := types.BigInt(discrepancy.MaxDelta)
+		if err != nil {
+			return fmt.Errorf("%w: invalid max_delta %s", err, types.PrintStruct(discrepancy))
+		}
+
+		if maxDelta.Sign() < 0 {
+			return fmt.Errorf("max_delta cannot be negative %s", types.PrintStruct(discrepancy))
+		}
+	}
+
+	return nil
+}
+
+// assertBootstrapBalances ensures all balances can be
+// bootstrapped (positive integer values of valid accounts
+// and currencies).
+func assertBootstrapBalances(balances []*modules.BootstrapBalance) error {
+	for _, balance := range balances {
+		if balance == nil {
+			return errors.New("bootstrap balance cannot be nil")
+		}
+
+		if err := asserter.AccountIdentifier(balance.Account); err != nil {
+			return fmt.Errorf("%w: invalid account %s", err, types.PrintStruct(balance))
+		}
+
+		if err := asserter.Currency(balance.Currency); err != nil {
+			return fmt.Errorf("%w: invalid currency %s", err, types.PrintStruct(balance))
+		}
+
+		value, ok := new(big.Int).SetString(balance.Value, 10)
+		if !ok {
+			return fmt.Errorf("value %s is not an integer", balance.Value)
+		}
+
+		if value.Sign() < 1 {
+			return fmt.Errorf("cannot bootstrap zero or negative balance %s", balance.Value)
+		}
+	}
+
+	return nil
+}
+
+func assertMonitorConfiguration(config *DataConfiguration) error {
+	if !config.MonitorAfterEndConditions {
+		return nil
+	}
+
+	if config.EndConditions == nil {
+		return errors.New("monitor_after_end_conditions requires end_conditions")
+	}
+
+	// The syncer stops at the end index, so there
+	// would be nothing to monitor.
+	if config.EndConditions.Index != nil {
+		return errors.New("monitor_after_end_conditions cannot be used with end_conditions.index")
+	}
+
+	if len(config.MonitorResultsOutputFile) > 0 &&
+		config.MonitorResultsOutputFile == config.ResultsOutputFile {
+		return errors.New("monitor_results_output_file must differ from results_output_file")
+	}
+
+	return nil
+}
+
+func assertDataConfiguration( // nolint:gocognit
+	config *DataConfiguration,
+	maxReorgDepth int,
+) error {
+	if !config.ReconciliationDisabled && config.BalanceTrackingDisabled {
+		return errors.New("balance tracking must be enabled to perform reconciliation")
+	}
+
+	if config.ReconciliationDisabled && config.LogReconciliations {
+		return errors.New(
+			"log_reconciliations cannot be true if reconciliation is disabled " +
+				"(there are no reconciliations to log)",
+		)
+	}
+
+	if err := assertResultsOutputFormat(config.ResultsOutputFormat); err != nil {
+		return err
+	}
+
+	if len(config.ArchiveURL) > 0 && config.HistoricalBalanceDisabled != nil &&
+		*config.HistoricalBalanceDisabled {
+		return errors.New("historical_balance_disabled cannot be true if archive_url is populated")
+	}
+
+	if config.PruningDepth != nil && *config.PruningDepth < int64(maxReorgDepth) {
+		return fmt.Errorf(
+			"pruning_depth %d must be >= max_reorg_depth %d",
+			*config.PruningDepth,
+			maxReorgDepth,
+		)
+	}
+
+	if config.StorageEstimateSamples < 0 {
+		return fmt.Errorf(
+			"storage_estimate_samples %d must be >= 0",
+			config.StorageEstimateSamples,
+		)
+	}
+
+	if config.FailureReportOperations < 0 {
+		return fmt.Errorf(
+			"failure_report_operations %d must be >= 0",
+			config.FailureReportOperations,
+		)
+	}
+
+	if config.PruningBlockInterval != nil && *config.PruningBlockInterval <= 0 {
+		return fmt.Errorf(
+			"pruning_block_interval %d must be > 0",
+			*config.PruningBlockInterval,
+		)
+	}
+
+	if err := AssertExemptAccounts(config.ExemptAccountsList); err != nil {
+		return fmt.Errorf("%w: invalid exempt_accounts_list", err)
+	}
+
+	if err := assertAcceptableDiscrepancies(config.AcceptableDiscrepancies); err != nil {
+		return fmt.Errorf("%w: invalid acceptable_discrepancies", err)
+	}
+
+	if err := assertBalanceExemptions(config.BalanceExemptions); err != nil {
+		return fmt.Errorf("%w: invalid balance_exemptions", err)
+	}
+
+	if err := assertReconciliationFailureThresholds(config); err != nil {
+		return err
+	}
+
+	if config.MaxErrors < 0 {
+		return fmt.Errorf("max_errors %d must be >= 0", config.MaxErrors)
+	}
+
+	for _, opType := range config.ExcludedOperationTypes {
+		if len(opType) == 0 {
+			return errors.New("excluded_operation_types cannot contain an empty operation type")
+		}
+	}
+
+	if config.BlockFetchConcurrency < 0 {
+		return fmt.Errorf(
+			"block_fetch_concurrency %d must be >= 0",
+			config.BlockFetchConcurrency,
+		)
+	}
+
+	if config.TipLongPoll && len(config.TipLongPollNotFoundCodes) == 0 {
+		return errors.New("tip_long_poll_not_found_codes must be populated if tip_long_poll is enabled")
+	}
+
+	if config.StaticTip != nil {
+		if *config.StaticTip < 0 {
+			return fmt.Errorf("static_tip %d must be >= 0", *config.StaticTip)
+		}
+
+		if config.TipLongPoll {
+			return errors.New("tip_long_poll cannot be enabled if static_tip is populated")
+		}
+	}
+
+	for _, currency := range config.ReconciliationCurrencies {
+		if err := asserter.Currency(currency); err != nil {
+			return fmt.Errorf("%w: invalid reconciliation_currencies", err)
+		}
+	}
+
+	if err := assertMonitorConfiguration(config); err != nil {
+		return fmt.Errorf("%w: invalid monitor configuration", err)
+	}
+
+	if len(config.BootstrapBalances) > 0 && len(config.BootstrapBalancesList) > 0 {
+		return errors.New("cannot populate both bootstrap_balances and bootstrap_balances_list")
+	}
+
+	if err := assertBootstrapBalances(config.BootstrapBalancesList); err != nil {
+		return fmt.Errorf("%w: invalid bootstrap_balances_list", err)
+	}
+
+	if hook := config.ReconciliationFailureHook; hook != nil {
+		if len(hook.Path) == 0 {
+			return errors.New("reconciliation_failure_hook path must be populated")
+		}
+
+		if hook.MaxConcurrency < 0 {
+			return fmt.Errorf(
+				"reconciliation_failure_hook max_concurrency %d must be > 0",
+				hook.MaxConcurrency,
+			)
+		}
+	}
+
+	if config.EndOfRunBlockAudit != nil && config.EndOfRunBlockAudit.SampleCount <= 0 {
+		return fmt.Errorf(
+			"end of run block audit sample count %d must be > 0",
+			config.EndOfRunBlockAudit.SampleCount,
+		)
+	}
+
+	if config.GenesisIndex != nil && *config.GenesisIndex < 0 {
+		return fmt.Errorf("genesis index %d must not be negative", *config.GenesisIndex)
+	}
+
+	for endpoint, source := range config.EndpointOverrides {
+		if !strings.HasPrefix(endpoint, "/") {
+			return fmt.Errorf("endpoint override %s must start with /", endpoint)
+		}
+
+		if len(source) == 0 {
+			return fmt.Errorf("endpoint override %s must have a fixture file or directory", endpoint)
+		}
+	}
+
+	if config.EndConditions == nil {
+		return nil
+	}
+
+	// Relative indexes can only be compared once
+	// they are resolved at startup.
+	endIndex := config.EndConditions.Index
+	if config.StartIndex != nil && *config.StartIndex >= 0 &&
+		endIndex != nil && *endIndex >= 0 && *endIndex < *config.StartIndex {
+		return fmt.Errorf(
+			"end index %d cannot be less than start index %d",
+			*endIndex,
+			*config.StartIndex,
+		)
+	}
+
+	if reconciliations := config.EndConditions.Reconciliations; reconciliations != nil {
+		if *reconciliations == 0 {
+			return errors.New("end_conditions.reconciliations must be > 0")
+		}
+
+		if config.BalanceTrackingDisabled {
+			return errors.New(
+				"balance tracking must be enabled for reconciliations end condition",
+			)
+		}
+
+		if config.ReconciliationDisabled {
+			return errors.New(
+				"reconciliation cannot be disabled for reconciliations end condition",
+			)
+		}
+	}
+
+	if config.EndConditions.BlockHash != nil && len(*config.EndConditions.BlockHash) == 0 {
+		return errors.New("end_conditions.block_hash cannot be empty")
+	}
+
+	if blockHashIndex := config.EndConditions.BlockHashIndex; blockHashIndex != nil {
+		if config.EndConditions.BlockHash == nil {
+			return errors.New("end_conditions.block_hash_index requires end_conditions.block_hash")
+		}
+
+		if *blockHashIndex < 0 {
+			return fmt.Errorf("end_conditions.block_hash_index %d must be >= 0", *blockHashIndex)
+		}
+	}
+
+	if config.EndConditions.ReconciliationCoverage != nil {
+		coverage := config.EndConditions.ReconciliationCoverage.Coverage
+		if coverage < 0 || coverage > 1 {
+			return fmt.Errorf("reconciliation coverage %f must be [0.0,1.0]", coverage)
+		}
+
+		index := config.EndConditions.ReconciliationCoverage.Index
+		if index != nil && *index < 0 {
+			return fmt.Errorf("reconciliation coverage height %d must be >= 0", *index)
+		}
+
+		accountCount := config.EndConditions.ReconciliationCoverage.AccountCount
+		if accountCount != nil && *accountCount < 0 {
+			return fmt.Errorf(
+				"reconciliation coverage account count %d must be >= 0",
+				*accountCount,
+			)
+		}
+
+		if config.BalanceTrackingDisabled {
+			return errors.New(
+				"balance tracking must be enabled for reconciliation coverage end condition",
+			)
+		}
+
+		if config.IgnoreReconciliationError {
+			return errors.New(
+				"reconciliation errors cannot be ignored for reconciliation coverage end condition",
+			)
+		}
+
+		if config.ReconciliationDisabled {
+			return errors.New(
+				"reconciliation cannot be disabled for reconciliation coverage end condition",
+			)
+		}
+	}
+
+	return nil
+}
+
+// AssertNetwork returns ErrMissingNetwork if config has no
+// network or an error if the network is invalid. A missing
+// network may still be provided with OverrideNetwork, so this
+// must be called once all overrides are applied.
+func AssertNetwork(config *Configuration) error {
+	if config.Network == nil {
+		return ErrMissingNetwork
+	}
+
+	if err := asserter.NetworkIdentifier(config.Network); err != nil {
+		return fmt.Errorf("%w: invalid network identifier", err)
+	}
+
+	return nil
+}
+
+func assertConfiguration(ctx context.Context, config *Configuration) error {
+	if config.Network != nil {
+		if err := AssertNetwork(config); err != nil {
+			return err
+		}
+	}
+
+	if config.SeenBlockWorkers <= 0 {
+		return errors.New("seen_block_workers must be > 0")
+	}
+
+	if config.SerialBlockWorkers <= 0 {
+		return errors.New("serial_block_workers must be > 0")
+	}
+
+	if config.ClockSkewWarningThreshold < 0 {
+		return errors.New("clock_skew_warning_threshold must be >= 0")
+	}
+
+	if config.MaxBlockSizeBytes < 0 {
+		return errors.New("max_block_size_bytes must be >= 0")
+	}
+
+	if config.MaxBlocksPerSecond < 0 {
+		return fmt.Errorf("max_blocks_per_second %f must be >= 0", config.MaxBlocksPerSecond)
+	}
+
+	if config.TipPollInterval < MinTipPollInterval ||
+		config.TipPollInterval > MaxTipPollInterval {
+		return fmt.Errorf(
+			"tip_poll_interval %d must be between %d and %d seconds",
+			config.TipPollInterval,
+			MinTipPollInterval,
+			MaxTipPollInterval,
+		)
+	}
+
+	if len(config.ComparisonURL) > 0 && config.ComparisonURL == config.OnlineURL {
+		return errors.New("comparison_url must differ from online_url")
+	}
+
+	if config.LogLevel < zapcore.DebugLevel || config.LogLevel > zapcore.ErrorLevel {
+		return fmt.Errorf("log_level %s is not supported", config.LogLevel)
+	}
+
+	switch config.LogFormat {
+	case "", TextLogFormat, JSONLogFormat:
+	default:
+		return fmt.Errorf(
+			"log_format %s must be %s or %s",
+			config.LogFormat,
+			TextLogFormat,
+			JSONLogFormat,
+		)
+	}
+
+	if err := assertTLSShorthand(config); err != nil {
+		return fmt.Errorf("%w: invalid tls configuration", err)
+	}
+
+	if err := assertTLSConfiguration(config.TLS); err != nil {
+		return fmt.Errorf("%w: invalid tls configuration", err)
+	}
+
+	if _, err := httpclient.ParseProxyURL(config.ProxyURL); err != nil {
+		return fmt.Errorf("%w: invalid proxy_url", err)
+	}
+
+	for key := range config.Headers {
+		if len(strings.TrimSpace(key)) == 0 {
+			return errors.New("header names cannot be empty")
+		}
+	}
+
+	if config.Baseline != nil && len(config.Baseline.File) == 0 {
+		return errors.New("baseline file must be populated")
+	}
+
+	if err := assertRetryBackoffConfiguration(config.RetryBackoff); err != nil {
+		return fmt.Errorf("%w: invalid retry_backoff configuration", err)
+	}
+
+	if err := assertStorageConfiguration(config.Storage); err != nil {
+		return fmt.Errorf("%w: invalid storage configuration", err)
+	}
+
+	if config.RequestIDHeader != nil &&
+		len(strings.TrimSpace(config.RequestIDHeader.Name)) == 0 {
+		return errors.New("request_id_header name cannot be empty")
+	}
+
+	if err := assertDataConfiguration(config.Data, config.MaxReorgDepth); err != nil {
 		return fmt.Errorf("%w: invalid data configuration", err)
 	}
 
@@ -331,9 +1383,23 @@ func assertConfiguration(ctx context.Context, config *Configuration) error {
 // to store all config-related files in the same directory and to run the rosetta-cli
 // from a different directory).
 func modifyFilePaths(config *Configuration, fileDir string) {
+	modifyTLSFilePaths(config.TLS, fileDir)
+
+	if len(config.TLSClientCertFile) > 0 {
+		config.TLSClientCertFile = path.Join(fileDir, config.TLSClientCertFile)
+	}
+
+	if len(config.TLSClientKeyFile) > 0 {
+		config.TLSClientKeyFile = path.Join(fileDir, config.TLSClientKeyFile)
+	}
+
+	if len(config.TLSCAFile) > 0 {
+		config.TLSCAFile = path.Join(fileDir, config.TLSCAFile)
+	}
+
 	if config.Data != nil {
-		if len(config.Data.BootstrapBalances) > 0 {
-			config.Data.BootstrapBalances = path.Join(fileDir, config.Data.BootstrapBalances)
+		for i, filePath := range config.Data.BootstrapBalances {
+			config.Data.BootstrapBalances[i] = path.Join(fileDir, filePath)
 		}
 
 		if len(config.Data.InterestingAccounts) > 0 {
@@ -343,6 +1409,28 @@ func modifyFilePaths(config *Configuration, fileDir string) {
 		if len(config.Data.ExemptAccounts) > 0 {
 			config.Data.ExemptAccounts = path.Join(fileDir, config.Data.ExemptAccounts)
 		}
+
+		if len(config.Data.TransactionOverrides) > 0 {
+			config.Data.TransactionOverrides = path.Join(
+				fileDir,
+				config.Data.TransactionOverrides,
+			)
+		}
+
+		if len(config.Data.CheckpointsFile) > 0 {
+			config.Data.CheckpointsFile = path.Join(fileDir, config.Data.CheckpointsFile)
+		}
+
+		for endpoint, source := range config.Data.EndpointOverrides {
+			if len(source) > 0 && !path.IsAbs(source) {
+				config.Data.EndpointOverrides[endpoint] = path.Join(fileDir, source)
+			}
+		}
+
+		hook := config.Data.ReconciliationFailureHook
+		if hook != nil && len(hook.Path) > 0 && !path.IsAbs(hook.Path) {
+			hook.Path = path.Join(fileDir, hook.Path)
+		}
 	}
 
 	if config.Construction != nil {
@@ -352,40 +1440,535 @@ func modifyFilePaths(config *Configuration, fileDir string) {
 				config.Construction.ConstructorDSLFile,
 			)
 		}
+
+		modifyTLSFilePaths(config.Construction.TLS, fileDir)
+
+		for _, account := range config.Construction.PrefundedAccounts {
+			if account != nil && len(account.PrivateKeyFile) > 0 &&
+				!path.IsAbs(account.PrivateKeyFile) {
+				account.PrivateKeyFile = path.Join(fileDir, account.PrivateKeyFile)
+			}
+		}
 	}
 
 	if len(config.ValidationFile) > 0 {
 		config.ValidationFile = path.Join(fileDir, config.ValidationFile)
 	}
+
+	if config.Baseline != nil && len(config.Baseline.File) > 0 &&
+		!path.IsAbs(config.Baseline.File) {
+		config.Baseline.File = path.Join(fileDir, config.Baseline.File)
+	}
+}
+
+func modifyTLSFilePaths(config *TLSConfiguration, fileDir string) {
+	if config == nil {
+		return
+	}
+
+	if len(config.CAFile) > 0 {
+		config.CAFile = path.Join(fileDir, config.CAFile)
+	}
+
+	if len(config.CertFile) > 0 {
+		config.CertFile = path.Join(fileDir, config.CertFile)
+	}
+
+	if len(config.KeyFile) > 0 {
+		config.KeyFile = path.Join(fileDir, config.KeyFile)
+	}
+}
+
+// warnInsecureTLS prints a warning if certificate verification
+// is disabled for requests to url.
+func warnInsecureTLS(config *TLSConfiguration, url string) {
+	if config == nil || !config.InsecureSkipVerify {
+		return
+	}
+
+	color.Red(
+		"[WARNING] insecure_skip_verify is enabled for %s: the implementation's certificate will NOT be verified and requests are vulnerable to man-in-the-middle attacks!", // nolint:lll
+		url,
+	)
+}
+
+// prefundedAccountAddress returns the address of account
+// (or an empty string if it is not populated).
+func prefundedAccountAddress(account *PrefundedAccount) string {
+	if account.AccountIdentifier == nil {
+		return ""
+	}
+
+	return account.AccountIdentifier.Address
+}
+
+// loadPrivateKeys populates the private key of each prefunded
+// account from its private_key_env or private_key_file.
+func loadPrivateKeys(config *Configuration) error {
+	if config.Construction == nil {
+		return nil
+	}
+
+	for _, account := range config.Construction.PrefundedAccounts {
+		if account == nil {
+			return errors.New("prefunded account cannot be nil")
+		}
+
+		address := prefundedAccountAddress(account)
+		sources := 0
+		for _, source := range []string{
+			account.PrivateKeyHex,
+			account.PrivateKeyEnv,
+			account.PrivateKeyFile,
+		} {
+			if len(source) > 0 {
+				sources++
+			}
+		}
+		if sources > 1 {
+			return fmt.Errorf(
+				"only one of privkey, private_key_env, and private_key_file "+
+					"can be populated for prefunded account %s",
+				address,
+			)
+		}
+
+		switch {
+		case len(account.PrivateKeyEnv) > 0:
+			value, ok := os.LookupEnv(account.PrivateKeyEnv)
+			if !ok {
+				return fmt.Errorf(
+					"environment variable %s is not set for prefunded account %s",
+					account.PrivateKeyEnv,
+					address,
+				)
+			}
+
+			account.PrivateKeyHex = strings.TrimSpace(value)
+		case len(account.PrivateKeyFile) > 0:
+			b, err := ioutil.ReadFile(path.Clean(account.PrivateKeyFile))
+			if err != nil {
+				return fmt.Errorf(
+					"%w: unable to read private key file %s for prefunded account %s",
+					err,
+					account.PrivateKeyFile,
+					address,
+				)
+			}
+
+			account.PrivateKeyHex = strings.TrimSpace(string(b))
+		}
+	}
+
+	return nil
+}
+
+// RedactedConfiguration returns a copy of config with the values
+// of sensitive headers and private keys redacted so that it can
+// be safely logged.
+func RedactedConfiguration(config *Configuration) *Configuration {
+	faucetAuth := config.Construction != nil &&
+		config.Construction.Faucet != nil &&
+		len(config.Construction.Faucet.AuthHeader) > 0
+	privateKeys := config.Construction != nil &&
+		len(config.Construction.PrefundedAccounts) > 0
+	if len(config.Headers) == 0 && !faucetAuth && !privateKeys {
+		return config
+	}
+
+	redacted := *config
+	if len(config.Headers) > 0 {
+		redacted.Headers = make(map[string]string, len(config.Headers))
+		for key, value := range config.Headers {
+			if sensitiveHeader(key) {
+				value = redactedValue
+			}
+
+			redacted.Headers[key] = value
+		}
+	}
+
+	if faucetAuth || privateKeys {
+		construction := *config.Construction
+		if faucetAuth {
+			faucet := *construction.Faucet
+			faucet.AuthHeader = redactedValue
+			construction.Faucet = &faucet
+		}
+
+		if privateKeys {
+			construction.PrefundedAccounts = make(
+				[]*PrefundedAccount,
+				len(config.Construction.PrefundedAccounts),
+			)
+			for i, account := range config.Construction.PrefundedAccounts {
+				if account == nil {
+					continue
+				}
+
+				redactedAccount := *account
+				if len(redactedAccount.PrivateKeyHex) > 0 {
+					redactedAccount.PrivateKeyHex = redactedValue
+				}
+				construction.PrefundedAccounts[i] = &redactedAccount
+			}
+		}
+
+		redacted.Construction = &construction
+	}
+
+	return &redacted
+}
+
+// sensitiveHeader returns a boolean indicating if
+// the value of a header is likely a credential.
+func sensitiveHeader(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, fragment := range sensitiveHeaderFragments {
+		if strings.Contains(lowerKey, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// overlay merges the values of profile into base. Objects
+// present in both are merged recursively and all other values
+// in profile replace the values in base.
+func overlay(base map[string]interface{}, profile map[string]interface{}) {
+	for key, value := range profile {
+		baseObject, baseOk := base[key].(map[string]interface{})
+		profileObject, profileOk := value.(map[string]interface{})
+		if baseOk && profileOk {
+			overlay(baseObject, profileObject)
+			continue
+		}
+
+		base[key] = value
+	}
+}
+
+// applyProfile returns the configuration file contents in b
+// with the overrides of profile applied. The profiles are
+// removed from the result so that only the effective
+// configuration remains.
+func applyProfile(b []byte, profile string) ([]byte, error) {
+	raw, err := decodeRaw(b)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, _ := raw["profiles"].(map[string]interface{})
+	overrides, ok := profiles[profile].(map[string]interface{})
+	if !ok {
+		available := make([]string, 0, len(profiles))
+		for name := range profiles {
+			available = append(available, name)
+		}
+		sort.Strings(available)
+
+		return nil, fmt.Errorf(
+			"%w: %s (available profiles: [%s])",
+			ErrProfileNotFound,
+			profile,
+			strings.Join(available, ", "),
+		)
+	}
+
+	delete(raw, "profiles")
+	delete(overrides, "profiles")
+	overlay(raw, overrides)
+
+	return json.Marshal(raw)
+}
+
+// loadSettings are the settings of LoadConfiguration.
+type loadSettings struct {
+	defaultNetwork bool
+}
+
+// LoadOption is used to configure LoadConfiguration
+// and LoadConfigurationProfile.
+type LoadOption func(s *loadSettings)
+
+// WithDefaultNetwork sets whether a missing network is populated
+// with the deprecated EthereumNetwork (the default). If not, the
+// network is left empty so that it can be provided with
+// OverrideNetwork and AssertNetwork must be called once all
+// overrides are applied.
+func WithDefaultNetwork(defaultNetwork bool) LoadOption {
+	return func(s *loadSettings) {
+		s.defaultNetwork = defaultNetwork
+	}
 }
 
 // LoadConfiguration returns a parsed and asserted Configuration for running
 // tests.
-func LoadConfiguration(ctx context.Context, filePath string) (*Configuration, error) {
+func LoadConfiguration(
+	ctx context.Context,
+	filePath string,
+	options ...LoadOption,
+) (*Configuration, error) {
+	return LoadConfigurationProfile(ctx, filePath, "", options...)
+}
+
+// LoadConfigurationProfile returns a parsed and asserted Configuration
+// for running tests with the overrides of profile applied. If profile
+// is empty, no overrides are applied.
+func LoadConfigurationProfile(
+	ctx context.Context,
+	filePath string,
+	profile string,
+	options ...LoadOption,
+) (*Configuration, error) {
+	settings := &loadSettings{defaultNetwork: true}
+	for _, opt := range options {
+		opt(settings)
+	}
+
+	b, err := ioutil.ReadFile(path.Clean(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to open configuration file %s", err, filePath)
+	}
+
+	b, migration, err := Migrate(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to migrate configuration file %s", err, filePath)
+	}
+
+	if len(profile) > 0 {
+		b, err = applyProfile(b, profile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to apply profile", err)
+		}
+	}
+
+	// To prevent silent erroring, we explicitly
+	// reject any unknown fields.
 	var configRaw Configuration
-	if err := utils.LoadAndParse(filePath, &configRaw); err != nil {
-		return nil, fmt.Errorf("%w: unable to open configuration file", err)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&configRaw); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse configuration file %s", err, filePath)
 	}
 
-	config := populateMissingFields(&configRaw)
+	config := populateMissingFields(&configRaw, settings.defaultNetwork)
 
 	// Get the configuration file directory so we can load all files
 	// relative to the location of the configuration file.
 	fileDir := path.Dir(filePath)
 	modifyFilePaths(config, fileDir)
 
+	if err := loadPrivateKeys(config); err != nil {
+		return nil, fmt.Errorf("%w: unable to load prefunded account private keys", err)
+	}
+
 	if err := assertConfiguration(ctx, config); err != nil {
 		return nil, fmt.Errorf("%w: invalid configuration", err)
 	}
 
+	// The color package already disables colors if stdout is not a
+	// terminal, so we only ever disable them here. NO_COLOR is also
+	// checked by the color package when it is initialized, but we
+	// check it again in case it was set after initialization. Colors
+	// are also disabled when logs are printed as JSON.
+	_, noColor := os.LookupEnv("NO_COLOR")
+	if config.DisableColors || noColor || config.LogFormat == JSONLogFormat {
+		color.NoColor = true
+	}
+
 	color.Cyan(
 		"loaded configuration file: %s\n",
 		filePath,
 	)
+	if len(profile) > 0 {
+		color.Cyan("applied configuration profile: %s\n", profile)
+	}
+	if migration != nil && len(migration.Changes) > 0 {
+		migration.Print(filePath)
+	}
+
+	warnInsecureTLS(config.TLS, config.OnlineURL)
+	if config.Construction != nil {
+		warnInsecureTLS(config.Construction.TLS, config.Construction.OfflineURL)
+	}
+	printWarnings(config)
 
 	if config.LogConfiguration {
-		log.Println(types.PrettyPrintStruct(config))
+		log.Println(types.PrettyPrintStruct(RedactedConfiguration(config)))
 	}
 
 	return config, nil
 }
+
+// PopulateConfiguration returns a copy of config (for example, one
+// constructed in code instead of loaded with LoadConfiguration) with
+// any missing fields populated with defaults and asserts the result,
+// including its network. config is not modified. Unlike
+// LoadConfiguration, a missing network is never populated with
+// EthereumNetwork.
+func PopulateConfiguration(
+	ctx context.Context,
+	config *Configuration,
+) (*Configuration, error) {
+	if config == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	config, err := copyConfiguration(config)
+	if err != nil {
+		return nil, err
+	}
+
+	config = populateMissingFields(config, false)
+	if err := assertConfiguration(ctx, config); err != nil {
+		return nil, fmt.Errorf("%w: invalid configuration", err)
+	}
+
+	if err := AssertNetwork(config); err != nil {
+		return nil, fmt.Errorf("%w: invalid configuration", err)
+	}
+
+	return config, nil
+}
+
+// copyConfiguration returns a deep copy of config, including
+// the fields that are never loaded from a configuration file.
+func copyConfiguration(config *Configuration) (*Configuration, error) {
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal configuration", err)
+	}
+
+	var copied Configuration
+	if err := json.Unmarshal(b, &copied); err != nil {
+		return nil, fmt.Errorf("%w: unable to unmarshal configuration", err)
+	}
+
+	copied.RunID = config.RunID
+	if config.Data != nil {
+		copied.Data.AllowEndpointOverridesSuccess = config.Data.AllowEndpointOverridesSuccess
+		copied.Data.EstimateStorage = config.Data.EstimateStorage
+		copied.Data.StrictStorageEstimate = config.Data.StrictStorageEstimate
+		copied.Data.Resume = config.Data.Resume
+		copied.Data.Fresh = config.Data.Fresh
+	}
+
+	return &copied, nil
+}
+
+// OverrideNetwork overrides the blockchain and/or network of
+// the configured *types.NetworkIdentifier after it has been
+// loaded. A nil blockchain or network is not overridden.
+func OverrideNetwork(config *Configuration, blockchain *string, network *string) error {
+	if blockchain == nil && network == nil {
+		return nil
+	}
+
+	overridden := &types.NetworkIdentifier{}
+	if config.Network != nil {
+		overridden.Blockchain = config.Network.Blockchain
+		overridden.Network = config.Network.Network
+		overridden.SubNetworkIdentifier = config.Network.SubNetworkIdentifier
+	}
+
+	if blockchain != nil {
+		overridden.Blockchain = *blockchain
+	}
+
+	if network != nil {
+		overridden.Network = *network
+	}
+
+	if err := asserter.NetworkIdentifier(overridden); err != nil {
+		return fmt.Errorf("%w: invalid network override", err)
+	}
+
+	config.Network = overridden
+	color.Cyan("overriding network: %s\n", types.PrintStruct(overridden))
+
+	return nil
+}
+
+// unsafePathCharacters matches characters that are
+// replaced when a network is used as a directory name.
+var unsafePathCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// pathElement returns s as a single directory name.
+func pathElement(s string) string {
+	s = unsafePathCharacters.ReplaceAllString(s, "_")
+	if s == "" || s == "." || s == ".." {
+		return strings.Repeat("_", len(s)+1)
+	}
+
+	return s
+}
+
+// NetworkDataDirectory returns the subdirectory of dataDirectory
+// for network (blockchain/network[/sub_network]), so that runs
+// against different networks never share a data directory. An
+// empty dataDirectory (a temporary directory is used) is returned
+// as is.
+func NetworkDataDirectory(dataDirectory string, network *types.NetworkIdentifier) string {
+	if len(dataDirectory) == 0 || network == nil {
+		return dataDirectory
+	}
+
+	elements := []string{
+		dataDirectory,
+		pathElement(network.Blockchain),
+		pathElement(network.Network),
+	}
+	if network.SubNetworkIdentifier != nil {
+		elements = append(elements, pathElement(network.SubNetworkIdentifier.Network))
+	}
+
+	return path.Join(elements...)
+}
+
+// ResolveRelativeIndexes replaces a negative StartIndex or
+// EndConditions.Index (which are relative to tip) with an
+// absolute index using the provided network status. Indexes
+// before genesis are resolved to the genesis index.
+func ResolveRelativeIndexes(
+	config *DataConfiguration,
+	status *types.NetworkStatusResponse,
+) error {
+	resolve := func(name string, index *int64) {
+		if index == nil || *index >= 0 {
+			return
+		}
+
+		relative := *index
+		*index = status.CurrentBlockIdentifier.Index + relative
+		if *index < status.GenesisBlockIdentifier.Index {
+			*index = status.GenesisBlockIdentifier.Index
+		}
+
+		color.Cyan(
+			"resolved %s %d to %d (tip: %d)\n",
+			name,
+			relative,
+			*index,
+			status.CurrentBlockIdentifier.Index,
+		)
+	}
+
+	resolve("start_index", config.StartIndex)
+	if config.EndConditions != nil {
+		resolve("end_conditions.index", config.EndConditions.Index)
+	}
+
+	if config.StartIndex != nil && config.EndConditions != nil &&
+		config.EndConditions.Index != nil &&
+		*config.EndConditions.Index < *config.StartIndex {
+		return fmt.Errorf(
+			"end index %d cannot be less than start index %d",
+			*config.EndConditions.Index,
+			*config.StartIndex,
+		)
+	}
+
+	return nil
+}
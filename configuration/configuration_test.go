@@ -16,6 +16,10 @@ package configuration
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
 	"os/exec"
 	"path"
 	"runtime"
@@ -25,17 +29,25 @@ import (
 	"github.com/coinbase/rosetta-sdk-go/storage/modules"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
 )
 
 var (
-	startIndex         = int64(89)
-	badStartIndex      = int64(-10)
-	goodCoverage       = float64(0.33)
-	badCoverage        = float64(-2)
-	endTip             = false
-	historicalDisabled = false
-	fakeWorkflows      = []*job.Workflow{
+	startIndex             = int64(89)
+	relativeIndex          = int64(-10)
+	lowEndIndex            = int64(10)
+	goodCoverage           = float64(0.33)
+	badCoverage            = float64(-2)
+	endTip                 = false
+	noReconciliations      = uint64(0)
+	reconciliations        = uint64(10)
+	historicalDisabled     = false
+	disabledStatusPort     = uint(0)
+	dataStatusPort         = uint(123)
+	constructionStatusPort = uint(21)
+	fakeWorkflows          = []*job.Workflow{
 		{
 			Name:        string(job.CreateAccount),
 			Concurrency: job.ReservedWorkflowConcurrency,
@@ -57,28 +69,35 @@ var (
 			},
 		},
 	}
+	testDSL      = "create_account(1){\n  blah{\n  }\n}\n\nrequest_funds(1){\n  blah{\n  }\n}\n"
+	invalidDSL   = "create_account(1){\n  blah{\n    x = not_an_action({});\n  }\n}\n"
 	whackyConfig = &Configuration{
 		Network: &types.NetworkIdentifier{
 			Blockchain: "sweet",
 			Network:    "sweeter",
 		},
-		OnlineURL:               "http://hasudhasjkdk",
-		MaxOnlineConnections:    10,
-		HTTPTimeout:             21,
-		MaxRetries:              1000,
-		MaxSyncConcurrency:      12,
-		TipDelay:                1231,
-		MaxReorgDepth:           12,
-		SeenBlockWorkers:        300,
-		SerialBlockWorkers:      200,
-		ErrorStackTraceDisabled: false,
+		OnlineURL:                 "http://hasudhasjkdk",
+		MaxOnlineConnections:      10,
+		HTTPTimeout:               21,
+		MaxRetries:                1000,
+		MaxSyncConcurrency:        12,
+		MaxBlocksPerSecond:        2.5,
+		TipDelay:                  1231,
+		TipPollInterval:           5,
+		MaxReorgDepth:             12,
+		ClockSkewWarningThreshold: 45,
+		SeenBlockWorkers:          300,
+		SerialBlockWorkers:        200,
+		ErrorStackTraceDisabled:   false,
 		Construction: &ConstructionConfiguration{
 			OfflineURL:            "https://ashdjaksdkjshdk",
 			MaxOfflineConnections: 21,
+			HTTPTimeout:           5,
 			StaleDepth:            12,
+			TipDelay:              600,
 			BroadcastLimit:        200,
 			BlockBroadcastLimit:   992,
-			StatusPort:            21,
+			StatusPort:            &constructionStatusPort,
 			Workflows: append(
 				fakeWorkflows,
 				&job.Workflow{
@@ -88,13 +107,27 @@ var (
 			),
 		},
 		Data: &DataConfiguration{
+			HTTPTimeout:                       30,
 			ActiveReconciliationConcurrency:   100,
 			InactiveReconciliationConcurrency: 2938,
 			InactiveReconciliationFrequency:   3,
+			BootstrapBalancesConcurrency:      12,
 			ReconciliationDisabled:            false,
 			HistoricalBalanceDisabled:         &historicalDisabled,
 			StartIndex:                        &startIndex,
-			StatusPort:                        123,
+			StatusPort:                        &dataStatusPort,
+			ProgressLogInterval:               30,
+			FetchRetrySoftLimit:               5,
+			HaltOnReorg:                       true,
+			ReconciliationFailureThreshold:    10,
+			ReconciliationFailureRate:         0.05,
+			BalanceExemptions: []*BalanceExemption{
+				{
+					Account:       &types.AccountIdentifier{Address: "validator"},
+					ExemptionType: types.BalanceGreaterOrEqual,
+				},
+			},
+			BalanceExemptionsCoverage: true,
 			EndConditions: &DataEndConditions{
 				ReconciliationCoverage: &ReconciliationCoverage{
 					Coverage: goodCoverage,
@@ -109,18 +142,15 @@ var (
 	}
 	invalidPrefundedAccounts = &Configuration{
 		Construction: &ConstructionConfiguration{
-			PrefundedAccounts: []*modules.PrefundedAccount{
+			PrefundedAccounts: []*PrefundedAccount{
 				{
-					PrivateKeyHex: "hello",
+					PrefundedAccount: modules.PrefundedAccount{
+						PrivateKeyHex: "hello",
+					},
 				},
 			},
 		},
 	}
-	invalidStartIndex = &Configuration{
-		Data: &DataConfiguration{
-			StartIndex: &badStartIndex,
-		},
-	}
 	multipleEndConditions = &Configuration{
 		Data: &DataConfiguration{
 			EndConditions: &DataEndConditions{
@@ -131,8 +161,9 @@ var (
 	}
 	invalidEndIndex = &Configuration{
 		Data: &DataConfiguration{
+			StartIndex: &startIndex,
 			EndConditions: &DataEndConditions{
-				Index: &badStartIndex,
+				Index: &lowEndIndex,
 			},
 		},
 	}
@@ -149,6 +180,14 @@ var (
 
 func TestLoadConfiguration(t *testing.T) {
 	var (
+		lowPruningDepth             = int64(DefaultMaxReorgDepth - 1)
+		pruningDepth                = int64(DefaultMaxReorgDepth)
+		pruningBlockInterval        = int64(50)
+		invalidPruningBlockInterval = int64(0)
+		defaultJitter               = DefaultRetryJitter
+		noJitter                    = float64(0)
+		invalidJitter               = float64(1.5)
+
 		goodAccountCount = int64(10)
 		badAccountCount  = int64(-10)
 	)
@@ -186,10 +225,70 @@ func TestLoadConfiguration(t *testing.T) {
 				cfg.Construction = &ConstructionConfiguration{
 					OfflineURL:            DefaultURL,
 					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           DefaultTimeout,
+					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
+					BroadcastLimit:        DefaultBroadcastLimit,
+					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
+					StatusPort:            defaultStatusPort(),
+					Workflows:             fakeWorkflows,
+				}
+
+				return cfg
+			}(),
+		},
+		"status server disabled": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows:  fakeWorkflows,
+					StatusPort: &disabledStatusPort,
+				},
+				Data: &DataConfiguration{
+					StatusPort: &disabledStatusPort,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.StatusPort = &disabledStatusPort
+				cfg.Construction = &ConstructionConfiguration{
+					OfflineURL:            DefaultURL,
+					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           DefaultTimeout,
+					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
+					BroadcastLimit:        DefaultBroadcastLimit,
+					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
+					StatusPort:            &disabledStatusPort,
+					Workflows:             fakeWorkflows,
+				}
+
+				return cfg
+			}(),
+		},
+		"inherit http timeout": {
+			provided: &Configuration{
+				HTTPTimeout: 30,
+				Construction: &ConstructionConfiguration{
+					Workflows: fakeWorkflows,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.HTTPTimeout = 30
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.HTTPTimeout = 30
+				cfg.Construction = &ConstructionConfiguration{
+					OfflineURL:            DefaultURL,
+					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           30,
 					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
 					BroadcastLimit:        DefaultBroadcastLimit,
 					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
-					StatusPort:            DefaultStatusPort,
+					StatusPort:            defaultStatusPort(),
 					Workflows:             fakeWorkflows,
 				}
 
@@ -210,10 +309,12 @@ func TestLoadConfiguration(t *testing.T) {
 				cfg.Construction = &ConstructionConfiguration{
 					OfflineURL:            DefaultURL,
 					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           DefaultTimeout,
 					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
 					BroadcastLimit:        DefaultBroadcastLimit,
 					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
-					StatusPort:            DefaultStatusPort,
+					StatusPort:            defaultStatusPort(),
 					Workflows:             fakeWorkflows,
 					ConstructorDSLFile:    "test.ros",
 				}
@@ -221,6 +322,114 @@ func TestLoadConfiguration(t *testing.T) {
 				return cfg
 			}(),
 		},
+		"overwrite missing with inline DSL": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					ConstructorDSL: testDSL,
+				},
+				Data: &DataConfiguration{},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Construction = &ConstructionConfiguration{
+					OfflineURL:            DefaultURL,
+					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           DefaultTimeout,
+					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
+					BroadcastLimit:        DefaultBroadcastLimit,
+					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
+					StatusPort:            defaultStatusPort(),
+					Workflows:             fakeWorkflows,
+					ConstructorDSL:        testDSL,
+				}
+
+				return cfg
+			}(),
+		},
+		"workflows and inline DSL": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows:      fakeWorkflows,
+					ConstructorDSL: testDSL,
+				},
+			},
+			err: true,
+		},
+		"DSL file and inline DSL": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					ConstructorDSLFile: "test.ros",
+					ConstructorDSL:     testDSL,
+				},
+			},
+			err: true,
+		},
+		"negative broadcast tps": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows:    fakeWorkflows,
+					BroadcastTPS: -1,
+				},
+			},
+			err: true,
+		},
+		"negative confirmation depth": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows:         fakeWorkflows,
+					ConfirmationDepth: -1,
+				},
+			},
+			err: true,
+		},
+		"stale depth not greater than confirmation depth": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows:         fakeWorkflows,
+					ConfirmationDepth: 30,
+					StaleDepth:        30,
+				},
+			},
+			err: true,
+		},
+		"negative construction tip delay": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows: fakeWorkflows,
+					TipDelay:  -1,
+				},
+			},
+			err: true,
+		},
+		"invalid allowed signer": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows:      fakeWorkflows,
+					AllowedSigners: []*types.AccountIdentifier{{}},
+				},
+			},
+			err: true,
+		},
+		"negative minimum balance confirmations": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows:                   fakeWorkflows,
+					MinimumBalanceConfirmations: -1,
+				},
+			},
+			err: true,
+		},
+		"invalid inline DSL": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					ConstructorDSL: invalidDSL,
+				},
+			},
+			err: true,
+		},
 		"transfer workflow": {
 			provided: &Configuration{
 				Construction: &ConstructionConfiguration{
@@ -240,10 +449,12 @@ func TestLoadConfiguration(t *testing.T) {
 				cfg.Construction = &ConstructionConfiguration{
 					OfflineURL:            DefaultURL,
 					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           DefaultTimeout,
 					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
 					BroadcastLimit:        DefaultBroadcastLimit,
 					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
-					StatusPort:            DefaultStatusPort,
+					StatusPort:            defaultStatusPort(),
 					Workflows: []*job.Workflow{
 						{
 							Name:        "transfer",
@@ -255,6 +466,71 @@ func TestLoadConfiguration(t *testing.T) {
 				return cfg
 			}(),
 		},
+		"workflow concurrency override": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows: []*job.Workflow{
+						{
+							Name:        "transfer",
+							Concurrency: 10,
+						},
+					},
+					WorkflowConcurrency: map[string]int{"transfer": 3},
+				},
+				Data: &DataConfiguration{},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Construction = &ConstructionConfiguration{
+					OfflineURL:            DefaultURL,
+					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           DefaultTimeout,
+					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
+					BroadcastLimit:        DefaultBroadcastLimit,
+					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
+					StatusPort:            defaultStatusPort(),
+					Workflows: []*job.Workflow{
+						{
+							Name:        "transfer",
+							Concurrency: 3,
+						},
+					},
+					WorkflowConcurrency: map[string]int{"transfer": 3},
+				}
+
+				return cfg
+			}(),
+		},
+		"unknown workflow concurrency override": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					ConstructorDSL:      testDSL,
+					WorkflowConcurrency: map[string]int{"transfr": 3},
+				},
+			},
+			err: true,
+		},
+		"reserved workflow concurrency override": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					ConstructorDSL:      testDSL,
+					WorkflowConcurrency: map[string]int{string(job.CreateAccount): 2},
+				},
+			},
+			err: true,
+		},
+		"invalid workflow concurrency override": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					ConstructorDSL:      testDSL,
+					WorkflowConcurrency: map[string]int{string(job.RequestFunds): 0},
+				},
+			},
+			err: true,
+		},
 		"invalid network": {
 			provided: invalidNetwork,
 			err:      true,
@@ -263,137 +539,1761 @@ func TestLoadConfiguration(t *testing.T) {
 			provided: invalidPrefundedAccounts,
 			err:      true,
 		},
-		"invalid start index": {
-			provided: invalidStartIndex,
-			err:      true,
+		"relative indexes": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					StartIndex: &relativeIndex,
+					EndConditions: &DataEndConditions{
+						Index: &relativeIndex,
+					},
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.StartIndex = &relativeIndex
+				cfg.Data.EndConditions = &DataEndConditions{
+					Index: &relativeIndex,
+				}
+
+				return cfg
+			}(),
 		},
 		"invalid end index": {
 			provided: invalidEndIndex,
 			err:      true,
 		},
-		"invalid reconciliation coverage": {
-			provided: invalidReconciliationCoverage,
-			err:      true,
-		},
-		"invalid reconciliation coverage (reconciliation disabled)": {
+		"invalid block hash": {
 			provided: &Configuration{
 				Data: &DataConfiguration{
-					ReconciliationDisabled: true,
 					EndConditions: &DataEndConditions{
-						ReconciliationCoverage: &ReconciliationCoverage{
-							Coverage: goodCoverage,
-						},
+						BlockHash: types.String(""),
 					},
 				},
 			},
 			err: true,
 		},
-		"invalid reconciliation coverage (balance tracking disabled)": {
+		"invalid block hash index (without block hash)": {
 			provided: &Configuration{
 				Data: &DataConfiguration{
-					BalanceTrackingDisabled: true,
 					EndConditions: &DataEndConditions{
-						ReconciliationCoverage: &ReconciliationCoverage{
-							Coverage: goodCoverage,
-						},
+						BlockHashIndex: types.Int64(10),
 					},
 				},
 			},
 			err: true,
 		},
-		"invalid reconciliation coverage (ignore reconciliation error)": {
+		"invalid block hash index (negative)": {
 			provided: &Configuration{
 				Data: &DataConfiguration{
-					IgnoreReconciliationError: true,
 					EndConditions: &DataEndConditions{
-						ReconciliationCoverage: &ReconciliationCoverage{
-							Coverage: goodCoverage,
-						},
+						BlockHash:      types.String("block 10"),
+						BlockHashIndex: types.Int64(-1),
 					},
 				},
 			},
 			err: true,
 		},
-		"valid reconciliation coverage (with account count)": {
+		"invalid static tip": {
 			provided: &Configuration{
 				Data: &DataConfiguration{
-					EndConditions: &DataEndConditions{
-						ReconciliationCoverage: &ReconciliationCoverage{
-							Coverage:     goodCoverage,
-							AccountCount: &goodAccountCount,
-							Index:        &goodAccountCount,
-						},
-					},
+					StaticTip: types.Int64(-1),
 				},
 			},
-			expected: func() *Configuration {
-				cfg := DefaultConfiguration()
-				cfg.SeenBlockWorkers = runtime.NumCPU()
-				cfg.SerialBlockWorkers = runtime.NumCPU()
-				cfg.Data.EndConditions = &DataEndConditions{
-					ReconciliationCoverage: &ReconciliationCoverage{
-						Coverage:     goodCoverage,
-						AccountCount: &goodAccountCount,
-						Index:        &goodAccountCount,
-					},
-				}
-
-				return cfg
-			}(),
+			err: true,
 		},
-		"invalid reconciliation coverage (with account count)": {
+		"invalid static tip (with tip long poll)": {
 			provided: &Configuration{
 				Data: &DataConfiguration{
-					EndConditions: &DataEndConditions{
-						ReconciliationCoverage: &ReconciliationCoverage{
-							Coverage:     goodCoverage,
-							AccountCount: &badAccountCount,
-						},
-					},
+					StaticTip:                types.Int64(10),
+					TipLongPoll:              true,
+					TipLongPollNotFoundCodes: []int32{404},
 				},
 			},
 			err: true,
 		},
-		"invalid reconciliation coverage (with index)": {
+		"invalid max errors": {
 			provided: &Configuration{
 				Data: &DataConfiguration{
-					EndConditions: &DataEndConditions{
-						ReconciliationCoverage: &ReconciliationCoverage{
-							Coverage: goodCoverage,
-							Index:    &badAccountCount,
-						},
-					},
+					MaxErrors: -1,
 				},
 			},
 			err: true,
 		},
-		"empty workflows": {
+		"invalid reconciliations": {
 			provided: &Configuration{
-				Construction: &ConstructionConfiguration{
-					Workflows: []*job.Workflow{},
+				Data: &DataConfiguration{
+					EndConditions: &DataEndConditions{
+						Reconciliations: &noReconciliations,
+					},
 				},
 			},
 			err: true,
 		},
-		"non-existent dsl file": {
+		"invalid reconciliations (reconciliation disabled)": {
 			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationDisabled: true,
+					EndConditions: &DataEndConditions{
+						Reconciliations: &reconciliations,
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid reconciliations (balance tracking disabled)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BalanceTrackingDisabled: true,
+					EndConditions: &DataEndConditions{
+						Reconciliations: &reconciliations,
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid genesis index": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					GenesisIndex: types.Int64(-1),
+				},
+			},
+			err: true,
+		},
+		"invalid reconciliation currency": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationCurrencies: []*types.Currency{
+						{Symbol: "BTC", Decimals: 8},
+						{Symbol: "", Decimals: 18},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid storage estimate samples": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					StorageEstimateSamples: -1,
+				},
+			},
+			err: true,
+		},
+		"invalid results output format": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ResultsOutputFormat: "xml",
+				},
+			},
+			err: true,
+		},
+		"invalid excluded operation type": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ExcludedOperationTypes: []string{"Reward", ""},
+				},
+			},
+			err: true,
+		},
+		"invalid log format": {
+			provided: &Configuration{
+				LogFormat: "xml",
+			},
+			err: true,
+		},
+		"invalid max block size": {
+			provided: &Configuration{
+				MaxBlockSizeBytes: -1,
+			},
+			err: true,
+		},
+		"invalid max blocks per second": {
+			provided: &Configuration{
+				MaxBlocksPerSecond: -1,
+			},
+			err: true,
+		},
+		"tip poll interval too large": {
+			provided: &Configuration{
+				TipPollInterval: MaxTipPollInterval + 1,
+			},
+			err: true,
+		},
+		"negative tip poll interval": {
+			provided: &Configuration{
+				TipPollInterval: -1,
+			},
+			err: true,
+		},
+		"archive url with historical balance disabled": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ArchiveURL:                "http://archive:8080",
+					HistoricalBalanceDisabled: types.Bool(true),
+				},
+			},
+			err: true,
+		},
+		"comparison url same as online url": {
+			provided: &Configuration{
+				OnlineURL:     "http://localhost:8080",
+				ComparisonURL: "http://localhost:8080",
+			},
+			err: true,
+		},
+		"invalid block fetch concurrency": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BlockFetchConcurrency: -1,
+				},
+			},
+			err: true,
+		},
+		"tip long poll without not found codes": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					TipLongPoll: true,
+				},
+			},
+			err: true,
+		},
+		"invalid endpoint override": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					EndpointOverrides: map[string]string{
+						"account/balance": "balances.json",
+					},
+				},
+			},
+			err: true,
+		},
+		"missing endpoint override fixtures": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					EndpointOverrides: map[string]string{
+						"/account/balance": "",
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid reconciliation coverage": {
+			provided: invalidReconciliationCoverage,
+			err:      true,
+		},
+		"invalid reconciliation coverage (reconciliation disabled)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationDisabled: true,
+					EndConditions: &DataEndConditions{
+						ReconciliationCoverage: &ReconciliationCoverage{
+							Coverage: goodCoverage,
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"acceptable discrepancy": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					AcceptableDiscrepancies: []*AcceptableDiscrepancy{
+						{
+							Account:  &types.AccountIdentifier{Address: "system"},
+							Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+							MaxDelta: "5",
+						},
+					},
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.AcceptableDiscrepancies = []*AcceptableDiscrepancy{
+					{
+						Account:  &types.AccountIdentifier{Address: "system"},
+						Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+						MaxDelta: "5",
+					},
+				}
+
+				return cfg
+			}(),
+		},
+		"invalid acceptable discrepancy (negative max delta)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					AcceptableDiscrepancies: []*AcceptableDiscrepancy{
+						{
+							Account:  &types.AccountIdentifier{Address: "system"},
+							Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+							MaxDelta: "-5",
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid acceptable discrepancy (missing currency)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					AcceptableDiscrepancies: []*AcceptableDiscrepancy{
+						{
+							Account:  &types.AccountIdentifier{Address: "system"},
+							MaxDelta: "5",
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"balance exemption": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BalanceExemptions: []*BalanceExemption{
+						{
+							SubAccountAddress: types.String("staking"),
+							ExemptionType:     types.BalanceGreaterOrEqual,
+						},
+					},
+					BalanceExemptionsCoverage: true,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.BalanceExemptions = []*BalanceExemption{
+					{
+						SubAccountAddress: types.String("staking"),
+						ExemptionType:     types.BalanceGreaterOrEqual,
+					},
+				}
+				cfg.Data.BalanceExemptionsCoverage = true
+
+				return cfg
+			}(),
+		},
+		"invalid balance exemption (exemption type)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BalanceExemptions: []*BalanceExemption{
+						{
+							Currency:      &types.Currency{Symbol: "BTC", Decimals: 8},
+							ExemptionType: "greater",
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid balance exemption (matches all accounts)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BalanceExemptions: []*BalanceExemption{
+						{
+							ExemptionType: types.BalanceDynamic,
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid balance exemption (account)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BalanceExemptions: []*BalanceExemption{
+						{
+							Account:       &types.AccountIdentifier{},
+							ExemptionType: types.BalanceDynamic,
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid reconciliation failure threshold": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationFailureThreshold: -1,
+				},
+			},
+			err: true,
+		},
+		"invalid reconciliation failure rate": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationFailureRate: 1,
+				},
+			},
+			err: true,
+		},
+		"reconciliation failure threshold with ignore reconciliation error": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationFailureThreshold: 10,
+					IgnoreReconciliationError:      true,
+				},
+			},
+			err: true,
+		},
+		"log reconciliations with reconciliation disabled": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationDisabled: true,
+					LogReconciliations:     true,
+				},
+			},
+			err: true,
+		},
+		"invalid reconciliation coverage (balance tracking disabled)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BalanceTrackingDisabled: true,
+					EndConditions: &DataEndConditions{
+						ReconciliationCoverage: &ReconciliationCoverage{
+							Coverage: goodCoverage,
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"monitor mode without end conditions": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					MonitorAfterEndConditions: true,
+				},
+			},
+			err: true,
+		},
+		"monitor mode with end index": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					MonitorAfterEndConditions: true,
+					EndConditions: &DataEndConditions{
+						Index: &startIndex,
+					},
+				},
+			},
+			err: true,
+		},
+		"monitor mode with shared results file": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					MonitorAfterEndConditions: true,
+					MonitorResultsOutputFile:  "/tmp/results.json",
+					ResultsOutputFile:         "/tmp/results.json",
+					EndConditions: &DataEndConditions{
+						Tip: types.Bool(true),
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid reconciliation coverage (ignore reconciliation error)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					IgnoreReconciliationError: true,
+					EndConditions: &DataEndConditions{
+						ReconciliationCoverage: &ReconciliationCoverage{
+							Coverage: goodCoverage,
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"valid reconciliation coverage (with account count)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					EndConditions: &DataEndConditions{
+						ReconciliationCoverage: &ReconciliationCoverage{
+							Coverage:     goodCoverage,
+							AccountCount: &goodAccountCount,
+							Index:        &goodAccountCount,
+						},
+					},
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.EndConditions = &DataEndConditions{
+					ReconciliationCoverage: &ReconciliationCoverage{
+						Coverage:     goodCoverage,
+						AccountCount: &goodAccountCount,
+						Index:        &goodAccountCount,
+					},
+				}
+
+				return cfg
+			}(),
+		},
+		"invalid reconciliation coverage (with account count)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					EndConditions: &DataEndConditions{
+						ReconciliationCoverage: &ReconciliationCoverage{
+							Coverage:     goodCoverage,
+							AccountCount: &badAccountCount,
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid reconciliation coverage (with index)": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					EndConditions: &DataEndConditions{
+						ReconciliationCoverage: &ReconciliationCoverage{
+							Coverage: goodCoverage,
+							Index:    &badAccountCount,
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"empty workflows": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows: []*job.Workflow{},
+				},
+			},
+			err: true,
+		},
+		"non-existent dsl file": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					ConstructorDSLFile: "blah.ros",
+				},
+			},
+			err: true,
+		},
+		"tls cert without key": {
+			provided: &Configuration{
+				TLS: &TLSConfiguration{
+					CertFile: "client.crt",
+				},
+			},
+			err: true,
+		},
+		"tls client cert shorthand without key": {
+			provided: &Configuration{
+				TLSClientCertFile: "client.crt",
+			},
+			err: true,
+		},
+		"unloadable tls client cert shorthand": {
+			provided: &Configuration{
+				TLSClientCertFile: "client.crt",
+				TLSClientKeyFile:  "client.key",
+			},
+			err: true,
+		},
+		"conflicting tls shorthand": {
+			provided: &Configuration{
+				TLS: &TLSConfiguration{
+					CAFile: "ca.crt",
+				},
+				TLSCAFile: "other-ca.crt",
+			},
+			err: true,
+		},
+		"non-existent tls ca file": {
+			provided: &Configuration{
+				TLS: &TLSConfiguration{
+					CAFile: "ca.crt",
+				},
+			},
+			err: true,
+		},
+		"invalid construction tls": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows: fakeWorkflows,
+					TLS: &TLSConfiguration{
+						KeyFile: "client.key",
+					},
+				},
+			},
+			err: true,
+		},
+		"faucet defaults": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows: fakeWorkflows,
+					Faucet: &FaucetConfiguration{
+						URL:             "http://localhost:9000/fund",
+						RequestTemplate: `{"address":"{{address}}"}`,
+					},
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Construction = &ConstructionConfiguration{
+					OfflineURL:            DefaultURL,
+					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           DefaultTimeout,
+					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
+					BroadcastLimit:        DefaultBroadcastLimit,
+					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
+					StatusPort:            defaultStatusPort(),
+					Workflows:             fakeWorkflows,
+					Faucet: &FaucetConfiguration{
+						URL:             "http://localhost:9000/fund",
+						RequestTemplate: `{"address":"{{address}}"}`,
+						Cooldown:        DefaultFaucetCooldown,
+					},
+				}
+
+				return cfg
+			}(),
+		},
+		"invalid faucet url": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows: fakeWorkflows,
+					Faucet:    &FaucetConfiguration{},
+				},
+			},
+			err: true,
+		},
+		"invalid faucet response matcher": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows: fakeWorkflows,
+					Faucet: &FaucetConfiguration{
+						URL:             "http://localhost:9000/fund",
+						ResponseMatcher: "(",
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid faucet amount": {
+			provided: &Configuration{
+				Construction: &ConstructionConfiguration{
+					Workflows: fakeWorkflows,
+					Faucet: &FaucetConfiguration{
+						URL: "http://localhost:9000/fund",
+						Amount: &types.Amount{
+							Value: "ten",
+							Currency: &types.Currency{
+								Symbol:   "BTC",
+								Decimals: 8,
+							},
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid block audit sample count": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					EndOfRunBlockAudit: &BlockAuditConfiguration{
+						SampleCount: 0,
+					},
+				},
+			},
+			err: true,
+		},
+		"pruning depth below max reorg depth": {
+			provided: &Configuration{
+				MaxReorgDepth: 100,
+				Data: &DataConfiguration{
+					PruningDepth: &lowPruningDepth,
+				},
+			},
+			err: true,
+		},
+		"invalid pruning block interval": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					PruningBlockInterval: &invalidPruningBlockInterval,
+				},
+			},
+			err: true,
+		},
+		"pruning depth and block interval": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					PruningDepth:         &pruningDepth,
+					PruningBlockInterval: &pruningBlockInterval,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.PruningDepth = &pruningDepth
+				cfg.Data.PruningBlockInterval = &pruningBlockInterval
+
+				return cfg
+			}(),
+		},
+		"exempt accounts list": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ExemptAccountsList: []*types.AccountCurrency{
+						{
+							Account:  &types.AccountIdentifier{Address: "addr1"},
+							Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+						},
+					},
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.ExemptAccountsList = []*types.AccountCurrency{
+					{
+						Account:  &types.AccountIdentifier{Address: "addr1"},
+						Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+					},
+				}
+
+				return cfg
+			}(),
+		},
+		"exempt currency": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ExemptAccountsList: []*types.AccountCurrency{
+						{
+							Currency: &types.Currency{Symbol: "RBS", Decimals: 18},
+						},
+					},
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.ExemptAccountsList = []*types.AccountCurrency{
+					{
+						Currency: &types.Currency{Symbol: "RBS", Decimals: 18},
+					},
+				}
+
+				return cfg
+			}(),
+		},
+		"bootstrap balances list": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BootstrapBalancesList: []*modules.BootstrapBalance{
+						{
+							Account:  &types.AccountIdentifier{Address: "addr1"},
+							Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+							Value:    "100",
+						},
+					},
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.BootstrapBalancesList = []*modules.BootstrapBalance{
+					{
+						Account:  &types.AccountIdentifier{Address: "addr1"},
+						Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+						Value:    "100",
+					},
+				}
+
+				return cfg
+			}(),
+		},
+		"bootstrap balances file and list": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BootstrapBalances: FilePaths{"bootstrap_balances.json"},
+					BootstrapBalancesList: []*modules.BootstrapBalance{
+						{
+							Account:  &types.AccountIdentifier{Address: "addr1"},
+							Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+							Value:    "100",
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"non-integer bootstrap balance": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BootstrapBalancesList: []*modules.BootstrapBalance{
+						{
+							Account:  &types.AccountIdentifier{Address: "addr1"},
+							Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+							Value:    "1.5",
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"zero bootstrap balance": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					BootstrapBalancesList: []*modules.BootstrapBalance{
+						{
+							Account:  &types.AccountIdentifier{Address: "addr1"},
+							Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+							Value:    "0",
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid exempt accounts list": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ExemptAccountsList: []*types.AccountCurrency{
+						{
+							Account: &types.AccountIdentifier{Address: "addr1"},
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"invalid exempt currency": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ExemptAccountsList: []*types.AccountCurrency{
+						{
+							Currency: &types.Currency{Decimals: 18},
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		"reconciliation failure hook defaults": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationFailureHook: &ReconciliationFailureHookConfiguration{
+						Path: "/usr/local/bin/hook.sh",
+					},
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.ReconciliationFailureHook = &ReconciliationFailureHookConfiguration{
+					Path:           "/usr/local/bin/hook.sh",
+					Timeout:        DefaultReconciliationFailureHookTimeout,
+					MaxConcurrency: DefaultReconciliationFailureHookMaxConcurrency,
+				}
+
+				return cfg
+			}(),
+		},
+		"missing reconciliation failure hook path": {
+			provided: &Configuration{
+				Data: &DataConfiguration{
+					ReconciliationFailureHook: &ReconciliationFailureHookConfiguration{},
+				},
+			},
+			err: true,
+		},
+		"retry backoff defaults": {
+			provided: &Configuration{
+				RetryBackoff: &RetryBackoffConfiguration{
+					MaxIntervalMs: 10000,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.RetryBackoff = &RetryBackoffConfiguration{
+					InitialIntervalMs: DefaultRetryInitialIntervalMs,
+					MaxIntervalMs:     10000,
+					Multiplier:        DefaultRetryMultiplier,
+					Jitter:            &defaultJitter,
+				}
+
+				return cfg
+			}(),
+		},
+		"retry backoff without jitter": {
+			provided: &Configuration{
+				RetryBackoff: &RetryBackoffConfiguration{
+					Jitter: &noJitter,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.RetryBackoff = &RetryBackoffConfiguration{
+					InitialIntervalMs: DefaultRetryInitialIntervalMs,
+					MaxIntervalMs:     DefaultRetryMaxIntervalMs,
+					Multiplier:        DefaultRetryMultiplier,
+					Jitter:            &noJitter,
+				}
+
+				return cfg
+			}(),
+		},
+		"invalid retry backoff interval": {
+			provided: &Configuration{
+				RetryBackoff: &RetryBackoffConfiguration{
+					InitialIntervalMs: 1000,
+					MaxIntervalMs:     100,
+				},
+			},
+			err: true,
+		},
+		"invalid retry backoff multiplier": {
+			provided: &Configuration{
+				RetryBackoff: &RetryBackoffConfiguration{
+					Multiplier: 0.5,
+				},
+			},
+			err: true,
+		},
+		"invalid retry backoff jitter": {
+			provided: &Configuration{
+				RetryBackoff: &RetryBackoffConfiguration{
+					Jitter: &invalidJitter,
+				},
+			},
+			err: true,
+		},
+		"storage defaults": {
+			provided: &Configuration{
+				Storage: &StorageConfiguration{
+					MaxCommitBatchBlocks: 32,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Storage = &StorageConfiguration{
+					MaxCommitBatchBlocks:     32,
+					CommitLatencyThresholdMs: DefaultCommitLatencyThresholdMs,
+				}
+
+				return cfg
+			}(),
+		},
+		"invalid max commit batch blocks": {
+			provided: &Configuration{
+				Storage: &StorageConfiguration{
+					MaxCommitBatchBlocks: -1,
+				},
+			},
+			err: true,
+		},
+		"empty header name": {
+			provided: &Configuration{
+				Headers: map[string]string{
+					" ": "value",
+				},
+			},
+			err: true,
+		},
+		"missing baseline file": {
+			provided: &Configuration{
+				Baseline: &BaselineConfiguration{},
+			},
+			err: true,
+		},
+		"unsupported proxy scheme": {
+			provided: &Configuration{
+				ProxyURL: "ftp://proxy.example.com",
+			},
+			err: true,
+		},
+		"empty request id header name": {
+			provided: &Configuration{
+				RequestIDHeader: &RequestIDHeaderConfiguration{
+					Prefix: "test-",
+				},
+			},
+			err: true,
+		},
+		"default request id prefix": {
+			provided: &Configuration{
+				RequestIDHeader: &RequestIDHeaderConfiguration{
+					Name: "X-Request-ID",
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.RequestIDHeader = &RequestIDHeaderConfiguration{
+					Name:   "X-Request-ID",
+					Prefix: DefaultRequestIDPrefix,
+				}
+
+				return cfg
+			}(),
+		},
+		"construction inherits tls": {
+			provided: &Configuration{
+				TLS: &TLSConfiguration{
+					InsecureSkipVerify: true,
+				},
 				Construction: &ConstructionConfiguration{
-					ConstructorDSLFile: "blah.ros",
+					Workflows: fakeWorkflows,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.TLS = &TLSConfiguration{
+					InsecureSkipVerify: true,
+				}
+				cfg.Construction = &ConstructionConfiguration{
+					OfflineURL:            DefaultURL,
+					MaxOfflineConnections: DefaultMaxOfflineConnections,
+					HTTPTimeout:           DefaultTimeout,
+					StaleDepth:            DefaultStaleDepth,
+					TipDelay:              DefaultTipDelay,
+					BroadcastLimit:        DefaultBroadcastLimit,
+					BlockBroadcastLimit:   DefaultBlockBroadcastLimit,
+					StatusPort:            defaultStatusPort(),
+					Workflows:             fakeWorkflows,
+					TLS: &TLSConfiguration{
+						InsecureSkipVerify: true,
+					},
+				}
+
+				return cfg
+			}(),
+		},
+		"multiple end conditions": {
+			provided: multipleEndConditions,
+			expected: func() *Configuration {
+				def := DefaultConfiguration()
+				def.SeenBlockWorkers = runtime.NumCPU()
+				def.SerialBlockWorkers = runtime.NumCPU()
+				def.Data.EndConditions = multipleEndConditions.Data.EndConditions
+
+				return def
+			}(),
+			err: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			filePath := path.Join(dir, "test.json")
+			err = utils.SerializeAndWrite(filePath, test.provided)
+			assert.NoError(t, err)
+
+			// Copy test.ros to temp dir
+			cmd := exec.Command("cp", "testdata/test.ros", path.Join(dir, "test.ros"))
+			assert.NoError(t, cmd.Run())
+
+			// Check if expected fields populated
+			config, err := LoadConfiguration(context.Background(), filePath)
+			if test.err {
+				assert.Error(t, err)
+				assert.Nil(t, config)
+			} else {
+				assert.NoError(t, err)
+
+				// Ensure test.ros expected file path is right
+				if test.expected.Construction != nil && len(test.expected.Construction.ConstructorDSLFile) > 0 {
+					test.expected.Construction.ConstructorDSLFile = path.Join(dir, test.expected.Construction.ConstructorDSLFile)
+				}
+
+				// Configuration files are always migrated
+				// to the current version.
+				test.expected.ConfigVersion = CurrentConfigVersion
+				assert.Equal(t, test.expected, config)
+			}
+		})
+	}
+}
+
+func TestLoadConfigurationMissingNetwork(t *testing.T) {
+	var tests = map[string]struct {
+		defaultNetwork bool
+		overrides      bool
+
+		expected *Configuration
+		err      error
+	}{
+		"default network not allowed": {
+			err: ErrMissingNetwork,
+		},
+		"default network not allowed with overrides": {
+			overrides: true,
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.Network = &types.NetworkIdentifier{
+					Blockchain: "Bitcoin",
+					Network:    "Testnet3",
+				}
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+
+				return cfg
+			}(),
+		},
+		"default network allowed": {
+			defaultNetwork: true,
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+
+				return cfg
+			}(),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			filePath := path.Join(dir, "test.json")
+			assert.NoError(t, utils.SerializeAndWrite(filePath, &Configuration{}))
+
+			config, err := LoadConfiguration(
+				context.Background(),
+				filePath,
+				WithDefaultNetwork(test.defaultNetwork),
+			)
+			assert.NoError(t, err)
+
+			if test.overrides {
+				blockchain, network := "Bitcoin", "Testnet3"
+				assert.NoError(t, OverrideNetwork(config, &blockchain, &network))
+			}
+
+			err = AssertNetwork(config)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, config)
+		})
+	}
+}
+
+func TestApplyConfirmationDepth(t *testing.T) {
+	var tests = map[string]struct {
+		confirmationDepth int64
+
+		expectedActions []*job.Action
+	}{
+		"not populated": {
+			expectedActions: []*job.Action{},
+		},
+		"populated": {
+			confirmationDepth: 2,
+			expectedActions: []*job.Action{
+				{
+					Type:       job.SetVariable,
+					Input:      `"2"`,
+					OutputPath: "blah.confirmation_depth",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := &ConstructionConfiguration{
+				ConfirmationDepth: test.confirmationDepth,
+				Workflows: []*job.Workflow{
+					{
+						Name:        "transfer",
+						Concurrency: 1,
+						Scenarios: []*job.Scenario{
+							{
+								Name:    "blah",
+								Actions: []*job.Action{},
+							},
+						},
+					},
+				},
+			}
+
+			applyConfirmationDepth(config)
+			assert.Equal(t, test.expectedActions, config.Workflows[0].Scenarios[0].Actions)
+		})
+	}
+}
+
+func TestCompileConstructorDSL(t *testing.T) {
+	var tests = map[string]struct {
+		contents string
+
+		expected    []*job.Workflow
+		expectedErr string
+	}{
+		"valid": {
+			contents: testDSL,
+			expected: fakeWorkflows,
+		},
+		"syntax error": {
+			contents:    invalidDSL,
+			expectedErr: "line 3",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			workflows, err := compileConstructorDSL(context.Background(), test.contents)
+			if len(test.expectedErr) > 0 {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				assert.Nil(t, workflows)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, workflows)
+			}
+		})
+	}
+}
+
+func TestNewConstructionConfiguration(t *testing.T) {
+	var tests = map[string]struct {
+		contents      string
+		endConditions map[string]int
+
+		expectedErr string
+	}{
+		"valid": {
+			contents:      testDSL,
+			endConditions: map[string]int{"create_account": 10},
+		},
+		"syntax error": {
+			contents:    invalidDSL,
+			expectedErr: "line 3",
+		},
+		"unknown workflow": {
+			contents:      testDSL,
+			endConditions: map[string]int{"transfer": 10},
+			expectedErr:   "end condition workflow transfer is not defined",
+		},
+		"invalid count": {
+			contents:      testDSL,
+			endConditions: map[string]int{"create_account": 0},
+			expectedErr:   "end condition count for create_account must be > 0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			assert.NoError(t, ioutil.WriteFile(
+				path.Join(dir, "workflows.ros"),
+				[]byte(test.contents),
+				os.FileMode(utils.DefaultFilePermissions),
+			))
+
+			config, err := NewConstructionConfiguration(
+				context.Background(),
+				dir,
+				"http://offline:8080",
+				"workflows.ros",
+				test.endConditions,
+			)
+			if len(test.expectedErr) > 0 {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				assert.Nil(t, config)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "http://offline:8080", config.OfflineURL)
+			assert.Equal(t, "workflows.ros", config.ConstructorDSLFile)
+			assert.Equal(t, test.endConditions, config.EndConditions)
+			assert.Equal(t, int64(DefaultStaleDepth), config.StaleDepth)
+			assert.Nil(t, config.Workflows)
+		})
+	}
+}
+
+func TestPopulateTLSShorthand(t *testing.T) {
+	var tests = map[string]struct {
+		provided *Configuration
+
+		expected *TLSConfiguration
+	}{
+		"no shorthand": {
+			provided: &Configuration{},
+		},
+		"shorthand only": {
+			provided: &Configuration{
+				TLSClientCertFile: "client.crt",
+				TLSClientKeyFile:  "client.key",
+				TLSCAFile:         "ca.crt",
+			},
+			expected: &TLSConfiguration{
+				CAFile:   "ca.crt",
+				CertFile: "client.crt",
+				KeyFile:  "client.key",
+			},
+		},
+		"shorthand and tls": {
+			provided: &Configuration{
+				TLS: &TLSConfiguration{
+					CAFile:             "ca.crt",
+					InsecureSkipVerify: true,
 				},
+				TLSClientCertFile: "client.crt",
+				TLSClientKeyFile:  "client.key",
+			},
+			expected: &TLSConfiguration{
+				CAFile:             "ca.crt",
+				CertFile:           "client.crt",
+				KeyFile:            "client.key",
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			populateTLSShorthand(test.provided)
+			assert.Equal(t, test.expected, test.provided.TLS)
+			assert.NoError(t, assertTLSShorthand(test.provided))
+		})
+	}
+}
+
+func TestRedactedConfiguration(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Headers = map[string]string{
+		"Authorization": "Bearer secret",
+		"X-Api-Key":     "secret",
+		"X-Tenant-ID":   "tenant-1",
+	}
+
+	redacted := RedactedConfiguration(config)
+	assert.Equal(t, map[string]string{
+		"Authorization": redactedValue,
+		"X-Api-Key":     redactedValue,
+		"X-Tenant-ID":   "tenant-1",
+	}, redacted.Headers)
+
+	// The original configuration should not be modified.
+	assert.Equal(t, "Bearer secret", config.Headers["Authorization"])
+	assert.Equal(t, config.OnlineURL, redacted.OnlineURL)
+}
+
+func TestRedactedConfigurationFaucet(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Construction = &ConstructionConfiguration{
+		Faucet: &FaucetConfiguration{
+			URL:        "http://faucet",
+			AuthHeader: "Bearer secret",
+		},
+	}
+
+	redacted := RedactedConfiguration(config)
+	assert.Equal(t, redactedValue, redacted.Construction.Faucet.AuthHeader)
+	assert.Equal(t, "http://faucet", redacted.Construction.Faucet.URL)
+
+	// The original configuration should not be modified.
+	assert.Equal(t, "Bearer secret", config.Construction.Faucet.AuthHeader)
+}
+
+func TestRedactedConfigurationPrivateKeys(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Construction = &ConstructionConfiguration{
+		PrefundedAccounts: []*PrefundedAccount{
+			{
+				PrefundedAccount: modules.PrefundedAccount{PrivateKeyHex: "abcd"},
+			},
+			{
+				PrefundedAccount: modules.PrefundedAccount{PrivateKeyHex: "abcd"},
+				PrivateKeyEnv:    "PRIVATE_KEY",
+			},
+		},
+	}
+
+	redacted := RedactedConfiguration(config)
+	for _, account := range redacted.Construction.PrefundedAccounts {
+		assert.Equal(t, redactedValue, account.PrivateKeyHex)
+	}
+	assert.Equal(t, "PRIVATE_KEY", redacted.Construction.PrefundedAccounts[1].PrivateKeyEnv)
+
+	// The original configuration should not be modified.
+	assert.Equal(t, "abcd", config.Construction.PrefundedAccounts[0].PrivateKeyHex)
+}
+
+func TestLoadPrivateKeys(t *testing.T) {
+	const (
+		envName   = "ROSETTA_CLI_TEST_PRIVATE_KEY"
+		keyFile   = "key.txt"
+		privKey   = "e3b0c44298fc1c149afbf4c8996fb924"
+		accountID = "addr1"
+	)
+
+	var tests = map[string]struct {
+		account *PrefundedAccount
+		env     *string
+		file    *string
+
+		expectedErr string
+	}{
+		"privkey": {
+			account: &PrefundedAccount{
+				PrefundedAccount: modules.PrefundedAccount{PrivateKeyHex: privKey},
+			},
+		},
+		"environment variable": {
+			account: &PrefundedAccount{PrivateKeyEnv: envName},
+			env:     types.String(privKey + "\n"),
+		},
+		"missing environment variable": {
+			account:     &PrefundedAccount{PrivateKeyEnv: envName},
+			expectedErr: "environment variable " + envName + " is not set for prefunded account addr1",
+		},
+		"invalid environment variable": {
+			account:     &PrefundedAccount{PrivateKeyEnv: envName},
+			env:         types.String("hello"),
+			expectedErr: "private key is not hex encoded for prefunded account addr1",
+		},
+		"file": {
+			account: &PrefundedAccount{PrivateKeyFile: keyFile},
+			file:    types.String(privKey + "\n"),
+		},
+		"missing file": {
+			account:     &PrefundedAccount{PrivateKeyFile: keyFile},
+			expectedErr: "unable to read private key file",
+		},
+		"multiple sources": {
+			account: &PrefundedAccount{
+				PrefundedAccount: modules.PrefundedAccount{PrivateKeyHex: privKey},
+				PrivateKeyEnv:    envName,
+			},
+			expectedErr: "only one of privkey, private_key_env, and private_key_file " +
+				"can be populated for prefunded account addr1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			if test.env != nil {
+				assert.NoError(t, os.Setenv(envName, *test.env))
+				defer os.Unsetenv(envName)
+			}
+
+			if test.file != nil {
+				assert.NoError(t, ioutil.WriteFile(
+					path.Join(dir, keyFile),
+					[]byte(*test.file),
+					os.FileMode(utils.DefaultFilePermissions),
+				))
+			}
+
+			account := *test.account
+			account.AccountIdentifier = &types.AccountIdentifier{Address: accountID}
+			account.CurveType = types.Secp256k1
+			account.Currency = &types.Currency{Symbol: "BTC", Decimals: 8}
+
+			construction := *whackyConfig.Construction
+			construction.PrefundedAccounts = []*PrefundedAccount{&account}
+			config := *whackyConfig
+			config.Construction = &construction
+
+			filePath := path.Join(dir, "test.json")
+			assert.NoError(t, utils.SerializeAndWrite(filePath, &config))
+
+			loaded, err := LoadConfiguration(context.Background(), filePath)
+			if len(test.expectedErr) > 0 {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				assert.NotContains(t, err.Error(), "hello")
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, privKey, loaded.Construction.PrefundedAccounts[0].PrivateKeyHex)
+		})
+	}
+}
+
+func TestLoadConfigurationDisableColors(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	if value, ok := os.LookupEnv("NO_COLOR"); ok {
+		defer os.Setenv("NO_COLOR", value)
+	} else {
+		defer os.Unsetenv("NO_COLOR")
+	}
+
+	var tests = map[string]struct {
+		disableColors bool
+		noColorEnv    bool
+		logFormat     LogFormat
+
+		expected bool
+	}{
+		"colors enabled": {
+			expected: false,
+		},
+		"disable_colors": {
+			disableColors: true,
+			expected:      true,
+		},
+		"NO_COLOR": {
+			noColorEnv: true,
+			expected:   true,
+		},
+		"json logs": {
+			logFormat: JSONLogFormat,
+			expected:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			config := DefaultConfiguration()
+			config.DisableColors = test.disableColors
+			config.LogFormat = test.logFormat
+			filePath := path.Join(dir, "test.json")
+			assert.NoError(t, utils.SerializeAndWrite(filePath, config))
+
+			if test.noColorEnv {
+				assert.NoError(t, os.Setenv("NO_COLOR", "1"))
+			} else {
+				assert.NoError(t, os.Unsetenv("NO_COLOR"))
+			}
+
+			// Colors start enabled regardless of whether
+			// the test is run in a terminal.
+			color.NoColor = false
+			_, err = LoadConfiguration(context.Background(), filePath)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, color.NoColor)
+		})
+	}
+}
+
+func TestLoadConfigurationLogLevel(t *testing.T) {
+	var tests = map[string]struct {
+		logLevel interface{}
+
+		expected zapcore.Level
+		err      bool
+	}{
+		"not populated": {
+			expected: zapcore.InfoLevel,
+		},
+		"debug": {
+			logLevel: "debug",
+			expected: zapcore.DebugLevel,
+		},
+		"warn": {
+			logLevel: "warn",
+			expected: zapcore.WarnLevel,
+		},
+		"error": {
+			logLevel: "error",
+			expected: zapcore.ErrorLevel,
+		},
+		"unsupported level": {
+			logLevel: "panic",
+			err:      true,
+		},
+		"unknown level": {
+			logLevel: "verbose",
+			err:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			// Old configuration files don't populate log_level.
+			var rawConfig map[string]interface{}
+			rawDefault, err := json.Marshal(DefaultConfiguration())
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal(rawDefault, &rawConfig))
+			delete(rawConfig, "log_level")
+			if test.logLevel != nil {
+				rawConfig["log_level"] = test.logLevel
+			}
+
+			filePath := path.Join(dir, "test.json")
+			assert.NoError(t, utils.SerializeAndWrite(filePath, rawConfig))
+
+			config, err := LoadConfiguration(context.Background(), filePath)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, config.LogLevel)
+		})
+	}
+}
+
+func TestDataLogCategories(t *testing.T) {
+	var tests = map[string]struct {
+		logLevel zapcore.Level
+		data     *DataConfiguration
+
+		expected *LogCategories
+	}{
+		"error": {
+			logLevel: zapcore.ErrorLevel,
+			expected: &LogCategories{},
+		},
+		"warn": {
+			logLevel: zapcore.WarnLevel,
+			expected: &LogCategories{},
+		},
+		"info": {
+			logLevel: zapcore.InfoLevel,
+			expected: &LogCategories{},
+		},
+		"info with default data configuration": {
+			logLevel: zapcore.InfoLevel,
+			data:     DefaultDataConfiguration(),
+			expected: &LogCategories{},
+		},
+		"debug": {
+			logLevel: zapcore.DebugLevel,
+			expected: &LogCategories{
+				Blocks:         true,
+				Transactions:   true,
+				BalanceChanges: true,
 			},
-			err: true,
 		},
-		"multiple end conditions": {
-			provided: multipleEndConditions,
+		"warn with booleans enabled": {
+			logLevel: zapcore.WarnLevel,
+			data: &DataConfiguration{
+				LogBlocks:          true,
+				LogTransactions:    true,
+				LogBalanceChanges:  true,
+				LogReconciliations: true,
+			},
+			expected: &LogCategories{
+				Blocks:          true,
+				Transactions:    true,
+				BalanceChanges:  true,
+				Reconciliations: true,
+			},
+		},
+		"debug with reconciliations enabled": {
+			logLevel: zapcore.DebugLevel,
+			data: &DataConfiguration{
+				LogReconciliations: true,
+			},
+			expected: &LogCategories{
+				Blocks:          true,
+				Transactions:    true,
+				BalanceChanges:  true,
+				Reconciliations: true,
+			},
+		},
+		"info with transactions enabled": {
+			logLevel: zapcore.InfoLevel,
+			data: &DataConfiguration{
+				LogTransactions: true,
+			},
+			expected: &LogCategories{
+				Transactions: true,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := &Configuration{LogLevel: test.logLevel, Data: test.data}
+			assert.Equal(t, test.expected, DataLogCategories(config))
+		})
+	}
+}
+
+func TestLoadConfigurationProfile(t *testing.T) {
+	endIndex := int64(100)
+	testnet := &types.NetworkIdentifier{Blockchain: "Ethereum", Network: "Goerli"}
+
+	var tests = map[string]struct {
+		profile string
+
+		expected    func() *Configuration
+		expectedErr string
+	}{
+		"no profile": {
 			expected: func() *Configuration {
-				def := DefaultConfiguration()
-				def.SeenBlockWorkers = runtime.NumCPU()
-				def.SerialBlockWorkers = runtime.NumCPU()
-				def.Data.EndConditions = multipleEndConditions.Data.EndConditions
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Profiles = map[string]map[string]interface{}{
+					"testnet": {
+						"network":    map[string]interface{}{"network": "Goerli"},
+						"online_url": "http://testnet:8080",
+						"data": map[string]interface{}{
+							"end_conditions": map[string]interface{}{"index": float64(100)},
+						},
+					},
+				}
 
-				return def
-			}(),
-			err: false,
+				return cfg
+			},
+		},
+		"testnet": {
+			profile: "testnet",
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Network = testnet
+				cfg.OnlineURL = "http://testnet:8080"
+				cfg.Data.EndConditions = &DataEndConditions{Index: &endIndex}
+
+				return cfg
+			},
+		},
+		"missing profile": {
+			profile:     "mainnet",
+			expectedErr: "mainnet (available profiles: [testnet])",
 		},
 	}
 
@@ -403,28 +2303,416 @@ func TestLoadConfiguration(t *testing.T) {
 			assert.NoError(t, err)
 			defer utils.RemoveTempDir(dir)
 
+			// The testnet profile only overrides the network name,
+			// so the blockchain is preserved.
+			var rawConfig map[string]interface{}
+			rawDefault, err := json.Marshal(DefaultConfiguration())
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal(rawDefault, &rawConfig))
+			rawConfig["profiles"] = map[string]interface{}{
+				"testnet": map[string]interface{}{
+					"network":    map[string]interface{}{"network": "Goerli"},
+					"online_url": "http://testnet:8080",
+					"data": map[string]interface{}{
+						"end_conditions": map[string]interface{}{"index": 100},
+					},
+				},
+			}
+
 			filePath := path.Join(dir, "test.json")
-			err = utils.SerializeAndWrite(filePath, test.provided)
+			assert.NoError(t, utils.SerializeAndWrite(filePath, rawConfig))
+
+			config, err := LoadConfigurationProfile(context.Background(), filePath, test.profile)
+			if len(test.expectedErr) > 0 {
+				assert.ErrorIs(t, err, ErrProfileNotFound)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				assert.Nil(t, config)
+				return
+			}
+
 			assert.NoError(t, err)
+			assert.Equal(t, test.expected(), config)
+		})
+	}
+}
 
-			// Copy test.ros to temp dir
-			cmd := exec.Command("cp", "testdata/test.ros", path.Join(dir, "test.ros"))
-			assert.NoError(t, cmd.Run())
+func TestOverrideNetwork(t *testing.T) {
+	subNetwork := &types.SubNetworkIdentifier{Network: "shard 1"}
+	var tests = map[string]struct {
+		network    *types.NetworkIdentifier
+		blockchain *string
+		override   *string
 
-			// Check if expected fields populated
-			config, err := LoadConfiguration(context.Background(), filePath)
+		expected *types.NetworkIdentifier
+		err      bool
+	}{
+		"no override": {
+			network:  EthereumNetwork,
+			expected: EthereumNetwork,
+		},
+		"network": {
+			network:  EthereumNetwork,
+			override: types.String("Goerli"),
+			expected: &types.NetworkIdentifier{Blockchain: "Ethereum", Network: "Goerli"},
+		},
+		"blockchain and network": {
+			network:    EthereumNetwork,
+			blockchain: types.String("Bitcoin"),
+			override:   types.String("Testnet3"),
+			expected:   &types.NetworkIdentifier{Blockchain: "Bitcoin", Network: "Testnet3"},
+		},
+		"sub network preserved": {
+			network: &types.NetworkIdentifier{
+				Blockchain:           "Ethereum",
+				Network:              "Mainnet",
+				SubNetworkIdentifier: subNetwork,
+			},
+			override: types.String("Goerli"),
+			expected: &types.NetworkIdentifier{
+				Blockchain:           "Ethereum",
+				Network:              "Goerli",
+				SubNetworkIdentifier: subNetwork,
+			},
+		},
+		"empty network": {
+			network:  EthereumNetwork,
+			override: types.String(""),
+			expected: EthereumNetwork,
+			err:      true,
+		},
+		"empty blockchain": {
+			network:    EthereumNetwork,
+			blockchain: types.String(""),
+			expected:   EthereumNetwork,
+			err:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := DefaultConfiguration()
+			config.Network = test.network
+
+			err := OverrideNetwork(config, test.blockchain, test.override)
 			if test.err {
 				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expected, config.Network)
+		})
+	}
+
+	// The configured network is never modified.
+	assert.Equal(t, &types.NetworkIdentifier{
+		Blockchain: EthereumIDBlockchain,
+		Network:    EthereumIDNetwork,
+	}, EthereumNetwork)
+}
+
+func TestPopulateConfiguration(t *testing.T) {
+	var tests = map[string]struct {
+		provided *Configuration
+
+		expected *Configuration
+		err      error
+	}{
+		"nil": {
+			err: ErrMissingNetwork,
+		},
+		"missing network": {
+			provided: &Configuration{},
+			err:      ErrMissingNetwork,
+		},
+		"network only": {
+			provided: &Configuration{Network: EthereumNetwork},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.ConfigVersion = 0
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+
+				return cfg
+			}(),
+		},
+		"partial data configuration": {
+			provided: &Configuration{
+				Network: EthereumNetwork,
+				Data: &DataConfiguration{
+					ReconciliationDisabled: true,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.ConfigVersion = 0
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.Data.HTTPTimeout = DefaultTimeout
+				cfg.Data.ReconciliationDisabled = true
+
+				return cfg
+			}(),
+		},
+		"unloaded fields": {
+			provided: &Configuration{
+				Network: EthereumNetwork,
+				RunID:   "run",
+				Data: &DataConfiguration{
+					Resume: true,
+				},
+			},
+			expected: func() *Configuration {
+				cfg := DefaultConfiguration()
+				cfg.ConfigVersion = 0
+				cfg.SeenBlockWorkers = runtime.NumCPU()
+				cfg.SerialBlockWorkers = runtime.NumCPU()
+				cfg.RunID = "run"
+				cfg.Data.HTTPTimeout = DefaultTimeout
+				cfg.Data.Resume = true
+
+				return cfg
+			}(),
+		},
+		"invalid": {
+			provided: &Configuration{
+				Network: EthereumNetwork,
+				Data: &DataConfiguration{
+					StartIndex: types.Int64(10),
+					EndConditions: &DataEndConditions{
+						Index: types.Int64(5),
+					},
+				},
+			},
+			err: errors.New("end index 5 cannot be less than start index 10"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var provided *Configuration
+			if test.provided != nil {
+				var err error
+				provided, err = copyConfiguration(test.provided)
+				assert.NoError(t, err)
+			}
+
+			config, err := PopulateConfiguration(context.Background(), test.provided)
+
+			// The provided configuration is never modified.
+			assert.Equal(t, provided, test.provided)
+
+			if test.err != nil {
+				assert.Contains(t, err.Error(), test.err.Error())
 				assert.Nil(t, config)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, config)
+		})
+	}
+}
+
+func TestResolveRelativeIndexes(t *testing.T) {
+	status := &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: &types.BlockIdentifier{Index: 1000, Hash: "block 1000"},
+		GenesisBlockIdentifier: &types.BlockIdentifier{Index: 1, Hash: "block 1"},
+	}
+
+	var tests = map[string]struct {
+		startIndex *int64
+		endIndex   *int64
+
+		expectedStartIndex *int64
+		expectedEndIndex   *int64
+		err                bool
+	}{
+		"no indexes": {},
+		"absolute indexes": {
+			startIndex:         types.Int64(10),
+			endIndex:           types.Int64(20),
+			expectedStartIndex: types.Int64(10),
+			expectedEndIndex:   types.Int64(20),
+		},
+		"relative start index": {
+			startIndex:         types.Int64(-500),
+			expectedStartIndex: types.Int64(500),
+		},
+		"relative end index": {
+			startIndex:         types.Int64(-500),
+			endIndex:           types.Int64(-10),
+			expectedStartIndex: types.Int64(500),
+			expectedEndIndex:   types.Int64(990),
+		},
+		"relative start index before genesis": {
+			startIndex:         types.Int64(-5000),
+			expectedStartIndex: types.Int64(1),
+		},
+		"relative end index before start index": {
+			startIndex:         types.Int64(995),
+			endIndex:           types.Int64(-10),
+			expectedStartIndex: types.Int64(995),
+			expectedEndIndex:   types.Int64(990),
+			err:                true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := &DataConfiguration{
+				StartIndex:    test.startIndex,
+				EndConditions: &DataEndConditions{Index: test.endIndex},
+			}
+
+			err := ResolveRelativeIndexes(config, status)
+			if test.err {
+				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedStartIndex, config.StartIndex)
+			assert.Equal(t, test.expectedEndIndex, config.EndConditions.Index)
+		})
+	}
+}
 
-				// Ensure test.ros expected file path is right
-				if test.expected.Construction != nil && len(test.expected.Construction.ConstructorDSLFile) > 0 {
-					test.expected.Construction.ConstructorDSLFile = path.Join(dir, test.expected.Construction.ConstructorDSLFile)
-				}
-				assert.Equal(t, test.expected, config)
+func TestFilePaths(t *testing.T) {
+	var tests = map[string]struct {
+		raw string
+
+		expected    FilePaths
+		expectedErr bool
+	}{
+		"empty path": {
+			raw: `""`,
+		},
+		"single path": {
+			raw:      `"balances.json"`,
+			expected: FilePaths{"balances.json"},
+		},
+		"list of paths": {
+			raw:      `["balances1.json","balances2.json"]`,
+			expected: FilePaths{"balances1.json", "balances2.json"},
+		},
+		"invalid": {
+			raw:         `10`,
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var filePaths FilePaths
+			err := json.Unmarshal([]byte(test.raw), &filePaths)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, filePaths)
+
+			// FilePaths are written in the same form
+			// they are read.
+			raw, err := json.Marshal(filePaths)
+			assert.NoError(t, err)
+			assert.Equal(t, test.raw, string(raw))
+		})
+	}
+}
+
+func TestReconciliationCoverageUnmarshal(t *testing.T) {
+	index := int64(100)
+	accountCount := int64(500)
+	var tests = map[string]struct {
+		raw string
+
+		expected    *ReconciliationCoverage
+		expectedErr bool
+	}{
+		"number": {
+			raw:      `0.95`,
+			expected: &ReconciliationCoverage{Coverage: 0.95},
+		},
+		"object": {
+			raw: `{"coverage":0.95,"from_tip":true,"index":100,"account_count":500}`,
+			expected: &ReconciliationCoverage{
+				Coverage:     0.95,
+				FromTip:      true,
+				Index:        &index,
+				AccountCount: &accountCount,
+			},
+		},
+		"unknown field": {
+			raw:         `{"coverage":0.95,"height":100}`,
+			expectedErr: true,
+		},
+		"invalid": {
+			raw:         `"0.95"`,
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var coverage ReconciliationCoverage
+			err := json.Unmarshal([]byte(test.raw), &coverage)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
 			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, &coverage)
+		})
+	}
+}
+
+func TestNetworkDataDirectory(t *testing.T) {
+	var tests = map[string]struct {
+		dataDirectory string
+		network       *types.NetworkIdentifier
+
+		expected string
+	}{
+		"network": {
+			dataDirectory: "data",
+			network:       EthereumNetwork,
+			expected:      "data/Ethereum/Ropsten",
+		},
+		"sub network": {
+			dataDirectory: "/var/data",
+			network: &types.NetworkIdentifier{
+				Blockchain:           "Ethereum",
+				Network:              "Mainnet",
+				SubNetworkIdentifier: &types.SubNetworkIdentifier{Network: "shard 1"},
+			},
+			expected: "/var/data/Ethereum/Mainnet/shard_1",
+		},
+		"unsafe characters": {
+			dataDirectory: "data",
+			network: &types.NetworkIdentifier{
+				Blockchain: "../Bitcoin",
+				Network:    "..",
+			},
+			expected: "data/.._Bitcoin/___",
+		},
+		"no data directory": {
+			network: EthereumNetwork,
+		},
+		"no network": {
+			dataDirectory: "data",
+			expected:      "data",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(
+				t,
+				test.expected,
+				NetworkDataDirectory(test.dataDirectory, test.network),
+			)
 		})
 	}
 }
@@ -0,0 +1,263 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// CurrentConfigVersion is the config_version of configuration
+// files created by this version of rosetta-cli. Configuration
+// files without a config_version are version 0.
+const CurrentConfigVersion = 2
+
+var (
+	// ErrUnsupportedConfigVersion is returned when a configuration
+	// file was created by a newer version of rosetta-cli.
+	ErrUnsupportedConfigVersion = errors.New(
+		"config_version is not supported by this version of rosetta-cli (upgrade rosetta-cli)",
+	)
+
+	// ErrMigrationConflict is returned when a configuration file
+	// populates both a legacy key and the key it was replaced with.
+	ErrMigrationConflict = errors.New("legacy key conflicts with its replacement")
+)
+
+// migration upgrades a configuration file (or
+// profile) from version-1 to version.
+type migration struct {
+	version int
+	apply   func(raw map[string]interface{}) ([]string, error)
+}
+
+// migrations are applied in order to configuration
+// files older than CurrentConfigVersion:
+//
+// Version 1:
+//   - sync_concurrency is renamed to max_sync_concurrency
+//   - transaction_concurrency is removed (it is no longer used)
+//   - data.historical_balance_enabled is replaced with
+//     data.historical_balance_disabled (with the opposite value)
+//
+// Version 2:
+//   - a numeric data.end_conditions.reconciliation_coverage is
+//     moved to data.end_conditions.reconciliation_coverage.coverage
+var migrations = []*migration{
+	{version: 1, apply: migrateVersion1},
+	{version: 2, apply: migrateVersion2},
+}
+
+// Migration describes the changes made to
+// upgrade a configuration file.
+type Migration struct {
+	FromVersion int
+	ToVersion   int
+	Changes     []string
+}
+
+// Print logs the changes made by m.
+func (m *Migration) Print(filePath string) {
+	color.Yellow(
+		"migrated configuration file %s from config_version %d to %d:\n",
+		filePath,
+		m.FromVersion,
+		m.ToVersion,
+	)
+	for _, change := range m.Changes {
+		color.Yellow("  %s\n", change)
+	}
+}
+
+// Migrate upgrades the configuration file b to CurrentConfigVersion.
+// The returned *Migration is nil if b is already current. Profiles
+// are migrated along with the rest of the configuration file.
+func Migrate(b []byte) ([]byte, *Migration, error) {
+	raw, err := decodeRaw(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	version, err := configVersion(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if version == CurrentConfigVersion {
+		return b, nil, nil
+	}
+
+	m := &Migration{FromVersion: version, ToVersion: CurrentConfigVersion, Changes: []string{}}
+	profiles, _ := raw["profiles"].(map[string]interface{})
+	profileNames := make([]string, 0, len(profiles))
+	for name := range profiles {
+		profileNames = append(profileNames, name)
+	}
+	sort.Strings(profileNames)
+
+	for _, migration := range migrations {
+		if migration.version <= version {
+			continue
+		}
+
+		changes, err := migration.apply(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		m.Changes = append(m.Changes, changes...)
+
+		for _, name := range profileNames {
+			profile, ok := profiles[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			changes, err := migration.apply(profile)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: profile %s", err, name)
+			}
+
+			for _, change := range changes {
+				m.Changes = append(m.Changes, fmt.Sprintf("profile %s: %s", name, change))
+			}
+		}
+	}
+
+	raw["config_version"] = CurrentConfigVersion
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unable to marshal migrated configuration", err)
+	}
+
+	return migrated, m, nil
+}
+
+// decodeRaw decodes b into a map (preserving numbers).
+func decodeRaw(b []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: unable to unmarshal", err)
+	}
+
+	return raw, nil
+}
+
+// configVersion returns the config_version of raw
+// (or 0 if it is not populated).
+func configVersion(raw map[string]interface{}) (int, error) {
+	value, ok := raw["config_version"]
+	if !ok {
+		return 0, nil
+	}
+
+	number, ok := value.(json.Number)
+	if !ok {
+		return -1, fmt.Errorf("config_version %v must be an integer", value)
+	}
+
+	version, err := number.Int64()
+	if err != nil || version < 0 {
+		return -1, fmt.Errorf("config_version %s must be a non-negative integer", number)
+	}
+
+	if version > CurrentConfigVersion {
+		return -1, fmt.Errorf(
+			"%w: %d (latest supported is %d)",
+			ErrUnsupportedConfigVersion,
+			version,
+			CurrentConfigVersion,
+		)
+	}
+
+	return int(version), nil
+}
+
+// rename moves raw[from] to raw[to].
+func rename(raw map[string]interface{}, prefix string, from string, to string) ([]string, error) {
+	value, ok := raw[from]
+	if !ok {
+		return nil, nil
+	}
+
+	if _, ok := raw[to]; ok {
+		return nil, fmt.Errorf("%w: %s%s and %s%s", ErrMigrationConflict, prefix, from, prefix, to)
+	}
+
+	delete(raw, from)
+	raw[to] = value
+	return []string{fmt.Sprintf("renamed %s%s to %s%s", prefix, from, prefix, to)}, nil
+}
+
+func migrateVersion1(raw map[string]interface{}) ([]string, error) {
+	changes, err := rename(raw, "", "sync_concurrency", "max_sync_concurrency")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := raw["transaction_concurrency"]; ok {
+		delete(raw, "transaction_concurrency")
+		changes = append(changes, "removed transaction_concurrency (no longer used)")
+	}
+
+	data, _ := raw["data"].(map[string]interface{})
+	enabled, ok := data["historical_balance_enabled"]
+	if !ok {
+		return changes, nil
+	}
+
+	if _, ok := data["historical_balance_disabled"]; ok {
+		return nil, fmt.Errorf(
+			"%w: data.historical_balance_enabled and data.historical_balance_disabled",
+			ErrMigrationConflict,
+		)
+	}
+
+	enabledBool, ok := enabled.(bool)
+	if !ok {
+		return nil, fmt.Errorf("data.historical_balance_enabled %v must be a boolean", enabled)
+	}
+
+	delete(data, "historical_balance_enabled")
+	data["historical_balance_disabled"] = !enabledBool
+	return append(changes, fmt.Sprintf(
+		"replaced data.historical_balance_enabled=%t with data.historical_balance_disabled=%t",
+		enabledBool,
+		!enabledBool,
+	)), nil
+}
+
+func migrateVersion2(raw map[string]interface{}) ([]string, error) {
+	data, _ := raw["data"].(map[string]interface{})
+	endConditions, _ := data["end_conditions"].(map[string]interface{})
+	coverage, ok := endConditions["reconciliation_coverage"].(json.Number)
+	if !ok {
+		return nil, nil
+	}
+
+	endConditions["reconciliation_coverage"] = map[string]interface{}{"coverage": coverage}
+	return []string{fmt.Sprintf(
+		"moved data.end_conditions.reconciliation_coverage=%s to "+
+			"data.end_conditions.reconciliation_coverage.coverage",
+		coverage,
+	)}, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configuration
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	var tests = map[string]struct {
+		file    string
+		profile string
+
+		expectedMigration *Migration
+		expectedErr       error
+		check             func(t *testing.T, config *Configuration)
+	}{
+		"version 0": {
+			file: "version_0.json",
+			expectedMigration: &Migration{
+				FromVersion: 0,
+				ToVersion:   CurrentConfigVersion,
+				Changes: []string{
+					"renamed sync_concurrency to max_sync_concurrency",
+					"removed transaction_concurrency (no longer used)",
+					"replaced data.historical_balance_enabled=true with " +
+						"data.historical_balance_disabled=false",
+					"profile mainnet: renamed sync_concurrency to max_sync_concurrency",
+				},
+			},
+			check: func(t *testing.T, config *Configuration) {
+				assert.Equal(t, int64(8), config.MaxSyncConcurrency)
+				assert.False(t, *config.Data.HistoricalBalanceDisabled)
+				assert.True(t, *config.Data.EndConditions.Tip)
+			},
+		},
+		"version 0 with profile": {
+			file:    "version_0.json",
+			profile: "mainnet",
+			expectedMigration: &Migration{
+				FromVersion: 0,
+				ToVersion:   CurrentConfigVersion,
+				Changes: []string{
+					"renamed sync_concurrency to max_sync_concurrency",
+					"removed transaction_concurrency (no longer used)",
+					"replaced data.historical_balance_enabled=true with " +
+						"data.historical_balance_disabled=false",
+					"profile mainnet: renamed sync_concurrency to max_sync_concurrency",
+				},
+			},
+			check: func(t *testing.T, config *Configuration) {
+				assert.Equal(t, int64(16), config.MaxSyncConcurrency)
+				assert.Equal(t, "Mainnet", config.Network.Network)
+			},
+		},
+		"version 1": {
+			file: "version_1.json",
+			expectedMigration: &Migration{
+				FromVersion: 1,
+				ToVersion:   CurrentConfigVersion,
+				Changes: []string{
+					"moved data.end_conditions.reconciliation_coverage=0.95 to " +
+						"data.end_conditions.reconciliation_coverage.coverage",
+				},
+			},
+			check: func(t *testing.T, config *Configuration) {
+				assert.Equal(t, 0.95, config.Data.EndConditions.ReconciliationCoverage.Coverage)
+			},
+		},
+		"current version": {
+			file: "version_2.json",
+			check: func(t *testing.T, config *Configuration) {
+				assert.Equal(t, 0.95, config.Data.EndConditions.ReconciliationCoverage.Coverage)
+			},
+		},
+		"future version": {
+			file:        "version_3.json",
+			expectedErr: ErrUnsupportedConfigVersion,
+		},
+		"conflicting keys": {
+			file:        "conflict.json",
+			expectedErr: ErrMigrationConflict,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filePath := path.Join("testdata", "migrations", test.file)
+			b, err := ioutil.ReadFile(filePath)
+			assert.NoError(t, err)
+
+			migrated, migration, err := Migrate(b)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+				assert.Nil(t, migrated)
+
+				_, err = LoadConfigurationProfile(context.Background(), filePath, test.profile)
+				assert.True(t, errors.Is(err, test.expectedErr))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedMigration, migration)
+
+			// Migrating a migrated configuration
+			// file makes no changes.
+			_, migration, err = Migrate(migrated)
+			assert.NoError(t, err)
+			assert.Nil(t, migration)
+
+			config, err := LoadConfigurationProfile(context.Background(), filePath, test.profile)
+			assert.NoError(t, err)
+			assert.Equal(t, CurrentConfigVersion, config.ConfigVersion)
+			test.check(t, config)
+		})
+	}
+}
+
+func TestConfigVersion(t *testing.T) {
+	var tests = map[string]struct {
+		config string
+
+		expected    int
+		expectedErr bool
+	}{
+		"missing": {
+			config:   `{}`,
+			expected: 0,
+		},
+		"current": {
+			config:   `{"config_version": 2}`,
+			expected: 2,
+		},
+		"negative": {
+			config:      `{"config_version": -1}`,
+			expectedErr: true,
+		},
+		"not an integer": {
+			config:      `{"config_version": 1.5}`,
+			expectedErr: true,
+		},
+		"string": {
+			config:      `{"config_version": "2"}`,
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			raw, err := decodeRaw([]byte(test.config))
+			assert.NoError(t, err)
+
+			version, err := configVersion(raw)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, version)
+		})
+	}
+}
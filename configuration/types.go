@@ -15,11 +15,54 @@
 package configuration
 
 import (
+	"bytes"
+	"encoding/json"
+
 	"github.com/coinbase/rosetta-sdk-go/constructor/job"
 	"github.com/coinbase/rosetta-sdk-go/storage/modules"
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.uber.org/zap/zapcore"
 )
 
+// FilePaths is a list of file paths that can be
+// provided in JSON as a single path or as a list.
+type FilePaths []string
+
+// MarshalJSON writes FilePaths as a single path
+// if it contains 0 or 1 paths.
+func (f FilePaths) MarshalJSON() ([]byte, error) {
+	switch len(f) {
+	case 0:
+		return json.Marshal("")
+	case 1:
+		return json.Marshal(f[0])
+	default:
+		return json.Marshal([]string(f))
+	}
+}
+
+// UnmarshalJSON reads FilePaths from a single
+// path or a list of paths.
+func (f *FilePaths) UnmarshalJSON(b []byte) error {
+	var filePath string
+	if err := json.Unmarshal(b, &filePath); err == nil {
+		*f = nil
+		if len(filePath) > 0 {
+			*f = FilePaths{filePath}
+		}
+
+		return nil
+	}
+
+	var filePaths []string
+	if err := json.Unmarshal(b, &filePaths); err != nil {
+		return err
+	}
+
+	*f = FilePaths(filePaths)
+	return nil
+}
+
 // CheckDataEndCondition is a type of "successful" end
 // for the "check:data" method.
 type CheckDataEndCondition string
@@ -40,6 +83,62 @@ const (
 	// ReconciliationCoverageEndCondition is used to indicate that the reconciliation
 	// coverage end condition has been met.
 	ReconciliationCoverageEndCondition CheckDataEndCondition = "Reconciliation Coverage End Condition"
+
+	// BlockHashEndCondition is used to indicate that the block
+	// hash end condition has been met.
+	BlockHashEndCondition CheckDataEndCondition = "Block Hash End Condition"
+
+	// ReconciliationsEndCondition is used to indicate that the
+	// reconciliations end condition has been met.
+	ReconciliationsEndCondition CheckDataEndCondition = "Reconciliations End Condition"
+
+	// InterruptedEndCondition is used to indicate that check:data
+	// was stopped by a signal (SIGINT or SIGTERM). Unlike other
+	// end conditions, it is reported alongside an error.
+	InterruptedEndCondition CheckDataEndCondition = "Interrupted"
+
+	// ReorgEndCondition is used to indicate that check:data was
+	// stopped because a block was orphaned with halt_on_reorg
+	// enabled. Like InterruptedEndCondition, it is reported
+	// alongside an error.
+	ReorgEndCondition CheckDataEndCondition = "Reorg"
+)
+
+// LogFormat is the format of the block, transaction,
+// and reconciliation logs printed to the console.
+type LogFormat string
+
+const (
+	// TextLogFormat prints logs as plain text.
+	TextLogFormat LogFormat = "text"
+
+	// JSONLogFormat prints logs as JSON lines.
+	JSONLogFormat LogFormat = "json"
+)
+
+// LogCategories are the check:data logging
+// categories that are enabled.
+type LogCategories struct {
+	Blocks          bool
+	Transactions    bool
+	BalanceChanges  bool
+	Reconciliations bool
+}
+
+// ResultsOutputFormat is the format that
+// results are written to ResultsOutputFile in.
+type ResultsOutputFormat string
+
+const (
+	// JSONResultsOutputFormat writes results as JSON.
+	JSONResultsOutputFormat ResultsOutputFormat = "json"
+
+	// YAMLResultsOutputFormat writes results as YAML.
+	YAMLResultsOutputFormat ResultsOutputFormat = "yaml"
+
+	// TextResultsOutputFormat writes the summary of results
+	// that is printed to the console at the end of a run.
+	TextResultsOutputFormat ResultsOutputFormat = "text"
 )
 
 // Default Configuration Values
@@ -53,6 +152,7 @@ const (
 	DefaultActiveReconciliationConcurrency   = 16
 	DefaultInactiveReconciliationConcurrency = 4
 	DefaultInactiveReconciliationFrequency   = 250
+	DefaultBootstrapBalancesConcurrency      = 4
 	DefaultConfirmationDepth                 = 10
 	DefaultStaleDepth                        = 30
 	DefaultBroadcastLimit                    = 3
@@ -60,6 +160,48 @@ const (
 	DefaultBlockBroadcastLimit               = 5
 	DefaultStatusPort                        = 9090
 	DefaultMaxReorgDepth                     = 100
+	DefaultClockSkewWarningThreshold         = 30
+	DefaultTipPollInterval                   = 10
+
+	// Tip poll interval bounds (in seconds)
+	MinTipPollInterval = 1
+	MaxTipPollInterval = 600
+
+	// Retry backoff defaults match the exponential backoff
+	// used by the fetcher.
+	DefaultRetryInitialIntervalMs = 500
+	DefaultRetryMaxIntervalMs     = 60000
+	DefaultRetryMultiplier        = 1.5
+	DefaultRetryJitter            = 0.5
+
+	// DefaultCommitLatencyThresholdMs is the commit latency above
+	// which blocks are batched if max_commit_batch_blocks > 1.
+	DefaultCommitLatencyThresholdMs = 100
+
+	// DefaultRequestIDPrefix is the prefix used for request ids
+	// if a request id header is configured without a prefix.
+	DefaultRequestIDPrefix = "rosetta-cli-{run_id}-"
+
+	// DefaultFaucetCooldown is the number of seconds to wait
+	// before requesting funds for the same address again.
+	DefaultFaucetCooldown = 300
+
+	// Reconciliation Failure Hook Defaults
+	DefaultReconciliationFailureHookTimeout        = 30
+	DefaultReconciliationFailureHookMaxConcurrency = 4
+
+	// Tip Long Poll Defaults
+	DefaultTipLongPollDelayMs = 100
+	DefaultTipLongPollTimeout = 30
+
+	// DefaultProgressLogInterval is the number of seconds
+	// between each check:data progress summary.
+	DefaultProgressLogInterval = 60
+
+	// DefaultFetchRetrySoftLimit is the number of failed attempts
+	// of a request that are tolerated before the request is
+	// counted as a non-fatal error.
+	DefaultFetchRetrySoftLimit = 3
 
 	// ETH Defaults
 	EthereumIDBlockchain = "Ethereum"
@@ -74,24 +216,151 @@ var (
 	}
 )
 
+// TLSConfiguration contains the TLS settings used when making
+// requests to a Rosetta API implementation.
+type TLSConfiguration struct {
+	// CAFile is a path relative to the configuration file of a
+	// PEM-encoded CA certificate used to verify the implementation
+	// (in addition to the system roots).
+	CAFile string `json:"ca_file,omitempty"`
+
+	// CertFile is a path relative to the configuration file of a
+	// PEM-encoded client certificate presented to the implementation.
+	// If populated, KeyFile must also be populated.
+	CertFile string `json:"cert_file,omitempty"`
+
+	// KeyFile is a path relative to the configuration file of the
+	// PEM-encoded private key for CertFile.
+	KeyFile string `json:"key_file,omitempty"`
+
+	// InsecureSkipVerify disables verification of the implementation's
+	// certificate. This should ONLY be used when debugging as it
+	// allows for man-in-the-middle attacks!
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+// RetryBackoffConfiguration configures the exponential backoff
+// used to retry failed requests. Any fields that are not populated
+// default to the backoff used by the fetcher.
+type RetryBackoffConfiguration struct {
+	// InitialIntervalMs is the number of milliseconds
+	// to wait before the first retry.
+	InitialIntervalMs uint64 `json:"initial_interval_ms"`
+
+	// MaxIntervalMs is the maximum number of milliseconds
+	// to wait between retries (before jitter is applied).
+	MaxIntervalMs uint64 `json:"max_interval_ms"`
+
+	// Multiplier is applied to the interval after each retry.
+	Multiplier float64 `json:"multiplier"`
+
+	// Jitter randomizes each interval by up to +/- Jitter * interval
+	// to avoid many requests being retried at the same time. It must
+	// be in [0, 1].
+	Jitter *float64 `json:"jitter,omitempty"`
+}
+
+// BaselineConfiguration configures the comparison of the findings
+// of a run (errors, failed tests, and warnings) with those in the
+// results file of an accepted run. Findings are classified as new,
+// persisting, or resolved, and the comparison is written to the
+// results file.
+type BaselineConfiguration struct {
+	// File is the results file (results_output_file) of the accepted
+	// run. It must have been written by a compatible version of
+	// rosetta-cli.
+	File string `json:"file"`
+
+	// ReportOnly, if true, never changes the outcome of a run. Otherwise,
+	// a run fails if (and only if) it has any new findings.
+	ReportOnly bool `json:"report_only,omitempty"`
+}
+
+// RequestIDHeaderConfiguration configures a header containing a
+// unique request id that is attached to every request made to the
+// OnlineURL and OfflineURL.
+type RequestIDHeaderConfiguration struct {
+	// Name is the name of the header (i.e. X-Request-ID).
+	Name string `json:"name"`
+
+	// Prefix is prepended to a sequence number to form each request
+	// id. Any occurrence of "{run_id}" is replaced with a UUID that is
+	// generated each time rosetta-cli is invoked, so that ids are unique
+	// across runs.
+	Prefix string `json:"prefix"`
+}
+
+// StorageConfiguration configures how check:data
+// commits processed blocks to storage.
+type StorageConfiguration struct {
+	// MaxCommitBatchBlocks is the maximum number of blocks grouped
+	// into a single storage commit. When recent commits take longer
+	// than CommitLatencyThresholdMs, blocks are batched (up to this
+	// many) to improve throughput on slow storage (like network
+	// filesystems). Blocks are committed one at a time again when
+	// latency recovers. Each batch is committed atomically. If 0 or 1,
+	// blocks are always committed one at a time.
+	MaxCommitBatchBlocks int `json:"max_commit_batch_blocks"`
+
+	// CommitLatencyThresholdMs is the commit latency per block
+	// (in milliseconds) above which blocks are batched.
+	CommitLatencyThresholdMs uint64 `json:"commit_latency_threshold_ms,omitempty"`
+}
+
 // ConstructionConfiguration contains all configurations
 // to run check:construction.
 type ConstructionConfiguration struct {
 	// OfflineURL is the URL of a Rosetta API implementation in "offline mode".
 	OfflineURL string `json:"offline_url"`
 
+	// VerifyOfflineMode, if true, fails check:construction before any
+	// workflows are run if OfflineURL is the same as OnlineURL or if the
+	// node at OfflineURL serves /block (which requires a connection to
+	// the network). OfflineURL is always checked to be reachable.
+	VerifyOfflineMode bool `json:"verify_offline_mode,omitempty"`
+
+	// SkipPrefundedVerification, if true, skips checking that each
+	// prefunded account has a non-zero balance of its configured
+	// currency on the OnlineURL before any workflows are run (for
+	// offline-only smoke tests).
+	SkipPrefundedVerification bool `json:"skip_prefunded_verification,omitempty"`
+
 	// MaxOffineConnections is the maximum number of open connections that the offline
 	// fetcher will open.
 	MaxOfflineConnections int `json:"max_offline_connections"`
 
+	// HTTPTimeout overrides the top-level HTTPTimeout (in seconds) for
+	// all requests to the OfflineURL and /construction/* requests to the
+	// OnlineURL. Other requests to the OnlineURL made by check:construction
+	// (like syncing blocks) use the top-level HTTPTimeout. If not
+	// populated, the top-level HTTPTimeout is used.
+	HTTPTimeout uint64 `json:"http_timeout,omitempty"`
+
 	// ForceRetry overrides the default retry handling to retry
 	// on all non-200 responses.
 	ForceRetry bool `json:"force_retry,omitempty"`
 
+	// TLS overrides the top-level TLS settings for requests to
+	// the OfflineURL. If not populated, the top-level TLS settings
+	// are used.
+	TLS *TLSConfiguration `json:"tls,omitempty"`
+
+	// ConfirmationDepth is the number of blocks a broadcast transaction
+	// must be buried under before it is considered confirmed. If
+	// populated, it overrides the confirmation_depth set by each workflow
+	// scenario (so scenarios no longer need to set it). It must be less
+	// than StaleDepth.
+	ConfirmationDepth int64 `json:"confirmation_depth,omitempty"`
+
 	// StaleDepth is the number of blocks to wait before attempting
 	// to rebroadcast after not finding a transaction on-chain.
 	StaleDepth int64 `json:"stale_depth"`
 
+	// TipDelay overrides the top-level tip_delay for check:construction
+	// (which often expects a different block cadence than check:data).
+	// If not populated, the top-level tip_delay is used.
+	TipDelay int64 `json:"tip_delay,omitempty"`
+
 	// BroadcastLimit is the number of times to attempt re-broadcast
 	// before giving up on a transaction broadcast.
 	BroadcastLimit int `json:"broadcast_limit"`
@@ -113,13 +382,19 @@ type ConstructionConfiguration struct {
 	// broadcasts, it may make sense to limit the number of broadcasts.
 	BlockBroadcastLimit int `json:"block_broadcast_limit"`
 
+	// BroadcastTPS is the maximum number of transactions submitted to
+	// /construction/submit per second (across all blocks). Submissions
+	// are spread out evenly instead of sent in bursts. If 0,
+	// submissions are not throttled.
+	BroadcastTPS float64 `json:"broadcast_tps,omitempty"`
+
 	// RebroadcastAll indicates if all pending broadcasts should be
 	// rebroadcast from BroadcastStorage on restart.
 	RebroadcastAll bool `json:"rebroadcast_all"`
 
 	// PrefundedAccounts is an array of prefunded accounts
 	// to use while testing.
-	PrefundedAccounts []*modules.PrefundedAccount `json:"prefunded_accounts,omitempty"`
+	PrefundedAccounts []*PrefundedAccount `json:"prefunded_accounts,omitempty"`
 
 	// Workflows are executed by the rosetta-cli to test
 	// certain construction flows.
@@ -132,6 +407,18 @@ type ConstructionConfiguration struct {
 	// DSL Spec: https://github.com/coinbase/rosetta-sdk-go/tree/master/constructor/dsl
 	ConstructorDSLFile string `json:"constructor_dsl_file"`
 
+	// ConstructorDSL is the contents of a Rosetta Constructor DSL
+	// file that describes which Workflows to test. This is useful
+	// when workflows are generated programmatically.
+	ConstructorDSL string `json:"constructor_dsl,omitempty"`
+
+	// WorkflowConcurrency is a map of workflow:concurrency that
+	// overrides the concurrency of workflows after they are compiled.
+	// This makes it possible to share a Rosetta Constructor DSL file
+	// across environments. Reserved workflows must still have
+	// a concurrency of 1.
+	WorkflowConcurrency map[string]int `json:"workflow_concurrency,omitempty"`
+
 	// EndConditions is a map of workflow:count that
 	// indicates how many of each workflow should be performed
 	// before check:construction should stop. For example,
@@ -139,15 +426,38 @@ type ConstructionConfiguration struct {
 	// workflows should be performed before stopping.
 	EndConditions map[string]int `json:"end_conditions,omitempty"`
 
+	// Duration is the maximum number of seconds check:construction
+	// may run before failing. If all EndConditions are reached first,
+	// they take precedence. If 0, check:construction runs until
+	// EndConditions are reached.
+	Duration uint64 `json:"duration,omitempty"`
+
 	// StatusPort allows the caller to query a running check:construction
 	// test to get stats about progress. This can be used instead
 	// of parsing logs to populate some sort of status dashboard.
-	StatusPort uint `json:"status_port,omitempty"`
+	//
+	// If not populated, DefaultStatusPort is used. If 0, no status
+	// server is started.
+	StatusPort *uint `json:"status_port,omitempty"`
 
 	// ResultsOutputFile is the absolute filepath of where to save
 	// the results of a check:construction run.
 	ResultsOutputFile string `json:"results_output_file,omitempty"`
 
+	// ResultsOutputFormat is the format ResultsOutputFile is written
+	// in (json, yaml, or text). If not populated, json is used. Only
+	// json results can be used as a baseline.
+	ResultsOutputFormat ResultsOutputFormat `json:"results_output_format,omitempty"`
+
+	// RandomSeed seeds the randomness used by rosetta-cli during a run
+	// (i.e. the jitter applied to retries). If not populated, a seed is
+	// generated. The seed used is logged and included in the results.
+	//
+	// Randomness in workflow actions (random_number, random_string, and
+	// account creation in find_balance) is generated by rosetta-sdk-go
+	// and is not derived from this seed.
+	RandomSeed *int64 `json:"random_seed,omitempty"`
+
 	// Quiet is a boolean indicating if all request and response
 	// logging should be silenced.
 	Quiet bool `json:"quiet,omitempty"`
@@ -161,6 +471,80 @@ type ConstructionConfiguration struct {
 	// This is a separate config from the data config because it
 	// is usually false whereas the data config by the same name is usually true.
 	InitialBalanceFetchDisabled bool `json:"initial_balance_fetch_disabled"`
+
+	// Faucet, if populated, is invoked to fund accounts while a
+	// request_funds workflow is waiting for funds. If not populated,
+	// accounts must be funded manually.
+	Faucet *FaucetConfiguration `json:"faucet,omitempty"`
+
+	// DryRun, if true, runs each workflow through construction and
+	// signing but never submits the signed transaction to
+	// /construction/submit. Instead, the signed payload is logged and
+	// the broadcast is immediately considered complete so that end
+	// conditions are evaluated against workflows completed without
+	// broadcast.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// AllowedSigners may be returned in signing payloads by
+	// /construction/payloads even if they do not appear in the
+	// intent of a transaction (ex: a fee payer). Signing payloads
+	// for any other account not in the intent fail the run.
+	AllowedSigners []*types.AccountIdentifier `json:"allowed_signers,omitempty"`
+
+	// MinimumBalanceConfirmations is the number of confirmations
+	// (including the block it is in) a transaction depositing funds
+	// must have before those funds can be used by find_balance or
+	// find_coin. Shallower funds are considered pending. If 0 or 1,
+	// funds are available as soon as they are included in a block.
+	MinimumBalanceConfirmations int64 `json:"minimum_balance_confirmations,omitempty"`
+}
+
+// PrefundedAccount is a prefunded account to use while testing.
+// Instead of populating its hex-encoded private key (privkey) in
+// the configuration file, it can be loaded from an environment
+// variable or file when the configuration file is loaded.
+type PrefundedAccount struct {
+	modules.PrefundedAccount
+
+	// PrivateKeyEnv is the name of an environment variable
+	// containing the hex-encoded private key.
+	PrivateKeyEnv string `json:"private_key_env,omitempty"`
+
+	// PrivateKeyFile is the path of a file containing the
+	// hex-encoded private key. Relative paths are relative
+	// to the configuration file.
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+}
+
+// FaucetConfiguration configures the HTTP faucet used
+// to fund accounts in check:construction.
+type FaucetConfiguration struct {
+	// URL is the faucet endpoint. Requests are sent with
+	// the POST method.
+	URL string `json:"url"`
+
+	// RequestTemplate is the body of each faucet request. All
+	// occurrences of {{address}} are replaced with the address
+	// of the account to fund.
+	RequestTemplate string `json:"request_template"`
+
+	// AuthHeader, if populated, is sent as the value of the
+	// Authorization header on each faucet request.
+	AuthHeader string `json:"auth_header,omitempty"`
+
+	// ResponseMatcher is a regular expression that a response body
+	// must match for a request to be considered successful. If not
+	// populated, any 2xx response is considered successful.
+	ResponseMatcher string `json:"response_matcher,omitempty"`
+
+	// Cooldown is the number of seconds to wait before requesting
+	// funds for the same address again.
+	Cooldown uint64 `json:"cooldown"`
+
+	// Amount, if populated, is the amount the faucet sends on each
+	// successful request. It is used to report the total funds
+	// sourced from the faucet.
+	Amount *types.Amount `json:"amount,omitempty"`
 }
 
 // ReconciliationCoverage is used to add conditions
@@ -168,9 +552,10 @@ type ConstructionConfiguration struct {
 // All provided conditions must be satisfied before
 // the end condition is considered satisfied.
 //
-// If FromTip, Tip, Height, and AccountCount are not provided,
+// If FromTip, Tip, Index, and AccountCount are not provided,
 // `check:data` will halt as soon as coverage surpasses
-// Coverage.
+// Coverage. For backwards compatibility, ReconciliationCoverage
+// can also be provided as a number (the required Coverage).
 type ReconciliationCoverage struct {
 	// Coverage is some value [0.0, 1.0] that represents
 	// the % of accounts reconciled.
@@ -187,6 +572,7 @@ type ReconciliationCoverage struct {
 
 	// Index is an int64 indicating the height that must be
 	// reached before reconciliation coverage is considered valid.
+	// Only reconciliations performed at or above Index are counted.
 	Index *int64 `json:"index,omitempty"`
 
 	// AccountCount is an int64 indicating the number of accounts
@@ -195,11 +581,35 @@ type ReconciliationCoverage struct {
 	AccountCount *int64 `json:"account_count,omitempty"`
 }
 
+// UnmarshalJSON reads ReconciliationCoverage from an
+// object or a number (the required Coverage).
+func (r *ReconciliationCoverage) UnmarshalJSON(b []byte) error {
+	var coverage float64
+	if err := json.Unmarshal(b, &coverage); err == nil {
+		*r = ReconciliationCoverage{Coverage: coverage}
+		return nil
+	}
+
+	// reconciliationCoverage does not implement json.Unmarshaler,
+	// so decoding into it does not recurse.
+	type reconciliationCoverage ReconciliationCoverage
+	var decoded reconciliationCoverage
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&decoded); err != nil {
+		return err
+	}
+
+	*r = ReconciliationCoverage(decoded)
+	return nil
+}
+
 // DataEndConditions contains all the conditions for the syncer to stop
 // when running check:data. If any one of these conditions is considered
 // true, `check:data` will stop with success.
 type DataEndConditions struct {
 	// Index configures the syncer to stop once reaching a particular block height.
+	// If Index is negative, it is relative to the tip at startup.
 	Index *int64 `json:"index,omitempty"`
 
 	// Tip configures the syncer to stop once it reached the tip.
@@ -214,10 +624,78 @@ type DataEndConditions struct {
 	// ReconciliationCoverage configures the syncer to stop once it reaches
 	// some level of reconciliation coverage.
 	ReconciliationCoverage *ReconciliationCoverage `json:"reconciliation_coverage,omitempty"`
+
+	// BlockHash configures the syncer to stop once the block with
+	// BlockHash is synced (indexes can differ across forks, hashes
+	// cannot).
+	BlockHash *string `json:"block_hash,omitempty"`
+
+	// BlockHashIndex is the index BlockHash is expected at. If
+	// populated, check:data fails if another block is synced
+	// at BlockHashIndex.
+	BlockHashIndex *int64 `json:"block_hash_index,omitempty"`
+
+	// Reconciliations configures the syncer to stop once this many
+	// reconciliations (active and inactive) have succeeded.
+	Reconciliations *uint64 `json:"reconciliations,omitempty"`
+
+	// RequireAll configures the syncer to stop only once all populated
+	// end conditions are met at the same time (instead of once any of
+	// them is met). Tip and reconciliation coverage are re-evaluated
+	// as syncing continues, so they are no longer met if the syncer
+	// falls behind tip or coverage drops below the requirement. When
+	// enabled, syncing continues past Index until the other end
+	// conditions are met.
+	RequireAll bool `json:"require_all,omitempty"`
+}
+
+// BlockAuditConfiguration configures the audit of stored
+// blocks performed at the end of a successful check:data run.
+type BlockAuditConfiguration struct {
+	// SampleCount is the number of stored blocks to randomly
+	// sample, re-fetch, and compare against what was stored.
+	SampleCount int `json:"sample_count"`
+}
+
+// AcceptableDiscrepancy is a known reconciliation discrepancy
+// of an account and currency that is tolerated.
+type AcceptableDiscrepancy struct {
+	Account  *types.AccountIdentifier `json:"account_identifier"`
+	Currency *types.Currency          `json:"currency"`
+
+	// MaxDelta is the maximum absolute difference (in atomic
+	// units) between the computed and live balance.
+	MaxDelta string `json:"max_delta"`
+}
+
+// BalanceExemption is a reconciliation discrepancy in a direction
+// (like staking rewards that accrue without operations) that is
+// tolerated on matching accounts and currencies. Unpopulated
+// fields match any account or currency.
+type BalanceExemption struct {
+	// Account is matched like the entries of ExemptAccounts
+	// (an address-only entry matches any of its sub-accounts).
+	Account *types.AccountIdentifier `json:"account_identifier,omitempty"`
+
+	// SubAccountAddress matches any account with
+	// a sub-account with this address.
+	SubAccountAddress *string `json:"sub_account_address,omitempty"`
+
+	Currency *types.Currency `json:"currency,omitempty"`
+
+	// ExemptionType is the direction the live balance may differ
+	// from the computed balance (greater_or_equal, less_or_equal,
+	// or dynamic).
+	ExemptionType types.ExemptionType `json:"exemption_type"`
 }
 
 // DataConfiguration contains all configurations to run check:data.
 type DataConfiguration struct {
+	// HTTPTimeout overrides the top-level HTTPTimeout (in seconds) for
+	// all requests made by check:data. If not populated, the top-level
+	// HTTPTimeout is used.
+	HTTPTimeout uint64 `json:"http_timeout,omitempty"`
+
 	// ActiveReconciliationConcurrency is the concurrency to use while fetching accounts
 	// during active reconciliation.
 	ActiveReconciliationConcurrency uint64 `json:"active_reconciliation_concurrency"`
@@ -230,13 +708,28 @@ type DataConfiguration struct {
 	// inactive reconiliations on each account.
 	InactiveReconciliationFrequency uint64 `json:"inactive_reconciliation_frequency"`
 
+	// ReconciliationTimeout is the maximum number of seconds to wait for
+	// each account balance fetch during active and inactive reconciliation.
+	// A fetch that times out is counted and returned as a reconciliation
+	// error. If not populated, balance fetches are only bounded by
+	// http_timeout and max_retries.
+	ReconciliationTimeout uint64 `json:"reconciliation_timeout,omitempty"`
+
 	// LogBlocks is a boolean indicating whether to log processed blocks.
+	// Blocks are also logged at log_level debug.
 	LogBlocks bool `json:"log_blocks"`
 
 	// LogTransactions is a boolean indicating whether to log processed transactions.
+	// Transactions are also logged at log_level debug.
 	LogTransactions bool `json:"log_transactions"`
 
+	// LogTransactionOpTypes filters the logged transactions to those
+	// containing at least one operation of these types. If not
+	// populated, all transactions are logged.
+	LogTransactionOpTypes []string `json:"log_transaction_op_types,omitempty"`
+
 	// LogBalanceChanges is a boolean indicating whether to log all balance changes.
+	// Balance changes are also logged at log_level debug.
 	LogBalanceChanges bool `json:"log_balance_changes"`
 
 	// LogReconciliations is a boolean indicating whether to log all reconciliations.
@@ -247,16 +740,116 @@ type DataConfiguration struct {
 	// reconciliation errors during development.
 	IgnoreReconciliationError bool `json:"ignore_reconciliation_error"`
 
+	// ReconciliationFailureThreshold is the number of reconciliation
+	// failures tolerated before check:data halts. If it (or
+	// ReconciliationFailureRate) is populated, check:data keeps
+	// collecting failures until a threshold is exceeded instead of
+	// halting on the first failure. All failures are included in the
+	// results.
+	ReconciliationFailureThreshold int64 `json:"reconciliation_failure_threshold,omitempty"`
+
+	// ReconciliationFailureRate is the fraction (in [0, 1)) of attempted
+	// reconciliations that may fail before check:data halts. The rate is
+	// evaluated on each failure once at least 100 reconciliations have
+	// been attempted.
+	ReconciliationFailureRate float64 `json:"reconciliation_failure_rate,omitempty"`
+
+	// MaxErrors is the number of non-fatal errors tolerated before
+	// check:data halts. Non-fatal errors are reconciliation failures
+	// that did not halt check:data (i.e. with ignore_reconciliation_error),
+	// failed reconciliations that were exempted (by exempt_accounts or
+	// a balance exemption), currency casing warnings, and requests that
+	// only succeeded after more than fetch_retry_soft_limit failed
+	// attempts. If not populated, there is no limit. Non-fatal errors are
+	// always counted by type on the status endpoint and in the results.
+	MaxErrors int64 `json:"max_errors,omitempty"`
+
+	// FetchRetrySoftLimit is the number of failed attempts of a request
+	// (retried with retry_backoff or by the fetcher) that are tolerated
+	// before a request that eventually succeeds is counted as a non-fatal
+	// error. Attempts are matched by method, URL, and body. If not
+	// populated, DefaultFetchRetrySoftLimit is used.
+	FetchRetrySoftLimit uint64 `json:"fetch_retry_soft_limit,omitempty"`
+
 	// ExemptAccounts is a path relative to the configuration file
 	// to a file listing all accounts to exempt from balance
 	// tracking and reconciliation. Look at the examples directory for an example of
-	// how to structure this file.
+	// how to structure this file. An entry with a currency but no account
+	// exempts that currency from reconciliation on all accounts (balances
+	// of the currency are still tracked).
+	//
+	// An entry with only an address matches the address and any of its
+	// sub-accounts, an entry with sub-account address "*" matches any
+	// sub-account of the address, and metadata (of the account or
+	// sub-account) is only compared if it is populated in the entry.
 	ExemptAccounts string `json:"exempt_accounts"`
 
-	// BootstrapBalances is a path relative to the configuration file to a file used
-	// to bootstrap balances before starting syncing. If this value is populated after
-	// beginning syncing, it will be ignored.
-	BootstrapBalances string `json:"bootstrap_balances"`
+	// ExemptAccountsList is a list of accounts to exempt from balance
+	// tracking and reconciliation, structured like the entries in the
+	// ExemptAccounts file (including entries without an account). If both
+	// are populated, the accounts are merged.
+	ExemptAccountsList []*types.AccountCurrency `json:"exempt_accounts_list,omitempty"`
+
+	// AcceptableDiscrepancies are reconciliation discrepancies that
+	// are tolerated. A failed reconciliation of a listed account and
+	// currency is considered exempt if the absolute difference between
+	// the computed and live balance is at most max_delta. Unlike
+	// exempt_accounts, larger discrepancies still fail.
+	AcceptableDiscrepancies []*AcceptableDiscrepancy `json:"acceptable_discrepancies,omitempty"`
+
+	// BalanceExemptions are reconciliation discrepancies in a direction
+	// that are tolerated (like the balance exemptions an implementation
+	// can return in /network/options). A failed reconciliation that
+	// matches an exemption is logged (with the delta) and counted as
+	// exempt instead of failing.
+	BalanceExemptions []*BalanceExemption `json:"balance_exemptions,omitempty"`
+
+	// BalanceExemptionsCoverage determines if accounts reconciled by a
+	// BalanceExemption count towards reconciliation coverage (by default,
+	// they are not considered reconciled).
+	BalanceExemptionsCoverage bool `json:"balance_exemptions_coverage,omitempty"`
+
+	// ExcludedOperationTypes are operation types that are never included
+	// in balance changes (i.e. synthetic operations that do not affect
+	// fetchable balances).
+	ExcludedOperationTypes []string `json:"excluded_operation_types,omitempty"`
+
+	// BootstrapBalances is a path (or a list of paths) relative to the
+	// configuration file to files used to bootstrap balances before starting
+	// syncing. The balances in all files are concatenated and an account
+	// and currency may only appear once across all files. If this value is
+	// populated after beginning syncing, it will be ignored.
+	BootstrapBalances FilePaths `json:"bootstrap_balances"`
+
+	// BootstrapBalancesList is a list of balances used to bootstrap
+	// balances before starting syncing, structured like the entries in
+	// the BootstrapBalances file. It cannot be populated with
+	// BootstrapBalances.
+	BootstrapBalancesList []*modules.BootstrapBalance `json:"bootstrap_balances_list,omitempty"`
+
+	// BootstrapBalancesConcurrency is the number of workers that write
+	// bootstrapped balances to storage (in batches).
+	BootstrapBalancesConcurrency uint64 `json:"bootstrap_balances_concurrency"`
+
+	// TransactionOverrides is a path relative to the configuration file
+	// to a file that replaces the balance changes of specific transactions
+	// (i.e. transactions affected by a consensus bug) with corrected balance
+	// changes. Every override applied is logged and reported in the results.
+	// Look at the examples directory for an example of how to structure
+	// this file.
+	TransactionOverrides string `json:"transaction_overrides,omitempty"`
+
+	// EndpointOverrides maps Rosetta endpoints (i.e. /account/balance) to a
+	// fixture file or directory (relative to the configuration file) that
+	// requests to the endpoint are served from instead of the implementation.
+	// This is useful for exercising a partial implementation. Look at the
+	// examples directory for an example of how to structure fixtures. Runs
+	// with any override active never report success unless
+	// --allow-overrides-success is provided.
+	EndpointOverrides map[string]string `json:"endpoint_overrides,omitempty"`
+
+	// AllowEndpointOverridesSuccess is set by --allow-overrides-success.
+	AllowEndpointOverridesSuccess bool `json:"-"`
 
 	// HistoricalBalanceDisabled is a boolean that dictates how balance lookup is performed.
 	// When set to false, balances are looked up at the block where a balance
@@ -264,15 +857,39 @@ type DataConfiguration struct {
 	// historical balance lookup should set this to true.
 	HistoricalBalanceDisabled *bool `json:"historical_balance_disabled,omitempty"`
 
+	// ArchiveURL is the URL of a Rosetta API implementation of the same
+	// network that supports historical balance lookup (like an archive
+	// node). If populated, historical balance lookup is enabled and all
+	// balances are looked up from the ArchiveURL (while blocks are still
+	// synced from the OnlineURL). Requests to the ArchiveURL use the
+	// same TLS configuration as requests to the OnlineURL.
+	ArchiveURL string `json:"archive_url,omitempty"`
+
 	// InterestingAccounts is a path to a file listing all accounts to check on each block. Look
 	// at the examples directory for an example of how to structure this file.
+	//
+	// Entries are matched like ExemptAccounts entries: any account that matches
+	// an entry (like a sub-account of an address-only entry) is checked on each
+	// block after its first balance change. Entries with sub-account address "*"
+	// are only used for matching.
 	InterestingAccounts string `json:"interesting_accounts"`
 
+	// InterestingAccountsReloadInterval is the frequency (in seconds) that
+	// InterestingAccounts is re-read so that newly added accounts are checked
+	// on subsequent blocks. The file is also re-read whenever SIGHUP is
+	// received. If not populated, the file is only re-read on SIGHUP.
+	InterestingAccountsReloadInterval uint64 `json:"interesting_accounts_reload_interval,omitempty"`
+
 	// ReconciliationDisabled is a boolean that indicates reconciliation should not
 	// be attempted. When first testing an implementation, it can be useful to disable
 	// some of the more advanced checks to confirm syncing is working as expected.
 	ReconciliationDisabled bool `json:"reconciliation_disabled"`
 
+	// ReconciliationCurrencies restricts active and inactive reconciliation
+	// to accounts holding these currencies. Balances of all currencies are
+	// still tracked. If not populated, all currencies are reconciled.
+	ReconciliationCurrencies []*types.Currency `json:"reconciliation_currencies,omitempty"`
+
 	// ReconciliationDrainDisabled is a boolean that configures the rosetta-cli
 	// to exit check:data before the entire active reconciliation queue has
 	// been drained (if reconciliation is enabled).
@@ -299,20 +916,56 @@ type DataConfiguration struct {
 	// StartIndex is the block height to start syncing from. If no StartIndex
 	// is provided, syncing will start from the last saved block.
 	// If no blocks have ever been synced, syncing will start from genesis.
+	//
+	// If StartIndex is negative, it is relative to the tip at startup
+	// (i.e. -500 starts syncing 500 blocks before the current tip).
 	StartIndex *int64 `json:"start_index,omitempty"`
 
+	// GenesisIndex is the block height to start syncing from when no
+	// StartIndex is provided and no blocks have ever been synced. This
+	// should be set for blockchains whose Rosetta implementation does
+	// not start indexing blocks at 0.
+	GenesisIndex *int64 `json:"genesis_index,omitempty"`
+
 	// EndCondition contains the conditions for the syncer to stop.
 	EndConditions *DataEndConditions `json:"end_conditions,omitempty"`
 
 	// StatusPort allows the caller to query a running check:data
 	// test to get stats about progress. This can be used instead
 	// of parsing logs to populate some sort of status dashboard.
-	StatusPort uint `json:"status_port,omitempty"`
+	//
+	// Liveness and readiness verdicts (suitable for Kubernetes probes)
+	// are served at /healthz and /readyz. A run is live if the head block
+	// has advanced within TipDelay while behind tip. A run is ready if
+	// it is within TipDelay of tip and the reconciler queue is draining
+	// (it has not grown in each of the last few status intervals).
+	//
+	// If not populated, DefaultStatusPort is used. If 0, no status
+	// server is started.
+	StatusPort *uint `json:"status_port,omitempty"`
 
 	// ResultsOutputFile is the absolute filepath of where to save
 	// the results of a check:data run.
 	ResultsOutputFile string `json:"results_output_file"`
 
+	// ResultsOutputFormat is the format ResultsOutputFile is written
+	// in (json, yaml, or text). If not populated, json is used. Only
+	// json results can be used as a baseline.
+	ResultsOutputFormat ResultsOutputFormat `json:"results_output_format,omitempty"`
+
+	// PreviousResultsFile is the filepath of the json results of a
+	// previous check:data run (often the same as ResultsOutputFile).
+	// If populated and found, the change of throughput, duration, and
+	// reconciliation counts since that run is included in the results.
+	PreviousResultsFile string `json:"previous_results_file,omitempty"`
+
+	// FailureReportOperations is the number of recent balance-changing
+	// operations of the failing account included in the failure report
+	// (failure_report.json in the data directory) written when check:data
+	// halts on a reconciliation failure. If not populated, 10 operations
+	// are included.
+	FailureReportOperations int `json:"failure_report_operations,omitempty"`
+
 	// PruningDisabled is a bolean that indicates storage pruning should
 	// not be attempted. This should really only ever be set to true if you
 	// wish to use `start_index` at a later point to restart from some
@@ -324,6 +977,111 @@ type DataConfiguration struct {
 	// provided in the `statefulsyncer` package.
 	PruningFrequency *int `json:"pruning_frequency,omitempty"`
 
+	// PruningDepth is the minimum number of blocks behind the head
+	// block that are retained when pruning. It must be >= MaxReorgDepth.
+	// If not populated, MaxReorgDepth is used.
+	PruningDepth *int64 `json:"pruning_depth,omitempty"`
+
+	// PruningBlockInterval is the minimum number of blocks that must be
+	// synced between prune passes. This is useful for keeping the last
+	// blocks around for debugging without pruning on each PruningFrequency
+	// tick. If not populated, we prune whenever there are blocks to prune.
+	PruningBlockInterval *int64 `json:"pruning_block_interval,omitempty"`
+
+	// MaxDataDirectorySizeMB is the maximum size (in MB) of the
+	// check:data data directory. When the directory approaches this
+	// size, pruning is escalated (only MaxReorgDepth blocks are
+	// retained and blocks are pruned on every pass). If the limit is
+	// still exceeded once nothing more can be pruned, check:data halts.
+	// If not populated, the size of the data directory is not limited.
+	MaxDataDirectorySizeMB uint64 `json:"max_data_directory_size_mb,omitempty"`
+
+	// HaltOnReorg, if true, causes check:data to halt with the Reorg
+	// end condition (and an error) when a block is orphaned instead
+	// of removing it and continuing. This is useful when validating
+	// an implementation (like an archive node) that should never reorg.
+	HaltOnReorg bool `json:"halt_on_reorg,omitempty"`
+
+	// CheckpointsFile is a path relative to the configuration file to a
+	// list of trusted block identifiers. Each synced block at the index of
+	// a trusted checkpoint must have the hash of the checkpoint, otherwise
+	// check:data halts. Blocks at indexes without a checkpoint are not
+	// checked. Look at the examples directory for an example of how to
+	// structure this file.
+	CheckpointsFile string `json:"checkpoints_file,omitempty"`
+
+	// SlowBlockThreshold is the time (in milliseconds) that fetching
+	// and processing a single block may take before a warning is logged
+	// with the index of the block and the elapsed time. If populated, the
+	// slowest block is also included in the results. If not populated,
+	// blocks are not timed.
+	SlowBlockThreshold uint64 `json:"slow_block_threshold,omitempty"`
+
+	// StorageEstimateSamples is the number of blocks fetched to estimate
+	// the storage required by a run (with --estimate-storage). If not
+	// populated, 20 blocks are sampled.
+	StorageEstimateSamples int `json:"storage_estimate_samples,omitempty"`
+
+	// BlockFetchConcurrency is the maximum number of blocks fetched
+	// concurrently while syncing. The syncer lowers the number of
+	// blocks fetched concurrently when fetched blocks would exceed
+	// BlockBufferSize. If not populated, max_sync_concurrency is used.
+	BlockFetchConcurrency int64 `json:"block_fetch_concurrency,omitempty"`
+
+	// BlockBufferSize is the size (in MB) of fetched blocks buffered
+	// before they are processed. If not populated, 2000 MB is used.
+	BlockBufferSize uint64 `json:"block_buffer_size,omitempty"`
+
+	// TipLongPoll configures check:data to long poll for the next
+	// block once it has synced to tip. Instead of polling
+	// /network/status every few seconds, /block is requested for
+	// tip+1 and re-requested after TipLongPollDelayMs (with jitter)
+	// whenever the implementation responds with an error code in
+	// TipLongPollNotFoundCodes. If the implementation responds with
+	// any other error, check:data falls back to polling.
+	TipLongPoll bool `json:"tip_long_poll,omitempty"`
+
+	// TipLongPollNotFoundCodes are the error codes the implementation
+	// returns when a block does not exist yet. It must be populated
+	// if TipLongPoll is enabled.
+	TipLongPollNotFoundCodes []int32 `json:"tip_long_poll_not_found_codes,omitempty"`
+
+	// TipLongPollDelayMs is the delay before re-requesting a block
+	// that does not exist yet. Up to 100% jitter is added to each
+	// delay. If not populated, 100ms is used.
+	TipLongPollDelayMs uint64 `json:"tip_long_poll_delay_ms,omitempty"`
+
+	// TipLongPollTimeout is the number of seconds to long poll for
+	// the next block before polling /network/status again. If not
+	// populated, 30 seconds is used.
+	TipLongPollTimeout uint64 `json:"tip_long_poll_timeout,omitempty"`
+
+	// StaticTip, if populated, is the index treated as tip instead
+	// of the current block returned by /network/status (which is only
+	// requested once, to get the genesis block). This is useful when
+	// syncing a static dataset (like an archive that is no longer
+	// updated). Syncing stops at StaticTip, and tip end conditions
+	// are met once StaticTip is synced (tip_delay is not used).
+	StaticTip *int64 `json:"static_tip,omitempty"`
+
+	// ProgressLogInterval is the number of seconds between each
+	// one-line progress summary (the current and average rate of
+	// blocks synced and, if an index or tip end condition is
+	// configured, the blocks remaining and ETA). If not populated,
+	// 60 seconds is used.
+	ProgressLogInterval uint64 `json:"progress_log_interval,omitempty"`
+
+	// EstimateStorage is set by --estimate-storage and
+	// StrictStorageEstimate is set by --strict.
+	EstimateStorage       bool `json:"-"`
+	StrictStorageEstimate bool `json:"-"`
+
+	// Resume is set by --resume.
+	Resume bool `json:"-"`
+
+	// Fresh is set by --fresh.
+	Fresh bool `json:"-"`
+
 	// InitialBalanceFetchDisabled configures rosetta-cli
 	// not to lookup the balance of newly seen accounts at the
 	// parent block before applying operations. Disabling
@@ -337,11 +1095,78 @@ type DataConfiguration struct {
 	// to keep in the active reconciliation backlog before skipping
 	// reconciliation on new changes.
 	ReconcilerActiveBacklog *int `json:"reconciler_active_backlog,omitempty"`
+
+	// EndOfRunBlockAudit configures check:data to re-fetch a random
+	// sample of stored blocks once an end condition is reached and
+	// to fail if the implementation no longer serves exactly what
+	// was stored (or if stored blocks are corrupt).
+	EndOfRunBlockAudit *BlockAuditConfiguration `json:"end_of_run_block_audit,omitempty"`
+
+	// MonitorAfterEndConditions, if true, causes check:data to keep
+	// following tip once its end conditions are met instead of exiting.
+	// The results of the passing check are written as usual and any
+	// reconciliation failures found afterwards are reported (and passed
+	// to the reconciliation failure hook, if configured) without halting.
+	// check:data then runs until it is stopped by a signal or by a POST
+	// to /monitor/stop on the status server.
+	MonitorAfterEndConditions bool `json:"monitor_after_end_conditions,omitempty"`
+
+	// MonitorResultsOutputFile is the absolute filepath of where to save
+	// the findings of monitor mode. It is rewritten on each finding so
+	// that the results of the passing check are never modified.
+	MonitorResultsOutputFile string `json:"monitor_results_output_file,omitempty"`
+
+	// AsserterConfigurationFile is the absolute filepath of where to save
+	// the asserter configuration (allowed operation types, statuses, and
+	// errors) resolved from /network/options when check:data starts.
+	// This is the same file utils:asserter-configuration generates.
+	AsserterConfigurationFile string `json:"asserter_configuration_file,omitempty"`
+
+	// CurrencyCasingCheck, if true, records the first-seen currency for
+	// each symbol (compared case-insensitively) and warns about every
+	// balance-affecting operation that uses the same symbol with a
+	// different casing, decimals, or metadata. All variants found are
+	// written to the results.
+	CurrencyCasingCheck bool `json:"currency_casing_check,omitempty"`
+
+	// WarningsAsErrors, if true, causes check:data to fail at the end
+	// of an otherwise successful run if any warnings were found by
+	// optional checks (i.e. currency_casing_check).
+	WarningsAsErrors bool `json:"warnings_as_errors,omitempty"`
+
+	// ReconciliationFailureHook, if populated, is an executable invoked
+	// on each reconciliation failure. The outcome of the hook never
+	// affects the outcome of check:data.
+	ReconciliationFailureHook *ReconciliationFailureHookConfiguration `json:"reconciliation_failure_hook,omitempty"` // nolint:lll
+}
+
+// ReconciliationFailureHookConfiguration configures the executable
+// invoked on each reconciliation failure in check:data. The hook is
+// provided a JSON description of the failure on stdin. Look at the
+// examples directory for an example hook.
+type ReconciliationFailureHookConfiguration struct {
+	// Path is the path to the hook executable. Relative paths are
+	// relative to the configuration file.
+	Path string `json:"path"`
+
+	// Timeout is the number of seconds a hook invocation may run
+	// before it is killed.
+	Timeout uint64 `json:"timeout"`
+
+	// MaxConcurrency is the maximum number of hook invocations
+	// that may run at once. Additional invocations wait for a
+	// running invocation to finish.
+	MaxConcurrency int `json:"max_concurrency"`
 }
 
 // Configuration contains all configuration settings for running
 // check:data or check:construction.
 type Configuration struct {
+	// ConfigVersion is the version of the configuration file format.
+	// Configuration files with an older (or no) version are migrated
+	// when loaded (see configuration:migrate).
+	ConfigVersion int `json:"config_version"`
+
 	// Network is the *types.NetworkIdentifier where transactions should
 	// be constructed and where blocks should be synced to monitor
 	// for broadcast success.
@@ -350,6 +1175,14 @@ type Configuration struct {
 	// OnlineURL is the URL of a Rosetta API implementation in "online mode".
 	OnlineURL string `json:"online_url"`
 
+	// ComparisonURL is the URL of a second Rosetta API implementation
+	// of the same network. If populated, check:data fetches each synced
+	// block from it and fails at the first block whose operations or
+	// balance changes differ from those served by the OnlineURL.
+	// Requests to the ComparisonURL use the same TLS configuration
+	// as requests to the OnlineURL.
+	ComparisonURL string `json:"comparison_url,omitempty"`
+
 	// DataDirectory is a folder used to store logs and any data used to perform validation.
 	// The path can be absolute, or it can be relative to where rosetta-cli
 	// binary is being executed.
@@ -365,6 +1198,12 @@ type Configuration struct {
 	// RetryElapsedTime is the total time to spend retrying a HTTP request in seconds.
 	RetryElapsedTime uint64 `json:"retry_elapsed_time"`
 
+	// RetryBackoff, if populated, configures the backoff between
+	// retries of failed requests (bounded by MaxRetries and
+	// RetryElapsedTime). When populated, HTTPTimeout applies to each
+	// attempt instead of to all attempts of a request.
+	RetryBackoff *RetryBackoffConfiguration `json:"retry_backoff,omitempty"`
+
 	// MaxOnlineConnections is the maximum number of open connections that the online
 	// fetcher will open.
 	MaxOnlineConnections int `json:"max_online_connections"`
@@ -373,15 +1212,83 @@ type Configuration struct {
 	// on all non-200 responses.
 	ForceRetry bool `json:"force_retry,omitempty"`
 
+	// MaxBlockSizeBytes, if populated, is the maximum size of the
+	// response body read for each /block (and /block/transaction)
+	// request. A block with a larger response fails with an error
+	// instead of being buffered in memory. This can be overridden
+	// with --max-block-size.
+	MaxBlockSizeBytes int64 `json:"max_block_size_bytes,omitempty"`
+
+	// TLS configures client certificates and custom CAs for
+	// requests to the OnlineURL (and the OfflineURL, if not
+	// overridden in the construction configuration).
+	TLS *TLSConfiguration `json:"tls,omitempty"`
+
+	// TLSClientCertFile is shorthand for tls.cert_file.
+	TLSClientCertFile string `json:"tls_client_cert_file,omitempty"`
+
+	// TLSClientKeyFile is shorthand for tls.key_file.
+	TLSClientKeyFile string `json:"tls_client_key_file,omitempty"`
+
+	// TLSCAFile is shorthand for tls.ca_file.
+	TLSCAFile string `json:"tls_ca_file,omitempty"`
+
+	// ProxyURL is the URL of a proxy to use for all outbound requests
+	// (to the OnlineURL, OfflineURL, ComparisonURL, ArchiveURL, and
+	// faucet). The http, https, and socks5 schemes are supported. If
+	// not populated, the proxy is determined by the HTTP_PROXY,
+	// HTTPS_PROXY, and NO_PROXY environment variables.
+	ProxyURL string `json:"proxy_url,omitempty"`
+
+	// Headers are attached to every request made to the OnlineURL
+	// and OfflineURL (i.e. an Authorization header required by an API
+	// gateway). Values of sensitive headers are redacted when the
+	// configuration is logged.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// RequestIDHeader, if populated, attaches a unique request id to
+	// every request made to the OnlineURL and OfflineURL. The request id
+	// is included in any error caused by the request so that failures can
+	// be correlated with the implementation's logs.
+	RequestIDHeader *RequestIDHeaderConfiguration `json:"request_id_header,omitempty"`
+
+	// UserAgent is the User-Agent header of every request made to the
+	// OnlineURL and OfflineURL. Any occurrence of "{run_id}" is replaced
+	// with a UUID that is unique to each invocation of rosetta-cli (and is
+	// included in the results file). If not populated, the User-Agent is
+	// rosetta-cli/<version>.
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// Baseline, if populated, compares the findings of check:data or
+	// check:construction with those of an accepted run.
+	Baseline *BaselineConfiguration `json:"baseline,omitempty"`
+
+	// RunID uniquely identifies this invocation of rosetta-cli.
+	// It is populated at startup and is never loaded from or
+	// written to a configuration file.
+	RunID string `json:"-"`
+
 	// MaxSyncConcurrency is the maximum sync concurrency to use while syncing blocks.
 	// Sync concurrency is managed automatically by the `syncer` package.
 	MaxSyncConcurrency int64 `json:"max_sync_concurrency"`
 
+	// MaxBlocksPerSecond is the maximum number of blocks fetched per
+	// second while syncing (regardless of MaxSyncConcurrency). Fetches
+	// are spread out evenly instead of sent in bursts. If 0, block
+	// fetches are not throttled.
+	MaxBlocksPerSecond float64 `json:"max_blocks_per_second,omitempty"`
+
 	// TipDelay dictates how many seconds behind the current time is considered
 	// tip. If we are > TipDelay seconds from the last processed block,
 	// we are considered to be behind tip.
 	TipDelay int64 `json:"tip_delay"`
 
+	// TipPollInterval is the number of seconds to wait between checks
+	// of /network/status to determine if the Rosetta implementation is at
+	// tip (when evaluating data end conditions and when waiting for tip
+	// in check:construction). It must be between 1 and 600.
+	TipPollInterval int64 `json:"tip_poll_interval,omitempty"`
+
 	// MaxReorgDepth specifies the maximum possible reorg depth of the blockchain
 	// being synced. This value is used to determine how aggressively to prune
 	// old block data.
@@ -391,10 +1298,48 @@ type Configuration struct {
 	// error caused by missing block data!
 	MaxReorgDepth int `json:"max_reorg_depth,omitempty"`
 
+	// TipDelayClockSkewCorrection configures rosetta-cli to adjust TipDelay
+	// by the estimated clock skew between the host running rosetta-cli and
+	// the Rosetta implementation. Skew is estimated at startup (and periodically
+	// afterwards) using the Date header returned on /network/status and the
+	// timestamp of the latest block.
+	TipDelayClockSkewCorrection bool `json:"tip_delay_clock_skew_correction,omitempty"`
+
+	// ClockSkewWarningThreshold is the absolute clock skew (in seconds) above
+	// which a warning is printed. Skews this large usually indicate a broken
+	// node clock rather than a broken test host.
+	ClockSkewWarningThreshold int64 `json:"clock_skew_warning_threshold,omitempty"`
+
 	// LogConfiguration determines if the configuration settings
 	// should be printed to the console when a file is loaded.
 	LogConfiguration bool `json:"log_configuration"`
 
+	// LogLevel is the verbosity of check:data and check:construction
+	// output (one of "debug", "info", "warn", or "error"). At "debug",
+	// processed blocks, transactions, and balance changes are logged
+	// (as if log_blocks, log_transactions, and log_balance_changes were
+	// enabled) and, when retry_backoff is populated, fetch retries are
+	// printed. Without retry_backoff, fetch retries are printed at every
+	// level. At "info" (the default), periodic status summaries are
+	// printed. At "warn", only warnings (like reconciliation failures)
+	// and errors are printed. The data logging booleans enable their
+	// respective streams (and stream files) at any level.
+	LogLevel zapcore.Level `json:"log_level"`
+
+	// LogFormat is the format of the block, transaction, and
+	// reconciliation logs printed to the console ("text" or
+	// "json"). In "json", each log is printed as a JSON line
+	// with the level, msg, block_index, account, and currency
+	// keys (when applicable) and colors are disabled. Stream
+	// files are always written as text. If not populated, logs
+	// are printed as text.
+	LogFormat LogFormat `json:"log_format,omitempty"`
+
+	// DisableColors prints all output without colors. Colors are
+	// also disabled if the NO_COLOR environment variable is set or
+	// if stdout is not a terminal.
+	DisableColors bool `json:"disable_colors"`
+
 	// CompressionDisabled configures the storage layer to not
 	// perform data compression before writing to disk. This leads
 	// to significantly more on-disk storage usage but can lead
@@ -406,6 +1351,10 @@ type Configuration struct {
 	// but can use 10s of GBs of RAM, even with pruning enabled.
 	MemoryLimitDisabled bool `json:"memory_limit_disabled"`
 
+	// Storage configures adaptive commit batching
+	// of blocks in check:data.
+	Storage *StorageConfiguration `json:"storage,omitempty"`
+
 	// SeenBlockWorkers is the number of goroutines spawned to store
 	// seen blocks in storage before we attempt to sequence. If not populated,
 	// this value defaults to runtime.NumCPU().
@@ -433,4 +1382,12 @@ type Configuration struct {
 
 	Construction *ConstructionConfiguration `json:"construction"`
 	Data         *DataConfiguration         `json:"data"`
+
+	// Profiles is a map of profile name to a partial configuration that
+	// is overlaid on this configuration when the profile is selected
+	// (with --profile). Objects in a profile are merged with the objects
+	// they override and all other values replace the values they
+	// override. This is useful when running against multiple networks
+	// with mostly identical configurations.
+	Profiles map[string]map[string]interface{} `json:"profiles,omitempty"`
 }
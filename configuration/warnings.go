@@ -0,0 +1,109 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configuration
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"go.uber.org/zap/zapcore"
+)
+
+// Warning is a combination of configuration options
+// that is valid but likely does not behave as intended.
+type Warning struct {
+	Options     []string `json:"options"`
+	Explanation string   `json:"explanation"`
+}
+
+// String returns a one-line representation of a *Warning.
+func (w *Warning) String() string {
+	return fmt.Sprintf("%s: %s", strings.Join(w.Options, " + "), w.Explanation)
+}
+
+// DataWarnings returns a *Warning for each suspicious
+// combination of check:data configuration options.
+func DataWarnings(config *Configuration) []*Warning {
+	if config.Data == nil {
+		return nil
+	}
+
+	warnings := []*Warning{}
+	if config.Data.StartIndex != nil && !config.Data.PruningDisabled {
+		warnings = append(warnings, &Warning{
+			Options: []string{"data.start_index", "data.pruning_disabled=false"},
+			Explanation: "synced blocks are pruned, so a later run with an earlier " +
+				"start_index cannot re-check them from storage",
+		})
+	}
+
+	if config.Data.HistoricalBalanceDisabled != nil &&
+		*config.Data.HistoricalBalanceDisabled &&
+		len(config.Data.ArchiveURL) == 0 &&
+		!config.Data.InactiveDiscrepancySearchDisabled &&
+		!config.Data.ReconciliationDisabled {
+		warnings = append(warnings, &Warning{
+			Options: []string{
+				"data.historical_balance_disabled=true",
+				"data.inactive_discrepancy_search_disabled=false",
+			},
+			Explanation: "the inactive discrepancy search requires historical balance " +
+				"lookup and will never run",
+		})
+	}
+
+	if len(config.Data.LogTransactionOpTypes) > 0 && !DataLogCategories(config).Transactions {
+		warnings = append(warnings, &Warning{
+			Options: []string{"data.log_transaction_op_types", "data.log_transactions=false"},
+			Explanation: "transactions are not logged, so they are never filtered " +
+				"by operation type",
+		})
+	}
+
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	return warnings
+}
+
+// ConstructionWarnings returns a *Warning for each suspicious
+// combination of check:construction configuration options.
+func ConstructionWarnings(config *Configuration) []*Warning {
+	if config.Construction == nil {
+		return nil
+	}
+
+	if config.Construction.Quiet && config.LogLevel.Enabled(zapcore.DebugLevel) {
+		return []*Warning{
+			{
+				Options: []string{"construction.quiet=true", "log_level=debug"},
+				Explanation: "quiet silences construction request logging regardless " +
+					"of log_level",
+			},
+		}
+	}
+
+	return nil
+}
+
+// printWarnings prints each *Warning of config to the console.
+func printWarnings(config *Configuration) {
+	warnings := append(DataWarnings(config), ConstructionWarnings(config)...)
+	for _, warning := range warnings {
+		color.Yellow("[WARNING] %s\n", warning)
+	}
+}
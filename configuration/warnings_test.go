@@ -0,0 +1,135 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWarnings(t *testing.T) {
+	var tests = map[string]struct {
+		config *Configuration
+
+		expectedData         []string
+		expectedConstruction []string
+	}{
+		"default": {
+			config: DefaultConfiguration(),
+		},
+		"start index with pruning": {
+			config: &Configuration{
+				Data: &DataConfiguration{StartIndex: types.Int64(10)},
+			},
+			expectedData: []string{
+				"data.start_index + data.pruning_disabled=false: synced blocks are pruned, " +
+					"so a later run with an earlier start_index cannot re-check them from storage",
+			},
+		},
+		"start index without pruning": {
+			config: &Configuration{
+				Data: &DataConfiguration{StartIndex: types.Int64(10), PruningDisabled: true},
+			},
+		},
+		"historical balance disabled": {
+			config: &Configuration{
+				Data: &DataConfiguration{
+					HistoricalBalanceDisabled: types.Bool(true),
+					PruningDisabled:           true,
+				},
+			},
+			expectedData: []string{
+				"data.historical_balance_disabled=true + " +
+					"data.inactive_discrepancy_search_disabled=false: the inactive " +
+					"discrepancy search requires historical balance lookup and will never run",
+			},
+		},
+		"historical balance disabled with archive url": {
+			config: &Configuration{
+				Data: &DataConfiguration{
+					HistoricalBalanceDisabled: types.Bool(true),
+					ArchiveURL:                "http://archive:8080",
+				},
+			},
+		},
+		"historical balance disabled without search": {
+			config: &Configuration{
+				Data: &DataConfiguration{
+					HistoricalBalanceDisabled:         types.Bool(true),
+					InactiveDiscrepancySearchDisabled: true,
+				},
+			},
+		},
+		"transaction op types without logging transactions": {
+			config: &Configuration{
+				LogLevel: zapcore.InfoLevel,
+				Data: &DataConfiguration{
+					LogTransactionOpTypes: []string{"transfer"},
+					PruningDisabled:       true,
+				},
+			},
+			expectedData: []string{
+				"data.log_transaction_op_types + data.log_transactions=false: transactions " +
+					"are not logged, so they are never filtered by operation type",
+			},
+		},
+		"transaction op types": {
+			config: &Configuration{
+				LogLevel: zapcore.InfoLevel,
+				Data: &DataConfiguration{
+					LogTransactions:       true,
+					LogTransactionOpTypes: []string{"transfer"},
+					PruningDisabled:       true,
+				},
+			},
+		},
+		"quiet construction at debug": {
+			config: &Configuration{
+				LogLevel:     zapcore.DebugLevel,
+				Construction: &ConstructionConfiguration{Quiet: true},
+			},
+			expectedConstruction: []string{
+				"construction.quiet=true + log_level=debug: quiet silences construction " +
+					"request logging regardless of log_level",
+			},
+		},
+		"quiet construction at info": {
+			config: &Configuration{
+				LogLevel:     zapcore.InfoLevel,
+				Construction: &ConstructionConfiguration{Quiet: true},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var data []string
+			for _, warning := range DataWarnings(test.config) {
+				data = append(data, warning.String())
+			}
+
+			var construction []string
+			for _, warning := range ConstructionWarnings(test.config) {
+				construction = append(construction, warning.String())
+			}
+
+			assert.Equal(t, test.expectedData, data)
+			assert.Equal(t, test.expectedConstruction, construction)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+#!/bin/sh
+# Example reconciliation failure hook for check:data.
+#
+# rosetta-cli writes a JSON description of each reconciliation
+# failure to stdin:
+#
+# {
+#   "run_id": "...",
+#   "failure_type": "ACTIVE",
+#   "account_identifier": {"address": "..."},
+#   "currency": {"symbol": "BTC", "decimals": 8},
+#   "computed_balance": "100",
+#   "live_balance": "90",
+#   "block_identifier": {"index": 1000, "hash": "..."}
+# }
+#
+# A non-zero exit code is logged by rosetta-cli but never
+# affects the outcome of check:data.
+set -e
+
+FAILURES_FILE="${FAILURES_FILE:-reconciliation_failures.jsonl}"
+
+# Append the failure to a file (one JSON object per line). Replace
+# this with a call to your ticketing or tracing system.
+tr -d '\n' >> "$FAILURES_FILE"
+echo >> "$FAILURES_FILE"
@@ -3,12 +3,15 @@ module github.com/coinbase/rosetta-cli
 go 1.16
 
 require (
+	github.com/DataDog/zstd v1.5.0
 	github.com/coinbase/rosetta-sdk-go v0.7.7
 	github.com/fatih/color v1.13.0
+	github.com/neilotoole/errgroup v0.1.6
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/cobra v1.3.0
 	github.com/stretchr/testify v1.7.0
 	go.uber.org/zap v1.21.0
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,318 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+var (
+	// ErrEndpointOverrides is returned when a run that served
+	// responses from fixtures would otherwise succeed (and
+	// success was not explicitly allowed).
+	ErrEndpointOverrides = errors.New(
+		"endpoint overrides were active so results are not representative " +
+			"(pass --allow-overrides-success to report success)",
+	)
+
+	// ErrNoMatchingFixture is returned (as a non-retriable
+	// Rosetta error) when no fixture matches a request.
+	ErrNoMatchingFixture = errors.New("no fixture matches request")
+)
+
+// Fixture is a canned response to requests to an endpoint.
+// A request matches a Fixture if every field in Request is
+// present (and equal) in the request body, so a Fixture
+// without a Request matches all requests. For example,
+// /account/balance fixtures are usually keyed by the
+// account_identifier and block_identifier of the request.
+type Fixture struct {
+	Request    map[string]interface{} `json:"request,omitempty"`
+	Response   json.RawMessage        `json:"response"`
+	StatusCode int                    `json:"status_code,omitempty"`
+}
+
+// LoadFixtures returns the fixtures in filePath. If filePath
+// is a directory, the fixtures in all .json files in
+// the directory are returned (ordered by file name).
+func LoadFixtures(filePath string) ([]*Fixture, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to open fixtures %s", err, filePath)
+	}
+
+	files := []string{filePath}
+	if info.IsDir() {
+		entries, err := ioutil.ReadDir(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read fixtures directory %s", err, filePath)
+		}
+
+		files = []string{}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+				files = append(files, path.Join(filePath, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	fixtures := []*Fixture{}
+	for _, file := range files {
+		fileFixtures := []*Fixture{}
+		if err := utils.LoadAndParse(file, &fileFixtures); err != nil {
+			return nil, fmt.Errorf("%w: unable to parse fixtures %s", err, file)
+		}
+
+		for i, fixture := range fileFixtures {
+			if fixture == nil || len(fixture.Response) == 0 {
+				return nil, fmt.Errorf("fixture %d in %s is missing a response", i, file)
+			}
+		}
+
+		fixtures = append(fixtures, fileFixtures...)
+	}
+
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", filePath)
+	}
+
+	return fixtures, nil
+}
+
+// matches returns a boolean indicating if actual contains
+// every field in pattern (recursively for objects).
+func matches(pattern interface{}, actual interface{}) bool {
+	patternObject, ok := pattern.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(pattern, actual)
+	}
+
+	actualObject, ok := actual.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for key, value := range patternObject {
+		if !matches(value, actualObject[key]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// endpoint is an endpoint served from fixtures.
+type endpoint struct {
+	source   string
+	fixtures []*Fixture
+	served   int64
+}
+
+// EndpointOverrides serves requests to overridden endpoints from
+// fixtures instead of the Rosetta implementation.
+type EndpointOverrides struct {
+	endpoints map[string]*endpoint
+}
+
+// NewEndpointOverrides loads the fixtures of each endpoint in overrides
+// (endpoint -> fixture file or directory). If overrides is
+// empty, nil is returned.
+func NewEndpointOverrides(overrides map[string]string) (*EndpointOverrides, error) {
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+
+	o := &EndpointOverrides{endpoints: map[string]*endpoint{}}
+	for endpointPath, source := range overrides {
+		fixtures, err := LoadFixtures(source)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load fixtures for %s", err, endpointPath)
+		}
+
+		o.endpoints[endpointPath] = &endpoint{source: source, fixtures: fixtures}
+		color.Yellow(
+			"[WARNING] serving %s from %d fixture(s) in %s instead of the implementation",
+			endpointPath,
+			len(fixtures),
+			source,
+		)
+	}
+
+	return o, nil
+}
+
+// Transport returns an http.RoundTripper that serves requests
+// to overridden endpoints from fixtures and sends all other
+// requests to transport.
+func (o *EndpointOverrides) Transport(transport http.RoundTripper) http.RoundTripper {
+	return &overrideTransport{overrides: o, transport: transport}
+}
+
+// overrideTransport serves requests to
+// overridden endpoints from fixtures.
+type overrideTransport struct {
+	overrides *EndpointOverrides
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *overrideTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint, ok := t.overrides.endpoints[req.URL.Path]
+	if !ok {
+		return t.transport.RoundTrip(req)
+	}
+
+	var body interface{}
+	if req.Body != nil {
+		contents, err := ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read request to %s", err, req.URL.Path)
+		}
+
+		if err := json.Unmarshal(contents, &body); err != nil {
+			return nil, fmt.Errorf("%w: unable to parse request to %s", err, req.URL.Path)
+		}
+	}
+
+	for _, fixture := range endpoint.fixtures {
+		if fixture.Request != nil && !matches(fixture.Request, body) {
+			continue
+		}
+
+		atomic.AddInt64(&endpoint.served, 1)
+		statusCode := fixture.StatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+
+		return response(req, statusCode, fixture.Response), nil
+	}
+
+	rosettaErr, err := json.Marshal(&types.Error{
+		Message:   ErrNoMatchingFixture.Error(),
+		Retriable: false,
+		Details: map[string]interface{}{
+			"endpoint": req.URL.Path,
+			"source":   endpoint.source,
+			"request":  body,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to serialize fixture error", err)
+	}
+
+	return response(req, http.StatusInternalServerError, rosettaErr), nil
+}
+
+// response returns an *http.Response for req
+// with statusCode and a JSON body.
+func response(req *http.Request, statusCode int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json; charset=UTF-8"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// FixtureEndpointResults summarizes the requests
+// served from fixtures for an endpoint.
+type FixtureEndpointResults struct {
+	Endpoint string `json:"endpoint"`
+	Source   string `json:"source"`
+	Served   int64  `json:"served"`
+}
+
+// EndpointOverridesResults summarizes the endpoint
+// overrides active during a run.
+type EndpointOverridesResults struct {
+	Endpoints []*FixtureEndpointResults `json:"endpoints"`
+}
+
+// EndpointOverridesResults returns the *EndpointOverridesResults of the run
+// (or nil if no endpoints are overridden).
+func (o *EndpointOverrides) Results() *EndpointOverridesResults {
+	if o == nil {
+		return nil
+	}
+
+	results := &EndpointOverridesResults{Endpoints: []*FixtureEndpointResults{}}
+	for endpointPath, endpoint := range o.endpoints {
+		results.Endpoints = append(results.Endpoints, &FixtureEndpointResults{
+			Endpoint: endpointPath,
+			Source:   endpoint.source,
+			Served:   atomic.LoadInt64(&endpoint.served),
+		})
+	}
+
+	sort.Slice(results.Endpoints, func(i, j int) bool {
+		return results.Endpoints[i].Endpoint < results.Endpoints[j].Endpoint
+	})
+
+	return results
+}
+
+// Print logs EndpointOverridesResults to the console. Fixtures are not the
+// implementation, so they are always printed as a warning.
+func (r *EndpointOverridesResults) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes EndpointOverridesResults to w.
+func (r *EndpointOverridesResults) Fprint(w io.Writer) {
+	color.New(color.FgRed).Fprintf(
+		w,
+		"[WARNING] %d endpoint(s) were served from fixtures by endpoint_overrides\n",
+		len(r.Endpoints),
+	)
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Endpoint Overrides", "Source", "Served"})
+	for _, endpoint := range r.Endpoints {
+		table.Append([]string{
+			endpoint.Endpoint,
+			endpoint.Source,
+			strconv.FormatInt(endpoint.Served, 10),
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	// The example fixtures are valid.
+	fixtures, err := LoadFixtures(path.Join("..", "..", "examples", "account_balance_fixtures.json"))
+	assert.NoError(t, err)
+	assert.Len(t, fixtures, 2)
+
+	// Fixtures in a directory are loaded in file name order.
+	fixturesDir := path.Join(dir, "fixtures")
+	assert.NoError(t, utils.EnsurePathExists(fixturesDir))
+	assert.NoError(t, ioutil.WriteFile(
+		path.Join(fixturesDir, "b.json"),
+		[]byte(`[{"response":{"b":1}}]`),
+		0600,
+	))
+	assert.NoError(t, ioutil.WriteFile(
+		path.Join(fixturesDir, "a.json"),
+		[]byte(`[{"response":{"a":1}},{"response":{"a":2}}]`),
+		0600,
+	))
+	assert.NoError(t, ioutil.WriteFile(path.Join(fixturesDir, "notes.txt"), []byte("x"), 0600))
+	fixtures, err = LoadFixtures(fixturesDir)
+	assert.NoError(t, err)
+	assert.Len(t, fixtures, 3)
+	assert.JSONEq(t, `{"a":1}`, string(fixtures[0].Response))
+	assert.JSONEq(t, `{"b":1}`, string(fixtures[2].Response))
+
+	var tests = map[string]string{
+		"missing response": `[{"request":{}}]`,
+		"no fixtures":      `[]`,
+		"invalid json":     `{`,
+	}
+	for name, contents := range tests {
+		t.Run(name, func(t *testing.T) {
+			filePath := path.Join(dir, "invalid.json")
+			assert.NoError(t, ioutil.WriteFile(filePath, []byte(contents), 0600))
+
+			fixtures, err := LoadFixtures(filePath)
+			assert.Error(t, err)
+			assert.Nil(t, fixtures)
+		})
+	}
+
+	_, err = LoadFixtures(path.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestEndpointOverrides(t *testing.T) {
+	overrides, err := NewEndpointOverrides(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, overrides)
+	assert.Nil(t, overrides.Results())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		_, _ = w.Write([]byte(`{"live":true}`))
+	}))
+	defer ts.Close()
+
+	overrides, err = NewEndpointOverrides(map[string]string{
+		"/account/balance": path.Join("..", "..", "examples", "account_balance_fixtures.json"),
+	})
+	assert.NoError(t, err)
+	client := &http.Client{Transport: overrides.Transport(http.DefaultTransport)}
+
+	post := func(endpoint string, body string) (int, []byte) {
+		resp, err := client.Post(ts.URL+endpoint, "application/json", bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		contents, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp.StatusCode, contents
+	}
+
+	var tests = map[string]struct {
+		endpoint string
+		body     string
+
+		expectedStatus int
+		expectedIndex  int64
+		expectedLive   bool
+	}{
+		"not overridden": {
+			endpoint:       "/network/status",
+			body:           `{}`,
+			expectedStatus: http.StatusOK,
+			expectedLive:   true,
+		},
+		"matching account and block": {
+			endpoint: "/account/balance",
+			body: `{"network_identifier":{"blockchain":"b","network":"n"},` +
+				`"account_identifier":{"address":"addr1"},"block_identifier":{"index":1000}}`,
+			expectedStatus: http.StatusOK,
+			expectedIndex:  1000,
+		},
+		"matching account": {
+			endpoint:       "/account/balance",
+			body:           `{"account_identifier":{"address":"addr1"}}`,
+			expectedStatus: http.StatusOK,
+			expectedIndex:  1001,
+		},
+		"no matching fixture": {
+			endpoint:       "/account/balance",
+			body:           `{"account_identifier":{"address":"addr2"}}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			status, body := post(test.endpoint, test.body)
+			assert.Equal(t, test.expectedStatus, status)
+
+			switch {
+			case test.expectedLive:
+				assert.JSONEq(t, `{"live":true}`, string(body))
+			case status == http.StatusOK:
+				var response types.AccountBalanceResponse
+				assert.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, test.expectedIndex, response.BlockIdentifier.Index)
+			default:
+				var rosettaErr types.Error
+				assert.NoError(t, json.Unmarshal(body, &rosettaErr))
+				assert.Equal(t, ErrNoMatchingFixture.Error(), rosettaErr.Message)
+				assert.False(t, rosettaErr.Retriable)
+			}
+		})
+	}
+
+	results := overrides.Results()
+	assert.Len(t, results.Endpoints, 1)
+	assert.Equal(t, "/account/balance", results.Endpoints[0].Endpoint)
+	assert.Equal(t, int64(2), results.Endpoints[0].Served)
+	results.Print() // make sure doesn't panic
+}
@@ -0,0 +1,473 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+
+	"github.com/coinbase/rosetta-sdk-go/client"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+)
+
+const (
+	userAgentHeader = "User-Agent"
+)
+
+var (
+	// ErrCertKeyMismatch is returned when only one of a client
+	// certificate or a client key is provided.
+	ErrCertKeyMismatch = errors.New("cert_file and key_file must be provided together")
+
+	// ErrInvalidCAFile is returned when a CA file does not
+	// contain any PEM-encoded certificates.
+	ErrInvalidCAFile = errors.New("no PEM-encoded certificates found")
+
+	// ErrUnsupportedProxyScheme is returned when a proxy URL
+	// does not use the http, https, or socks5 scheme.
+	ErrUnsupportedProxyScheme = errors.New("proxy scheme must be http, https, or socks5")
+
+	// ErrProxy is returned when a request fails because a
+	// connection could not be established through a proxy. This
+	// makes it possible to distinguish proxy errors from errors
+	// returned by the Rosetta implementation.
+	ErrProxy = errors.New("unable to connect through proxy")
+)
+
+type settings struct {
+	tlsConfig *tls.Config
+	proxyURL  *url.URL
+	headers   map[string]string
+	userAgent string
+
+	endpointOverrides *EndpointOverrides
+
+	requestIDGenerator *RequestIDGenerator
+	requestDurations   *metrics.RequestDurations
+
+	pathTimeouts     map[string]time.Duration
+	maxResponseSizes map[string]int64
+
+	retryBackoff      *Backoff
+	forceRetry        bool
+	retryLogsDisabled bool
+	randomSeed        *int64
+	retriedRequests   *nonfatal.Tracker
+	retrySoftLimit    uint64
+}
+
+// Option is used to configure the *http.Client
+// returned by New.
+type Option func(s *settings)
+
+// WithTLSConfig sets the *tls.Config used by
+// the client transport.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(s *settings) {
+		s.tlsConfig = tlsConfig
+	}
+}
+
+// WithProxyURL sets the proxy used for all requests made by
+// the client. If not provided, the proxy is determined by
+// the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables.
+func WithProxyURL(proxyURL *url.URL) Option {
+	return func(s *settings) {
+		s.proxyURL = proxyURL
+	}
+}
+
+// WithHeaders sets headers that are attached to
+// every request made by the client.
+func WithHeaders(headers map[string]string) Option {
+	return func(s *settings) {
+		s.headers = headers
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request
+// made by the client (unless it is set by WithHeaders).
+func WithUserAgent(userAgent string) Option {
+	return func(s *settings) {
+		s.userAgent = userAgent
+	}
+}
+
+// WithEndpointOverrides serves requests to the endpoints
+// in overrides from fixtures instead of making them.
+func WithEndpointOverrides(overrides *EndpointOverrides) Option {
+	return func(s *settings) {
+		s.endpointOverrides = overrides
+	}
+}
+
+// WithPathTimeout overrides the client timeout for
+// requests with a URL path that starts with prefix.
+func WithPathTimeout(prefix string, timeout time.Duration) Option {
+	return func(s *settings) {
+		if s.pathTimeouts == nil {
+			s.pathTimeouts = map[string]time.Duration{}
+		}
+
+		s.pathTimeouts[prefix] = timeout
+	}
+}
+
+// New returns an *http.Client for making requests to a Rosetta
+// implementation. The transport mirrors the one constructed by
+// fetcher.New when no client is provided.
+func New(timeout time.Duration, maxConnections int, options ...Option) *http.Client {
+	s := &settings{}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	// See this conversation around why `.Clone()` is used here:
+	// https://github.com/golang/go/issues/26013
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.IdleConnTimeout = fetcher.DefaultIdleConnTimeout
+	transport.MaxIdleConns = maxConnections
+	transport.MaxIdleConnsPerHost = fetcher.DefaultMaxConnections
+	if s.tlsConfig != nil {
+		transport.TLSClientConfig = s.tlsConfig
+	}
+
+	// The cloned transport already uses http.ProxyFromEnvironment,
+	// so we only override it when a proxy is explicitly provided.
+	if s.proxyURL != nil {
+		transport.Proxy = http.ProxyURL(s.proxyURL)
+	}
+
+	var roundTripper http.RoundTripper = &proxyErrorTransport{transport: transport}
+	if s.requestDurations != nil {
+		roundTripper = &durationTransport{
+			durations: s.requestDurations,
+			transport: roundTripper,
+		}
+	}
+	if s.endpointOverrides != nil {
+		roundTripper = s.endpointOverrides.Transport(roundTripper)
+	}
+	if s.requestIDGenerator != nil {
+		roundTripper = &requestIDTransport{
+			generator: s.requestIDGenerator,
+			transport: roundTripper,
+		}
+	}
+
+	// Retried requests are counted below the retry transport
+	// so that both its attempts and the attempts made by the
+	// caller (like a *fetcher.Fetcher) are counted.
+	if s.retriedRequests != nil {
+		roundTripper = newRetriedRequestsTransport(
+			s.retriedRequests,
+			s.retrySoftLimit,
+			roundTripper,
+		)
+	}
+
+	// Each retry is a separate attempt with its own request id
+	// and timeout, so the client timeout must not apply to the
+	// request as a whole. The client timeout also can't be used
+	// when it is overridden for some paths.
+	timeouts := &requestTimeouts{timeout: timeout, paths: s.pathTimeouts}
+	clientTimeout := timeout
+	switch {
+	case s.retryBackoff != nil:
+		seed := time.Now().UnixNano()
+		if s.randomSeed != nil {
+			seed = *s.randomSeed
+		}
+
+		roundTripper = newRetryTransport(
+			s.retryBackoff,
+			s.forceRetry,
+			!s.retryLogsDisabled,
+			timeouts,
+			roundTripper,
+			seed,
+		)
+		clientTimeout = 0
+	case len(s.pathTimeouts) > 0:
+		roundTripper = &timeoutTransport{
+			timeouts:  timeouts,
+			transport: roundTripper,
+		}
+		clientTimeout = 0
+	}
+
+	// Response sizes are limited after retries so that
+	// oversized responses are never requested again.
+	if len(s.maxResponseSizes) > 0 {
+		roundTripper = &responseSizeTransport{
+			maxSizes:  s.maxResponseSizes,
+			transport: roundTripper,
+		}
+	}
+
+	// Headers are applied before the request id is set
+	// so that the request id header can't be overwritten.
+	headers := s.headers
+	if len(s.userAgent) > 0 {
+		headers = withUserAgent(headers, s.userAgent)
+	}
+	if len(headers) > 0 {
+		roundTripper = &headerTransport{
+			headers:   headers,
+			transport: roundTripper,
+		}
+	}
+
+	return &http.Client{
+		Timeout:   clientTimeout,
+		Transport: roundTripper,
+	}
+}
+
+// requestTimeouts determines the timeout of
+// a request using the prefix of its URL path.
+type requestTimeouts struct {
+	timeout time.Duration
+	paths   map[string]time.Duration
+}
+
+// forRequest returns the timeout of req.
+func (r *requestTimeouts) forRequest(req *http.Request) time.Duration {
+	for prefix, timeout := range r.paths {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return timeout
+		}
+	}
+
+	return r.timeout
+}
+
+// withTimeout returns a context for req that
+// expires after the timeout of req (if any).
+func (r *requestTimeouts) withTimeout(req *http.Request) (context.Context, context.CancelFunc) {
+	if timeout := r.forRequest(req); timeout > 0 {
+		return context.WithTimeout(req.Context(), timeout)
+	}
+
+	return context.WithCancel(req.Context())
+}
+
+// timeoutTransport applies the timeout
+// of each request to its context.
+type timeoutTransport struct {
+	timeouts  *requestTimeouts
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := t.timeouts.withTimeout(req)
+	resp, err := t.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose cancels the context of a
+// request when its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements the io.Closer interface.
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+
+	return err
+}
+
+// headerTransport attaches headers to
+// all outgoing requests.
+type headerTransport struct {
+	headers   map[string]string
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper should not modify the provided request.
+	req = req.Clone(req.Context())
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	return t.transport.RoundTrip(req)
+}
+
+// withUserAgent returns a copy of headers with a User-Agent
+// header of userAgent if one is not already present.
+func withUserAgent(headers map[string]string, userAgent string) map[string]string {
+	merged := map[string]string{userAgentHeader: userAgent}
+	for key, value := range headers {
+		if http.CanonicalHeaderKey(key) == userAgentHeader {
+			delete(merged, userAgentHeader)
+		}
+
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// proxyErrorTransport wraps errors caused by a
+// proxy with ErrProxy.
+type proxyErrorTransport struct {
+	transport *http.Transport
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *proxyErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err == nil || !isProxyError(err) {
+		return resp, err
+	}
+
+	proxy := "unknown"
+	if proxyURL, proxyErr := t.transport.Proxy(req); proxyErr == nil && proxyURL != nil {
+		proxy = proxyURL.Redacted()
+	}
+
+	return nil, fmt.Errorf("%w %s: %s", ErrProxy, proxy, err.Error())
+}
+
+// isProxyError returns a boolean indicating if err occurred
+// while connecting to (or negotiating with) a proxy.
+func isProxyError(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	// HTTP(S) proxy errors are returned with the "proxyconnect" op
+	// and SOCKS errors are returned with a "socks <cmd>" op.
+	return opErr.Op == "proxyconnect" || strings.HasPrefix(opErr.Op, "socks ")
+}
+
+// ParseProxyURL parses and validates a proxy URL. If proxyURL
+// is empty, nil is returned.
+func ParseProxyURL(proxyURL string) (*url.URL, error) {
+	if len(proxyURL) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to parse proxy url", err)
+	}
+
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProxyScheme, parsed.Redacted())
+	}
+
+	if len(parsed.Host) == 0 {
+		return nil, fmt.Errorf("proxy url %s is missing a host", parsed.Redacted())
+	}
+
+	return parsed, nil
+}
+
+// FetcherOption returns a fetcher.Option that configures
+// a *fetcher.Fetcher to make requests to url using httpClient.
+func FetcherOption(url string, httpClient *http.Client) fetcher.Option {
+	return fetcher.WithClient(client.NewAPIClient(client.NewConfiguration(
+		url,
+		fetcher.DefaultUserAgent,
+		httpClient,
+	)))
+}
+
+// LoadTLSConfig returns a *tls.Config that trusts the certificates
+// in caFile (in addition to the system roots) and presents the
+// client certificate in certFile and keyFile. If no files are
+// provided and insecureSkipVerify is false, nil is returned so
+// that the default TLS settings are used.
+func LoadTLSConfig(
+	caFile string,
+	certFile string,
+	keyFile string,
+	insecureSkipVerify bool,
+) (*tls.Config, error) {
+	if len(caFile) == 0 && len(certFile) == 0 && len(keyFile) == 0 && !insecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify, // #nosec G402
+	}
+
+	if len(certFile) > 0 || len(keyFile) > 0 {
+		if len(certFile) == 0 || len(keyFile) == 0 {
+			return nil, ErrCertKeyMismatch
+		}
+
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w: unable to load client certificate %s with key %s",
+				err,
+				certFile,
+				keyFile,
+			)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(caFile) > 0 {
+		caPEM, err := ioutil.ReadFile(caFile) // #nosec G304
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read CA file %s", err, caFile)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("%w: CA file %s", ErrInvalidCAFile, caFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
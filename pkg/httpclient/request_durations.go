@@ -0,0 +1,47 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+)
+
+// WithRequestDurations records the duration of each request
+// made by the client (until its response headers are
+// received) in durations. Each retry is recorded separately.
+func WithRequestDurations(durations *metrics.RequestDurations) Option {
+	return func(s *settings) {
+		s.requestDurations = durations
+	}
+}
+
+// durationTransport records the duration
+// of each request by URL path.
+type durationTransport struct {
+	durations *metrics.RequestDurations
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *durationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	t.durations.Observe(req.URL.Path, time.Since(start))
+
+	return resp, err
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientRequestDurations(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+	defer ts.Close()
+
+	durations := metrics.NewRequestDurations()
+	client := New(time.Second, 1, WithRequestDurations(durations))
+	for _, path := range []string{"/block", "/block", "/network/status"} {
+		resp, err := client.Post(ts.URL+path, "application/json", nil)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, metrics.Write(&buf, nil, durations))
+	assert.Contains(t, buf.String(), `rosetta_cli_request_duration_seconds_count{path="/block"} 2`)
+	assert.Contains(
+		t,
+		buf.String(),
+		`rosetta_cli_request_duration_seconds_count{path="/network/status"} 1`,
+	)
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// Version is the current version of rosetta-cli.
+	Version = "0.7.3"
+
+	// RunIDPlaceholder is replaced with the run id in a
+	// request id prefix template or User-Agent template.
+	RunIDPlaceholder = "{run_id}"
+
+	// RequestIDDetailKey is the key used to attach the request
+	// id to the details of a *types.Error returned by the
+	// Rosetta implementation.
+	RequestIDDetailKey = "rosetta_cli_request_id"
+)
+
+// NewRunID returns a random (version 4) UUID that
+// identifies a single invocation of rosetta-cli.
+func NewRunID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand should never fail but a run id is not
+		// worth crashing over.
+		return "00000000-0000-4000-8000-000000000000"
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ResolveUserAgent returns the User-Agent for all requests,
+// replacing any RunIDPlaceholder in template with runID. If
+// template is empty, the default rosetta-cli User-Agent is
+// returned.
+func ResolveUserAgent(template string, runID string) string {
+	if len(template) == 0 {
+		return "rosetta-cli/" + Version
+	}
+
+	return strings.ReplaceAll(template, RunIDPlaceholder, runID)
+}
+
+// RequestIDGenerator generates request ids of the form
+// <prefix><sequence>. Ids are unique for the lifetime of
+// the generator, so a single generator should be shared
+// by all clients in a run.
+type RequestIDGenerator struct {
+	// sequence must be the first field so that it is 64-bit
+	// aligned for atomic access on 32-bit platforms.
+	sequence uint64
+
+	header string
+	prefix string
+}
+
+// NewRequestIDGenerator returns a new *RequestIDGenerator that
+// sets header on each request. Any RunIDPlaceholder in
+// prefixTemplate is replaced with runID.
+func NewRequestIDGenerator(
+	header string,
+	prefixTemplate string,
+	runID string,
+) *RequestIDGenerator {
+	return &RequestIDGenerator{
+		header: header,
+		prefix: strings.ReplaceAll(prefixTemplate, RunIDPlaceholder, runID),
+	}
+}
+
+// Header returns the name of the header
+// the request id is set on.
+func (g *RequestIDGenerator) Header() string {
+	return g.header
+}
+
+// Prefix returns the expanded prefix of
+// all generated request ids.
+func (g *RequestIDGenerator) Prefix() string {
+	return g.prefix
+}
+
+// Next returns a new request id. It is safe to
+// call Next concurrently.
+func (g *RequestIDGenerator) Next() string {
+	return g.prefix + strconv.FormatUint(atomic.AddUint64(&g.sequence, 1), 10)
+}
+
+// WithRequestIDGenerator attaches a unique request id
+// generated by generator to every request made by
+// the client.
+func WithRequestIDGenerator(generator *RequestIDGenerator) Option {
+	return func(s *settings) {
+		s.requestIDGenerator = generator
+	}
+}
+
+// requestIDTransport attaches a unique request id to all
+// outgoing requests and records the request id on any
+// failure caused by the request.
+type requestIDTransport struct {
+	generator *RequestIDGenerator
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestID := t.generator.Next()
+
+	// A RoundTripper should not modify the provided request.
+	req = req.Clone(req.Context())
+	req.Header.Set(t.generator.header, requestID)
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: request id %s", err, requestID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		annotateErrorResponse(resp, requestID)
+	}
+
+	return resp, nil
+}
+
+// annotateErrorResponse adds requestID to the details of the
+// *types.Error in resp so that it is included in any error
+// surfaced by the fetcher. Bodies that cannot be parsed as a
+// *types.Error are left unmodified.
+func annotateErrorResponse(resp *http.Response, requestID string) {
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return
+	}
+
+	var rosettaErr types.Error
+	if err := json.Unmarshal(body, &rosettaErr); err != nil || len(rosettaErr.Message) == 0 {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return
+	}
+
+	if rosettaErr.Details == nil {
+		rosettaErr.Details = map[string]interface{}{}
+	}
+	rosettaErr.Details[RequestIDDetailKey] = requestID
+
+	annotated, err := json.Marshal(rosettaErr)
+	if err != nil {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(annotated))
+	resp.ContentLength = int64(len(annotated))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(annotated)))
+}
@@ -0,0 +1,264 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRequestIDHeader = "X-Request-ID"
+	testRequestIDPrefix = "rosetta-cli-{run_id}-"
+)
+
+var (
+	testNetwork = &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+
+	uuidRegex = regexp.MustCompile(
+		`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+	)
+)
+
+func TestNewRunID(t *testing.T) {
+	runID := NewRunID()
+	assert.Regexp(t, uuidRegex, runID)
+	assert.NotEqual(t, runID, NewRunID())
+}
+
+func TestResolveUserAgent(t *testing.T) {
+	var tests = map[string]struct {
+		template string
+
+		expected string
+	}{
+		"default": {
+			expected: "rosetta-cli/" + Version,
+		},
+		"static": {
+			template: "validator",
+			expected: "validator",
+		},
+		"run id": {
+			template: "validator/{run_id}",
+			expected: "validator/run",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ResolveUserAgent(test.template, "run"))
+		})
+	}
+}
+
+func TestNewRequestIDGenerator(t *testing.T) {
+	var tests = map[string]struct {
+		prefixTemplate string
+
+		expectedPrefix string
+	}{
+		"run id": {
+			prefixTemplate: "rosetta-cli-{run_id}-",
+			expectedPrefix: "rosetta-cli-run-",
+		},
+		"multiple run ids": {
+			prefixTemplate: "{run_id}/{run_id}/",
+			expectedPrefix: "run/run/",
+		},
+		"no run id": {
+			prefixTemplate: "static-",
+			expectedPrefix: "static-",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			generator := NewRequestIDGenerator(testRequestIDHeader, test.prefixTemplate, "run")
+			assert.Equal(t, testRequestIDHeader, generator.Header())
+			assert.Equal(t, test.expectedPrefix, generator.Prefix())
+			assert.Equal(t, test.expectedPrefix+"1", generator.Next())
+			assert.Equal(t, test.expectedPrefix+"2", generator.Next())
+		})
+	}
+}
+
+func TestRequestIDGeneratorConcurrency(t *testing.T) {
+	generator := NewRequestIDGenerator(testRequestIDHeader, "test-", "run")
+
+	workers := 64
+	idsPerWorker := 5000
+
+	var wg sync.WaitGroup
+	results := make([][]string, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			ids := make([]string, idsPerWorker)
+			for j := range ids {
+				ids[j] = generator.Next()
+			}
+			results[worker] = ids
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]struct{}, workers*idsPerWorker)
+	for _, ids := range results {
+		for _, id := range ids {
+			_, ok := seen[id]
+			assert.False(t, ok, "duplicate request id %s", id)
+			seen[id] = struct{}{}
+		}
+	}
+	assert.Len(t, seen, workers*idsPerWorker)
+}
+
+func TestClientRequestID(t *testing.T) {
+	generator := NewRequestIDGenerator(testRequestIDHeader, "test-{run_id}-", "run")
+
+	var (
+		seenLock sync.Mutex
+		seen     = []string{}
+	)
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(testRequestIDHeader)
+			assert.True(t, strings.HasPrefix(requestID, "test-run-"))
+
+			seenLock.Lock()
+			seen = append(seen, requestID)
+			seenLock.Unlock()
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusInternalServerError)
+			assert.NoError(t, json.NewEncoder(w).Encode(&types.Error{
+				Code:    12,
+				Message: "node is not ready",
+				Details: map[string]interface{}{
+					"peers": float64(0),
+				},
+			}))
+		}),
+	)
+	defer ts.Close()
+
+	f := fetcher.New(
+		ts.URL,
+		fetcher.WithMaxRetries(0),
+		FetcherOption(ts.URL, New(time.Second, 1, WithRequestIDGenerator(generator))),
+	)
+	_, fetchErr := f.NetworkStatus(context.Background(), testNetwork, nil)
+	assert.NotNil(t, fetchErr)
+	assert.Len(t, seen, 1)
+	assert.Equal(t, &types.Error{
+		Code:    12,
+		Message: "node is not ready",
+		Details: map[string]interface{}{
+			"peers":            float64(0),
+			RequestIDDetailKey: seen[0],
+		},
+	}, fetchErr.ClientErr)
+	assert.Contains(t, fetchErr.Err.Error(), seen[0])
+
+	// Errors that occur before a response is received
+	// should also include the request id.
+	ts.Close()
+	_, fetchErr = f.NetworkStatus(context.Background(), testNetwork, nil)
+	assert.NotNil(t, fetchErr)
+	assert.Contains(t, fetchErr.Err.Error(), "request id test-run-2")
+}
+
+func TestClientRequestIDOverridesHeaders(t *testing.T) {
+	generator := NewRequestIDGenerator(testRequestIDHeader, "test-", "run")
+
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "test-1", r.Header.Get(testRequestIDHeader))
+			assert.Equal(t, "tenant-1", r.Header.Get("X-Tenant-ID"))
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+	defer ts.Close()
+
+	client := New(
+		time.Second,
+		1,
+		WithHeaders(map[string]string{
+			testRequestIDHeader: "static",
+			"X-Tenant-ID":       "tenant-1",
+		}),
+		WithRequestIDGenerator(generator),
+	)
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+}
+
+func BenchmarkRequestIDGeneratorNext(b *testing.B) {
+	generator := NewRequestIDGenerator(testRequestIDHeader, testRequestIDPrefix, NewRunID())
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = generator.Next()
+		}
+	})
+}
+
+// noopTransport returns an empty 200 response without
+// making a request so that the overhead of other
+// transports can be measured.
+type noopTransport struct{}
+
+func (noopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Request: req}, nil
+}
+
+func BenchmarkRequestIDTransport(b *testing.B) {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8080/block", nil)
+
+	benchmarks := map[string]http.RoundTripper{
+		"baseline": noopTransport{},
+		"request id": &requestIDTransport{
+			generator: NewRequestIDGenerator(testRequestIDHeader, testRequestIDPrefix, NewRunID()),
+			transport: noopTransport{},
+		},
+	}
+
+	for name, transport := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := transport.RoundTrip(req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrResponseTooLarge is returned when reading a response
+// body that exceeds the size limit of its path.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// WithMaxResponseSize limits the number of bytes read from the
+// body of responses to requests with a URL path that starts
+// with prefix. Reading past maxBytes returns ErrResponseTooLarge
+// instead of buffering the rest of the body.
+func WithMaxResponseSize(prefix string, maxBytes int64) Option {
+	return func(s *settings) {
+		if s.maxResponseSizes == nil {
+			s.maxResponseSizes = map[string]int64{}
+		}
+
+		s.maxResponseSizes[prefix] = maxBytes
+	}
+}
+
+// responseSizeTransport limits the size of
+// response bodies by URL path prefix.
+type responseSizeTransport struct {
+	maxSizes  map[string]int64
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *responseSizeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBytes, ok := t.maxSize(req.URL.Path)
+	if !ok {
+		return resp, nil
+	}
+
+	// Fail before reading anything if the
+	// response declares its size.
+	if resp.ContentLength > maxBytes {
+		resp.Body.Close()
+		return nil, tooLargeError(req.URL.Path, maxBytes)
+	}
+
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		path:       req.URL.Path,
+		remaining:  maxBytes,
+		maxBytes:   maxBytes,
+	}
+
+	return resp, nil
+}
+
+// maxSize returns the size limit of
+// the longest prefix matching path.
+func (t *responseSizeTransport) maxSize(path string) (int64, bool) {
+	var (
+		match    string
+		maxBytes int64
+		found    bool
+	)
+	for prefix, size := range t.maxSizes {
+		if strings.HasPrefix(path, prefix) && (!found || len(prefix) > len(match)) {
+			match, maxBytes, found = prefix, size, true
+		}
+	}
+
+	return maxBytes, found
+}
+
+// limitedBody returns ErrResponseTooLarge once
+// more than maxBytes have been read from it.
+type limitedBody struct {
+	io.ReadCloser
+	path      string
+	remaining int64
+	maxBytes  int64
+}
+
+// Read implements the io.Reader interface.
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, tooLargeError(l.path, l.maxBytes)
+	}
+
+	// Read one byte past the limit to determine
+	// if the body is larger than allowed.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), tooLargeError(l.path, l.maxBytes)
+	}
+
+	return n, err
+}
+
+func tooLargeError(path string, maxBytes int64) error {
+	return fmt.Errorf("%w: %s response exceeded %d bytes", ErrResponseTooLarge, path, maxBytes)
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientMaxResponseSize(t *testing.T) {
+	body := strings.Repeat("a", 100)
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Flushing before writing the body omits
+			// the Content-Length header.
+			if r.URL.Query().Get("chunked") == "true" {
+				w.(http.Flusher).Flush()
+			}
+
+			_, _ = w.Write([]byte(body))
+		}),
+	)
+	defer ts.Close()
+
+	var tests = map[string]struct {
+		path     string
+		maxBytes int64
+
+		expectedRequestErr bool
+		expectedReadErr    bool
+	}{
+		"under limit": {
+			path:     "/block",
+			maxBytes: 101,
+		},
+		"at limit": {
+			path:     "/block?chunked=true",
+			maxBytes: 100,
+		},
+		"over limit with content length": {
+			path:               "/block",
+			maxBytes:           99,
+			expectedRequestErr: true,
+		},
+		"over limit without content length": {
+			path:            "/block?chunked=true",
+			maxBytes:        99,
+			expectedReadErr: true,
+		},
+		"other path": {
+			path:     "/network/status",
+			maxBytes: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := New(time.Second, 1, WithMaxResponseSize("/block", test.maxBytes))
+
+			resp, err := client.Get(ts.URL + test.path)
+			if test.expectedRequestErr {
+				assert.True(t, errors.Is(err, ErrResponseTooLarge))
+				return
+			}
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			read, err := ioutil.ReadAll(resp.Body)
+			if test.expectedReadErr {
+				assert.True(t, errors.Is(err, ErrResponseTooLarge))
+				assert.Len(t, read, int(test.maxBytes))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, body, string(read))
+		})
+	}
+}
+
+func TestFetcherMaxBlockSize(t *testing.T) {
+	index := int64(10)
+	block := &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: index, Hash: "block 10"},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: index - 1, Hash: "block 9"},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: strings.Repeat("a", 10000),
+				},
+			},
+		},
+	}
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.(http.Flusher).Flush()
+			assert.NoError(t, json.NewEncoder(w).Encode(&types.BlockResponse{Block: block}))
+		}),
+	)
+	defer ts.Close()
+
+	var tests = map[string]struct {
+		options []Option
+
+		expectedErr bool
+	}{
+		"no limit": {},
+		"under limit": {
+			options: []Option{WithMaxResponseSize("/block", 20000)},
+		},
+		"oversized block": {
+			options:     []Option{WithMaxResponseSize("/block", 1000)},
+			expectedErr: true,
+		},
+		"oversized block with retries": {
+			options: []Option{
+				WithMaxResponseSize("/block", 1000),
+				WithRetryBackoff(&Backoff{
+					InitialInterval: time.Millisecond,
+					MaxInterval:     time.Millisecond,
+					Multiplier:      1,
+					MaxRetries:      3,
+				}, true),
+			},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := fetcher.New(
+				ts.URL,
+				FetcherOption(ts.URL, New(time.Second, 1, test.options...)),
+			)
+
+			fetched, err := f.UnsafeBlock(
+				context.Background(),
+				&types.NetworkIdentifier{Blockchain: "blockchain", Network: "network"},
+				&types.PartialBlockIdentifier{Index: &index},
+			)
+			if test.expectedErr {
+				assert.Nil(t, fetched)
+				assert.Contains(t, err.Err.Error(), "/block response exceeded 1000 bytes")
+				assert.False(t, err.Retry)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, block, fetched)
+		})
+	}
+}
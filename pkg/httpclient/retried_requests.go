@@ -0,0 +1,128 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+)
+
+const (
+	// maxFailedRequests is the maximum number of failed
+	// requests tracked at once. Requests that keep failing
+	// are never removed, so this bounds the memory used
+	// when an implementation is unavailable for a long time.
+	maxFailedRequests = 10000
+)
+
+// WithRetriedRequests records each request that only succeeded
+// after more than softLimit failed attempts as a
+// nonfatal.FetchRetry in tracker. Attempts are counted whether
+// the request is retried by a client configured with
+// WithRetryBackoff or by the caller (like a *fetcher.Fetcher),
+// so attempts are matched by method, URL, and body.
+func WithRetriedRequests(tracker *nonfatal.Tracker, softLimit uint64) Option {
+	return func(s *settings) {
+		s.retriedRequests = tracker
+		s.retrySoftLimit = softLimit
+	}
+}
+
+// requestKey identifies the attempts of a request.
+type requestKey [sha256.Size]byte
+
+// retriedRequestsTransport counts the failed attempts of
+// each request and records requests that only succeeded
+// after more than softLimit failed attempts.
+type retriedRequestsTransport struct {
+	tracker   *nonfatal.Tracker
+	softLimit uint64
+	transport http.RoundTripper
+
+	lock     sync.Mutex
+	failures map[requestKey]uint64
+}
+
+func newRetriedRequestsTransport(
+	tracker *nonfatal.Tracker,
+	softLimit uint64,
+	transport http.RoundTripper,
+) *retriedRequestsTransport {
+	return &retriedRequestsTransport{
+		tracker:   tracker,
+		softLimit: softLimit,
+		transport: transport,
+		failures:  map[requestKey]uint64{},
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *retriedRequestsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok := newRequestKey(req)
+	resp, err := t.transport.RoundTrip(req)
+	if !ok {
+		return resp, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err != nil || resp.StatusCode != http.StatusOK {
+		if _, exists := t.failures[key]; exists || len(t.failures) < maxFailedRequests {
+			t.failures[key]++
+		}
+
+		return resp, err
+	}
+
+	if t.failures[key] > t.softLimit {
+		t.tracker.Record(nonfatal.FetchRetry)
+	}
+	delete(t.failures, key)
+
+	return resp, err
+}
+
+// newRequestKey returns the requestKey of req (or false
+// if the body of req can't be read without consuming it).
+func newRequestKey(req *http.Request) (requestKey, bool) {
+	hash := sha256.New()
+	_, _ = io.WriteString(hash, req.Method+" "+req.URL.String()+"\n")
+
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return requestKey{}, false
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return requestKey{}, false
+		}
+		defer body.Close()
+
+		if _, err := io.Copy(hash, body); err != nil {
+			return requestKey{}, false
+		}
+	}
+
+	var key requestKey
+	copy(key[:], hash.Sum(nil))
+
+	return key, true
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetriedRequests(t *testing.T) {
+	var tests = map[string]struct {
+		failures  int
+		softLimit uint64
+		bodies    []string
+
+		expectedRetried int64
+	}{
+		"no failures": {
+			bodies: []string{"a", "a"},
+		},
+		"retried once": {
+			failures:        1,
+			bodies:          []string{"a", "a"},
+			expectedRetried: 1,
+		},
+		"within soft limit": {
+			failures:  2,
+			softLimit: 2,
+			bodies:    []string{"a", "a", "a"},
+		},
+		"exceeds soft limit": {
+			failures:        3,
+			softLimit:       2,
+			bodies:          []string{"a", "a", "a", "a"},
+			expectedRetried: 1,
+		},
+		"different requests": {
+			failures: 1,
+			bodies:   []string{"a", "b"},
+		},
+		"counted once per request": {
+			failures:        1,
+			bodies:          []string{"a", "a", "a"},
+			expectedRetried: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				lock     sync.Mutex
+				attempts = map[string]int{}
+			)
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, err := ioutil.ReadAll(r.Body)
+					assert.NoError(t, err)
+
+					lock.Lock()
+					attempts[string(body)]++
+					attempt := attempts[string(body)]
+					lock.Unlock()
+
+					if attempt <= test.failures {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+
+					w.WriteHeader(http.StatusOK)
+				}),
+			)
+			defer ts.Close()
+
+			// Requests are retried by the caller (without
+			// WithRetryBackoff), like a *fetcher.Fetcher.
+			retried := nonfatal.New(0)
+			client := New(time.Second, 1, WithRetriedRequests(retried, test.softLimit))
+			for _, body := range test.bodies {
+				resp, err := client.Post(ts.URL, "text/plain", bytes.NewBufferString(body))
+				assert.NoError(t, err)
+				assert.NoError(t, resp.Body.Close())
+			}
+
+			if test.expectedRetried > 0 {
+				assert.Equal(t, test.expectedRetried, retried.Results().Total)
+			} else {
+				assert.Nil(t, retried.Results())
+			}
+		})
+	}
+}
+
+func TestRetriedRequestsFetcher(t *testing.T) {
+	status := &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		CurrentBlockTimestamp:  1582833600000,
+		GenesisBlockIdentifier: &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		Peers:                  []*types.Peer{},
+	}
+
+	var (
+		lock     sync.Mutex
+		attempts int
+	)
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			attempts++
+			attempt := attempts
+			lock.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			if attempt <= 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				assert.NoError(t, json.NewEncoder(w).Encode(&types.Error{
+					Code:      1,
+					Message:   "node busy",
+					Retriable: true,
+				}))
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			assert.NoError(t, json.NewEncoder(w).Encode(status))
+		}),
+	)
+	defer ts.Close()
+
+	retried := nonfatal.New(0)
+	f := fetcher.New(
+		ts.URL,
+		FetcherOption(ts.URL, New(time.Second, 1, WithRetriedRequests(retried, 1))),
+		fetcher.WithMaxRetries(3),
+	)
+
+	resp, fetchErr := f.NetworkStatusRetry(
+		context.Background(),
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		nil,
+	)
+	assert.Nil(t, fetchErr)
+	assert.Equal(t, status, resp)
+	assert.Equal(t, 3, attempts)
+
+	// The request was retried twice by the fetcher, which
+	// exceeds the soft limit.
+	assert.Equal(t, &nonfatal.Results{
+		Total:  1,
+		Counts: []*nonfatal.Count{{Kind: nonfatal.FetchRetry, Count: 1}},
+	}, retried.Results())
+}
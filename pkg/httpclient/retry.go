@@ -0,0 +1,301 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// Backoff describes an exponential backoff schedule with
+// jitter used to retry failed requests.
+type Backoff struct {
+	// InitialInterval is the interval before the first retry.
+	InitialInterval time.Duration
+
+	// MaxInterval caps the interval between retries
+	// (before jitter is applied).
+	MaxInterval time.Duration
+
+	// Multiplier is applied to the interval after each retry.
+	Multiplier float64
+
+	// Jitter randomizes each interval to be within
+	// [interval * (1 - Jitter), interval * (1 + Jitter)].
+	Jitter float64
+
+	// MaxRetries is the maximum number of times
+	// a request is retried.
+	MaxRetries uint64
+
+	// MaxElapsedTime is the maximum amount of time spent
+	// retrying a request. If 0, there is no limit.
+	MaxElapsedTime time.Duration
+}
+
+// Interval returns the interval before the retry with the
+// provided index (starting at 0) without jitter.
+func (b *Backoff) Interval(retry uint64) time.Duration {
+	interval := float64(b.InitialInterval) * math.Pow(b.Multiplier, float64(retry))
+	if interval > float64(b.MaxInterval) {
+		return b.MaxInterval
+	}
+
+	return time.Duration(interval)
+}
+
+// Schedule returns the intervals (without jitter)
+// between all retries.
+func (b *Backoff) Schedule() []time.Duration {
+	schedule := make([]time.Duration, b.MaxRetries)
+	for i := range schedule {
+		schedule[i] = b.Interval(uint64(i))
+	}
+
+	return schedule
+}
+
+// jitter returns interval randomized by b.Jitter
+// using the provided random value in [0, 1).
+func (b *Backoff) jitter(interval time.Duration, random float64) time.Duration {
+	delta := b.Jitter * float64(interval)
+	min := float64(interval) - delta
+
+	return time.Duration(min + random*(2*delta))
+}
+
+// WithRetryBackoff configures the client to retry failed requests
+// using backoff. If forceRetry is true, all non-200 responses are
+// retried. Otherwise, only transient errors and responses the
+// implementation marks as retriable are retried.
+//
+// When retries are enabled, the client timeout is applied to
+// each attempt instead of to the request as a whole.
+func WithRetryBackoff(backoff *Backoff, forceRetry bool) Option {
+	return func(s *settings) {
+		s.retryBackoff = backoff
+		s.forceRetry = forceRetry
+	}
+}
+
+// WithRandomSeed seeds the jitter applied to retry
+// intervals so that runs with the same seed wait
+// the same intervals between retries.
+func WithRandomSeed(seed int64) Option {
+	return func(s *settings) {
+		s.randomSeed = &seed
+	}
+}
+
+// WithoutRetryLogs disables logging each retry
+// made by a client configured with WithRetryBackoff.
+func WithoutRetryLogs() Option {
+	return func(s *settings) {
+		s.retryLogsDisabled = true
+	}
+}
+
+// retryTransport retries failed requests
+// according to a *Backoff.
+type retryTransport struct {
+	backoff    *Backoff
+	forceRetry bool
+	logRetries bool
+	timeouts   *requestTimeouts
+	transport  http.RoundTripper
+
+	randLock sync.Mutex
+	rand     *rand.Rand
+}
+
+func newRetryTransport(
+	backoff *Backoff,
+	forceRetry bool,
+	logRetries bool,
+	timeouts *requestTimeouts,
+	transport http.RoundTripper,
+	seed int64,
+) *retryTransport {
+	return &retryTransport{
+		backoff:    backoff,
+		forceRetry: forceRetry,
+		logRetries: logRetries,
+		timeouts:   timeouts,
+		transport:  transport,
+		rand:       rand.New(rand.NewSource(seed)), // #nosec G404
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+
+	for retries := uint64(0); ; retries++ {
+		attemptReq, cancel, err := t.attemptRequest(req, retries)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.transport.RoundTrip(attemptReq)
+		reason, retriable := t.retriable(ctx, resp, err)
+		if !retriable || retries >= t.backoff.MaxRetries {
+			return t.finish(resp, err, cancel)
+		}
+
+		wait := t.backoff.jitter(t.backoff.Interval(retries), t.random())
+		if t.backoff.MaxElapsedTime > 0 && time.Since(start)+wait > t.backoff.MaxElapsedTime {
+			return t.finish(resp, err, cancel)
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		cancel()
+
+		if t.logRetries {
+			log.Printf(
+				"%s: retrying request to %s after %fs (prior attempts: %d)\n",
+				reason,
+				req.URL.Path,
+				wait.Seconds(),
+				retries+1,
+			)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attemptRequest returns a copy of req with a fresh body
+// and a context that expires after the request timeout.
+func (t *retryTransport) attemptRequest(
+	req *http.Request,
+	retries uint64,
+) (*http.Request, context.CancelFunc, error) {
+	ctx, cancel := t.timeouts.withTimeout(req)
+	attemptReq := req.Clone(ctx)
+	if retries > 0 && req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			cancel()
+			return nil, nil, errors.New("unable to retry request without GetBody")
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("%w: unable to get request body", err)
+		}
+
+		attemptReq.Body = body
+	}
+
+	return attemptReq, cancel, nil
+}
+
+// finish returns the result of the final attempt, ensuring
+// the attempt context is canceled once the response body
+// is closed.
+func (t *retryTransport) finish(
+	resp *http.Response,
+	err error,
+	cancel context.CancelFunc,
+) (*http.Response, error) {
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retriable returns a boolean indicating if a request should
+// be retried (and the reason it should be retried).
+func (t *retryTransport) retriable(
+	ctx context.Context,
+	resp *http.Response,
+	err error,
+) (string, bool) {
+	// Don't retry if the caller gave up.
+	if ctx.Err() != nil {
+		return "", false
+	}
+
+	if err != nil {
+		return err.Error(), true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return "", false
+	case http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		http.StatusRequestTimeout:
+		return fmt.Sprintf("received status code %d", resp.StatusCode), true
+	}
+
+	if t.forceRetry {
+		return fmt.Sprintf("received status code %d", resp.StatusCode), true
+	}
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		return "", false
+	}
+
+	// Only retry errors the implementation marks as retriable. We
+	// restore the body so that it can be read by the caller.
+	body, readErr := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return readErr.Error(), true
+	}
+
+	var rosettaErr types.Error
+	if err := json.Unmarshal(body, &rosettaErr); err != nil || !rosettaErr.Retriable {
+		return "", false
+	}
+
+	return types.PrintStruct(rosettaErr), true
+}
+
+func (t *retryTransport) random() float64 {
+	t.randLock.Lock()
+	defer t.randLock.Unlock()
+
+	return t.rand.Float64()
+}
@@ -0,0 +1,355 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffSchedule(t *testing.T) {
+	var tests = map[string]struct {
+		backoff *Backoff
+
+		expected []time.Duration
+	}{
+		"sdk defaults": {
+			backoff: &Backoff{
+				InitialInterval: 500 * time.Millisecond,
+				MaxInterval:     60 * time.Second,
+				Multiplier:      1.5,
+				MaxRetries:      5,
+			},
+			expected: []time.Duration{
+				500 * time.Millisecond,
+				750 * time.Millisecond,
+				1125 * time.Millisecond,
+				1687500 * time.Microsecond,
+				2531250 * time.Microsecond,
+			},
+		},
+		"doubling with cap": {
+			backoff: &Backoff{
+				InitialInterval: time.Second,
+				MaxInterval:     5 * time.Second,
+				Multiplier:      2,
+				MaxRetries:      5,
+			},
+			expected: []time.Duration{
+				time.Second,
+				2 * time.Second,
+				4 * time.Second,
+				5 * time.Second,
+				5 * time.Second,
+			},
+		},
+		"constant": {
+			backoff: &Backoff{
+				InitialInterval: 100 * time.Millisecond,
+				MaxInterval:     time.Second,
+				Multiplier:      1,
+				MaxRetries:      3,
+			},
+			expected: []time.Duration{
+				100 * time.Millisecond,
+				100 * time.Millisecond,
+				100 * time.Millisecond,
+			},
+		},
+		"no retries": {
+			backoff: &Backoff{
+				InitialInterval: time.Second,
+				MaxInterval:     time.Second,
+				Multiplier:      2,
+			},
+			expected: []time.Duration{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.backoff.Schedule())
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	backoff := &Backoff{Jitter: 0.5}
+
+	assert.Equal(t, 500*time.Millisecond, backoff.jitter(time.Second, 0))
+	assert.Equal(t, time.Second, backoff.jitter(time.Second, 0.5))
+	assert.Equal(t, 1499*time.Millisecond, backoff.jitter(time.Second, 0.999))
+
+	backoff.Jitter = 0
+	assert.Equal(t, time.Second, backoff.jitter(time.Second, 0.999))
+}
+
+func TestRetryRandomSeed(t *testing.T) {
+	a := newRetryTransport(&Backoff{}, false, false, nil, nil, 42)
+	b := newRetryTransport(&Backoff{}, false, false, nil, nil, 42)
+	c := newRetryTransport(&Backoff{}, false, false, nil, nil, 43)
+
+	for i := 0; i < 10; i++ {
+		random := a.random()
+		assert.Equal(t, random, b.random())
+		assert.NotEqual(t, random, c.random())
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	retriableErr := &types.Error{Code: 1, Message: "node busy", Retriable: true}
+	nonRetriableErr := &types.Error{Code: 2, Message: "bad request", Retriable: false}
+
+	var tests = map[string]struct {
+		failures   int
+		status     int
+		body       interface{}
+		forceRetry bool
+		maxRetries uint64
+		softLimit  uint64
+
+		expectedAttempts int32
+		expectedStatus   int
+		expectedRetried  int64
+	}{
+		"no failures": {
+			maxRetries:       3,
+			expectedAttempts: 1,
+			expectedStatus:   http.StatusOK,
+		},
+		"retry unavailable": {
+			failures:         2,
+			status:           http.StatusServiceUnavailable,
+			maxRetries:       3,
+			expectedAttempts: 3,
+			expectedStatus:   http.StatusOK,
+			expectedRetried:  1,
+		},
+		"retries within soft limit": {
+			failures:         2,
+			status:           http.StatusServiceUnavailable,
+			maxRetries:       3,
+			softLimit:        2,
+			expectedAttempts: 3,
+			expectedStatus:   http.StatusOK,
+		},
+		"retries exceed soft limit": {
+			failures:         3,
+			status:           http.StatusServiceUnavailable,
+			maxRetries:       3,
+			softLimit:        2,
+			expectedAttempts: 4,
+			expectedStatus:   http.StatusOK,
+			expectedRetried:  1,
+		},
+		"retry retriable error": {
+			failures:         1,
+			status:           http.StatusInternalServerError,
+			body:             retriableErr,
+			maxRetries:       3,
+			expectedAttempts: 2,
+			expectedStatus:   http.StatusOK,
+			expectedRetried:  1,
+		},
+		"do not retry non-retriable error": {
+			failures:         1,
+			status:           http.StatusInternalServerError,
+			body:             nonRetriableErr,
+			maxRetries:       3,
+			expectedAttempts: 1,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+		"force retry non-retriable error": {
+			failures:         1,
+			status:           http.StatusInternalServerError,
+			body:             nonRetriableErr,
+			forceRetry:       true,
+			maxRetries:       3,
+			expectedAttempts: 2,
+			expectedStatus:   http.StatusOK,
+			expectedRetried:  1,
+		},
+		"retries exhausted": {
+			failures:         5,
+			status:           http.StatusBadGateway,
+			maxRetries:       2,
+			expectedAttempts: 3,
+			expectedStatus:   http.StatusBadGateway,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var attempts int32
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					// The request body must be replayed on each attempt.
+					body, err := ioutil.ReadAll(r.Body)
+					assert.NoError(t, err)
+					assert.Equal(t, `{"hello":"world"}`, string(body))
+
+					attempt := atomic.AddInt32(&attempts, 1)
+					if int(attempt) <= test.failures {
+						w.WriteHeader(test.status)
+						if test.body != nil {
+							assert.NoError(t, json.NewEncoder(w).Encode(test.body))
+						}
+						return
+					}
+
+					w.WriteHeader(http.StatusOK)
+				}),
+			)
+			defer ts.Close()
+
+			retried := nonfatal.New(0)
+			client := New(
+				time.Second,
+				1,
+				WithRetryBackoff(&Backoff{
+					InitialInterval: time.Millisecond,
+					MaxInterval:     5 * time.Millisecond,
+					Multiplier:      2,
+					Jitter:          0.5,
+					MaxRetries:      test.maxRetries,
+				}, test.forceRetry),
+				WithRetriedRequests(retried, test.softLimit),
+			)
+			assert.Equal(t, time.Duration(0), client.Timeout)
+
+			resp, err := client.Post(
+				ts.URL,
+				"application/json",
+				bytes.NewBufferString(`{"hello":"world"}`),
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			assert.Equal(t, test.expectedAttempts, atomic.LoadInt32(&attempts))
+
+			if test.body != nil && test.expectedStatus != http.StatusOK {
+				var rosettaErr types.Error
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&rosettaErr))
+				assert.Equal(t, test.body, &rosettaErr)
+			}
+			assert.NoError(t, resp.Body.Close())
+
+			if test.expectedRetried > 0 {
+				assert.Equal(t, test.expectedRetried, retried.Results().Total)
+			} else {
+				assert.Nil(t, retried.Results())
+			}
+		})
+	}
+}
+
+func TestClientRetryTimeout(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The first attempt exceeds the per-attempt timeout.
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				time.Sleep(200 * time.Millisecond)
+			}
+
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+	defer ts.Close()
+
+	client := New(
+		50*time.Millisecond,
+		1,
+		WithRetryBackoff(&Backoff{
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			Multiplier:      1,
+			MaxRetries:      1,
+		}, false),
+	)
+
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestClientRetryLogs(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+
+	var tests = map[string]struct {
+		options []Option
+
+		expectedLogs bool
+	}{
+		"retries logged": {
+			expectedLogs: true,
+		},
+		"retry logs disabled": {
+			options:      []Option{WithoutRetryLogs()},
+			expectedLogs: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var attempts int32
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if atomic.AddInt32(&attempts, 1) == 1 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+
+					w.WriteHeader(http.StatusOK)
+				}),
+			)
+			defer ts.Close()
+
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+
+			options := append([]Option{
+				WithRetryBackoff(&Backoff{
+					InitialInterval: time.Millisecond,
+					MaxInterval:     time.Millisecond,
+					Multiplier:      1,
+					MaxRetries:      1,
+				}, false),
+			}, test.options...)
+			client := New(time.Second, 1, options...)
+
+			resp, err := client.Get(ts.URL)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NoError(t, resp.Body.Close())
+			assert.Equal(t, test.expectedLogs, strings.Contains(logs.String(), "retrying request"))
+		})
+	}
+}
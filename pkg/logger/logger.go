@@ -20,10 +20,9 @@ import (
 	"log"
 	"os"
 	"path"
+	"sync"
 
-	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
-
+	"github.com/coinbase/rosetta-cli/configuration"
 	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/parser"
@@ -32,6 +31,8 @@ import (
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var _ statefulsyncer.Logger = (*Logger)(nil)
@@ -56,6 +57,10 @@ const (
 	reconcileSuccessStreamFile = "successful_reconciliations.txt"
 	reconcileFailureStreamFile = "failure_reconciliations.txt"
 
+	// reconcileExemptStreamFile contains the stream of failed
+	// reconciliations tolerated by a balance exemption.
+	reconcileExemptStreamFile = "exempt_reconciliations.txt"
+
 	// addEvent is printed in a stream
 	// when an event is added.
 	addEvent = "Add"
@@ -78,6 +83,19 @@ type Logger struct {
 	logTransactions   bool
 	logBalanceChanges bool
 	logReconciliation bool
+	level             zapcore.Level
+
+	// categoriesLock guards the logging booleans
+	// (which change when the configuration is reloaded).
+	categoriesLock sync.RWMutex
+
+	// transactionOpTypes is only populated if logged
+	// transactions are filtered by operation type.
+	transactionOpTypes map[string]struct{}
+
+	// jsonLogger is only populated if logs are
+	// printed as JSON lines.
+	jsonLogger *zap.Logger
 
 	lastStatsMessage    string
 	lastProgressMessage string
@@ -85,40 +103,107 @@ type Logger struct {
 	zapLogger *zap.Logger
 }
 
-// NewLogger constructs a new Logger.
+// NewLogger constructs a new Logger. At zapcore.DebugLevel, block
+// progress is printed even if block logging is disabled (the block
+// stream file is only written if logBlocks is true). If format is
+// configuration.JSONLogFormat, block, transaction, and reconciliation
+// logs are printed to stdout as JSON lines.
 func NewLogger(
 	logDir string,
 	logBlocks bool,
 	logTransactions bool,
 	logBalanceChanges bool,
 	logReconciliation bool,
+	level zapcore.Level,
+	format configuration.LogFormat,
 	checkType CheckType,
 	network *types.NetworkIdentifier,
 	fields ...zap.Field,
 ) (*Logger, error) {
-	zapLogger, err := buildZapLogger(checkType, network, fields...)
+	zapLogger, err := buildZapLogger(level, checkType, network, fields...)
 	if err != nil {
 		return nil, err
 	}
+
+	var jsonLogger *zap.Logger
+	if format == configuration.JSONLogFormat {
+		jsonLogger = buildJSONLogger(zapcore.Lock(os.Stdout), checkType, network, fields...)
+	}
+
 	return &Logger{
 		logDir:            logDir,
 		logBlocks:         logBlocks,
 		logTransactions:   logTransactions,
 		logBalanceChanges: logBalanceChanges,
 		logReconciliation: logReconciliation,
+		level:             level,
+		jsonLogger:        jsonLogger,
 		zapLogger:         zapLogger,
 	}, nil
 }
 
+// SetLogCategories changes the categories of
+// data that are logged during a run.
+func (l *Logger) SetLogCategories(categories *configuration.LogCategories) {
+	l.categoriesLock.Lock()
+	defer l.categoriesLock.Unlock()
+
+	l.logBlocks = categories.Blocks
+	l.logTransactions = categories.Transactions
+	l.logBalanceChanges = categories.BalanceChanges
+	l.logReconciliation = categories.Reconciliations
+}
+
+// SetTransactionOpTypes filters logged transactions to those
+// containing at least one operation of opTypes. If opTypes is
+// empty, all transactions are logged.
+func (l *Logger) SetTransactionOpTypes(opTypes []string) {
+	if len(opTypes) == 0 {
+		l.transactionOpTypes = nil
+		return
+	}
+
+	l.transactionOpTypes = map[string]struct{}{}
+	for _, opType := range opTypes {
+		l.transactionOpTypes[opType] = struct{}{}
+	}
+}
+
+// logTransaction returns a boolean indicating if tx
+// passes the operation type filter.
+func (l *Logger) logTransaction(tx *types.Transaction) bool {
+	if l.transactionOpTypes == nil {
+		return true
+	}
+
+	for _, op := range tx.Operations {
+		if _, ok := l.transactionOpTypes[op.Type]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enabled returns the value of a logging boolean.
+func (l *Logger) enabled(category *bool) bool {
+	l.categoriesLock.RLock()
+	defer l.categoriesLock.RUnlock()
+
+	return *category
+}
+
 func buildZapLogger(
+	level zapcore.Level,
 	checkType CheckType,
 	network *types.NetworkIdentifier,
 	fields ...zap.Field,
 ) (*zap.Logger, error) {
 	config := zap.NewDevelopmentConfig()
+	config.Level = zap.NewAtomicLevelAt(level)
 	config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 
-	baseSlice := []zap.Field {
+	baseSlice := []zap.Field{
 		zap.String("blockchain", network.Blockchain),
 		zap.String("network", network.Network),
 		zap.String("check_type", string(checkType)),
@@ -131,14 +216,50 @@ func buildZapLogger(
 	return zapLogger, err
 }
 
+// buildJSONLogger returns a *zap.Logger that writes JSON lines to w.
+// Logs are never filtered by level here because the caller already
+// decides which logs to print (as it does with text logs).
+func buildJSONLogger(
+	w zapcore.WriteSyncer,
+	checkType CheckType,
+	network *types.NetworkIdentifier,
+	fields ...zap.Field,
+) *zap.Logger {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "time"
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.CallerKey = ""
+	encoderConfig.StacktraceKey = ""
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), w, zapcore.DebugLevel)
+	return zap.New(core).With(append([]zap.Field{
+		zap.String("blockchain", network.Blockchain),
+		zap.String("network", network.Network),
+		zap.String("check_type", string(checkType)),
+	}, fields...)...)
+}
+
+// blockFields returns the fields used to
+// log events for block.
+func blockFields(block *types.BlockIdentifier) []zap.Field {
+	return []zap.Field{
+		zap.Int64("block_index", block.Index),
+		zap.String("block_hash", block.Hash),
+	}
+}
+
 // LogDataStatus logs results.CheckDataStatus.
 func (l *Logger) LogDataStatus(ctx context.Context, status *results.CheckDataStatus) {
+	if !l.level.Enabled(zapcore.InfoLevel) {
+		return
+	}
+
 	if status.Stats.Blocks == 0 { // wait for at least 1 block to be processed
 		return
 	}
 
 	statsMessage := fmt.Sprintf(
-		"[STATS] Blocks: %d (Orphaned: %d) Transactions: %d Operations: %d Accounts: %d Reconciliations: %d (Inactive: %d, Exempt: %d, Skipped: %d, Coverage: %f%%)", // nolint:lll
+		"[STATS] Blocks: %d (Orphaned: %d) Transactions: %d Operations: %d Accounts: %d Reconciliations: %d (Inactive: %d, Exempt: %d, Skipped: %d, Currency Exempt: %d, Coverage: %f%%)", // nolint:lll
 		status.Stats.Blocks,
 		status.Stats.Orphans,
 		status.Stats.Transactions,
@@ -148,9 +269,19 @@ func (l *Logger) LogDataStatus(ctx context.Context, status *results.CheckDataSta
 		status.Stats.InactiveReconciliations,
 		status.Stats.ExemptReconciliations,
 		status.Stats.SkippedReconciliations,
+		status.Stats.CurrencyExemptReconciliations,
 		status.Stats.ReconciliationCoverage*utils.OneHundred,
 	)
 
+	if status.Reconciliations != nil {
+		statsMessage = fmt.Sprintf(
+			"%s Reconciliations End Condition: %d/%d",
+			statsMessage,
+			status.Reconciliations.Succeeded,
+			status.Reconciliations.Target,
+		)
+	}
+
 	// Don't print out the same stats message twice.
 	if statsMessage == l.lastStatsMessage {
 		return
@@ -184,11 +315,51 @@ func (l *Logger) LogDataStatus(ctx context.Context, status *results.CheckDataSta
 	color.Cyan(progressMessage)
 }
 
+// LogDataProgress logs a one-line summary of
+// results.ThroughputProgress.
+func (l *Logger) LogDataProgress(ctx context.Context, progress *results.ThroughputProgress) {
+	if !l.level.Enabled(zapcore.InfoLevel) {
+		return
+	}
+
+	if progress == nil { // wait for at least 1 block to be synced
+		return
+	}
+
+	progressMessage := fmt.Sprintf(
+		"[THROUGHPUT] Head: %d Current Rate: %f/second Average Rate: %f/second",
+		progress.Head,
+		progress.CurrentRate,
+		progress.AverageRate,
+	)
+
+	if progress.Target != nil {
+		eta := progress.ETA
+		if len(eta) == 0 {
+			eta = "unknown"
+		}
+
+		progressMessage = fmt.Sprintf(
+			"%s Target: %d Blocks Remaining: %d ETA: %s",
+			progressMessage,
+			*progress.Target,
+			*progress.BlocksRemaining,
+			eta,
+		)
+	}
+
+	color.Cyan(progressMessage)
+}
+
 // LogConstructionStatus logs results.CheckConstructionStatus.
 func (l *Logger) LogConstructionStatus(
 	ctx context.Context,
 	status *results.CheckConstructionStatus,
 ) {
+	if !l.level.Enabled(zapcore.InfoLevel) {
+		return
+	}
+
 	statsMessage := fmt.Sprintf(
 		"[STATS] Transactions Confirmed: %d (Created: %d, In Progress: %d, Stale: %d, Failed: %d) Addresses Created: %d",
 		status.Stats.TransactionsConfirmed,
@@ -198,6 +369,17 @@ func (l *Logger) LogConstructionStatus(
 		status.Stats.FailedBroadcasts,
 		status.Stats.AddressesCreated,
 	)
+	if status.Confirmations != nil {
+		for _, currency := range status.Confirmations.Currencies {
+			statsMessage = fmt.Sprintf(
+				"%s %s Pending: %s Available: %s",
+				statsMessage,
+				currency.Currency.Symbol,
+				currency.Pending,
+				currency.Available,
+			)
+		}
+	}
 	if statsMessage == l.lastStatsMessage {
 		return
 	}
@@ -226,7 +408,21 @@ func (l *Logger) AddBlockStream(
 	ctx context.Context,
 	block *types.Block,
 ) error {
-	if !l.logBlocks {
+	blockString := fmt.Sprintf(
+		"%s Block %d:%s with Parent Block %d:%s\n",
+		addEvent,
+		block.BlockIdentifier.Index,
+		block.BlockIdentifier.Hash,
+		block.ParentBlockIdentifier.Index,
+		block.ParentBlockIdentifier.Hash,
+	)
+	fields := append(
+		blockFields(block.BlockIdentifier),
+		zap.Int64("parent_block_index", block.ParentBlockIdentifier.Index),
+		zap.String("parent_block_hash", block.ParentBlockIdentifier.Hash),
+	)
+	if !l.enabled(&l.logBlocks) {
+		l.printBlockProgress(blockString, addEvent, fields)
 		return nil
 	}
 
@@ -241,15 +437,7 @@ func (l *Logger) AddBlockStream(
 
 	defer closeFile(f)
 
-	blockString := fmt.Sprintf(
-		"%s Block %d:%s with Parent Block %d:%s\n",
-		addEvent,
-		block.BlockIdentifier.Index,
-		block.BlockIdentifier.Hash,
-		block.ParentBlockIdentifier.Index,
-		block.ParentBlockIdentifier.Hash,
-	)
-	fmt.Print(blockString)
+	l.printBlock(zapcore.InfoLevel, blockString, addEvent, fields)
 	if _, err := f.WriteString(blockString); err != nil {
 		return err
 	}
@@ -257,13 +445,47 @@ func (l *Logger) AddBlockStream(
 	return l.TransactionStream(ctx, block)
 }
 
+// printBlockProgress prints a block event at
+// zapcore.DebugLevel when block logging is disabled.
+func (l *Logger) printBlockProgress(blockString string, event string, fields []zap.Field) {
+	if l.level.Enabled(zapcore.DebugLevel) {
+		l.printBlock(zapcore.DebugLevel, blockString, event, fields)
+	}
+}
+
+// printBlock prints a block event as text
+// (or as a JSON line at level).
+func (l *Logger) printBlock(
+	level zapcore.Level,
+	blockString string,
+	event string,
+	fields []zap.Field,
+) {
+	if l.jsonLogger == nil {
+		fmt.Print(blockString)
+		return
+	}
+
+	if ce := l.jsonLogger.Check(level, fmt.Sprintf("%s Block", event)); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
 // RemoveBlockStream writes the next processed block to the end of the
 // blockStreamFile output file.
 func (l *Logger) RemoveBlockStream(
 	ctx context.Context,
 	block *types.BlockIdentifier,
 ) error {
-	if !l.logBlocks {
+	blockString := fmt.Sprintf(
+		"%s Block %d:%s\n",
+		removeEvent,
+		block.Index,
+		block.Hash,
+	)
+	fields := blockFields(block)
+	if !l.enabled(&l.logBlocks) {
+		l.printBlockProgress(blockString, removeEvent, fields)
 		return nil
 	}
 
@@ -278,13 +500,7 @@ func (l *Logger) RemoveBlockStream(
 
 	defer closeFile(f)
 
-	blockString := fmt.Sprintf(
-		"%s Block %d:%s\n",
-		removeEvent,
-		block.Index,
-		block.Hash,
-	)
-	fmt.Print(blockString)
+	l.printBlock(zapcore.InfoLevel, blockString, removeEvent, fields)
 	_, err = f.WriteString(blockString)
 	return err
 }
@@ -295,7 +511,7 @@ func (l *Logger) TransactionStream(
 	ctx context.Context,
 	block *types.Block,
 ) error {
-	if !l.logTransactions {
+	if !l.enabled(&l.logTransactions) {
 		return nil
 	}
 
@@ -311,6 +527,10 @@ func (l *Logger) TransactionStream(
 	defer closeFile(f)
 
 	for _, tx := range block.Transactions {
+		if !l.logTransaction(tx) {
+			continue
+		}
+
 		transactionString := fmt.Sprintf(
 			"Transaction %s at Block %d:%s\n",
 			tx.TransactionIdentifier.Hash,
@@ -318,7 +538,18 @@ func (l *Logger) TransactionStream(
 			block.BlockIdentifier.Hash,
 		)
 
-		fmt.Print(transactionString)
+		if l.jsonLogger == nil {
+			fmt.Print(transactionString)
+		} else {
+			l.jsonLogger.Info(
+				"Transaction",
+				append(
+					blockFields(block.BlockIdentifier),
+					zap.String("transaction_hash", tx.TransactionIdentifier.Hash),
+				)...,
+			)
+		}
+
 		_, err = f.WriteString(transactionString)
 
 		if err != nil {
@@ -367,7 +598,7 @@ func (l *Logger) BalanceStream(
 	ctx context.Context,
 	balanceChanges []*parser.BalanceChange,
 ) error {
-	if !l.logBalanceChanges {
+	if !l.enabled(&l.logBalanceChanges) {
 		return nil
 	}
 
@@ -409,7 +640,7 @@ func (l *Logger) ReconcileSuccessStream(
 	balance string,
 	block *types.BlockIdentifier,
 ) error {
-	if !l.logReconciliation {
+	if !l.enabled(&l.logReconciliation) {
 		return nil
 	}
 
@@ -424,12 +655,22 @@ func (l *Logger) ReconcileSuccessStream(
 
 	defer closeFile(f)
 
-	log.Printf(
-		"%s Reconciled %s at %d\n",
-		reconciliationType,
-		types.AccountString(account),
-		block.Index,
-	)
+	if l.jsonLogger == nil {
+		log.Printf(
+			"%s Reconciled %s at %d\n",
+			reconciliationType,
+			types.AccountString(account),
+			block.Index,
+		)
+	} else {
+		l.jsonLogger.Info(
+			"Reconciled",
+			append(
+				reconciliationFields(reconciliationType, account, currency, block),
+				zap.String("balance", balance),
+			)...,
+		)
+	}
 
 	_, err = f.WriteString(fmt.Sprintf(
 		"Type:%s Account: %s Currency: %s Balance: %s Block: %d:%s\n",
@@ -458,8 +699,25 @@ func (l *Logger) ReconcileFailureStream(
 	liveBalance string,
 	block *types.BlockIdentifier,
 ) error {
-	// Always print out reconciliation failures
-	if reconciliationType == reconciler.InactiveReconciliation {
+	// Print out reconciliation failures unless
+	// only errors are logged.
+	switch {
+	case !l.level.Enabled(zapcore.WarnLevel):
+	case l.jsonLogger != nil:
+		msg := "Reconciliation failed"
+		if reconciliationType == reconciler.InactiveReconciliation {
+			msg = "Missing balance-changing operation detected"
+		}
+
+		l.jsonLogger.Warn(
+			msg,
+			append(
+				reconciliationFields(reconciliationType, account, currency, block),
+				zap.String("computed_balance", computedBalance),
+				zap.String("live_balance", liveBalance),
+			)...,
+		)
+	case reconciliationType == reconciler.InactiveReconciliation:
 		color.Yellow(
 			"Missing balance-changing operation detected for %s computed: %s%s live: %s%s",
 			types.AccountString(account),
@@ -468,7 +726,7 @@ func (l *Logger) ReconcileFailureStream(
 			liveBalance,
 			currency.Symbol,
 		)
-	} else {
+	default:
 		color.Yellow(
 			"Reconciliation failed for %s at %d computed: %s%s live: %s%s",
 			types.AccountString(account),
@@ -480,7 +738,7 @@ func (l *Logger) ReconcileFailureStream(
 		)
 	}
 
-	if !l.logReconciliation {
+	if !l.enabled(&l.logReconciliation) {
 		return nil
 	}
 
@@ -512,6 +770,95 @@ func (l *Logger) ReconcileFailureStream(
 	return nil
 }
 
+// ReconcileExemptStream logs reconciliations that failed
+// but were tolerated by a balance exemption (with the delta
+// of the live balance from the computed balance).
+func (l *Logger) ReconcileExemptStream(
+	ctx context.Context,
+	reconciliationType string,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	computedBalance string,
+	liveBalance string,
+	delta string,
+	exemptionType types.ExemptionType,
+	block *types.BlockIdentifier,
+) error {
+	switch {
+	case !l.level.Enabled(zapcore.InfoLevel):
+	case l.jsonLogger != nil:
+		l.jsonLogger.Info(
+			"Reconciliation exempt",
+			append(
+				reconciliationFields(reconciliationType, account, currency, block),
+				zap.String("computed_balance", computedBalance),
+				zap.String("live_balance", liveBalance),
+				zap.String("delta", delta),
+				zap.String("exemption_type", string(exemptionType)),
+			)...,
+		)
+	default:
+		color.Cyan(
+			"Reconciliation exempt (%s) for %s at %d computed: %s%s live: %s%s delta: %s%s",
+			exemptionType,
+			types.AccountString(account),
+			block.Index,
+			computedBalance,
+			currency.Symbol,
+			liveBalance,
+			currency.Symbol,
+			delta,
+			currency.Symbol,
+		)
+	}
+
+	if !l.enabled(&l.logReconciliation) {
+		return nil
+	}
+
+	f, err := os.OpenFile(
+		path.Join(l.logDir, reconcileExemptStreamFile),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer closeFile(f)
+
+	_, err = f.WriteString(fmt.Sprintf(
+		"Type:%s Account: %s Currency: %s Block: %s:%d computed: %s live: %s delta: %s exemption: %s\n",
+		reconciliationType,
+		types.AccountString(account),
+		types.CurrencyString(currency),
+		block.Hash,
+		block.Index,
+		computedBalance,
+		liveBalance,
+		delta,
+		exemptionType,
+	))
+
+	return err
+}
+
+// reconciliationFields returns the fields
+// used to log a reconciliation.
+func reconciliationFields(
+	reconciliationType string,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	block *types.BlockIdentifier,
+) []zap.Field {
+	return append(
+		blockFields(block),
+		zap.String("reconciliation_type", reconciliationType),
+		zap.String("account", types.AccountString(account)),
+		zap.String("currency", types.CurrencyString(currency)),
+	)
+}
+
 // Info logs at Info level
 func (l *Logger) Info(msg string, fields ...zap.Field) {
 	l.zapLogger.Info(msg, fields...)
@@ -0,0 +1,360 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	network = &types.NetworkIdentifier{Blockchain: "blockchain", Network: "network"}
+	account = &types.AccountIdentifier{Address: "addr"}
+	btc     = &types.Currency{Symbol: "BTC", Decimals: 8}
+	block   = &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: 9, Hash: "block 9"},
+		Transactions: []*types.Transaction{
+			{TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx"}},
+		},
+	}
+)
+
+func TestJSONLogFormat(t *testing.T) {
+	var tests = map[string]struct {
+		log func(ctx context.Context, l *Logger) error
+
+		expected []map[string]interface{}
+	}{
+		"add block": {
+			log: func(ctx context.Context, l *Logger) error {
+				return l.AddBlockStream(ctx, block)
+			},
+			expected: []map[string]interface{}{
+				{
+					"level":              "info",
+					"msg":                "Add Block",
+					"block_index":        float64(10),
+					"block_hash":         "block 10",
+					"parent_block_index": float64(9),
+				},
+				{
+					"level":            "info",
+					"msg":              "Transaction",
+					"block_index":      float64(10),
+					"transaction_hash": "tx",
+				},
+			},
+		},
+		"remove block": {
+			log: func(ctx context.Context, l *Logger) error {
+				return l.RemoveBlockStream(ctx, block.BlockIdentifier)
+			},
+			expected: []map[string]interface{}{
+				{
+					"level":       "info",
+					"msg":         "Remove Block",
+					"block_index": float64(10),
+				},
+			},
+		},
+		"reconciliation success": {
+			log: func(ctx context.Context, l *Logger) error {
+				return l.ReconcileSuccessStream(
+					ctx,
+					reconciler.ActiveReconciliation,
+					account,
+					btc,
+					"100",
+					block.BlockIdentifier,
+				)
+			},
+			expected: []map[string]interface{}{
+				{
+					"level":       "info",
+					"msg":         "Reconciled",
+					"block_index": float64(10),
+					"account":     "addr",
+					"currency":    types.CurrencyString(btc),
+					"balance":     "100",
+				},
+			},
+		},
+		"reconciliation failure": {
+			log: func(ctx context.Context, l *Logger) error {
+				return l.ReconcileFailureStream(
+					ctx,
+					reconciler.ActiveReconciliation,
+					account,
+					btc,
+					"100",
+					"90",
+					block.BlockIdentifier,
+				)
+			},
+			expected: []map[string]interface{}{
+				{
+					"level":            "warn",
+					"msg":              "Reconciliation failed",
+					"block_index":      float64(10),
+					"account":          "addr",
+					"currency":         types.CurrencyString(btc),
+					"computed_balance": "100",
+					"live_balance":     "90",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			l, err := NewLogger(
+				dir,
+				true,
+				true,
+				true,
+				true,
+				zapcore.InfoLevel,
+				configuration.JSONLogFormat,
+				Data,
+				network,
+			)
+			assert.NoError(t, err)
+
+			var b bytes.Buffer
+			l.jsonLogger = buildJSONLogger(zapcore.AddSync(&b), Data, network)
+			assert.NoError(t, test.log(context.Background(), l))
+
+			lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+			assert.Len(t, lines, len(test.expected))
+			for i, line := range lines {
+				var parsed map[string]interface{}
+				assert.NoError(t, json.Unmarshal([]byte(line), &parsed))
+				assert.Equal(t, "blockchain", parsed["blockchain"])
+				assert.Equal(t, string(Data), parsed["check_type"])
+				assert.Contains(t, parsed, "time")
+				for key, value := range test.expected[i] {
+					assert.Equal(t, value, parsed[key], key)
+				}
+			}
+		})
+	}
+}
+
+func TestTextLogFormat(t *testing.T) {
+	l, err := NewLogger(
+		"",
+		false,
+		false,
+		false,
+		false,
+		zapcore.DebugLevel,
+		configuration.TextLogFormat,
+		Data,
+		network,
+	)
+	assert.NoError(t, err)
+	assert.Nil(t, l.jsonLogger)
+}
+
+func TestSetLogCategories(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	l, err := NewLogger(
+		dir,
+		false,
+		false,
+		false,
+		false,
+		zapcore.ErrorLevel,
+		configuration.TextLogFormat,
+		Data,
+		network,
+	)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	changes := []*parser.BalanceChange{
+		{
+			Account:    account,
+			Currency:   btc,
+			Difference: "100",
+			Block:      block.BlockIdentifier,
+		},
+	}
+	assert.NoError(t, l.BalanceStream(ctx, changes))
+	_, err = os.Stat(path.Join(dir, balanceStreamFile))
+	assert.True(t, os.IsNotExist(err))
+
+	l.SetLogCategories(&configuration.LogCategories{BalanceChanges: true})
+	assert.NoError(t, l.BalanceStream(ctx, changes))
+	contents, err := ioutil.ReadFile(path.Join(dir, balanceStreamFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "Account: addr Change: 100:BTC:8 Block: 10:block 10")
+}
+
+func TestTransactionOpTypes(t *testing.T) {
+	mixed := &types.Block{
+		BlockIdentifier:       block.BlockIdentifier,
+		ParentBlockIdentifier: block.ParentBlockIdentifier,
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "transfer tx"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "transfer",
+						Status:              types.String("SUCCESS"),
+					},
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 1},
+						Type:                "fee",
+						Status:              types.String("SUCCESS"),
+					},
+				},
+			},
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "fee tx"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "fee",
+						Status:              types.String("SUCCESS"),
+					},
+				},
+			},
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "reward tx"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "reward",
+						Status:              types.String("SUCCESS"),
+					},
+				},
+			},
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "empty tx"},
+			},
+		},
+	}
+
+	var tests = map[string]struct {
+		opTypes []string
+
+		expected []string
+	}{
+		"all": {
+			expected: []string{"transfer tx", "fee tx", "reward tx", "empty tx"},
+		},
+		"transfer": {
+			opTypes:  []string{"transfer"},
+			expected: []string{"transfer tx"},
+		},
+		"fee": {
+			opTypes:  []string{"fee"},
+			expected: []string{"transfer tx", "fee tx"},
+		},
+		"multiple": {
+			opTypes:  []string{"transfer", "reward"},
+			expected: []string{"transfer tx", "reward tx"},
+		},
+		"none matching": {
+			opTypes: []string{"stake"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			l, err := NewLogger(
+				dir,
+				false,
+				true,
+				false,
+				false,
+				zapcore.InfoLevel,
+				configuration.JSONLogFormat,
+				Data,
+				network,
+			)
+			assert.NoError(t, err)
+			l.SetTransactionOpTypes(test.opTypes)
+
+			var b bytes.Buffer
+			l.jsonLogger = buildJSONLogger(zapcore.AddSync(&b), Data, network)
+			assert.NoError(t, l.TransactionStream(context.Background(), mixed))
+
+			var logged []string
+			for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+				if len(line) == 0 {
+					continue
+				}
+
+				var parsed map[string]interface{}
+				assert.NoError(t, json.Unmarshal([]byte(line), &parsed))
+				logged = append(logged, parsed["transaction_hash"].(string))
+			}
+			assert.Equal(t, test.expected, logged)
+
+			// The transaction stream file is
+			// filtered the same way.
+			contents, err := ioutil.ReadFile(path.Join(dir, transactionStreamFile))
+			assert.NoError(t, err)
+			for _, tx := range mixed.Transactions {
+				hash := tx.TransactionIdentifier.Hash
+				assert.Equal(
+					t,
+					contains(test.expected, hash),
+					strings.Contains(string(contents), "Transaction "+hash+" "),
+				)
+			}
+		})
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
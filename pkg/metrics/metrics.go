@@ -0,0 +1,260 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+)
+
+const (
+	// Path is the path of the status server that serves
+	// metrics in the Prometheus text exposition format.
+	Path = "/metrics"
+
+	// contentType is the content type of the Prometheus
+	// text exposition format.
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// Metric names are part of the interface of rosetta-cli
+// (dashboards and alerts are built on them), so they must
+// not be changed.
+const (
+	// BlocksSynced is the number of blocks synced
+	// (including blocks that were later orphaned).
+	BlocksSynced = "rosetta_cli_blocks_synced_total"
+
+	// OrphanedBlocks is the number of blocks
+	// orphaned by reorgs.
+	OrphanedBlocks = "rosetta_cli_orphaned_blocks_total"
+
+	// CurrentBlockIndex is the index of the
+	// last block synced.
+	CurrentBlockIndex = "rosetta_cli_current_block_index"
+
+	// TipBlockIndex is the index of the current block
+	// of the Rosetta implementation. It is omitted if
+	// /network/status fails.
+	TipBlockIndex = "rosetta_cli_tip_block_index"
+
+	// ActiveReconciliations is the number of
+	// active reconciliations performed.
+	ActiveReconciliations = "rosetta_cli_active_reconciliations_total"
+
+	// InactiveReconciliations is the number of
+	// inactive reconciliations performed.
+	InactiveReconciliations = "rosetta_cli_inactive_reconciliations_total"
+
+	// FailedReconciliations is the number of
+	// reconciliations that failed.
+	FailedReconciliations = "rosetta_cli_failed_reconciliations_total"
+
+	// BroadcastsCreated is the number of
+	// transactions created.
+	BroadcastsCreated = "rosetta_cli_broadcasts_created_total"
+
+	// BroadcastsConfirmed is the number of
+	// transactions confirmed on-chain.
+	BroadcastsConfirmed = "rosetta_cli_broadcasts_confirmed_total"
+
+	// BroadcastsFailed is the number of transactions
+	// that were never confirmed on-chain.
+	BroadcastsFailed = "rosetta_cli_broadcasts_failed_total"
+
+	// DatabaseSize is the size (in bytes) of
+	// the database directory.
+	DatabaseSize = "rosetta_cli_database_size_bytes"
+
+	// SlowBlocks is the number of blocks that took longer
+	// than slow_block_threshold to fetch and process. It is
+	// omitted if slow_block_threshold is not populated.
+	SlowBlocks = "rosetta_cli_slow_blocks_total"
+
+	// SlowestBlockDuration is the time (in seconds) taken
+	// to fetch and process the slowest block. It is omitted
+	// if slow_block_threshold is not populated.
+	SlowestBlockDuration = "rosetta_cli_slowest_block_seconds"
+
+	// RequestDuration is a histogram of the duration (in
+	// seconds) of each request to a Rosetta implementation
+	// (labeled by URL path).
+	RequestDuration = "rosetta_cli_request_duration_seconds"
+)
+
+// Type is the Prometheus type of a metric.
+type Type string
+
+const (
+	// Counter is a metric that only increases.
+	Counter Type = "counter"
+
+	// Gauge is a metric that can increase or decrease.
+	Gauge Type = "gauge"
+
+	// Histogram is a metric that counts observations
+	// in buckets.
+	Histogram Type = "histogram"
+)
+
+// definition is the type and help text of a metric.
+type definition struct {
+	metricType Type
+	help       string
+}
+
+var (
+	// definitions are the definitions of all metrics.
+	definitions = map[string]definition{
+		BlocksSynced:            {Counter, "Number of blocks synced (including orphaned blocks)."},
+		OrphanedBlocks:          {Counter, "Number of blocks orphaned by reorgs."},
+		CurrentBlockIndex:       {Gauge, "Index of the last block synced."},
+		TipBlockIndex:           {Gauge, "Index of the current block of the implementation."},
+		ActiveReconciliations:   {Counter, "Number of active reconciliations performed."},
+		InactiveReconciliations: {Counter, "Number of inactive reconciliations performed."},
+		FailedReconciliations:   {Counter, "Number of failed reconciliations."},
+		BroadcastsCreated:       {Counter, "Number of transactions created."},
+		BroadcastsConfirmed:     {Counter, "Number of transactions confirmed on-chain."},
+		BroadcastsFailed:        {Counter, "Number of transactions never confirmed on-chain."},
+		DatabaseSize:            {Gauge, "Size of the database directory in bytes."},
+		SlowBlocks:              {Counter, "Number of blocks slower than the slow block threshold."},
+		SlowestBlockDuration:    {Gauge, "Time to fetch and process the slowest block in seconds."},
+		RequestDuration:         {Histogram, "Duration of requests to the implementation."},
+	}
+
+	// DataCounters are the counters of check:data
+	// (keyed by metric name).
+	DataCounters = map[string]string{
+		BlocksSynced:            modules.BlockCounter,
+		OrphanedBlocks:          modules.OrphanCounter,
+		ActiveReconciliations:   modules.ActiveReconciliationCounter,
+		InactiveReconciliations: modules.InactiveReconciliationCounter,
+		FailedReconciliations:   modules.FailedReconciliationCounter,
+	}
+
+	// ConstructionCounters are the counters of
+	// check:construction (keyed by metric name).
+	ConstructionCounters = map[string]string{
+		BroadcastsCreated:   modules.TransactionsCreatedCounter,
+		BroadcastsConfirmed: modules.TransactionsConfirmedCounter,
+		BroadcastsFailed:    modules.FailedBroadcastsCounter,
+	}
+)
+
+// Sample is the value of a metric when it was collected.
+type Sample struct {
+	Name  string
+	Value float64
+}
+
+// CounterSamples returns a *Sample for each counter
+// in counters (keyed by metric name), sorted by name.
+func CounterSamples(
+	ctx context.Context,
+	counterStorage *modules.CounterStorage,
+	counters map[string]string,
+) ([]*Sample, error) {
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	samples := make([]*Sample, 0, len(counters))
+	for _, name := range names {
+		counter := counters[name]
+		value, err := counterStorage.Get(ctx, counter)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to get %s counter", err, counter)
+		}
+
+		samples = append(samples, &Sample{Name: name, Value: float64(value.Int64())})
+	}
+
+	return samples, nil
+}
+
+// Collector collects the current value of
+// metrics when they are scraped.
+type Collector interface {
+	CollectMetrics(ctx context.Context) ([]*Sample, error)
+}
+
+// Write writes samples and durations (if not nil) to w
+// in the Prometheus text exposition format. Samples are
+// written in the order provided.
+func Write(w io.Writer, samples []*Sample, durations *RequestDurations) error {
+	buf := bufio.NewWriter(w)
+	for _, sample := range samples {
+		writeHeader(buf, sample.Name)
+		fmt.Fprintf(buf, "%s %s\n", sample.Name, formatFloat(sample.Value))
+	}
+
+	if durations != nil {
+		durations.write(buf)
+	}
+
+	return buf.Flush()
+}
+
+// writeHeader writes the HELP and TYPE lines of
+// name (if it is defined).
+func writeHeader(w io.Writer, name string) {
+	def, ok := definitions[name]
+	if !ok {
+		return
+	}
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, def.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, def.metricType)
+}
+
+// formatFloat formats value as a Prometheus sample value.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// Handler serves the metrics of collector and durations
+// on a GET to Path and forwards all other requests to next.
+func Handler(collector Collector, durations *RequestDurations, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != Path {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		samples, err := collector.CollectMetrics(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		_ = Write(w, samples, durations)
+	})
+}
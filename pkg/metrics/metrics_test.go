@@ -0,0 +1,182 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type counterCollector struct {
+	counterStorage *modules.CounterStorage
+	counters       map[string]string
+	err            error
+}
+
+func (c *counterCollector) CollectMetrics(ctx context.Context) ([]*Sample, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	samples, err := CounterSamples(ctx, c.counterStorage, c.counters)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(
+		samples,
+		&Sample{Name: CurrentBlockIndex, Value: 10},
+		&Sample{Name: TipBlockIndex, Value: 12},
+		&Sample{Name: DatabaseSize, Value: 2048},
+	), nil
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	counterStorage := modules.NewCounterStorage(db)
+	_, err = counterStorage.Update(ctx, modules.BlockCounter, big.NewInt(11))
+	assert.NoError(t, err)
+	_, err = counterStorage.Update(ctx, modules.OrphanCounter, big.NewInt(1))
+	assert.NoError(t, err)
+	_, err = counterStorage.Update(ctx, modules.TransactionsCreatedCounter, big.NewInt(3))
+	assert.NoError(t, err)
+
+	durations := NewRequestDurations()
+	durations.Observe("/block", 20*time.Millisecond)
+	durations.Observe("/block", 2*time.Second)
+	durations.Observe("/network/status", time.Millisecond)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	var tests = map[string]struct {
+		collector *counterCollector
+		method    string
+		path      string
+
+		expectedStatus int
+		expectedSeries []string
+	}{
+		"check:data": {
+			collector:      &counterCollector{counterStorage: counterStorage, counters: DataCounters},
+			method:         http.MethodGet,
+			path:           Path,
+			expectedStatus: http.StatusOK,
+			expectedSeries: []string{
+				"# HELP rosetta_cli_blocks_synced_total ",
+				"# TYPE rosetta_cli_blocks_synced_total counter",
+				"rosetta_cli_blocks_synced_total 11\n",
+				"rosetta_cli_orphaned_blocks_total 1\n",
+				"# TYPE rosetta_cli_current_block_index gauge",
+				"rosetta_cli_current_block_index 10\n",
+				"rosetta_cli_tip_block_index 12\n",
+				"rosetta_cli_active_reconciliations_total 0\n",
+				"rosetta_cli_inactive_reconciliations_total 0\n",
+				"rosetta_cli_failed_reconciliations_total 0\n",
+				"rosetta_cli_database_size_bytes 2048\n",
+				"# TYPE rosetta_cli_request_duration_seconds histogram",
+				`rosetta_cli_request_duration_seconds_bucket{path="/block",le="0.025"} 1` + "\n",
+				`rosetta_cli_request_duration_seconds_bucket{path="/block",le="2.5"} 2` + "\n",
+				`rosetta_cli_request_duration_seconds_bucket{path="/block",le="+Inf"} 2` + "\n",
+				`rosetta_cli_request_duration_seconds_sum{path="/block"} 2.02` + "\n",
+				`rosetta_cli_request_duration_seconds_count{path="/network/status"} 1` + "\n",
+			},
+		},
+		"check:construction": {
+			collector: &counterCollector{
+				counterStorage: counterStorage,
+				counters:       ConstructionCounters,
+			},
+			method:         http.MethodGet,
+			path:           Path,
+			expectedStatus: http.StatusOK,
+			expectedSeries: []string{
+				"rosetta_cli_broadcasts_created_total 3\n",
+				"rosetta_cli_broadcasts_confirmed_total 0\n",
+				"rosetta_cli_broadcasts_failed_total 0\n",
+				"rosetta_cli_current_block_index 10\n",
+				"rosetta_cli_request_duration_seconds_count{path=\"/block\"} 2\n",
+			},
+		},
+		"collection error": {
+			collector:      &counterCollector{err: errors.New("bad")},
+			method:         http.MethodGet,
+			path:           Path,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		"wrong method": {
+			collector:      &counterCollector{counterStorage: counterStorage, counters: DataCounters},
+			method:         http.MethodPost,
+			path:           Path,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		"forwarded": {
+			collector:      &counterCollector{counterStorage: counterStorage, counters: DataCounters},
+			method:         http.MethodGet,
+			path:           "/",
+			expectedStatus: http.StatusTeapot,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(Handler(test.collector, durations, next))
+			defer server.Close()
+
+			req, err := http.NewRequest(test.method, server.URL+test.path, nil)
+			assert.NoError(t, err)
+			resp, err := server.Client().Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			if test.expectedStatus != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, contentType, resp.Header.Get("Content-Type"))
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			for _, series := range test.expectedSeries {
+				assert.Contains(t, string(body), series)
+			}
+		})
+	}
+}
+
+func TestRequestDurationsNil(t *testing.T) {
+	var durations *RequestDurations
+	durations.Observe("/block", time.Second)
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DurationBuckets are the upper bounds (in seconds) of
+// the buckets of the RequestDuration histogram.
+var DurationBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30,
+}
+
+// histogram counts observations in DurationBuckets.
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// RequestDurations records the RequestDuration
+// histogram of each URL path. It is safe to use
+// concurrently.
+type RequestDurations struct {
+	lock  sync.Mutex
+	paths map[string]*histogram
+}
+
+// NewRequestDurations returns a new *RequestDurations.
+func NewRequestDurations() *RequestDurations {
+	return &RequestDurations{paths: map[string]*histogram{}}
+}
+
+// Observe records a request to path that took duration.
+// Observe is a no-op if r is nil.
+func (r *RequestDurations) Observe(path string, duration time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	h, ok := r.paths[path]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(DurationBuckets))}
+		r.paths[path] = h
+	}
+
+	seconds := duration.Seconds()
+	for i, bound := range DurationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// write writes the histogram of each path
+// (sorted by path) to w.
+func (r *RequestDurations) write(w io.Writer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	writeHeader(w, RequestDuration)
+
+	paths := make([]string, 0, len(r.paths))
+	for path := range r.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		h := r.paths[path]
+		for i, bound := range DurationBuckets {
+			fmt.Fprintf(
+				w,
+				"%s_bucket{path=%q,le=%q} %d\n",
+				RequestDuration,
+				path,
+				formatFloat(bound),
+				h.buckets[i],
+			)
+		}
+		fmt.Fprintf(w, "%s_bucket{path=%q,le=\"+Inf\"} %d\n", RequestDuration, path, h.count)
+		fmt.Fprintf(w, "%s_sum{path=%q} %s\n", RequestDuration, path, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{path=%q} %d\n", RequestDuration, path, h.count)
+	}
+}
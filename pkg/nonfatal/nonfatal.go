@@ -0,0 +1,216 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nonfatal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Kind is the type of a non-fatal error.
+type Kind string
+
+const (
+	// ReconciliationFailure is a reconciliation failure that
+	// did not halt check:data (because ignore_reconciliation_error
+	// is enabled or a failure threshold was not exceeded).
+	ReconciliationFailure Kind = "Reconciliation Failure"
+
+	// ExemptedAssertion is a failed balance assertion (reconciliation)
+	// that was tolerated because of a balance exemption or an
+	// acceptable discrepancy.
+	ExemptedAssertion Kind = "Exempted Assertion"
+
+	// CurrencyVariant is a currency casing warning
+	// (found with currency_casing_check).
+	CurrencyVariant Kind = "Currency Variant"
+
+	// FetchRetry is a request that only succeeded after
+	// more than fetch_retry_soft_limit failed attempts.
+	FetchRetry Kind = "Fetch Retry"
+)
+
+var (
+	// ErrMaxErrorsExceeded is returned when more
+	// than max_errors non-fatal errors occurred.
+	ErrMaxErrorsExceeded = errors.New("max_errors exceeded")
+)
+
+// Count is the number of non-fatal
+// errors of a Kind.
+type Count struct {
+	Kind  Kind  `json:"kind"`
+	Count int64 `json:"count"`
+}
+
+// Results are the non-fatal errors of a check:data run.
+type Results struct {
+	// Max is the number of non-fatal errors tolerated
+	// before check:data halts (0 if there is no limit).
+	Max int64 `json:"max,omitempty"`
+
+	// Total is the number of non-fatal errors
+	// (of all kinds).
+	Total int64 `json:"total"`
+
+	// Exceeded is true if Total exceeded Max
+	// (causing check:data to halt).
+	Exceeded bool `json:"exceeded"`
+
+	// Counts are the non-fatal errors grouped
+	// by Kind (sorted by count).
+	Counts []*Count `json:"counts"`
+}
+
+// Print logs Results to the console.
+func (r *Results) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes Results to w.
+func (r *Results) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Non-Fatal Errors", "Count"})
+	for _, count := range r.Counts {
+		table.Append([]string{string(count.Kind), strconv.FormatInt(count.Count, 10)})
+	}
+	table.Render()
+
+	if r.Max == 0 {
+		fmt.Fprintf(w, "%d non-fatal errors\n", r.Total)
+		return
+	}
+
+	fmt.Fprintf(w, "%d non-fatal errors (max_errors: %d)\n", r.Total, r.Max)
+	if r.Exceeded {
+		color.New(color.FgRed).Fprintf(
+			w,
+			"[NON-FATAL ERRORS] %d non-fatal errors exceeded max_errors %d\n",
+			r.Total,
+			r.Max,
+		)
+	}
+}
+
+// Tracker counts non-fatal errors by Kind and determines
+// when check:data should halt. All methods are safe to
+// call on a nil *Tracker.
+type Tracker struct {
+	max int64
+
+	lock     sync.Mutex
+	total    int64
+	counts   map[Kind]int64
+	exceeded chan struct{}
+}
+
+// New returns a new *Tracker that halts once more than
+// max non-fatal errors occur (if max is populated).
+func New(max int64) *Tracker {
+	return &Tracker{
+		max:      max,
+		counts:   map[Kind]int64{},
+		exceeded: make(chan struct{}),
+	}
+}
+
+// Record records a non-fatal error of kind.
+func (t *Tracker) Record(kind Kind) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.total++
+	t.counts[kind]++
+
+	// The channel is only closed once because
+	// total only increases.
+	if t.max > 0 && t.total == t.max+1 {
+		close(t.exceeded)
+	}
+}
+
+// Exceeded returns a channel that is closed once
+// more than max non-fatal errors occur.
+func (t *Tracker) Exceeded() <-chan struct{} {
+	if t == nil {
+		return nil
+	}
+
+	return t.exceeded
+}
+
+// Err returns an error wrapping ErrMaxErrorsExceeded
+// (or nil if max was not exceeded).
+func (t *Tracker) Err() error {
+	results := t.Results()
+	if results == nil || !results.Exceeded {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: %d non-fatal errors (max: %d)",
+		ErrMaxErrorsExceeded,
+		results.Total,
+		results.Max,
+	)
+}
+
+// Results returns the *Results of t (or nil if
+// t is nil or no non-fatal errors occurred).
+func (t *Tracker) Results() *Results {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.total == 0 {
+		return nil
+	}
+
+	counts := make([]*Count, 0, len(t.counts))
+	for kind, count := range t.counts {
+		counts = append(counts, &Count{Kind: kind, Count: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+
+		return counts[i].Kind < counts[j].Kind
+	})
+
+	return &Results{
+		Max:      t.max,
+		Total:    t.total,
+		Exceeded: t.max > 0 && t.total > t.max,
+		Counts:   counts,
+	}
+}
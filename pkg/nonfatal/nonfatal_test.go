@@ -0,0 +1,142 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nonfatal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker(t *testing.T) {
+	var tests = map[string]struct {
+		max     int64
+		records []Kind
+
+		expectedResults *Results
+		expectedErr     error
+	}{
+		"no errors": {
+			max: 3,
+		},
+		"no max": {
+			records: []Kind{FetchRetry, ReconciliationFailure, FetchRetry},
+			expectedResults: &Results{
+				Total: 3,
+				Counts: []*Count{
+					{Kind: FetchRetry, Count: 2},
+					{Kind: ReconciliationFailure, Count: 1},
+				},
+			},
+		},
+		"within max": {
+			max:     3,
+			records: []Kind{CurrencyVariant, ReconciliationFailure, FetchRetry},
+			expectedResults: &Results{
+				Max:   3,
+				Total: 3,
+				Counts: []*Count{
+					{Kind: CurrencyVariant, Count: 1},
+					{Kind: FetchRetry, Count: 1},
+					{Kind: ReconciliationFailure, Count: 1},
+				},
+			},
+		},
+		"exempted assertions": {
+			max:     3,
+			records: []Kind{ExemptedAssertion, ReconciliationFailure, ExemptedAssertion},
+			expectedResults: &Results{
+				Max:   3,
+				Total: 3,
+				Counts: []*Count{
+					{Kind: ExemptedAssertion, Count: 2},
+					{Kind: ReconciliationFailure, Count: 1},
+				},
+			},
+		},
+		"exceeded max": {
+			max: 3,
+			records: []Kind{
+				ReconciliationFailure,
+				ReconciliationFailure,
+				FetchRetry,
+				ReconciliationFailure,
+				ReconciliationFailure,
+			},
+			expectedResults: &Results{
+				Max:      3,
+				Total:    5,
+				Exceeded: true,
+				Counts: []*Count{
+					{Kind: ReconciliationFailure, Count: 4},
+					{Kind: FetchRetry, Count: 1},
+				},
+			},
+			expectedErr: ErrMaxErrorsExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := New(test.max)
+			for _, kind := range test.records {
+				tracker.Record(kind)
+			}
+
+			assert.Equal(t, test.expectedResults, tracker.Results())
+
+			select {
+			case <-tracker.Exceeded():
+				assert.NotNil(t, test.expectedErr)
+			default:
+				assert.Nil(t, test.expectedErr)
+			}
+
+			err := tracker.Err()
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *Tracker
+	tracker.Record(FetchRetry)
+	assert.Nil(t, tracker.Results())
+	assert.Nil(t, tracker.Exceeded())
+	assert.NoError(t, tracker.Err())
+}
+
+func TestResultsFprint(t *testing.T) {
+	results := &Results{
+		Max:      1,
+		Total:    2,
+		Exceeded: true,
+		Counts: []*Count{
+			{Kind: ReconciliationFailure, Count: 2},
+		},
+	}
+
+	var buf bytes.Buffer
+	results.Fprint(&buf)
+	assert.Contains(t, buf.String(), "Reconciliation Failure")
+	assert.Contains(t, buf.String(), "2 non-fatal errors (max_errors: 1)")
+	assert.Contains(t, buf.String(), "exceeded max_errors 1")
+}
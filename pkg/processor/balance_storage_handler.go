@@ -19,6 +19,7 @@ import (
 	"math/big"
 
 	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/parser"
 	"github.com/coinbase/rosetta-sdk-go/reconciler"
@@ -39,6 +40,18 @@ type BalanceStorageHandler struct {
 
 	reconcile          bool
 	interestingAccount *types.AccountCurrency
+
+	// interestingAccounts is only populated if the
+	// interesting accounts file can be reloaded.
+	interestingAccounts *InterestingAccountsWatcher
+
+	// reconciliationCurrencies restricts
+	// reconciliation to some currencies.
+	reconciliationCurrencies CurrencyFilter
+
+	// exemptCurrencies are never reconciled
+	// (on any account).
+	exemptCurrencies ExemptCurrencies
 }
 
 // NewBalanceStorageHandler returns a new *BalanceStorageHandler.
@@ -48,13 +61,19 @@ func NewBalanceStorageHandler(
 	counterStorage *modules.CounterStorage,
 	reconcile bool,
 	interestingAccount *types.AccountCurrency,
+	interestingAccounts *InterestingAccountsWatcher,
+	reconciliationCurrencies CurrencyFilter,
+	exemptCurrencies ExemptCurrencies,
 ) *BalanceStorageHandler {
 	return &BalanceStorageHandler{
-		logger:             logger,
-		reconciler:         reconciler,
-		counterStorage:     counterStorage,
-		reconcile:          reconcile,
-		interestingAccount: interestingAccount,
+		logger:                   logger,
+		reconciler:               reconciler,
+		counterStorage:           counterStorage,
+		reconcile:                reconcile,
+		interestingAccount:       interestingAccount,
+		interestingAccounts:      interestingAccounts,
+		reconciliationCurrencies: reconciliationCurrencies,
+		exemptCurrencies:         exemptCurrencies,
 	}
 }
 
@@ -94,6 +113,28 @@ func (h *BalanceStorageHandler) BlockAdded(
 		}
 	}
 
+	// Accounts added to the interesting accounts file during
+	// the run are checked on each block (like those loaded
+	// at startup).
+	changes = h.interestingAccounts.Changes(block.BlockIdentifier, changes)
+
+	// Balances of all currencies are tracked but only
+	// changes of some currencies may be reconciled.
+	changes = h.reconciliationCurrencies.Changes(changes)
+
+	// Changes of exempt currencies are skipped (and
+	// counted separately from other skipped reconciliations).
+	changes, skipped := h.exemptCurrencies.Changes(changes)
+	if skipped > 0 {
+		if _, err := h.counterStorage.Update(
+			ctx,
+			results.CurrencyExemptReconciliationCounter,
+			big.NewInt(skipped),
+		); err != nil {
+			return err
+		}
+	}
+
 	// Mark accounts for reconciliation...this may be
 	// blocking
 	return h.reconciler.QueueChanges(ctx, block.BlockIdentifier, changes)
@@ -40,10 +40,14 @@ type BalanceStorageHelper struct {
 
 	// Configuration settings
 	lookupBalanceByBlock bool
-	exemptAccounts       map[string]struct{}
+	exemptAccounts       *AccountMatcher
 	balanceExemptions    []*types.BalanceExemption
 	initialFetchDisabled bool
 
+	// excludedOperationTypes are never
+	// included in balance changes.
+	excludedOperationTypes map[string]struct{}
+
 	// Interesting-only Parsing
 	interestingOnly      bool
 	interestingAddresses map[string]struct{}
@@ -59,13 +63,11 @@ func NewBalanceStorageHelper(
 	interestingOnly bool,
 	balanceExemptions []*types.BalanceExemption,
 	initialFetchDisabled bool,
+	excludedOperationTypes []string,
 ) *BalanceStorageHelper {
-	exemptMap := map[string]struct{}{}
-
-	// Pre-process exemptAccounts on initialization
-	// to provide fast lookup while syncing.
-	for _, account := range exemptAccounts {
-		exemptMap[types.Hash(account)] = struct{}{}
+	excludedMap := map[string]struct{}{}
+	for _, opType := range excludedOperationTypes {
+		excludedMap[opType] = struct{}{}
 	}
 
 	return &BalanceStorageHelper{
@@ -73,11 +75,13 @@ func NewBalanceStorageHelper(
 		fetcher:              fetcher,
 		counterStorage:       counterStorage,
 		lookupBalanceByBlock: lookupBalanceByBlock,
-		exemptAccounts:       exemptMap,
+		exemptAccounts:       NewAccountMatcher(exemptAccounts),
 		interestingAddresses: map[string]struct{}{},
 		interestingOnly:      interestingOnly,
 		balanceExemptions:    balanceExemptions,
 		initialFetchDisabled: initialFetchDisabled,
+
+		excludedOperationTypes: excludedMap,
 	}
 }
 
@@ -139,19 +143,17 @@ func (h *BalanceStorageHelper) AddInterestingAddress(address string) {
 // ExemptFunc returns a parser.ExemptOperation.
 func (h *BalanceStorageHelper) ExemptFunc() parser.ExemptOperation {
 	return func(op *types.Operation) bool {
+		if _, excluded := h.excludedOperationTypes[op.Type]; excluded {
+			return true
+		}
+
 		if h.interestingOnly {
 			if _, exists := h.interestingAddresses[op.Account.Address]; !exists {
 				return true
 			}
 		}
 
-		thisAcct := types.Hash(&types.AccountCurrency{
-			Account:  op.Account,
-			Currency: op.Amount.Currency,
-		})
-
-		_, exists := h.exemptAccounts[thisAcct]
-		return exists
+		return h.exemptAccounts.Match(op.Account, op.Amount.Currency)
 	}
 }
 
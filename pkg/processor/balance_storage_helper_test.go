@@ -15,8 +15,11 @@
 package processor
 
 import (
+	"context"
 	"testing"
 
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -73,6 +76,38 @@ func TestExemptFuncExemptAccounts(t *testing.T) {
 			},
 			exempt: true,
 		},
+		"address is exempt": {
+			exemptAccounts: []*types.AccountCurrency{
+				{
+					Account: &types.AccountIdentifier{
+						Address: opAmountCurrency.Account.Address,
+					},
+					Currency: opAmountCurrency.Currency,
+				},
+			},
+			exempt: true,
+		},
+		"other sub-account exempt": {
+			exemptAccounts: []*types.AccountCurrency{
+				{
+					Account: &types.AccountIdentifier{
+						Address: opAmountCurrency.Account.Address,
+						SubAccount: &types.SubAccountIdentifier{
+							Address: "staking",
+						},
+					},
+					Currency: opAmountCurrency.Currency,
+				},
+			},
+		},
+		"other currency exempt": {
+			exemptAccounts: []*types.AccountCurrency{
+				{
+					Account:  opAmountCurrency.Account,
+					Currency: &types.Currency{Symbol: "ETH", Decimals: 18},
+				},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -86,6 +121,7 @@ func TestExemptFuncExemptAccounts(t *testing.T) {
 				false,
 				nil,
 				false,
+				nil,
 			)
 
 			result := helper.ExemptFunc()(&types.Operation{
@@ -130,6 +166,7 @@ func TestExemptFuncInterestingParsing(t *testing.T) {
 				true,
 				nil,
 				false,
+				nil,
 			)
 
 			for _, addr := range test.interestingAddresses {
@@ -148,3 +185,111 @@ func TestExemptFuncInterestingParsing(t *testing.T) {
 		})
 	}
 }
+
+func TestExemptFuncExcludedOperationTypes(t *testing.T) {
+	a, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{
+			Blockchain: "bitcoin",
+			Network:    "testnet",
+		},
+		&types.BlockIdentifier{
+			Hash:  "block 0",
+			Index: 0,
+		},
+		[]string{"Transfer", "Reward"},
+		[]*types.OperationStatus{{Status: "Success", Successful: true}},
+		[]*types.Error{},
+		nil,
+		&asserter.Validations{
+			Enabled: false,
+		},
+	)
+	assert.NoError(t, err)
+
+	other := &types.AccountIdentifier{Address: "other"}
+	block := &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Hash: "block 1", Index: 1},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx 1"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "Transfer",
+						Status:              types.String("Success"),
+						Account:             opAmountCurrency.Account,
+						Amount: &types.Amount{
+							Value:    "100",
+							Currency: opAmountCurrency.Currency,
+						},
+					},
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 1},
+						Type:                "Reward",
+						Status:              types.String("Success"),
+						Account:             opAmountCurrency.Account,
+						Amount: &types.Amount{
+							Value:    "5",
+							Currency: opAmountCurrency.Currency,
+						},
+					},
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 2},
+						Type:                "Reward",
+						Status:              types.String("Success"),
+						Account:             other,
+						Amount: &types.Amount{
+							Value:    "5",
+							Currency: opAmountCurrency.Currency,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var tests = map[string]struct {
+		excludedOperationTypes []string
+		expected               map[string]string
+	}{
+		"no excluded operation types": {
+			expected: map[string]string{
+				opAmountCurrency.Account.Address: "105",
+				other.Address:                    "5",
+			},
+		},
+		"excluded operation type": {
+			excludedOperationTypes: []string{"Reward"},
+			expected: map[string]string{
+				opAmountCurrency.Account.Address: "100",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			helper := NewBalanceStorageHelper(
+				nil,
+				nil,
+				nil,
+				false,
+				nil,
+				false,
+				nil,
+				false,
+				test.excludedOperationTypes,
+			)
+
+			p := parser.New(a, helper.ExemptFunc(), nil)
+			changes, err := p.BalanceChanges(context.Background(), block, false)
+			assert.NoError(t, err)
+
+			differences := map[string]string{}
+			for _, change := range changes {
+				differences[change.Account.Address] = change.Difference
+			}
+
+			assert.Equal(t, test.expected, differences)
+		})
+	}
+}
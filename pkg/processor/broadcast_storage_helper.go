@@ -17,12 +17,14 @@ package processor
 import (
 	"context"
 	"fmt"
-	"github.com/coinbase/rosetta-sdk-go/utils"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/storage/database"
 	"github.com/coinbase/rosetta-sdk-go/storage/modules"
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
 )
 
 var _ modules.BroadcastStorageHelper = (*BroadcastStorageHelper)(nil)
@@ -33,6 +35,9 @@ type BroadcastStorageHelper struct {
 	network      *types.NetworkIdentifier
 	blockStorage *modules.BlockStorage
 	fetcher      *fetcher.Fetcher
+	skewMonitor  *SkewMonitor
+	limiter      *RateLimiter
+	endpoints    *results.EndpointTracker
 }
 
 // NewBroadcastStorageHelper returns a new BroadcastStorageHelper.
@@ -40,20 +45,33 @@ func NewBroadcastStorageHelper(
 	network *types.NetworkIdentifier,
 	blockStorage *modules.BlockStorage,
 	fetcher *fetcher.Fetcher,
+	skewMonitor *SkewMonitor,
+	broadcastTPS float64,
+	endpoints *results.EndpointTracker,
 ) *BroadcastStorageHelper {
 	return &BroadcastStorageHelper{
 		network:      network,
 		blockStorage: blockStorage,
 		fetcher:      fetcher,
+		skewMonitor:  skewMonitor,
+		limiter:      NewRateLimiter(broadcastTPS),
+		endpoints:    endpoints,
 	}
 }
 
 // AtTip is called before transaction broadcast to determine if we are at tip.
+// tipDelay is corrected using the most recent clock skew estimate.
 func (h *BroadcastStorageHelper) AtTip(
 	ctx context.Context,
 	tipDelay int64,
 ) (bool, error) {
-	atTip, _, err := utils.CheckStorageTip(ctx, h.network, tipDelay, h.fetcher, h.blockStorage)
+	atTip, _, err := utils.CheckStorageTip(
+		ctx,
+		h.network,
+		h.skewMonitor.AdjustTipDelay(tipDelay),
+		h.fetcher,
+		h.blockStorage,
+	)
 	if err != nil {
 		return false, err
 	}
@@ -92,16 +110,23 @@ func (h *BroadcastStorageHelper) FindTransaction(
 
 // BroadcastTransaction broadcasts a transaction to a Rosetta implementation
 // and returns the *types.TransactionIdentifier returned by the implementation.
+// If broadcast_tps is configured, this blocks until the transaction may
+// be submitted without exceeding it.
 func (h *BroadcastStorageHelper) BroadcastTransaction(
 	ctx context.Context,
 	networkIdentifier *types.NetworkIdentifier,
 	networkTransaction string,
 ) (*types.TransactionIdentifier, error) {
+	if err := h.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("%w: unable to wait for broadcast rate limit", err)
+	}
+
 	transactionIdentifier, _, fetchErr := h.fetcher.ConstructionSubmit(
 		ctx,
 		networkIdentifier,
 		networkTransaction,
 	)
+	h.endpoints.Record(constructionSubmit, fetchErr)
 	if fetchErr != nil {
 		return nil, fmt.Errorf("%w: unable to broadcast transaction", fetchErr.Err)
 	}
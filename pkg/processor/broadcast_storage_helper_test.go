@@ -0,0 +1,126 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcastStorageHelperTPS(t *testing.T) {
+	ctx := context.Background()
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+
+	var submitsLock sync.Mutex
+	submits := []time.Time{}
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/construction/submit", r.URL.Path)
+
+			var request types.ConstructionSubmitRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+			submitsLock.Lock()
+			submits = append(submits, time.Now())
+			submitsLock.Unlock()
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			assert.NoError(t, json.NewEncoder(w).Encode(&types.TransactionIdentifierResponse{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: request.SignedTransaction,
+				},
+			}))
+		}),
+	)
+	defer ts.Close()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	blockStorage := modules.NewBlockStorage(db, 1)
+	blockStorage.Initialize([]modules.BlockWorker{})
+	block := &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		Timestamp:             utils.Milliseconds(),
+	}
+	assert.NoError(t, blockStorage.SeeBlock(ctx, block))
+	assert.NoError(t, blockStorage.AddBlock(ctx, block))
+
+	onlineFetcher := fetcher.New(ts.URL, fetcher.WithMaxRetries(0))
+	broadcastStorage := modules.NewBroadcastStorage(db, 10, 3, 60, false, 100)
+	broadcastStorage.Initialize(
+		NewBroadcastStorageHelper(
+			network,
+			blockStorage,
+			onlineFetcher,
+			NewSkewMonitor(ts.URL, network, http.DefaultClient, false, time.Minute),
+			10,
+			nil,
+		),
+		nil,
+	)
+
+	// Queue many pending broadcasts.
+	pending := 6
+	dbTx := db.Transaction(ctx)
+	for i := 0; i < pending; i++ {
+		hash := fmt.Sprintf("tx%d", i)
+		assert.NoError(t, broadcastStorage.Broadcast(
+			ctx,
+			dbTx,
+			fmt.Sprintf("job%d", i),
+			network,
+			[]*types.Operation{},
+			&types.TransactionIdentifier{Hash: hash},
+			hash,
+			1,
+		))
+	}
+	assert.NoError(t, dbTx.Commit(ctx))
+
+	start := time.Now()
+	assert.NoError(t, broadcastStorage.BroadcastAll(ctx, true))
+	elapsed := time.Since(start)
+
+	// At 10 TPS, 6 submissions take at least 500ms
+	// and are never closer than the limit allows.
+	assert.Len(t, submits, pending)
+	assert.GreaterOrEqual(t, elapsed, 500*time.Millisecond)
+	for i := 1; i < len(submits); i++ {
+		assert.GreaterOrEqual(t, submits[i].Sub(submits[i-1]), 90*time.Millisecond)
+	}
+}
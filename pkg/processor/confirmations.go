@@ -0,0 +1,366 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/neilotoole/errgroup"
+)
+
+const (
+	// reorgWindow is the number of blocks deposits are
+	// remembered after they are counted so that they can
+	// be returned to pending if they are reorged.
+	reorgWindow = 100
+)
+
+// PendingDeposit is a successful operation that credits
+// an account (or creates a coin).
+type PendingDeposit struct {
+	Account               *types.AccountIdentifier     `json:"account_identifier"`
+	Amount                *types.Amount                `json:"amount"`
+	CoinIdentifier        *types.CoinIdentifier        `json:"coin_identifier,omitempty"`
+	BlockIdentifier       *types.BlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+
+	// Counted is true once the deposit has reached the
+	// minimum confirmations.
+	Counted bool `json:"-"`
+}
+
+var _ modules.BlockWorker = (*ConfirmationTracker)(nil)
+
+// ConfirmationTracker records recent deposits so that funds are only
+// considered available once the transaction that deposited
+// them has reached the minimum number of confirmations.
+// Deposits are only tracked in memory, so deposits seen
+// before a restart are considered available.
+type ConfirmationTracker struct {
+	minimum  int64
+	asserter *asserter.Asserter
+	exempt   parser.ExemptOperation
+
+	lock       sync.Mutex
+	head       int64
+	deposits   []*PendingDeposit
+	currencies map[string]*types.Currency
+	reverted   int64
+}
+
+// NewConfirmationTracker returns a new *ConfirmationTracker. If minimum is not greater
+// than 1 (i.e. funds are available as soon as they are included
+// in a block), nil is returned. Deposits for operations exempted
+// by exempt are not tracked.
+func NewConfirmationTracker(
+	minimum int64,
+	asserter *asserter.Asserter,
+	exempt parser.ExemptOperation,
+) *ConfirmationTracker {
+	if minimum <= 1 {
+		return nil
+	}
+
+	return &ConfirmationTracker{
+		minimum:    minimum,
+		asserter:   asserter,
+		exempt:     exempt,
+		head:       -1,
+		currencies: map[string]*types.Currency{},
+	}
+}
+
+// confirmations returns the number of confirmations
+// of a deposit in block index.
+func (t *ConfirmationTracker) confirmations(index int64) int64 {
+	return t.head - index + 1
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (t *ConfirmationTracker) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.head = block.BlockIdentifier.Index
+	for _, tx := range block.Transactions {
+		for _, op := range tx.Operations {
+			if op.Account == nil || op.Amount == nil {
+				continue
+			}
+
+			successful, err := t.asserter.OperationSuccessful(op)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to determine if operation is successful", err)
+			}
+
+			if !successful || (t.exempt != nil && t.exempt(op)) {
+				continue
+			}
+
+			value, err := types.AmountValue(op.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to parse operation amount", err)
+			}
+
+			if value.Sign() <= 0 {
+				continue
+			}
+
+			deposit := &PendingDeposit{
+				Account:               op.Account,
+				Amount:                op.Amount,
+				BlockIdentifier:       block.BlockIdentifier,
+				TransactionIdentifier: tx.TransactionIdentifier,
+			}
+			if op.CoinChange != nil && op.CoinChange.CoinAction == types.CoinCreated {
+				deposit.CoinIdentifier = op.CoinChange.CoinIdentifier
+			}
+
+			t.deposits = append(t.deposits, deposit)
+			t.currencies[types.Hash(op.Amount.Currency)] = op.Amount.Currency
+		}
+	}
+
+	remaining := []*PendingDeposit{}
+	for _, deposit := range t.deposits {
+		confirmations := t.confirmations(deposit.BlockIdentifier.Index)
+		if confirmations >= t.minimum {
+			deposit.Counted = true
+		}
+
+		if confirmations > t.minimum+reorgWindow {
+			continue
+		}
+
+		remaining = append(remaining, deposit)
+	}
+	t.deposits = remaining
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// Deposits in the removed block are forgotten and deposits in earlier blocks
+// that no longer have the minimum confirmations are pending again. Because
+// blocks are removed one at a time and minimum is greater than 1, a counted
+// deposit is always returned to pending before its block is removed.
+func (t *ConfirmationTracker) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.head = block.ParentBlockIdentifier.Index
+	remaining := []*PendingDeposit{}
+	for _, deposit := range t.deposits {
+		if deposit.BlockIdentifier.Index >= block.BlockIdentifier.Index {
+			continue
+		}
+
+		if deposit.Counted && t.confirmations(deposit.BlockIdentifier.Index) < t.minimum {
+			deposit.Counted = false
+			t.reverted++
+			color.Yellow(
+				"[WARNING] deposit of %s %s to %s in transaction %s is pending again after a reorg",
+				deposit.Amount.Value,
+				deposit.Amount.Currency.Symbol,
+				types.PrintStruct(deposit.Account),
+				deposit.TransactionIdentifier.Hash,
+			)
+		}
+
+		remaining = append(remaining, deposit)
+	}
+	t.deposits = remaining
+
+	return nil, nil
+}
+
+// Pending returns the sum of all deposits to account in
+// currency that have not reached the minimum confirmations
+// and the confirmations of the shallowest one.
+func (t *ConfirmationTracker) Pending(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) (*big.Int, int64) {
+	pending := big.NewInt(0)
+	if t == nil {
+		return pending, 0
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	accountHash := types.Hash(account)
+	currencyHash := types.Hash(currency)
+	confirmations := t.minimum
+	for _, deposit := range t.deposits {
+		if deposit.Counted ||
+			types.Hash(deposit.Account) != accountHash ||
+			types.Hash(deposit.Amount.Currency) != currencyHash {
+			continue
+		}
+
+		// Amounts are validated when the deposit is added.
+		value, _ := types.AmountValue(deposit.Amount)
+		pending.Add(pending, value)
+		if c := t.confirmations(deposit.BlockIdentifier.Index); c < confirmations {
+			confirmations = c
+		}
+	}
+
+	return pending, confirmations
+}
+
+// PendingCoin returns a boolean indicating if coin was created
+// by a deposit that has not reached the minimum confirmations.
+func (t *ConfirmationTracker) PendingCoin(coin *types.CoinIdentifier) bool {
+	if t == nil {
+		return false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, deposit := range t.deposits {
+		if !deposit.Counted &&
+			deposit.CoinIdentifier != nil &&
+			deposit.CoinIdentifier.Identifier == coin.Identifier {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Minimum returns the minimum number of confirmations
+// before deposited funds are available.
+func (t *ConfirmationTracker) Minimum() int64 {
+	if t == nil {
+		return 0
+	}
+
+	return t.minimum
+}
+
+// ConfirmationStatus returns the pending and available balances of
+// accounts in all currencies deposited since the ConfirmationTracker was
+// created (and in currencies). If t is nil, nil is returned.
+func (t *ConfirmationTracker) Status(
+	ctx context.Context,
+	balanceStorage *modules.BalanceStorage,
+	accounts []*types.AccountIdentifier,
+	currencies []*types.Currency,
+) (*results.ConfirmationStatus, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	t.lock.Lock()
+	head := t.head
+	reverted := t.reverted
+	allCurrencies := map[string]*types.Currency{}
+	for key, currency := range t.currencies {
+		allCurrencies[key] = currency
+	}
+	t.lock.Unlock()
+
+	for _, currency := range currencies {
+		allCurrencies[types.Hash(currency)] = currency
+	}
+
+	status := &results.ConfirmationStatus{
+		MinimumConfirmations: t.minimum,
+		Currencies:           []*results.CurrencyConfirmationStatus{},
+		RevertedDeposits:     reverted,
+	}
+	if head < 0 {
+		return status, nil
+	}
+
+	for _, currency := range allCurrencies {
+		pending := big.NewInt(0)
+		available := big.NewInt(0)
+		for _, account := range accounts {
+			amount, err := balanceStorage.GetBalance(ctx, account, currency, head)
+			if errors.Is(err, storageErrs.ErrAccountMissing) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf(
+					"%w: unable to get balance of %s",
+					err,
+					types.PrintStruct(account),
+				)
+			}
+
+			balance, err := types.AmountValue(amount)
+			if err != nil {
+				return nil, err
+			}
+
+			accountPending, _ := t.Pending(account, currency)
+			accountAvailable := AvailableBalance(balance, accountPending)
+			pending.Add(pending, new(big.Int).Sub(balance, accountAvailable))
+			available.Add(available, accountAvailable)
+		}
+
+		status.Currencies = append(status.Currencies, &results.CurrencyConfirmationStatus{
+			Currency:  currency,
+			Pending:   pending.String(),
+			Available: available.String(),
+		})
+	}
+
+	sort.Slice(status.Currencies, func(i, j int) bool {
+		return status.Currencies[i].Currency.Symbol < status.Currencies[j].Currency.Symbol
+	})
+
+	return status, nil
+}
+
+// AvailableBalance returns the portion of balance that is not
+// pending. If funds were spent since pending deposits
+// were made, available is never negative.
+func AvailableBalance(balance *big.Int, pending *big.Int) *big.Int {
+	available := new(big.Int).Sub(balance, pending)
+	if available.Sign() < 0 {
+		return big.NewInt(0)
+	}
+
+	return available
+}
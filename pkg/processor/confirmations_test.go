@@ -0,0 +1,271 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	confirmationsBtc   = &types.Currency{Symbol: "BTC", Decimals: 8}
+	confirmationsAddr1 = &types.AccountIdentifier{Address: "addr1"}
+	confirmationsAddr2 = &types.AccountIdentifier{Address: "addr2"}
+	exempt             = &types.AccountIdentifier{Address: "exempt"}
+	coin               = &types.CoinIdentifier{Identifier: "tx1:0"}
+)
+
+func newAsserter(t *testing.T) *asserter.Asserter {
+	a, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		&types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		[]string{"transfer"},
+		[]*types.OperationStatus{
+			{Status: "SUCCESS", Successful: true},
+			{Status: "FAILURE", Successful: false},
+		},
+		[]*types.Error{},
+		nil,
+		&asserter.Validations{Enabled: false},
+	)
+	assert.NoError(t, err)
+
+	return a
+}
+
+func operation(
+	account *types.AccountIdentifier,
+	value string,
+	status string,
+) *types.Operation {
+	return &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{Index: 0},
+		Type:                "transfer",
+		Status:              types.String(status),
+		Account:             account,
+		Amount:              &types.Amount{Value: value, Currency: confirmationsBtc},
+	}
+}
+
+func confirmationsBlock(index int64, ops ...*types.Operation) *types.Block {
+	parent := index - 1
+	if parent < 0 {
+		parent = 0
+	}
+
+	transactions := []*types.Transaction{}
+	if len(ops) > 0 {
+		transactions = append(transactions, &types.Transaction{
+			TransactionIdentifier: &types.TransactionIdentifier{
+				Hash: fmt.Sprintf("tx%d", index),
+			},
+			Operations: ops,
+		})
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: parent,
+			Hash:  fmt.Sprintf("block %d", parent),
+		},
+		Transactions: transactions,
+	}
+}
+
+func TestNewConfirmationTracker(t *testing.T) {
+	assert.Nil(t, NewConfirmationTracker(0, nil, nil))
+	assert.Nil(t, NewConfirmationTracker(1, nil, nil))
+
+	// A nil tracker considers all funds available.
+	var tracker *ConfirmationTracker
+	pending, _ := tracker.Pending(confirmationsAddr1, confirmationsBtc)
+	assert.Equal(t, big.NewInt(0), pending)
+	assert.False(t, tracker.PendingCoin(coin))
+	assert.Equal(t, int64(0), tracker.Minimum())
+
+	status, err := tracker.Status(context.Background(), nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+}
+
+func TestConfirmationTracker(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewConfirmationTracker(
+		3,
+		newAsserter(t),
+		func(op *types.Operation) bool {
+			return op.Account.Address == exempt.Address
+		},
+	)
+	assert.Equal(t, int64(3), tracker.Minimum())
+
+	deposit := operation(confirmationsAddr1, "100", "SUCCESS")
+	deposit.CoinChange = &types.CoinChange{
+		CoinIdentifier: coin,
+		CoinAction:     types.CoinCreated,
+	}
+	blocks := []*types.Block{
+		confirmationsBlock(0),
+		confirmationsBlock(
+			1,
+			deposit,
+			operation(confirmationsAddr1, "50", "FAILURE"),
+			operation(confirmationsAddr2, "-10", "SUCCESS"),
+			operation(exempt, "10", "SUCCESS"),
+		),
+		confirmationsBlock(2, operation(confirmationsAddr1, "5", "SUCCESS")),
+		confirmationsBlock(3),
+	}
+
+	var tests = map[string]struct {
+		head int64
+
+		expectedPending       *big.Int
+		expectedConfirmations int64
+		expectedPendingCoin   bool
+	}{
+		"genesis": {
+			head:                  0,
+			expectedPending:       big.NewInt(0),
+			expectedConfirmations: 3,
+		},
+		"single confirmation": {
+			head:                  1,
+			expectedPending:       big.NewInt(100),
+			expectedConfirmations: 1,
+			expectedPendingCoin:   true,
+		},
+		"multiple pending deposits": {
+			head:                  2,
+			expectedPending:       big.NewInt(105),
+			expectedConfirmations: 1,
+			expectedPendingCoin:   true,
+		},
+		"minimum confirmations": {
+			head:                  3,
+			expectedPending:       big.NewInt(5),
+			expectedConfirmations: 2,
+		},
+	}
+
+	for i, b := range blocks {
+		_, err := tracker.AddingBlock(ctx, nil, b, nil)
+		assert.NoError(t, err)
+
+		for name, test := range tests {
+			if test.head != int64(i) {
+				continue
+			}
+
+			t.Run(name, func(t *testing.T) {
+				pending, confirmations := tracker.Pending(confirmationsAddr1, confirmationsBtc)
+				assert.Equal(t, test.expectedPending, pending)
+				assert.Equal(t, test.expectedConfirmations, confirmations)
+				assert.Equal(t, test.expectedPendingCoin, tracker.PendingCoin(coin))
+
+				pending, _ = tracker.Pending(exempt, confirmationsBtc)
+				assert.Equal(t, big.NewInt(0), pending)
+				pending, _ = tracker.Pending(confirmationsAddr2, confirmationsBtc)
+				assert.Equal(t, big.NewInt(0), pending)
+			})
+		}
+	}
+
+	// Removing block 3 returns the counted deposit in block 1 to pending.
+	_, err := tracker.RemovingBlock(ctx, nil, blocks[3], nil)
+	assert.NoError(t, err)
+	pending, confirmations := tracker.Pending(confirmationsAddr1, confirmationsBtc)
+	assert.Equal(t, big.NewInt(105), pending)
+	assert.Equal(t, int64(1), confirmations)
+	assert.True(t, tracker.PendingCoin(coin))
+	assert.Equal(t, int64(1), tracker.reverted)
+
+	// Re-adding blocks counts the deposit in block 1 again.
+	_, err = tracker.AddingBlock(ctx, nil, confirmationsBlock(3), nil)
+	assert.NoError(t, err)
+	_, err = tracker.AddingBlock(ctx, nil, confirmationsBlock(4), nil)
+	assert.NoError(t, err)
+	pending, _ = tracker.Pending(confirmationsAddr1, confirmationsBtc)
+	assert.Equal(t, big.NewInt(0), pending)
+
+	// Orphaning deposits forgets them.
+	for i := int64(4); i > 0; i-- {
+		_, err = tracker.RemovingBlock(ctx, nil, confirmationsBlock(i), nil)
+		assert.NoError(t, err)
+	}
+	pending, _ = tracker.Pending(confirmationsAddr1, confirmationsBtc)
+	assert.Equal(t, big.NewInt(0), pending)
+	assert.False(t, tracker.PendingCoin(coin))
+	assert.Equal(t, int64(3), tracker.reverted)
+	assert.Len(t, tracker.deposits, 0)
+}
+
+func TestConfirmationTrackerPrune(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewConfirmationTracker(2, newAsserter(t), nil)
+
+	_, err := tracker.AddingBlock(ctx, nil, confirmationsBlock(1, operation(confirmationsAddr1, "1", "SUCCESS")), nil)
+	assert.NoError(t, err)
+	for i := int64(2); i <= 2+reorgWindow; i++ {
+		_, err = tracker.AddingBlock(ctx, nil, confirmationsBlock(i), nil)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, tracker.deposits, 1)
+
+	_, err = tracker.AddingBlock(ctx, nil, confirmationsBlock(3+reorgWindow), nil)
+	assert.NoError(t, err)
+	assert.Len(t, tracker.deposits, 0)
+}
+
+func TestAvailableBalance(t *testing.T) {
+	var tests = map[string]struct {
+		balance *big.Int
+		pending *big.Int
+
+		expected *big.Int
+	}{
+		"no pending": {
+			balance:  big.NewInt(10),
+			pending:  big.NewInt(0),
+			expected: big.NewInt(10),
+		},
+		"partially pending": {
+			balance:  big.NewInt(10),
+			pending:  big.NewInt(4),
+			expected: big.NewInt(6),
+		},
+		"spent before confirmed": {
+			balance:  big.NewInt(2),
+			pending:  big.NewInt(4),
+			expected: big.NewInt(0),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, AvailableBalance(test.balance, test.pending))
+		})
+	}
+}
@@ -20,6 +20,10 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/constructor/coordinator"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
@@ -72,12 +76,36 @@ type CoordinatorHelper struct {
 	coinStorage      *modules.CoinStorage
 	broadcastStorage *modules.BroadcastStorage
 	counterStorage   *modules.CounterStorage
+	lineageStorage   *LineageStorage
+	jobStorage       coordinator.JobStorage
 
 	balanceStorageHelper *BalanceStorageHelper
 
 	// quiet determines if requests/responses logging
 	// should be silenced.
 	quiet bool
+
+	// dryRun determines if signed transactions should
+	// be logged instead of broadcast.
+	dryRun bool
+
+	// allowedSigners may sign any transaction, even
+	// if they are not in its intent.
+	allowedSigners []*types.AccountIdentifier
+
+	// expectedSigners are the accounts that must sign
+	// each unsigned or signed transaction being constructed.
+	expectedSigners     map[string]map[string]*types.AccountIdentifier
+	expectedSignersLock sync.Mutex
+
+	// confirmations tracks deposits that have not reached
+	// the minimum confirmations. If nil, all funds are
+	// available once included in a block.
+	confirmations *ConfirmationTracker
+
+	// endpoints records the outcome of
+	// calls to construction endpoints.
+	endpoints *results.EndpointTracker
 }
 
 // NewCoordinatorHelper returns a new *CoordinatorHelper.
@@ -92,7 +120,13 @@ func NewCoordinatorHelper(
 	broadcastStorage *modules.BroadcastStorage,
 	balanceStorageHelper *BalanceStorageHelper,
 	counterStorage *modules.CounterStorage,
+	lineageStorage *LineageStorage,
+	jobStorage coordinator.JobStorage,
 	quiet bool,
+	dryRun bool,
+	allowedSigners []*types.AccountIdentifier,
+	confirmations *ConfirmationTracker,
+	endpoints *results.EndpointTracker,
 ) *CoordinatorHelper {
 	return &CoordinatorHelper{
 		offlineFetcher:       offlineFetcher,
@@ -104,8 +138,15 @@ func NewCoordinatorHelper(
 		coinStorage:          coinStorage,
 		broadcastStorage:     broadcastStorage,
 		counterStorage:       counterStorage,
+		lineageStorage:       lineageStorage,
+		jobStorage:           jobStorage,
 		balanceStorageHelper: balanceStorageHelper,
 		quiet:                quiet,
+		dryRun:               dryRun,
+		allowedSigners:       allowedSigners,
+		expectedSigners:      map[string]map[string]*types.AccountIdentifier{},
+		confirmations:        confirmations,
+		endpoints:            endpoints,
 	}
 }
 
@@ -151,6 +192,7 @@ func (c *CoordinatorHelper) Derive(
 		publicKey,
 		metadata,
 	)
+	c.endpoints.Record(constructionDerive, fetchErr)
 	if fetchErr != nil {
 		c.verboseLog(reqerror, constructionDerive, arg{argError, fetchErr})
 		return nil, nil, fetchErr.Err
@@ -183,6 +225,7 @@ func (c *CoordinatorHelper) Preprocess(
 		metadata,
 	)
 
+	c.endpoints.Record(constructionPreprocess, fetchErr)
 	if fetchErr != nil {
 		c.verboseLog(reqerror, constructionPreprocess, arg{argError, fetchErr})
 		return nil, nil, fetchErr.Err
@@ -215,6 +258,7 @@ func (c *CoordinatorHelper) Metadata(
 		publicKeys,
 	)
 
+	c.endpoints.Record(constructionMetadata, fetchErr)
 	if fetchErr != nil {
 		c.verboseLog(reqerror, constructionMetadata, arg{argError, fetchErr})
 		return nil, nil, fetchErr.Err
@@ -249,6 +293,7 @@ func (c *CoordinatorHelper) Payloads(
 		publicKeys,
 	)
 
+	c.endpoints.Record(constructionPayloads, fetchErr)
 	if fetchErr != nil {
 		c.verboseLog(reqerror, constructionPayloads, arg{argError, fetchErr})
 		return "", nil, fetchErr.Err
@@ -258,6 +303,24 @@ func (c *CoordinatorHelper) Payloads(
 		arg{argUnsignedTransaction, res},
 		arg{"payloads", payloads},
 	)
+
+	unexpected := UnexpectedSigningPayloads(
+		AllowedSigners(intent, c.allowedSigners),
+		payloads,
+	)
+	if len(unexpected) > 0 {
+		c.incrementCounter(ctx, results.UnexpectedPayloadsCounter, len(unexpected))
+		return "", nil, fmt.Errorf(
+			"%w: %s not in intent or allowed_signers",
+			ErrUnexpectedSigningPayload,
+			strings.Join(unexpected, ", "),
+		)
+	}
+
+	c.expectedSignersLock.Lock()
+	c.expectedSigners[res] = ExpectedSigners(payloads)
+	c.expectedSignersLock.Unlock()
+
 	return res, payloads, nil
 }
 
@@ -274,13 +337,14 @@ func (c *CoordinatorHelper) Parse(
 		arg{"signed", signed},
 		arg{"transaction", transaction},
 	)
-	ops, signers, metadata, fetchErr := c.offlineFetcher.ConstructionParse(
+	ops, parsedSigners, metadata, fetchErr := c.offlineFetcher.ConstructionParse(
 		ctx,
 		networkIdentifier,
 		signed,
 		transaction,
 	)
 
+	c.endpoints.Record(constructionParse, fetchErr)
 	if fetchErr != nil {
 		c.verboseLog(reqerror, constructionParse, arg{argError, fetchErr})
 		return nil, nil, nil, fetchErr.Err
@@ -288,10 +352,55 @@ func (c *CoordinatorHelper) Parse(
 
 	c.verboseLog(response, constructionParse,
 		arg{"operations", ops},
-		arg{"signers", signers},
+		arg{"signers", parsedSigners},
 		arg{argMetadata, metadata},
 	)
-	return ops, signers, metadata, nil
+
+	if signed {
+		if err := c.verifySigners(ctx, transaction, parsedSigners); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return ops, parsedSigners, metadata, nil
+}
+
+// verifySigners ensures the signers parsed from a signed
+// transaction are exactly the accounts that were provided
+// signing payloads for it.
+func (c *CoordinatorHelper) verifySigners(
+	ctx context.Context,
+	transaction string,
+	parsedSigners []*types.AccountIdentifier,
+) error {
+	c.expectedSignersLock.Lock()
+	expected, ok := c.expectedSigners[transaction]
+	delete(c.expectedSigners, transaction)
+	c.expectedSignersLock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	missing, unexpected := CompareSigners(expected, parsedSigners)
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return nil
+	}
+
+	c.incrementCounter(ctx, results.MissingSignersCounter, len(missing))
+	c.incrementCounter(ctx, results.UnexpectedSignersCounter, len(unexpected))
+	return SignerMismatchError(missing, unexpected)
+}
+
+// incrementCounter adds amount to counter, logging
+// (instead of returning) any error.
+func (c *CoordinatorHelper) incrementCounter(ctx context.Context, counter string, amount int) {
+	if amount == 0 {
+		return
+	}
+
+	if _, err := c.counterStorage.Update(ctx, counter, big.NewInt(int64(amount))); err != nil {
+		log.Printf("%s: unable to update %s counter\n", err.Error(), counter)
+	}
 }
 
 // Combine calls the /construction/combine endpoint
@@ -314,12 +423,23 @@ func (c *CoordinatorHelper) Combine(
 		signatures,
 	)
 
+	c.endpoints.Record(constructionCombine, fetchErr)
 	if fetchErr != nil {
 		c.verboseLog(reqerror, constructionCombine, arg{argError, fetchErr})
 		return "", fetchErr.Err
 	}
 
 	c.verboseLog(response, constructionCombine, arg{argNetworkTransaction, res})
+
+	// The signed transaction must be signed by the same
+	// accounts as the unsigned transaction.
+	c.expectedSignersLock.Lock()
+	if expected, ok := c.expectedSigners[unsignedTransaction]; ok {
+		delete(c.expectedSigners, unsignedTransaction)
+		c.expectedSigners[res] = expected
+	}
+	c.expectedSignersLock.Unlock()
+
 	return res, nil
 }
 
@@ -340,6 +460,7 @@ func (c *CoordinatorHelper) Hash(
 		networkTransaction,
 	)
 
+	c.endpoints.Record(constructionHash, fetchErr)
 	if fetchErr != nil {
 		c.verboseLog(reqerror, constructionHash, arg{argError, fetchErr})
 		return nil, fetchErr.Err
@@ -378,6 +499,7 @@ func (c *CoordinatorHelper) StoreKey(
 ) error {
 	// We optimisically add the interesting address although the dbTx could be reverted.
 	c.balanceStorageHelper.AddInterestingAddress(account.Address)
+	c.lineageStorage.AddPending(dbTx, account)
 
 	_, _ = c.counterStorage.UpdateTransactional(
 		ctx,
@@ -406,13 +528,45 @@ func (c *CoordinatorHelper) Balance(
 		return nil, errors.New("no blocks synced")
 	}
 
-	return c.balanceStorage.GetOrSetBalanceTransactional(
+	amount, err := c.balanceStorage.GetOrSetBalanceTransactional(
 		ctx,
 		dbTx,
 		accountIdentifier,
 		currency,
 		headBlock,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Funds deposited by transactions that have not reached
+	// the minimum confirmations are not available.
+	pending, depth := c.confirmations.Pending(accountIdentifier, currency)
+	if pending.Sign() == 0 {
+		return amount, nil
+	}
+
+	balance, err := types.AmountValue(amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to parse balance", err)
+	}
+
+	available := AvailableBalance(balance, pending)
+	if !c.quiet {
+		log.Printf(
+			"%s %s of %s is pending (%d of %d confirmations)\n",
+			pending.String(),
+			currency.Symbol,
+			types.PrintStruct(accountIdentifier),
+			depth,
+			c.confirmations.Minimum(),
+		)
+	}
+
+	return &types.Amount{
+		Value:    available.String(),
+		Currency: currency,
+	}, nil
 }
 
 // Coins returns all *types.Coin owned by
@@ -438,6 +592,19 @@ func (c *CoordinatorHelper) Coins(
 			continue
 		}
 
+		if c.confirmations.PendingCoin(coin.CoinIdentifier) {
+			if !c.quiet {
+				log.Printf(
+					"coin %s of %s is pending (waiting on %d confirmations)\n",
+					coin.CoinIdentifier.Identifier,
+					types.PrintStruct(accountIdentifier),
+					c.confirmations.Minimum(),
+				)
+			}
+
+			continue
+		}
+
 		coinsToReturn = append(coinsToReturn, coin)
 	}
 
@@ -474,12 +641,32 @@ func (c *CoordinatorHelper) Broadcast(
 	payload string,
 	confirmationDepth int64,
 ) error {
+	if c.dryRun {
+		return c.skipBroadcast(
+			ctx,
+			dbTx,
+			identifier,
+			intent,
+			transactionIdentifier,
+			payload,
+		)
+	}
+
 	c.verboseLog(queue, constructionSubmit,
 		arg{argNetwork, network},
 		arg{argIntent, intent},
 		arg{argTransactionIdentifier, transactionIdentifier},
 		arg{argNetworkTransaction, payload},
 	)
+	if err := c.lineageStorage.RecordBroadcast(
+		ctx,
+		dbTx,
+		identifier,
+		transactionIdentifier,
+	); err != nil {
+		return fmt.Errorf("%w: unable to record broadcast lineage", err)
+	}
+
 	return c.broadcastStorage.Broadcast(
 		ctx,
 		dbTx,
@@ -492,6 +679,44 @@ func (c *CoordinatorHelper) Broadcast(
 	)
 }
 
+// skipBroadcast logs a signed transaction instead of enqueueing
+// it for broadcast and marks the broadcast of the job complete.
+// The intent is used as the operations of the completed transaction
+// because the transaction is never observed on-chain.
+func (c *CoordinatorHelper) skipBroadcast(
+	ctx context.Context,
+	dbTx database.Transaction,
+	identifier string,
+	intent []*types.Operation,
+	transactionIdentifier *types.TransactionIdentifier,
+	payload string,
+) error {
+	log.Printf(
+		"dry run: skipping %s for transaction %s with signed payload %s\n",
+		constructionSubmit,
+		transactionIdentifier.Hash,
+		payload,
+	)
+
+	j, err := c.jobStorage.Get(ctx, dbTx, identifier)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get job %s", err, identifier)
+	}
+
+	if err := j.BroadcastComplete(ctx, &types.Transaction{
+		TransactionIdentifier: transactionIdentifier,
+		Operations:            intent,
+	}); err != nil {
+		return fmt.Errorf("%w: unable to mark dry run broadcast complete", err)
+	}
+
+	if _, err := c.jobStorage.Update(ctx, dbTx, j); err != nil {
+		return fmt.Errorf("%w: unable to update job %s", err, identifier)
+	}
+
+	return nil
+}
+
 // BroadcastAll attempts to broadcast all ready transactions.
 func (c *CoordinatorHelper) BroadcastAll(
 	ctx context.Context,
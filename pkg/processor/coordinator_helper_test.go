@@ -0,0 +1,351 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/constructor/job"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoordinatorHelperBroadcast(t *testing.T) {
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+	transactionIdentifier := &types.TransactionIdentifier{Hash: "tx1"}
+	intent := []*types.Operation{
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 0},
+			Type:                "transfer",
+			Account:             &types.AccountIdentifier{Address: "addr1"},
+			Amount: &types.Amount{
+				Value:    "-100",
+				Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+			},
+		},
+	}
+
+	var tests = map[string]struct {
+		dryRun bool
+
+		expectedSubmits    int64
+		expectedBroadcasts int
+		expectedCompleted  int
+	}{
+		"broadcast": {
+			expectedSubmits:    1,
+			expectedBroadcasts: 1,
+		},
+		"dry run": {
+			dryRun:            true,
+			expectedCompleted: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			var submits int64
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "/construction/submit", r.URL.Path)
+					atomic.AddInt64(&submits, 1)
+
+					w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+					assert.NoError(t, json.NewEncoder(w).Encode(&types.TransactionIdentifierResponse{
+						TransactionIdentifier: transactionIdentifier,
+					}))
+				}),
+			)
+			defer ts.Close()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			blockStorage := modules.NewBlockStorage(db, 1)
+			blockStorage.Initialize([]modules.BlockWorker{})
+			block := &types.Block{
+				BlockIdentifier:       &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+				ParentBlockIdentifier: &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+				Timestamp:             utils.Milliseconds(),
+			}
+			assert.NoError(t, blockStorage.SeeBlock(ctx, block))
+			assert.NoError(t, blockStorage.AddBlock(ctx, block))
+
+			onlineFetcher := fetcher.New(ts.URL, fetcher.WithMaxRetries(0))
+			broadcastStorage := modules.NewBroadcastStorage(db, 10, 3, 60, false, 10)
+			broadcastStorage.Initialize(
+				NewBroadcastStorageHelper(
+					network,
+					blockStorage,
+					onlineFetcher,
+					NewSkewMonitor(ts.URL, network, http.DefaultClient, false, time.Minute),
+					0,
+					nil,
+				),
+				nil,
+			)
+
+			jobStorage := modules.NewJobStorage(db)
+			lineageStorage := NewLineageStorage(db, jobStorage, nil)
+			helper := NewCoordinatorHelper(
+				nil,
+				onlineFetcher,
+				db,
+				blockStorage,
+				nil,
+				nil,
+				nil,
+				broadcastStorage,
+				nil,
+				modules.NewCounterStorage(db),
+				lineageStorage,
+				jobStorage,
+				true,
+				test.dryRun,
+				nil,
+				nil,
+				nil,
+			)
+
+			dbTx := db.Transaction(ctx)
+			jobIdentifier, err := jobStorage.Update(ctx, dbTx, &job.Job{
+				Workflow:  "transfer",
+				Scenarios: []*job.Scenario{{Name: "transfer"}},
+				Index:     1,
+				Status:    job.Broadcasting,
+				State:     "{}",
+			})
+			assert.NoError(t, err)
+			assert.NoError(t, helper.Broadcast(
+				ctx,
+				dbTx,
+				jobIdentifier,
+				network,
+				intent,
+				transactionIdentifier,
+				"signed payload",
+				1,
+			))
+			assert.NoError(t, dbTx.Commit(ctx))
+			assert.NoError(t, helper.BroadcastAll(ctx))
+
+			assert.Equal(t, test.expectedSubmits, atomic.LoadInt64(&submits))
+
+			broadcasts, err := helper.AllBroadcasts(ctx)
+			assert.NoError(t, err)
+			assert.Len(t, broadcasts, test.expectedBroadcasts)
+
+			completed, err := jobStorage.Completed(ctx, "transfer")
+			assert.NoError(t, err)
+			assert.Len(t, completed, test.expectedCompleted)
+		})
+	}
+}
+
+func TestCoordinatorHelperSigners(t *testing.T) {
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+	addr1 := &types.AccountIdentifier{Address: "addr1"}
+	addr2 := &types.AccountIdentifier{Address: "addr2"}
+	intent := []*types.Operation{
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 0},
+			Type:                "transfer",
+			Account:             addr1,
+			Amount: &types.Amount{
+				Value:    "-100",
+				Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+			},
+		},
+	}
+
+	var tests = map[string]struct {
+		allowedSigners []*types.AccountIdentifier
+		payloadSigners []*types.AccountIdentifier
+		parsedSigners  []*types.AccountIdentifier
+
+		expectedPayloadsErr error
+		expectedParseErr    error
+		expectedCounters    map[string]int64
+	}{
+		"expected signers": {
+			payloadSigners: []*types.AccountIdentifier{addr1},
+			parsedSigners:  []*types.AccountIdentifier{addr1},
+		},
+		"unexpected signing payload": {
+			payloadSigners:      []*types.AccountIdentifier{addr1, addr2},
+			expectedPayloadsErr: ErrUnexpectedSigningPayload,
+			expectedCounters: map[string]int64{
+				results.UnexpectedPayloadsCounter: 1,
+			},
+		},
+		"allowed signer": {
+			allowedSigners: []*types.AccountIdentifier{addr2},
+			payloadSigners: []*types.AccountIdentifier{addr1, addr2},
+			parsedSigners:  []*types.AccountIdentifier{addr1, addr2},
+		},
+		"missing and unexpected signers": {
+			allowedSigners:   []*types.AccountIdentifier{addr2},
+			payloadSigners:   []*types.AccountIdentifier{addr1},
+			parsedSigners:    []*types.AccountIdentifier{addr2},
+			expectedParseErr: ErrSignerMismatch,
+			expectedCounters: map[string]int64{
+				results.MissingSignersCounter:    1,
+				results.UnexpectedSignersCounter: 1,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var response interface{}
+					switch r.URL.Path {
+					case "/construction/payloads":
+						payloads := []*types.SigningPayload{}
+						for _, signer := range test.payloadSigners {
+							payloads = append(payloads, &types.SigningPayload{
+								AccountIdentifier: signer,
+								Bytes:             []byte("payload"),
+								SignatureType:     types.Ecdsa,
+							})
+						}
+						response = &types.ConstructionPayloadsResponse{
+							UnsignedTransaction: "unsigned",
+							Payloads:            payloads,
+						}
+					case "/construction/combine":
+						response = &types.ConstructionCombineResponse{
+							SignedTransaction: "signed",
+						}
+					case "/construction/parse":
+						response = &types.ConstructionParseResponse{
+							Operations:               intent,
+							AccountIdentifierSigners: test.parsedSigners,
+						}
+					default:
+						t.Fatalf("unexpected request to %s", r.URL.Path)
+					}
+
+					w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+					assert.NoError(t, json.NewEncoder(w).Encode(response))
+				}),
+			)
+			defer ts.Close()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			offlineAsserter, err := asserter.NewClientWithOptions(
+				network,
+				&types.BlockIdentifier{Index: 0, Hash: "block 0"},
+				[]string{"transfer"},
+				[]*types.OperationStatus{{Status: "SUCCESS", Successful: true}},
+				[]*types.Error{},
+				nil,
+				&asserter.Validations{Enabled: false},
+			)
+			assert.NoError(t, err)
+
+			counterStorage := modules.NewCounterStorage(db)
+			helper := NewCoordinatorHelper(
+				fetcher.New(
+					ts.URL,
+					fetcher.WithMaxRetries(0),
+					fetcher.WithAsserter(offlineAsserter),
+				),
+				nil,
+				db,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				counterStorage,
+				nil,
+				nil,
+				true,
+				false,
+				test.allowedSigners,
+				nil,
+				nil,
+			)
+
+			unsigned, payloads, err := helper.Payloads(ctx, network, intent, nil, nil)
+			if test.expectedPayloadsErr != nil {
+				assert.True(t, errors.Is(err, test.expectedPayloadsErr))
+				assert.Contains(t, err.Error(), "addr2")
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, payloads, len(test.payloadSigners))
+
+				signed, err := helper.Combine(ctx, network, unsigned, []*types.Signature{})
+				assert.NoError(t, err)
+
+				_, _, _, err = helper.Parse(ctx, network, true, signed)
+				if test.expectedParseErr != nil {
+					assert.True(t, errors.Is(err, test.expectedParseErr))
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+
+			for _, counter := range []string{
+				results.UnexpectedPayloadsCounter,
+				results.MissingSignersCounter,
+				results.UnexpectedSignersCounter,
+			} {
+				value, err := counterStorage.Get(ctx, counter)
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedCounters[counter], value.Int64(), counter)
+			}
+		})
+	}
+}
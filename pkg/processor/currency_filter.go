@@ -0,0 +1,147 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// CurrencyFilter restricts reconciliation to some currencies.
+// A nil CurrencyFilter allows all currencies.
+type CurrencyFilter map[string]struct{}
+
+// NewCurrencyFilter returns a CurrencyFilter that only allows
+// currencies (or nil if currencies is empty).
+func NewCurrencyFilter(currencies []*types.Currency) CurrencyFilter {
+	if len(currencies) == 0 {
+		return nil
+	}
+
+	filter := CurrencyFilter{}
+	for _, currency := range currencies {
+		filter[types.Hash(currency)] = struct{}{}
+	}
+
+	return filter
+}
+
+// Allowed returns a boolean indicating if
+// currency should be reconciled.
+func (f CurrencyFilter) Allowed(currency *types.Currency) bool {
+	if f == nil {
+		return true
+	}
+
+	_, ok := f[types.Hash(currency)]
+	return ok
+}
+
+// Changes returns the changes with an allowed currency.
+func (f CurrencyFilter) Changes(changes []*parser.BalanceChange) []*parser.BalanceChange {
+	if f == nil {
+		return changes
+	}
+
+	filtered := []*parser.BalanceChange{}
+	for _, change := range changes {
+		if f.Allowed(change.Currency) {
+			filtered = append(filtered, change)
+		}
+	}
+
+	return filtered
+}
+
+// Accounts returns the accounts with an allowed currency.
+func (f CurrencyFilter) Accounts(accounts []*types.AccountCurrency) []*types.AccountCurrency {
+	if f == nil {
+		return accounts
+	}
+
+	filtered := []*types.AccountCurrency{}
+	for _, account := range accounts {
+		if f.Allowed(account.Currency) {
+			filtered = append(filtered, account)
+		}
+	}
+
+	return filtered
+}
+
+// ExemptCurrencies are currencies that are never reconciled
+// (on any account). A nil ExemptCurrencies exempts nothing.
+type ExemptCurrencies map[string]struct{}
+
+// NewExemptCurrencies returns the ExemptCurrencies of currencies
+// (or nil if currencies is empty).
+func NewExemptCurrencies(currencies []*types.Currency) ExemptCurrencies {
+	if len(currencies) == 0 {
+		return nil
+	}
+
+	exempt := ExemptCurrencies{}
+	for _, currency := range currencies {
+		exempt[types.Hash(currency)] = struct{}{}
+	}
+
+	return exempt
+}
+
+// Exempt returns a boolean indicating if
+// currency should not be reconciled.
+func (e ExemptCurrencies) Exempt(currency *types.Currency) bool {
+	if e == nil {
+		return false
+	}
+
+	_, ok := e[types.Hash(currency)]
+	return ok
+}
+
+// Changes returns the changes of currencies that are not
+// exempt and the number of changes that were skipped.
+func (e ExemptCurrencies) Changes(
+	changes []*parser.BalanceChange,
+) ([]*parser.BalanceChange, int64) {
+	if e == nil {
+		return changes, 0
+	}
+
+	filtered := []*parser.BalanceChange{}
+	for _, change := range changes {
+		if !e.Exempt(change.Currency) {
+			filtered = append(filtered, change)
+		}
+	}
+
+	return filtered, int64(len(changes) - len(filtered))
+}
+
+// Accounts returns the accounts of currencies that are not exempt.
+func (e ExemptCurrencies) Accounts(accounts []*types.AccountCurrency) []*types.AccountCurrency {
+	if e == nil {
+		return accounts
+	}
+
+	filtered := []*types.AccountCurrency{}
+	for _, account := range accounts {
+		if !e.Exempt(account.Currency) {
+			filtered = append(filtered, account)
+		}
+	}
+
+	return filtered
+}
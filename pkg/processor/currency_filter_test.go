@@ -0,0 +1,136 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrencyFilter(t *testing.T) {
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	eth := &types.Currency{Symbol: "ETH", Decimals: 18}
+	token := &types.Currency{
+		Symbol:   "TKN",
+		Decimals: 6,
+		Metadata: map[string]interface{}{"contract": "0x1"},
+	}
+	account := &types.AccountIdentifier{Address: "addr1"}
+
+	changes := []*parser.BalanceChange{
+		{Account: account, Currency: btc, Difference: "1"},
+		{Account: account, Currency: eth, Difference: "2"},
+		{Account: account, Currency: token, Difference: "3"},
+	}
+	accounts := []*types.AccountCurrency{
+		{Account: account, Currency: btc},
+		{Account: account, Currency: eth},
+		{Account: account, Currency: token},
+	}
+
+	var tests = map[string]struct {
+		currencies []*types.Currency
+
+		expectedChanges  []*parser.BalanceChange
+		expectedAccounts []*types.AccountCurrency
+	}{
+		"no currencies": {
+			expectedChanges:  changes,
+			expectedAccounts: accounts,
+		},
+		"single currency": {
+			currencies:       []*types.Currency{eth},
+			expectedChanges:  []*parser.BalanceChange{changes[1]},
+			expectedAccounts: []*types.AccountCurrency{accounts[1]},
+		},
+		"currency with metadata": {
+			currencies:       []*types.Currency{btc, token},
+			expectedChanges:  []*parser.BalanceChange{changes[0], changes[2]},
+			expectedAccounts: []*types.AccountCurrency{accounts[0], accounts[2]},
+		},
+		"currency without metadata": {
+			currencies: []*types.Currency{
+				{Symbol: "TKN", Decimals: 6},
+			},
+			expectedChanges:  []*parser.BalanceChange{},
+			expectedAccounts: []*types.AccountCurrency{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter := NewCurrencyFilter(test.currencies)
+			assert.Equal(t, test.expectedChanges, filter.Changes(changes))
+			assert.Equal(t, test.expectedAccounts, filter.Accounts(accounts))
+		})
+	}
+}
+
+func TestExemptCurrencies(t *testing.T) {
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	rebasing := &types.Currency{
+		Symbol:   "RBS",
+		Decimals: 18,
+		Metadata: map[string]interface{}{"contract": "0x2"},
+	}
+	account := &types.AccountIdentifier{Address: "addr1"}
+
+	changes := []*parser.BalanceChange{
+		{Account: account, Currency: btc, Difference: "1"},
+		{Account: account, Currency: rebasing, Difference: "2"},
+		{Account: &types.AccountIdentifier{Address: "addr2"}, Currency: rebasing, Difference: "3"},
+	}
+	accounts := []*types.AccountCurrency{
+		{Account: account, Currency: btc},
+		{Account: account, Currency: rebasing},
+	}
+
+	var tests = map[string]struct {
+		currencies []*types.Currency
+
+		expectedChanges  []*parser.BalanceChange
+		expectedSkipped  int64
+		expectedAccounts []*types.AccountCurrency
+	}{
+		"no currencies": {
+			expectedChanges:  changes,
+			expectedAccounts: accounts,
+		},
+		"exempt currency": {
+			currencies:       []*types.Currency{rebasing},
+			expectedChanges:  []*parser.BalanceChange{changes[0]},
+			expectedSkipped:  2,
+			expectedAccounts: []*types.AccountCurrency{accounts[0]},
+		},
+		"currency without metadata": {
+			currencies:       []*types.Currency{{Symbol: "RBS", Decimals: 18}},
+			expectedChanges:  changes,
+			expectedAccounts: accounts,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			exempt := NewExemptCurrencies(test.currencies)
+			filtered, skipped := exempt.Changes(changes)
+			assert.Equal(t, test.expectedChanges, filtered)
+			assert.Equal(t, test.expectedSkipped, skipped)
+			assert.Equal(t, test.expectedAccounts, exempt.Accounts(accounts))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+)
+
+const (
+	// MinDiscrepancyRateAttempts is the number of reconciliations that
+	// must be attempted before the failure rate is evaluated.
+	MinDiscrepancyRateAttempts = 100
+
+	// MaxDiscrepancyFailures is the number of failures retained in
+	// *DiscrepancyResults (Count counts all of them).
+	MaxDiscrepancyFailures = 10000
+)
+
+// DiscrepancyTracker records reconciliation failures and determines when
+// check:data should halt. Without a threshold or rate, it halts
+// on the first failure. All methods are safe to call on a nil
+// *DiscrepancyTracker.
+type DiscrepancyTracker struct {
+	threshold int64
+	rate      float64
+
+	lock      sync.Mutex
+	attempted int64
+	count     int64
+	exceeded  string
+	failures  []*results.DiscrepancyFailure
+}
+
+// NewDiscrepancyTracker returns a new *DiscrepancyTracker that halts once more than
+// threshold failures occur or more than rate of attempted
+// reconciliations fail (if populated).
+func NewDiscrepancyTracker(threshold int64, rate float64) *DiscrepancyTracker {
+	return &DiscrepancyTracker{
+		threshold: threshold,
+		rate:      rate,
+		failures:  []*results.DiscrepancyFailure{},
+	}
+}
+
+// Attempted records a reconciliation that did not fail.
+func (t *DiscrepancyTracker) Attempted() {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.attempted++
+}
+
+// Failed records failure and returns a boolean
+// indicating if check:data should halt.
+func (t *DiscrepancyTracker) Failed(failure *results.DiscrepancyFailure) bool {
+	if t == nil {
+		return true
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.attempted++
+	t.count++
+	if len(t.failures) < MaxDiscrepancyFailures {
+		t.failures = append(t.failures, failure)
+	}
+
+	if t.threshold == 0 && t.rate == 0 {
+		return true
+	}
+
+	if len(t.exceeded) == 0 {
+		t.exceeded = t.exceededThreshold()
+	}
+
+	return len(t.exceeded) > 0
+}
+
+// exceededThreshold returns a description of the
+// threshold exceeded (or "" if none were exceeded).
+func (t *DiscrepancyTracker) exceededThreshold() string {
+	if t.threshold > 0 && t.count > t.threshold {
+		return fmt.Sprintf(
+			"%d reconciliation failures exceeded reconciliation_failure_threshold %d",
+			t.count,
+			t.threshold,
+		)
+	}
+
+	rate := float64(t.count) / float64(t.attempted)
+	if t.rate > 0 && t.attempted >= MinDiscrepancyRateAttempts && rate > t.rate {
+		return fmt.Sprintf(
+			"reconciliation failure rate %s (%d of %d) exceeded reconciliation_failure_rate %s",
+			strconv.FormatFloat(rate, 'g', 4, 64),
+			t.count,
+			t.attempted,
+			strconv.FormatFloat(t.rate, 'f', -1, 64),
+		)
+	}
+
+	return ""
+}
+
+// DiscrepancyResults returns the *DiscrepancyResults of t (or nil if
+// t is nil or no reconciliation failed).
+func (t *DiscrepancyTracker) Results() *results.DiscrepancyResults {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.count == 0 {
+		return nil
+	}
+
+	failures := make([]*results.DiscrepancyFailure, len(t.failures))
+	copy(failures, t.failures)
+	return &results.DiscrepancyResults{
+		Count:     t.count,
+		Attempted: t.attempted,
+		Exceeded:  t.exceeded,
+		Failures:  failures,
+	}
+}
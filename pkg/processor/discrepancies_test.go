@@ -0,0 +1,119 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var discrepanciesFailure = &results.DiscrepancyFailure{
+	ReconciliationType: "ACTIVE",
+	Account:            &types.AccountIdentifier{Address: "addr1"},
+	Currency:           &types.Currency{Symbol: "BTC", Decimals: 8},
+	ComputedBalance:    "100",
+	LiveBalance:        "90",
+	Block:              &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+}
+
+func TestDiscrepancyTracker(t *testing.T) {
+	var tests = map[string]struct {
+		threshold int64
+		rate      float64
+		attempted int
+		failed    int
+
+		expectedHalt     bool
+		expectedExceeded string
+	}{
+		"no threshold": {
+			failed:       1,
+			expectedHalt: true,
+		},
+		"within threshold": {
+			threshold: 3,
+			failed:    3,
+		},
+		"exceeded threshold": {
+			threshold:        3,
+			failed:           4,
+			expectedHalt:     true,
+			expectedExceeded: "4 reconciliation failures exceeded reconciliation_failure_threshold 3",
+		},
+		"rate not evaluated (too few attempts)": {
+			rate:   0.1,
+			failed: 50,
+		},
+		"within rate": {
+			rate:      0.1,
+			attempted: 180,
+			failed:    20,
+		},
+		"exceeded rate": {
+			rate:             0.1,
+			attempted:        89,
+			failed:           11,
+			expectedHalt:     true,
+			expectedExceeded: "reconciliation failure rate 0.11 (11 of 100) exceeded reconciliation_failure_rate 0.1", // nolint:lll
+		},
+		"threshold exceeded before rate": {
+			threshold:        5,
+			rate:             0.5,
+			failed:           6,
+			expectedHalt:     true,
+			expectedExceeded: "6 reconciliation failures exceeded reconciliation_failure_threshold 5",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := NewDiscrepancyTracker(test.threshold, test.rate)
+			assert.Nil(t, tracker.Results())
+
+			for i := 0; i < test.attempted; i++ {
+				tracker.Attempted()
+			}
+
+			var halt bool
+			for i := 0; i < test.failed; i++ {
+				halt = tracker.Failed(discrepanciesFailure)
+			}
+			assert.Equal(t, test.expectedHalt, halt)
+
+			results := tracker.Results()
+			assert.Equal(t, int64(test.failed), results.Count)
+			assert.Equal(t, int64(test.attempted+test.failed), results.Attempted)
+			assert.Equal(t, test.expectedExceeded, results.Exceeded)
+			assert.Len(t, results.Failures, test.failed)
+
+			var b bytes.Buffer
+			results.Fprint(&b)
+			assert.Contains(t, b.String(), "attempted reconciliations failed")
+			assert.Contains(t, b.String(), test.expectedExceeded)
+		})
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *DiscrepancyTracker
+	tracker.Attempted()
+	assert.True(t, tracker.Failed(discrepanciesFailure))
+	assert.Nil(t, tracker.Results())
+}
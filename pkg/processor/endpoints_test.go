@@ -0,0 +1,115 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointTracker(t *testing.T) {
+	var nilTracker *results.EndpointTracker
+	nilTracker.Record("/construction/submit", nil)
+	assert.Nil(t, nilTracker.Results())
+
+	insufficientFunds := &fetcher.Error{
+		Err: fmt.Errorf("%w: /construction/submit", fetcher.ErrRequestFailed),
+		ClientErr: &types.Error{
+			Code:        12,
+			Message:     "insufficient funds",
+			Description: types.String("balance too low"),
+		},
+	}
+	badNonce := &fetcher.Error{
+		Err:       fmt.Errorf("%w: /construction/submit", fetcher.ErrRequestFailed),
+		ClientErr: &types.Error{Code: 14, Message: "bad nonce"},
+	}
+	timeout := &fetcher.Error{
+		Err: fmt.Errorf(
+			"%w: /construction/submit context deadline exceeded",
+			fetcher.ErrRequestFailed,
+		),
+	}
+	invalidResponse := &fetcher.Error{
+		Err: errors.New("transaction identifier is nil: /construction/hash"),
+	}
+
+	insufficientFundsCode := int32(12)
+	badNonceCode := int32(14)
+	tracker := results.NewEndpointTracker()
+	tracker.Record("/construction/hash", nil)
+	tracker.Record("/construction/hash", invalidResponse)
+	tracker.Record("/construction/submit", nil)
+	tracker.Record("/construction/submit", badNonce)
+	tracker.Record("/construction/submit", insufficientFunds)
+	tracker.Record("/construction/submit", insufficientFunds)
+	tracker.Record("/construction/submit", timeout)
+	tracker.Record("/construction/preprocess", nil)
+
+	assert.Equal(t, &results.ConstructionEndpointsResults{
+		Endpoints: []*results.EndpointResults{
+			{
+				Endpoint: "/construction/preprocess",
+				Calls:    1,
+				Errors:   []*results.EndpointFailure{},
+			},
+			{
+				Endpoint: "/construction/hash",
+				Calls:    2,
+				Failures: 1,
+				Errors: []*results.EndpointFailure{
+					{
+						Type:    results.InvalidResponse,
+						Count:   1,
+						Example: "transaction identifier is nil: /construction/hash",
+					},
+				},
+			},
+			{
+				Endpoint: "/construction/submit",
+				Calls:    5,
+				Failures: 4,
+				Errors: []*results.EndpointFailure{
+					{
+						Type:    results.ImplementationError,
+						Code:    &insufficientFundsCode,
+						Count:   2,
+						Example: "insufficient funds: balance too low",
+					},
+					{
+						Type:    results.ImplementationError,
+						Code:    &badNonceCode,
+						Count:   1,
+						Example: "bad nonce",
+					},
+					{
+						Type:    results.TransportFailure,
+						Count:   1,
+						Example: "request failed: /construction/submit context deadline exceeded",
+					},
+				},
+			},
+		},
+	}, tracker.Results())
+
+	tracker.Results().Print() // make sure doesn't panic
+}
@@ -0,0 +1,178 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+// FailureHookPayload is written to the stdin of the reconciliation
+// failure hook on each reconciliation failure.
+type FailureHookPayload struct {
+	RunID           string                   `json:"run_id"`
+	FailureType     string                   `json:"failure_type"`
+	Account         *types.AccountIdentifier `json:"account_identifier"`
+	Currency        *types.Currency          `json:"currency"`
+	ComputedBalance string                   `json:"computed_balance"`
+	LiveBalance     string                   `json:"live_balance"`
+	Block           *types.BlockIdentifier   `json:"block_identifier"`
+}
+
+// FailureHook invokes an executable on each reconciliation
+// failure. Invocations are performed in the background
+// so that a slow hook never slows down check:data.
+type FailureHook struct {
+	path    string
+	timeout time.Duration
+	runID   string
+
+	// semaphore caps the number of concurrent
+	// invocations.
+	semaphore chan struct{}
+	wg        sync.WaitGroup
+
+	statsLock sync.Mutex
+	stats     *results.FailureHookStats
+}
+
+// NewFailureHook returns a new *FailureHook. If config is nil,
+// nil is returned.
+func NewFailureHook(
+	config *configuration.ReconciliationFailureHookConfiguration,
+	runID string,
+) *FailureHook {
+	if config == nil {
+		return nil
+	}
+
+	return &FailureHook{
+		path:      config.Path,
+		timeout:   time.Duration(config.Timeout) * time.Second,
+		runID:     runID,
+		semaphore: make(chan struct{}, config.MaxConcurrency),
+		stats:     &results.FailureHookStats{},
+	}
+}
+
+// ReconciliationFailed invokes the hook in the background
+// with a description of the failure.
+func (h *FailureHook) ReconciliationFailed(
+	reconciliationType string,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	computedBalance string,
+	liveBalance string,
+	block *types.BlockIdentifier,
+) {
+	payload := &FailureHookPayload{
+		RunID:           h.runID,
+		FailureType:     reconciliationType,
+		Account:         account,
+		Currency:        currency,
+		ComputedBalance: computedBalance,
+		LiveBalance:     liveBalance,
+		Block:           block,
+	}
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+
+		h.semaphore <- struct{}{}
+		defer func() { <-h.semaphore }()
+
+		h.invoke(payload)
+	}()
+}
+
+// invoke runs the hook with payload on stdin. The hook is not
+// run with the context of check:data so that it is not killed
+// when check:data halts on the failure being reported.
+func (h *FailureHook) invoke(payload *FailureHookPayload) {
+	start := time.Now()
+	err := h.run(payload)
+	elapsed := time.Since(start)
+
+	h.statsLock.Lock()
+	h.stats.Calls++
+	if err != nil {
+		h.stats.Failures++
+	}
+	if elapsed.Milliseconds() > h.stats.SlowestInvocationMilliseconds {
+		h.stats.SlowestInvocationMilliseconds = elapsed.Milliseconds()
+	}
+	h.statsLock.Unlock()
+
+	if err != nil {
+		color.Yellow(
+			"[WARNING] reconciliation failure hook failed for %s: %s",
+			types.PrintStruct(payload.Account),
+			err.Error(),
+		)
+	}
+}
+
+func (h *FailureHook) run(payload *FailureHookPayload) error {
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal payload", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.path) // #nosec G204
+	cmd.Stdin = bytes.NewReader(input)
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("hook timed out after %s", h.timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+	}
+
+	return nil
+}
+
+// FailureHookStats returns the *FailureHookStats of all completed
+// invocations without waiting for pending ones.
+func (h *FailureHook) Stats() *results.FailureHookStats {
+	h.statsLock.Lock()
+	defer h.statsLock.Unlock()
+
+	stats := *h.stats
+	return &stats
+}
+
+// Wait blocks until all hook invocations have
+// completed and returns the resulting *FailureHookStats.
+func (h *FailureHook) Wait() *results.FailureHookStats {
+	h.wg.Wait()
+
+	return h.Stats()
+}
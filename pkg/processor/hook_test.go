@@ -0,0 +1,131 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeHook writes a fake hook executable to dir that
+// writes its stdin to a new file in output and then runs body.
+func writeHook(t *testing.T, dir string, output string, body string) string {
+	hookPath := path.Join(dir, "hook.sh")
+	script := fmt.Sprintf("#!/bin/sh\ncat > $(mktemp %s/payload.XXXXXX)\n%s\n", output, body)
+	assert.NoError(t, ioutil.WriteFile(hookPath, []byte(script), 0700)) // #nosec G306
+
+	return hookPath
+}
+
+func TestFailureHook(t *testing.T) {
+	var tests = map[string]struct {
+		body           string
+		timeout        uint64
+		maxConcurrency int
+		failures       int
+
+		expectedStats      *results.FailureHookStats
+		expectedMinSlowest int64
+	}{
+		"success": {
+			body:           "exit 0",
+			timeout:        10,
+			maxConcurrency: 2,
+			failures:       3,
+			expectedStats:  &results.FailureHookStats{Calls: 3},
+		},
+		"non-zero exit": {
+			body:           "echo broken >&2; exit 1",
+			timeout:        10,
+			maxConcurrency: 1,
+			failures:       2,
+			expectedStats:  &results.FailureHookStats{Calls: 2, Failures: 2},
+		},
+		"timeout": {
+			body:               "exec sleep 5",
+			timeout:            1,
+			maxConcurrency:     1,
+			failures:           1,
+			expectedStats:      &results.FailureHookStats{Calls: 1, Failures: 1},
+			expectedMinSlowest: 1000,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			output := path.Join(dir, "payloads")
+			assert.NoError(t, os.Mkdir(output, 0700))
+			h := NewFailureHook(&configuration.ReconciliationFailureHookConfiguration{
+				Path:           writeHook(t, dir, output, test.body),
+				Timeout:        test.timeout,
+				MaxConcurrency: test.maxConcurrency,
+			}, "run")
+
+			account := &types.AccountIdentifier{Address: "addr1"}
+			currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+			for i := 0; i < test.failures; i++ {
+				h.ReconciliationFailed(
+					"ACTIVE",
+					account,
+					currency,
+					"100",
+					"90",
+					&types.BlockIdentifier{Index: int64(i), Hash: fmt.Sprintf("block %d", i)},
+				)
+			}
+
+			stats := h.Wait()
+			assert.Equal(t, test.expectedStats.Calls, stats.Calls)
+			assert.Equal(t, test.expectedStats.Failures, stats.Failures)
+			assert.GreaterOrEqual(t, stats.SlowestInvocationMilliseconds, test.expectedMinSlowest)
+
+			// Each invocation is provided the failure on stdin.
+			files, err := ioutil.ReadDir(output)
+			assert.NoError(t, err)
+
+			indexes := map[int64]struct{}{}
+			for _, file := range files {
+				var payload FailureHookPayload
+				assert.NoError(t, utils.LoadAndParse(path.Join(output, file.Name()), &payload))
+				assert.Equal(t, "run", payload.RunID)
+				assert.Equal(t, "ACTIVE", payload.FailureType)
+				assert.Equal(t, account, payload.Account)
+				assert.Equal(t, currency, payload.Currency)
+				assert.Equal(t, "100", payload.ComputedBalance)
+				assert.Equal(t, "90", payload.LiveBalance)
+				indexes[payload.Block.Index] = struct{}{}
+			}
+			assert.Len(t, indexes, test.failures)
+		})
+	}
+}
+
+func TestNewFailureHookNilConfiguration(t *testing.T) {
+	assert.Nil(t, NewFailureHook(nil, "run"))
+}
@@ -0,0 +1,241 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+)
+
+const (
+	// zeroString is the difference of a balance
+	// change added for an unchanged account.
+	zeroString = "0"
+)
+
+// InterestingAccountsWatcher reloads an interesting accounts file (on SIGHUP
+// or every interval) so that accounts added during a run
+// are checked on subsequent blocks.
+//
+// Entries also match other accounts (see processor.AccountMatches),
+// so any account of a matching balance change is checked
+// on subsequent blocks.
+//
+// Accounts are never removed from a InterestingAccountsWatcher, so
+// reloading never disrupts already tracked accounts.
+type InterestingAccountsWatcher struct {
+	filePath string
+	interval time.Duration
+
+	lock    sync.Mutex
+	known   map[string]struct{}
+	added   []*types.AccountCurrency
+	entries *AccountMatcher
+}
+
+// NewInterestingAccountsWatcher returns a new *InterestingAccountsWatcher for filePath. initial
+// is the entries of the interesting accounts file (the
+// concrete accounts of which are already checked on each
+// block and are not returned by Added).
+func NewInterestingAccountsWatcher(
+	filePath string,
+	interval time.Duration,
+	initial []*types.AccountCurrency,
+) *InterestingAccountsWatcher {
+	known := map[string]struct{}{}
+	for _, account := range initial {
+		known[types.Hash(account)] = struct{}{}
+	}
+
+	return &InterestingAccountsWatcher{
+		filePath: filePath,
+		interval: interval,
+		known:    known,
+		added:    []*types.AccountCurrency{},
+		entries:  NewAccountMatcher(initial),
+	}
+}
+
+// Reload reads the interesting accounts file and starts
+// tracking any entries that are not already tracked. It
+// returns the number of entries added. If the file is
+// invalid, no entries are added.
+func (w *InterestingAccountsWatcher) Reload() (int, error) {
+	accounts := []*types.AccountCurrency{}
+	if err := utils.LoadAndParse(w.filePath, &accounts); err != nil {
+		return 0, fmt.Errorf("%w: unable to open account file", err)
+	}
+
+	if err := configuration.AssertAccounts(accounts); err != nil {
+		return 0, fmt.Errorf("%w: invalid account file %s", err, w.filePath)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	added := 0
+	for _, account := range accounts {
+		key := types.Hash(account)
+		if _, ok := w.known[key]; ok {
+			continue
+		}
+
+		w.known[key] = struct{}{}
+		w.entries.Add(account)
+		if !WildcardAccount(account.Account) {
+			w.added = append(w.added, account)
+		}
+		added++
+	}
+
+	return added, nil
+}
+
+// match starts tracking the account of each change
+// that matches an entry and is not already tracked.
+func (w *InterestingAccountsWatcher) match(changes []*parser.BalanceChange) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.entries.Empty() {
+		return
+	}
+
+	for _, change := range changes {
+		if !w.entries.Match(change.Account, change.Currency) {
+			continue
+		}
+
+		account := &types.AccountCurrency{
+			Account:  change.Account,
+			Currency: change.Currency,
+		}
+		key := types.Hash(account)
+		if _, ok := w.known[key]; ok {
+			continue
+		}
+
+		w.known[key] = struct{}{}
+		w.added = append(w.added, account)
+	}
+}
+
+// Added returns the accounts added since
+// the *InterestingAccountsWatcher was created.
+func (w *InterestingAccountsWatcher) Added() []*types.AccountCurrency {
+	if w == nil {
+		return nil
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.added
+}
+
+// Changes returns changes with a zero-difference change
+// for each added account that did not change in block
+// (so that it is checked on every block). Accounts of
+// changes that match an entry are added.
+func (w *InterestingAccountsWatcher) Changes(
+	block *types.BlockIdentifier,
+	changes []*parser.BalanceChange,
+) []*parser.BalanceChange {
+	if w == nil {
+		return changes
+	}
+
+	w.match(changes)
+	added := w.Added()
+	if len(added) == 0 {
+		return changes
+	}
+
+	changed := map[string]struct{}{}
+	for _, change := range changes {
+		changed[types.Hash(&types.AccountCurrency{
+			Account:  change.Account,
+			Currency: change.Currency,
+		})] = struct{}{}
+	}
+
+	for _, account := range added {
+		if _, ok := changed[types.Hash(account)]; ok {
+			continue
+		}
+
+		changes = append(changes, &parser.BalanceChange{
+			Account:    account.Account,
+			Currency:   account.Currency,
+			Difference: zeroString,
+			Block:      block,
+		})
+	}
+
+	return changes
+}
+
+// reload calls Reload and logs the result. A failed
+// reload is logged but does not stop the run.
+func (w *InterestingAccountsWatcher) reload(reason string) {
+	added, err := w.Reload()
+	if err != nil {
+		color.Red("%s: unable to reload interesting accounts (%s)", err.Error(), reason)
+		return
+	}
+
+	if added > 0 {
+		color.Cyan("Tracking %d new interesting account(s) from %s (%s)", added, w.filePath, reason)
+	}
+}
+
+// Start reloads the interesting accounts file whenever
+// SIGHUP is received and, if the interval is non-zero,
+// every interval until ctx is done.
+func (w *InterestingAccountsWatcher) Start(ctx context.Context) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		tc := time.NewTicker(w.interval)
+		defer tc.Stop()
+		tick = tc.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sigs:
+			w.reload("received SIGHUP")
+		case <-tick:
+			w.reload("reload interval elapsed")
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	interestingCurrency = &types.Currency{Symbol: "BTC", Decimals: 8}
+	interestingBlock    = &types.BlockIdentifier{Hash: "block 1", Index: 1}
+
+	account1 = &types.AccountCurrency{
+		Account:  &types.AccountIdentifier{Address: "addr1"},
+		Currency: interestingCurrency,
+	}
+	account2 = &types.AccountCurrency{
+		Account:  &types.AccountIdentifier{Address: "addr2"},
+		Currency: interestingCurrency,
+	}
+	account3 = &types.AccountCurrency{
+		Account:  &types.AccountIdentifier{Address: "addr3"},
+		Currency: interestingCurrency,
+	}
+)
+
+func TestReload(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	filePath := path.Join(dir, "interesting.json")
+	assert.NoError(t, utils.SerializeAndWrite(filePath, []*types.AccountCurrency{account1}))
+
+	w := NewInterestingAccountsWatcher(filePath, 0, []*types.AccountCurrency{account1})
+	added, err := w.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, added)
+	assert.Len(t, w.Added(), 0)
+
+	// Add accounts (and remove an existing one).
+	assert.NoError(t, utils.SerializeAndWrite(
+		filePath,
+		[]*types.AccountCurrency{account2, account3},
+	))
+	added, err = w.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, []*types.AccountCurrency{account2, account3}, w.Added())
+
+	// Invalid files don't change tracked accounts.
+	assert.NoError(t, utils.SerializeAndWrite(filePath, []*types.AccountCurrency{{}}))
+	_, err = w.Reload()
+	assert.Error(t, err)
+	assert.Len(t, w.Added(), 2)
+
+	// Reloading the same accounts is a no-op.
+	assert.NoError(t, utils.SerializeAndWrite(filePath, []*types.AccountCurrency{account3}))
+	added, err = w.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, added)
+	assert.Len(t, w.Added(), 2)
+}
+
+func TestChanges(t *testing.T) {
+	change := &parser.BalanceChange{
+		Account:    account2.Account,
+		Currency:   interestingCurrency,
+		Difference: "100",
+		Block:      interestingBlock,
+	}
+
+	var nilWatcher *InterestingAccountsWatcher
+	assert.Equal(
+		t,
+		[]*parser.BalanceChange{change},
+		nilWatcher.Changes(interestingBlock, []*parser.BalanceChange{change}),
+	)
+
+	w := NewInterestingAccountsWatcher("", 0, nil)
+	w.added = []*types.AccountCurrency{account2, account3}
+	assert.Equal(t, []*parser.BalanceChange{
+		change,
+		{
+			Account:    account3.Account,
+			Currency:   interestingCurrency,
+			Difference: "0",
+			Block:      interestingBlock,
+		},
+	}, w.Changes(interestingBlock, []*parser.BalanceChange{change}))
+}
+
+func TestStart(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	filePath := path.Join(dir, "interesting.json")
+	assert.NoError(t, utils.SerializeAndWrite(filePath, []*types.AccountCurrency{account1}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewInterestingAccountsWatcher(filePath, 10*time.Millisecond, []*types.AccountCurrency{account1})
+	done := make(chan error)
+	go func() {
+		done <- w.Start(ctx)
+	}()
+
+	// Before the account is added, it is not checked.
+	assert.Len(t, w.Changes(interestingBlock, nil), 0)
+
+	// Add an account to the file mid-run.
+	assert.NoError(t, utils.SerializeAndWrite(
+		filePath,
+		[]*types.AccountCurrency{account1, account2},
+	))
+	assert.Eventually(t, func() bool {
+		return len(w.Added()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []*parser.BalanceChange{
+		{
+			Account:    account2.Account,
+			Currency:   interestingCurrency,
+			Difference: "0",
+			Block:      interestingBlock,
+		},
+	}, w.Changes(interestingBlock, nil))
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func TestChangesMatching(t *testing.T) {
+	staking := &types.AccountIdentifier{
+		Address:    "addr1",
+		SubAccount: &types.SubAccountIdentifier{Address: "staking"},
+	}
+	wildcard := &types.AccountCurrency{
+		Account: &types.AccountIdentifier{
+			Address:    "addr2",
+			SubAccount: &types.SubAccountIdentifier{Address: "*"},
+		},
+		Currency: interestingCurrency,
+	}
+	delegation := &types.AccountIdentifier{
+		Address:    "addr2",
+		SubAccount: &types.SubAccountIdentifier{Address: "delegation"},
+	}
+
+	w := NewInterestingAccountsWatcher("", 0, []*types.AccountCurrency{account1, wildcard})
+	changes := []*parser.BalanceChange{
+		{Account: account1.Account, Currency: interestingCurrency, Difference: "1", Block: interestingBlock},
+		{Account: staking, Currency: interestingCurrency, Difference: "2", Block: interestingBlock},
+		{Account: delegation, Currency: interestingCurrency, Difference: "3", Block: interestingBlock},
+		{Account: account2.Account, Currency: interestingCurrency, Difference: "4", Block: interestingBlock},
+		{Account: account3.Account, Currency: interestingCurrency, Difference: "5", Block: interestingBlock},
+	}
+	assert.Equal(t, changes, w.Changes(interestingBlock, changes))
+
+	// Matching accounts are checked on subsequent blocks
+	// (the concrete entries are checked by the reconciler).
+	assert.Equal(t, []*types.AccountCurrency{
+		{Account: staking, Currency: interestingCurrency},
+		{Account: delegation, Currency: interestingCurrency},
+	}, w.Added())
+
+	next := &types.BlockIdentifier{Hash: "block 2", Index: 2}
+	assert.Equal(t, []*parser.BalanceChange{
+		{Account: staking, Currency: interestingCurrency, Difference: "0", Block: next},
+		{Account: delegation, Currency: interestingCurrency, Difference: "0", Block: next},
+	}, w.Changes(next, []*parser.BalanceChange{}))
+}
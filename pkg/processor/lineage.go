@@ -0,0 +1,670 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/constructor/coordinator"
+	"github.com/coinbase/rosetta-sdk-go/constructor/job"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/neilotoole/errgroup"
+)
+
+const (
+	namespace            = "lineage"
+	originNamespace      = "origin"
+	broadcastNamespace   = "broadcast"
+	transactionNamespace = "transaction"
+)
+
+const (
+	// PrefundedFunds accounts are provided in the configuration.
+	PrefundedFunds results.FundsCategory = "prefunded"
+
+	// GeneratedFunds accounts are created by a workflow.
+	GeneratedFunds results.FundsCategory = "generated"
+
+	// ExternalFunds accounts are not controlled by check:construction
+	// (ex: a faucet or the destination of returned funds).
+	ExternalFunds results.FundsCategory = "external"
+)
+
+var (
+	_ modules.BlockWorker    = (*LineageStorage)(nil)
+	_ coordinator.JobStorage = (*LineageJobStorage)(nil)
+)
+
+// LineageOrigin is the creation context of an account.
+type LineageOrigin struct {
+	Account       *types.AccountIdentifier `json:"account_identifier"`
+	Category      results.FundsCategory    `json:"category"`
+	Workflow      string                   `json:"workflow,omitempty"`
+	JobIdentifier string                   `json:"job_identifier,omitempty"`
+}
+
+// LineageChange is the balance change of a single
+// account in a transaction.
+type LineageChange struct {
+	Account  *types.AccountIdentifier `json:"account_identifier"`
+	Category results.FundsCategory    `json:"category"`
+	Amount   *types.Amount            `json:"amount"`
+}
+
+// LineageTransaction contains all balance changes in a confirmed
+// transaction that involved at least one tracked account.
+// If the transaction was created by check:construction,
+// the creating workflow and job are populated.
+type LineageTransaction struct {
+	BlockIdentifier       *types.BlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	Workflow              string                       `json:"workflow,omitempty"`
+	JobIdentifier         string                       `json:"job_identifier,omitempty"`
+	Changes               []*LineageChange             `json:"changes"`
+}
+
+// LineageEntry is a single funding event or spend
+// in the ledger of an account.
+type LineageEntry struct {
+	BlockIdentifier       *types.BlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	Workflow              string                       `json:"workflow,omitempty"`
+	JobIdentifier         string                       `json:"job_identifier,omitempty"`
+
+	// Amount is positive for funding events
+	// and negative for spends.
+	Amount *types.Amount `json:"amount"`
+
+	// Counterparties are the accounts in the transaction
+	// whose balance moved in the opposite direction.
+	Counterparties []*LineageChange `json:"counterparties,omitempty"`
+}
+
+type broadcast struct {
+	Workflow      string `json:"workflow"`
+	JobIdentifier string `json:"job_identifier"`
+}
+
+func originKey(account *types.AccountIdentifier) []byte {
+	return []byte(fmt.Sprintf("%s/%s/%s", namespace, originNamespace, types.Hash(account)))
+}
+
+func broadcastKey(transactionIdentifier *types.TransactionIdentifier) []byte {
+	return []byte(fmt.Sprintf("%s/%s/%s", namespace, broadcastNamespace, transactionIdentifier.Hash))
+}
+
+// blockPrefix is zero-padded so that transactions
+// are scanned in the order they were confirmed.
+func blockPrefix(index int64) []byte {
+	return []byte(fmt.Sprintf("%s/%s/%020d/", namespace, transactionNamespace, index))
+}
+
+func transactionKey(
+	block *types.BlockIdentifier,
+	transactionIdentifier *types.TransactionIdentifier,
+) []byte {
+	return append(blockPrefix(block.Index), []byte(transactionIdentifier.Hash)...)
+}
+
+// LineageStorage records the lineage of all accounts used
+// by check:construction: the workflow and job that created
+// each account and every confirmed transaction that moved
+// its funds.
+type LineageStorage struct {
+	db     database.Database
+	jobs   coordinator.JobStorage
+	parser *parser.Parser
+
+	// pending contains accounts stored in a database transaction
+	// before the job that created them was assigned an identifier.
+	pendingLock sync.Mutex
+	pending     map[database.Transaction][]*types.AccountIdentifier
+}
+
+// NewLineageStorage returns a new *LineageStorage. jobs and parser are only
+// required when recording lineage (not when reading it).
+func NewLineageStorage(
+	db database.Database,
+	jobs coordinator.JobStorage,
+	parser *parser.Parser,
+) *LineageStorage {
+	return &LineageStorage{
+		db:      db,
+		jobs:    jobs,
+		parser:  parser,
+		pending: map[database.Transaction][]*types.AccountIdentifier{},
+	}
+}
+
+func setJSON(ctx context.Context, dbTx database.Transaction, key []byte, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode %s", err, string(key))
+	}
+
+	return dbTx.Set(ctx, key, value, false)
+}
+
+func getJSON(
+	ctx context.Context,
+	dbTx database.Transaction,
+	key []byte,
+	v interface{},
+) (bool, error) {
+	exists, value, err := dbTx.Get(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("%w: unable to get %s", err, string(key))
+	}
+
+	if !exists {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(value, v); err != nil {
+		return false, fmt.Errorf("%w: unable to decode %s", err, string(key))
+	}
+
+	return true, nil
+}
+
+// ImportPrefunded records the origin of prefunded
+// accounts that do not have an origin yet.
+func (s *LineageStorage) ImportPrefunded(
+	ctx context.Context,
+	accounts []*types.AccountIdentifier,
+) error {
+	dbTx := s.db.Transaction(ctx)
+	defer dbTx.Discard(ctx)
+
+	for _, account := range accounts {
+		origin, err := s.getOrigin(ctx, dbTx, account)
+		if err != nil {
+			return err
+		}
+
+		if origin != nil {
+			continue
+		}
+
+		if err := setJSON(ctx, dbTx, originKey(account), &LineageOrigin{
+			Account:  account,
+			Category: PrefundedFunds,
+		}); err != nil {
+			return fmt.Errorf("%w: unable to store prefunded account origin", err)
+		}
+	}
+
+	return dbTx.Commit(ctx)
+}
+
+// AddPending records that account was created in dbTx. Its
+// origin is stored when the job that created it is updated in
+// the same dbTx.
+func (s *LineageStorage) AddPending(dbTx database.Transaction, account *types.AccountIdentifier) {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+
+	s.pending[dbTx] = append(s.pending[dbTx], account)
+}
+
+// resetPending drops accounts created in database
+// transactions that were discarded.
+func (s *LineageStorage) resetPending() {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+
+	s.pending = map[database.Transaction][]*types.AccountIdentifier{}
+}
+
+// storePending stores the origin of all accounts
+// created in dbTx by the provided job.
+func (s *LineageStorage) storePending(
+	ctx context.Context,
+	dbTx database.Transaction,
+	jobIdentifier string,
+	workflow string,
+) error {
+	s.pendingLock.Lock()
+	accounts := s.pending[dbTx]
+	delete(s.pending, dbTx)
+	s.pendingLock.Unlock()
+
+	for _, account := range accounts {
+		if err := setJSON(ctx, dbTx, originKey(account), &LineageOrigin{
+			Account:       account,
+			Category:      GeneratedFunds,
+			Workflow:      workflow,
+			JobIdentifier: jobIdentifier,
+		}); err != nil {
+			return fmt.Errorf("%w: unable to store generated account origin", err)
+		}
+	}
+
+	return nil
+}
+
+// RecordBroadcast attributes a transaction to the job
+// (and workflow) that created it.
+func (s *LineageStorage) RecordBroadcast(
+	ctx context.Context,
+	dbTx database.Transaction,
+	jobIdentifier string,
+	transactionIdentifier *types.TransactionIdentifier,
+) error {
+	j, err := s.jobs.Get(ctx, dbTx, jobIdentifier)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get job %s", err, jobIdentifier)
+	}
+
+	return setJSON(ctx, dbTx, broadcastKey(transactionIdentifier), &broadcast{
+		Workflow:      j.Workflow,
+		JobIdentifier: jobIdentifier,
+	})
+}
+
+func (s *LineageStorage) getOrigin(
+	ctx context.Context,
+	dbTx database.Transaction,
+	account *types.AccountIdentifier,
+) (*LineageOrigin, error) {
+	var origin LineageOrigin
+	exists, err := getJSON(ctx, dbTx, originKey(account), &origin)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return &origin, nil
+}
+
+// transaction returns a *LineageTransaction if tx involves
+// any tracked account.
+func (s *LineageStorage) transaction(
+	ctx context.Context,
+	dbTx database.Transaction,
+	block *types.Block,
+	tx *types.Transaction,
+) (*LineageTransaction, error) {
+	balanceChanges, err := s.parser.BalanceChanges(ctx, &types.Block{
+		BlockIdentifier:       block.BlockIdentifier,
+		ParentBlockIdentifier: block.ParentBlockIdentifier,
+		Transactions:          []*types.Transaction{tx},
+	}, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to calculate balance changes", err)
+	}
+
+	tracked := false
+	changes := make([]*LineageChange, len(balanceChanges))
+	for i, balanceChange := range balanceChanges {
+		origin, err := s.getOrigin(ctx, dbTx, balanceChange.Account)
+		if err != nil {
+			return nil, err
+		}
+
+		category := ExternalFunds
+		if origin != nil {
+			category = origin.Category
+			tracked = true
+		}
+
+		changes[i] = &LineageChange{
+			Account:  balanceChange.Account,
+			Category: category,
+			Amount: &types.Amount{
+				Value:    balanceChange.Difference,
+				Currency: balanceChange.Currency,
+			},
+		}
+	}
+
+	if !tracked {
+		return nil, nil
+	}
+
+	// Balance changes are computed from a map, so we sort
+	// them to make the output deterministic.
+	sort.Slice(changes, func(i, j int) bool {
+		return types.Hash(changes[i]) < types.Hash(changes[j])
+	})
+
+	var b broadcast
+	if _, err := getJSON(ctx, dbTx, broadcastKey(tx.TransactionIdentifier), &b); err != nil {
+		return nil, err
+	}
+
+	return &LineageTransaction{
+		BlockIdentifier:       block.BlockIdentifier,
+		TransactionIdentifier: tx.TransactionIdentifier,
+		Workflow:              b.Workflow,
+		JobIdentifier:         b.JobIdentifier,
+		Changes:               changes,
+	}, nil
+}
+
+// AddingBlock records all transactions in the block
+// that involve a tracked account.
+func (s *LineageStorage) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	dbTx database.Transaction,
+) (database.CommitWorker, error) {
+	for _, tx := range block.Transactions {
+		transaction, err := s.transaction(ctx, dbTx, block, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		if transaction == nil {
+			continue
+		}
+
+		key := transactionKey(block.BlockIdentifier, tx.TransactionIdentifier)
+		if err := setJSON(ctx, dbTx, key, transaction); err != nil {
+			return nil, fmt.Errorf("%w: unable to store transaction", err)
+		}
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock removes all transactions
+// recorded for an orphaned block.
+func (s *LineageStorage) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	dbTx database.Transaction,
+) (database.CommitWorker, error) {
+	keys := [][]byte{}
+	_, err := dbTx.Scan(
+		ctx,
+		blockPrefix(block.BlockIdentifier.Index),
+		blockPrefix(block.BlockIdentifier.Index),
+		func(k []byte, v []byte) error {
+			keys = append(keys, append([]byte{}, k...))
+			return nil
+		},
+		false,
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to scan transactions", err)
+	}
+
+	for _, key := range keys {
+		if err := dbTx.Delete(ctx, key); err != nil {
+			return nil, fmt.Errorf("%w: unable to delete transaction", err)
+		}
+	}
+
+	return nil, nil
+}
+
+// Origins returns the origin of all tracked accounts.
+func (s *LineageStorage) Origins(ctx context.Context) ([]*LineageOrigin, error) {
+	dbTx := s.db.ReadTransaction(ctx)
+	defer dbTx.Discard(ctx)
+
+	prefix := []byte(fmt.Sprintf("%s/%s/", namespace, originNamespace))
+	origins := []*LineageOrigin{}
+	_, err := dbTx.Scan(ctx, prefix, prefix, func(k []byte, v []byte) error {
+		var origin LineageOrigin
+		if err := json.Unmarshal(v, &origin); err != nil {
+			return fmt.Errorf("%w: unable to decode origin", err)
+		}
+
+		origins = append(origins, &origin)
+		return nil
+	}, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to scan origins", err)
+	}
+
+	return origins, nil
+}
+
+// Transactions returns all recorded transactions
+// in the order they were confirmed.
+func (s *LineageStorage) Transactions(ctx context.Context) ([]*LineageTransaction, error) {
+	dbTx := s.db.ReadTransaction(ctx)
+	defer dbTx.Discard(ctx)
+
+	prefix := []byte(fmt.Sprintf("%s/%s/", namespace, transactionNamespace))
+	transactions := []*LineageTransaction{}
+	_, err := dbTx.Scan(ctx, prefix, prefix, func(k []byte, v []byte) error {
+		var transaction LineageTransaction
+		if err := json.Unmarshal(v, &transaction); err != nil {
+			return fmt.Errorf("%w: unable to decode transaction", err)
+		}
+
+		transactions = append(transactions, &transaction)
+		return nil
+	}, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to scan transactions", err)
+	}
+
+	return transactions, nil
+}
+
+// Ledger returns a chronological ledger of all
+// funding events and spends of an account.
+func (s *LineageStorage) Ledger(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+) ([]*LineageEntry, error) {
+	transactions, err := s.Transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*LineageEntry{}
+	for _, transaction := range transactions {
+		for _, change := range transaction.Changes {
+			if types.Hash(change.Account) != types.Hash(account) {
+				continue
+			}
+
+			entries = append(entries, &LineageEntry{
+				BlockIdentifier:       transaction.BlockIdentifier,
+				TransactionIdentifier: transaction.TransactionIdentifier,
+				Workflow:              transaction.Workflow,
+				JobIdentifier:         transaction.JobIdentifier,
+				Amount:                change.Amount,
+				Counterparties:        counterparties(transaction, change),
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// counterparties returns all changes in transaction of
+// the same currency in the opposite direction of change.
+func counterparties(transaction *LineageTransaction, change *LineageChange) []*LineageChange {
+	sign := amountSign(change.Amount)
+	matches := []*LineageChange{}
+	for _, other := range transaction.Changes {
+		if types.Hash(other.Amount.Currency) != types.Hash(change.Amount.Currency) {
+			continue
+		}
+
+		if amountSign(other.Amount)*sign < 0 {
+			matches = append(matches, other)
+		}
+	}
+
+	return matches
+}
+
+func amountSign(amount *types.Amount) int {
+	value, err := types.BigInt(amount.Value)
+	if err != nil {
+		return 0
+	}
+
+	return value.Sign()
+}
+
+// FundsFlow computes the total inflow and outflow of each
+// currency for each category of account.
+func (s *LineageStorage) FundsFlow(ctx context.Context) (*results.FundsFlow, error) {
+	transactions, err := s.Transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ComputeFundsFlow(transactions)
+}
+
+// ComputeFundsFlow computes *FundsFlow from
+// a slice of recorded transactions.
+func ComputeFundsFlow(transactions []*LineageTransaction) (*results.FundsFlow, error) {
+	type flow struct {
+		category results.FundsCategory
+		currency *types.Currency
+		inflow   *big.Int
+		outflow  *big.Int
+	}
+
+	flows := map[string]*flow{}
+	net := map[string]*types.Amount{}
+	for _, transaction := range transactions {
+		for _, change := range transaction.Changes {
+			value, err := types.BigInt(change.Amount.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to parse amount", err)
+			}
+
+			key := fmt.Sprintf("%s/%s", change.Category, types.Hash(change.Amount.Currency))
+			f, ok := flows[key]
+			if !ok {
+				f = &flow{
+					category: change.Category,
+					currency: change.Amount.Currency,
+					inflow:   big.NewInt(0),
+					outflow:  big.NewInt(0),
+				}
+				flows[key] = f
+			}
+
+			if value.Sign() > 0 {
+				f.inflow.Add(f.inflow, value)
+			} else {
+				f.outflow.Sub(f.outflow, value)
+			}
+
+			currencyKey := types.Hash(change.Amount.Currency)
+			total, ok := net[currencyKey]
+			if !ok {
+				net[currencyKey] = change.Amount
+				continue
+			}
+
+			sum, err := types.AddValues(total.Value, change.Amount.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to add amounts", err)
+			}
+
+			net[currencyKey] = &types.Amount{Value: sum, Currency: total.Currency}
+		}
+	}
+
+	fundsFlow := &results.FundsFlow{
+		Flows: []*results.CategoryFlow{},
+		Net:   []*types.Amount{},
+	}
+	for _, f := range flows {
+		fundsFlow.Flows = append(fundsFlow.Flows, &results.CategoryFlow{
+			Category: f.category,
+			Currency: f.currency,
+			Inflow:   f.inflow.String(),
+			Outflow:  f.outflow.String(),
+		})
+	}
+	sort.Slice(fundsFlow.Flows, func(i, j int) bool {
+		if fundsFlow.Flows[i].Category != fundsFlow.Flows[j].Category {
+			return fundsFlow.Flows[i].Category > fundsFlow.Flows[j].Category
+		}
+
+		return fundsFlow.Flows[i].Currency.Symbol < fundsFlow.Flows[j].Currency.Symbol
+	})
+
+	for _, amount := range net {
+		fundsFlow.Net = append(fundsFlow.Net, amount)
+	}
+	sort.Slice(fundsFlow.Net, func(i, j int) bool {
+		return fundsFlow.Net[i].Currency.Symbol < fundsFlow.Net[j].Currency.Symbol
+	})
+
+	return fundsFlow, nil
+}
+
+// LineageJobStorage wraps a coordinator.JobStorage so that
+// accounts created while processing a job are
+// attributed to that job.
+type LineageJobStorage struct {
+	coordinator.JobStorage
+
+	storage *LineageStorage
+}
+
+// NewLineageJobStorage returns a new *LineageJobStorage that
+// wraps the coordinator.JobStorage of storage.
+func NewLineageJobStorage(storage *LineageStorage) *LineageJobStorage {
+	return &LineageJobStorage{
+		JobStorage: storage.jobs,
+		storage:    storage,
+	}
+}
+
+// Ready is called at the start of each job processing
+// attempt, so we use it to drop accounts from attempts
+// that were discarded.
+func (j *LineageJobStorage) Ready(ctx context.Context, dbTx database.Transaction) ([]*job.Job, error) {
+	j.storage.resetPending()
+
+	return j.JobStorage.Ready(ctx, dbTx)
+}
+
+// Update stores the origin of any accounts created
+// while processing the job.
+func (j *LineageJobStorage) Update(
+	ctx context.Context,
+	dbTx database.Transaction,
+	v *job.Job,
+) (string, error) {
+	jobIdentifier, err := j.JobStorage.Update(ctx, dbTx, v)
+	if err != nil {
+		return "", err
+	}
+
+	if err := j.storage.storePending(ctx, dbTx, jobIdentifier, v.Workflow); err != nil {
+		return "", err
+	}
+
+	return jobIdentifier, nil
+}
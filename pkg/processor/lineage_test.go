@@ -0,0 +1,260 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/constructor/job"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	lineageCurrency = &types.Currency{
+		Symbol:   "BTC",
+		Decimals: 8,
+	}
+
+	prefunded = &types.AccountIdentifier{Address: "prefunded"}
+	generated = &types.AccountIdentifier{Address: "generated"}
+	faucet    = &types.AccountIdentifier{Address: "faucet"}
+	recipient = &types.AccountIdentifier{Address: "recipient"}
+)
+
+func transfer(hash string, changes map[*types.AccountIdentifier]string) *types.Transaction {
+	tx := &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+	}
+
+	for account, value := range changes {
+		tx.Operations = append(tx.Operations, &types.Operation{
+			OperationIdentifier: &types.OperationIdentifier{
+				Index: int64(len(tx.Operations)),
+			},
+			Type:    "Transfer",
+			Status:  types.String("Success"),
+			Account: account,
+			Amount: &types.Amount{
+				Value:    value,
+				Currency: lineageCurrency,
+			},
+		})
+	}
+
+	return tx
+}
+
+func lineageBlock(index int64, txs ...*types.Transaction) *types.Block {
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  "block",
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: index - 1,
+			Hash:  "parent",
+		},
+		Transactions: txs,
+	}
+}
+
+func addBlock(ctx context.Context, t *testing.T, db database.Database, s *LineageStorage, b *types.Block) {
+	dbTx := db.Transaction(ctx)
+	defer dbTx.Discard(ctx)
+
+	_, err := s.AddingBlock(ctx, nil, b, dbTx)
+	assert.NoError(t, err)
+	assert.NoError(t, dbTx.Commit(ctx))
+}
+
+func TestLineage(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	db, err := database.NewBadgerDatabase(ctx, newDir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	a, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{
+			Blockchain: "bitcoin",
+			Network:    "testnet",
+		},
+		&types.BlockIdentifier{
+			Hash:  "block 0",
+			Index: 0,
+		},
+		[]string{"Transfer"},
+		[]*types.OperationStatus{{Status: "Success", Successful: true}},
+		[]*types.Error{},
+		nil,
+		&asserter.Validations{
+			Enabled: false,
+		},
+	)
+	assert.NoError(t, err)
+
+	jobStorage := modules.NewJobStorage(db)
+	s := NewLineageStorage(db, jobStorage, parser.New(a, nil, nil))
+	jobs := NewLineageJobStorage(s)
+
+	assert.NoError(t, s.ImportPrefunded(ctx, []*types.AccountIdentifier{prefunded}))
+
+	t.Run("discarded account creation is not recorded", func(t *testing.T) {
+		dbTx := db.Transaction(ctx)
+		_, err := jobs.Ready(ctx, dbTx)
+		assert.NoError(t, err)
+		s.AddPending(dbTx, recipient)
+		dbTx.Discard(ctx)
+
+		dbTx = db.Transaction(ctx)
+		_, err = jobs.Ready(ctx, dbTx)
+		assert.NoError(t, err)
+		_, err = jobs.Update(ctx, dbTx, job.New(&job.Workflow{Name: "transfer"}))
+		assert.NoError(t, err)
+		assert.NoError(t, dbTx.Commit(ctx))
+
+		origins, err := s.Origins(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, origins, 1)
+	})
+
+	var createJob string
+	t.Run("account creation", func(t *testing.T) {
+		dbTx := db.Transaction(ctx)
+		_, err := jobs.Ready(ctx, dbTx)
+		assert.NoError(t, err)
+		s.AddPending(dbTx, generated)
+		createJob, err = jobs.Update(ctx, dbTx, job.New(&job.Workflow{Name: "create_account"}))
+		assert.NoError(t, err)
+		assert.NoError(t, dbTx.Commit(ctx))
+
+		origins, err := s.Origins(ctx)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*LineageOrigin{
+			{Account: prefunded, Category: PrefundedFunds},
+			{
+				Account:       generated,
+				Category:      GeneratedFunds,
+				Workflow:      "create_account",
+				JobIdentifier: createJob,
+			},
+		}, origins)
+	})
+
+	var transferJob string
+	t.Run("funding and spends", func(t *testing.T) {
+		dbTx := db.Transaction(ctx)
+		transferJob, err = jobs.Update(ctx, dbTx, job.New(&job.Workflow{Name: "transfer"}))
+		assert.NoError(t, err)
+		assert.NoError(t, s.RecordBroadcast(
+			ctx,
+			dbTx,
+			transferJob,
+			&types.TransactionIdentifier{Hash: "tx 2"},
+		))
+		assert.NoError(t, dbTx.Commit(ctx))
+
+		// Irrelevant transactions are not recorded.
+		addBlock(ctx, t, db, s, lineageBlock(1,
+			transfer("tx 0", map[*types.AccountIdentifier]string{
+				faucet:    "-100",
+				recipient: "100",
+			}),
+			transfer("tx 1", map[*types.AccountIdentifier]string{
+				faucet:    "-1010",
+				prefunded: "1000",
+			}),
+		))
+		addBlock(ctx, t, db, s, lineageBlock(2,
+			transfer("tx 2", map[*types.AccountIdentifier]string{
+				prefunded: "-510",
+				generated: "500",
+			}),
+		))
+		addBlock(ctx, t, db, s, lineageBlock(3,
+			transfer("tx 3", map[*types.AccountIdentifier]string{
+				generated: "-210",
+				recipient: "200",
+			}),
+		))
+
+		entries, err := s.Ledger(ctx, generated)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+
+		assert.Equal(t, "tx 2", entries[0].TransactionIdentifier.Hash)
+		assert.Equal(t, "transfer", entries[0].Workflow)
+		assert.Equal(t, transferJob, entries[0].JobIdentifier)
+		assert.Equal(t, "500", entries[0].Amount.Value)
+		assert.Equal(t, []*LineageChange{
+			{
+				Account:  prefunded,
+				Category: PrefundedFunds,
+				Amount:   &types.Amount{Value: "-510", Currency: lineageCurrency},
+			},
+		}, entries[0].Counterparties)
+
+		assert.Equal(t, "tx 3", entries[1].TransactionIdentifier.Hash)
+		assert.Empty(t, entries[1].Workflow)
+		assert.Equal(t, "-210", entries[1].Amount.Value)
+		assert.Equal(t, []*LineageChange{
+			{
+				Account:  recipient,
+				Category: ExternalFunds,
+				Amount:   &types.Amount{Value: "200", Currency: lineageCurrency},
+			},
+		}, entries[1].Counterparties)
+
+		fundsFlow, err := s.FundsFlow(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, &results.FundsFlow{
+			Flows: []*results.CategoryFlow{
+				{Category: PrefundedFunds, Currency: lineageCurrency, Inflow: "1000", Outflow: "510"},
+				{Category: GeneratedFunds, Currency: lineageCurrency, Inflow: "500", Outflow: "210"},
+				{Category: ExternalFunds, Currency: lineageCurrency, Inflow: "200", Outflow: "1010"},
+			},
+			Net: []*types.Amount{{Value: "-30", Currency: lineageCurrency}},
+		}, fundsFlow)
+	})
+
+	t.Run("orphaned block", func(t *testing.T) {
+		dbTx := db.Transaction(ctx)
+		_, err := s.RemovingBlock(ctx, nil, lineageBlock(3), dbTx)
+		assert.NoError(t, err)
+		assert.NoError(t, dbTx.Commit(ctx))
+
+		entries, err := s.Ledger(ctx, generated)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "tx 2", entries[0].TransactionIdentifier.Hash)
+
+		entries, err = s.Ledger(ctx, prefunded)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import "github.com/coinbase/rosetta-sdk-go/types"
+
+// AnySubAccount is the sub-account address of an
+// entry that matches any sub-account of its address.
+const AnySubAccount = "*"
+
+// AccountMatches returns a boolean indicating if account
+// matches entry:
+//   - the addresses must be equal
+//   - an entry without a sub-account matches any
+//     sub-account (or no sub-account) of its address
+//   - an entry with sub-account AnySubAccount matches
+//     any sub-account (but not the address itself)
+//   - metadata (of the account or sub-account) is
+//     only compared if it is populated in entry
+func AccountMatches(entry *types.AccountIdentifier, account *types.AccountIdentifier) bool {
+	if entry == nil || account == nil {
+		return false
+	}
+
+	if entry.Address != account.Address || !metadataMatches(entry.Metadata, account.Metadata) {
+		return false
+	}
+
+	if entry.SubAccount == nil {
+		return true
+	}
+
+	if account.SubAccount == nil {
+		return false
+	}
+
+	if entry.SubAccount.Address != AnySubAccount &&
+		entry.SubAccount.Address != account.SubAccount.Address {
+		return false
+	}
+
+	return metadataMatches(entry.SubAccount.Metadata, account.SubAccount.Metadata)
+}
+
+// metadataMatches returns a boolean indicating if metadata
+// is equal to the metadata of an entry (if populated).
+func metadataMatches(entry map[string]interface{}, metadata map[string]interface{}) bool {
+	return len(entry) == 0 || types.Hash(entry) == types.Hash(metadata)
+}
+
+// WildcardAccount returns a boolean indicating if entry matches
+// accounts other than the account it identifies (so it cannot
+// be used as an account identifier).
+func WildcardAccount(entry *types.AccountIdentifier) bool {
+	return entry != nil && entry.SubAccount != nil && entry.SubAccount.Address == AnySubAccount
+}
+
+// ConcreteAccounts returns the entries in accounts that identify an
+// account (all entries except those that are a WildcardAccount).
+func ConcreteAccounts(accounts []*types.AccountCurrency) []*types.AccountCurrency {
+	concrete := []*types.AccountCurrency{}
+	for _, account := range accounts {
+		if !WildcardAccount(account.Account) {
+			concrete = append(concrete, account)
+		}
+	}
+
+	return concrete
+}
+
+// AccountMatcher determines if an account and currency match
+// any of a list of entries (using the semantics of AccountMatches
+// for the account and requiring an equal currency).
+//
+// Matcher is not safe for concurrent use if Add is called.
+type AccountMatcher struct {
+	// entries are indexed by address to avoid
+	// hashing currencies for most lookups.
+	entries map[string][]*types.AccountCurrency
+}
+
+// NewAccountMatcher returns a new *AccountMatcher for accounts.
+func NewAccountMatcher(accounts []*types.AccountCurrency) *AccountMatcher {
+	m := &AccountMatcher{entries: map[string][]*types.AccountCurrency{}}
+	m.Add(accounts...)
+
+	return m
+}
+
+// Add adds entries to m.
+func (m *AccountMatcher) Add(accounts ...*types.AccountCurrency) {
+	for _, account := range accounts {
+		address := account.Account.Address
+		m.entries[address] = append(m.entries[address], account)
+	}
+}
+
+// Empty returns a boolean indicating if m
+// has no entries (or m is nil).
+func (m *AccountMatcher) Empty() bool {
+	return m == nil || len(m.entries) == 0
+}
+
+// Match returns a boolean indicating if account and currency
+// match any entry in m. A nil *AccountMatcher matches nothing.
+func (m *AccountMatcher) Match(account *types.AccountIdentifier, currency *types.Currency) bool {
+	if m == nil || account == nil {
+		return false
+	}
+
+	entries := m.entries[account.Address]
+	if len(entries) == 0 {
+		return false
+	}
+
+	currencyHash := types.Hash(currency)
+	for _, entry := range entries {
+		if AccountMatches(entry.Account, account) && types.Hash(entry.Currency) == currencyHash {
+			return true
+		}
+	}
+
+	return false
+}
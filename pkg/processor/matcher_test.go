@@ -0,0 +1,195 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	matcherBtc = &types.Currency{Symbol: "BTC", Decimals: 8}
+	eth        = &types.Currency{Symbol: "ETH", Decimals: 18}
+
+	address = &types.AccountIdentifier{Address: "addr1"}
+	staking = &types.AccountIdentifier{
+		Address:    "addr1",
+		SubAccount: &types.SubAccountIdentifier{Address: "staking"},
+	}
+	validator = &types.AccountIdentifier{
+		Address: "addr1",
+		SubAccount: &types.SubAccountIdentifier{
+			Address:  "validator",
+			Metadata: map[string]interface{}{"epoch": float64(1)},
+		},
+	}
+	wildcard = &types.AccountIdentifier{
+		Address:    "addr1",
+		SubAccount: &types.SubAccountIdentifier{Address: AnySubAccount},
+	}
+)
+
+func TestAccountMatches(t *testing.T) {
+	var tests = map[string]struct {
+		entry   *types.AccountIdentifier
+		account *types.AccountIdentifier
+
+		expected bool
+	}{
+		"address only (same account)": {
+			entry:    address,
+			account:  address,
+			expected: true,
+		},
+		"address only (sub-account)": {
+			entry:    address,
+			account:  staking,
+			expected: true,
+		},
+		"address only (different address)": {
+			entry:   address,
+			account: &types.AccountIdentifier{Address: "addr2"},
+		},
+		"exact sub-account": {
+			entry:    staking,
+			account:  staking,
+			expected: true,
+		},
+		"exact sub-account (different sub-account)": {
+			entry:   staking,
+			account: validator,
+		},
+		"exact sub-account (no sub-account)": {
+			entry:   staking,
+			account: address,
+		},
+		"exact sub-account (metadata ignored)": {
+			entry: &types.AccountIdentifier{
+				Address:    "addr1",
+				SubAccount: &types.SubAccountIdentifier{Address: "validator"},
+			},
+			account:  validator,
+			expected: true,
+		},
+		"wildcard": {
+			entry:    wildcard,
+			account:  staking,
+			expected: true,
+		},
+		"wildcard (sub-account metadata)": {
+			entry:    wildcard,
+			account:  validator,
+			expected: true,
+		},
+		"wildcard (no sub-account)": {
+			entry:   wildcard,
+			account: address,
+		},
+		"wildcard (different address)": {
+			entry: wildcard,
+			account: &types.AccountIdentifier{
+				Address:    "addr2",
+				SubAccount: &types.SubAccountIdentifier{Address: "staking"},
+			},
+		},
+		"sub-account metadata": {
+			entry:    validator,
+			account:  validator,
+			expected: true,
+		},
+		"sub-account metadata (different)": {
+			entry: validator,
+			account: &types.AccountIdentifier{
+				Address: "addr1",
+				SubAccount: &types.SubAccountIdentifier{
+					Address:  "validator",
+					Metadata: map[string]interface{}{"epoch": float64(2)},
+				},
+			},
+		},
+		"account metadata": {
+			entry: &types.AccountIdentifier{
+				Address:  "addr1",
+				Metadata: map[string]interface{}{"type": "multisig"},
+			},
+			account: &types.AccountIdentifier{
+				Address:    "addr1",
+				SubAccount: &types.SubAccountIdentifier{Address: "staking"},
+				Metadata:   map[string]interface{}{"type": "multisig"},
+			},
+			expected: true,
+		},
+		"account metadata (missing)": {
+			entry: &types.AccountIdentifier{
+				Address:  "addr1",
+				Metadata: map[string]interface{}{"type": "multisig"},
+			},
+			account: address,
+		},
+		"account metadata (ignored)": {
+			entry: address,
+			account: &types.AccountIdentifier{
+				Address:  "addr1",
+				Metadata: map[string]interface{}{"type": "multisig"},
+			},
+			expected: true,
+		},
+		"nil account": {
+			entry: address,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, AccountMatches(test.entry, test.account))
+		})
+	}
+}
+
+func TestAccountMatcher(t *testing.T) {
+	m := NewAccountMatcher([]*types.AccountCurrency{
+		{Account: address, Currency: matcherBtc},
+		{Account: wildcard, Currency: eth},
+	})
+	assert.False(t, m.Empty())
+
+	assert.True(t, m.Match(address, matcherBtc))
+	assert.True(t, m.Match(staking, matcherBtc))
+	assert.True(t, m.Match(staking, eth))
+	assert.False(t, m.Match(address, eth))
+	assert.False(t, m.Match(&types.AccountIdentifier{Address: "addr2"}, matcherBtc))
+	assert.False(t, m.Match(nil, matcherBtc))
+
+	m.Add(&types.AccountCurrency{Account: address, Currency: eth})
+	assert.True(t, m.Match(address, eth))
+
+	var nilMatcher *AccountMatcher
+	assert.True(t, nilMatcher.Empty())
+	assert.False(t, nilMatcher.Match(address, matcherBtc))
+	assert.True(t, NewAccountMatcher(nil).Empty())
+}
+
+func TestConcreteAccounts(t *testing.T) {
+	accounts := []*types.AccountCurrency{
+		{Account: address, Currency: matcherBtc},
+		{Account: wildcard, Currency: matcherBtc},
+		{Account: staking, Currency: matcherBtc},
+	}
+
+	assert.Equal(t, []*types.AccountCurrency{accounts[0], accounts[2]}, ConcreteAccounts(accounts))
+	assert.Equal(t, []*types.AccountCurrency{}, ConcreteAccounts(nil))
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+)
+
+const (
+	// MaxMonitorFindings is the number of most recent findings
+	// retained in *FindingsMonitorResults.
+	MaxMonitorFindings = 1000
+
+	// MonitorStopPath is the path of the status server that stops
+	// check:data while it is in monitor mode (POST only).
+	MonitorStopPath = "/monitor/stop"
+)
+
+// FindingsMonitor accumulates the findings of check:data after
+// its end conditions are met. Findings are written to a
+// separate results file (rewritten on each finding) so
+// that the passing results are never modified.
+type FindingsMonitor struct {
+	outputFile string
+
+	lock          sync.Mutex
+	active        bool
+	stopRequested bool
+	results       *results.FindingsMonitorResults
+}
+
+// NewFindingsMonitor returns a new *FindingsMonitor that writes its results
+// to outputFile (if populated).
+func NewFindingsMonitor(outputFile string) *FindingsMonitor {
+	return &FindingsMonitor{
+		outputFile: outputFile,
+	}
+}
+
+// Start enters monitor mode. Calling Start more
+// than once has no effect.
+func (m *FindingsMonitor) Start() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.active {
+		return
+	}
+
+	m.active = true
+	m.results = &results.FindingsMonitorResults{
+		StartedAt: utils.Milliseconds(),
+		Findings:  []*results.MonitorFinding{},
+	}
+	m.write()
+
+	color.Green("check passed, entering monitor mode")
+}
+
+// Active returns a boolean indicating if monitor
+// mode has been entered. It is safe to call on a
+// nil *FindingsMonitor.
+func (m *FindingsMonitor) Active() bool {
+	if m == nil {
+		return false
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.active
+}
+
+// RequestStop records that monitor mode was stopped on request
+// and returns a boolean indicating if monitor mode is active.
+func (m *FindingsMonitor) RequestStop() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.active {
+		return false
+	}
+
+	m.stopRequested = true
+	return true
+}
+
+// StopRequested returns a boolean indicating if
+// monitor mode was stopped on request.
+func (m *FindingsMonitor) StopRequested() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.stopRequested
+}
+
+// ReconciliationFailed records a reconciliation failure.
+func (m *FindingsMonitor) ReconciliationFailed(
+	reconciliationType string,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	computedBalance string,
+	liveBalance string,
+	block *types.BlockIdentifier,
+) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	color.Red(
+		"[MONITOR] %s reconciliation failure for %s at %d (computed: %s, live: %s)",
+		reconciliationType,
+		types.PrintStruct(account),
+		block.Index,
+		computedBalance,
+		liveBalance,
+	)
+
+	m.results.Failures++
+	m.results.Findings = append(m.results.Findings, &results.MonitorFinding{
+		Timestamp:          utils.Milliseconds(),
+		ReconciliationType: reconciliationType,
+		Account:            account,
+		Currency:           currency,
+		ComputedBalance:    computedBalance,
+		LiveBalance:        liveBalance,
+		Block:              block,
+	})
+	if len(m.results.Findings) > MaxMonitorFindings {
+		m.results.Findings = m.results.Findings[len(m.results.Findings)-MaxMonitorFindings:]
+	}
+
+	m.write()
+}
+
+// FindingsMonitorResults returns a copy of the current *FindingsMonitorResults or nil
+// if monitor mode has not been entered. It is safe to
+// call on a nil *FindingsMonitor.
+func (m *FindingsMonitor) Results() *results.FindingsMonitorResults {
+	if m == nil {
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.results == nil {
+		return nil
+	}
+
+	monitorResults := *m.results
+	monitorResults.Findings = append([]*results.MonitorFinding{}, m.results.Findings...)
+	return &monitorResults
+}
+
+// Stop records err (if not nil), writes the final
+// results, and returns them.
+func (m *FindingsMonitor) Stop(err error) *results.FindingsMonitorResults {
+	m.lock.Lock()
+	if err != nil {
+		m.results.Error = fmt.Sprintf("%+v", err)
+	}
+	m.write()
+	m.lock.Unlock()
+
+	return m.Results()
+}
+
+// write saves the results to outputFile. The caller
+// must hold the lock.
+func (m *FindingsMonitor) write() {
+	if len(m.outputFile) == 0 {
+		return
+	}
+
+	if err := utils.SerializeAndWrite(m.outputFile, m.results); err != nil {
+		log.Printf("%s: unable to save monitor results\n", err.Error())
+	}
+}
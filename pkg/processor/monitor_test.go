@@ -0,0 +1,104 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindingsMonitor(t *testing.T) {
+	var tests = map[string]struct {
+		failures int
+		err      error
+
+		expectedFindings int
+		expectedFirst    int64
+		expectedError    string
+	}{
+		"no failures": {
+			expectedFindings: 0,
+		},
+		"failures": {
+			failures:         3,
+			expectedFindings: 3,
+		},
+		"rolling findings": {
+			failures:         MaxMonitorFindings + 5,
+			expectedFindings: MaxMonitorFindings,
+			expectedFirst:    5,
+		},
+		"error": {
+			failures:         1,
+			err:              errors.New("syncer failed"),
+			expectedFindings: 1,
+			expectedError:    "syncer failed",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			outputFile := path.Join(dir, "monitor.json")
+			m := NewFindingsMonitor(outputFile)
+			assert.False(t, m.Active())
+			assert.Nil(t, m.Results())
+
+			m.Start()
+			assert.True(t, m.Active())
+
+			for i := 0; i < test.failures; i++ {
+				m.ReconciliationFailed(
+					"ACTIVE",
+					&types.AccountIdentifier{Address: "addr1"},
+					&types.Currency{Symbol: "BTC", Decimals: 8},
+					"100",
+					"90",
+					&types.BlockIdentifier{Index: int64(i), Hash: fmt.Sprintf("block %d", i)},
+				)
+			}
+
+			monitorResults := m.Stop(test.err)
+			assert.Equal(t, int64(test.failures), monitorResults.Failures)
+			assert.Len(t, monitorResults.Findings, test.expectedFindings)
+			if test.expectedFindings > 0 {
+				assert.Equal(t, test.expectedFirst, monitorResults.Findings[0].Block.Index)
+			}
+			assert.Equal(t, test.expectedError, monitorResults.Error)
+
+			// The output file always contains the latest results.
+			var written results.FindingsMonitorResults
+			assert.NoError(t, utils.LoadAndParse(outputFile, &written))
+			assert.Equal(t, monitorResults, &written)
+		})
+	}
+}
+
+func TestFindingsMonitorNil(t *testing.T) {
+	var m *FindingsMonitor
+	assert.False(t, m.Active())
+	assert.Nil(t, m.Results())
+}
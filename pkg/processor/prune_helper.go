@@ -0,0 +1,108 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/statefulsyncer"
+)
+
+var _ statefulsyncer.PruneHelper = (*PruneHelper)(nil)
+
+// PruneHelper implements the statefulsyncer.PruneHelper
+// interface.
+type PruneHelper struct {
+	depth    int64
+	interval int64
+	minDepth int64
+
+	lastIndex   int64
+	initialized bool
+	escalated   bool
+	prunedSince bool
+	mutex       sync.Mutex
+}
+
+// NewPruneHelper returns a new *PruneHelper that retains
+// at least depth blocks behind the head block and only
+// advances the pruneable index once it has moved at
+// least interval blocks since the last prune pass. When
+// escalated, it retains only minDepth blocks and prunes
+// on every pass.
+func NewPruneHelper(depth int64, interval int64, minDepth int64) *PruneHelper {
+	return &PruneHelper{
+		depth:    depth,
+		interval: interval,
+		minDepth: minDepth,
+	}
+}
+
+// Escalate tightens pruning to retain only minDepth
+// blocks on every prune pass. It returns false if
+// pruning was already escalated.
+func (h *PruneHelper) Escalate() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.escalated {
+		return false
+	}
+
+	h.depth = h.minDepth
+	h.interval = 0
+	h.escalated = true
+	return true
+}
+
+// PrunedSinceEscalation returns a boolean indicating
+// if a prune pass has run since pruning was escalated.
+func (h *PruneHelper) PrunedSinceEscalation() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.prunedSince
+}
+
+// PruneableIndex is the index that is
+// safe for pruning.
+func (h *PruneHelper) PruneableIndex(
+	ctx context.Context,
+	headIndex int64,
+) (int64, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// We don't need blocks to exist to reconcile
+	// balances at their index.
+	//
+	// It is ok if the returned value here is negative.
+	index := headIndex - h.depth
+	if h.escalated {
+		h.prunedSince = true
+	}
+
+	if h.initialized && index-h.lastIndex < h.interval {
+		// Returning an index that was already pruned
+		// is a no-op.
+		return h.lastIndex, nil
+	}
+
+	h.lastIndex = index
+	h.initialized = true
+
+	return index, nil
+}
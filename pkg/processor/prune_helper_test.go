@@ -0,0 +1,99 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneableIndex(t *testing.T) {
+	var tests = map[string]struct {
+		depth    int64
+		interval int64
+
+		heads    []int64
+		expected []int64
+	}{
+		"below depth": {
+			depth:    100,
+			interval: 1,
+			heads:    []int64{0, 50, 99, 100},
+			expected: []int64{-100, -50, -1, 0},
+		},
+		"every block": {
+			depth:    10,
+			interval: 1,
+			heads:    []int64{20, 21, 21, 25},
+			expected: []int64{10, 11, 11, 15},
+		},
+		"interval not reached": {
+			depth:    10,
+			interval: 5,
+			heads:    []int64{20, 21, 24},
+			expected: []int64{10, 10, 10},
+		},
+		"interval boundary": {
+			depth:    10,
+			interval: 5,
+			heads:    []int64{20, 24, 25, 29, 31},
+			expected: []int64{10, 10, 15, 15, 21},
+		},
+		"zero interval": {
+			depth:    10,
+			interval: 0,
+			heads:    []int64{20, 21},
+			expected: []int64{10, 11},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			helper := NewPruneHelper(test.depth, test.interval, test.depth)
+			for i, head := range test.heads {
+				index, err := helper.PruneableIndex(context.Background(), head)
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected[i], index)
+
+				// Blocks in (index, head] are never pruned, so
+				// at least depth blocks are always retained.
+				assert.GreaterOrEqual(t, head-index, test.depth)
+			}
+		})
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	ctx := context.Background()
+	helper := NewPruneHelper(100, 10, 5)
+
+	index, err := helper.PruneableIndex(ctx, 200)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), index)
+	assert.False(t, helper.PrunedSinceEscalation())
+
+	assert.True(t, helper.Escalate())
+	assert.False(t, helper.Escalate())
+	assert.False(t, helper.PrunedSinceEscalation())
+
+	// The interval is no longer enforced and only
+	// minDepth blocks are retained.
+	index, err = helper.PruneableIndex(ctx, 201)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(196), index)
+	assert.True(t, helper.PrunedSinceEscalation())
+}
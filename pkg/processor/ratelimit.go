@@ -0,0 +1,79 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that is refilled at a fixed
+// rate and holds at most a single token. This spreads
+// events out evenly instead of allowing bursts.
+type RateLimiter struct {
+	interval time.Duration
+
+	lock sync.Mutex
+
+	// next is the time at which the next
+	// token will be available.
+	next time.Time
+}
+
+// NewRateLimiter returns a new *RateLimiter that allows tps
+// events per second. If tps is not positive,
+// nil is returned (which never limits).
+func NewRateLimiter(tps float64) *RateLimiter {
+	if tps <= 0 {
+		return nil
+	}
+
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / tps),
+	}
+}
+
+// Wait blocks until a token is available or ctx
+// is canceled. Waiting on a nil *RateLimiter returns
+// immediately.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.lock.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.lock.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
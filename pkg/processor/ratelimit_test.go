@@ -0,0 +1,72 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var tests = map[string]struct {
+		tps    float64
+		events int
+
+		expectedMin time.Duration
+		expectedMax time.Duration
+	}{
+		"unlimited": {
+			tps:         0,
+			events:      100,
+			expectedMax: 100 * time.Millisecond,
+		},
+		"limited": {
+			tps:         20,
+			events:      5,
+			expectedMin: 200 * time.Millisecond,
+			expectedMax: time.Second,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			l := NewRateLimiter(test.tps)
+
+			start := time.Now()
+			for i := 0; i < test.events; i++ {
+				assert.NoError(t, l.Wait(ctx))
+			}
+			elapsed := time.Since(start)
+
+			assert.GreaterOrEqual(t, elapsed, test.expectedMin)
+			assert.Less(t, elapsed, test.expectedMax)
+		})
+	}
+}
+
+func TestRateLimiterCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := NewRateLimiter(0.1)
+
+	// The first token is available immediately.
+	assert.NoError(t, l.Wait(ctx))
+
+	cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+}
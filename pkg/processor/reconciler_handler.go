@@ -21,7 +21,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/coinbase/rosetta-cli/configuration"
 	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
 	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/reconciler"
@@ -51,12 +53,23 @@ type ReconcilerHandler struct {
 	counterStorage            *modules.CounterStorage
 	balanceStorage            *modules.BalanceStorage
 	haltOnReconciliationError bool
+	failureHook               *FailureHook
+	monitor                   *FindingsMonitor
+	acceptableDiscrepancies   []*configuration.AcceptableDiscrepancy
+	balanceExemptions         []*configuration.BalanceExemption
+	balanceExemptionsCoverage bool
+	failures                  *DiscrepancyTracker
+	nonFatalErrors            *nonfatal.Tracker
 
 	InactiveFailure      *types.AccountCurrency
 	InactiveFailureBlock *types.BlockIdentifier
 
 	ActiveFailureBlock *types.BlockIdentifier
 
+	// HaltFailure is the reconciliation failure
+	// that caused check:data to halt (if any).
+	HaltFailure *results.DiscrepancyFailure
+
 	counterLock sync.Mutex
 	counts      map[string]int64
 }
@@ -67,6 +80,13 @@ func NewReconcilerHandler(
 	counterStorage *modules.CounterStorage,
 	balanceStorage *modules.BalanceStorage,
 	haltOnReconciliationError bool,
+	failureHook *FailureHook,
+	monitor *FindingsMonitor,
+	acceptableDiscrepancies []*configuration.AcceptableDiscrepancy,
+	balanceExemptions []*configuration.BalanceExemption,
+	balanceExemptionsCoverage bool,
+	failures *DiscrepancyTracker,
+	nonFatalErrors *nonfatal.Tracker,
 ) *ReconcilerHandler {
 	counts := map[string]int64{}
 	for _, key := range countKeys {
@@ -78,6 +98,13 @@ func NewReconcilerHandler(
 		counterStorage:            counterStorage,
 		balanceStorage:            balanceStorage,
 		haltOnReconciliationError: haltOnReconciliationError,
+		failureHook:               failureHook,
+		monitor:                   monitor,
+		acceptableDiscrepancies:   acceptableDiscrepancies,
+		balanceExemptions:         balanceExemptions,
+		balanceExemptionsCoverage: balanceExemptionsCoverage,
+		failures:                  failures,
+		nonFatalErrors:            nonFatalErrors,
 		counts:                    counts,
 	}
 }
@@ -119,9 +146,120 @@ func (h *ReconcilerHandler) UpdateCounts(ctx context.Context) error {
 	return nil
 }
 
+// Succeeded returns the number of successful active and
+// inactive reconciliations (including those not yet
+// written to modules).
+func (h *ReconcilerHandler) Succeeded(ctx context.Context) (int64, error) {
+	succeeded := int64(0)
+	for _, key := range []string{
+		modules.ActiveReconciliationCounter,
+		modules.InactiveReconciliationCounter,
+	} {
+		count, err := h.counterStorage.Get(ctx, key)
+		if err != nil {
+			return -1, fmt.Errorf("%w: cannot get %s counter", err, key)
+		}
+
+		h.counterLock.Lock()
+		succeeded += count.Int64() + h.counts[key]
+		h.counterLock.Unlock()
+	}
+
+	return succeeded, nil
+}
+
+// acceptableDiscrepancy returns true if the difference between
+// computedBalance and liveBalance is within an acceptable
+// discrepancy of account and currency.
+func (h *ReconcilerHandler) acceptableDiscrepancy(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	computedBalance string,
+	liveBalance string,
+) (bool, error) {
+	for _, discrepancy := range h.acceptableDiscrepancies {
+		if types.Hash(discrepancy.Account) != types.Hash(account) ||
+			types.Hash(discrepancy.Currency) != types.Hash(currency) {
+			continue
+		}
+
+		difference, err := types.SubtractValues(computedBalance, liveBalance)
+		if err != nil {
+			return false, fmt.Errorf("%w: unable to compute discrepancy", err)
+		}
+
+		delta, err := types.BigInt(difference)
+		if err != nil {
+			return false, fmt.Errorf("%w: unable to parse discrepancy", err)
+		}
+
+		maxDelta, err := types.BigInt(discrepancy.MaxDelta)
+		if err != nil {
+			return false, fmt.Errorf("%w: unable to parse max_delta", err)
+		}
+
+		if delta.Abs(delta).Cmp(maxDelta) <= 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// balanceExemption returns the first balance exemption of account
+// and currency that tolerates the delta of liveBalance from
+// computedBalance (or nil if none do) and the delta.
+func (h *ReconcilerHandler) balanceExemption(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	computedBalance string,
+	liveBalance string,
+) (*configuration.BalanceExemption, string, error) {
+	if len(h.balanceExemptions) == 0 {
+		return nil, "", nil
+	}
+
+	delta, err := types.SubtractValues(liveBalance, computedBalance)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: unable to compute discrepancy", err)
+	}
+
+	bigDelta, err := types.BigInt(delta)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: unable to parse discrepancy", err)
+	}
+
+	for _, exemption := range h.balanceExemptions {
+		if exemption.Account != nil && !AccountMatches(exemption.Account, account) {
+			continue
+		}
+
+		if exemption.SubAccountAddress != nil && (account.SubAccount == nil ||
+			account.SubAccount.Address != *exemption.SubAccountAddress) {
+			continue
+		}
+
+		if exemption.Currency != nil && types.Hash(exemption.Currency) != types.Hash(currency) {
+			continue
+		}
+
+		if exemption.ExemptionType == types.BalanceDynamic ||
+			(exemption.ExemptionType == types.BalanceGreaterOrEqual && bigDelta.Sign() >= 0) ||
+			(exemption.ExemptionType == types.BalanceLessOrEqual && bigDelta.Sign() <= 0) {
+			return exemption, delta, nil
+		}
+	}
+
+	return nil, delta, nil
+}
+
 // ReconciliationFailed is called each time a reconciliation fails.
 // In this Handler implementation, we halt if haltOnReconciliationError
-// was set to true. We also cancel the context.
+// was set to true and failures exceed the threshold or rate of
+// failures (if populated). We also cancel the context. Failures
+// found in monitor mode are recorded but never halt. Failures
+// within an acceptable discrepancy or tolerated by a balance
+// exemption are considered exempt.
 func (h *ReconcilerHandler) ReconciliationFailed(
 	ctx context.Context,
 	reconciliationType string,
@@ -131,11 +269,48 @@ func (h *ReconcilerHandler) ReconciliationFailed(
 	liveBalance string,
 	block *types.BlockIdentifier,
 ) error {
+	acceptable, err := h.acceptableDiscrepancy(account, currency, computedBalance, liveBalance)
+	if err != nil {
+		return err
+	}
+
+	if acceptable {
+		return h.ReconciliationExempt(
+			ctx,
+			reconciliationType,
+			account,
+			currency,
+			computedBalance,
+			liveBalance,
+			block,
+			nil,
+		)
+	}
+
+	exemption, delta, err := h.balanceExemption(account, currency, computedBalance, liveBalance)
+	if err != nil {
+		return err
+	}
+
+	if exemption != nil {
+		return h.balanceExempt(
+			ctx,
+			reconciliationType,
+			account,
+			currency,
+			computedBalance,
+			liveBalance,
+			delta,
+			exemption,
+			block,
+		)
+	}
+
 	h.counterLock.Lock()
 	h.counts[modules.FailedReconciliationCounter]++
 	h.counterLock.Unlock()
 
-	err := h.logger.ReconcileFailureStream(
+	err = h.logger.ReconcileFailureStream(
 		ctx,
 		reconciliationType,
 		account,
@@ -148,9 +323,43 @@ func (h *ReconcilerHandler) ReconciliationFailed(
 		return err
 	}
 
-	if h.haltOnReconciliationError {
+	if h.failureHook != nil {
+		h.failureHook.ReconciliationFailed(
+			reconciliationType,
+			account,
+			currency,
+			computedBalance,
+			liveBalance,
+			block,
+		)
+	}
+
+	if h.monitor.Active() {
+		h.monitor.ReconciliationFailed(
+			reconciliationType,
+			account,
+			currency,
+			computedBalance,
+			liveBalance,
+			block,
+		)
+
+		return nil
+	}
+
+	failure := &results.DiscrepancyFailure{
+		ReconciliationType: reconciliationType,
+		Account:            account,
+		Currency:           currency,
+		ComputedBalance:    computedBalance,
+		LiveBalance:        liveBalance,
+		Block:              block,
+	}
+	halt := h.failures.Failed(failure)
+	if h.haltOnReconciliationError && halt {
 		// Update counts before exiting
 		_ = h.UpdateCounts(ctx)
+		h.HaltFailure = failure
 
 		if reconciliationType == reconciler.InactiveReconciliation {
 			// Populate inactive failure information so we can try to find block with
@@ -186,6 +395,7 @@ func (h *ReconcilerHandler) ReconciliationFailed(
 		)
 	}
 
+	h.nonFatalErrors.Record(nonfatal.ReconciliationFailure)
 	return nil
 }
 
@@ -204,6 +414,8 @@ func (h *ReconcilerHandler) ReconciliationExempt(
 	h.counterLock.Lock()
 	h.counts[modules.ExemptReconciliationCounter]++
 	h.counterLock.Unlock()
+	h.failures.Attempted()
+	h.nonFatalErrors.Record(nonfatal.ExemptedAssertion)
 
 	// Although the reconciliation was exempt (non-zero difference that was ignored),
 	// we still mark the account as being reconciled because the balance was in the range
@@ -215,6 +427,45 @@ func (h *ReconcilerHandler) ReconciliationExempt(
 	return nil
 }
 
+// balanceExempt logs a failed reconciliation tolerated by exemption
+// and counts it as exempt. The account is only considered reconciled
+// if balanceExemptionsCoverage is true.
+func (h *ReconcilerHandler) balanceExempt(
+	ctx context.Context,
+	reconciliationType string,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	computedBalance string,
+	liveBalance string,
+	delta string,
+	exemption *configuration.BalanceExemption,
+	block *types.BlockIdentifier,
+) error {
+	h.counterLock.Lock()
+	h.counts[modules.ExemptReconciliationCounter]++
+	h.counterLock.Unlock()
+	h.failures.Attempted()
+	h.nonFatalErrors.Record(nonfatal.ExemptedAssertion)
+
+	if h.balanceExemptionsCoverage {
+		if err := h.balanceStorage.Reconciled(ctx, account, currency, block); err != nil {
+			return fmt.Errorf("%w: unable to store updated reconciliation", err)
+		}
+	}
+
+	return h.logger.ReconcileExemptStream(
+		ctx,
+		reconciliationType,
+		account,
+		currency,
+		computedBalance,
+		liveBalance,
+		delta,
+		exemption.ExemptionType,
+		block,
+	)
+}
+
 // ReconciliationSkipped is called each time a reconciliation is skipped.
 func (h *ReconcilerHandler) ReconciliationSkipped(
 	ctx context.Context,
@@ -248,6 +499,7 @@ func (h *ReconcilerHandler) ReconciliationSucceeded(
 	h.counterLock.Lock()
 	h.counts[counter]++
 	h.counterLock.Unlock()
+	h.failures.Attempted()
 
 	if err := h.balanceStorage.Reconciled(ctx, account, currency, block); err != nil {
 		return fmt.Errorf("%w: unable to store updated reconciliation", err)
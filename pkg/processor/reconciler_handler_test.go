@@ -0,0 +1,552 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestReconciliationFailedAcceptableDiscrepancy(t *testing.T) {
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+	systemAccount := &types.AccountIdentifier{Address: "system"}
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	acceptableDiscrepancies := []*configuration.AcceptableDiscrepancy{
+		{
+			Account:  systemAccount,
+			Currency: btc,
+			MaxDelta: "5",
+		},
+	}
+
+	var tests = map[string]struct {
+		account  *types.AccountIdentifier
+		currency *types.Currency
+		computed string
+		live     string
+
+		expectedErr    error
+		expectedExempt int64
+		expectedFailed int64
+	}{
+		"within bound": {
+			account:        systemAccount,
+			currency:       btc,
+			computed:       "100",
+			live:           "95",
+			expectedExempt: 1,
+		},
+		"within bound (negative)": {
+			account:        systemAccount,
+			currency:       btc,
+			computed:       "100",
+			live:           "103",
+			expectedExempt: 1,
+		},
+		"out of bound": {
+			account:        systemAccount,
+			currency:       btc,
+			computed:       "100",
+			live:           "94",
+			expectedErr:    results.ErrReconciliationFailure,
+			expectedFailed: 1,
+		},
+		"other account": {
+			account:        &types.AccountIdentifier{Address: "addr1"},
+			currency:       btc,
+			computed:       "100",
+			live:           "99",
+			expectedErr:    results.ErrReconciliationFailure,
+			expectedFailed: 1,
+		},
+		"other currency": {
+			account:        systemAccount,
+			currency:       &types.Currency{Symbol: "ETH", Decimals: 18},
+			computed:       "100",
+			live:           "99",
+			expectedErr:    results.ErrReconciliationFailure,
+			expectedFailed: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			l, err := logger.NewLogger(
+				dir,
+				false,
+				false,
+				false,
+				false,
+				zapcore.ErrorLevel,
+				configuration.TextLogFormat,
+				logger.Data,
+				network,
+			)
+			assert.NoError(t, err)
+
+			counterStorage := modules.NewCounterStorage(db)
+			nonFatalErrors := nonfatal.New(0)
+			handler := NewReconcilerHandler(
+				l,
+				counterStorage,
+				modules.NewBalanceStorage(db),
+				true,
+				nil,
+				nil,
+				acceptableDiscrepancies,
+				nil,
+				false,
+				nil,
+				nonFatalErrors,
+			)
+
+			err = handler.ReconciliationFailed(
+				ctx,
+				reconciler.ActiveReconciliation,
+				test.account,
+				test.currency,
+				test.computed,
+				test.live,
+				&types.BlockIdentifier{Index: 10, Hash: "block 10"},
+			)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, handler.UpdateCounts(ctx))
+			exempt, err := counterStorage.Get(ctx, modules.ExemptReconciliationCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedExempt, exempt.Int64())
+
+			failed, err := counterStorage.Get(ctx, modules.FailedReconciliationCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedFailed, failed.Int64())
+
+			// Only exempted failures are counted as non-fatal errors
+			// (the other failures halt check:data).
+			if test.expectedExempt > 0 {
+				assert.Equal(t, &nonfatal.Results{
+					Total: test.expectedExempt,
+					Counts: []*nonfatal.Count{
+						{Kind: nonfatal.ExemptedAssertion, Count: test.expectedExempt},
+					},
+				}, nonFatalErrors.Results())
+			} else {
+				assert.Nil(t, nonFatalErrors.Results())
+			}
+		})
+	}
+}
+
+func TestReconciliationFailedThreshold(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+	l, err := logger.NewLogger(
+		dir,
+		false,
+		false,
+		false,
+		false,
+		zapcore.ErrorLevel,
+		configuration.TextLogFormat,
+		logger.Data,
+		network,
+	)
+	assert.NoError(t, err)
+
+	failures := NewDiscrepancyTracker(1, 0)
+	nonFatalErrors := nonfatal.New(0)
+	handler := NewReconcilerHandler(
+		l,
+		modules.NewCounterStorage(db),
+		modules.NewBalanceStorage(db),
+		true,
+		nil,
+		nil,
+		nil,
+		nil,
+		false,
+		failures,
+		nonFatalErrors,
+	)
+
+	account := &types.AccountIdentifier{Address: "addr1"}
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	block := &types.BlockIdentifier{Index: 10, Hash: "block 10"}
+	assert.NoError(t, handler.ReconciliationSucceeded(
+		ctx,
+		reconciler.ActiveReconciliation,
+		account,
+		btc,
+		"100",
+		block,
+	))
+
+	// The first failure is tolerated.
+	assert.NoError(t, handler.ReconciliationFailed(
+		ctx,
+		reconciler.ActiveReconciliation,
+		account,
+		btc,
+		"100",
+		"99",
+		block,
+	))
+	assert.Nil(t, handler.ActiveFailureBlock)
+	assert.Nil(t, handler.HaltFailure)
+	assert.Equal(t, int64(1), nonFatalErrors.Results().Total)
+
+	err = handler.ReconciliationFailed(
+		ctx,
+		reconciler.ActiveReconciliation,
+		account,
+		btc,
+		"100",
+		"98",
+		block,
+	)
+	assert.True(t, errors.Is(err, results.ErrReconciliationFailure))
+	assert.Equal(t, block, handler.ActiveFailureBlock)
+	assert.Equal(t, "98", handler.HaltFailure.LiveBalance)
+
+	// The failure that halts check:data is not non-fatal.
+	assert.Equal(t, int64(1), nonFatalErrors.Results().Total)
+
+	failureResults := failures.Results()
+	assert.Equal(t, int64(2), failureResults.Count)
+	assert.Equal(t, int64(3), failureResults.Attempted)
+	assert.Len(t, failureResults.Failures, 2)
+	assert.Equal(t, "98", failureResults.Failures[1].LiveBalance)
+}
+
+func TestReconcilerHandlerSucceeded(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+	l, err := logger.NewLogger(
+		dir,
+		false,
+		false,
+		false,
+		false,
+		zapcore.ErrorLevel,
+		configuration.TextLogFormat,
+		logger.Data,
+		network,
+	)
+	assert.NoError(t, err)
+
+	handler := NewReconcilerHandler(
+		l,
+		modules.NewCounterStorage(db),
+		modules.NewBalanceStorage(db),
+		true,
+		nil,
+		nil,
+		nil,
+		nil,
+		false,
+		nil,
+		nil,
+	)
+
+	succeeded, err := handler.Succeeded(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), succeeded)
+
+	account := &types.AccountIdentifier{Address: "addr1"}
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	block := &types.BlockIdentifier{Index: 10, Hash: "block 10"}
+	for _, reconciliationType := range []string{
+		reconciler.ActiveReconciliation,
+		reconciler.ActiveReconciliation,
+		reconciler.InactiveReconciliation,
+	} {
+		assert.NoError(t, handler.ReconciliationSucceeded(
+			ctx,
+			reconciliationType,
+			account,
+			btc,
+			"100",
+			block,
+		))
+	}
+
+	// Pending counts are included before they are written.
+	succeeded, err = handler.Succeeded(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), succeeded)
+
+	assert.NoError(t, handler.UpdateCounts(ctx))
+	succeeded, err = handler.Succeeded(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), succeeded)
+}
+
+func TestReconciliationFailedBalanceExemption(t *testing.T) {
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	eth := &types.Currency{Symbol: "ETH", Decimals: 18}
+	validator := &types.AccountIdentifier{Address: "validator"}
+	staking := &types.AccountIdentifier{
+		Address:    "validator",
+		SubAccount: &types.SubAccountIdentifier{Address: "staking"},
+	}
+	vesting := &types.AccountIdentifier{
+		Address:    "addr1",
+		SubAccount: &types.SubAccountIdentifier{Address: "vesting"},
+	}
+	balanceExemptions := []*configuration.BalanceExemption{
+		{
+			Account:       validator,
+			Currency:      btc,
+			ExemptionType: types.BalanceGreaterOrEqual,
+		},
+		{
+			SubAccountAddress: types.String("vesting"),
+			ExemptionType:     types.BalanceLessOrEqual,
+		},
+		{
+			Currency:      eth,
+			ExemptionType: types.BalanceDynamic,
+		},
+	}
+
+	var tests = map[string]struct {
+		account  *types.AccountIdentifier
+		currency *types.Currency
+		live     string
+		coverage bool
+
+		expectedErr        error
+		expectedExempt     int64
+		expectedReconciled bool
+	}{
+		"greater": {
+			account:        validator,
+			currency:       btc,
+			live:           "105",
+			expectedExempt: 1,
+		},
+		"greater (sub-account of address)": {
+			account:        staking,
+			currency:       btc,
+			live:           "105",
+			expectedExempt: 1,
+		},
+		"greater (counted as reconciled)": {
+			account:            validator,
+			currency:           btc,
+			live:               "105",
+			coverage:           true,
+			expectedExempt:     1,
+			expectedReconciled: true,
+		},
+		"less (wrong direction)": {
+			account:     validator,
+			currency:    btc,
+			live:        "95",
+			expectedErr: results.ErrReconciliationFailure,
+		},
+		"sub-account address": {
+			account:        vesting,
+			currency:       btc,
+			live:           "95",
+			expectedExempt: 1,
+		},
+		"sub-account address (wrong direction)": {
+			account:     vesting,
+			currency:    btc,
+			live:        "105",
+			expectedErr: results.ErrReconciliationFailure,
+		},
+		"dynamic currency": {
+			account:        &types.AccountIdentifier{Address: "addr2"},
+			currency:       eth,
+			live:           "1",
+			expectedExempt: 1,
+		},
+		"no match": {
+			account:     &types.AccountIdentifier{Address: "addr2"},
+			currency:    btc,
+			live:        "105",
+			expectedErr: results.ErrReconciliationFailure,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			l, err := logger.NewLogger(
+				dir,
+				false,
+				false,
+				false,
+				true,
+				zapcore.ErrorLevel,
+				configuration.TextLogFormat,
+				logger.Data,
+				network,
+			)
+			assert.NoError(t, err)
+
+			counterStorage := modules.NewCounterStorage(db)
+			balanceStorage := modules.NewBalanceStorage(db)
+			nonFatalErrors := nonfatal.New(0)
+			balanceStorage.Initialize(
+				NewBalanceStorageHelper(
+					nil,
+					&fetcher.Fetcher{},
+					counterStorage,
+					false,
+					nil,
+					false,
+					nil,
+					false,
+					nil,
+				),
+				NewBalanceStorageHandler(l, nil, counterStorage, false, nil, nil, nil, nil),
+			)
+
+			block := &types.BlockIdentifier{Index: 10, Hash: "block 10"}
+			dbTx := db.Transaction(ctx)
+			assert.NoError(t, balanceStorage.SetBalance(
+				ctx,
+				dbTx,
+				test.account,
+				&types.Amount{Value: "100", Currency: test.currency},
+				block,
+			))
+			assert.NoError(t, dbTx.Commit(ctx))
+
+			handler := NewReconcilerHandler(
+				l,
+				counterStorage,
+				balanceStorage,
+				true,
+				nil,
+				nil,
+				nil,
+				balanceExemptions,
+				test.coverage,
+				nil,
+				nonFatalErrors,
+			)
+
+			err = handler.ReconciliationFailed(
+				ctx,
+				reconciler.ActiveReconciliation,
+				test.account,
+				test.currency,
+				"100",
+				test.live,
+				block,
+			)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, handler.UpdateCounts(ctx))
+			exempt, err := counterStorage.Get(ctx, modules.ExemptReconciliationCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedExempt, exempt.Int64())
+
+			// Exempted failures are counted as non-fatal errors.
+			if test.expectedExempt > 0 {
+				nonFatalResults := nonFatalErrors.Results()
+				assert.Equal(t, test.expectedExempt, nonFatalResults.Total)
+				assert.Equal(t, nonfatal.ExemptedAssertion, nonFatalResults.Counts[0].Kind)
+			} else {
+				assert.Nil(t, nonFatalErrors.Results())
+			}
+
+			coverage, err := balanceStorage.ReconciliationCoverage(ctx, 0)
+			assert.NoError(t, err)
+			if test.expectedReconciled {
+				assert.Equal(t, float64(1), coverage)
+			} else {
+				assert.Equal(t, float64(0), coverage)
+			}
+
+			if test.expectedExempt > 0 {
+				assert.FileExists(t, path.Join(dir, "exempt_reconciliations.txt"))
+			}
+		})
+	}
+}
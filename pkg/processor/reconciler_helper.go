@@ -16,8 +16,15 @@ package processor
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync/atomic"
+	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
 
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/reconciler"
@@ -29,17 +36,34 @@ import (
 
 var _ reconciler.Helper = (*ReconcilerHelper)(nil)
 
+const (
+	// InactiveFrequency is the inactive frequency of a
+	// reconciler.Reconciler that uses a ReconcilerHelper. It is
+	// never reached, so ForceInactiveReconciliation determines
+	// when an account is reconciled inactively (which allows
+	// inactive_reconciliation_frequency to change during a run).
+	InactiveFrequency = math.MaxInt64 / 2
+)
+
+var (
+	// ErrReconciliationTimeout is returned when an account balance
+	// fetch exceeds reconciliation_timeout.
+	ErrReconciliationTimeout = errors.New("reconciliation balance fetch timed out")
+)
+
 // ReconcilerHelper implements the Reconciler.Helper
 // interface.
 type ReconcilerHelper struct {
 	config *configuration.Configuration
 
-	network *types.NetworkIdentifier
-	fetcher *fetcher.Fetcher
+	network     *types.NetworkIdentifier
+	fetcher     *fetcher.Fetcher
+	skewMonitor *SkewMonitor
 
 	database                    database.Database
 	blockStorage                *modules.BlockStorage
 	balanceStorage              *modules.BalanceStorage
+	counterStorage              *modules.CounterStorage
 	forceInactiveReconciliation *bool
 }
 
@@ -48,18 +72,22 @@ func NewReconcilerHelper(
 	config *configuration.Configuration,
 	network *types.NetworkIdentifier,
 	fetcher *fetcher.Fetcher,
+	skewMonitor *SkewMonitor,
 	database database.Database,
 	blockStorage *modules.BlockStorage,
 	balanceStorage *modules.BalanceStorage,
+	counterStorage *modules.CounterStorage,
 	forceInactiveReconciliation *bool,
 ) *ReconcilerHelper {
 	return &ReconcilerHelper{
 		config:                      config,
 		network:                     network,
 		fetcher:                     fetcher,
+		skewMonitor:                 skewMonitor,
 		database:                    database,
 		blockStorage:                blockStorage,
 		balanceStorage:              balanceStorage,
+		counterStorage:              counterStorage,
 		forceInactiveReconciliation: forceInactiveReconciliation,
 	}
 }
@@ -87,14 +115,15 @@ func (h *ReconcilerHelper) CanonicalBlock(
 // index is at tip (provided some acceptable
 // tip delay). If the index is ahead of the head block
 // and the head block is at tip, we consider the
-// index at tip.
+// index at tip. TipDelay is corrected for clock skew
+// if configured.
 func (h *ReconcilerHelper) IndexAtTip(
 	ctx context.Context,
 	index int64,
 ) (bool, error) {
 	return h.blockStorage.IndexAtTip(
 		ctx,
-		h.config.TipDelay,
+		h.skewMonitor.AdjustTipDelay(atomic.LoadInt64(&h.config.TipDelay)),
 		index,
 	)
 }
@@ -122,15 +151,27 @@ func (h *ReconcilerHelper) ComputedBalance(
 	return h.balanceStorage.GetBalanceTransactional(ctx, dbTx, account, currency, index)
 }
 
-// LiveBalance returns the live balance of an account.
+// LiveBalance returns the live balance of an account. If
+// reconciliation_timeout is populated, the fetch is canceled
+// (and counted) once it is exceeded.
 func (h *ReconcilerHelper) LiveBalance(
 	ctx context.Context,
 	account *types.AccountIdentifier,
 	currency *types.Currency,
 	index int64,
 ) (*types.Amount, *types.BlockIdentifier, error) {
+	fetchCtx := ctx
+	if h.config.Data.ReconciliationTimeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(
+			ctx,
+			time.Duration(h.config.Data.ReconciliationTimeout)*time.Second,
+		)
+		defer cancel()
+	}
+
 	amt, block, err := utils.CurrencyBalance(
-		ctx,
+		fetchCtx,
 		h.network,
 		h.fetcher,
 		account,
@@ -138,11 +179,41 @@ func (h *ReconcilerHelper) LiveBalance(
 		index,
 	)
 	if err != nil {
+		if ctx.Err() == nil && errors.Is(fetchCtx.Err(), context.DeadlineExceeded) {
+			return nil, nil, h.reconciliationTimeout(ctx, account, currency)
+		}
+
 		return nil, nil, err
 	}
 	return amt, block, nil
 }
 
+// reconciliationTimeout counts a balance fetch that exceeded
+// reconciliation_timeout and returns ErrReconciliationTimeout.
+func (h *ReconcilerHelper) reconciliationTimeout(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) error {
+	if h.counterStorage != nil {
+		if _, err := h.counterStorage.Update(
+			ctx,
+			results.ReconciliationTimeoutCounter,
+			big.NewInt(1),
+		); err != nil {
+			return fmt.Errorf("%w: unable to update reconciliation timeout counter", err)
+		}
+	}
+
+	return fmt.Errorf(
+		"%w: %s %s after %ds",
+		ErrReconciliationTimeout,
+		types.PrintStruct(account),
+		types.PrintStruct(currency),
+		h.config.Data.ReconciliationTimeout,
+	)
+}
+
 // PruneBalances removes all historical balance states
 // <= some index. This can significantly reduce storage
 // usage in scenarios where historical balances are only
@@ -167,16 +238,29 @@ func (h *ReconcilerHelper) PruneBalances(
 
 // ForceInactiveReconciliation overrides the default
 // calculation to determine if an account should be
-// reconciled inactively.
+// reconciled inactively. An account is reconciled once
+// inactive_reconciliation_frequency blocks have been
+// synced since it was last checked (or whenever inactive
+// reconciliation is forced).
 func (h *ReconcilerHelper) ForceInactiveReconciliation(
 	ctx context.Context,
 	account *types.AccountIdentifier,
 	currency *types.Currency,
 	lastChecked *types.BlockIdentifier,
 ) bool {
-	if h.forceInactiveReconciliation == nil {
+	if h.forceInactiveReconciliation != nil && *h.forceInactiveReconciliation {
+		return true
+	}
+
+	if lastChecked == nil {
+		return true
+	}
+
+	head, err := h.blockStorage.GetHeadBlockIdentifier(ctx)
+	if err != nil {
 		return false
 	}
 
-	return *h.forceInactiveReconciliation
+	frequency := int64(atomic.LoadUint64(&h.config.Data.InactiveReconciliationFrequency))
+	return lastChecked.Index+frequency <= head.Index
 }
@@ -0,0 +1,204 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcilerHelperLiveBalanceTimeout(t *testing.T) {
+	network := &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+	account := &types.AccountIdentifier{Address: "addr1"}
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+
+	var tests = map[string]struct {
+		reconciliationTimeout uint64
+		delay                 time.Duration
+
+		expectedErr      error
+		expectedTimeouts int64
+	}{
+		"no timeout": {
+			delay: 100 * time.Millisecond,
+		},
+		"within timeout": {
+			reconciliationTimeout: 1,
+		},
+		"timeout": {
+			reconciliationTimeout: 1,
+			delay:                 3 * time.Second,
+			expectedErr:           ErrReconciliationTimeout,
+			expectedTimeouts:      1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "/account/balance", r.URL.Path)
+					select {
+					case <-time.After(test.delay):
+					case <-r.Context().Done():
+						return
+					}
+
+					w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+					assert.NoError(t, json.NewEncoder(w).Encode(&types.AccountBalanceResponse{
+						BlockIdentifier: &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+						Balances:        []*types.Amount{{Value: "100", Currency: currency}},
+					}))
+				}),
+			)
+			defer ts.Close()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			config := configuration.DefaultConfiguration()
+			config.Data.ReconciliationTimeout = test.reconciliationTimeout
+			counterStorage := modules.NewCounterStorage(db)
+			helper := NewReconcilerHelper(
+				config,
+				network,
+				fetcher.New(ts.URL, fetcher.WithMaxRetries(0)),
+				nil,
+				db,
+				nil,
+				nil,
+				counterStorage,
+				nil,
+			)
+
+			amount, block, err := helper.LiveBalance(ctx, account, currency, 10)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+				assert.Contains(t, err.Error(), "addr1")
+				assert.Nil(t, amount)
+				assert.Nil(t, block)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "100", amount.Value)
+				assert.Equal(t, int64(10), block.Index)
+			}
+
+			timeouts, err := counterStorage.Get(ctx, results.ReconciliationTimeoutCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedTimeouts, timeouts.Int64())
+		})
+	}
+}
+
+func TestReconcilerHelperForceInactiveReconciliation(t *testing.T) {
+	account := &types.AccountIdentifier{Address: "addr1"}
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+
+	var tests = map[string]struct {
+		frequency   uint64
+		force       bool
+		lastChecked *types.BlockIdentifier
+
+		expected bool
+	}{
+		"not checked": {
+			frequency: 100,
+			expected:  true,
+		},
+		"within frequency": {
+			frequency:   100,
+			lastChecked: &types.BlockIdentifier{Index: 50, Hash: "block 50"},
+		},
+		"frequency reached": {
+			frequency:   100,
+			lastChecked: &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+			expected:    true,
+		},
+		"forced": {
+			frequency:   100,
+			force:       true,
+			lastChecked: &types.BlockIdentifier{Index: 50, Hash: "block 50"},
+			expected:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			blockStorage := modules.NewBlockStorage(db, 1)
+			dbTx := db.WriteTransaction(ctx, "", true)
+			assert.NoError(t, blockStorage.StoreHeadBlockIdentifier(
+				ctx,
+				dbTx,
+				&types.BlockIdentifier{Index: 100, Hash: "block 100"},
+			))
+			assert.NoError(t, dbTx.Commit(ctx))
+
+			config := configuration.DefaultConfiguration()
+			config.Data.InactiveReconciliationFrequency = test.frequency
+			force := test.force
+			helper := NewReconcilerHelper(
+				config,
+				nil,
+				nil,
+				nil,
+				db,
+				blockStorage,
+				nil,
+				nil,
+				&force,
+			)
+
+			assert.Equal(t, test.expected, helper.ForceInactiveReconciliation(
+				ctx,
+				account,
+				currency,
+				test.lastChecked,
+			))
+		})
+	}
+}
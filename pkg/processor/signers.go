@@ -0,0 +1,133 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var (
+	// ErrUnexpectedSigningPayload is returned when a signing payload
+	// is for an account that is not a signer of the intent.
+	ErrUnexpectedSigningPayload = errors.New("unexpected signing payload")
+
+	// ErrSignerMismatch is returned when /construction/parse of a
+	// signed transaction does not return exactly the expected signers.
+	ErrSignerMismatch = errors.New("parsed signers do not match signing payloads")
+)
+
+// AllowedSigners returns the addresses permitted to sign
+// a transaction with intent. This is every address
+// in intent and in allowlist.
+//
+// Addresses are compared instead of full account identifiers
+// because implementations often omit sub-accounts and metadata
+// from signing payloads.
+func AllowedSigners(
+	intent []*types.Operation,
+	allowlist []*types.AccountIdentifier,
+) map[string]struct{} {
+	allowed := map[string]struct{}{}
+	for _, op := range intent {
+		if op.Account == nil {
+			continue
+		}
+
+		allowed[op.Account.Address] = struct{}{}
+	}
+
+	for _, account := range allowlist {
+		allowed[account.Address] = struct{}{}
+	}
+
+	return allowed
+}
+
+// UnexpectedSigningPayloads returns the addresses of all payloads
+// not in allowed (in the order they were returned).
+func UnexpectedSigningPayloads(
+	allowed map[string]struct{},
+	payloads []*types.SigningPayload,
+) []string {
+	unexpected := []string{}
+	for _, payload := range payloads {
+		if payload.AccountIdentifier == nil {
+			continue
+		}
+
+		if _, ok := allowed[payload.AccountIdentifier.Address]; !ok {
+			unexpected = append(unexpected, payload.AccountIdentifier.Address)
+		}
+	}
+
+	return unexpected
+}
+
+// ExpectedSigners returns the set of accounts that must sign
+// payloads, keyed by hash.
+func ExpectedSigners(payloads []*types.SigningPayload) map[string]*types.AccountIdentifier {
+	expected := map[string]*types.AccountIdentifier{}
+	for _, payload := range payloads {
+		expected[types.Hash(payload.AccountIdentifier)] = payload.AccountIdentifier
+	}
+
+	return expected
+}
+
+// CompareSigners returns the expected accounts missing from observed
+// and the observed accounts that were not expected. Accounts
+// are compared by their full identifier, like the
+// verification performed after /construction/combine.
+func CompareSigners(
+	expected map[string]*types.AccountIdentifier,
+	observed []*types.AccountIdentifier,
+) ([]string, []string) {
+	seen := map[string]struct{}{}
+	unexpected := []string{}
+	for _, signer := range observed {
+		hash := types.Hash(signer)
+		if _, ok := expected[hash]; !ok {
+			unexpected = append(unexpected, types.PrintStruct(signer))
+			continue
+		}
+
+		seen[hash] = struct{}{}
+	}
+
+	missing := []string{}
+	for hash, account := range expected {
+		if _, ok := seen[hash]; !ok {
+			missing = append(missing, types.PrintStruct(account))
+		}
+	}
+	sort.Strings(missing)
+
+	return missing, unexpected
+}
+
+// SignerMismatchError returns an error describing missing
+// and unexpected signers.
+func SignerMismatchError(missing []string, unexpected []string) error {
+	return fmt.Errorf(
+		"%w: missing signers %v, unexpected signers %v",
+		ErrSignerMismatch,
+		missing,
+		unexpected,
+	)
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	signersAddr1 = &types.AccountIdentifier{Address: "addr1"}
+	signersAddr2 = &types.AccountIdentifier{Address: "addr2"}
+	addr3        = &types.AccountIdentifier{Address: "addr3"}
+
+	intent = []*types.Operation{
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 0},
+			Type:                "transfer",
+			Account:             signersAddr1,
+		},
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 1},
+			Type:                "transfer",
+			Account: &types.AccountIdentifier{
+				Address:    "addr2",
+				SubAccount: &types.SubAccountIdentifier{Address: "stake"},
+			},
+		},
+	}
+)
+
+func TestUnexpectedSigningPayloads(t *testing.T) {
+	var tests = map[string]struct {
+		allowlist []*types.AccountIdentifier
+		payloads  []*types.SigningPayload
+
+		expected []string
+	}{
+		"intent signers": {
+			payloads: []*types.SigningPayload{
+				{AccountIdentifier: signersAddr1},
+				{AccountIdentifier: signersAddr2},
+			},
+			expected: []string{},
+		},
+		"unexpected signer": {
+			payloads: []*types.SigningPayload{
+				{AccountIdentifier: signersAddr1},
+				{AccountIdentifier: addr3},
+			},
+			expected: []string{"addr3"},
+		},
+		"allowed signer": {
+			allowlist: []*types.AccountIdentifier{addr3},
+			payloads: []*types.SigningPayload{
+				{AccountIdentifier: addr3},
+			},
+			expected: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(
+				t,
+				test.expected,
+				UnexpectedSigningPayloads(AllowedSigners(intent, test.allowlist), test.payloads),
+			)
+		})
+	}
+}
+
+func TestCompareSigners(t *testing.T) {
+	expected := ExpectedSigners([]*types.SigningPayload{
+		{AccountIdentifier: signersAddr1},
+		{AccountIdentifier: signersAddr1},
+		{AccountIdentifier: signersAddr2},
+	})
+
+	var tests = map[string]struct {
+		observed []*types.AccountIdentifier
+
+		expectedMissing    []string
+		expectedUnexpected []string
+	}{
+		"exact": {
+			observed:           []*types.AccountIdentifier{signersAddr2, signersAddr1},
+			expectedMissing:    []string{},
+			expectedUnexpected: []string{},
+		},
+		"missing": {
+			observed:           []*types.AccountIdentifier{signersAddr1},
+			expectedMissing:    []string{types.PrintStruct(signersAddr2)},
+			expectedUnexpected: []string{},
+		},
+		"unexpected": {
+			observed:           []*types.AccountIdentifier{signersAddr1, signersAddr2, addr3},
+			expectedMissing:    []string{},
+			expectedUnexpected: []string{types.PrintStruct(addr3)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			missing, unexpected := CompareSigners(expected, test.observed)
+			assert.Equal(t, test.expectedMissing, missing)
+			assert.Equal(t, test.expectedUnexpected, unexpected)
+		})
+	}
+
+	assert.True(t, errors.Is(SignerMismatchError([]string{"addr1"}, nil), ErrSignerMismatch))
+}
@@ -0,0 +1,257 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+const (
+	// SkewCheckInterval is the frequency that clock skew
+	// is re-estimated while a check is running.
+	SkewCheckInterval = 5 * time.Minute
+
+	// DateHeaderSource indicates a skew estimate was derived
+	// from the Date header returned by the implementation.
+	DateHeaderSource = "date_header"
+
+	// BlockTimestampSource indicates a skew estimate was derived
+	// from the timestamp of the implementation's latest block.
+	BlockTimestampSource = "block_timestamp"
+
+	// dateHeaderResolution is the precision of the HTTP Date
+	// header. Dates are truncated to the second, so we assume the
+	// response was generated halfway through the second.
+	dateHeaderResolution = time.Second
+
+	networkStatusPath = "/network/status"
+)
+
+var (
+	// ErrSkewUnavailable is returned when the implementation
+	// does not provide enough information to estimate clock skew.
+	ErrSkewUnavailable = errors.New("unable to estimate clock skew")
+)
+
+// SkewMonitor periodically estimates the clock skew between the
+// host running rosetta-cli and a Rosetta implementation and, if
+// configured, corrects tip delay calculations to account for it.
+type SkewMonitor struct {
+	url        string
+	network    *types.NetworkIdentifier
+	client     *http.Client
+	correction bool
+	threshold  time.Duration
+
+	estimateLock sync.RWMutex
+	estimate     *results.SkewEstimate
+}
+
+// NewSkewMonitor returns a new *SkewMonitor.
+func NewSkewMonitor(
+	url string,
+	network *types.NetworkIdentifier,
+	client *http.Client,
+	correction bool,
+	threshold time.Duration,
+) *SkewMonitor {
+	return &SkewMonitor{
+		url:        strings.TrimSuffix(url, "/"),
+		network:    network,
+		client:     client,
+		correction: correction,
+		threshold:  threshold,
+	}
+}
+
+// Estimate fetches /network/status from the implementation and
+// updates the current skew estimate. The Date header of the response
+// is preferred. If it is missing, the timestamp of the latest block
+// is used (this only provides a useful estimate when the block
+// appears to be produced in the future).
+func (m *SkewMonitor) Estimate(ctx context.Context) (*results.SkewEstimate, error) {
+	body, err := json.Marshal(&types.NetworkRequest{NetworkIdentifier: m.network})
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal network request", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		m.url+networkStatusPath,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to create network status request", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to fetch network status", err)
+	}
+	defer resp.Body.Close()
+	end := time.Now()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received %d status from %s", resp.StatusCode, networkStatusPath)
+	}
+
+	var status types.NetworkStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode network status", err)
+	}
+
+	// We assume the implementation handled the request halfway
+	// through the round trip.
+	local := start.Add(end.Sub(start) / 2) // nolint:gomnd
+	remote, source, err := implementationTime(resp.Header, &status, local)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &results.SkewEstimate{
+		SkewMilliseconds:   local.Sub(remote).Milliseconds(),
+		Source:             source,
+		LocalTime:          local.UTC().Format(time.RFC3339),
+		ImplementationTime: remote.UTC().Format(time.RFC3339),
+		Corrected:          m.correction,
+	}
+
+	m.estimateLock.Lock()
+	m.estimate = estimate
+	m.estimateLock.Unlock()
+
+	m.warn(estimate)
+
+	return estimate, nil
+}
+
+func implementationTime(
+	header http.Header,
+	status *types.NetworkStatusResponse,
+	local time.Time,
+) (time.Time, string, error) {
+	if date := header.Get("Date"); len(date) > 0 {
+		parsed, err := http.ParseTime(date)
+		if err == nil {
+			return parsed.Add(dateHeaderResolution / 2), DateHeaderSource, nil // nolint:gomnd
+		}
+	}
+
+	// A block produced before the local time tells us nothing about
+	// skew (the block could just be old), but a block produced in the
+	// future means the implementation clock is at least that far ahead.
+	blockTime := time.Unix(0, status.CurrentBlockTimestamp*int64(time.Millisecond))
+	if blockTime.After(local) {
+		return blockTime, BlockTimestampSource, nil
+	}
+
+	return time.Time{}, "", fmt.Errorf(
+		"%w: no Date header returned and latest block is not ahead of local clock",
+		ErrSkewUnavailable,
+	)
+}
+
+func (m *SkewMonitor) warn(estimate *results.SkewEstimate) {
+	skew := estimate.Skew()
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if m.threshold <= 0 || skew <= m.threshold {
+		return
+	}
+
+	direction := "ahead of"
+	if estimate.SkewMilliseconds < 0 {
+		direction = "behind"
+	}
+
+	color.Red(
+		"[CLOCK SKEW] local clock (%s) is %s %s implementation clock (%s), estimated from %s. A skew this large usually indicates the node clock is broken, not the test host.", // nolint:lll
+		estimate.LocalTime,
+		skew.Round(time.Second),
+		direction,
+		estimate.ImplementationTime,
+		estimate.Source,
+	)
+}
+
+// Current returns the latest *SkewEstimate (or nil if no
+// estimate has been made).
+func (m *SkewMonitor) Current() *results.SkewEstimate {
+	m.estimateLock.RLock()
+	defer m.estimateLock.RUnlock()
+
+	return m.estimate
+}
+
+// AdjustTipDelay returns the tip delay (in seconds) to use when
+// comparing block timestamps to the local clock. If correction is
+// disabled or skew has not been estimated, tipDelay is returned
+// unmodified. The adjusted tip delay is never negative.
+func (m *SkewMonitor) AdjustTipDelay(tipDelay int64) int64 {
+	if !m.correction {
+		return tipDelay
+	}
+
+	estimate := m.Current()
+	if estimate == nil {
+		return tipDelay
+	}
+
+	// If the local clock is ahead of the implementation clock, all
+	// blocks appear older than they are, so we allow for a larger delay.
+	adjusted := tipDelay + int64(estimate.Skew().Round(time.Second)/time.Second)
+	if adjusted < 0 {
+		return 0
+	}
+
+	return adjusted
+}
+
+// Start re-estimates clock skew every SkewCheckInterval
+// until the provided context is canceled.
+func (m *SkewMonitor) Start(ctx context.Context) error {
+	tc := time.NewTicker(SkewCheckInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tc.C:
+			if _, err := m.Estimate(ctx); err != nil {
+				log.Printf("%s: unable to estimate clock skew\n", err.Error())
+			}
+		}
+	}
+}
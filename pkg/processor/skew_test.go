@@ -0,0 +1,143 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	network = &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+)
+
+func TestSkewMonitor(t *testing.T) {
+	var tests = map[string]struct {
+		dateOffset  *time.Duration
+		blockOffset time.Duration
+		correction  bool
+		tipDelay    int64
+
+		expectedSource   string
+		expectedSkew     time.Duration
+		expectedTipDelay int64
+		expectedErr      error
+	}{
+		"implementation clock behind": {
+			dateOffset:       durationPtr(-2 * time.Minute),
+			blockOffset:      -10 * time.Minute,
+			correction:       true,
+			tipDelay:         300,
+			expectedSource:   DateHeaderSource,
+			expectedSkew:     2 * time.Minute,
+			expectedTipDelay: 420,
+		},
+		"implementation clock ahead": {
+			dateOffset:       durationPtr(2 * time.Minute),
+			blockOffset:      -10 * time.Minute,
+			correction:       true,
+			tipDelay:         300,
+			expectedSource:   DateHeaderSource,
+			expectedSkew:     -2 * time.Minute,
+			expectedTipDelay: 180,
+		},
+		"implementation clock ahead by more than tip delay": {
+			dateOffset:       durationPtr(10 * time.Minute),
+			blockOffset:      -10 * time.Minute,
+			correction:       true,
+			tipDelay:         300,
+			expectedSource:   DateHeaderSource,
+			expectedSkew:     -10 * time.Minute,
+			expectedTipDelay: 0,
+		},
+		"correction disabled": {
+			dateOffset:       durationPtr(-2 * time.Minute),
+			blockOffset:      -10 * time.Minute,
+			tipDelay:         300,
+			expectedSource:   DateHeaderSource,
+			expectedSkew:     2 * time.Minute,
+			expectedTipDelay: 300,
+		},
+		"no date header, block in future": {
+			blockOffset:      5 * time.Minute,
+			correction:       true,
+			tipDelay:         300,
+			expectedSource:   BlockTimestampSource,
+			expectedSkew:     -5 * time.Minute,
+			expectedTipDelay: 0,
+		},
+		"no date header, block in past": {
+			blockOffset:      -5 * time.Minute,
+			correction:       true,
+			tipDelay:         300,
+			expectedTipDelay: 300,
+			expectedErr:      ErrSkewUnavailable,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, networkStatusPath, r.URL.Path)
+
+				now := time.Now()
+				if test.dateOffset != nil {
+					w.Header().Set("Date", now.Add(*test.dateOffset).UTC().Format(http.TimeFormat))
+				} else {
+					// Go's http server populates the Date header automatically
+					// unless it is explicitly suppressed.
+					w.Header()["Date"] = nil
+				}
+				w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+				w.WriteHeader(http.StatusOK)
+				assert.NoError(t, json.NewEncoder(w).Encode(&types.NetworkStatusResponse{
+					CurrentBlockIdentifier: &types.BlockIdentifier{Index: 10, Hash: "10"},
+					CurrentBlockTimestamp:  now.Add(test.blockOffset).UnixNano() / int64(time.Millisecond),
+					GenesisBlockIdentifier: &types.BlockIdentifier{Index: 0, Hash: "0"},
+				}))
+			}))
+			defer ts.Close()
+
+			m := NewSkewMonitor(ts.URL, network, ts.Client(), test.correction, time.Minute)
+			estimate, err := m.Estimate(context.Background())
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				assert.Nil(t, m.Current())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedSource, estimate.Source)
+				assert.InDelta(t, test.expectedSkew, estimate.Skew(), float64(2*time.Second))
+				assert.Equal(t, estimate, m.Current())
+			}
+
+			assert.InDelta(t, test.expectedTipDelay, m.AdjustTipDelay(test.tipDelay), 2)
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,267 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	// BaselineSchemaVersion is the version of the results file schema
+	// (written to schema_version). It must be incremented whenever
+	// the fingerprint of a finding changes so that older results
+	// files are not used as a baseline.
+	BaselineSchemaVersion = 1
+
+	// ErrorFindingPrefix is the fingerprint prefix
+	// of the error that ended a run.
+	ErrorFindingPrefix = "error:"
+)
+
+var (
+	// ErrBaselineSchemaVersion is returned when a baseline results
+	// file has an unsupported schema version.
+	ErrBaselineSchemaVersion = errors.New("unsupported baseline schema version")
+
+	// ErrNewFindings is returned when a run has findings
+	// that are not in the baseline.
+	ErrNewFindings = errors.New("new findings relative to baseline")
+
+	// variablePattern matches hashes and numbers in an error
+	// so that errors differing only by (for example) a block
+	// index have the same fingerprint.
+	variablePattern = regexp.MustCompile(`0x[0-9a-fA-F]+|[0-9a-fA-F]{16,}|[0-9]+`)
+)
+
+// Finding is an issue found during a run. Findings are
+// matched across runs by their Fingerprint, which never
+// contains counts (which often fluctuate between runs).
+type Finding struct {
+	Fingerprint string `json:"fingerprint"`
+	Description string `json:"description"`
+	Count       int64  `json:"count,omitempty"`
+}
+
+// ErrorFinding returns the *Finding for the error that ended a run.
+// Hashes and numbers are removed from the error before it is
+// fingerprinted.
+func ErrorFinding(err error) *Finding {
+	normalized := variablePattern.ReplaceAllString(err.Error(), "#")
+	hash := sha256.Sum256([]byte(normalized))
+
+	return &Finding{
+		Fingerprint: ErrorFindingPrefix + hex.EncodeToString(hash[:8]),
+		Description: normalized,
+		Count:       1,
+	}
+}
+
+// FindingIdentity identifies the results file used as
+// a baseline so that a comparison can be audited.
+type FindingIdentity struct {
+	Path  string `json:"path"`
+	Hash  string `json:"sha256"`
+	RunID string `json:"run_id,omitempty"`
+}
+
+// Baseline is a results file of an accepted run.
+type Baseline struct {
+	Identity *FindingIdentity
+	Findings []*Finding
+}
+
+// baselineFile is the subset of a results
+// file needed to use it as a baseline.
+type baselineFile struct {
+	SchemaVersion int        `json:"schema_version"`
+	RunID         string     `json:"run_id"`
+	Findings      []*Finding `json:"findings"`
+}
+
+// LoadBaseline loads the results file at path as a *Baseline.
+func LoadBaseline(path string) (*Baseline, error) {
+	contents, err := ioutil.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read baseline %s", err, path)
+	}
+
+	var file baselineFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse baseline %s", err, path)
+	}
+
+	if file.SchemaVersion != BaselineSchemaVersion {
+		return nil, fmt.Errorf(
+			"%w: %s has schema version %d (expected %d)",
+			ErrBaselineSchemaVersion,
+			path,
+			file.SchemaVersion,
+			BaselineSchemaVersion,
+		)
+	}
+
+	hash := sha256.Sum256(contents)
+	return &Baseline{
+		Identity: &FindingIdentity{
+			Path:  path,
+			Hash:  hex.EncodeToString(hash[:]),
+			RunID: file.RunID,
+		},
+		Findings: file.Findings,
+	}, nil
+}
+
+// PersistingFinding is a finding present in both
+// the baseline and the current run.
+type PersistingFinding struct {
+	*Finding
+	BaselineCount int64 `json:"baseline_count,omitempty"`
+}
+
+// BaselineComparison classifies the findings of a run
+// relative to a baseline.
+type BaselineComparison struct {
+	Baseline   *FindingIdentity     `json:"baseline"`
+	New        []*Finding           `json:"new"`
+	Persisting []*PersistingFinding `json:"persisting"`
+	Resolved   []*Finding           `json:"resolved"`
+}
+
+// DiffBaseline classifies findings as new (not in the baseline),
+// persisting (in the baseline, regardless of count), or
+// resolved (only in the baseline).
+func DiffBaseline(baseline *Baseline, findings []*Finding) *BaselineComparison {
+	comparison := &BaselineComparison{
+		Baseline:   baseline.Identity,
+		New:        []*Finding{},
+		Persisting: []*PersistingFinding{},
+		Resolved:   []*Finding{},
+	}
+
+	baselineFindings := map[string]*Finding{}
+	for _, finding := range baseline.Findings {
+		baselineFindings[finding.Fingerprint] = finding
+	}
+
+	seen := map[string]struct{}{}
+	for _, finding := range findings {
+		seen[finding.Fingerprint] = struct{}{}
+		baselineFinding, ok := baselineFindings[finding.Fingerprint]
+		if !ok {
+			comparison.New = append(comparison.New, finding)
+			continue
+		}
+
+		comparison.Persisting = append(comparison.Persisting, &PersistingFinding{
+			Finding:       finding,
+			BaselineCount: baselineFinding.Count,
+		})
+	}
+
+	for _, finding := range baseline.Findings {
+		if _, ok := seen[finding.Fingerprint]; !ok {
+			comparison.Resolved = append(comparison.Resolved, finding)
+		}
+	}
+
+	sort.Slice(comparison.New, func(i, j int) bool {
+		return comparison.New[i].Fingerprint < comparison.New[j].Fingerprint
+	})
+	sort.Slice(comparison.Persisting, func(i, j int) bool {
+		return comparison.Persisting[i].Fingerprint < comparison.Persisting[j].Fingerprint
+	})
+	sort.Slice(comparison.Resolved, func(i, j int) bool {
+		return comparison.Resolved[i].Fingerprint < comparison.Resolved[j].Fingerprint
+	})
+
+	return comparison
+}
+
+// Err returns an error if there are any new findings.
+func (c *BaselineComparison) Err() error {
+	if len(c.New) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d new finding(s)", ErrNewFindings, len(c.New))
+}
+
+// Print logs the BaselineComparison to the console.
+func (c *BaselineComparison) Print() {
+	c.Fprint(color.Output)
+}
+
+// Fprint writes the BaselineComparison to w.
+func (c *BaselineComparison) Fprint(w io.Writer) {
+	color.New(color.FgCyan).Fprintf(
+		w,
+		"Findings compared with baseline %s (sha256: %s, run id: %s)\n",
+		c.Baseline.Path,
+		c.Baseline.Hash,
+		c.Baseline.RunID,
+	)
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Finding", "Description", "Status", "Count", "Baseline Count"})
+	for _, finding := range c.New {
+		table.Append([]string{
+			finding.Fingerprint,
+			finding.Description,
+			"NEW",
+			strconv.FormatInt(finding.Count, 10),
+			"",
+		})
+	}
+	for _, finding := range c.Persisting {
+		table.Append([]string{
+			finding.Fingerprint,
+			finding.Description,
+			"PERSISTING",
+			strconv.FormatInt(finding.Count, 10),
+			strconv.FormatInt(finding.BaselineCount, 10),
+		})
+	}
+	for _, finding := range c.Resolved {
+		table.Append([]string{
+			finding.Fingerprint,
+			finding.Description,
+			"RESOLVED",
+			"",
+			strconv.FormatInt(finding.Count, 10),
+		})
+	}
+	table.Render()
+
+	if len(c.New) > 0 {
+		color.New(color.FgRed).Fprintf(w, "%d new finding(s) relative to baseline\n", len(c.New))
+		return
+	}
+
+	color.New(color.FgGreen).Fprintf(w, "No new findings relative to baseline\n")
+}
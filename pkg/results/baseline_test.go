@@ -0,0 +1,123 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorFinding(t *testing.T) {
+	a := ErrorFinding(errors.New("unable to sync to 1234: block 0xabc123 not found"))
+	b := ErrorFinding(fmt.Errorf("unable to sync to 99: block 0xdef456 not found"))
+	c := ErrorFinding(errors.New("unable to sync to 1234: request timed out"))
+
+	assert.Equal(t, a.Fingerprint, b.Fingerprint)
+	assert.NotEqual(t, a.Fingerprint, c.Fingerprint)
+	assert.Equal(t, "unable to sync to #: block # not found", a.Description)
+}
+
+func TestLoadBaseline(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	var tests = map[string]struct {
+		contents string
+
+		expectedFindings int
+		expectedRunID    string
+		expectedErr      error
+	}{
+		"valid baseline": {
+			contents: `{"schema_version":1,"run_id":"run","findings":[` +
+				`{"fingerprint":"test:reconciliation","description":"failed","count":1}]}`,
+			expectedFindings: 1,
+			expectedRunID:    "run",
+		},
+		"missing schema version": {
+			contents:    `{"run_id":"run","findings":[]}`,
+			expectedErr: ErrBaselineSchemaVersion,
+		},
+		"future schema version": {
+			contents:    `{"schema_version":2,"findings":[]}`,
+			expectedErr: ErrBaselineSchemaVersion,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filePath := path.Join(dir, "results.json")
+			assert.NoError(t, ioutil.WriteFile(filePath, []byte(test.contents), 0600))
+
+			baseline, err := LoadBaseline(filePath)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+				assert.Nil(t, baseline)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, baseline.Findings, test.expectedFindings)
+			assert.Equal(t, filePath, baseline.Identity.Path)
+			assert.Equal(t, test.expectedRunID, baseline.Identity.RunID)
+			assert.Len(t, baseline.Identity.Hash, 64)
+		})
+	}
+
+	_, err = LoadBaseline(path.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestDiffBaseline(t *testing.T) {
+	baseline := &Baseline{
+		Identity: &FindingIdentity{Path: "results.json", Hash: "hash", RunID: "run"},
+		Findings: []*Finding{
+			{Fingerprint: "currency_casing:a", Count: 10},
+			{Fingerprint: "stat:failed_reconciliations", Count: 2},
+			{Fingerprint: "test:reconciliation", Count: 1},
+		},
+	}
+
+	comparison := DiffBaseline(baseline, []*Finding{
+		{Fingerprint: "stat:failed_reconciliations", Count: 5},
+		{Fingerprint: "currency_casing:a", Count: 3},
+		{Fingerprint: "block_audit:fetch_failed", Count: 1},
+	})
+
+	assert.Equal(t, baseline.Identity, comparison.Baseline)
+	assert.Equal(t, []*Finding{{Fingerprint: "block_audit:fetch_failed", Count: 1}}, comparison.New)
+	assert.Equal(t, []*PersistingFinding{
+		{Finding: &Finding{Fingerprint: "currency_casing:a", Count: 3}, BaselineCount: 10},
+		{Finding: &Finding{Fingerprint: "stat:failed_reconciliations", Count: 5}, BaselineCount: 2},
+	}, comparison.Persisting)
+	assert.Equal(t, []*Finding{{Fingerprint: "test:reconciliation", Count: 1}}, comparison.Resolved)
+	assert.True(t, errors.Is(comparison.Err(), ErrNewFindings))
+
+	// Count fluctuations alone are never new findings.
+	comparison = DiffBaseline(baseline, []*Finding{
+		{Fingerprint: "currency_casing:a", Count: 100},
+	})
+	assert.Len(t, comparison.New, 0)
+	assert.Len(t, comparison.Persisting, 1)
+	assert.Len(t, comparison.Resolved, 2)
+	assert.NoError(t, comparison.Err())
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// DivergenceType describes why a stored block
+// failed the block audit.
+type DivergenceType string
+
+const (
+	// FetchFailed indicates the implementation no longer serves
+	// a block that was processed during the run.
+	FetchFailed DivergenceType = "fetch_failed"
+)
+
+var (
+	// ErrBlockAuditFailure is returned when any sampled
+	// block fails the block audit.
+	ErrBlockAuditFailure = errors.New("block audit failure")
+)
+
+// BlockDivergence describes a sampled block that
+// failed the block audit.
+type BlockDivergence struct {
+	Index           int64                  `json:"index"`
+	BlockIdentifier *types.BlockIdentifier `json:"block_identifier,omitempty"`
+	Type            DivergenceType         `json:"type"`
+	Detail          string                 `json:"detail"`
+	Diff            []*FieldDiff           `json:"diff,omitempty"`
+}
+
+// BlockAuditResults contains the outcome of
+// an end-of-run block audit.
+type BlockAuditResults struct {
+	SampledIndexes []int64            `json:"sampled_indexes"`
+	Divergences    []*BlockDivergence `json:"divergences"`
+}
+
+// Passed returns a boolean indicating if all
+// sampled blocks passed the audit.
+func (r *BlockAuditResults) Passed() bool {
+	return len(r.Divergences) == 0
+}
+
+// Print logs BlockAuditResults to the console.
+func (r *BlockAuditResults) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes BlockAuditResults to w.
+func (r *BlockAuditResults) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Block Audit", "Value"})
+	table.Append([]string{"Sampled Blocks", strconv.Itoa(len(r.SampledIndexes))})
+	table.Append([]string{"Divergences", strconv.Itoa(len(r.Divergences))})
+	table.Render()
+
+	for _, divergence := range r.Divergences {
+		color.New(color.FgRed).Fprintf(
+			w,
+			"[BLOCK AUDIT] block %d (%s): %s\n",
+			divergence.Index,
+			divergence.Type,
+			divergence.Detail,
+		)
+		for _, diff := range divergence.Diff {
+			color.New(color.FgRed).Fprintf(w, "  %s\n", diff)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/olekukonko/tablewriter"
+)
+
+// BlockTimingResults summarizes the time spent
+// fetching and processing blocks.
+type BlockTimingResults struct {
+	ThresholdMs int64 `json:"threshold_ms"`
+	Blocks      int64 `json:"blocks"`
+	SlowBlocks  int64 `json:"slow_blocks"`
+
+	SlowestBlock   *types.BlockIdentifier `json:"slowest_block,omitempty"`
+	SlowestBlockMs int64                  `json:"slowest_block_ms"`
+}
+
+// Print logs the BlockTimingResults to the console.
+func (r *BlockTimingResults) Print() {
+	r.Fprint(os.Stdout)
+}
+
+// Fprint writes the BlockTimingResults to w.
+func (r *BlockTimingResults) Fprint(w io.Writer) {
+	slowest := "none"
+	if r.SlowestBlock != nil {
+		slowest = strconv.FormatInt(r.SlowestBlock.Index, 10) + " (" + r.SlowestBlock.Hash + ")"
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Block Timing", "Description", "Value"})
+	table.Append([]string{
+		"Threshold",
+		"Time to fetch and process a block before it is slow",
+		strconv.FormatInt(r.ThresholdMs, 10) + " ms",
+	})
+	table.Append([]string{
+		"Blocks",
+		"# of blocks timed",
+		strconv.FormatInt(r.Blocks, 10),
+	})
+	table.Append([]string{
+		"Slow Blocks",
+		"# of blocks that exceeded the threshold",
+		strconv.FormatInt(r.SlowBlocks, 10),
+	})
+	table.Append([]string{"Slowest Block", "Block that took the longest", slowest})
+	table.Append([]string{
+		"Slowest Block Time",
+		"Time to fetch and process the slowest block",
+		strconv.FormatInt(r.SlowestBlockMs, 10) + " ms",
+	})
+	table.Render()
+}
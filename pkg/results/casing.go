@@ -0,0 +1,68 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// CurrencyVariant is a currency that differs from the first-seen
+// currency with the same (case-insensitive) symbol.
+type CurrencyVariant struct {
+	Canonical  *types.Currency        `json:"canonical"`
+	Variant    *types.Currency        `json:"variant"`
+	Count      int64                  `json:"count"`
+	FirstBlock *types.BlockIdentifier `json:"first_block"`
+	LastBlock  *types.BlockIdentifier `json:"last_block"`
+}
+
+// CurrencyCasingResults contains all currency variants
+// found during a run.
+type CurrencyCasingResults struct {
+	Variants []*CurrencyVariant `json:"variants"`
+}
+
+// Print logs CurrencyCasingResults to the console.
+func (r *CurrencyCasingResults) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes CurrencyCasingResults to w.
+func (r *CurrencyCasingResults) Fprint(w io.Writer) {
+	if len(r.Variants) == 0 {
+		color.New(color.FgGreen).Fprintf(w, "No currency casing variants found\n")
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Currency Canonical", "Currency Variant", "Count", "First Block"})
+	for _, variant := range r.Variants {
+		table.Append([]string{
+			types.PrintStruct(variant.Canonical),
+			types.PrintStruct(variant.Variant),
+			strconv.FormatInt(variant.Count, 10),
+			strconv.FormatInt(variant.FirstBlock.Index, 10),
+		})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+var (
+	// ErrCoinDiscrepancy is returned when an operation in a block
+	// spends or creates a coin in a way that is inconsistent with
+	// the coins tracked so far.
+	ErrCoinDiscrepancy = errors.New("coin discrepancy")
+)
+
+const (
+	// AmountMismatch is the Reason of a CoinDiscrepancy where the
+	// amount spent is not the negation of the coin amount.
+	AmountMismatch = "amount mismatch"
+)
+
+// CoinDiscrepancy is the first coin that was spent or
+// created inconsistently with the tracked coins.
+type CoinDiscrepancy struct {
+	BlockIdentifier *types.BlockIdentifier   `json:"block_identifier"`
+	CoinIdentifier  *types.CoinIdentifier    `json:"coin_identifier"`
+	Account         *types.AccountIdentifier `json:"account_identifier"`
+	Reason          string                   `json:"reason"`
+	Expected        string                   `json:"expected"`
+	Actual          string                   `json:"actual"`
+}
+
+// String returns a human-readable representation
+// of a *CoinDiscrepancy.
+func (d *CoinDiscrepancy) String() string {
+	return fmt.Sprintf(
+		"%s of coin %s by %s in block %s: expected=%s actual=%s",
+		d.Reason,
+		d.CoinIdentifier.Identifier,
+		types.PrintStruct(d.Account),
+		types.PrintStruct(d.BlockIdentifier),
+		d.Expected,
+		d.Actual,
+	)
+}
+
+// CoinTrackingResults contains the coin operations
+// checked during a run.
+type CoinTrackingResults struct {
+	CoinsChecked int64            `json:"coins_checked"`
+	Discrepancy  *CoinDiscrepancy `json:"discrepancy,omitempty"`
+}
+
+// Print logs CoinTrackingResults to the console.
+func (r *CoinTrackingResults) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes CoinTrackingResults to w.
+func (r *CoinTrackingResults) Fprint(w io.Writer) {
+	discrepantCoin := "none"
+	if r.Discrepancy != nil {
+		discrepantCoin = r.Discrepancy.CoinIdentifier.Identifier
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Coin Tracking", "Value"})
+	table.Append([]string{"Coins Checked", strconv.FormatInt(r.CoinsChecked, 10)})
+	table.Append([]string{"First Discrepant Coin", discrepantCoin})
+	table.Render()
+
+	if r.Discrepancy != nil {
+		color.New(color.FgRed).Fprintf(w, "[COIN TRACKING] %s\n", r.Discrepancy)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// ComparisonFieldDiff is a single field that differs between the block
+// served by the online and comparison implementations.
+type ComparisonFieldDiff struct {
+	Path       string `json:"path"`
+	Online     string `json:"online"`
+	Comparison string `json:"comparison"`
+}
+
+// String returns a human-readable representation
+// of a *ComparisonFieldDiff.
+func (d *ComparisonFieldDiff) String() string {
+	return fmt.Sprintf("%s: online=%s comparison=%s", d.Path, d.Online, d.Comparison)
+}
+
+// ComparisonDivergence is the first block that differs between
+// the online and comparison implementations.
+type ComparisonDivergence struct {
+	BlockIdentifier *types.BlockIdentifier `json:"block_identifier"`
+	Diff            []*ComparisonFieldDiff `json:"diff"`
+}
+
+// ComparisonResults contains the blocks compared with the
+// comparison implementation during a run.
+type ComparisonResults struct {
+	BlocksCompared int64                 `json:"blocks_compared"`
+	Divergence     *ComparisonDivergence `json:"divergence,omitempty"`
+}
+
+// Print logs ComparisonResults to the console.
+func (r *ComparisonResults) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes ComparisonResults to w.
+func (r *ComparisonResults) Fprint(w io.Writer) {
+	divergentBlock := "none"
+	if r.Divergence != nil {
+		divergentBlock = strconv.FormatInt(r.Divergence.BlockIdentifier.Index, 10)
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Comparison", "Value"})
+	table.Append([]string{"Blocks Compared", strconv.FormatInt(r.BlocksCompared, 10)})
+	table.Append([]string{"First Divergent Block", divergentBlock})
+	table.Render()
+
+	if r.Divergence == nil {
+		return
+	}
+
+	color.New(color.FgRed).Fprintf(
+		w,
+		"[COMPARISON] block %s differs from comparison implementation\n",
+		types.PrintStruct(r.Divergence.BlockIdentifier),
+	)
+	for _, diff := range r.Divergence.Diff {
+		color.New(color.FgRed).Fprintf(w, "  %s\n", diff)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import "github.com/coinbase/rosetta-sdk-go/types"
+
+// CurrencyConfirmationStatus is the total pending and available
+// balance of all tracked accounts in a currency.
+type CurrencyConfirmationStatus struct {
+	Currency  *types.Currency `json:"currency"`
+	Pending   string          `json:"pending"`
+	Available string          `json:"available"`
+}
+
+// ConfirmationStatus summarizes deposits waiting
+// on confirmations.
+type ConfirmationStatus struct {
+	MinimumConfirmations int64                         `json:"minimum_confirmations"`
+	Currencies           []*CurrencyConfirmationStatus `json:"currencies"`
+
+	// RevertedDeposits is the number of deposits that were
+	// available and returned to pending by a reorg.
+	RevertedDeposits int64 `json:"reverted_deposits"`
+}
@@ -16,13 +16,12 @@ package results
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"os"
 	"strconv"
 
-	pkgError "github.com/pkg/errors"
-
 	"github.com/coinbase/rosetta-cli/configuration"
 
 	"github.com/coinbase/rosetta-sdk-go/storage/modules"
@@ -30,40 +29,146 @@ import (
 	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
+	pkgError "github.com/pkg/errors"
 )
 
 // CheckConstructionResults contains any error that
 // occurred on a check:construction run and a collection
 // of interesting stats.
 type CheckConstructionResults struct {
+	// SchemaVersion is the version of the results
+	// schema used when comparing with a baseline.
+	SchemaVersion int `json:"schema_version"`
+
+	// RunID identifies the run in the User-Agent
+	// and request ids of its requests.
+	RunID string `json:"run_id,omitempty"`
+
+	// RandomSeed is the seed of the randomness
+	// used during the run.
+	RandomSeed *int64 `json:"random_seed,omitempty"`
+
 	Error         string                  `json:"error"`
+	TimedOut      bool                    `json:"timed_out,omitempty"`
 	EndConditions map[string]int          `json:"end_conditions"`
 	Stats         *CheckConstructionStats `json:"stats"`
+	ClockSkew     *SkewEstimate           `json:"clock_skew,omitempty"`
+	FundsFlow     *FundsFlow              `json:"funds_flow,omitempty"`
+
+	// Endpoints contains the calls and failures (by
+	// failure type and error code) of each construction
+	// endpoint.
+	Endpoints *ConstructionEndpointsResults `json:"endpoints,omitempty"`
+
+	// ConfigurationWarnings are the suspicious combinations
+	// of configuration options used during the run.
+	ConfigurationWarnings []*configuration.Warning `json:"configuration_warnings,omitempty"`
+
+	// Findings are used to compare this run with a
+	// baseline (and to use this run as a baseline).
+	Findings []*Finding `json:"findings"`
+
+	// Baseline is populated if a baseline is configured.
+	Baseline *BaselineComparison `json:"baseline,omitempty"`
 	// TODO: add test output (like check data)
 }
 
 // Print logs CheckConstructionResults to the console.
 func (c *CheckConstructionResults) Print() {
-	if len(c.Error) > 0 {
-		fmt.Printf("\n")
-		color.Red("Error: %s", c.Error)
-	} else {
-		fmt.Printf("\n")
-		color.Green("Success: %s", types.PrintStruct(c.EndConditions))
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckConstructionResults to w.
+func (c *CheckConstructionResults) Fprint(w io.Writer) {
+	switch {
+	case c.TimedOut:
+		fmt.Fprintf(w, "\n")
+		color.New(color.FgRed).Fprintf(w, "Timed Out: %s\n", c.Error)
+	case len(c.Error) > 0:
+		fmt.Fprintf(w, "\n")
+		color.New(color.FgRed).Fprintf(w, "Error: %s\n", c.Error)
+	default:
+		fmt.Fprintf(w, "\n")
+		color.New(color.FgGreen).Fprintf(w, "Success: %s\n", types.PrintStruct(c.EndConditions))
+	}
+
+	if c.RandomSeed != nil {
+		fmt.Fprintf(w, "\n")
+		color.New(color.FgCyan).Fprintf(w, "Random Seed: %d\n", *c.RandomSeed)
 	}
 
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 	if c.Stats != nil {
-		c.Stats.Print()
-		fmt.Printf("\n")
+		c.Stats.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.ClockSkew != nil {
+		printClockSkew(w, c.ClockSkew)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.FundsFlow != nil {
+		FprintFundsFlow(w, c.FundsFlow)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.Endpoints != nil && len(c.Endpoints.Endpoints) > 0 {
+		c.Endpoints.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if len(c.ConfigurationWarnings) > 0 {
+		fprintConfigurationWarnings(w, c.ConfigurationWarnings)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.Baseline != nil {
+		c.Baseline.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// PrintFundsFlow logs the inflow and outflow of each
+// category of account to the console.
+func PrintFundsFlow(fundsFlow *FundsFlow) {
+	FprintFundsFlow(color.Output, fundsFlow)
+}
+
+// FprintFundsFlow writes the inflow and outflow of
+// each category of account to w.
+func FprintFundsFlow(w io.Writer, fundsFlow *FundsFlow) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"check:construction Funds Flow", "Inflow", "Outflow"})
+	for _, flow := range fundsFlow.Flows {
+		table.Append([]string{
+			string(flow.Category),
+			prettyValue(flow.Inflow, flow.Currency),
+			prettyValue(flow.Outflow, flow.Currency),
+		})
+	}
+	for _, net := range fundsFlow.Net {
+		table.Append([]string{
+			"net (fees)",
+			prettyValue(net.Value, net.Currency),
+			"",
+		})
+	}
+
+	table.Render()
+}
+
+func prettyValue(value string, currency *types.Currency) string {
+	parsed, err := types.BigInt(value)
+	if err != nil {
+		return value
 	}
+
+	return utils.PrettyAmount(parsed, currency)
 }
 
 // Output writes CheckConstructionResults to the provided
-// path.
-func (c *CheckConstructionResults) Output(path string) {
+// path in format.
+func (c *CheckConstructionResults) Output(path string, format configuration.ResultsOutputFormat) {
 	if len(path) > 0 {
-		writeErr := utils.SerializeAndWrite(path, c)
+		writeErr := writeResults(path, format, c)
 		if writeErr != nil {
 			log.Printf("%s: unable to save results\n", writeErr.Error())
 		}
@@ -77,15 +182,29 @@ func ComputeCheckConstructionResults(
 	err error,
 	counterStorage *modules.CounterStorage,
 	jobStorage *modules.JobStorage,
+	lineageStorage FundsFlowSource,
 ) *CheckConstructionResults {
 	ctx := context.Background()
 	stats := ComputeCheckConstructionStats(ctx, cfg, counterStorage, jobStorage)
 	results := &CheckConstructionResults{
 		Stats: stats,
 	}
+	if cfg.Construction != nil {
+		results.RandomSeed = cfg.Construction.RandomSeed
+	}
+
+	if lineageStorage != nil {
+		fundsFlow, err := lineageStorage.FundsFlow(ctx)
+		if err != nil {
+			log.Printf("%s cannot compute funds flow\n", err.Error())
+		}
+
+		results.FundsFlow = fundsFlow
+	}
 
 	if err != nil {
 		results.Error = fmt.Sprintf("%+v", err)
+		results.TimedOut = errors.Is(err, ErrDurationExceeded)
 
 		// We never want to populate an end condition
 		// if there was an error!
@@ -106,12 +225,23 @@ type CheckConstructionStats struct {
 	FailedBroadcasts      int64 `json:"failed_broadcasts"`
 	AddressesCreated      int64 `json:"addresses_created"`
 
+	// UnexpectedSigningPayloads, MissingSigners, and UnexpectedSigners
+	// count signer discrepancies found while constructing transactions.
+	UnexpectedSigningPayloads int64 `json:"unexpected_signing_payloads"`
+	MissingSigners            int64 `json:"missing_signers"`
+	UnexpectedSigners         int64 `json:"unexpected_signers"`
+
+	// FaucetCalls and FaucetFunds are only populated
+	// if a faucet is configured.
+	FaucetCalls int64         `json:"faucet_calls,omitempty"`
+	FaucetFunds *types.Amount `json:"faucet_funds,omitempty"`
+
 	WorkflowsCompleted map[string]int64 `json:"workflows_completed"`
 }
 
-// PrintCounts logs counter-related stats to the console.
-func (c *CheckConstructionStats) PrintCounts() {
-	table := tablewriter.NewWriter(os.Stdout)
+// FprintCounts writes counter-related stats to w.
+func (c *CheckConstructionStats) FprintCounts(w io.Writer) {
+	table := tablewriter.NewWriter(w)
 	table.SetRowLine(true)
 	table.SetRowSeparator("-")
 	table.SetHeader([]string{"check:construction Stats", "Description", "Value"})
@@ -140,13 +270,42 @@ func (c *CheckConstructionStats) PrintCounts() {
 		"# of transactions that exceeded broadcast limit",
 		strconv.FormatInt(c.FailedBroadcasts, 10),
 	})
+	table.Append([]string{
+		"Unexpected Signing Payloads",
+		"# of signing payloads for accounts not in the intent",
+		strconv.FormatInt(c.UnexpectedSigningPayloads, 10),
+	})
+	table.Append([]string{
+		"Missing Signers",
+		"# of expected signers missing from signed transactions",
+		strconv.FormatInt(c.MissingSigners, 10),
+	})
+	table.Append([]string{
+		"Unexpected Signers",
+		"# of unexpected signers in signed transactions",
+		strconv.FormatInt(c.UnexpectedSigners, 10),
+	})
+	if c.FaucetCalls > 0 {
+		table.Append([]string{
+			"Faucet Calls",
+			"# of successful faucet requests",
+			strconv.FormatInt(c.FaucetCalls, 10),
+		})
+	}
+	if c.FaucetFunds != nil {
+		table.Append([]string{
+			"Faucet Funds",
+			"total funds sent by the faucet",
+			prettyValue(c.FaucetFunds.Value, c.FaucetFunds.Currency),
+		})
+	}
 
 	table.Render()
 }
 
-// PrintWorkflows logs workflow counts to the console.
-func (c *CheckConstructionStats) PrintWorkflows() {
-	table := tablewriter.NewWriter(os.Stdout)
+// FprintWorkflows writes workflow counts to w.
+func (c *CheckConstructionStats) FprintWorkflows(w io.Writer) {
+	table := tablewriter.NewWriter(w)
 	table.SetRowLine(true)
 	table.SetRowSeparator("-")
 	table.SetHeader([]string{"check:construction Workflows", "Count"})
@@ -160,10 +319,15 @@ func (c *CheckConstructionStats) PrintWorkflows() {
 	table.Render()
 }
 
-// Print calls PrintCounts and PrintWorkflows.
+// Print logs CheckConstructionStats to the console.
 func (c *CheckConstructionStats) Print() {
-	c.PrintCounts()
-	c.PrintWorkflows()
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckConstructionStats to w.
+func (c *CheckConstructionStats) Fprint(w io.Writer) {
+	c.FprintCounts(w)
+	c.FprintWorkflows(w)
 }
 
 // ComputeCheckConstructionStats returns a populated
@@ -208,6 +372,48 @@ func ComputeCheckConstructionStats(
 		return nil
 	}
 
+	unexpectedSigningPayloads, err := counters.Get(ctx, UnexpectedPayloadsCounter)
+	if err != nil {
+		log.Printf("%s cannot get unexpected signing payloads counter\n", err.Error())
+		return nil
+	}
+
+	missingSigners, err := counters.Get(ctx, MissingSignersCounter)
+	if err != nil {
+		log.Printf("%s cannot get missing signers counter\n", err.Error())
+		return nil
+	}
+
+	unexpectedSigners, err := counters.Get(ctx, UnexpectedSignersCounter)
+	if err != nil {
+		log.Printf("%s cannot get unexpected signers counter\n", err.Error())
+		return nil
+	}
+
+	var faucetCalls int64
+	var faucetFunds *types.Amount
+	if config.Construction.Faucet != nil {
+		calls, err := counters.Get(ctx, FaucetCallsCounter)
+		if err != nil {
+			log.Printf("%s cannot get faucet calls counter\n", err.Error())
+			return nil
+		}
+		faucetCalls = calls.Int64()
+
+		if config.Construction.Faucet.Amount != nil {
+			funds, err := counters.Get(ctx, FaucetFundsCounter)
+			if err != nil {
+				log.Printf("%s cannot get faucet funds counter\n", err.Error())
+				return nil
+			}
+
+			faucetFunds = &types.Amount{
+				Value:    funds.String(),
+				Currency: config.Construction.Faucet.Amount.Currency,
+			}
+		}
+	}
+
 	workflowsCompleted := map[string]int64{}
 	for _, workflow := range config.Construction.Workflows {
 		completed, err := jobs.Completed(ctx, workflow.Name)
@@ -220,12 +426,17 @@ func ComputeCheckConstructionStats(
 	}
 
 	return &CheckConstructionStats{
-		TransactionsCreated:   transactionsCreated.Int64(),
-		TransactionsConfirmed: transactionsConfirmed.Int64(),
-		StaleBroadcasts:       staleBroadcasts.Int64(),
-		FailedBroadcasts:      failedBroadcasts.Int64(),
-		AddressesCreated:      addressesCreated.Int64(),
-		WorkflowsCompleted:    workflowsCompleted,
+		TransactionsCreated:       transactionsCreated.Int64(),
+		TransactionsConfirmed:     transactionsConfirmed.Int64(),
+		StaleBroadcasts:           staleBroadcasts.Int64(),
+		FailedBroadcasts:          failedBroadcasts.Int64(),
+		AddressesCreated:          addressesCreated.Int64(),
+		UnexpectedSigningPayloads: unexpectedSigningPayloads.Int64(),
+		MissingSigners:            missingSigners.Int64(),
+		UnexpectedSigners:         unexpectedSigners.Int64(),
+		FaucetCalls:               faucetCalls,
+		FaucetFunds:               faucetFunds,
+		WorkflowsCompleted:        workflowsCompleted,
 	}
 }
 
@@ -264,8 +475,13 @@ func ComputeCheckConstructionProgress(
 
 // CheckConstructionStatus contains CheckConstructionStats.
 type CheckConstructionStatus struct {
-	Stats    *CheckConstructionStats    `json:"stats"`
-	Progress *CheckConstructionProgress `json:"progress"`
+	Stats     *CheckConstructionStats    `json:"stats"`
+	Progress  *CheckConstructionProgress `json:"progress"`
+	ClockSkew *SkewEstimate              `json:"clock_skew,omitempty"`
+
+	// Confirmations is populated if funds must reach
+	// minimum confirmations before they are available.
+	Confirmations *ConfirmationStatus `json:"confirmations,omitempty"`
 }
 
 // ComputeCheckConstructionStatus returns a populated
@@ -276,10 +492,14 @@ func ComputeCheckConstructionStatus(
 	counters *modules.CounterStorage,
 	broadcasts *modules.BroadcastStorage,
 	jobs *modules.JobStorage,
+	clockSkew *SkewEstimate,
+	confirmationStatus *ConfirmationStatus,
 ) *CheckConstructionStatus {
 	return &CheckConstructionStatus{
-		Stats:    ComputeCheckConstructionStats(ctx, config, counters, jobs),
-		Progress: ComputeCheckConstructionProgress(ctx, broadcasts, jobs),
+		Stats:         ComputeCheckConstructionStats(ctx, config, counters, jobs),
+		Progress:      ComputeCheckConstructionProgress(ctx, broadcasts, jobs),
+		ClockSkew:     clockSkew,
+		Confirmations: confirmationStatus,
 	}
 }
 
@@ -293,14 +513,40 @@ func FetchCheckConstructionStatus(url string) (*CheckConstructionStatus, error)
 	return &status, nil
 }
 
+// FundsFlowSource computes the flow of funds between the
+// accounts used by check:construction.
+type FundsFlowSource interface {
+	FundsFlow(ctx context.Context) (*FundsFlow, error)
+}
+
+// ExitConstructionOptions are the results of the optional
+// checks of a check:construction run that ExitConstruction
+// includes in its results. Any of them may be empty.
+type ExitConstructionOptions struct {
+	// Lineage computes the flow of funds between the
+	// accounts used by check:construction.
+	Lineage FundsFlowSource
+
+	ClockSkew *SkewEstimate
+
+	// Endpoints are the outcomes of the calls to each
+	// construction endpoint.
+	Endpoints *ConstructionEndpointsResults
+}
+
 // ExitConstruction exits check:construction, logs the test results to the console,
-// and to a provided output path.
+// and to a provided output path. opts may be nil.
 func ExitConstruction(
 	config *configuration.Configuration,
 	counterStorage *modules.CounterStorage,
 	jobStorage *modules.JobStorage,
 	err error,
+	opts *ExitConstructionOptions,
 ) error {
+	if opts == nil {
+		opts = &ExitConstructionOptions{}
+	}
+
 	if !config.ErrorStackTraceDisabled {
 		err = pkgError.WithStack(err)
 	}
@@ -310,11 +556,19 @@ func ExitConstruction(
 		err,
 		counterStorage,
 		jobStorage,
+		opts.Lineage,
 	)
 	if results != nil {
+		results.ClockSkew = opts.ClockSkew
+		results.Endpoints = opts.Endpoints
+		results.ConfigurationWarnings = configuration.ConstructionWarnings(config)
+		results.RunID = config.RunID
+		results.SchemaVersion = BaselineSchemaVersion
+		results.Findings = ConstructionFindings(results, err)
+		results.Baseline, err = CompareBaseline(config.Baseline, results.Findings, err)
 		results.Print()
 		if config.Construction != nil {
-			results.Output(config.Construction.ResultsOutputFile)
+			results.Output(config.Construction.ResultsOutputFile, config.Construction.ResultsOutputFormat)
 		}
 	}
 
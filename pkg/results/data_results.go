@@ -18,14 +18,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
-	"os"
 	"strconv"
-
-	pkgError "github.com/pkg/errors"
+	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
@@ -37,6 +38,7 @@ import (
 	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
+	pkgError "github.com/pkg/errors"
 )
 
 var (
@@ -50,46 +52,233 @@ var (
 type EndCondition struct {
 	Type   configuration.CheckDataEndCondition `json:"type"`
 	Detail string                              `json:"detail"`
+
+	// Block is populated if the block hash end
+	// condition was met with the matched block.
+	Block *types.BlockIdentifier `json:"block,omitempty"`
 }
 
 // CheckDataResults contains any error that occurred
 // on a check:data run, the outcome of certain tests,
 // and a collection of interesting stats.
 type CheckDataResults struct {
+	// SchemaVersion is the version of the results
+	// schema used when comparing with a baseline.
+	SchemaVersion int `json:"schema_version"`
+
+	// RunID identifies the run in the User-Agent
+	// and request ids of its requests.
+	RunID string `json:"run_id,omitempty"`
+
 	Error        string          `json:"error"`
 	EndCondition *EndCondition   `json:"end_condition"`
 	Tests        *CheckDataTests `json:"tests"`
 	Stats        *CheckDataStats `json:"stats"`
+	ClockSkew    *SkewEstimate   `json:"clock_skew,omitempty"`
+
+	BlockAudit *BlockAuditResults `json:"block_audit,omitempty"`
+
+	// TransactionOverrides is populated if the balance changes
+	// of any transactions were overridden.
+	TransactionOverrides *TransactionOverridesResults `json:"transaction_overrides,omitempty"`
+
+	// ReconciliationFailureHook is populated if a reconciliation
+	// failure hook is configured.
+	ReconciliationFailureHook *FailureHookStats `json:"reconciliation_failure_hook,omitempty"`
+
+	// CurrencyCasing is populated if currency_casing_check
+	// is enabled.
+	CurrencyCasing *CurrencyCasingResults `json:"currency_casing,omitempty"`
+
+	// EndpointOverrides is populated if any endpoints
+	// were served from fixtures.
+	EndpointOverrides *httpclient.EndpointOverridesResults `json:"endpoint_overrides,omitempty"`
+
+	// StorageEstimate is populated if the storage required
+	// by the run was estimated (with --estimate-storage).
+	StorageEstimate *StorageEstimate `json:"storage_estimate,omitempty"`
+
+	// TipPolling is populated if syncing reached tip. It
+	// includes the requests made and the latency of blocks
+	// added at tip (to compare polling with long polling).
+	TipPolling *TipPollingResults `json:"tip_polling,omitempty"`
+
+	// EndConditionsMet is populated if end_conditions.require_all
+	// is enabled. It includes when each end condition was met
+	// and which end condition was met last.
+	EndConditionsMet *EndConditionsResults `json:"end_conditions_met,omitempty"`
+
+	// Comparison is populated if comparison_url is populated. It
+	// includes the first block that differed between the online
+	// and comparison implementations.
+	Comparison *ComparisonResults `json:"comparison,omitempty"`
+
+	// CoinTracking is populated if coin tracking is enabled. It
+	// includes the first coin (and the account and amounts
+	// involved) that was spent or created inconsistently with
+	// the tracked coins.
+	CoinTracking *CoinTrackingResults `json:"coin_tracking,omitempty"`
+
+	// OrphanedBlock is populated if check:data halted
+	// because a block was orphaned (with halt_on_reorg
+	// enabled).
+	OrphanedBlock *types.BlockIdentifier `json:"orphaned_block,omitempty"`
+
+	// ReconciliationFailures is populated if any reconciliation
+	// failed. It includes each failure and the threshold that
+	// was exceeded (if check:data halted).
+	ReconciliationFailures *DiscrepancyResults `json:"reconciliation_failures,omitempty"`
+
+	// NonFatalErrors is populated if any non-fatal errors
+	// occurred. It includes the number of each type and
+	// if max_errors was exceeded.
+	NonFatalErrors *nonfatal.Results `json:"non_fatal_errors,omitempty"`
+
+	// BlockTiming is populated if slow_block_threshold is
+	// populated. It includes the slowest block to fetch
+	// and process and the number of slow blocks.
+	BlockTiming *BlockTimingResults `json:"block_timing,omitempty"`
+
+	// FailureReport is the path of the failure report
+	// written when check:data halted (if any).
+	FailureReport string `json:"failure_report,omitempty"`
+
+	// ConfigurationWarnings are the suspicious combinations
+	// of configuration options used during the run.
+	ConfigurationWarnings []*configuration.Warning `json:"configuration_warnings,omitempty"`
+
+	// Findings are used to compare this run with a
+	// baseline (and to use this run as a baseline).
+	Findings []*Finding `json:"findings"`
+
+	// Baseline is populated if a baseline is configured.
+	Baseline *BaselineComparison `json:"baseline,omitempty"`
+
+	// PreviousRun is populated if previous_results_file is
+	// populated and exists. It includes the change of
+	// throughput, duration, and reconciliation counts.
+	PreviousRun *StatsDelta `json:"previous_run,omitempty"`
 }
 
 // Print logs CheckDataResults to the console.
 func (c *CheckDataResults) Print() {
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckDataResults to w.
+func (c *CheckDataResults) Fprint(w io.Writer) {
 	if len(c.Error) > 0 {
-		fmt.Printf("\n")
-		color.Red("Error: %s", c.Error)
+		fmt.Fprintf(w, "\n")
+		color.New(color.FgRed).Fprintf(w, "Error: %s\n", c.Error)
 	}
 
-	if c.EndCondition != nil {
-		fmt.Printf("\n")
-		color.Green("Success: %s [%s]", c.EndCondition.Type, c.EndCondition.Detail)
+	switch {
+	case c.EndCondition != nil && len(c.Error) > 0: // interrupted or reorg
+		fmt.Fprintf(w, "\n")
+		color.New(color.FgYellow).Fprintf(
+			w,
+			"End Condition: %s [%s]\n",
+			c.EndCondition.Type,
+			c.EndCondition.Detail,
+		)
+	case c.EndCondition != nil:
+		fmt.Fprintf(w, "\n")
+		color.New(color.FgGreen).Fprintf(
+			w,
+			"Success: %s [%s]\n",
+			c.EndCondition.Type,
+			c.EndCondition.Detail,
+		)
 	}
 
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 	if c.Tests != nil {
-		c.Tests.Print()
-		fmt.Printf("\n")
+		c.Tests.Fprint(w)
+		fmt.Fprintf(w, "\n")
 	}
 	if c.Stats != nil {
-		c.Stats.Print()
-		fmt.Printf("\n")
+		c.Stats.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.ClockSkew != nil {
+		printClockSkew(w, c.ClockSkew)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.BlockAudit != nil {
+		c.BlockAudit.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.TransactionOverrides != nil {
+		c.TransactionOverrides.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.ReconciliationFailureHook != nil {
+		c.ReconciliationFailureHook.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.CurrencyCasing != nil {
+		c.CurrencyCasing.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.EndpointOverrides != nil {
+		c.EndpointOverrides.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.StorageEstimate != nil {
+		c.StorageEstimate.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.TipPolling != nil {
+		c.TipPolling.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.EndConditionsMet != nil {
+		c.EndConditionsMet.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.Comparison != nil {
+		c.Comparison.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.CoinTracking != nil {
+		c.CoinTracking.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.ReconciliationFailures != nil {
+		c.ReconciliationFailures.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.NonFatalErrors != nil {
+		c.NonFatalErrors.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.BlockTiming != nil {
+		c.BlockTiming.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if len(c.FailureReport) > 0 {
+		color.New(color.FgYellow).Fprintf(w, "Failure Report: %s\n", c.FailureReport)
+		fmt.Fprintf(w, "\n")
+	}
+	if len(c.ConfigurationWarnings) > 0 {
+		fprintConfigurationWarnings(w, c.ConfigurationWarnings)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.Baseline != nil {
+		c.Baseline.Fprint(w)
+		fmt.Fprintf(w, "\n")
+	}
+	if c.PreviousRun != nil {
+		c.PreviousRun.Fprint(w)
+		fmt.Fprintf(w, "\n")
 	}
 }
 
 // Output writes *CheckDataResults to the provided
-// path.
-func (c *CheckDataResults) Output(path string) {
+// path in format.
+func (c *CheckDataResults) Output(path string, format configuration.ResultsOutputFormat) {
 	if len(path) > 0 {
-		writeErr := utils.SerializeAndWrite(path, c)
+		writeErr := writeResults(path, format, c)
 		if writeErr != nil {
 			log.Printf("%s: unable to save results\n", writeErr.Error())
 		}
@@ -109,12 +298,29 @@ type CheckDataStats struct {
 	ExemptReconciliations   int64   `json:"exempt_reconciliations"`
 	FailedReconciliations   int64   `json:"failed_reconciliations"`
 	SkippedReconciliations  int64   `json:"skipped_reconciliations"`
+	ReconciliationTimeouts  int64   `json:"reconciliation_timeouts,omitempty"`
 	ReconciliationCoverage  float64 `json:"reconciliation_coverage"`
+
+	// CurrencyExemptReconciliations are reconciliations skipped
+	// because their currency is exempt (not included in
+	// SkippedReconciliations).
+	CurrencyExemptReconciliations int64 `json:"currency_exempt_reconciliations,omitempty"`
+
+	// Duration is how long check:data ran (in seconds,
+	// including any runs it resumed) and BlocksPerSecond
+	// is the number of blocks synced per second of it.
+	Duration        float64 `json:"duration,omitempty"`
+	BlocksPerSecond float64 `json:"blocks_per_second,omitempty"`
 }
 
 // Print logs CheckDataStats to the console.
 func (c *CheckDataStats) Print() {
-	table := tablewriter.NewWriter(os.Stdout)
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckDataStats to w.
+func (c *CheckDataStats) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
 	table.SetRowLine(true)
 	table.SetRowSeparator("-")
 	table.SetHeader([]string{"check:data Stats", "Description", "Value"})
@@ -168,6 +374,24 @@ func (c *CheckDataStats) Print() {
 			strconv.FormatInt(c.SkippedReconciliations, 10),
 		},
 	)
+	if c.CurrencyExemptReconciliations > 0 {
+		table.Append(
+			[]string{
+				"Currency Exempt Reconciliations",
+				"# of reconciliations skipped because their currency is exempt",
+				strconv.FormatInt(c.CurrencyExemptReconciliations, 10),
+			},
+		)
+	}
+	if c.ReconciliationTimeouts > 0 {
+		table.Append(
+			[]string{
+				"Reconciliation Timeouts",
+				"# of balance fetches that exceeded reconciliation_timeout",
+				strconv.FormatInt(c.ReconciliationTimeouts, 10),
+			},
+		)
+	}
 	table.Append(
 		[]string{
 			"Reconciliation Coverage",
@@ -175,6 +399,22 @@ func (c *CheckDataStats) Print() {
 			fmt.Sprintf("%f%%", c.ReconciliationCoverage*utils.OneHundred),
 		},
 	)
+	if c.Duration > 0 {
+		table.Append(
+			[]string{
+				"Duration",
+				"time check:data ran",
+				(time.Duration(c.Duration * float64(time.Second))).Round(time.Second).String(),
+			},
+		)
+		table.Append(
+			[]string{
+				"Blocks Per Second",
+				"# of blocks synced per second",
+				fmt.Sprintf("%.2f", c.BlocksPerSecond),
+			},
+		)
+	}
 
 	table.Render()
 }
@@ -249,6 +489,18 @@ func ComputeCheckDataStats(
 		return nil
 	}
 
+	reconciliationTimeouts, err := counters.Get(ctx, ReconciliationTimeoutCounter)
+	if err != nil {
+		log.Printf("%s: cannot get reconciliation timeouts counter", err.Error())
+		return nil
+	}
+
+	currencyExemptReconciliations, err := counters.Get(ctx, CurrencyExemptReconciliationCounter)
+	if err != nil {
+		log.Printf("%s: cannot get currency exempt reconciliations counter", err.Error())
+		return nil
+	}
+
 	stats := &CheckDataStats{
 		Blocks:                  blocks.Int64(),
 		Orphans:                 orphans.Int64(),
@@ -260,6 +512,9 @@ func ComputeCheckDataStats(
 		ExemptReconciliations:   exemptReconciliations.Int64(),
 		FailedReconciliations:   failedReconciliations.Int64(),
 		SkippedReconciliations:  skippedReconciliations.Int64(),
+		ReconciliationTimeouts:  reconciliationTimeouts.Int64(),
+
+		CurrencyExemptReconciliations: currencyExemptReconciliations.Int64(),
 	}
 
 	if balances != nil {
@@ -393,8 +648,51 @@ func ComputeCheckDataProgress(
 // CheckDataStatus contains both CheckDataStats
 // and CheckDataProgress.
 type CheckDataStatus struct {
-	Stats    *CheckDataStats    `json:"stats"`
-	Progress *CheckDataProgress `json:"progress"`
+	Stats     *CheckDataStats    `json:"stats"`
+	Progress  *CheckDataProgress `json:"progress"`
+	ClockSkew *SkewEstimate      `json:"clock_skew,omitempty"`
+
+	// CommitBatchSize is the number of blocks currently grouped
+	// into each storage commit (only populated if commit
+	// batching is enabled).
+	CommitBatchSize int `json:"commit_batch_size,omitempty"`
+
+	// Monitor is only populated once check:data
+	// has entered monitor mode.
+	Monitor *FindingsMonitorResults `json:"monitor,omitempty"`
+
+	// DataDirectorySizeMB is the last measured size of the data
+	// directory (only populated if max_data_directory_size_mb
+	// is populated).
+	DataDirectorySizeMB *int64 `json:"data_directory_size_mb,omitempty"`
+
+	// BlockFetchConcurrency is the maximum number of blocks
+	// fetched concurrently. The syncer lowers the number of
+	// blocks it fetches concurrently when fetched blocks
+	// would exceed block_buffer_size, so BlocksInFlight
+	// is the effective concurrency.
+	BlockFetchConcurrency int64 `json:"block_fetch_concurrency,omitempty"`
+	BlocksInFlight        int64 `json:"blocks_in_flight"`
+
+	// Reconciliations is only populated if the
+	// reconciliations end condition is configured.
+	Reconciliations *ReconciliationsProgress `json:"reconciliations,omitempty"`
+
+	// NonFatalErrors are the non-fatal errors that have
+	// occurred (counted towards max_errors).
+	NonFatalErrors *nonfatal.Results `json:"non_fatal_errors,omitempty"`
+
+	// Throughput is the current and average rate of
+	// blocks synced (and, if an index or tip end condition
+	// is configured, the estimated time remaining).
+	Throughput *ThroughputProgress `json:"throughput,omitempty"`
+}
+
+// ReconciliationsProgress is the progress of
+// the reconciliations end condition.
+type ReconciliationsProgress struct {
+	Succeeded int64  `json:"succeeded"`
+	Target    uint64 `json:"target"`
 }
 
 // ComputeCheckDataStatus returns a populated
@@ -407,6 +705,8 @@ func ComputeCheckDataStatus(
 	fetcher *fetcher.Fetcher,
 	network *types.NetworkIdentifier,
 	reconciler *reconciler.Reconciler,
+	clockSkew *SkewEstimate,
+	commitBatchSize int,
 ) *CheckDataStatus {
 	return &CheckDataStatus{
 		Stats: ComputeCheckDataStats(
@@ -422,6 +722,8 @@ func ComputeCheckDataStatus(
 			blocks,
 			reconciler,
 		),
+		ClockSkew:       clockSkew,
+		CommitBatchSize: commitBatchSize,
 	}
 }
 
@@ -445,6 +747,7 @@ type CheckDataTests struct {
 	BlockSyncing      *bool `json:"block_syncing"`
 	BalanceTracking   *bool `json:"balance_tracking"`
 	Reconciliation    *bool `json:"reconciliation"`
+	BlockAudit        *bool `json:"block_audit"`
 }
 
 // convertBool converts a *bool
@@ -463,7 +766,12 @@ func convertBool(v *bool) string {
 
 // Print logs CheckDataTests to the console.
 func (c *CheckDataTests) Print() {
-	table := tablewriter.NewWriter(os.Stdout)
+	c.Fprint(color.Output)
+}
+
+// Fprint writes CheckDataTests to w.
+func (c *CheckDataTests) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
 	table.SetRowLine(true)
 	table.SetRowSeparator("-")
 	table.SetHeader([]string{"check:data Tests", "Description", "Status"})
@@ -502,6 +810,13 @@ func (c *CheckDataTests) Print() {
 			convertBool(c.Reconciliation),
 		},
 	)
+	table.Append(
+		[]string{
+			"Block Audit",
+			"Sampled blocks re-fetched at the end of the run match stored blocks",
+			convertBool(c.BlockAudit),
+		},
+	)
 
 	table.Render()
 }
@@ -530,7 +845,8 @@ func ResponseAssertionTest(err error) bool {
 func BlockSyncingTest(err error, blocksSynced bool) *bool {
 	syncPass := true
 	storageFailed, _ := storageErrs.Err(err)
-	if syncer.Err(err) ||
+	if syncer.Err(err) || errors.Is(err, ErrOrphanedBlock) ||
+		errors.Is(err, ErrTrustedCheckpointMismatch) || errors.Is(err, ErrBlockHashNotFound) ||
 		(storageFailed && !errors.Is(err, storageErrs.ErrNegativeBalance)) {
 		syncPass = false
 	}
@@ -586,6 +902,18 @@ func ReconciliationTest(
 	return &tr
 }
 
+// BlockAuditTest returns a boolean indicating
+// if the end-of-run block audit failed. If the audit
+// did not fail, the result is populated by ExitData
+// (as the audit only runs at the end of a run).
+func BlockAuditTest(err error) *bool {
+	if errors.Is(err, ErrBlockAuditFailure) {
+		return &f
+	}
+
+	return nil
+}
+
 // ComputeCheckDataTests returns a populated CheckDataTests.
 func ComputeCheckDataTests( // nolint:gocognit
 	ctx context.Context,
@@ -650,6 +978,7 @@ func ComputeCheckDataTests( // nolint:gocognit
 			reconciliationsPerformed,
 			reconciliationsFailed,
 		),
+		BlockAudit: BlockAuditTest(err),
 	}
 }
 
@@ -680,13 +1009,18 @@ func ComputeCheckDataResults(
 			tests.ResponseAssertion &&
 			(tests.BlockSyncing == nil || *tests.BlockSyncing) &&
 			(tests.BalanceTracking == nil || *tests.BalanceTracking) &&
-			(tests.Reconciliation == nil || *tests.Reconciliation) {
+			(tests.Reconciliation == nil || *tests.Reconciliation) &&
+			(tests.BlockAudit == nil || *tests.BlockAudit) {
 			results.Tests = nil
 		}
 
 		// We never want to populate an end condition
-		// if there was an error!
-		return results
+		// if there was an error (unless the run was
+		// interrupted or halted on a reorg)!
+		if endCondition != configuration.InterruptedEndCondition &&
+			endCondition != configuration.ReorgEndCondition {
+			return results
+		}
 	}
 
 	if len(endCondition) > 0 {
@@ -699,8 +1033,47 @@ func ComputeCheckDataResults(
 	return results
 }
 
+// ExitDataOptions are the results of the optional checks of a
+// check:data run (and details of how it ended) that ExitData
+// includes in its results. Any of them may be empty.
+type ExitDataOptions struct {
+	ClockSkew            *SkewEstimate
+	BlockAudit           *BlockAuditResults
+	TransactionOverrides *TransactionOverridesResults
+	FailureHook          *FailureHookStats
+	CurrencyCasing       *CurrencyCasingResults
+	EndpointOverrides    *httpclient.EndpointOverridesResults
+	StorageEstimate      *StorageEstimate
+	TipPolling           *TipPollingResults
+	EndConditionsMet     *EndConditionsResults
+	Comparison           *ComparisonResults
+	CoinTracking         *CoinTrackingResults
+
+	// OrphanedBlock is only populated if check:data halted
+	// on a reorg.
+	OrphanedBlock *types.BlockIdentifier
+
+	ReconciliationFailures *DiscrepancyResults
+
+	// FailureReport is the path of the failure report
+	// written when check:data halted (if any).
+	FailureReport string
+
+	BlockTiming *BlockTimingResults
+
+	// EndBlock is the block at which the end condition was
+	// met (if known).
+	EndBlock *types.BlockIdentifier
+
+	// Duration is how long check:data ran.
+	Duration time.Duration
+
+	NonFatalErrors *nonfatal.Results
+}
+
 // ExitData exits check:data, logs the test results to the console,
-// and to a provided output path.
+// and to a provided output path. The results are also returned
+// so that check:data can be run as a library. opts may be nil.
 func ExitData(
 	config *configuration.Configuration,
 	counterStorage *modules.CounterStorage,
@@ -708,7 +1081,19 @@ func ExitData(
 	err error,
 	endCondition configuration.CheckDataEndCondition,
 	endConditionDetail string,
-) error {
+	opts *ExitDataOptions,
+) (*CheckDataResults, error) {
+	if opts == nil {
+		opts = &ExitDataOptions{}
+	}
+
+	// Responses served from fixtures are not representative
+	// of the implementation, so a run can only succeed with
+	// them if explicitly allowed.
+	if err == nil && opts.EndpointOverrides != nil && !config.Data.AllowEndpointOverridesSuccess {
+		err = httpclient.ErrEndpointOverrides
+	}
+
 	if !config.ErrorStackTraceDisabled {
 		err = pkgError.WithStack(err)
 	}
@@ -722,9 +1107,43 @@ func ExitData(
 		endConditionDetail,
 	)
 	if results != nil {
+		results.ClockSkew = opts.ClockSkew
+		results.BlockAudit = opts.BlockAudit
+		results.TransactionOverrides = opts.TransactionOverrides
+		results.ReconciliationFailureHook = opts.FailureHook
+		results.CurrencyCasing = opts.CurrencyCasing
+		results.EndpointOverrides = opts.EndpointOverrides
+		results.StorageEstimate = opts.StorageEstimate
+		results.TipPolling = opts.TipPolling
+		results.EndConditionsMet = opts.EndConditionsMet
+		results.Comparison = opts.Comparison
+		results.CoinTracking = opts.CoinTracking
+		results.OrphanedBlock = opts.OrphanedBlock
+		results.ReconciliationFailures = opts.ReconciliationFailures
+		results.NonFatalErrors = opts.NonFatalErrors
+		results.FailureReport = opts.FailureReport
+		results.BlockTiming = opts.BlockTiming
+		if results.Stats != nil && opts.Duration > 0 {
+			results.Stats.Duration = opts.Duration.Seconds()
+			results.Stats.BlocksPerSecond = float64(results.Stats.Blocks) / opts.Duration.Seconds()
+		}
+		if results.EndCondition != nil {
+			results.EndCondition.Block = opts.EndBlock
+		}
+		results.ConfigurationWarnings = configuration.DataWarnings(config)
+		results.RunID = config.RunID
+		if opts.BlockAudit != nil && len(opts.BlockAudit.SampledIndexes) > 0 &&
+			results.Tests != nil && results.Tests.BlockAudit == nil {
+			passed := opts.BlockAudit.Passed()
+			results.Tests.BlockAudit = &passed
+		}
+		results.SchemaVersion = BaselineSchemaVersion
+		results.Findings = DataFindings(results, err)
+		results.Baseline, err = CompareBaseline(config.Baseline, results.Findings, err)
+		results.PreviousRun = ComparePreviousRun(config.Data.PreviousResultsFile, results.Stats)
 		results.Print()
-		results.Output(config.Data.ResultsOutputFile)
+		results.Output(config.Data.ResultsOutputFile, config.Data.ResultsOutputFormat)
 	}
 
-	return err
+	return results, err
 }
@@ -16,13 +16,16 @@ package results
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"path"
 	"testing"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
 	"github.com/coinbase/rosetta-sdk-go/fetcher"
@@ -152,6 +155,9 @@ func TestComputeCheckDataResults(t *testing.T) {
 			err: []error{
 				syncer.ErrCannotRemoveGenesisBlock,
 				syncer.ErrOutOfOrder,
+				ErrOrphanedBlock,
+				ErrTrustedCheckpointMismatch,
+				ErrBlockHashNotFound,
 				storageErrs.ErrDuplicateKey,
 				storageErrs.ErrDuplicateTransactionHash,
 			},
@@ -314,6 +320,23 @@ func TestComputeCheckDataResults(t *testing.T) {
 				},
 			},
 		},
+		"default configuration, counter storage with blocks, interrupted": {
+			cfg:                   configuration.DefaultConfiguration(),
+			provideCounterStorage: true,
+			blockCount:            100,
+			endCondition:          configuration.InterruptedEndCondition,
+			endConditionDetail:    "signal received",
+			err:                   []error{errors.New("check halted")},
+			result: &CheckDataResults{
+				EndCondition: &EndCondition{
+					Type:   configuration.InterruptedEndCondition,
+					Detail: "signal received",
+				},
+				Stats: &CheckDataStats{
+					Blocks: 100,
+				},
+			},
+		},
 		"default configuration, no storage, balance errors": {
 			cfg: configuration.DefaultConfiguration(),
 			err: []error{storageErrs.ErrNegativeBalance},
@@ -354,6 +377,17 @@ func TestComputeCheckDataResults(t *testing.T) {
 				},
 			},
 		},
+		"default configuration, no storage, block audit errors": {
+			cfg: configuration.DefaultConfiguration(),
+			err: []error{ErrBlockAuditFailure},
+			result: &CheckDataResults{
+				Tests: &CheckDataTests{
+					RequestResponse:   true,
+					ResponseAssertion: true,
+					BlockAudit:        &f,
+				},
+			},
+		},
 		"default configuration, no storage, unknown errors": {
 			cfg:    configuration.DefaultConfiguration(),
 			err:    []error{errors.New("unsure how to handle this error")},
@@ -483,7 +517,7 @@ func TestComputeCheckDataResults(t *testing.T) {
 					)
 					assert.Equal(t, test.result, results)
 					results.Print() // make sure doesn't panic
-					results.Output(logPath)
+					results.Output(logPath, configuration.JSONResultsOutputFormat)
 
 					var output CheckDataResults
 					assert.NoError(t, utils.LoadAndParse(logPath, &output))
@@ -496,3 +530,166 @@ func TestComputeCheckDataResults(t *testing.T) {
 		})
 	}
 }
+
+func TestExitDataEndpointOverrides(t *testing.T) {
+	endpointOverrides := &httpclient.EndpointOverridesResults{
+		Endpoints: []*httpclient.FixtureEndpointResults{
+			{Endpoint: "/account/balance", Source: "balances.json", Served: 10},
+		},
+	}
+	runErr := errors.New("unable to sync")
+
+	var tests = map[string]struct {
+		endpointOverrides *httpclient.EndpointOverridesResults
+		allowSuccess      bool
+		err               error
+
+		expectedErr error
+	}{
+		"no overrides": {},
+		"overrides": {
+			endpointOverrides: endpointOverrides,
+			expectedErr:       httpclient.ErrEndpointOverrides,
+		},
+		"overrides with success allowed": {
+			endpointOverrides: endpointOverrides,
+			allowSuccess:      true,
+		},
+		"overrides with error": {
+			endpointOverrides: endpointOverrides,
+			err:               runErr,
+			expectedErr:       runErr,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := configuration.DefaultConfiguration()
+			config.Data.AllowEndpointOverridesSuccess = test.allowSuccess
+
+			_, err := ExitData(
+				config,
+				nil,
+				nil,
+				test.err,
+				configuration.IndexEndCondition,
+				"",
+				&ExitDataOptions{
+					EndpointOverrides: test.endpointOverrides,
+				},
+			)
+			if test.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expectedErr))
+			}
+		})
+	}
+}
+
+func TestExitDataCoinTracking(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	config := configuration.DefaultConfiguration()
+	config.Data.ResultsOutputFile = path.Join(dir, "results.json")
+
+	coinTracking := &CoinTrackingResults{
+		CoinsChecked: 12,
+		Discrepancy: &CoinDiscrepancy{
+			BlockIdentifier: &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+			CoinIdentifier:  &types.CoinIdentifier{Identifier: "tx1:0"},
+			Account:         &types.AccountIdentifier{Address: "addr1"},
+			Reason:          AmountMismatch,
+			Expected:        `{"value":"-100","currency":{"symbol":"BTC","decimals":8}}`,
+			Actual:          `{"value":"-90","currency":{"symbol":"BTC","decimals":8}}`,
+		},
+	}
+	runErr := fmt.Errorf("%w: %s", ErrCoinDiscrepancy, coinTracking.Discrepancy)
+
+	exitResults, err := ExitData(
+		config,
+		nil,
+		nil,
+		runErr,
+		"",
+		"",
+		&ExitDataOptions{
+			CoinTracking: coinTracking,
+		},
+	)
+	assert.True(t, errors.Is(err, ErrCoinDiscrepancy))
+	assert.Equal(t, coinTracking, exitResults.CoinTracking)
+
+	output, err := ioutil.ReadFile(config.Data.ResultsOutputFile)
+	assert.NoError(t, err)
+
+	var results CheckDataResults
+	assert.NoError(t, json.Unmarshal(output, &results))
+	assert.Equal(t, coinTracking, results.CoinTracking)
+}
+
+func TestExitDataConfigurationWarnings(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	config := configuration.DefaultConfiguration()
+	config.Data.ResultsOutputFile = path.Join(dir, "results.json")
+	config.Data.StartIndex = types.Int64(10)
+
+	runErr := errors.New("unable to sync")
+	_, err = ExitData(
+		config,
+		nil,
+		nil,
+		runErr,
+		"",
+		"",
+		nil,
+	)
+	assert.True(t, errors.Is(err, runErr))
+
+	output, err := ioutil.ReadFile(config.Data.ResultsOutputFile)
+	assert.NoError(t, err)
+
+	var results CheckDataResults
+	assert.NoError(t, json.Unmarshal(output, &results))
+	assert.Equal(t, configuration.DataWarnings(config), results.ConfigurationWarnings)
+	assert.Len(t, results.ConfigurationWarnings, 1)
+}
+
+func TestExitDataBlockHash(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	config := configuration.DefaultConfiguration()
+	config.Data.ResultsOutputFile = path.Join(dir, "results.json")
+
+	block := &types.BlockIdentifier{Index: 10, Hash: "block 10"}
+	_, err = ExitData(
+		config,
+		nil,
+		nil,
+		nil,
+		configuration.BlockHashEndCondition,
+		"Block: 10 (block 10)",
+		&ExitDataOptions{
+			EndBlock: block,
+		},
+	)
+	assert.NoError(t, err)
+
+	output, err := ioutil.ReadFile(config.Data.ResultsOutputFile)
+	assert.NoError(t, err)
+
+	var results CheckDataResults
+	assert.NoError(t, json.Unmarshal(output, &results))
+	assert.Equal(t, &EndCondition{
+		Type:   configuration.BlockHashEndCondition,
+		Detail: "Block: 10 (block 10)",
+		Block:  block,
+	}, results.EndCondition)
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import "fmt"
+
+// FieldDiff is a single field that differs
+// between a stored and fetched value.
+type FieldDiff struct {
+	Path    string `json:"path"`
+	Stored  string `json:"stored"`
+	Fetched string `json:"fetched"`
+}
+
+// String returns a human-readable representation
+// of a *FieldDiff.
+func (d *FieldDiff) String() string {
+	return fmt.Sprintf("%s: stored=%s fetched=%s", d.Path, d.Stored, d.Fetched)
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// DiscrepancyFailure is a failed reconciliation.
+type DiscrepancyFailure struct {
+	ReconciliationType string                   `json:"reconciliation_type"`
+	Account            *types.AccountIdentifier `json:"account_identifier"`
+	Currency           *types.Currency          `json:"currency"`
+	ComputedBalance    string                   `json:"computed_balance"`
+	LiveBalance        string                   `json:"live_balance"`
+	Block              *types.BlockIdentifier   `json:"block_identifier"`
+}
+
+// DiscrepancyResults are the reconciliation failures of a check:data run.
+type DiscrepancyResults struct {
+	// Count is the number of failures (including
+	// failures that were not retained).
+	Count int64 `json:"count"`
+
+	// Attempted is the number of reconciliations attempted
+	// (succeeded, exempt, or failed) during the run.
+	Attempted int64 `json:"attempted"`
+
+	// Exceeded describes the threshold that was exceeded
+	// (causing check:data to halt), if any.
+	Exceeded string `json:"exceeded,omitempty"`
+
+	// Failures are the first MaxDiscrepancyFailures failures
+	// (in the order they occurred).
+	Failures []*DiscrepancyFailure `json:"failures"`
+}
+
+// Print logs DiscrepancyResults to the console.
+func (r *DiscrepancyResults) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes DiscrepancyResults to w.
+func (r *DiscrepancyResults) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{
+		"Reconciliation Failures",
+		"Account",
+		"Currency",
+		"Computed",
+		"Live",
+		"Block",
+	})
+	for _, failure := range r.Failures {
+		table.Append([]string{
+			failure.ReconciliationType,
+			types.AccountString(failure.Account),
+			types.CurrencyString(failure.Currency),
+			failure.ComputedBalance,
+			failure.LiveBalance,
+			fmt.Sprintf("%d:%s", failure.Block.Index, failure.Block.Hash),
+		})
+	}
+	table.Render()
+
+	fmt.Fprintf(
+		w,
+		"%d of %d attempted reconciliations failed\n",
+		r.Count,
+		r.Attempted,
+	)
+	if len(r.Exceeded) > 0 {
+		color.New(color.FgRed).Fprintf(w, "[RECONCILIATION FAILURES] %s\n", r.Exceeded)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// EndConditionStatus is a configured end
+// condition that has been met.
+type EndConditionStatus struct {
+	Condition configuration.CheckDataEndCondition `json:"condition"`
+	Detail    string                              `json:"detail"`
+
+	// Index is the last synced block
+	// when the condition was met.
+	Index int64 `json:"index"`
+
+	// Timestamp is when the condition
+	// was met (in milliseconds).
+	Timestamp int64 `json:"timestamp"`
+}
+
+// EndConditionsResults are the end conditions met by a
+// check:data run with require_all enabled.
+type EndConditionsResults struct {
+	// Met is sorted by when each
+	// condition was met.
+	Met []*EndConditionStatus `json:"met"`
+
+	// Last is the condition that was met last
+	// (causing check:data to stop).
+	Last *EndConditionStatus `json:"last,omitempty"`
+}
+
+// Print logs the end conditions that were met.
+func (r *EndConditionsResults) Print() {
+	r.Fprint(os.Stdout)
+}
+
+// Fprint writes the end conditions that were met to w.
+func (r *EndConditionsResults) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"End Condition (Require All)", "Detail", "Index", "Met"})
+	for _, condition := range r.Met {
+		name := string(condition.Condition)
+		if r.Last == condition {
+			name = fmt.Sprintf("%s (last)", name)
+		}
+
+		table.Append([]string{
+			name,
+			condition.Detail,
+			strconv.FormatInt(condition.Index, 10),
+			time.Unix(0, condition.Timestamp*int64(time.Millisecond)).UTC().Format(time.RFC3339),
+		})
+	}
+
+	table.Render()
+}
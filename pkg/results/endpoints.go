@@ -0,0 +1,272 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/olekukonko/tablewriter"
+)
+
+// EndpointFailureType is the type of a failed call.
+type EndpointFailureType string
+
+const (
+	// ImplementationError is a call rejected by the
+	// implementation with a Rosetta error.
+	ImplementationError EndpointFailureType = "implementation_error"
+
+	// InvalidResponse is a call that returned a
+	// response that failed assertion.
+	InvalidResponse EndpointFailureType = "invalid_response"
+
+	// TransportFailure is a call that failed before the
+	// implementation responded (i.e. a timeout or a refused
+	// connection).
+	TransportFailure EndpointFailureType = "transport_failure"
+)
+
+// order is the order endpoints are printed in
+// (the order they are called while constructing
+// a transaction).
+var order = []string{
+	"/construction/derive",
+	"/construction/preprocess",
+	"/construction/metadata",
+	"/construction/payloads",
+	"/construction/parse",
+	"/construction/combine",
+	"/construction/hash",
+	"/construction/submit",
+}
+
+// EndpointFailure counts the failed calls to an endpoint
+// of a EndpointFailureType (and error code, if the
+// implementation returned a Rosetta error).
+type EndpointFailure struct {
+	Type    EndpointFailureType `json:"type"`
+	Code    *int32              `json:"code,omitempty"`
+	Count   int64               `json:"count"`
+	Example string              `json:"example"`
+}
+
+// key returns the key of a *EndpointFailure.
+func (f *EndpointFailure) key() string {
+	if f.Code == nil {
+		return string(f.Type)
+	}
+
+	return string(f.Type) + ":" + strconv.FormatInt(int64(*f.Code), 10)
+}
+
+// EndpointResults contains the outcome of
+// all calls to an endpoint.
+type EndpointResults struct {
+	Endpoint string             `json:"endpoint"`
+	Calls    int64              `json:"calls"`
+	Failures int64              `json:"failures"`
+	Errors   []*EndpointFailure `json:"errors"`
+}
+
+// ConstructionEndpointsResults contains the outcome of calls
+// to each construction endpoint.
+type ConstructionEndpointsResults struct {
+	Endpoints []*EndpointResults `json:"endpoints"`
+}
+
+// EndpointTracker records the outcome of calls
+// to construction endpoints.
+type EndpointTracker struct {
+	lock      sync.Mutex
+	endpoints map[string]*EndpointResults
+	failures  map[string]map[string]*EndpointFailure
+}
+
+// NewEndpointTracker returns a new *EndpointTracker.
+func NewEndpointTracker() *EndpointTracker {
+	return &EndpointTracker{
+		endpoints: map[string]*EndpointResults{},
+		failures:  map[string]map[string]*EndpointFailure{},
+	}
+}
+
+// failure returns the *EndpointFailure that fetchErr is counted as.
+func endpointsFailure(fetchErr *fetcher.Error) *EndpointFailure {
+	if fetchErr.ClientErr != nil {
+		code := fetchErr.ClientErr.Code
+		example := fetchErr.ClientErr.Message
+		if fetchErr.ClientErr.Description != nil {
+			example += ": " + *fetchErr.ClientErr.Description
+		}
+
+		return &EndpointFailure{Type: ImplementationError, Code: &code, Example: example}
+	}
+
+	failureType := InvalidResponse
+	if errors.Is(fetchErr.Err, fetcher.ErrRequestFailed) ||
+		errors.Is(fetchErr.Err, fetcher.ErrCouldNotAcquireSemaphore) {
+		failureType = TransportFailure
+	}
+
+	return &EndpointFailure{Type: failureType, Example: fetchErr.Err.Error()}
+}
+
+// Record records a call to endpoint that
+// failed with fetchErr (nil if it succeeded).
+func (t *EndpointTracker) Record(endpoint string, fetchErr *fetcher.Error) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	results, ok := t.endpoints[endpoint]
+	if !ok {
+		results = &EndpointResults{Endpoint: endpoint, Errors: []*EndpointFailure{}}
+		t.endpoints[endpoint] = results
+		t.failures[endpoint] = map[string]*EndpointFailure{}
+	}
+
+	results.Calls++
+	if fetchErr == nil {
+		return
+	}
+
+	results.Failures++
+	newFailure := endpointsFailure(fetchErr)
+	existing, ok := t.failures[endpoint][newFailure.key()]
+	if !ok {
+		existing = newFailure
+		t.failures[endpoint][newFailure.key()] = existing
+		results.Errors = append(results.Errors, existing)
+	}
+
+	existing.Count++
+}
+
+// position returns the position of
+// endpoint when printing *ConstructionEndpointsResults.
+func position(endpoint string) int {
+	for i, orderedEndpoint := range order {
+		if endpoint == orderedEndpoint {
+			return i
+		}
+	}
+
+	return len(order)
+}
+
+// ConstructionEndpointsResults returns the *ConstructionEndpointsResults of all recorded calls
+// (or nil if the *EndpointTracker is nil).
+func (t *EndpointTracker) Results() *ConstructionEndpointsResults {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	results := &ConstructionEndpointsResults{Endpoints: []*EndpointResults{}}
+	for _, endpoint := range t.endpoints {
+		errs := make([]*EndpointFailure, len(endpoint.Errors))
+		for i, endpointErr := range endpoint.Errors {
+			copied := *endpointErr
+			errs[i] = &copied
+		}
+
+		sort.SliceStable(errs, func(i, j int) bool {
+			return errs[i].Count > errs[j].Count
+		})
+
+		results.Endpoints = append(results.Endpoints, &EndpointResults{
+			Endpoint: endpoint.Endpoint,
+			Calls:    endpoint.Calls,
+			Failures: endpoint.Failures,
+			Errors:   errs,
+		})
+	}
+
+	sort.Slice(results.Endpoints, func(i, j int) bool {
+		a, b := results.Endpoints[i].Endpoint, results.Endpoints[j].Endpoint
+		if position(a) != position(b) {
+			return position(a) < position(b)
+		}
+
+		return a < b
+	})
+
+	return results
+}
+
+// Print logs ConstructionEndpointsResults to the console.
+func (r *ConstructionEndpointsResults) Print() {
+	r.Fprint(os.Stdout)
+}
+
+// Fprint writes ConstructionEndpointsResults to w.
+func (r *ConstructionEndpointsResults) Fprint(w io.Writer) {
+	if len(r.Endpoints) == 0 {
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{
+		"Construction Endpoint",
+		"Calls",
+		"Failures",
+		"Failure Type",
+		"Code",
+		"Count",
+		"Example",
+	})
+
+	table.SetAutoMergeCellsByColumnIndex([]int{0})
+	for _, endpoint := range r.Endpoints {
+		calls := strconv.FormatInt(endpoint.Calls, 10)
+		failures := strconv.FormatInt(endpoint.Failures, 10)
+		if len(endpoint.Errors) == 0 {
+			table.Append([]string{endpoint.Endpoint, calls, failures, "", "", "", ""})
+			continue
+		}
+
+		for _, endpointErr := range endpoint.Errors {
+			code := ""
+			if endpointErr.Code != nil {
+				code = strconv.FormatInt(int64(*endpointErr.Code), 10)
+			}
+
+			table.Append([]string{
+				endpoint.Endpoint,
+				calls,
+				failures,
+				string(endpointErr.Type),
+				code,
+				strconv.FormatInt(endpointErr.Count, 10),
+				endpointErr.Example,
+			})
+		}
+	}
+
+	table.Render()
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	// bytesInMB is the number of bytes in a MB.
+	estimateBytesInMB = 1024 * 1024
+)
+
+// ErrInsufficientStorage is returned when the estimated
+// storage of a run exceeds the available disk space.
+var ErrInsufficientStorage = errors.New("estimated storage exceeds available disk space")
+
+// EstimateInputs are the measurements an StorageEstimate
+// is extrapolated from.
+type EstimateInputs struct {
+	StartIndex    int64   `json:"start_index"`
+	EndIndex      int64   `json:"end_index"`
+	SampledBlocks []int64 `json:"sampled_blocks"`
+
+	// Compressed indicates if blocks were compressed
+	// (as they are in storage) when measured.
+	Compressed bool `json:"compressed"`
+
+	AverageSerializedBlockBytes int64 `json:"average_serialized_block_bytes"`
+	AverageEncodedBlockBytes    int64 `json:"average_encoded_block_bytes"`
+
+	// AccountsPerBlock is the average number of accounts
+	// (and currencies) with a balance change in each block.
+	AccountsPerBlock float64 `json:"accounts_per_block"`
+
+	// CoinChangesPerBlock is the average number of coins
+	// created or spent in each block.
+	CoinChangesPerBlock float64 `json:"coin_changes_per_block"`
+}
+
+// StorageEstimate is the storage required to sync
+// (and reconcile) a range of blocks.
+type StorageEstimate struct {
+	Inputs *EstimateInputs `json:"inputs"`
+
+	BlockStorageBytes   int64 `json:"block_storage_bytes"`
+	BalanceStorageBytes int64 `json:"balance_storage_bytes"`
+	CoinStorageBytes    int64 `json:"coin_storage_bytes"`
+	TotalBytes          int64 `json:"total_bytes"`
+
+	// AvailableBytes is only populated if the available
+	// disk space could be measured.
+	AvailableBytes *int64 `json:"available_bytes,omitempty"`
+
+	// ActualBytes is the size of the data
+	// directory when the run completed.
+	ActualBytes *int64 `json:"actual_bytes,omitempty"`
+}
+
+// Check returns ErrInsufficientStorage if the
+// estimate exceeds the available disk space.
+func (e *StorageEstimate) Check() error {
+	if e.AvailableBytes == nil || e.TotalBytes <= *e.AvailableBytes {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: estimated %d MB but only %d MB are available",
+		ErrInsufficientStorage,
+		e.TotalBytes/estimateBytesInMB,
+		*e.AvailableBytes/estimateBytesInMB,
+	)
+}
+
+// formatMB formats bytes as MB.
+func formatMB(bytes int64) string {
+	return strconv.FormatInt(bytes/estimateBytesInMB, 10) + " MB"
+}
+
+// Print logs the StorageEstimate to the console.
+func (e *StorageEstimate) Print() {
+	e.Fprint(os.Stdout)
+}
+
+// Fprint writes the StorageEstimate to w.
+func (e *StorageEstimate) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Storage Estimate", "Description", "Value"})
+	table.Append([]string{
+		"Blocks",
+		"# of blocks to sync",
+		strconv.FormatInt(e.Inputs.EndIndex-e.Inputs.StartIndex+1, 10),
+	})
+	table.Append([]string{
+		"Sampled Blocks",
+		"# of blocks measured",
+		strconv.Itoa(len(e.Inputs.SampledBlocks)),
+	})
+	table.Append([]string{
+		"Block Size",
+		"Average stored size of a sampled block",
+		strconv.FormatInt(e.Inputs.AverageEncodedBlockBytes, 10) + " B",
+	})
+	table.Append([]string{
+		"Accounts Per Block",
+		"Average # of accounts changed in a sampled block",
+		strconv.FormatFloat(e.Inputs.AccountsPerBlock, 'f', 2, 64),
+	})
+	table.Append([]string{"Block Storage", "Estimated", formatMB(e.BlockStorageBytes)})
+	table.Append([]string{"Balance Storage", "Estimated", formatMB(e.BalanceStorageBytes)})
+	table.Append([]string{"Coin Storage", "Estimated", formatMB(e.CoinStorageBytes)})
+	table.Append([]string{"Total", "Estimated", formatMB(e.TotalBytes)})
+	if e.AvailableBytes != nil {
+		table.Append([]string{"Available", "Free disk space", formatMB(*e.AvailableBytes)})
+	}
+	if e.ActualBytes != nil {
+		table.Append([]string{
+			"Actual",
+			"Data directory size at the end of the run",
+			formatMB(*e.ActualBytes),
+		})
+	}
+
+	table.Render()
+}
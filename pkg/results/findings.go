@@ -0,0 +1,202 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+// countFinding returns a *Finding for a
+// non-zero count (and nil otherwise).
+func countFinding(fingerprint string, description string, count int64) *Finding {
+	if count == 0 {
+		return nil
+	}
+
+	return &Finding{
+		Fingerprint: fingerprint,
+		Description: description,
+		Count:       count,
+	}
+}
+
+// appendFindings appends all non-nil findings.
+func appendFindings(findings []*Finding, more ...*Finding) []*Finding {
+	for _, finding := range more {
+		if finding != nil {
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+// DataFindings returns the findings of a check:data run
+// that ended with err (which may be nil).
+func DataFindings(c *CheckDataResults, err error) []*Finding {
+	findings := []*Finding{}
+	if err != nil {
+		findings = append(findings, ErrorFinding(err))
+	}
+
+	if c.Tests != nil {
+		tests := []struct {
+			name   string
+			passed *bool
+		}{
+			{"request_response", &c.Tests.RequestResponse},
+			{"response_assertion", &c.Tests.ResponseAssertion},
+			{"block_syncing", c.Tests.BlockSyncing},
+			{"balance_tracking", c.Tests.BalanceTracking},
+			{"reconciliation", c.Tests.Reconciliation},
+			{"block_audit", c.Tests.BlockAudit},
+		}
+		for _, test := range tests {
+			if test.passed != nil && !*test.passed {
+				findings = append(findings, &Finding{
+					Fingerprint: "test:" + test.name,
+					Description: fmt.Sprintf("check:data test %s failed", test.name),
+					Count:       1,
+				})
+			}
+		}
+	}
+
+	if c.Stats != nil {
+		findings = appendFindings(findings, countFinding(
+			"stat:failed_reconciliations",
+			"reconciliations failed",
+			c.Stats.FailedReconciliations,
+		))
+	}
+
+	if c.BlockAudit != nil {
+		divergences := map[DivergenceType]int64{}
+		divergenceTypes := []DivergenceType{}
+		for _, divergence := range c.BlockAudit.Divergences {
+			if _, ok := divergences[divergence.Type]; !ok {
+				divergenceTypes = append(divergenceTypes, divergence.Type)
+			}
+
+			divergences[divergence.Type]++
+		}
+
+		for _, divergenceType := range divergenceTypes {
+			findings = appendFindings(findings, countFinding(
+				"block_audit:"+string(divergenceType),
+				fmt.Sprintf("sampled blocks failed the block audit (%s)", divergenceType),
+				divergences[divergenceType],
+			))
+		}
+	}
+
+	if c.ReconciliationFailureHook != nil {
+		findings = appendFindings(findings, countFinding(
+			"reconciliation_failure_hook:failures",
+			"reconciliation failure hook invocations failed",
+			c.ReconciliationFailureHook.Failures,
+		))
+	}
+
+	if c.CurrencyCasing != nil {
+		for _, variant := range c.CurrencyCasing.Variants {
+			findings = append(findings, &Finding{
+				Fingerprint: "currency_casing:" + types.Hash(variant.Variant),
+				Description: fmt.Sprintf(
+					"currency %s differs from %s",
+					types.PrintStruct(variant.Variant),
+					types.PrintStruct(variant.Canonical),
+				),
+				Count: variant.Count,
+			})
+		}
+	}
+
+	return findings
+}
+
+// ConstructionFindings returns the findings of a
+// check:construction run that ended with err
+// (which may be nil).
+func ConstructionFindings(c *CheckConstructionResults, err error) []*Finding {
+	findings := []*Finding{}
+	if err != nil {
+		findings = append(findings, ErrorFinding(err))
+	}
+
+	if c.Stats != nil {
+		findings = appendFindings(
+			findings,
+			countFinding(
+				"stat:failed_broadcasts",
+				"broadcasts failed",
+				c.Stats.FailedBroadcasts,
+			),
+			countFinding(
+				"stat:unexpected_signing_payloads",
+				"signing payloads for accounts not in the intent",
+				c.Stats.UnexpectedSigningPayloads,
+			),
+			countFinding(
+				"stat:missing_signers",
+				"expected signers missing from signed transactions",
+				c.Stats.MissingSigners,
+			),
+			countFinding(
+				"stat:unexpected_signers",
+				"unexpected signers in signed transactions",
+				c.Stats.UnexpectedSigners,
+			),
+		)
+	}
+
+	return findings
+}
+
+// CompareBaseline compares findings with the baseline described
+// by config (if any) and returns the comparison and the error the
+// run should exit with. Unless config.ReportOnly is true, a run
+// fails if (and only if) it has new findings.
+func CompareBaseline(
+	config *configuration.BaselineConfiguration,
+	findings []*Finding,
+	err error,
+) (*BaselineComparison, error) {
+	if config == nil {
+		return nil, err
+	}
+
+	accepted, loadErr := LoadBaseline(config.File)
+	if loadErr != nil {
+		color.Red("%s: unable to compare findings with baseline", loadErr.Error())
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, loadErr
+	}
+
+	comparison := DiffBaseline(accepted, findings)
+	if config.ReportOnly {
+		return comparison, err
+	}
+
+	return comparison, comparison.Err()
+}
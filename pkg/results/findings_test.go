@@ -0,0 +1,178 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func fingerprints(findings []*Finding) []string {
+	values := []string{}
+	for _, finding := range findings {
+		values = append(values, finding.Fingerprint)
+	}
+
+	return values
+}
+
+func TestDataFindings(t *testing.T) {
+	failed := false
+	passed := true
+	variant := &types.Currency{Symbol: "btc", Decimals: 8}
+	results := &CheckDataResults{
+		Tests: &CheckDataTests{
+			RequestResponse:   true,
+			ResponseAssertion: true,
+			BlockSyncing:      &passed,
+			Reconciliation:    &failed,
+		},
+		Stats: &CheckDataStats{FailedReconciliations: 3},
+		BlockAudit: &BlockAuditResults{
+			Divergences: []*BlockDivergence{
+				{Index: 1, Type: FetchFailed},
+				{Index: 2, Type: FetchFailed},
+			},
+		},
+		ReconciliationFailureHook: &FailureHookStats{Calls: 3},
+		CurrencyCasing: &CurrencyCasingResults{
+			Variants: []*CurrencyVariant{
+				{
+					Canonical: &types.Currency{Symbol: "BTC", Decimals: 8},
+					Variant:   variant,
+					Count:     7,
+				},
+			},
+		},
+	}
+
+	findings := DataFindings(results, errors.New("reconciliation failed at block 10"))
+	assert.Equal(t, []string{
+		ErrorFinding(errors.New("reconciliation failed at block 99")).Fingerprint,
+		"test:reconciliation",
+		"stat:failed_reconciliations",
+		"block_audit:fetch_failed",
+		"currency_casing:" + types.Hash(variant),
+	}, fingerprints(findings))
+	assert.Equal(t, int64(2), findings[3].Count)
+	assert.Equal(t, int64(7), findings[4].Count)
+
+	assert.Len(t, DataFindings(&CheckDataResults{}, nil), 0)
+}
+
+func TestConstructionFindings(t *testing.T) {
+	findings := ConstructionFindings(&CheckConstructionResults{
+		Stats: &CheckConstructionStats{
+			TransactionsConfirmed: 10,
+			FailedBroadcasts:      1,
+			MissingSigners:        2,
+		},
+	}, nil)
+	assert.Equal(t, []string{
+		"stat:failed_broadcasts",
+		"stat:missing_signers",
+	}, fingerprints(findings))
+}
+
+func TestCompareBaseline(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	// The results of an accepted run with a failed reconciliation.
+	accepted := &CheckDataResults{
+		SchemaVersion: BaselineSchemaVersion,
+		RunID:         "accepted",
+		Stats:         &CheckDataStats{FailedReconciliations: 1},
+	}
+	accepted.Findings = DataFindings(accepted, nil)
+	baselinePath := path.Join(dir, "results.json")
+	accepted.Output(baselinePath, configuration.JSONResultsOutputFormat)
+
+	runErr := errors.New("unable to sync")
+	var tests = map[string]struct {
+		config   *configuration.BaselineConfiguration
+		findings []*Finding
+		err      error
+
+		expectedNew int
+		expectedErr error
+	}{
+		"no baseline": {
+			err:         runErr,
+			expectedErr: runErr,
+		},
+		"persisting findings": {
+			config: &configuration.BaselineConfiguration{File: baselinePath},
+			findings: []*Finding{
+				{Fingerprint: "stat:failed_reconciliations", Count: 4},
+			},
+		},
+		"new findings": {
+			config: &configuration.BaselineConfiguration{File: baselinePath},
+			findings: []*Finding{
+				ErrorFinding(runErr),
+			},
+			err:         runErr,
+			expectedNew: 1,
+			expectedErr: ErrNewFindings,
+		},
+		"report only": {
+			config: &configuration.BaselineConfiguration{File: baselinePath, ReportOnly: true},
+			findings: []*Finding{
+				ErrorFinding(runErr),
+			},
+			err:         runErr,
+			expectedNew: 1,
+			expectedErr: runErr,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			comparison, err := CompareBaseline(test.config, test.findings, test.err)
+			if test.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expectedErr))
+			}
+
+			if test.config == nil {
+				assert.Nil(t, comparison)
+				return
+			}
+
+			assert.Len(t, comparison.New, test.expectedNew)
+			assert.Equal(t, "accepted", comparison.Baseline.RunID)
+			assert.Equal(t, baselinePath, comparison.Baseline.Path)
+		})
+	}
+
+	// A baseline that can't be loaded fails a successful run.
+	comparison, err := CompareBaseline(
+		&configuration.BaselineConfiguration{File: path.Join(dir, "missing.json")},
+		nil,
+		nil,
+	)
+	assert.Error(t, err)
+	assert.Nil(t, comparison)
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// colorCodes matches the escape codes
+// added by github.com/fatih/color.
+var colorCodes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// renderable is implemented by all results
+// that can be written to a results file.
+type renderable interface {
+	Fprint(w io.Writer)
+}
+
+// Render returns results in format.
+func Render(format configuration.ResultsOutputFormat, results renderable) ([]byte, error) {
+	switch format {
+	case "", configuration.JSONResultsOutputFormat:
+		return []byte(types.PrettyPrintStruct(results)), nil
+	case configuration.YAMLResultsOutputFormat:
+		// Results are converted from JSON so that
+		// YAML keys match the JSON field names
+		// (and are written in the same order).
+		b, err := json.Marshal(results)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to marshal results", err)
+		}
+
+		var fields yaml.MapSlice
+		if err := yaml.Unmarshal(b, &fields); err != nil {
+			return nil, fmt.Errorf("%w: unable to convert results to YAML", err)
+		}
+
+		return yaml.Marshal(fields)
+	case configuration.TextResultsOutputFormat:
+		var buf bytes.Buffer
+		results.Fprint(&buf)
+		return colorCodes.ReplaceAll(buf.Bytes(), nil), nil
+	default:
+		return nil, fmt.Errorf("results output format %s is not supported", format)
+	}
+}
+
+// writeResults writes results to path in format.
+func writeResults(
+	path string,
+	format configuration.ResultsOutputFormat,
+	results renderable,
+) error {
+	b, err := Render(format, results)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, b, os.FileMode(utils.DefaultFilePermissions)); err != nil {
+		return fmt.Errorf("%w: unable to write to file path %s", err, path)
+	}
+
+	return nil
+}
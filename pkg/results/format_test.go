@@ -0,0 +1,135 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"flag"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRender(t *testing.T) {
+	// Make sure color codes are stripped
+	// from text output.
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	dataResults := &CheckDataResults{
+		SchemaVersion: BaselineSchemaVersion,
+		RunID:         "run",
+		Error:         "reconciliation failure",
+		Tests: &CheckDataTests{
+			RequestResponse:   true,
+			ResponseAssertion: true,
+			BlockSyncing:      types.Bool(true),
+			BalanceTracking:   types.Bool(true),
+			Reconciliation:    types.Bool(false),
+		},
+		Stats: &CheckDataStats{
+			Blocks:                 100,
+			Transactions:           250,
+			Operations:             500,
+			Accounts:               40,
+			ActiveReconciliations:  30,
+			FailedReconciliations:  1,
+			ReconciliationCoverage: 0.75,
+		},
+		Findings: []*Finding{
+			{
+				Fingerprint: "error:reconciliation failure",
+				Description: "reconciliation failure",
+				Count:       1,
+			},
+		},
+	}
+
+	constructionResults := &CheckConstructionResults{
+		SchemaVersion: BaselineSchemaVersion,
+		EndConditions: map[string]int{"transfer": 10},
+		Stats: &CheckConstructionStats{
+			TransactionsConfirmed: 10,
+			TransactionsCreated:   12,
+			AddressesCreated:      4,
+			WorkflowsCompleted:    map[string]int64{"transfer": 10},
+		},
+		Findings: []*Finding{},
+	}
+
+	var tests = map[string]struct {
+		results renderable
+		format  configuration.ResultsOutputFormat
+		golden  string
+	}{
+		"data json": {
+			results: dataResults,
+			format:  configuration.JSONResultsOutputFormat,
+			golden:  "data_results.json",
+		},
+		"data yaml": {
+			results: dataResults,
+			format:  configuration.YAMLResultsOutputFormat,
+			golden:  "data_results.yaml",
+		},
+		"data text": {
+			results: dataResults,
+			format:  configuration.TextResultsOutputFormat,
+			golden:  "data_results.txt",
+		},
+		"construction json": {
+			results: constructionResults,
+			format:  configuration.JSONResultsOutputFormat,
+			golden:  "construction_results.json",
+		},
+		"construction yaml": {
+			results: constructionResults,
+			format:  configuration.YAMLResultsOutputFormat,
+			golden:  "construction_results.yaml",
+		},
+		"construction text": {
+			results: constructionResults,
+			format:  configuration.TextResultsOutputFormat,
+			golden:  "construction_results.txt",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rendered, err := Render(test.format, test.results)
+			assert.NoError(t, err)
+
+			goldenPath := path.Join("testdata", test.golden)
+			if *update {
+				assert.NoError(t, ioutil.WriteFile(goldenPath, rendered, 0600))
+			}
+
+			expected, err := ioutil.ReadFile(goldenPath)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), string(rendered))
+		})
+	}
+
+	_, err := Render("xml", dataResults)
+	assert.Error(t, err)
+}
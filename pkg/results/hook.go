@@ -0,0 +1,52 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// FailureHookStats summarizes the invocations of the
+// reconciliation failure hook.
+type FailureHookStats struct {
+	Calls                         int64 `json:"calls"`
+	Failures                      int64 `json:"failures"`
+	SlowestInvocationMilliseconds int64 `json:"slowest_invocation_milliseconds"`
+}
+
+// Print logs FailureHookStats to the console.
+func (s *FailureHookStats) Print() {
+	s.Fprint(os.Stdout)
+}
+
+// Fprint writes FailureHookStats to w.
+func (s *FailureHookStats) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Reconciliation Failure Hook", "Value"})
+	table.Append([]string{"Calls", strconv.FormatInt(s.Calls, 10)})
+	table.Append([]string{"Failures", strconv.FormatInt(s.Failures, 10)})
+	table.Append([]string{
+		"Slowest Invocation",
+		(time.Duration(s.SlowestInvocationMilliseconds) * time.Millisecond).String(),
+	})
+	table.Render()
+}
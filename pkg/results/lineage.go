@@ -0,0 +1,40 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import "github.com/coinbase/rosetta-sdk-go/types"
+
+// FundsCategory describes where an account came from.
+type FundsCategory string
+
+// CategoryFlow is the total inflow and outflow of a
+// currency for a category of accounts.
+type CategoryFlow struct {
+	Category FundsCategory   `json:"category"`
+	Currency *types.Currency `json:"currency"`
+	Inflow   string          `json:"inflow"`
+	Outflow  string          `json:"outflow"`
+}
+
+// FundsFlow summarizes the movement of funds across
+// all transactions involving tracked accounts.
+type FundsFlow struct {
+	Flows []*CategoryFlow `json:"flows"`
+
+	// Net is the sum of all balance changes for each currency.
+	// Any value other than the (negative) fees paid indicates
+	// that funds are unaccounted for.
+	Net []*types.Amount `json:"net"`
+}
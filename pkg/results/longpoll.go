@@ -0,0 +1,116 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// ErrOrphanedBlock is returned when a block is orphaned
+// and the *TipPoller halts on reorgs.
+var ErrOrphanedBlock = errors.New("block orphaned with halt_on_reorg enabled")
+
+// TipPollingResults summarizes the requests made and the latency of
+// blocks added after syncing reached tip.
+type TipPollingResults struct {
+	LongPoll bool `json:"long_poll"`
+
+	// FellBack is true if long polling was disabled
+	// after an unexpected error.
+	FellBack bool `json:"fell_back,omitempty"`
+
+	TipSeconds        int64   `json:"tip_seconds"`
+	StatusRequests    int64   `json:"status_requests"`
+	BlockRequests     int64   `json:"block_requests"`
+	NotFoundResponses int64   `json:"not_found_responses"`
+	RequestsPerMinute float64 `json:"requests_per_minute"`
+
+	Blocks           int64 `json:"blocks"`
+	LongPolledBlocks int64 `json:"long_polled_blocks"`
+
+	// Latency is measured from the block timestamp
+	// to when the block was added.
+	AverageLatencyMs int64 `json:"average_latency_ms"`
+	MaxLatencyMs     int64 `json:"max_latency_ms"`
+}
+
+// Print logs the TipPollingResults to the console.
+func (r *TipPollingResults) Print() {
+	r.Fprint(os.Stdout)
+}
+
+// Fprint writes the TipPollingResults to w.
+func (r *TipPollingResults) Fprint(w io.Writer) {
+	mode := "polling"
+	if r.LongPoll {
+		mode = "long polling"
+	}
+	if r.FellBack {
+		mode = "long polling (fell back to polling)"
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Tip Syncing", "Description", "Value"})
+	table.Append([]string{"Mode", "How new blocks were found at tip", mode})
+	table.Append([]string{
+		"Time At Tip",
+		"Seconds since syncing reached tip",
+		strconv.FormatInt(r.TipSeconds, 10),
+	})
+	table.Append([]string{
+		"Status Requests",
+		"# of /network/status requests at tip",
+		strconv.FormatInt(r.StatusRequests, 10),
+	})
+	table.Append([]string{
+		"Block Requests",
+		"# of /block requests at tip",
+		strconv.FormatInt(r.BlockRequests, 10),
+	})
+	table.Append([]string{
+		"Not Found Responses",
+		"# of /block requests for blocks that did not exist yet",
+		strconv.FormatInt(r.NotFoundResponses, 10),
+	})
+	table.Append([]string{
+		"Request Rate",
+		"Requests per minute at tip",
+		strconv.FormatFloat(r.RequestsPerMinute, 'f', 2, 64),
+	})
+	table.Append([]string{
+		"Blocks",
+		"# of blocks added at tip",
+		strconv.FormatInt(r.Blocks, 10),
+	})
+	table.Append([]string{
+		"Average Latency",
+		"Average time from block timestamp to block added",
+		strconv.FormatInt(r.AverageLatencyMs, 10) + " ms",
+	})
+	table.Append([]string{
+		"Max Latency",
+		"Max time from block timestamp to block added",
+		strconv.FormatInt(r.MaxLatencyMs, 10) + " ms",
+	})
+
+	table.Render()
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/olekukonko/tablewriter"
+)
+
+// MonitorFinding is a reconciliation failure found
+// while in monitor mode.
+type MonitorFinding struct {
+	Timestamp          int64                    `json:"timestamp"`
+	ReconciliationType string                   `json:"reconciliation_type"`
+	Account            *types.AccountIdentifier `json:"account_identifier"`
+	Currency           *types.Currency          `json:"currency"`
+	ComputedBalance    string                   `json:"computed_balance"`
+	LiveBalance        string                   `json:"live_balance"`
+	Block              *types.BlockIdentifier   `json:"block_identifier"`
+}
+
+// FindingsMonitorResults contains all findings of monitor mode. Only
+// the MaxMonitorFindings most recent findings are retained but
+// Failures counts all of them.
+type FindingsMonitorResults struct {
+	StartedAt int64             `json:"started_at"`
+	Failures  int64             `json:"failures"`
+	Findings  []*MonitorFinding `json:"findings"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Print logs FindingsMonitorResults to the console.
+func (r *FindingsMonitorResults) Print() {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Monitor Mode", "Value"})
+	table.Append([]string{
+		"Started At",
+		time.Unix(0, r.StartedAt*int64(time.Millisecond)).UTC().Format(time.RFC3339),
+	})
+	table.Append([]string{"Reconciliation Failures", strconv.FormatInt(r.Failures, 10)})
+	if len(r.Error) > 0 {
+		table.Append([]string{"Error", r.Error})
+	}
+	table.Render()
+}
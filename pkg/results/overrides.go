@@ -0,0 +1,64 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// TransactionOverridesResults summarizes the overrides applied
+// during a run.
+type TransactionOverridesResults struct {
+	Configured int                            `json:"configured"`
+	Applied    int64                          `json:"applied"`
+	Unobserved []*types.TransactionIdentifier `json:"unobserved"`
+}
+
+// Print logs TransactionOverridesResults to the console. Overrides silently change
+// the outcome of a run, so they are always printed as a warning.
+func (r *TransactionOverridesResults) Print() {
+	r.Fprint(color.Output)
+}
+
+// Fprint writes TransactionOverridesResults to w.
+func (r *TransactionOverridesResults) Fprint(w io.Writer) {
+	color.New(color.FgRed).Fprintf(
+		w,
+		"[WARNING] balance changes of %d transaction(s) were overridden by transaction_overrides\n",
+		r.Configured,
+	)
+
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Transaction Overrides", "Value"})
+	table.Append([]string{"Configured", strconv.Itoa(r.Configured)})
+	table.Append([]string{"Applied", strconv.FormatInt(r.Applied, 10)})
+	table.Append([]string{"Unobserved", strconv.Itoa(len(r.Unobserved))})
+	table.Render()
+
+	for _, transactionIdentifier := range r.Unobserved {
+		color.New(color.FgRed).Fprintf(
+			w,
+			"[OVERRIDE] transaction %s was never observed\n",
+			transactionIdentifier.Hash,
+		)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// StatDelta is the change of a stat
+// since the previous run.
+type StatDelta struct {
+	Stat     string  `json:"stat"`
+	Previous float64 `json:"previous"`
+	Current  float64 `json:"current"`
+
+	// Change is the relative change from Previous
+	// (omitted if Previous is 0).
+	Change *float64 `json:"change,omitempty"`
+}
+
+// StatsDelta compares the stats of a run
+// with those of the previous run.
+type StatsDelta struct {
+	PreviousFile  string       `json:"previous_file"`
+	PreviousRunID string       `json:"previous_run_id,omitempty"`
+	Deltas        []*StatDelta `json:"deltas"`
+}
+
+// Print logs StatsDelta to the console.
+func (d *StatsDelta) Print() {
+	d.Fprint(color.Output)
+}
+
+// Fprint writes StatsDelta to w.
+func (d *StatsDelta) Fprint(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Previous Run", "Previous", "Current", "Change"})
+	for _, delta := range d.Deltas {
+		change := "n/a"
+		if delta.Change != nil {
+			change = fmt.Sprintf("%+.2f%%", *delta.Change*100) // nolint:gomnd
+		}
+
+		table.Append([]string{
+			delta.Stat,
+			fmt.Sprintf("%.2f", delta.Previous),
+			fmt.Sprintf("%.2f", delta.Current),
+			change,
+		})
+	}
+
+	table.Render()
+}
+
+// previousRunFile is the subset of a results file
+// compared with the stats of the current run.
+type previousRunFile struct {
+	RunID string          `json:"run_id"`
+	Stats *CheckDataStats `json:"stats"`
+}
+
+// CompareStats returns the *StatDelta of each compared stat of current
+// relative to previous.
+func CompareStats(previous *CheckDataStats, current *CheckDataStats) []*StatDelta {
+	stats := []struct {
+		name     string
+		previous float64
+		current  float64
+	}{
+		{"Blocks Per Second", previous.BlocksPerSecond, current.BlocksPerSecond},
+		{"Duration (seconds)", previous.Duration, current.Duration},
+		{"Blocks", float64(previous.Blocks), float64(current.Blocks)},
+		{
+			"Active Reconciliations",
+			float64(previous.ActiveReconciliations),
+			float64(current.ActiveReconciliations),
+		},
+		{
+			"Inactive Reconciliations",
+			float64(previous.InactiveReconciliations),
+			float64(current.InactiveReconciliations),
+		},
+		{
+			"Failed Reconciliations",
+			float64(previous.FailedReconciliations),
+			float64(current.FailedReconciliations),
+		},
+	}
+
+	deltas := make([]*StatDelta, len(stats))
+	for i, stat := range stats {
+		deltas[i] = &StatDelta{
+			Stat:     stat.name,
+			Previous: stat.previous,
+			Current:  stat.current,
+		}
+
+		if stat.previous != 0 {
+			change := (stat.current - stat.previous) / stat.previous
+			deltas[i].Change = &change
+		}
+	}
+
+	return deltas
+}
+
+// ComparePreviousRun returns the *StatsDelta of stats relative to
+// the stats in the JSON results file at path. If path is not
+// populated, does not exist, or has no stats, nil is returned
+// (the current run is reported as usual).
+func ComparePreviousRun(path string, stats *CheckDataStats) *StatsDelta {
+	if len(path) == 0 || stats == nil {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(path) // #nosec G304
+	if os.IsNotExist(err) {
+		color.Yellow("no previous results found at %s, skipping comparison", path)
+		return nil
+	}
+	if err != nil {
+		color.Red("%s: unable to read previous results %s", err.Error(), path)
+		return nil
+	}
+
+	var file previousRunFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		color.Red("%s: unable to parse previous results %s", err.Error(), path)
+		return nil
+	}
+
+	if file.Stats == nil {
+		color.Yellow("previous results %s have no stats, skipping comparison", path)
+		return nil
+	}
+
+	return &StatsDelta{
+		PreviousFile:  path,
+		PreviousRunID: file.RunID,
+		Deltas:        CompareStats(file.Stats, stats),
+	}
+}
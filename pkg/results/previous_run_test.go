@@ -0,0 +1,138 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComparePreviousRun(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	previous := &CheckDataResults{
+		RunID: "previous",
+		Stats: &CheckDataStats{
+			Blocks:                  1000,
+			ActiveReconciliations:   200,
+			InactiveReconciliations: 50,
+			Duration:                100,
+			BlocksPerSecond:         10,
+		},
+	}
+	previousPath := path.Join(dir, "previous.json")
+	previous.Output(previousPath, configuration.JSONResultsOutputFormat)
+
+	noStatsPath := path.Join(dir, "no_stats.json")
+	(&CheckDataResults{RunID: "failed"}).Output(noStatsPath, configuration.JSONResultsOutputFormat)
+
+	current := &CheckDataStats{
+		Blocks:                  1200,
+		ActiveReconciliations:   200,
+		InactiveReconciliations: 100,
+		FailedReconciliations:   1,
+		Duration:                200,
+		BlocksPerSecond:         6,
+	}
+
+	var tests = map[string]struct {
+		path  string
+		stats *CheckDataStats
+
+		expected *StatsDelta
+	}{
+		"not configured": {
+			stats: current,
+		},
+		"no previous results": {
+			path:  path.Join(dir, "missing.json"),
+			stats: current,
+		},
+		"previous results without stats": {
+			path:  noStatsPath,
+			stats: current,
+		},
+		"no current stats": {
+			path: previousPath,
+		},
+		"previous results": {
+			path:  previousPath,
+			stats: current,
+			expected: &StatsDelta{
+				PreviousFile:  previousPath,
+				PreviousRunID: "previous",
+				Deltas: []*StatDelta{
+					{Stat: "Blocks Per Second", Previous: 10, Current: 6, Change: float(-0.4)},
+					{Stat: "Duration (seconds)", Previous: 100, Current: 200, Change: float(1)},
+					{Stat: "Blocks", Previous: 1000, Current: 1200, Change: float(0.2)},
+					{
+						Stat:     "Active Reconciliations",
+						Previous: 200,
+						Current:  200,
+						Change:   float(0),
+					},
+					{
+						Stat:     "Inactive Reconciliations",
+						Previous: 50,
+						Current:  100,
+						Change:   float(1),
+					},
+					{Stat: "Failed Reconciliations", Previous: 0, Current: 1},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			delta := ComparePreviousRun(test.path, test.stats)
+			if test.expected == nil {
+				assert.Nil(t, delta)
+				return
+			}
+
+			assert.Equal(t, test.expected.PreviousFile, delta.PreviousFile)
+			assert.Equal(t, test.expected.PreviousRunID, delta.PreviousRunID)
+			assert.Len(t, delta.Deltas, len(test.expected.Deltas))
+			for i, expected := range test.expected.Deltas {
+				assert.Equal(t, expected.Stat, delta.Deltas[i].Stat)
+				assert.Equal(t, expected.Previous, delta.Deltas[i].Previous)
+				assert.Equal(t, expected.Current, delta.Deltas[i].Current)
+				if expected.Change == nil {
+					assert.Nil(t, delta.Deltas[i].Change)
+					continue
+				}
+				assert.InDelta(t, *expected.Change, *delta.Deltas[i].Change, 0.0001)
+			}
+
+			var b bytes.Buffer
+			delta.Fprint(&b)
+			assert.Contains(t, b.String(), "-40.00%")
+			assert.Contains(t, b.String(), "n/a")
+		})
+	}
+}
+
+func float(f float64) *float64 {
+	return &f
+}
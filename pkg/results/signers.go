@@ -0,0 +1,31 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+const (
+	// UnexpectedPayloadsCounter is the number of signing payloads
+	// returned by /construction/payloads for an account that
+	// is not a signer of the intent.
+	UnexpectedPayloadsCounter = "unexpected_signing_payloads"
+
+	// MissingSignersCounter is the number of expected signers
+	// not returned by /construction/parse of a signed transaction.
+	MissingSignersCounter = "missing_signers"
+
+	// UnexpectedSignersCounter is the number of signers returned
+	// by /construction/parse of a signed transaction that
+	// were not expected.
+	UnexpectedSignersCounter = "unexpected_signers"
+)
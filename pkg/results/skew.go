@@ -0,0 +1,35 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import "time"
+
+// SkewEstimate is the estimated difference between the clock
+// of the host running rosetta-cli and the clock of the
+// Rosetta implementation.
+type SkewEstimate struct {
+	// SkewMilliseconds is how far the local clock is ahead
+	// of the implementation clock (negative if it is behind).
+	SkewMilliseconds   int64  `json:"skew_milliseconds"`
+	Source             string `json:"source"`
+	LocalTime          string `json:"local_time"`
+	ImplementationTime string `json:"implementation_time"`
+	Corrected          bool   `json:"corrected"`
+}
+
+// Skew returns SkewMilliseconds as a time.Duration.
+func (e *SkewEstimate) Skew() time.Duration {
+	return time.Duration(e.SkewMilliseconds) * time.Millisecond
+}
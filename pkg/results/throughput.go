@@ -0,0 +1,37 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+// ThroughputProgress is the syncing throughput of check:data
+// and, if it is syncing to a target, the estimated
+// time until the target is synced.
+type ThroughputProgress struct {
+	// Head is the index of the last synced block.
+	Head int64 `json:"head"`
+
+	// CurrentRate is the number of blocks synced per second
+	// over the rolling window and AverageRate is the number
+	// of blocks synced per second since the first observation.
+	CurrentRate float64 `json:"current_rate"`
+	AverageRate float64 `json:"average_rate"`
+
+	// Target, BlocksRemaining, and ETA are only populated
+	// if check:data is syncing to a target (an index or tip
+	// end condition). ETA is only populated once the target
+	// is synced faster than it moves forward.
+	Target          *int64 `json:"target,omitempty"`
+	BlocksRemaining *int64 `json:"blocks_remaining,omitempty"`
+	ETA             string `json:"eta,omitempty"`
+}
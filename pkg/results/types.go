@@ -21,6 +21,14 @@ import (
 const (
 	// TimeElapsedCounter tracks the total time elapsed in seconds.
 	TimeElapsedCounter = "time_elapsed"
+
+	// ReconciliationTimeoutCounter tracks the number of account
+	// balance fetches that exceeded reconciliation_timeout.
+	ReconciliationTimeoutCounter = "reconciliation_timeouts"
+
+	// CurrencyExemptReconciliationCounter tracks the number of
+	// reconciliations skipped because their currency is exempt.
+	CurrencyExemptReconciliationCounter = "currency_exempt_reconciliations"
 )
 
 var (
@@ -28,4 +36,8 @@ var (
 	// TODO: Move to reconciler package (had to remove from processor
 	// to prevent circular dependency)
 	ErrReconciliationFailure = errors.New("reconciliation failure")
+
+	// ErrDurationExceeded is returned if check:construction
+	// reaches its duration before its end conditions.
+	ErrDurationExceeded = errors.New("duration exceeded before end conditions were reached")
 )
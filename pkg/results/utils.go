@@ -17,8 +17,15 @@ package results
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
 )
 
 // JSONFetch makes a GET request to the URL and marshals
@@ -45,3 +52,37 @@ func JSONFetch(url string, output interface{}) error {
 
 	return nil
 }
+
+// fprintConfigurationWarnings writes each *configuration.Warning to w.
+func fprintConfigurationWarnings(w io.Writer, warnings []*configuration.Warning) {
+	for _, warning := range warnings {
+		color.New(color.FgYellow).Fprintf(w, "[CONFIGURATION WARNING] %s\n", warning)
+	}
+}
+
+// printClockSkew writes a *SkewEstimate to w.
+func printClockSkew(w io.Writer, estimate *SkewEstimate) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Clock Skew", "Description", "Value"})
+	table.Append([]string{
+		"Skew",
+		"Local clock minus implementation clock",
+		estimate.Skew().Round(time.Millisecond).String(),
+	})
+	table.Append([]string{"Source", "How the skew was estimated", estimate.Source})
+	table.Append([]string{"Local Time", "Local clock at estimation", estimate.LocalTime})
+	table.Append([]string{
+		"Implementation Time",
+		"Implementation clock at estimation",
+		estimate.ImplementationTime,
+	})
+	table.Append([]string{
+		"Corrected",
+		"Tip delay adjusted by skew",
+		fmt.Sprintf("%t", estimate.Corrected),
+	})
+
+	table.Render()
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+// WriteAsserterConfiguration saves the configuration of an
+// initialized *asserter.Asserter to filePath with all array
+// fields sorted so that it can be diffed across releases.
+func WriteAsserterConfiguration(a *asserter.Asserter, filePath string) error {
+	configuration, err := a.ClientConfiguration()
+	if err != nil {
+		return fmt.Errorf("%w: unable to generate spec", err)
+	}
+
+	sortArrayFieldsOnConfiguration(configuration)
+
+	if err := utils.SerializeAndWrite(filePath, configuration); err != nil {
+		return fmt.Errorf("%w: unable to serialize asserter configuration", err)
+	}
+
+	return nil
+}
+
+func sortArrayFieldsOnConfiguration(configuration *asserter.Configuration) {
+	sort.Strings(configuration.AllowedOperationTypes)
+	sort.Slice(configuration.AllowedOperationStatuses, func(i, j int) bool {
+		return configuration.AllowedOperationStatuses[i].Status < configuration.AllowedOperationStatuses[j].Status
+	})
+	sort.Slice(configuration.AllowedErrors, func(i, j int) bool {
+		return configuration.AllowedErrors[i].Code < configuration.AllowedErrors[j].Code
+	})
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	basicNetwork = &types.NetworkIdentifier{
+		Blockchain: "blockchain",
+		Network:    "network",
+	}
+
+	basicBlock = &types.BlockIdentifier{
+		Index: 10,
+		Hash:  "block 10",
+	}
+
+	allowedOperationTypes = []string{"OUTPUT", "INPUT", "TRANSFER"}
+
+	allowedOperationStatuses = []*types.OperationStatus{
+		{
+			Status:     "SUCCESS",
+			Successful: true,
+		},
+		{
+			Status:     "SKIPPED",
+			Successful: true,
+		},
+	}
+
+	allowedErrors = []*types.Error{
+		{
+			Code:      4,
+			Message:   "Block not found",
+			Retriable: false,
+		},
+		{
+			Code:      0,
+			Message:   "Endpoint not implemented",
+			Retriable: false,
+		},
+		{
+			Code:      3,
+			Message:   "Bitcoind error",
+			Retriable: false,
+		},
+	}
+
+	timestampStartIndex = int64(6)
+)
+
+func TestSortArrayFields(t *testing.T) {
+	var clientConfiguration = &asserter.Configuration{
+		NetworkIdentifier:          basicNetwork,
+		GenesisBlockIdentifier:     basicBlock,
+		AllowedOperationTypes:      allowedOperationTypes,
+		AllowedOperationStatuses:   allowedOperationStatuses,
+		AllowedErrors:              allowedErrors,
+		AllowedTimestampStartIndex: timestampStartIndex,
+	}
+	var assert = assert.New(t)
+	sortArrayFieldsOnConfiguration(clientConfiguration)
+	assert.Equal([]string{"INPUT", "OUTPUT", "TRANSFER"}, clientConfiguration.AllowedOperationTypes)
+	assert.Equal([]*types.OperationStatus{
+		{
+			Status:     "SKIPPED",
+			Successful: true,
+		},
+		{
+			Status:     "SUCCESS",
+			Successful: true,
+		},
+	}, clientConfiguration.AllowedOperationStatuses)
+	assert.Equal([]*types.Error{
+		{
+			Code:      0,
+			Message:   "Endpoint not implemented",
+			Retriable: false,
+		},
+		{
+			Code:      3,
+			Message:   "Bitcoind error",
+			Retriable: false,
+		},
+		{
+			Code:      4,
+			Message:   "Block not found",
+			Retriable: false,
+		},
+	}, clientConfiguration.AllowedErrors)
+}
+
+func TestWriteAsserterConfiguration(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var response interface{}
+			switch r.URL.Path {
+			case "/network/list":
+				response = &types.NetworkListResponse{
+					NetworkIdentifiers: []*types.NetworkIdentifier{basicNetwork},
+				}
+			case "/network/status":
+				response = &types.NetworkStatusResponse{
+					CurrentBlockIdentifier: basicBlock,
+					CurrentBlockTimestamp:  1600000000000,
+					GenesisBlockIdentifier: basicBlock,
+				}
+			case "/network/options":
+				response = &types.NetworkOptionsResponse{
+					Version: &types.Version{
+						RosettaVersion: "1.4.10",
+						NodeVersion:    "1.0",
+					},
+					Allow: &types.Allow{
+						OperationStatuses:       allowedOperationStatuses,
+						OperationTypes:          allowedOperationTypes,
+						Errors:                  allowedErrors,
+						TimestampStartIndex:     &timestampStartIndex,
+						HistoricalBalanceLookup: true,
+					},
+				}
+			default:
+				t.Fatalf("unexpected request to %s", r.URL.Path)
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			assert.NoError(t, json.NewEncoder(w).Encode(response))
+		}),
+	)
+	defer ts.Close()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	f := fetcher.New(ts.URL, fetcher.WithMaxRetries(0))
+	_, _, fetchErr := f.InitializeAsserter(context.Background(), basicNetwork, "")
+	assert.Nil(t, fetchErr)
+
+	filePath := path.Join(dir, "asserter.json")
+	assert.NoError(t, WriteAsserterConfiguration(f.Asserter, filePath))
+
+	var configuration asserter.Configuration
+	assert.NoError(t, utils.LoadAndParse(filePath, &configuration))
+	assert.Equal(t, basicNetwork, configuration.NetworkIdentifier)
+	assert.Equal(t, basicBlock, configuration.GenesisBlockIdentifier)
+	assert.Equal(t, []string{"INPUT", "OUTPUT", "TRANSFER"}, configuration.AllowedOperationTypes)
+	assert.Equal(t, "SKIPPED", configuration.AllowedOperationStatuses[0].Status)
+	assert.Equal(t, "SUCCESS", configuration.AllowedOperationStatuses[1].Status)
+	assert.Len(t, configuration.AllowedErrors, 3)
+	assert.Equal(t, int32(0), configuration.AllowedErrors[0].Code)
+	assert.Equal(t, int32(4), configuration.AllowedErrors[2].Code)
+	assert.Equal(t, timestampStartIndex, configuration.AllowedTimestampStartIndex)
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package tester
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// AvailableBytes returns the number of bytes available
+// to an unprivileged user on the filesystem of path.
+func AvailableBytes(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return -1, fmt.Errorf("%w: unable to stat filesystem of %s", err, path)
+	}
+
+	return int64(stat.Bavail) * int64(stat.Bsize), nil // nolint:unconvert
+}
@@ -0,0 +1,315 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/zstd"
+)
+
+const (
+	// manifestFile is the file that identifies
+	// a directory as a Badger store.
+	manifestFile = "MANIFEST"
+
+	// tmpSuffix is appended to the paths written
+	// by BackupDataDirectory and RestoreDataDirectory until they succeed.
+	tmpSuffix = ".tmp"
+)
+
+// ErrDataDirectoryNotEmpty is returned when restoring to
+// a data directory that is not empty.
+var ErrDataDirectoryNotEmpty = errors.New("data directory is not empty")
+
+// DataDirectoryStores returns the Badger stores in dataDirectory.
+func DataDirectoryStores(dataDirectory string) ([]string, error) {
+	stores := []string{}
+	err := filepath.Walk(dataDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && info.Name() == manifestFile {
+			stores = append(stores, filepath.Dir(path))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to find stores in %s", err, dataDirectory)
+	}
+
+	return stores, nil
+}
+
+// BackupDataDirectory archives dataDirectory to a zstd-compressed tar
+// file at out. All Badger stores in dataDirectory are locked
+// while they are archived (so the backup fails if any store is
+// open), so the archive is consistent. It returns the number
+// of files archived.
+func BackupDataDirectory(dataDirectory string, out string) (int, error) {
+	info, err := os.Stat(dataDirectory)
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to read data directory %s", err, dataDirectory)
+	}
+
+	if !info.IsDir() {
+		return -1, fmt.Errorf("data directory %s is not a directory", dataDirectory)
+	}
+
+	stores, err := DataDirectoryStores(dataDirectory)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, store := range stores {
+		unlock, err := lockDirectory(store)
+		if err != nil {
+			return -1, err
+		}
+		defer unlock()
+	}
+
+	// The archive is written to a temporary file so
+	// that a failed backup never leaves a partial
+	// archive at out.
+	tmpOut := out + tmpSuffix
+	f, err := os.Create(tmpOut)
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to create %s", err, tmpOut)
+	}
+	defer os.Remove(tmpOut) // nolint:errcheck
+
+	count, err := write(f, dataDirectory, []string{out, tmpOut})
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("%w: unable to close %s", closeErr, tmpOut)
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	if err := os.Rename(tmpOut, out); err != nil {
+		return -1, fmt.Errorf("%w: unable to move backup to %s", err, out)
+	}
+
+	return count, nil
+}
+
+// write writes a zstd-compressed tar archive of dataDirectory
+// (excluding any path in skip) to w.
+func write(w io.Writer, dataDirectory string, skip []string) (int, error) {
+	skipped := map[string]struct{}{}
+	for _, path := range skip {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return -1, fmt.Errorf("%w: unable to resolve %s", err, path)
+		}
+
+		skipped[abs] = struct{}{}
+	}
+
+	zw := zstd.NewWriter(w)
+	tw := tar.NewWriter(zw)
+
+	count := 0
+	err := filepath.Walk(dataDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := skipped[abs]; ok {
+			return nil
+		}
+
+		name, err := filepath.Rel(dataDirectory, path)
+		if err != nil {
+			return err
+		}
+
+		if name == "." {
+			return nil
+		}
+
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.ToSlash(name)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if _, err := io.Copy(tw, f); err != nil {
+			return err
+		}
+
+		count++
+		return nil
+	})
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to archive %s", err, dataDirectory)
+	}
+
+	if err := tw.Close(); err != nil {
+		return -1, fmt.Errorf("%w: unable to close archive", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return -1, fmt.Errorf("%w: unable to close compressor", err)
+	}
+
+	return count, nil
+}
+
+// RestoreDataDirectory extracts the archive at in (created by BackupDataDirectory) to
+// dataDirectory, which must be empty or not exist. The archive
+// is extracted to a temporary directory first, so a failed
+// restore never leaves a partial store in dataDirectory. It
+// returns the number of files restored.
+func RestoreDataDirectory(in string, dataDirectory string) (int, error) {
+	entries, err := ioutil.ReadDir(dataDirectory)
+	switch {
+	case err == nil && len(entries) > 0:
+		return -1, fmt.Errorf("%w: %s", ErrDataDirectoryNotEmpty, dataDirectory)
+	case err != nil && !os.IsNotExist(err):
+		return -1, fmt.Errorf("%w: unable to read data directory %s", err, dataDirectory)
+	}
+
+	f, err := os.Open(in)
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to open %s", err, in)
+	}
+	defer f.Close()
+
+	tmpDirectory := filepath.Clean(dataDirectory) + tmpSuffix
+	if err := os.RemoveAll(tmpDirectory); err != nil {
+		return -1, fmt.Errorf("%w: unable to remove %s", err, tmpDirectory)
+	}
+	defer os.RemoveAll(tmpDirectory) // nolint:errcheck
+
+	count, err := read(f, tmpDirectory)
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to restore %s", err, in)
+	}
+
+	// dataDirectory is empty (if it exists), so
+	// it can be replaced with the restored data.
+	if err := os.Remove(dataDirectory); err != nil && !os.IsNotExist(err) {
+		return -1, fmt.Errorf("%w: unable to remove %s", err, dataDirectory)
+	}
+
+	if err := os.Rename(tmpDirectory, dataDirectory); err != nil {
+		return -1, fmt.Errorf("%w: unable to move restored data to %s", err, dataDirectory)
+	}
+
+	return count, nil
+}
+
+// read extracts the zstd-compressed tar archive
+// in r to directory.
+func read(r io.Reader, directory string) (int, error) {
+	if err := os.MkdirAll(directory, os.FileMode(0750)); err != nil {
+		return -1, err
+	}
+
+	zr := zstd.NewReader(r)
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	count := 0
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return -1, err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." ||
+			strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return -1, fmt.Errorf("invalid path %s in archive", header.Name)
+		}
+
+		path := filepath.Join(directory, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, header.FileInfo().Mode().Perm()); err != nil {
+				return -1, err
+			}
+		case tar.TypeReg:
+			if err := extract(tr, path, header.FileInfo().Mode().Perm()); err != nil {
+				return -1, err
+			}
+
+			count++
+		default:
+			return -1, fmt.Errorf("unsupported entry %s in archive", header.Name)
+		}
+	}
+}
+
+// extract writes the contents of r to a
+// file at path with mode.
+func extract(r io.Reader, path string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0750)); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
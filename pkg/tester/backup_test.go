@@ -0,0 +1,206 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/DataDog/zstd"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const blocks = 20
+
+func backupBlock(index int64) *types.Block {
+	identifier := &types.BlockIdentifier{
+		Index: index,
+		Hash:  fmt.Sprintf("block %d", index),
+	}
+	parent := identifier
+	if index > 0 {
+		parent = &types.BlockIdentifier{
+			Index: index - 1,
+			Hash:  fmt.Sprintf("block %d", index-1),
+		}
+	}
+
+	return &types.Block{
+		BlockIdentifier:       identifier,
+		ParentBlockIdentifier: parent,
+		Timestamp:             1601000000000 + index,
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: fmt.Sprintf("tx %d", index),
+				},
+				Operations: []*types.Operation{},
+			},
+		},
+	}
+}
+
+// openBlockStorage opens the store in dataPath and
+// returns its *modules.BlockStorage.
+func openBlockStorage(
+	ctx context.Context,
+	t *testing.T,
+	dataPath string,
+) (database.Database, *modules.BlockStorage) {
+	db, err := database.NewBadgerDatabase(ctx, dataPath)
+	assert.NoError(t, err)
+
+	blockStorage := modules.NewBlockStorage(db, 1)
+	blockStorage.Initialize([]modules.BlockWorker{})
+
+	return db, blockStorage
+}
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	dataDirectory := path.Join(dir, "data")
+	dataPath := path.Join(dataDirectory, "check-data", "network")
+	assert.NoError(t, os.MkdirAll(dataPath, os.FileMode(0750)))
+	assert.NoError(t, ioutil.WriteFile(
+		path.Join(dataDirectory, "results.json"),
+		[]byte("{}"),
+		os.FileMode(0600),
+	))
+
+	db, blockStorage := openBlockStorage(ctx, t, dataPath)
+	for i := int64(0); i < blocks; i++ {
+		assert.NoError(t, blockStorage.SeeBlock(ctx, backupBlock(i)))
+		assert.NoError(t, blockStorage.AddBlock(ctx, backupBlock(i)))
+	}
+
+	// Open stores cannot be backed up.
+	out := path.Join(dir, "backup.tar.zst")
+	_, err = BackupDataDirectory(dataDirectory, out)
+	assert.Error(t, err)
+	assert.NoFileExists(t, out)
+	assert.NoFileExists(t, out+tmpSuffix)
+
+	assert.NoError(t, db.Close(ctx))
+	stores, err := DataDirectoryStores(dataDirectory)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dataPath}, stores)
+
+	count, err := BackupDataDirectory(dataDirectory, out)
+	assert.NoError(t, err)
+	assert.Greater(t, count, 1)
+	assert.FileExists(t, out)
+
+	// The backed up store can be opened
+	// after the backup completes.
+	db, _ = openBlockStorage(ctx, t, dataPath)
+	assert.NoError(t, db.Close(ctx))
+
+	restored := path.Join(dir, "restored")
+	restoredCount, err := RestoreDataDirectory(out, restored)
+	assert.NoError(t, err)
+	assert.Equal(t, count, restoredCount)
+	assert.NoDirExists(t, restored+tmpSuffix)
+
+	contents, err := ioutil.ReadFile(path.Join(restored, "results.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("{}"), contents)
+
+	db, blockStorage = openBlockStorage(ctx, t, path.Join(restored, "check-data", "network"))
+	defer db.Close(ctx)
+
+	head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, backupBlock(blocks-1).BlockIdentifier, head)
+	for i := int64(0); i < blocks; i++ {
+		stored, err := blockStorage.GetBlock(
+			ctx,
+			types.ConstructPartialBlockIdentifier(backupBlock(i).BlockIdentifier),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, backupBlock(i), stored)
+	}
+
+	// Restoring to a non-empty data directory fails.
+	_, err = RestoreDataDirectory(out, restored)
+	assert.ErrorIs(t, err, ErrDataDirectoryNotEmpty)
+
+	// Restoring to an empty data directory succeeds.
+	empty := path.Join(dir, "empty")
+	assert.NoError(t, os.MkdirAll(empty, os.FileMode(0750)))
+	_, err = RestoreDataDirectory(out, empty)
+	assert.NoError(t, err)
+	assert.FileExists(t, path.Join(empty, "results.json"))
+}
+
+func TestBackupInDataDirectory(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, "file"), []byte("a"), os.FileMode(0600)))
+
+	// The archive never includes itself.
+	out := path.Join(dir, "backup.tar.zst")
+	count, err := BackupDataDirectory(dir, out)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = BackupDataDirectory(dir, out)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestRestoreInvalidPath(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	var b bytes.Buffer
+	zw := zstd.NewWriter(&b)
+	tw := tar.NewWriter(zw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "../escaped",
+		Typeflag: tar.TypeReg,
+		Mode:     0600,
+		Size:     1,
+	}))
+	_, err = tw.Write([]byte("a"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, zw.Close())
+
+	in := path.Join(dir, "invalid.tar.zst")
+	assert.NoError(t, ioutil.WriteFile(in, b.Bytes(), os.FileMode(0600)))
+
+	restored := path.Join(dir, "restored")
+	_, err = RestoreDataDirectory(in, restored)
+	assert.Error(t, err)
+	assert.NoDirExists(t, restored)
+	assert.NoFileExists(t, path.Join(dir, "escaped"))
+}
@@ -0,0 +1,494 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/neilotoole/errgroup"
+)
+
+const (
+	// blockSyncIdentifier is the write transaction identifier
+	// used by *modules.BlockStorage to add and remove blocks.
+	blockSyncIdentifier = "blockSyncIdentifier"
+
+	// maxBatchBytes is the number of bytes written in a batch
+	// after which it is committed, regardless of the number of
+	// blocks in it. This keeps batches well below the maximum
+	// transaction size of the database.
+	maxBatchBytes = 8 << 20
+
+	// maxBatchLinger is the longest a batch waits
+	// for another block before it is committed.
+	maxBatchLinger = time.Second
+)
+
+var (
+	// ErrBatchRolledBack is returned when a block is committed
+	// after a batch of previously applied blocks failed
+	// to commit.
+	ErrBatchRolledBack = errors.New("commit batch was rolled back")
+)
+
+var (
+	_ database.Database    = (*BatchingDatabase)(nil)
+	_ database.Transaction = (*transaction)(nil)
+	_ modules.BlockWorker  = (*BatchingDatabase)(nil)
+)
+
+// BatchingDatabase wraps a database.Database so that blocks added by
+// *modules.BlockStorage are committed in batches when commits
+// are slow. The writes of each block are kept in memory until
+// the batch is committed in a single database transaction, so
+// a batch is either applied in full or not at all. All other
+// transactions read through the batch, so blocks are visible
+// as soon as they are added.
+//
+// After each commit, the number of blocks per batch is doubled
+// (up to maxBlocks) if the commit took longer than threshold
+// per block and halved otherwise. Database must also be
+// registered as a modules.BlockWorker so that batches are
+// committed before a block is removed.
+type BatchingDatabase struct {
+	database.Database
+
+	maxBlocks int
+	threshold time.Duration
+
+	// lock is held from the time a block transaction
+	// is created until it is committed or discarded.
+	lock       sync.Mutex
+	blocks     int
+	generation uint64
+	timer      *time.Timer
+	err        error
+
+	// commitLock is held while committing the batch or any
+	// other write transaction so that writes committed
+	// outside of the batch are never overwritten by it.
+	commitLock sync.Mutex
+
+	// batchLock guards replacing the batch once committed.
+	batchLock sync.RWMutex
+	batch     *overlay
+
+	batchSize int64
+}
+
+// NewBatchingDatabase returns a new *BatchingDatabase that groups up to
+// maxBlocks blocks into a single commit.
+func NewBatchingDatabase(db database.Database, maxBlocks int, threshold time.Duration) *BatchingDatabase {
+	return &BatchingDatabase{
+		Database:  db,
+		maxBlocks: maxBlocks,
+		threshold: threshold,
+		batch:     newOverlay(),
+		batchSize: 1,
+	}
+}
+
+// BatchSize returns the number of blocks
+// currently grouped into each commit.
+func (d *BatchingDatabase) BatchSize() int {
+	return int(atomic.LoadInt64(&d.batchSize))
+}
+
+// ReadTransaction returns a transaction that reads
+// the current batch and the underlying database.
+func (d *BatchingDatabase) ReadTransaction(ctx context.Context) database.Transaction {
+	d.batchLock.RLock()
+	defer d.batchLock.RUnlock()
+
+	return &transaction{
+		db:       d,
+		snapshot: d.Database.ReadTransaction(ctx),
+		batch:    d.batch,
+		writes:   newOverlay(),
+	}
+}
+
+// WriteTransaction returns a transaction that is added to the
+// current batch if identifier is used to add or remove blocks.
+// Otherwise, a transaction is created by the underlying database
+// that reads through the current batch.
+func (d *BatchingDatabase) WriteTransaction(
+	ctx context.Context,
+	identifier string,
+	priority bool,
+) database.Transaction {
+	// Pruning uses the same identifier without priority
+	// and must not be added to a batch.
+	if identifier != blockSyncIdentifier || !priority {
+		d.batchLock.RLock()
+		defer d.batchLock.RUnlock()
+
+		return &transaction{
+			db:       d,
+			snapshot: d.Database.WriteTransaction(ctx, identifier, priority),
+			batch:    d.batch,
+			writes:   newOverlay(),
+		}
+	}
+
+	d.lock.Lock()
+	d.batchLock.RLock()
+	defer d.batchLock.RUnlock()
+
+	return &transaction{
+		db:       d,
+		snapshot: d.Database.ReadTransaction(ctx),
+		batch:    d.batch,
+		writes:   newOverlay(),
+		block:    true,
+	}
+}
+
+// AddingBlock is called by BlockStorage when adding a block.
+func (d *BatchingDatabase) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	txn database.Transaction,
+) (database.CommitWorker, error) {
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block.
+// Commit workers of other BlockWorkers may modify storage directly
+// when a block is removed, so the batch is committed with the
+// removal.
+func (d *BatchingDatabase) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	txn database.Transaction,
+) (database.CommitWorker, error) {
+	if t, ok := txn.(*transaction); ok {
+		t.removing = true
+	}
+
+	return nil, nil
+}
+
+// Close commits the current batch and
+// closes the underlying database.
+func (d *BatchingDatabase) Close(ctx context.Context) error {
+	d.lock.Lock()
+	if err := d.commitBatch(ctx); err != nil {
+		log.Printf("%s: unable to commit batch before closing database\n", err.Error())
+	}
+	d.lock.Unlock()
+
+	return d.Database.Close(ctx)
+}
+
+// commitBlock adds the writes of t to the batch. The batch is
+// only committed if it is full or the block is removed.
+func (d *BatchingDatabase) commitBlock(ctx context.Context, t *transaction) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	d.batch.merge(t.writes)
+	d.blocks++
+	if !t.removing && d.blocks < d.BatchSize() && d.batch.bytes < maxBatchBytes {
+		if d.timer != nil {
+			d.timer.Stop()
+		}
+
+		d.generation++
+		generation := d.generation
+		d.timer = time.AfterFunc(maxBatchLinger, func() {
+			d.flush(generation)
+		})
+
+		return nil
+	}
+
+	return d.commitBatch(ctx)
+}
+
+// commitBatch writes the batch in a single database transaction
+// and adjusts the batch size using the commit latency.
+func (d *BatchingDatabase) commitBatch(ctx context.Context) error {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	if d.blocks == 0 || d.err != nil {
+		return d.err
+	}
+
+	d.commitLock.Lock()
+	defer d.commitLock.Unlock()
+
+	start := time.Now()
+	txn := d.Database.WriteTransaction(ctx, blockSyncIdentifier, true)
+	defer txn.Discard(ctx)
+
+	err := d.batch.apply(ctx, txn)
+	if err == nil {
+		err = txn.Commit(ctx)
+	}
+
+	// A batch that failed to commit is dropped so
+	// that its blocks are no longer visible.
+	d.batchLock.Lock()
+	d.batch = newOverlay()
+	d.batchLock.Unlock()
+
+	blocks := d.blocks
+	d.blocks = 0
+	if err != nil {
+		d.err = fmt.Errorf("%w: unable to commit %d blocks: %s", ErrBatchRolledBack, blocks, err.Error())
+		return d.err
+	}
+
+	d.adjustBatchSize(time.Since(start) / time.Duration(blocks))
+	return nil
+}
+
+// commitDirect commits a transaction that is not part of
+// the batch and updates any of its keys in the batch so
+// that the batch does not overwrite them when committed.
+func (d *BatchingDatabase) commitDirect(ctx context.Context, t *transaction) error {
+	d.commitLock.Lock()
+	defer d.commitLock.Unlock()
+
+	if err := t.snapshot.Commit(ctx); err != nil {
+		return err
+	}
+
+	d.batchLock.RLock()
+	defer d.batchLock.RUnlock()
+
+	d.batch.update(t.writes)
+	return nil
+}
+
+// adjustBatchSize doubles the batch size if the commit
+// latency per block exceeds the threshold and halves it
+// otherwise.
+func (d *BatchingDatabase) adjustBatchSize(latency time.Duration) {
+	size := d.BatchSize()
+	if latency > d.threshold {
+		size *= 2
+	} else {
+		size /= 2
+	}
+
+	if size > d.maxBlocks {
+		size = d.maxBlocks
+	}
+
+	if size < 1 {
+		size = 1
+	}
+
+	atomic.StoreInt64(&d.batchSize, int64(size))
+}
+
+// flush commits the batch if no blocks have
+// been added to it since generation.
+func (d *BatchingDatabase) flush(generation uint64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.generation != generation {
+		return
+	}
+
+	if err := d.commitBatch(context.Background()); err != nil {
+		log.Printf("%s: unable to commit batch\n", err.Error())
+	}
+}
+
+// transaction is a database.Transaction for a single block or,
+// if block is false, for a transaction created by the underlying
+// database. Reads are served from the writes of the transaction,
+// the batch, and snapshot (in that order).
+type transaction struct {
+	db       *BatchingDatabase
+	snapshot database.Transaction
+	batch    *overlay
+	writes   *overlay
+	block    bool
+	removing bool
+	done     bool
+}
+
+// Set stores a value in the transaction.
+func (t *transaction) Set(
+	ctx context.Context,
+	key []byte,
+	value []byte,
+	reclaimValue bool,
+) error {
+	if t.block {
+		t.writes.set(key, value, reclaimValue)
+		return nil
+	}
+
+	// The underlying database may reclaim value
+	// once committed, so we store a copy.
+	stored := make([]byte, len(value))
+	copy(stored, value)
+	if err := t.snapshot.Set(ctx, key, value, reclaimValue); err != nil {
+		return err
+	}
+
+	t.writes.set(key, stored, false)
+	return nil
+}
+
+// Get accesses a value in the transaction.
+func (t *transaction) Get(ctx context.Context, key []byte) (bool, []byte, error) {
+	e, ok := t.writes.get(key)
+	if !ok {
+		e, ok = t.batch.get(key)
+	}
+
+	if !ok {
+		return t.snapshot.Get(ctx, key)
+	}
+
+	if e.deleted {
+		return false, nil, nil
+	}
+
+	// Callers may reclaim the returned value,
+	// so we can't return the stored value.
+	value := make([]byte, len(e.value))
+	copy(value, e.value)
+	return true, value, nil
+}
+
+// Delete removes a value in the transaction.
+func (t *transaction) Delete(ctx context.Context, key []byte) error {
+	if !t.block {
+		if err := t.snapshot.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	t.writes.delete(key)
+	return nil
+}
+
+// Scan iterates over values in the transaction.
+func (t *transaction) Scan(
+	ctx context.Context,
+	prefix []byte,
+	seekStart []byte,
+	worker func([]byte, []byte) error,
+	logEntries bool,
+	reverse bool,
+) (int, error) {
+	merged := newOverlay()
+	merged.merge(t.batch)
+	merged.merge(t.writes)
+	pending := merged.scan(prefix, seekStart, reverse)
+	if len(pending) == 0 {
+		return t.snapshot.Scan(ctx, prefix, seekStart, worker, logEntries, reverse)
+	}
+
+	entries := 0
+	emit := func(e *entry) error {
+		if e.deleted {
+			return nil
+		}
+
+		entries++
+		if err := worker([]byte(e.key), e.value); err != nil {
+			return fmt.Errorf("%w: worker failed for key %s", err, e.key)
+		}
+
+		return nil
+	}
+
+	_, err := t.snapshot.Scan(ctx, prefix, seekStart, func(k []byte, v []byte) error {
+		key := string(k)
+		for len(pending) > 0 && before(pending[0].key, key, reverse) {
+			if err := emit(pending[0]); err != nil {
+				return err
+			}
+			pending = pending[1:]
+		}
+
+		if len(pending) > 0 && pending[0].key == key {
+			e := pending[0]
+			pending = pending[1:]
+			return emit(e)
+		}
+
+		entries++
+		return worker(k, v)
+	}, logEntries, reverse)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, e := range pending {
+		if err := emit(e); err != nil {
+			return -1, err
+		}
+	}
+
+	return entries, nil
+}
+
+// Commit adds a block to the batch, committing the batch
+// if it is full. Other transactions are committed directly.
+func (t *transaction) Commit(ctx context.Context) error {
+	if t.done {
+		return nil
+	}
+
+	t.done = true
+	if !t.block {
+		return t.db.commitDirect(ctx, t)
+	}
+
+	t.snapshot.Discard(ctx)
+	defer t.db.lock.Unlock()
+
+	return t.db.commitBlock(ctx, t)
+}
+
+// Discard drops the writes of the transaction if it has not
+// been committed. Blocks already added to the batch are
+// unaffected.
+func (t *transaction) Discard(ctx context.Context) {
+	if t.done {
+		return
+	}
+
+	t.done = true
+	t.snapshot.Discard(ctx)
+	if t.block {
+		t.db.lock.Unlock()
+	}
+}
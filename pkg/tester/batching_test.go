@@ -0,0 +1,564 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	threshold = 5 * time.Millisecond
+	slow      = 30 * time.Millisecond
+)
+
+// slowDatabase delays all write transaction
+// commits by delay.
+type slowDatabase struct {
+	database.Database
+
+	delay int64
+	fail  int32
+}
+
+func (s *slowDatabase) setDelay(delay time.Duration) {
+	atomic.StoreInt64(&s.delay, int64(delay))
+}
+
+func (s *slowDatabase) WriteTransaction(
+	ctx context.Context,
+	identifier string,
+	priority bool,
+) database.Transaction {
+	return &slowTransaction{
+		Transaction: s.Database.WriteTransaction(ctx, identifier, priority),
+		delay:       time.Duration(atomic.LoadInt64(&s.delay)),
+		fail:        atomic.LoadInt32(&s.fail) == 1,
+	}
+}
+
+type slowTransaction struct {
+	database.Transaction
+
+	delay time.Duration
+	fail  bool
+}
+
+func (s *slowTransaction) Commit(ctx context.Context) error {
+	time.Sleep(s.delay)
+	if s.fail {
+		s.Transaction.Discard(ctx)
+		return errors.New("commit failed")
+	}
+
+	return s.Transaction.Commit(ctx)
+}
+
+func batchingNewDatabase(ctx context.Context, t testing.TB) (*slowDatabase, func()) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+
+	return &slowDatabase{Database: db}, func() {
+		assert.NoError(t, db.Close(ctx))
+		utils.RemoveTempDir(dir)
+	}
+}
+
+// newBatchingDatabase returns a *BatchingDatabase wrapping a *slowDatabase.
+// The *BatchingDatabase is closed (committing any open batch) on cleanup.
+func newBatchingDatabase(
+	ctx context.Context,
+	t testing.TB,
+	maxBlocks int,
+	threshold time.Duration,
+) (*slowDatabase, *BatchingDatabase, func()) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+
+	badgerDB, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+
+	slowDB := &slowDatabase{Database: badgerDB}
+	db := NewBatchingDatabase(slowDB, maxBlocks, threshold)
+	return slowDB, db, func() {
+		assert.NoError(t, db.Close(ctx))
+		utils.RemoveTempDir(dir)
+	}
+}
+
+func blockKey(index int, key int) []byte {
+	return []byte(fmt.Sprintf("block/%d/%d", index, key))
+}
+
+// addBlock writes keys for a block the same
+// way *modules.BlockStorage adds a block.
+func addBlock(ctx context.Context, db database.Database, index int, keys int) error {
+	txn := db.WriteTransaction(ctx, blockSyncIdentifier, true)
+	defer txn.Discard(ctx)
+
+	for i := 0; i < keys; i++ {
+		if err := txn.Set(ctx, blockKey(index, i), []byte("value"), false); err != nil {
+			return err
+		}
+	}
+
+	return txn.Commit(ctx)
+}
+
+func stored(ctx context.Context, t *testing.T, db database.Database, index int) bool {
+	txn := db.ReadTransaction(ctx)
+	defer txn.Discard(ctx)
+
+	exists, _, err := txn.Get(ctx, blockKey(index, 0))
+	assert.NoError(t, err)
+
+	return exists
+}
+
+func TestBatching(t *testing.T) {
+	ctx := context.Background()
+	slowDB, db, closeDB := newBatchingDatabase(ctx, t, 4, threshold)
+	defer closeDB()
+
+	assert.Equal(t, 1, db.BatchSize())
+
+	t.Run("fast commits are not batched", func(t *testing.T) {
+		assert.NoError(t, addBlock(ctx, db, 0, 1))
+		assert.True(t, stored(ctx, t, db, 0))
+		assert.Equal(t, 1, db.BatchSize())
+	})
+
+	t.Run("slow commits are batched", func(t *testing.T) {
+		slowDB.setDelay(slow)
+		assert.NoError(t, addBlock(ctx, db, 1, 1))
+		assert.True(t, stored(ctx, t, db, 1))
+		assert.Equal(t, 2, db.BatchSize())
+
+		// Blocks in the batch are visible before
+		// they are committed.
+		assert.NoError(t, addBlock(ctx, db, 2, 1))
+		assert.True(t, stored(ctx, t, db, 2))
+		assert.False(t, stored(ctx, t, slowDB, 2))
+		assert.NoError(t, addBlock(ctx, db, 3, 1))
+		assert.True(t, stored(ctx, t, slowDB, 2))
+		assert.True(t, stored(ctx, t, slowDB, 3))
+		assert.Equal(t, 4, db.BatchSize())
+
+		// The batch size never exceeds the maximum.
+		for i := 4; i < 8; i++ {
+			assert.NoError(t, addBlock(ctx, db, i, 1))
+			assert.True(t, stored(ctx, t, db, i))
+			assert.Equal(t, i == 7, stored(ctx, t, slowDB, i))
+		}
+		assert.Equal(t, 4, db.BatchSize())
+	})
+
+	t.Run("fall back to per-block commits", func(t *testing.T) {
+		slowDB.setDelay(0)
+		for i := 8; i < 12; i++ {
+			assert.NoError(t, addBlock(ctx, db, i, 1))
+		}
+		assert.Equal(t, 2, db.BatchSize())
+
+		for i := 12; i < 14; i++ {
+			assert.NoError(t, addBlock(ctx, db, i, 1))
+		}
+		assert.Equal(t, 1, db.BatchSize())
+
+		assert.NoError(t, addBlock(ctx, db, 14, 1))
+		assert.True(t, stored(ctx, t, db, 14))
+	})
+
+	t.Run("other transactions are not batched", func(t *testing.T) {
+		txn := db.WriteTransaction(ctx, blockSyncIdentifier, false)
+		assert.False(t, txn.(*transaction).block)
+		txn.Discard(ctx)
+
+		txn = db.WriteTransaction(ctx, "other", true)
+		assert.False(t, txn.(*transaction).block)
+		txn.Discard(ctx)
+	})
+}
+
+func TestBatchRollback(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	badgerDB, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+
+	slowDB := &slowDatabase{Database: badgerDB}
+	slowDB.setDelay(slow)
+	db := NewBatchingDatabase(slowDB, 4, threshold)
+	assert.NoError(t, addBlock(ctx, db, 0, 1))
+	assert.Equal(t, 2, db.BatchSize())
+
+	t.Run("failed block", func(t *testing.T) {
+		assert.NoError(t, addBlock(ctx, db, 1, 1))
+
+		// Only the writes of the failed block are discarded.
+		txn := db.WriteTransaction(ctx, blockSyncIdentifier, true)
+		assert.NoError(t, txn.Set(ctx, blockKey(2, 0), []byte("value"), false))
+		txn.Discard(ctx)
+
+		assert.NoError(t, addBlock(ctx, db, 3, 1))
+		assert.True(t, stored(ctx, t, db, 1))
+		assert.False(t, stored(ctx, t, db, 2))
+		assert.True(t, stored(ctx, t, db, 3))
+	})
+
+	t.Run("crash", func(t *testing.T) {
+		assert.NoError(t, addBlock(ctx, db, 4, 1))
+		assert.NoError(t, addBlock(ctx, db, 5, 1))
+		assert.NoError(t, addBlock(ctx, db, 6, 1))
+
+		// Close the database without committing the batch.
+		db.lock.Lock()
+		db.timer.Stop()
+		db.lock.Unlock()
+		assert.NoError(t, badgerDB.Close(ctx))
+		badgerDB, err = database.NewBadgerDatabase(ctx, dir)
+		assert.NoError(t, err)
+		defer badgerDB.Close(ctx)
+
+		assert.True(t, stored(ctx, t, badgerDB, 3))
+		for i := 4; i < 7; i++ {
+			assert.False(t, stored(ctx, t, badgerDB, i))
+		}
+	})
+}
+
+func TestBatchCommitFailure(t *testing.T) {
+	ctx := context.Background()
+	slowDB, db, closeDB := newBatchingDatabase(ctx, t, 4, threshold)
+	defer closeDB()
+
+	slowDB.setDelay(slow)
+	assert.NoError(t, addBlock(ctx, db, 0, 1))
+	assert.NoError(t, addBlock(ctx, db, 1, 1))
+
+	atomic.StoreInt32(&slowDB.fail, 1)
+	err := addBlock(ctx, db, 2, 1)
+	assert.True(t, errors.Is(err, ErrBatchRolledBack))
+	assert.False(t, stored(ctx, t, db, 1))
+	assert.False(t, stored(ctx, t, db, 2))
+
+	// No further blocks can be committed because
+	// applied blocks were rolled back.
+	atomic.StoreInt32(&slowDB.fail, 0)
+	err = addBlock(ctx, db, 3, 1)
+	assert.True(t, errors.Is(err, ErrBatchRolledBack))
+	assert.False(t, stored(ctx, t, db, 3))
+}
+
+func TestBatchFlush(t *testing.T) {
+	ctx := context.Background()
+	slowDB, db, closeDB := newBatchingDatabase(ctx, t, 4, threshold)
+	defer closeDB()
+
+	slowDB.setDelay(slow)
+	assert.NoError(t, addBlock(ctx, db, 0, 1))
+	assert.Equal(t, 2, db.BatchSize())
+
+	t.Run("removing a block commits the batch", func(t *testing.T) {
+		assert.NoError(t, addBlock(ctx, db, 1, 1))
+		assert.False(t, stored(ctx, t, slowDB, 1))
+
+		txn := db.WriteTransaction(ctx, blockSyncIdentifier, true)
+		_, err := db.RemovingBlock(ctx, nil, nil, txn)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Delete(ctx, blockKey(0, 0)))
+		assert.NoError(t, txn.Commit(ctx))
+		txn.Discard(ctx)
+
+		assert.False(t, stored(ctx, t, slowDB, 0))
+		assert.True(t, stored(ctx, t, slowDB, 1))
+		assert.Equal(t, 4, db.BatchSize())
+	})
+
+	t.Run("idle batches are committed", func(t *testing.T) {
+		assert.NoError(t, addBlock(ctx, db, 2, 1))
+		assert.False(t, stored(ctx, t, slowDB, 2))
+		assert.Eventually(t, func() bool {
+			return stored(ctx, t, slowDB, 2)
+		}, 2*maxBatchLinger, 10*time.Millisecond)
+	})
+
+	t.Run("large batches are committed", func(t *testing.T) {
+		assert.NoError(t, addBlock(ctx, db, 3, 1))
+		assert.False(t, stored(ctx, t, slowDB, 3))
+
+		txn := db.WriteTransaction(ctx, blockSyncIdentifier, true)
+		assert.NoError(t, txn.Set(ctx, blockKey(4, 0), make([]byte, maxBatchBytes), false))
+		assert.NoError(t, txn.Commit(ctx))
+		txn.Discard(ctx)
+
+		assert.True(t, stored(ctx, t, slowDB, 3))
+		assert.True(t, stored(ctx, t, slowDB, 4))
+	})
+}
+
+func TestTransactionScan(t *testing.T) {
+	ctx := context.Background()
+	slowDB, db, closeDB := newBatchingDatabase(ctx, t, 4, threshold)
+	defer closeDB()
+
+	// expected contains the same writes
+	// without batching.
+	expected, closeExpected := batchingNewDatabase(ctx, t)
+	defer closeExpected()
+
+	write := func(db database.Database, keys []string, deletes []string) {
+		txn := db.WriteTransaction(ctx, blockSyncIdentifier, true)
+		defer txn.Discard(ctx)
+
+		for _, key := range keys {
+			assert.NoError(t, txn.Set(ctx, []byte(key), []byte(key+" value"), false))
+		}
+		for _, key := range deletes {
+			assert.NoError(t, txn.Delete(ctx, []byte(key)))
+		}
+		assert.NoError(t, txn.Commit(ctx))
+	}
+
+	slowDB.setDelay(slow)
+	for _, d := range []database.Database{db, expected} {
+		write(d, []string{"a/1", "a/3", "a/5", "b/1"}, nil)
+		write(d, []string{"a/2", "a/6"}, []string{"a/3"})
+	}
+
+	// Read transactions include the batch.
+	readTxn := db.ReadTransaction(ctx)
+	defer readTxn.Discard(ctx)
+	exists, _, err := readTxn.Get(ctx, []byte("a/2"))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	expectedReadTxn := expected.ReadTransaction(ctx)
+	defer expectedReadTxn.Discard(ctx)
+
+	// The last block is not committed when scanning.
+	blockTxn := db.WriteTransaction(ctx, blockSyncIdentifier, true)
+	defer blockTxn.Discard(ctx)
+	assert.NoError(t, blockTxn.Set(ctx, []byte("a/4"), []byte("a/4 value"), false))
+	assert.NoError(t, blockTxn.Set(ctx, []byte("a/5"), []byte("a/5 new value"), false))
+	assert.NoError(t, blockTxn.Delete(ctx, []byte("a/1")))
+
+	expectedTxn := expected.WriteTransaction(ctx, blockSyncIdentifier, true)
+	defer expectedTxn.Discard(ctx)
+	assert.NoError(t, expectedTxn.Set(ctx, []byte("a/4"), []byte("a/4 value"), false))
+	assert.NoError(t, expectedTxn.Set(ctx, []byte("a/5"), []byte("a/5 new value"), false))
+	assert.NoError(t, expectedTxn.Delete(ctx, []byte("a/1")))
+
+	scan := func(txn database.Transaction, prefix string, seekStart string, reverse bool) []string {
+		results := []string{}
+		count, err := txn.Scan(ctx, []byte(prefix), []byte(seekStart), func(k []byte, v []byte) error {
+			results = append(results, string(k)+"="+string(v))
+			return nil
+		}, false, reverse)
+		assert.NoError(t, err)
+		assert.Len(t, results, count)
+
+		return results
+	}
+
+	var tests = map[string]struct {
+		prefix    string
+		seekStart string
+		reverse   bool
+	}{
+		"forward":               {prefix: "a/", seekStart: "a/"},
+		"forward with seek":     {prefix: "a/", seekStart: "a/3"},
+		"reverse":               {prefix: "a/", seekStart: "a/9", reverse: true},
+		"reverse with seek":     {prefix: "a/", seekStart: "a/4", reverse: true},
+		"reverse with deletion": {prefix: "a/", seekStart: "a/3", reverse: true},
+		"other prefix":          {prefix: "b/", seekStart: "b/"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(
+				t,
+				scan(expectedTxn, test.prefix, test.seekStart, test.reverse),
+				scan(blockTxn, test.prefix, test.seekStart, test.reverse),
+			)
+			assert.Equal(
+				t,
+				scan(expectedReadTxn, test.prefix, test.seekStart, test.reverse),
+				scan(readTxn, test.prefix, test.seekStart, test.reverse),
+			)
+		})
+	}
+
+	exists, value, err := blockTxn.Get(ctx, []byte("a/5"))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "a/5 new value", string(value))
+
+	exists, _, err = blockTxn.Get(ctx, []byte("a/3"))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestDirectTransaction(t *testing.T) {
+	ctx := context.Background()
+	slowDB, db, closeDB := newBatchingDatabase(ctx, t, 4, threshold)
+	defer closeDB()
+
+	slowDB.setDelay(slow)
+	assert.NoError(t, addBlock(ctx, db, 0, 1))
+	assert.NoError(t, addBlock(ctx, db, 1, 1))
+	assert.False(t, stored(ctx, t, slowDB, 1))
+
+	// Transactions outside of the batch read
+	// through it and are committed directly.
+	txn := db.WriteTransaction(ctx, "other", true)
+	exists, value, err := txn.Get(ctx, blockKey(1, 0))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "value", string(value))
+	assert.NoError(t, txn.Set(ctx, blockKey(1, 0), []byte("updated"), true))
+	assert.NoError(t, txn.Set(ctx, []byte("other"), []byte("value"), false))
+	assert.NoError(t, txn.Commit(ctx))
+	txn.Discard(ctx)
+
+	readTxn := slowDB.ReadTransaction(ctx)
+	exists, _, err = readTxn.Get(ctx, []byte("other"))
+	readTxn.Discard(ctx)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// Committing the batch does not overwrite
+	// the direct write.
+	assert.NoError(t, addBlock(ctx, db, 2, 1))
+	assert.True(t, stored(ctx, t, slowDB, 2))
+
+	readTxn = slowDB.ReadTransaction(ctx)
+	exists, value, err = readTxn.Get(ctx, blockKey(1, 0))
+	readTxn.Discard(ctx)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "updated", string(value))
+}
+
+func newBlock(index int64) *types.Block {
+	parent := index - 1
+	if parent < 0 {
+		parent = 0
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: parent,
+			Hash:  fmt.Sprintf("block %d", parent),
+		},
+	}
+}
+
+func TestBlockStorage(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	badgerDB, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+
+	slowDB := &slowDatabase{Database: badgerDB}
+	slowDB.setDelay(slow)
+	db := NewBatchingDatabase(slowDB, 4, threshold)
+	blockStorage := modules.NewBlockStorage(db, 1)
+	blockStorage.Initialize([]modules.BlockWorker{db})
+
+	// Blocks 3 and 4 are in an open batch.
+	for i := int64(0); i < 5; i++ {
+		assert.NoError(t, blockStorage.SeeBlock(ctx, newBlock(i)))
+		assert.NoError(t, blockStorage.AddBlock(ctx, newBlock(i)))
+	}
+
+	head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, newBlock(4).BlockIdentifier, head)
+
+	head, err = modules.NewBlockStorage(slowDB, 1).GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, newBlock(2).BlockIdentifier, head)
+
+	// Removing a block commits the batch.
+	assert.NoError(t, blockStorage.RemoveBlock(ctx, newBlock(4).BlockIdentifier))
+	head, err = blockStorage.GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, newBlock(3).BlockIdentifier, head)
+
+	// An open batch is committed when closing.
+	assert.NoError(t, blockStorage.SeeBlock(ctx, newBlock(4)))
+	assert.NoError(t, blockStorage.AddBlock(ctx, newBlock(4)))
+	head, err = modules.NewBlockStorage(slowDB, 1).GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, newBlock(3).BlockIdentifier, head)
+	assert.NoError(t, db.Close(ctx))
+
+	badgerDB, err = database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer badgerDB.Close(ctx)
+
+	head, err = modules.NewBlockStorage(badgerDB, 1).GetHeadBlockIdentifier(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, newBlock(4).BlockIdentifier, head)
+}
+
+// BenchmarkCommitBatching measures the throughput of adding
+// blocks when each commit is artificially delayed.
+func BenchmarkCommitBatching(b *testing.B) {
+	for _, maxBlocks := range []int{1, 16, 64} {
+		b.Run(fmt.Sprintf("max_commit_batch_blocks=%d", maxBlocks), func(b *testing.B) {
+			ctx := context.Background()
+			slowDB, db, closeDB := newBatchingDatabase(ctx, b, maxBlocks, time.Millisecond)
+			defer closeDB()
+
+			slowDB.setDelay(5 * time.Millisecond)
+
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				if err := addBlock(ctx, db, i, 50); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "blocks/s")
+		})
+	}
+}
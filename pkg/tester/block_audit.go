@@ -0,0 +1,287 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// HistoryChanged indicates the implementation now serves a
+	// block that differs from the block it served during the run.
+	HistoryChanged results.DivergenceType = "implementation_changed_history"
+
+	// StorageCorruption indicates a stored block could not be
+	// deserialized or is not internally consistent.
+	StorageCorruption results.DivergenceType = "storage_corruption"
+)
+
+// AuditBlockStorage is the subset of *modules.BlockStorage
+// used by the BlockAuditor.
+type AuditBlockStorage interface {
+	GetHeadBlockIdentifier(ctx context.Context) (*types.BlockIdentifier, error)
+	GetOldestBlockIndex(ctx context.Context) (int64, error)
+	GetBlock(ctx context.Context, blockIdentifier *types.PartialBlockIdentifier) (*types.Block, error)
+}
+
+// AuditBlockFetcher is the subset of *fetcher.Fetcher
+// used by the BlockAuditor.
+type AuditBlockFetcher interface {
+	BlockRetry(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		blockIdentifier *types.PartialBlockIdentifier,
+	) (*types.Block, *fetcher.Error)
+}
+
+// BlockAuditor re-fetches a random sample of stored
+// blocks and compares them with what was stored.
+type BlockAuditor struct {
+	network     *types.NetworkIdentifier
+	fetcher     AuditBlockFetcher
+	storage     AuditBlockStorage
+	asserter    *asserter.Asserter
+	sampleCount int
+	rand        *rand.Rand
+}
+
+// NewBlockAuditor returns a new *BlockAuditor. If asserter
+// is nil, stored blocks are not validated before comparison.
+func NewBlockAuditor(
+	network *types.NetworkIdentifier,
+	fetcher AuditBlockFetcher,
+	storage AuditBlockStorage,
+	asserter *asserter.Asserter,
+	sampleCount int,
+) *BlockAuditor {
+	return &BlockAuditor{
+		network:     network,
+		fetcher:     fetcher,
+		storage:     storage,
+		asserter:    asserter,
+		sampleCount: sampleCount,
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())), // #nosec G404
+	}
+}
+
+// sample returns up to sampleCount distinct indexes
+// in [oldest, head] in ascending order.
+func (a *BlockAuditor) sample(oldest int64, head int64) []int64 {
+	available := head - oldest + 1
+	if available <= 0 {
+		return []int64{}
+	}
+
+	if available <= int64(a.sampleCount) {
+		indexes := make([]int64, available)
+		for i := range indexes {
+			indexes[i] = oldest + int64(i)
+		}
+
+		return indexes
+	}
+
+	seen := map[int64]struct{}{}
+	indexes := make([]int64, 0, a.sampleCount)
+	for len(indexes) < a.sampleCount {
+		index := oldest + a.rand.Int63n(available)
+		if _, ok := seen[index]; ok {
+			continue
+		}
+
+		seen[index] = struct{}{}
+		indexes = append(indexes, index)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
+
+// Audit samples stored blocks, fetches each sampled block by
+// hash, and compares the fetched block against the stored block.
+func (a *BlockAuditor) Audit(ctx context.Context) (*results.BlockAuditResults, error) {
+	auditResults := &results.BlockAuditResults{
+		SampledIndexes: []int64{},
+		Divergences:    []*results.BlockDivergence{},
+	}
+
+	head, err := a.storage.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, storageErrs.ErrHeadBlockNotFound) {
+		return auditResults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get head block identifier", err)
+	}
+
+	oldest, err := a.storage.GetOldestBlockIndex(ctx)
+	if errors.Is(err, storageErrs.ErrOldestIndexMissing) {
+		return auditResults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get oldest block index", err)
+	}
+
+	auditResults.SampledIndexes = a.sample(oldest, head.Index)
+	for _, index := range auditResults.SampledIndexes {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		divergence := a.auditBlock(ctx, index, oldest)
+		if divergence != nil {
+			auditResults.Divergences = append(auditResults.Divergences, divergence)
+		}
+	}
+
+	return auditResults, nil
+}
+
+// auditBlock returns a *BlockDivergence if the block
+// stored at index fails the audit.
+func (a *BlockAuditor) auditBlock(
+	ctx context.Context,
+	index int64,
+	oldest int64,
+) *results.BlockDivergence {
+	stored, err := a.storage.GetBlock(
+		ctx,
+		&types.PartialBlockIdentifier{Index: &index},
+	)
+	if err != nil {
+		return &results.BlockDivergence{
+			Index:  index,
+			Type:   StorageCorruption,
+			Detail: fmt.Sprintf("unable to load stored block: %s", err.Error()),
+		}
+	}
+
+	storedParent, detail := a.checkConsistency(ctx, index, oldest, stored)
+	if len(detail) > 0 {
+		return &results.BlockDivergence{
+			Index:           index,
+			BlockIdentifier: stored.BlockIdentifier,
+			Type:            StorageCorruption,
+			Detail:          detail,
+		}
+	}
+
+	fetched, fetchErr := a.fetcher.BlockRetry(
+		ctx,
+		a.network,
+		types.ConstructPartialBlockIdentifier(stored.BlockIdentifier),
+	)
+	if fetchErr != nil {
+		return &results.BlockDivergence{
+			Index:           index,
+			BlockIdentifier: stored.BlockIdentifier,
+			Type:            results.FetchFailed,
+			Detail:          fmt.Sprintf("unable to fetch block by hash: %s", fetchErr.Err.Error()),
+		}
+	}
+
+	diff, err := DiffFields(stored, fetched)
+	if err != nil {
+		return &results.BlockDivergence{
+			Index:           index,
+			BlockIdentifier: stored.BlockIdentifier,
+			Type:            StorageCorruption,
+			Detail:          fmt.Sprintf("unable to compare blocks: %s", err.Error()),
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil
+	}
+
+	detail = "fetched block differs from stored block"
+	if storedParent != nil &&
+		types.Hash(fetched.ParentBlockIdentifier) != types.Hash(storedParent) {
+		detail = fmt.Sprintf(
+			"%s and no longer links to stored parent %s",
+			detail,
+			types.PrintStruct(storedParent),
+		)
+	}
+
+	return &results.BlockDivergence{
+		Index:           index,
+		BlockIdentifier: stored.BlockIdentifier,
+		Type:            HistoryChanged,
+		Detail:          detail,
+		Diff:            diff,
+	}
+}
+
+// checkConsistency returns a non-empty detail if the stored
+// block is not internally consistent. If the stored parent
+// is available, its identifier is also returned.
+func (a *BlockAuditor) checkConsistency(
+	ctx context.Context,
+	index int64,
+	oldest int64,
+	stored *types.Block,
+) (*types.BlockIdentifier, string) {
+	if stored.BlockIdentifier == nil || stored.BlockIdentifier.Index != index {
+		return nil, fmt.Sprintf(
+			"stored block %s does not match index %d",
+			types.PrintStruct(stored.BlockIdentifier),
+			index,
+		)
+	}
+
+	if a.asserter != nil {
+		if err := a.asserter.Block(stored); err != nil {
+			return nil, fmt.Sprintf("stored block is invalid: %s", err.Error())
+		}
+	}
+
+	if index <= oldest {
+		return nil, ""
+	}
+
+	parentIndex := index - 1
+	parent, err := a.storage.GetBlock(
+		ctx,
+		&types.PartialBlockIdentifier{Index: &parentIndex},
+	)
+	if err != nil {
+		// The parent may have been pruned (or be corrupt itself),
+		// in which case we can't check linkage.
+		return nil, ""
+	}
+
+	if types.Hash(stored.ParentBlockIdentifier) != types.Hash(parent.BlockIdentifier) {
+		return parent.BlockIdentifier, fmt.Sprintf(
+			"stored parent %s does not match stored block at index %d %s",
+			types.PrintStruct(stored.ParentBlockIdentifier),
+			parentIndex,
+			types.PrintStruct(parent.BlockIdentifier),
+		)
+	}
+
+	return parent.BlockIdentifier, ""
+}
@@ -0,0 +1,340 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	auditNetwork = &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+)
+
+type auditMockStorage struct {
+	blocks  map[int64]*types.Block
+	corrupt map[int64]error
+	oldest  int64
+}
+
+func (m *auditMockStorage) GetHeadBlockIdentifier(ctx context.Context) (*types.BlockIdentifier, error) {
+	var head *types.BlockIdentifier
+	for _, block := range m.blocks {
+		if head == nil || block.BlockIdentifier.Index > head.Index {
+			head = block.BlockIdentifier
+		}
+	}
+
+	if head == nil {
+		return nil, storageErrs.ErrHeadBlockNotFound
+	}
+
+	return head, nil
+}
+
+func (m *auditMockStorage) GetOldestBlockIndex(ctx context.Context) (int64, error) {
+	if len(m.blocks) == 0 {
+		return -1, storageErrs.ErrOldestIndexMissing
+	}
+
+	return m.oldest, nil
+}
+
+func (m *auditMockStorage) GetBlock(
+	ctx context.Context,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, error) {
+	if err, ok := m.corrupt[*blockIdentifier.Index]; ok {
+		return nil, err
+	}
+
+	block, ok := m.blocks[*blockIdentifier.Index]
+	if !ok {
+		return nil, storageErrs.ErrBlockNotFound
+	}
+
+	return block, nil
+}
+
+type auditMockFetcher struct {
+	blocks map[string]*types.Block
+}
+
+func (m *auditMockFetcher) BlockRetry(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, *fetcher.Error) {
+	block, ok := m.blocks[*blockIdentifier.Hash]
+	if !ok {
+		return nil, &fetcher.Error{Err: fetcher.ErrExhaustedRetries}
+	}
+
+	return block, nil
+}
+
+func auditBlockIdentifier(index int64) *types.BlockIdentifier {
+	return &types.BlockIdentifier{
+		Index: index,
+		Hash:  fmt.Sprintf("block %d", index),
+	}
+}
+
+func auditTestBlock(index int64, value string) *types.Block {
+	parent := auditBlockIdentifier(index - 1)
+	if index == 0 {
+		parent = auditBlockIdentifier(0)
+	}
+
+	return &types.Block{
+		BlockIdentifier:       auditBlockIdentifier(index),
+		ParentBlockIdentifier: parent,
+		Timestamp:             1000 + index,
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: fmt.Sprintf("tx %d", index),
+				},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "Transfer",
+						Status:              types.String("Success"),
+						Account:             &types.AccountIdentifier{Address: "addr1"},
+						Amount: &types.Amount{
+							Value: value,
+							Currency: &types.Currency{
+								Symbol:   "BTC",
+								Decimals: 8,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestBlockAuditor(t *testing.T) {
+	var tests = map[string]struct {
+		storedBlocks  []*types.Block
+		corrupt       map[int64]error
+		fetchedBlocks []*types.Block
+
+		expectedSampled     []int64
+		expectedDivergences []*results.BlockDivergence
+	}{
+		"no blocks": {
+			expectedSampled:     []int64{},
+			expectedDivergences: []*results.BlockDivergence{},
+		},
+		"all blocks match": {
+			storedBlocks:        []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "20")},
+			fetchedBlocks:       []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "20")},
+			expectedSampled:     []int64{0, 1},
+			expectedDivergences: []*results.BlockDivergence{},
+		},
+		"implementation changed block contents": {
+			storedBlocks:    []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "20")},
+			fetchedBlocks:   []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "25")},
+			expectedSampled: []int64{0, 1},
+			expectedDivergences: []*results.BlockDivergence{
+				{
+					Index:           1,
+					BlockIdentifier: auditBlockIdentifier(1),
+					Type:            HistoryChanged,
+					Detail:          "fetched block differs from stored block",
+					Diff: []*results.FieldDiff{
+						{
+							Path:    "transactions[0].operations[0].amount.value",
+							Stored:  `"20"`,
+							Fetched: `"25"`,
+						},
+					},
+				},
+			},
+		},
+		"implementation changed parent linkage": {
+			storedBlocks: []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "20")},
+			fetchedBlocks: []*types.Block{
+				auditTestBlock(0, "10"),
+				func() *types.Block {
+					b := auditTestBlock(1, "20")
+					b.ParentBlockIdentifier = &types.BlockIdentifier{Index: 0, Hash: "fork"}
+					return b
+				}(),
+			},
+			expectedSampled: []int64{0, 1},
+			expectedDivergences: []*results.BlockDivergence{
+				{
+					Index:           1,
+					BlockIdentifier: auditBlockIdentifier(1),
+					Type:            HistoryChanged,
+					Detail: fmt.Sprintf(
+						"fetched block differs from stored block and no longer links to stored parent %s",
+						types.PrintStruct(auditBlockIdentifier(0)),
+					),
+					Diff: []*results.FieldDiff{
+						{
+							Path:    "parent_block_identifier.hash",
+							Stored:  `"block 0"`,
+							Fetched: `"fork"`,
+						},
+					},
+				},
+			},
+		},
+		"implementation no longer serves block": {
+			storedBlocks:    []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "20")},
+			fetchedBlocks:   []*types.Block{auditTestBlock(0, "10")},
+			expectedSampled: []int64{0, 1},
+			expectedDivergences: []*results.BlockDivergence{
+				{
+					Index:           1,
+					BlockIdentifier: auditBlockIdentifier(1),
+					Type:            results.FetchFailed,
+					Detail: fmt.Sprintf(
+						"unable to fetch block by hash: %s",
+						fetcher.ErrExhaustedRetries.Error(),
+					),
+				},
+			},
+		},
+		"stored block fails to deserialize": {
+			storedBlocks:    []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "20")},
+			corrupt:         map[int64]error{1: errors.New("unexpected EOF")},
+			fetchedBlocks:   []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "20")},
+			expectedSampled: []int64{0, 1},
+			expectedDivergences: []*results.BlockDivergence{
+				{
+					Index:  1,
+					Type:   StorageCorruption,
+					Detail: "unable to load stored block: unexpected EOF",
+				},
+			},
+		},
+		"stored block does not link to stored parent": {
+			storedBlocks: []*types.Block{
+				auditTestBlock(0, "10"),
+				func() *types.Block {
+					b := auditTestBlock(1, "20")
+					b.ParentBlockIdentifier = &types.BlockIdentifier{Index: 0, Hash: "other"}
+					return b
+				}(),
+			},
+			fetchedBlocks:   []*types.Block{auditTestBlock(0, "10"), auditTestBlock(1, "20")},
+			expectedSampled: []int64{0, 1},
+			expectedDivergences: []*results.BlockDivergence{
+				{
+					Index:           1,
+					BlockIdentifier: auditBlockIdentifier(1),
+					Type:            StorageCorruption,
+					Detail: fmt.Sprintf(
+						"stored parent %s does not match stored block at index 0 %s",
+						types.PrintStruct(&types.BlockIdentifier{Index: 0, Hash: "other"}),
+						types.PrintStruct(auditBlockIdentifier(0)),
+					),
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storage := &auditMockStorage{
+				blocks:  map[int64]*types.Block{},
+				corrupt: test.corrupt,
+			}
+			for _, block := range test.storedBlocks {
+				storage.blocks[block.BlockIdentifier.Index] = block
+			}
+
+			f := &auditMockFetcher{blocks: map[string]*types.Block{}}
+			for _, block := range test.fetchedBlocks {
+				f.blocks[block.BlockIdentifier.Hash] = block
+			}
+
+			auditor := NewBlockAuditor(auditNetwork, f, storage, nil, 10)
+			results, err := auditor.Audit(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedSampled, results.SampledIndexes)
+			assert.Equal(t, test.expectedDivergences, results.Divergences)
+			assert.Equal(t, len(test.expectedDivergences) == 0, results.Passed())
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	auditor := NewBlockAuditor(auditNetwork, nil, nil, nil, 5)
+
+	assert.Equal(t, []int64{}, auditor.sample(10, 9))
+	assert.Equal(t, []int64{10, 11, 12}, auditor.sample(10, 12))
+
+	sampled := auditor.sample(100, 10000)
+	assert.Len(t, sampled, 5)
+	seen := map[int64]struct{}{}
+	for i, index := range sampled {
+		assert.GreaterOrEqual(t, index, int64(100))
+		assert.LessOrEqual(t, index, int64(10000))
+		if i > 0 {
+			assert.Greater(t, index, sampled[i-1])
+		}
+
+		_, ok := seen[index]
+		assert.False(t, ok)
+		seen[index] = struct{}{}
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	stored := auditTestBlock(1, "20")
+	fetched := auditTestBlock(1, "20")
+	fetched.Transactions = append(fetched.Transactions, &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: "extra"},
+		Operations:            []*types.Operation{},
+	})
+	fetched.Metadata = map[string]interface{}{"miner": "pool"}
+
+	diff, err := DiffFields(stored, fetched)
+	assert.NoError(t, err)
+	assert.Equal(t, []*results.FieldDiff{
+		{
+			Path:    "metadata",
+			Stored:  missingField,
+			Fetched: `{"miner":"pool"}`,
+		},
+		{
+			Path:    "transactions[1]",
+			Stored:  missingField,
+			Fetched: `{"operations":[],"transaction_identifier":{"hash":"extra"}}`,
+		},
+	}, diff)
+
+	diff, err = DiffFields(stored, auditTestBlock(1, "20"))
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+}
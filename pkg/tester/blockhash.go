@@ -0,0 +1,147 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/neilotoole/errgroup"
+)
+
+var _ modules.BlockWorker = (*BlockHashWatcher)(nil)
+
+// BlockHashWatcher watches synced blocks for the block
+// hash end condition.
+type BlockHashWatcher struct {
+	hash  string
+	index *int64
+
+	// onChange is called after the block with hash
+	// is committed (or with nil if it is orphaned).
+	onChange func(context.Context, *types.BlockIdentifier)
+
+	lock    sync.Mutex
+	matched *types.BlockIdentifier
+}
+
+// NewBlockHashWatcher returns a new *BlockHashWatcher for the block with hash. If
+// index is populated, a block committed at index with
+// another hash causes syncing to fail with ErrBlockHashNotFound.
+func NewBlockHashWatcher(
+	hash string,
+	index *int64,
+	onChange func(context.Context, *types.BlockIdentifier),
+) *BlockHashWatcher {
+	return &BlockHashWatcher{
+		hash:     hash,
+		index:    index,
+		onChange: onChange,
+	}
+}
+
+// Matched returns the identifier of the committed
+// block with the watched hash (or nil if it has
+// not been committed).
+func (w *BlockHashWatcher) Matched() *types.BlockIdentifier {
+	if w == nil {
+		return nil
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.matched
+}
+
+// Check returns ErrBlockHashNotFound if block is at the expected
+// index but does not have the watched hash.
+func (w *BlockHashWatcher) Check(block *types.BlockIdentifier) error {
+	if w.index == nil || block.Index != *w.index || block.Hash == w.hash {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: block %d is %s but expected %s",
+		results.ErrBlockHashNotFound,
+		block.Index,
+		block.Hash,
+		w.hash,
+	)
+}
+
+// Match records that block (already in storage) has
+// the watched hash. onChange is only called the first
+// time a block is matched.
+func (w *BlockHashWatcher) Match(ctx context.Context, block *types.BlockIdentifier) {
+	w.lock.Lock()
+	if w.matched != nil {
+		w.lock.Unlock()
+		return
+	}
+	w.matched = block
+	w.lock.Unlock()
+
+	w.onChange(ctx, block)
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+// Returning an error stops the block from being stored.
+func (w *BlockHashWatcher) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	if err := w.Check(block.BlockIdentifier); err != nil {
+		return nil, err
+	}
+
+	if block.BlockIdentifier.Hash != w.hash {
+		return nil, nil
+	}
+
+	return func(ctx context.Context) error {
+		w.Match(ctx, block.BlockIdentifier)
+		return nil
+	}, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+func (w *BlockHashWatcher) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	if block.BlockIdentifier.Hash != w.hash {
+		return nil, nil
+	}
+
+	return func(ctx context.Context) error {
+		w.lock.Lock()
+		w.matched = nil
+		w.lock.Unlock()
+
+		w.onChange(ctx, nil)
+		return nil
+	}, nil
+}
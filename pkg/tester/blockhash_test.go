@@ -0,0 +1,128 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockHashWatcherAddingBlock(t *testing.T) {
+	var tests = map[string]struct {
+		index  *int64
+		blocks []*types.BlockIdentifier
+
+		expectedMatched *types.BlockIdentifier
+		expectedErr     error
+	}{
+		"not found": {
+			blocks: []*types.BlockIdentifier{
+				{Index: 9, Hash: "block 9"},
+				{Index: 10, Hash: "other block 10"},
+				{Index: 11, Hash: "block 11"},
+			},
+		},
+		"found": {
+			blocks: []*types.BlockIdentifier{
+				{Index: 9, Hash: "block 9"},
+				{Index: 10, Hash: "block 10"},
+			},
+			expectedMatched: &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		},
+		"found at expected index": {
+			index: types.Int64(10),
+			blocks: []*types.BlockIdentifier{
+				{Index: 9, Hash: "block 9"},
+				{Index: 10, Hash: "block 10"},
+			},
+			expectedMatched: &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+		},
+		"other block at expected index": {
+			index: types.Int64(10),
+			blocks: []*types.BlockIdentifier{
+				{Index: 9, Hash: "block 9"},
+				{Index: 10, Hash: "other block 10"},
+			},
+			expectedErr: results.ErrBlockHashNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			changes := []*types.BlockIdentifier{}
+			w := NewBlockHashWatcher("block 10", test.index, func(ctx context.Context, b *types.BlockIdentifier) {
+				changes = append(changes, b)
+			})
+
+			var err error
+			for _, block := range test.blocks {
+				var commitWorker func(context.Context) error
+				commitWorker, err = w.AddingBlock(
+					ctx,
+					nil,
+					&types.Block{BlockIdentifier: block},
+					nil,
+				)
+				if err != nil {
+					break
+				}
+
+				if commitWorker != nil {
+					assert.NoError(t, commitWorker(ctx))
+				}
+			}
+
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expectedMatched, w.Matched())
+			if test.expectedMatched == nil {
+				assert.Empty(t, changes)
+				return
+			}
+
+			assert.Equal(t, []*types.BlockIdentifier{test.expectedMatched}, changes)
+
+			// The matched block is cleared
+			// when it is orphaned.
+			commitWorker, err := w.RemovingBlock(
+				ctx,
+				nil,
+				&types.Block{BlockIdentifier: test.expectedMatched},
+				nil,
+			)
+			assert.NoError(t, err)
+			assert.NoError(t, commitWorker(ctx))
+			assert.Nil(t, w.Matched())
+			assert.Equal(t, []*types.BlockIdentifier{test.expectedMatched, nil}, changes)
+		})
+	}
+}
+
+func TestMatchedNil(t *testing.T) {
+	var w *BlockHashWatcher
+	assert.Nil(t, w.Matched())
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// TimedSyncer is the syncer.Handler and syncer.Helper
+// wrapped by a *BlockTimer (i.e. a *statefulsyncer.StatefulSyncer).
+type TimedSyncer interface {
+	syncer.Handler
+	syncer.Helper
+}
+
+// BlockTimer wraps the TimedSyncer used to sync blocks and measures the
+// time spent fetching and processing (seeing and adding) each
+// block. Blocks that take longer than the threshold are logged.
+type BlockTimer struct {
+	syncer    TimedSyncer
+	threshold time.Duration
+
+	lock sync.Mutex
+
+	// durations are the fetch and seen durations
+	// of blocks that have not been added yet
+	// (keyed by block hash).
+	durations map[string]time.Duration
+
+	blocks          int64
+	slowBlocks      int64
+	slowestBlock    *types.BlockIdentifier
+	slowestDuration time.Duration
+}
+
+// NewBlockTimer returns a new *BlockTimer that logs blocks
+// that take longer than threshold.
+func NewBlockTimer(syncer TimedSyncer, threshold time.Duration) *BlockTimer {
+	return &BlockTimer{
+		syncer:    syncer,
+		threshold: threshold,
+		durations: map[string]time.Duration{},
+	}
+}
+
+// record adds elapsed to the duration of block.
+func (t *BlockTimer) record(block *types.BlockIdentifier, elapsed time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.durations[block.Hash] += elapsed
+}
+
+// Block is called by the syncer to fetch a block.
+func (t *BlockTimer) Block(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, error) {
+	start := time.Now()
+	block, err := t.syncer.Block(ctx, network, blockIdentifier)
+	if err != nil || block == nil {
+		return block, err
+	}
+
+	t.record(block.BlockIdentifier, time.Since(start))
+	return block, nil
+}
+
+// NetworkStatus is called by the syncer to
+// get the current network status.
+func (t *BlockTimer) NetworkStatus(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+) (*types.NetworkStatusResponse, error) {
+	return t.syncer.NetworkStatus(ctx, network)
+}
+
+// BlockSeen is called by the syncer when a block is seen.
+func (t *BlockTimer) BlockSeen(ctx context.Context, block *types.Block) error {
+	start := time.Now()
+	if err := t.syncer.BlockSeen(ctx, block); err != nil {
+		return err
+	}
+
+	t.record(block.BlockIdentifier, time.Since(start))
+	return nil
+}
+
+// BlockAdded is called by the syncer when a block is added.
+// The fetch, seen, and added durations of the block are
+// summed and the block is logged if it is slow.
+func (t *BlockTimer) BlockAdded(ctx context.Context, block *types.Block) error {
+	start := time.Now()
+	if err := t.syncer.BlockAdded(ctx, block); err != nil {
+		return err
+	}
+	added := time.Since(start)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	elapsed := t.durations[block.BlockIdentifier.Hash] + added
+	delete(t.durations, block.BlockIdentifier.Hash)
+
+	t.blocks++
+	if t.slowestBlock == nil || elapsed > t.slowestDuration {
+		t.slowestBlock = block.BlockIdentifier
+		t.slowestDuration = elapsed
+	}
+
+	if elapsed > t.threshold {
+		t.slowBlocks++
+		log.Printf(
+			"[WARNING] slow block %d (%s) took %s to fetch and process (threshold: %s)\n",
+			block.BlockIdentifier.Index,
+			block.BlockIdentifier.Hash,
+			elapsed.Round(time.Millisecond),
+			t.threshold,
+		)
+	}
+
+	return nil
+}
+
+// BlockRemoved is called by the syncer when a block is removed.
+func (t *BlockTimer) BlockRemoved(
+	ctx context.Context,
+	blockIdentifier *types.BlockIdentifier,
+) error {
+	return t.syncer.BlockRemoved(ctx, blockIdentifier)
+}
+
+// SlowBlocks returns the number of blocks that
+// took longer than the threshold (0 if the
+// *BlockTimer is nil).
+func (t *BlockTimer) SlowBlocks() int64 {
+	if t == nil {
+		return 0
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.slowBlocks
+}
+
+// Slowest returns the duration of the slowest
+// block (0 if the *BlockTimer is nil).
+func (t *BlockTimer) Slowest() time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.slowestDuration
+}
+
+// BlockTimingResults returns the *BlockTimingResults of the *BlockTimer. If the
+// *BlockTimer is nil or no blocks were added, nil is returned.
+func (t *BlockTimer) Results() *results.BlockTimingResults {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.blocks == 0 {
+		return nil
+	}
+
+	return &results.BlockTimingResults{
+		ThresholdMs:    t.threshold.Milliseconds(),
+		Blocks:         t.blocks,
+		SlowBlocks:     t.slowBlocks,
+		SlowestBlock:   t.slowestBlock,
+		SlowestBlockMs: t.slowestDuration.Milliseconds(),
+	}
+}
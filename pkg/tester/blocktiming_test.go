@@ -0,0 +1,146 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var blocktimingNetwork = &types.NetworkIdentifier{Blockchain: "blockchain", Network: "network"}
+
+func blocktimingTestBlock(index int64) *types.Block {
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Index: index, Hash: fmt.Sprintf("block %d", index)},
+	}
+}
+
+// mockSyncer fetches blocks after the delay
+// of their index (if any).
+type blocktimingMockSyncer struct {
+	delays map[int64]time.Duration
+}
+
+func (m *blocktimingMockSyncer) BlockSeen(context.Context, *types.Block) error { return nil }
+
+func (m *blocktimingMockSyncer) BlockAdded(context.Context, *types.Block) error { return nil }
+
+func (m *blocktimingMockSyncer) BlockRemoved(context.Context, *types.BlockIdentifier) error {
+	return nil
+}
+
+func (m *blocktimingMockSyncer) NetworkStatus(
+	context.Context,
+	*types.NetworkIdentifier,
+) (*types.NetworkStatusResponse, error) {
+	return &types.NetworkStatusResponse{}, nil
+}
+
+func (m *blocktimingMockSyncer) Block(
+	_ context.Context,
+	_ *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, error) {
+	time.Sleep(m.delays[*blockIdentifier.Index])
+	return blocktimingTestBlock(*blockIdentifier.Index), nil
+}
+
+// syncBlocks fetches, sees, and adds blocks
+// [0, count) through timer.
+func syncBlocks(t *testing.T, timer *BlockTimer, count int64) {
+	ctx := context.Background()
+	for i := int64(0); i < count; i++ {
+		index := i
+		block, err := timer.Block(ctx, blocktimingNetwork, &types.PartialBlockIdentifier{Index: &index})
+		assert.NoError(t, err)
+		assert.NoError(t, timer.BlockSeen(ctx, block))
+		assert.NoError(t, timer.BlockAdded(ctx, block))
+	}
+}
+
+func TestBlockTimer(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+
+	var tests = map[string]struct {
+		delays map[int64]time.Duration
+
+		expectedSlowBlocks int64
+		expectedSlowest    int64
+	}{
+		"no slow blocks": {
+			delays:             map[int64]time.Duration{2: 10 * time.Millisecond},
+			expectedSlowBlocks: 0,
+			expectedSlowest:    2,
+		},
+		"slow blocks": {
+			delays: map[int64]time.Duration{
+				1: 60 * time.Millisecond,
+				3: 120 * time.Millisecond,
+			},
+			expectedSlowBlocks: 2,
+			expectedSlowest:    3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+
+			timer := NewBlockTimer(&blocktimingMockSyncer{delays: test.delays}, 50*time.Millisecond)
+			syncBlocks(t, timer, 5)
+
+			results := timer.Results()
+			assert.Equal(t, int64(50), results.ThresholdMs)
+			assert.Equal(t, int64(5), results.Blocks)
+			assert.Equal(t, test.expectedSlowBlocks, results.SlowBlocks)
+			assert.Equal(t, test.expectedSlowBlocks, timer.SlowBlocks())
+			assert.Equal(t, blocktimingTestBlock(test.expectedSlowest).BlockIdentifier, results.SlowestBlock)
+			assert.Equal(t, timer.Slowest().Milliseconds(), results.SlowestBlockMs)
+			assert.GreaterOrEqual(t, results.SlowestBlockMs, test.delays[test.expectedSlowest].Milliseconds())
+
+			// Each slow block is logged with its index.
+			assert.Equal(t, int(test.expectedSlowBlocks), strings.Count(logs.String(), "slow block"))
+			for index, delay := range test.delays {
+				logged := strings.Contains(logs.String(), fmt.Sprintf("slow block %d (", index))
+				assert.Equal(t, delay > 50*time.Millisecond, logged)
+			}
+
+			// All blocks were added, so no
+			// durations are retained.
+			assert.Len(t, timer.durations, 0)
+		})
+	}
+}
+
+func TestNilTimer(t *testing.T) {
+	var timer *BlockTimer
+	assert.Nil(t, timer.Results())
+	assert.Equal(t, int64(0), timer.SlowBlocks())
+	assert.Equal(t, time.Duration(0), timer.Slowest())
+
+	// No results are returned until
+	// a block is added.
+	assert.Nil(t, NewBlockTimer(&blocktimingMockSyncer{}, time.Second).Results())
+}
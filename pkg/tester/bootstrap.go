@@ -0,0 +1,273 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// BootstrapBatchSize is the number of balances written
+	// in each database transaction.
+	BootstrapBatchSize = utils.MaxEntrySizePerTxn
+
+	// writeIdentifier prefixes the identifier of the write
+	// transaction of each batch (batches must use different
+	// identifiers to be written concurrently).
+	writeIdentifier = "bootstrap"
+)
+
+var _ modules.BalanceStorageHandler = (*counts)(nil)
+
+// counts is a modules.BalanceStorageHandler that counts
+// the accounts seen and reconciled in a batch instead of
+// updating their counters. Each batch is written in its own
+// transaction, so updating a shared counter in each of them
+// would cause concurrent transactions to conflict.
+type counts struct {
+	seen       int64
+	reconciled int64
+}
+
+// BlockAdded is never called while bootstrapping.
+func (c *counts) BlockAdded(
+	ctx context.Context,
+	block *types.Block,
+	changes []*parser.BalanceChange,
+) error {
+	return nil
+}
+
+// BlockRemoved is never called while bootstrapping.
+func (c *counts) BlockRemoved(
+	ctx context.Context,
+	block *types.Block,
+	changes []*parser.BalanceChange,
+) error {
+	return nil
+}
+
+// AccountsReconciled adds count to the
+// accounts reconciled in the batch.
+func (c *counts) AccountsReconciled(
+	ctx context.Context,
+	dbTx database.Transaction,
+	count int,
+) error {
+	c.reconciled += int64(count)
+	return nil
+}
+
+// AccountsSeen adds count to the
+// accounts seen in the batch.
+func (c *counts) AccountsSeen(
+	ctx context.Context,
+	dbTx database.Transaction,
+	count int,
+) error {
+	c.seen += int64(count)
+	return nil
+}
+
+// UniqueBootstrapBalances returns balances with only the last balance of
+// each account and currency (in the order provided), which
+// is the balance a sequential load would keep.
+func UniqueBootstrapBalances(balances []*modules.BootstrapBalance) []*modules.BootstrapBalance {
+	last := map[string]int{}
+	for i, balance := range balances {
+		last[types.Hash(&types.AccountCurrency{
+			Account:  balance.Account,
+			Currency: balance.Currency,
+		})] = i
+	}
+
+	if len(last) == len(balances) {
+		return balances
+	}
+
+	unique := make([]*modules.BootstrapBalance, 0, len(last))
+	for i, balance := range balances {
+		key := types.Hash(&types.AccountCurrency{
+			Account:  balance.Account,
+			Currency: balance.Currency,
+		})
+		if last[key] == i {
+			unique = append(unique, balance)
+		}
+	}
+
+	return unique
+}
+
+// BulkLoadBalances sets the balance of each account in balances at
+// block. Balances are written in batches of BootstrapBatchSize by
+// concurrency workers and any error aborts the load.
+//
+// Load is idempotent (bootstrapping an account again
+// replaces its balance) and each account is written by a
+// single batch, so the result does not depend on the order
+// in which batches are committed.
+func BulkLoadBalances(
+	ctx context.Context,
+	db database.Database,
+	helper modules.BalanceStorageHelper,
+	counterStorage *modules.CounterStorage,
+	balances []*modules.BootstrapBalance,
+	block *types.BlockIdentifier,
+	concurrency int,
+) error {
+	balances = UniqueBootstrapBalances(balances)
+	for _, balance := range balances {
+		value, ok := new(big.Int).SetString(balance.Value, 10)
+		if !ok {
+			return fmt.Errorf("%s is not an integer", balance.Value)
+		}
+
+		if value.Sign() < 1 {
+			return fmt.Errorf("cannot bootstrap zero or negative balance %s", value.String())
+		}
+	}
+
+	batches := make(chan int)
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		defer close(batches)
+
+		for start := 0; start < len(balances); start += BootstrapBatchSize {
+			select {
+			case batches <- start:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		return nil
+	})
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	for i := 0; i < concurrency; i++ {
+		handler := &counts{}
+		balanceStorage := modules.NewBalanceStorage(db)
+		balanceStorage.Initialize(helper, handler)
+
+		g.Go(func() error {
+			for start := range batches {
+				end := start + BootstrapBatchSize
+				if end > len(balances) {
+					end = len(balances)
+				}
+
+				err := writeBatch(
+					ctx,
+					db,
+					balanceStorage,
+					handler,
+					counterStorage,
+					fmt.Sprintf("%s-%d", writeIdentifier, start),
+					balances[start:end],
+					block,
+				)
+				if err != nil {
+					return fmt.Errorf(
+						"%w: unable to bootstrap balances %d to %d",
+						err,
+						start,
+						end-1,
+					)
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	log.Printf("%d Balances Bootstrapped\n", len(balances))
+	return nil
+}
+
+// writeBatch writes balances in a single transaction and
+// then updates the counters by the accounts seen and
+// reconciled in the batch.
+func writeBatch(
+	ctx context.Context,
+	db database.Database,
+	balanceStorage *modules.BalanceStorage,
+	handler *counts,
+	counterStorage *modules.CounterStorage,
+	identifier string,
+	balances []*modules.BootstrapBalance,
+	block *types.BlockIdentifier,
+) error {
+	handler.seen, handler.reconciled = 0, 0
+
+	dbTx := db.WriteTransaction(ctx, identifier, false)
+	defer dbTx.Discard(ctx)
+
+	for _, balance := range balances {
+		if err := balanceStorage.SetBalance(
+			ctx,
+			dbTx,
+			balance.Account,
+			&types.Amount{
+				Value:    balance.Value,
+				Currency: balance.Currency,
+			},
+			block,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return err
+	}
+
+	if _, err := counterStorage.Update(
+		ctx,
+		modules.SeenAccounts,
+		big.NewInt(handler.seen),
+	); err != nil {
+		return fmt.Errorf("%w: unable to update seen accounts", err)
+	}
+
+	if handler.reconciled != 0 {
+		if _, err := counterStorage.Update(
+			ctx,
+			modules.ReconciledAccounts,
+			big.NewInt(handler.reconciled),
+		); err != nil {
+			return fmt.Errorf("%w: unable to update reconciled accounts", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	genesisBlock = &types.BlockIdentifier{Index: 0, Hash: "block 0"}
+	bootstrapBtc = &types.Currency{Symbol: "BTC", Decimals: 8}
+)
+
+type mockHelper struct{}
+
+func (m *mockHelper) AccountBalance(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	block *types.BlockIdentifier,
+) (*types.Amount, error) {
+	return nil, nil
+}
+
+func (m *mockHelper) ExemptFunc() parser.ExemptOperation { return nil }
+
+func (m *mockHelper) BalanceExemptions() []*types.BalanceExemption { return nil }
+
+func (m *mockHelper) Asserter() *asserter.Asserter { return nil }
+
+func (m *mockHelper) AccountsReconciled(
+	ctx context.Context,
+	dbTx database.Transaction,
+) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (m *mockHelper) AccountsSeen(
+	ctx context.Context,
+	dbTx database.Transaction,
+) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+// syntheticBalances returns count balances of distinct accounts
+// (where the value of account i is i+1).
+func syntheticBalances(count int) []*modules.BootstrapBalance {
+	balances := make([]*modules.BootstrapBalance, count)
+	for i := range balances {
+		balances[i] = &modules.BootstrapBalance{
+			Account:  &types.AccountIdentifier{Address: fmt.Sprintf("addr%d", i)},
+			Currency: bootstrapBtc,
+			Value:    fmt.Sprintf("%d", i+1),
+		}
+	}
+
+	return balances
+}
+
+// newDatabase returns a database in a temporary
+// directory and a function to close and remove it.
+func bootstrapNewDatabase(ctx context.Context, t testing.TB) (database.Database, func()) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+
+	return db, func() {
+		db.Close(ctx)
+		utils.RemoveTempDir(dir)
+	}
+}
+
+func TestBulkLoadBalances(t *testing.T) {
+	ctx := context.Background()
+	db, closeDB := bootstrapNewDatabase(ctx, t)
+	defer closeDB()
+
+	// The balances are loaded from a file (like bootstrap_balances)
+	// spanning many batches, including a partial last batch.
+	count := 10*BootstrapBatchSize + 17
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	filePath := path.Join(dir, "bootstrap_balances.json")
+	assert.NoError(t, utils.SerializeAndWrite(filePath, syntheticBalances(count)))
+	balances := []*modules.BootstrapBalance{}
+	assert.NoError(t, utils.LoadAndParse(filePath, &balances))
+
+	counterStorage := modules.NewCounterStorage(db)
+	assert.NoError(t, BulkLoadBalances(ctx, db, &mockHelper{}, counterStorage, balances, genesisBlock, 8))
+
+	seen, err := counterStorage.Get(ctx, modules.SeenAccounts)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(count), seen.Int64())
+
+	balanceStorage := modules.NewBalanceStorage(db)
+	balanceStorage.Initialize(&mockHelper{}, &counts{})
+	accounts, err := balanceStorage.GetAllAccountCurrency(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, accounts, count)
+
+	for _, i := range []int{0, BootstrapBatchSize - 1, BootstrapBatchSize, count - 1} {
+		amount, err := balanceStorage.GetBalance(
+			ctx,
+			&types.AccountIdentifier{Address: fmt.Sprintf("addr%d", i)},
+			bootstrapBtc,
+			genesisBlock.Index,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%d", i+1), amount.Value)
+	}
+
+	// Loading again replaces the balances without
+	// counting the accounts twice.
+	assert.NoError(t, BulkLoadBalances(ctx, db, &mockHelper{}, counterStorage, balances, genesisBlock, 3))
+	seen, err = counterStorage.Get(ctx, modules.SeenAccounts)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(count), seen.Int64())
+}
+
+func TestBulkLoadBalancesInvalidBalance(t *testing.T) {
+	ctx := context.Background()
+	db, closeDB := bootstrapNewDatabase(ctx, t)
+	defer closeDB()
+
+	var tests = map[string]string{
+		"not an integer": "1.5",
+		"zero":           "0",
+		"negative":       "-1",
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			balances := syntheticBalances(3 * BootstrapBatchSize)
+			balances[2*BootstrapBatchSize+1].Value = value
+
+			counterStorage := modules.NewCounterStorage(db)
+			err := BulkLoadBalances(ctx, db, &mockHelper{}, counterStorage, balances, genesisBlock, 4)
+			assert.Error(t, err)
+
+			// No batch is written if any balance is invalid.
+			seen, err := counterStorage.Get(ctx, modules.SeenAccounts)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(0), seen.Int64())
+		})
+	}
+}
+
+func TestBulkLoadBalancesCanceled(t *testing.T) {
+	ctx := context.Background()
+	db, closeDB := bootstrapNewDatabase(ctx, t)
+	defer closeDB()
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	err := BulkLoadBalances(
+		canceled,
+		db,
+		&mockHelper{},
+		modules.NewCounterStorage(db),
+		syntheticBalances(4*BootstrapBatchSize),
+		genesisBlock,
+		2,
+	)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUniqueBootstrapBalances(t *testing.T) {
+	balances := syntheticBalances(3)
+	duplicate := &modules.BootstrapBalance{
+		Account:  balances[0].Account,
+		Currency: bootstrapBtc,
+		Value:    "100",
+	}
+
+	assert.Equal(t, balances, UniqueBootstrapBalances(balances))
+	assert.Equal(
+		t,
+		[]*modules.BootstrapBalance{balances[1], balances[2], duplicate},
+		UniqueBootstrapBalances(append(balances, duplicate)),
+	)
+}
+
+func BenchmarkBulkLoadBalances(b *testing.B) {
+	ctx := context.Background()
+	balances := syntheticBalances(20 * BootstrapBatchSize)
+
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("concurrency %d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				db, closeDB := bootstrapNewDatabase(ctx, b)
+				counterStorage := modules.NewCounterStorage(db)
+				b.StartTimer()
+
+				err := BulkLoadBalances(ctx, db, &mockHelper{}, counterStorage, balances, genesisBlock, concurrency)
+				assert.NoError(b, err)
+
+				b.StopTimer()
+				closeDB()
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/neilotoole/errgroup"
+)
+
+var (
+	// ErrCurrencyVariants is returned when warnings are treated
+	// as errors and currency variants were found.
+	ErrCurrencyVariants = errors.New("currency variants found")
+)
+
+var _ modules.BlockWorker = (*CurrencyCasingChecker)(nil)
+
+// CurrencyCasingChecker records the first-seen currency for each
+// symbol (compared case-insensitively) and reports every
+// balance-affecting operation that uses a different
+// casing, decimals, or metadata for the same symbol.
+//
+// Metadata is compared by content because key ordering is
+// not preserved when responses are decoded. First-seen
+// currencies are only tracked in memory, so they are
+// relearned whenever check:data is restarted.
+type CurrencyCasingChecker struct {
+	nonFatalErrors *nonfatal.Tracker
+
+	lock      sync.Mutex
+	canonical map[string]*types.Currency
+	variants  map[string]*results.CurrencyVariant
+}
+
+// NewCurrencyCasingChecker returns a new *CurrencyCasingChecker that records
+// each variant found in nonFatalErrors.
+func NewCurrencyCasingChecker(nonFatalErrors *nonfatal.Tracker) *CurrencyCasingChecker {
+	return &CurrencyCasingChecker{
+		nonFatalErrors: nonFatalErrors,
+		canonical:      map[string]*types.Currency{},
+		variants:       map[string]*results.CurrencyVariant{},
+	}
+}
+
+// check records currency (observed in transaction
+// of block) and reports it if it is a variant.
+func (c *CurrencyCasingChecker) check(
+	block *types.BlockIdentifier,
+	transaction *types.TransactionIdentifier,
+	currency *types.Currency,
+) {
+	symbol := strings.ToLower(currency.Symbol)
+	canonical, ok := c.canonical[symbol]
+	if !ok {
+		c.canonical[symbol] = currency
+		return
+	}
+
+	canonicalHash := types.Hash(canonical)
+	variantHash := types.Hash(currency)
+	if canonicalHash == variantHash {
+		return
+	}
+
+	color.Yellow(
+		"[WARNING] currency %s in transaction %s of block %d differs from first seen %s",
+		types.PrintStruct(currency),
+		transaction.Hash,
+		block.Index,
+		types.PrintStruct(canonical),
+	)
+
+	key := canonicalHash + variantHash
+	variant, ok := c.variants[key]
+	if !ok {
+		variant = &results.CurrencyVariant{
+			Canonical:  canonical,
+			Variant:    currency,
+			FirstBlock: block,
+		}
+		c.variants[key] = variant
+	}
+
+	variant.Count++
+	variant.LastBlock = block
+	c.nonFatalErrors.Record(nonfatal.CurrencyVariant)
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (c *CurrencyCasingChecker) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, tx := range block.Transactions {
+		for _, op := range tx.Operations {
+			if op.Amount == nil || op.Amount.Currency == nil {
+				continue
+			}
+
+			c.check(block.BlockIdentifier, tx.TransactionIdentifier, op.Amount.Currency)
+		}
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// Variants found in orphaned blocks are still reported because the
+// implementation returned them.
+func (c *CurrencyCasingChecker) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	return nil, nil
+}
+
+// CurrencyCasingResults returns the *CurrencyCasingResults of all blocks
+// checked. Variants are sorted by first block.
+func (c *CurrencyCasingChecker) Results() *results.CurrencyCasingResults {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	variants := make([]*results.CurrencyVariant, 0, len(c.variants))
+	for _, variant := range c.variants {
+		v := *variant
+		variants = append(variants, &v)
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		if variants[i].FirstBlock.Index != variants[j].FirstBlock.Index {
+			return variants[i].FirstBlock.Index < variants[j].FirstBlock.Index
+		}
+
+		return types.Hash(variants[i].Variant) < types.Hash(variants[j].Variant)
+	})
+
+	return &results.CurrencyCasingResults{Variants: variants}
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	casingBtc = &types.Currency{Symbol: "BTC", Decimals: 8}
+	btcLower  = &types.Currency{Symbol: "btc", Decimals: 8}
+	btcMeta   = &types.Currency{
+		Symbol:   "BTC",
+		Decimals: 8,
+		Metadata: map[string]interface{}{"issuer": "a"},
+	}
+	casingEth = &types.Currency{Symbol: "ETH", Decimals: 18}
+)
+
+// block returns a block at index with a single
+// transaction containing an operation for each currency.
+func casingBlock(index int64, currencies ...*types.Currency) *types.Block {
+	ops := []*types.Operation{
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 0},
+			Type:                "fee",
+		},
+	}
+	for i, currency := range currencies {
+		ops = append(ops, &types.Operation{
+			OperationIdentifier: &types.OperationIdentifier{Index: int64(i + 1)},
+			Type:                "transfer",
+			Account:             &types.AccountIdentifier{Address: "addr1"},
+			Amount:              &types.Amount{Value: "1", Currency: currency},
+		})
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: fmt.Sprintf("tx %d", index),
+				},
+				Operations: ops,
+			},
+		},
+	}
+}
+
+func TestCurrencyCasingChecker(t *testing.T) {
+	var tests = map[string]struct {
+		blocks []*types.Block
+
+		expected *results.CurrencyCasingResults
+	}{
+		"no variants": {
+			blocks: []*types.Block{
+				casingBlock(0, casingBtc, casingEth),
+				casingBlock(1, casingBtc),
+			},
+			expected: &results.CurrencyCasingResults{Variants: []*results.CurrencyVariant{}},
+		},
+		"casing variant": {
+			blocks: []*types.Block{
+				casingBlock(0, casingBtc),
+				casingBlock(1, btcLower, casingEth),
+				casingBlock(2, btcLower),
+				casingBlock(3, casingBtc),
+			},
+			expected: &results.CurrencyCasingResults{
+				Variants: []*results.CurrencyVariant{
+					{
+						Canonical:  casingBtc,
+						Variant:    btcLower,
+						Count:      2,
+						FirstBlock: casingBlock(1).BlockIdentifier,
+						LastBlock:  casingBlock(2).BlockIdentifier,
+					},
+				},
+			},
+		},
+		"lowercase seen first": {
+			blocks: []*types.Block{
+				casingBlock(0, btcLower),
+				casingBlock(1, casingBtc),
+			},
+			expected: &results.CurrencyCasingResults{
+				Variants: []*results.CurrencyVariant{
+					{
+						Canonical:  btcLower,
+						Variant:    casingBtc,
+						Count:      1,
+						FirstBlock: casingBlock(1).BlockIdentifier,
+						LastBlock:  casingBlock(1).BlockIdentifier,
+					},
+				},
+			},
+		},
+		"multiple variants": {
+			blocks: []*types.Block{
+				casingBlock(0, casingBtc),
+				casingBlock(1, btcMeta),
+				casingBlock(2, btcLower, btcMeta),
+			},
+			expected: &results.CurrencyCasingResults{
+				Variants: []*results.CurrencyVariant{
+					{
+						Canonical:  casingBtc,
+						Variant:    btcMeta,
+						Count:      2,
+						FirstBlock: casingBlock(1).BlockIdentifier,
+						LastBlock:  casingBlock(2).BlockIdentifier,
+					},
+					{
+						Canonical:  casingBtc,
+						Variant:    btcLower,
+						Count:      1,
+						FirstBlock: casingBlock(2).BlockIdentifier,
+						LastBlock:  casingBlock(2).BlockIdentifier,
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			nonFatalErrors := nonfatal.New(0)
+			c := NewCurrencyCasingChecker(nonFatalErrors)
+			for _, b := range test.blocks {
+				_, err := c.AddingBlock(ctx, nil, b, nil)
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expected, c.Results())
+
+			variants := int64(0)
+			for _, variant := range test.expected.Variants {
+				variants += variant.Count
+			}
+			if variants > 0 {
+				assert.Equal(t, variants, nonFatalErrors.Results().Total)
+			} else {
+				assert.Nil(t, nonFatalErrors.Results())
+			}
+		})
+	}
+}
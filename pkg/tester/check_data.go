@@ -0,0 +1,420 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sync/errgroup"
+)
+
+// CheckDataOptions are the settings of a check:data
+// run that are not part of its configuration.
+type CheckDataOptions struct {
+	// SignalReceived is set to true when a signal stops
+	// check:data and SigListeners are the context.CancelFuncs
+	// called when it does.
+	SignalReceived *bool
+	SigListeners   *[]context.CancelFunc
+
+	// ConfigurationSnapshot is the ConfigurationSnapshot of the
+	// configuration loaded from ConfigurationFile (with
+	// ConfigurationProfile). Changes to the configuration file
+	// are only applied to the running check:data if it is
+	// populated.
+	ConfigurationFile     string
+	ConfigurationProfile  string
+	ConfigurationSnapshot ConfigurationSnapshot
+
+	// ValidateNetworkOptions, if populated, is called with the
+	// initialized online fetcher before syncing starts.
+	ValidateNetworkOptions func(ctx context.Context, fetcher *fetcher.Fetcher) error
+}
+
+// CheckData runs check:data with config and returns its results
+// (which are also printed and, if data.results_output_file is
+// populated, written to a file). The returned error is nil
+// only if check:data passed.
+//
+// Unlike the check:data command, CheckData does not handle signals
+// (cancel ctx to stop check:data) or reload the configuration file,
+// and it can be called concurrently as long as the configurations
+// do not share a data directory or status port. config is not
+// modified: CheckData runs with a copy whose missing fields are
+// populated with defaults. If config.RunID is empty, a new run id
+// is generated (it is included in the results).
+func CheckData(
+	ctx context.Context,
+	config *configuration.Configuration,
+) (*results.CheckDataResults, error) {
+	config, err := configuration.PopulateConfiguration(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.RunID) == 0 {
+		config.RunID = httpclient.NewRunID()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	signalReceived := false
+	return RunCheckData(
+		ctx,
+		cancel,
+		config,
+		NewClients(config, config.RunID, metrics.NewRequestDurations()),
+		&CheckDataOptions{
+			SignalReceived: &signalReceived,
+			SigListeners:   &[]context.CancelFunc{cancel},
+		},
+	)
+}
+
+// RunCheckData runs check:data with a populated config (using
+// clients for all requests) until it stops and returns its
+// results. cancel stops check:data.
+func RunCheckData(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	config *configuration.Configuration,
+	clients *Clients,
+	opts *CheckDataOptions,
+) (*results.CheckDataResults, error) {
+	if err := EnsureDataDirectoryExists(config); err != nil {
+		cancel()
+		return results.ExitData(config, nil, nil, err, "", "", nil)
+	}
+
+	endpointOverrides, err := httpclient.NewEndpointOverrides(config.Data.EndpointOverrides)
+	if err != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to load endpoint overrides", err),
+			"",
+			"",
+			nil,
+		)
+	}
+
+	nonFatalErrors := nonfatal.New(config.Data.MaxErrors)
+	fetcher, err := clients.NewOnlineFetcher(
+		config.Data.HTTPTimeout,
+		httpclient.WithEndpointOverrides(endpointOverrides),
+		httpclient.WithRetriedRequests(nonFatalErrors, config.Data.FetchRetrySoftLimit),
+	)
+	if err != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize online fetcher", err),
+			"",
+			"",
+			nil,
+		)
+	}
+
+	_, _, fetchErr := fetcher.InitializeAsserter(ctx, config.Network, config.ValidationFile)
+	if fetchErr != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize asserter", fetchErr.Err),
+			"",
+			"",
+			&results.ExitDataOptions{
+				EndpointOverrides: endpointOverrides.Results(),
+			},
+		)
+	}
+
+	if len(config.Data.AsserterConfigurationFile) > 0 {
+		if err := WriteAsserterConfiguration(
+			fetcher.Asserter,
+			config.Data.AsserterConfigurationFile,
+		); err != nil {
+			cancel()
+			return results.ExitData(
+				config,
+				nil,
+				nil,
+				err,
+				"",
+				"",
+				&results.ExitDataOptions{
+					EndpointOverrides: endpointOverrides.Results(),
+				},
+			)
+		}
+
+		color.Cyan("saved asserter configuration to %s", config.Data.AsserterConfigurationFile)
+	}
+
+	networkStatus, err := utils.CheckNetworkSupported(ctx, config.Network, fetcher)
+	if err != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to confirm network", err),
+			"",
+			"",
+			&results.ExitDataOptions{
+				EndpointOverrides: endpointOverrides.Results(),
+			},
+		)
+	}
+
+	if err := configuration.ResolveRelativeIndexes(config.Data, networkStatus); err != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to resolve relative indexes", err),
+			"",
+			"",
+			&results.ExitDataOptions{
+				EndpointOverrides: endpointOverrides.Results(),
+			},
+		)
+	}
+
+	if opts.ValidateNetworkOptions != nil {
+		if err := opts.ValidateNetworkOptions(ctx, fetcher); err != nil {
+			cancel()
+			return results.ExitData(
+				config,
+				nil,
+				nil,
+				err,
+				"",
+				"",
+				&results.ExitDataOptions{
+					EndpointOverrides: endpointOverrides.Results(),
+				},
+			)
+		}
+	}
+
+	skewMonitor, err := clients.InitializeSkewMonitor(ctx)
+	if err != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize clock skew monitor", err),
+			"",
+			"",
+			&results.ExitDataOptions{
+				EndpointOverrides: endpointOverrides.Results(),
+			},
+		)
+	}
+
+	comparisonFetcher, err := clients.NewSecondaryFetcher(config.ComparisonURL, fetcher.Asserter)
+	if err != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize comparison fetcher", err),
+			"",
+			"",
+			&results.ExitDataOptions{
+				ClockSkew:         skewMonitor.Current(),
+				EndpointOverrides: endpointOverrides.Results(),
+			},
+		)
+	}
+
+	archiveFetcher, err := clients.NewSecondaryFetcher(config.Data.ArchiveURL, fetcher.Asserter)
+	if err != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize archive fetcher", err),
+			"",
+			"",
+			&results.ExitDataOptions{
+				ClockSkew:         skewMonitor.Current(),
+				EndpointOverrides: endpointOverrides.Results(),
+			},
+		)
+	}
+
+	dataTester, err := InitializeData(
+		ctx,
+		config,
+		config.Network,
+		fetcher,
+		skewMonitor,
+		cancel,
+		networkStatus.GenesisBlockIdentifier,
+		nil, // only populated when doing recursive search
+		opts.SignalReceived,
+		processor.NewFailureHook(config.Data.ReconciliationFailureHook, config.RunID),
+		endpointOverrides,
+		comparisonFetcher,
+		archiveFetcher,
+		nonFatalErrors,
+	)
+	if err != nil {
+		cancel()
+		return results.ExitData(
+			config,
+			nil,
+			nil,
+			fmt.Errorf("%w: unable to initialize data tester", err),
+			"",
+			"",
+			&results.ExitDataOptions{
+				ClockSkew:         skewMonitor.Current(),
+				EndpointOverrides: endpointOverrides.Results(),
+			},
+		)
+	}
+
+	defer func() {
+		if err := dataTester.CloseDatabase(ctx); err != nil {
+			log.Printf("%s\n", err.Error())
+		}
+	}()
+
+	if err := dataTester.EstimateStorage(ctx); err != nil {
+		return dataTester.HandleErr(err, &[]context.CancelFunc{cancel})
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return dataTester.StartPeriodicLogger(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartProgressLogger(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartReconciler(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartSyncing(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartPruning(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.WatchEndConditions(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartReconcilerCountUpdater(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartInterestingAccountsWatcher(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.WatchNonFatalErrors(ctx)
+	})
+
+	var reloader *Reloader
+	if opts.ConfigurationSnapshot != nil {
+		reloader = NewReloader(
+			opts.ConfigurationFile,
+			opts.ConfigurationProfile,
+			opts.ConfigurationSnapshot,
+			dataTester,
+		)
+	}
+
+	g.Go(func() error {
+		return reloader.Start(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartDiskMonitor(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartCheckpointing(ctx)
+	})
+
+	if config.LogLevel.Enabled(zapcore.InfoLevel) {
+		g.Go(func() error {
+			return LogMemoryLoop(ctx)
+		})
+	}
+
+	g.Go(func() error {
+		return skewMonitor.Start(ctx)
+	})
+
+	g.Go(func() error {
+		if err := StartServer(
+			ctx,
+			"check:data status",
+			HealthHandler(
+				dataTester,
+				ReloadHandler(
+					reloader,
+					metrics.Handler(dataTester, clients.RequestDurations(), dataTester),
+				),
+			),
+			*config.Data.StatusPort,
+		); err != nil {
+			return fmt.Errorf(
+				"%w: set data.status_port to a free port or 0 to disable the status server",
+				err,
+			)
+		}
+
+		return nil
+	})
+
+	// HandleErr will exit if we should not attempt
+	// to find missing operations.
+	return dataTester.HandleErr(g.Wait(), opts.SigListeners)
+}
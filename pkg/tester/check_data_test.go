@@ -0,0 +1,207 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// stubTip is the index of the last block
+	// served by newStubImplementation.
+	stubTip = 3
+)
+
+var stubNetwork = &types.NetworkIdentifier{
+	Blockchain: "bitcoin",
+	Network:    "mainnet",
+}
+
+func stubBlockIdentifier(index int64) *types.BlockIdentifier {
+	return &types.BlockIdentifier{
+		Index: index,
+		Hash:  fmt.Sprintf("block %d", index),
+	}
+}
+
+// newStubImplementation returns an *httptest.Server that
+// serves blocks 0 to stubTip (with no transactions) of
+// stubNetwork.
+func newStubImplementation(t *testing.T) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var response interface{}
+			switch r.URL.Path {
+			case "/network/list":
+				response = &types.NetworkListResponse{
+					NetworkIdentifiers: []*types.NetworkIdentifier{stubNetwork},
+				}
+			case "/network/options":
+				response = &types.NetworkOptionsResponse{
+					Version: &types.Version{
+						RosettaVersion: types.RosettaAPIVersion,
+						NodeVersion:    "1.0.0",
+					},
+					Allow: &types.Allow{
+						OperationStatuses: []*types.OperationStatus{
+							{Status: "SUCCESS", Successful: true},
+						},
+						OperationTypes: []string{"TRANSFER"},
+						Errors:         []*types.Error{},
+					},
+				}
+			case "/network/status":
+				response = &types.NetworkStatusResponse{
+					CurrentBlockIdentifier: stubBlockIdentifier(stubTip),
+					CurrentBlockTimestamp:  utils.Milliseconds(),
+					GenesisBlockIdentifier: stubBlockIdentifier(0),
+					Peers:                  []*types.Peer{},
+				}
+			case "/block":
+				var request types.BlockRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+				index := *request.BlockIdentifier.Index
+				parentIndex := index - 1
+				if parentIndex < 0 {
+					parentIndex = 0
+				}
+
+				response = &types.BlockResponse{
+					Block: &types.Block{
+						BlockIdentifier:       stubBlockIdentifier(index),
+						ParentBlockIdentifier: stubBlockIdentifier(parentIndex),
+						Timestamp:             utils.Milliseconds(),
+						Transactions:          []*types.Transaction{},
+					},
+				}
+			default:
+				http.NotFound(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusOK)
+			assert.NoError(t, json.NewEncoder(w).Encode(response))
+		}),
+	)
+}
+
+func TestCheckData(t *testing.T) {
+	// Each check:data run has its own implementation
+	// and data directory, so they can run concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		ts := newStubImplementation(t)
+		defer ts.Close()
+
+		dir, err := utils.CreateTempDir()
+		assert.NoError(t, err)
+		defer utils.RemoveTempDir(dir)
+
+		config := configuration.DefaultConfiguration()
+		config.Network = stubNetwork
+		config.OnlineURL = ts.URL
+		config.DataDirectory = dir
+		config.Data.StatusPort = new(uint)
+		config.Data.ReconciliationDisabled = true
+		config.Data.EndConditions = &configuration.DataEndConditions{
+			Index: types.Int64(stubTip),
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkResults, err := CheckData(context.Background(), config)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, checkResults.RunID)
+			assert.Empty(t, config.RunID)
+			assert.Equal(t, &results.EndCondition{
+				Type:   configuration.IndexEndCondition,
+				Detail: fmt.Sprintf("Index: %d", stubTip),
+			}, checkResults.EndCondition)
+			assert.True(t, *checkResults.Tests.BlockSyncing)
+			assert.Equal(t, int64(stubTip+1), checkResults.Stats.Blocks)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestCheckDataPopulatesConfiguration(t *testing.T) {
+	ts := newStubImplementation(t)
+	defer ts.Close()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	// Only the fields required for this run are populated,
+	// the rest are populated with defaults in the copy used by CheckData.
+	config := &configuration.Configuration{
+		Network:       stubNetwork,
+		OnlineURL:     ts.URL,
+		DataDirectory: dir,
+		RunID:         "run",
+		Data: &configuration.DataConfiguration{
+			StatusPort:             new(uint),
+			ReconciliationDisabled: true,
+			EndConditions: &configuration.DataEndConditions{
+				Index: types.Int64(stubTip),
+			},
+		},
+	}
+
+	checkResults, err := CheckData(context.Background(), config)
+	assert.NoError(t, err)
+	assert.Equal(t, "run", checkResults.RunID)
+	assert.True(t, *checkResults.Tests.BlockSyncing)
+	assert.Equal(t, int64(stubTip+1), checkResults.Stats.Blocks)
+
+	// The provided configuration is never modified.
+	assert.Equal(t, &configuration.Configuration{
+		Network:       stubNetwork,
+		OnlineURL:     ts.URL,
+		DataDirectory: dir,
+		RunID:         "run",
+		Data: &configuration.DataConfiguration{
+			StatusPort:             new(uint),
+			ReconciliationDisabled: true,
+			EndConditions: &configuration.DataEndConditions{
+				Index: types.Int64(stubTip),
+			},
+		},
+	}, config)
+}
+
+func TestCheckDataMissingNetwork(t *testing.T) {
+	checkResults, err := CheckData(context.Background(), &configuration.Configuration{})
+	assert.ErrorIs(t, err, configuration.ErrMissingNetwork)
+	assert.Nil(t, checkResults)
+}
@@ -0,0 +1,280 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/neilotoole/errgroup"
+)
+
+// CheckpointFileName is the name of the checkpoint
+// file in the data directory.
+const CheckpointFileName = "checkpoint.json"
+
+// storedKey is the database key of the
+// checkpoint stored with each block.
+var storedKey = []byte("checkpoint")
+
+var (
+	// ErrCheckpointNotFound is returned when resuming
+	// without a checkpoint file.
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+	// ErrCheckpointMismatch is returned when the checkpoint
+	// block is not the block in storage.
+	ErrCheckpointMismatch = errors.New("checkpoint does not match storage")
+)
+
+// Checkpoint is the state of a check:data
+// run that can be resumed from.
+type Checkpoint struct {
+	// Index and Hash identify the last
+	// processed block.
+	Index int64  `json:"index"`
+	Hash  string `json:"hash"`
+
+	// ReconciledIndex is the last block index for
+	// which all balance changes were reconciled
+	// (-1 if reconciliation hasn't started).
+	ReconciledIndex int64 `json:"reconciled_index"`
+
+	// Timestamp is when the checkpoint was
+	// written (in milliseconds).
+	Timestamp int64 `json:"timestamp"`
+
+	// Elapsed is how long check:data has run (in
+	// milliseconds), including the runs it resumed.
+	Elapsed int64 `json:"elapsed,omitempty"`
+
+	// Coverage is the last estimate of
+	// reconciliation coverage.
+	Coverage float64 `json:"coverage,omitempty"`
+
+	// EndConditions are the end conditions met
+	// so far (only populated with require_all).
+	EndConditions []*results.EndConditionStatus `json:"end_conditions,omitempty"`
+
+	// Clean is set when check:data shut down
+	// gracefully after the last block was stored.
+	Clean bool `json:"clean,omitempty"`
+}
+
+// ResumeIndex is the index to resume syncing from. Blocks
+// after ReconciledIndex are synced again so that their
+// balance changes are reconciled.
+func (c *Checkpoint) ResumeIndex() int64 {
+	if c.ReconciledIndex >= 0 && c.ReconciledIndex < c.Index {
+		return c.ReconciledIndex + 1
+	}
+
+	return c.Index + 1
+}
+
+// WriteCheckpoint atomically writes a *Checkpoint to path (a
+// temporary file is written and renamed to path).
+func WriteCheckpoint(path string, checkpoint *Checkpoint) error {
+	bytes, err := json.MarshalIndent(checkpoint, "", " ")
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal checkpoint", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("%w: unable to create temporary checkpoint file", err)
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close() // nolint:errcheck,gosec
+		return fmt.Errorf("%w: unable to write temporary checkpoint file", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close() // nolint:errcheck,gosec
+		return fmt.Errorf("%w: unable to sync temporary checkpoint file", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: unable to close temporary checkpoint file", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("%w: unable to rename temporary checkpoint file", err)
+	}
+
+	return nil
+}
+
+// ReadCheckpoint returns the *Checkpoint stored at path.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	bytes, err := ioutil.ReadFile(path) // #nosec G304
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrCheckpointNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read checkpoint file %s", err, path)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(bytes, &checkpoint); err != nil {
+		return nil, fmt.Errorf("%w: unable to unmarshal checkpoint file %s", err, path)
+	}
+
+	return &checkpoint, nil
+}
+
+var _ modules.BlockWorker = (*CheckpointWorker)(nil)
+
+// CheckpointWorker stores a *Checkpoint in the database transaction
+// that adds (or removes) each block. Unlike the checkpoint
+// file (which is written periodically), the stored checkpoint
+// always identifies the last committed block, even if
+// check:data did not shut down cleanly.
+type CheckpointWorker struct {
+	db       database.Database
+	progress func() *Checkpoint
+}
+
+// NewCheckpointWorker returns a new *CheckpointWorker. progress returns the
+// fields of each *Checkpoint that are not derived from
+// the block (like ReconciledIndex and Elapsed).
+func NewCheckpointWorker(db database.Database, progress func() *Checkpoint) *CheckpointWorker {
+	return &CheckpointWorker{
+		db:       db,
+		progress: progress,
+	}
+}
+
+// store sets the *Checkpoint of block in transaction.
+func (w *CheckpointWorker) store(
+	ctx context.Context,
+	transaction database.Transaction,
+	block *types.BlockIdentifier,
+) error {
+	checkpoint := w.progress()
+	checkpoint.Index = block.Index
+	checkpoint.Hash = block.Hash
+	checkpoint.Timestamp = utils.Milliseconds()
+	checkpoint.Clean = false
+
+	bytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal checkpoint", err)
+	}
+
+	if err := transaction.Set(ctx, storedKey, bytes, true); err != nil {
+		return fmt.Errorf("%w: unable to store checkpoint", err)
+	}
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (w *CheckpointWorker) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	return nil, w.store(ctx, transaction, block.BlockIdentifier)
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// The checkpoint is moved to the parent block (or deleted when the genesis
+// block is removed).
+func (w *CheckpointWorker) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	if block.BlockIdentifier.Index == block.ParentBlockIdentifier.Index {
+		if err := transaction.Delete(ctx, storedKey); err != nil {
+			return nil, fmt.Errorf("%w: unable to delete checkpoint", err)
+		}
+
+		return nil, nil
+	}
+
+	return nil, w.store(ctx, transaction, block.ParentBlockIdentifier)
+}
+
+// Stored returns the *Checkpoint stored with
+// the last committed block.
+func (w *CheckpointWorker) Stored(ctx context.Context) (*Checkpoint, error) {
+	dbTx := w.db.ReadTransaction(ctx)
+	defer dbTx.Discard(ctx)
+
+	return get(ctx, dbTx)
+}
+
+// get returns the *Checkpoint stored in transaction.
+func get(ctx context.Context, transaction database.Transaction) (*Checkpoint, error) {
+	exists, bytes, err := transaction.Get(ctx, storedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get stored checkpoint", err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%w: no checkpoint stored", ErrCheckpointNotFound)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(bytes, &checkpoint); err != nil {
+		return nil, fmt.Errorf("%w: unable to unmarshal stored checkpoint", err)
+	}
+
+	return &checkpoint, nil
+}
+
+// MarkClean records that check:data shut down gracefully. The
+// mark is cleared when the next block is added or removed.
+func (w *CheckpointWorker) MarkClean(ctx context.Context) error {
+	dbTx := w.db.Transaction(ctx)
+	defer dbTx.Discard(ctx)
+
+	checkpoint, err := get(ctx, dbTx)
+	if errors.Is(err, ErrCheckpointNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	checkpoint.Clean = true
+	bytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal checkpoint", err)
+	}
+
+	if err := dbTx.Set(ctx, storedKey, bytes, true); err != nil {
+		return fmt.Errorf("%w: unable to store checkpoint", err)
+	}
+
+	return dbTx.Commit(ctx)
+}
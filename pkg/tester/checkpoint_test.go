@@ -0,0 +1,174 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeIndex(t *testing.T) {
+	var tests = map[string]struct {
+		checkpoint *Checkpoint
+		expected   int64
+	}{
+		"reconciliation not started": {
+			checkpoint: &Checkpoint{Index: 10, ReconciledIndex: -1},
+			expected:   11,
+		},
+		"reconciliation behind": {
+			checkpoint: &Checkpoint{Index: 10, ReconciledIndex: 7},
+			expected:   8,
+		},
+		"reconciliation caught up": {
+			checkpoint: &Checkpoint{Index: 10, ReconciledIndex: 10},
+			expected:   11,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.checkpoint.ResumeIndex())
+		})
+	}
+}
+
+func TestCheckpointWriteRead(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	checkpointPath := path.Join(dir, CheckpointFileName)
+	_, err = ReadCheckpoint(checkpointPath)
+	assert.True(t, errors.Is(err, ErrCheckpointNotFound))
+
+	checkpoint := &Checkpoint{Index: 10, Hash: "block 10", ReconciledIndex: 8, Timestamp: 1}
+	assert.NoError(t, WriteCheckpoint(checkpointPath, checkpoint))
+
+	read, err := ReadCheckpoint(checkpointPath)
+	assert.NoError(t, err)
+	assert.Equal(t, checkpoint, read)
+
+	// Overwriting a checkpoint leaves
+	// no temporary files behind.
+	checkpoint.Index = 11
+	assert.NoError(t, WriteCheckpoint(checkpointPath, checkpoint))
+	read, err = ReadCheckpoint(checkpointPath)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), read.Index)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	assert.NoError(t, ioutil.WriteFile(checkpointPath, []byte("{"), 0600))
+	_, err = ReadCheckpoint(checkpointPath)
+	assert.Error(t, err)
+}
+
+func checkpointTestBlock(index int64) *types.Block {
+	parentIndex := index - 1
+	if parentIndex < 0 {
+		parentIndex = 0
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: parentIndex,
+			Hash:  fmt.Sprintf("block %d", parentIndex),
+		},
+	}
+}
+
+func TestCheckpointWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	worker := NewCheckpointWorker(db, func() *Checkpoint {
+		return &Checkpoint{ReconciledIndex: 0, Elapsed: 5000, Coverage: 0.5}
+	})
+
+	_, err = worker.Stored(ctx)
+	assert.True(t, errors.Is(err, ErrCheckpointNotFound))
+
+	// Marking a clean shutdown without a
+	// checkpoint is a no-op.
+	assert.NoError(t, worker.MarkClean(ctx))
+
+	commit := func(block *types.Block, adding bool) {
+		dbTx := db.Transaction(ctx)
+		defer dbTx.Discard(ctx)
+
+		if adding {
+			_, err = worker.AddingBlock(ctx, nil, block, dbTx)
+		} else {
+			_, err = worker.RemovingBlock(ctx, nil, block, dbTx)
+		}
+		assert.NoError(t, err)
+		assert.NoError(t, dbTx.Commit(ctx))
+	}
+
+	for i := int64(0); i <= 2; i++ {
+		commit(checkpointTestBlock(i), true)
+	}
+
+	stored, err := worker.Stored(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stored.Index)
+	assert.Equal(t, "block 2", stored.Hash)
+	assert.Equal(t, int64(5000), stored.Elapsed)
+	assert.Equal(t, 0.5, stored.Coverage)
+	assert.False(t, stored.Clean)
+
+	assert.NoError(t, worker.MarkClean(ctx))
+	stored, err = worker.Stored(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stored.Index)
+	assert.True(t, stored.Clean)
+
+	// Removing a block moves the checkpoint to its
+	// parent (and clears the clean shutdown mark).
+	commit(checkpointTestBlock(2), false)
+	stored, err = worker.Stored(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stored.Index)
+	assert.Equal(t, "block 1", stored.Hash)
+	assert.False(t, stored.Clean)
+
+	commit(checkpointTestBlock(1), false)
+	commit(checkpointTestBlock(0), false)
+	_, err = worker.Stored(ctx)
+	assert.True(t, errors.Is(err, ErrCheckpointNotFound))
+}
@@ -0,0 +1,322 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"go.uber.org/zap/zapcore"
+)
+
+// Clients creates the *http.Client and *fetcher.Fetcher used
+// for requests to a Rosetta implementation. Each check:data run
+// started with CheckData has its own *Clients, so that concurrent
+// runs do not share any settings.
+type Clients struct {
+	config             *configuration.Configuration
+	userAgent          string
+	requestIDGenerator *httpclient.RequestIDGenerator
+	requestDurations   *metrics.RequestDurations
+}
+
+// NewClients returns *Clients for config that identify
+// requests with runID and record their durations in
+// requestDurations.
+func NewClients(
+	config *configuration.Configuration,
+	runID string,
+	requestDurations *metrics.RequestDurations,
+) *Clients {
+	// The request id generator is shared by all clients so
+	// that request ids are unique across the online and
+	// offline URLs.
+	var requestIDGenerator *httpclient.RequestIDGenerator
+	if config.RequestIDHeader != nil {
+		requestIDGenerator = httpclient.NewRequestIDGenerator(
+			config.RequestIDHeader.Name,
+			config.RequestIDHeader.Prefix,
+			runID,
+		)
+	}
+
+	return &Clients{
+		config:             config,
+		userAgent:          httpclient.ResolveUserAgent(config.UserAgent, runID),
+		requestIDGenerator: requestIDGenerator,
+		requestDurations:   requestDurations,
+	}
+}
+
+// UserAgent returns the resolved User-Agent of all requests.
+func (c *Clients) UserAgent() string {
+	return c.userAgent
+}
+
+// RequestIDGenerator returns the *httpclient.RequestIDGenerator
+// of all requests (or nil if no request id header is configured).
+func (c *Clients) RequestIDGenerator() *httpclient.RequestIDGenerator {
+	return c.requestIDGenerator
+}
+
+// RequestDurations returns the *metrics.RequestDurations
+// that the duration of all requests is recorded in.
+func (c *Clients) RequestDurations() *metrics.RequestDurations {
+	return c.requestDurations
+}
+
+// newHTTPClient returns the *http.Client used for all
+// requests to a Rosetta implementation.
+func (c *Clients) newHTTPClient(
+	tlsConfiguration *configuration.TLSConfiguration,
+	httpTimeout uint64,
+	maxConnections int,
+	extraOpts ...httpclient.Option,
+) (*http.Client, error) {
+	var tlsConfig *tls.Config
+	if tlsConfiguration != nil {
+		var err error
+		tlsConfig, err = httpclient.LoadTLSConfig(
+			tlsConfiguration.CAFile,
+			tlsConfiguration.CertFile,
+			tlsConfiguration.KeyFile,
+			tlsConfiguration.InsecureSkipVerify,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load tls configuration", err)
+		}
+	}
+
+	proxyURL, err := httpclient.ParseProxyURL(c.config.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to parse proxy url", err)
+	}
+
+	opts := []httpclient.Option{
+		httpclient.WithTLSConfig(tlsConfig),
+		httpclient.WithProxyURL(proxyURL),
+		httpclient.WithHeaders(c.config.Headers),
+		httpclient.WithUserAgent(c.userAgent),
+		httpclient.WithRequestDurations(c.requestDurations),
+	}
+	if c.requestIDGenerator != nil {
+		opts = append(opts, httpclient.WithRequestIDGenerator(c.requestIDGenerator))
+	}
+	opts = append(opts, extraOpts...)
+
+	return httpclient.New(
+		time.Duration(httpTimeout)*time.Second,
+		maxConnections,
+		opts...,
+	), nil
+}
+
+// onlineHTTPClient returns the *http.Client used for
+// requests to the online URL with the given timeout
+// (in seconds).
+func (c *Clients) onlineHTTPClient(
+	httpTimeout uint64,
+	extraOpts ...httpclient.Option,
+) (*http.Client, error) {
+	return c.newHTTPClient(c.config.TLS, httpTimeout, c.config.MaxOnlineConnections, extraOpts...)
+}
+
+// NewBackoff returns the *httpclient.Backoff described
+// by a populated retry backoff configuration.
+func (c *Clients) NewBackoff(
+	config *configuration.RetryBackoffConfiguration,
+	maxElapsedTime time.Duration,
+) *httpclient.Backoff {
+	return &httpclient.Backoff{
+		InitialInterval: time.Duration(config.InitialIntervalMs) * time.Millisecond,
+		MaxInterval:     time.Duration(config.MaxIntervalMs) * time.Millisecond,
+		Multiplier:      config.Multiplier,
+		Jitter:          *config.Jitter,
+		MaxRetries:      c.config.MaxRetries,
+		MaxElapsedTime:  maxElapsedTime,
+	}
+}
+
+// retryOptions returns the options used to retry failed requests.
+// If a retry backoff is configured, requests are retried by the
+// *http.Client (the fetcher does not expose its backoff settings),
+// so retries in the fetcher are disabled.
+func (c *Clients) retryOptions(
+	maxElapsedTime time.Duration,
+	forceRetry bool,
+) ([]fetcher.Option, []httpclient.Option) {
+	if c.config.RetryBackoff == nil {
+		fetcherOpts := []fetcher.Option{fetcher.WithMaxRetries(c.config.MaxRetries)}
+		if forceRetry {
+			fetcherOpts = append(fetcherOpts, fetcher.WithForceRetry())
+		}
+
+		return fetcherOpts, nil
+	}
+
+	backoff := c.NewBackoff(c.config.RetryBackoff, maxElapsedTime)
+	clientOpts := []httpclient.Option{httpclient.WithRetryBackoff(backoff, forceRetry)}
+	if !c.config.LogLevel.Enabled(zapcore.DebugLevel) {
+		clientOpts = append(clientOpts, httpclient.WithoutRetryLogs())
+	}
+
+	return []fetcher.Option{fetcher.WithMaxRetries(0)}, clientOpts
+}
+
+// NewOnlineFetcher returns a *fetcher.Fetcher for the online URL
+// that uses the given timeout (in seconds).
+func (c *Clients) NewOnlineFetcher(
+	httpTimeout uint64,
+	extraOpts ...httpclient.Option,
+) (*fetcher.Fetcher, error) {
+	retryElapsedTime := time.Duration(c.config.RetryElapsedTime) * time.Second
+	retryFetcherOpts, retryClientOpts := c.retryOptions(retryElapsedTime, c.config.ForceRetry)
+	clientOpts := append(retryClientOpts, extraOpts...)
+	if c.config.MaxBlockSizeBytes > 0 {
+		clientOpts = append(
+			clientOpts,
+			httpclient.WithMaxResponseSize("/block", c.config.MaxBlockSizeBytes),
+		)
+	}
+
+	httpClient, err := c.onlineHTTPClient(httpTimeout, clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcherOpts := []fetcher.Option{
+		fetcher.WithMaxConnections(c.config.MaxOnlineConnections),
+		fetcher.WithRetryElapsedTime(retryElapsedTime),
+		fetcher.WithTimeout(time.Duration(httpTimeout) * time.Second),
+		httpclient.FetcherOption(c.config.OnlineURL, httpClient),
+	}
+	fetcherOpts = append(fetcherOpts, retryFetcherOpts...)
+
+	return fetcher.New(
+		c.config.OnlineURL,
+		fetcherOpts...,
+	), nil
+}
+
+// NewOfflineFetcher returns a *fetcher.Fetcher for the offline URL
+// that uses the same *asserter.Asserter as the online fetcher.
+func (c *Clients) NewOfflineFetcher(asserter *asserter.Asserter) (*fetcher.Fetcher, error) {
+	retryFetcherOpts, retryClientOpts := c.retryOptions(
+		fetcher.DefaultElapsedTime,
+		c.config.Construction.ForceRetry,
+	)
+	if c.config.Construction.RandomSeed != nil {
+		retryClientOpts = append(
+			retryClientOpts,
+			httpclient.WithRandomSeed(*c.config.Construction.RandomSeed),
+		)
+	}
+
+	httpClient, err := c.newHTTPClient(
+		c.config.Construction.TLS,
+		c.config.Construction.HTTPTimeout,
+		c.config.Construction.MaxOfflineConnections,
+		retryClientOpts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcherOpts := []fetcher.Option{
+		fetcher.WithMaxConnections(c.config.Construction.MaxOfflineConnections),
+		fetcher.WithAsserter(asserter),
+		fetcher.WithTimeout(time.Duration(c.config.Construction.HTTPTimeout) * time.Second),
+		httpclient.FetcherOption(c.config.Construction.OfflineURL, httpClient),
+	}
+	fetcherOpts = append(fetcherOpts, retryFetcherOpts...)
+
+	return fetcher.New(
+		c.config.Construction.OfflineURL,
+		fetcherOpts...,
+	), nil
+}
+
+// NewSecondaryFetcher returns a *fetcher.Fetcher for a secondary
+// online URL, like comparison_url or data.archive_url, (or nil if
+// url is not populated) that uses the same *asserter.Asserter and
+// TLS configuration as the online fetcher.
+func (c *Clients) NewSecondaryFetcher(
+	url string,
+	asserter *asserter.Asserter,
+) (*fetcher.Fetcher, error) {
+	if len(url) == 0 {
+		return nil, nil
+	}
+
+	retryElapsedTime := time.Duration(c.config.RetryElapsedTime) * time.Second
+	retryFetcherOpts, retryClientOpts := c.retryOptions(retryElapsedTime, c.config.ForceRetry)
+	httpClient, err := c.newHTTPClient(
+		c.config.TLS,
+		c.config.Data.HTTPTimeout,
+		c.config.MaxOnlineConnections,
+		retryClientOpts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcherOpts := []fetcher.Option{
+		fetcher.WithMaxConnections(c.config.MaxOnlineConnections),
+		fetcher.WithAsserter(asserter),
+		fetcher.WithRetryElapsedTime(retryElapsedTime),
+		fetcher.WithTimeout(time.Duration(c.config.Data.HTTPTimeout) * time.Second),
+		httpclient.FetcherOption(url, httpClient),
+	}
+	fetcherOpts = append(fetcherOpts, retryFetcherOpts...)
+
+	return fetcher.New(
+		url,
+		fetcherOpts...,
+	), nil
+}
+
+// InitializeSkewMonitor returns a *processor.SkewMonitor for the
+// online URL after performing an initial clock skew estimate.
+func (c *Clients) InitializeSkewMonitor(ctx context.Context) (*processor.SkewMonitor, error) {
+	httpClient, err := c.onlineHTTPClient(c.config.HTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	skewMonitor := processor.NewSkewMonitor(
+		c.config.OnlineURL,
+		c.config.Network,
+		httpClient,
+		c.config.TipDelayClockSkewCorrection,
+		time.Duration(c.config.ClockSkewWarningThreshold)*time.Second,
+	)
+
+	if _, err := skewMonitor.Estimate(ctx); err != nil {
+		log.Printf("%s: unable to estimate clock skew\n", err.Error())
+	}
+
+	return skewMonitor, nil
+}
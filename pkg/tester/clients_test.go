@@ -0,0 +1,156 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync/atomic"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetcherUserAgent(t *testing.T) {
+	var tests = map[string]struct {
+		template string
+
+		expected string
+	}{
+		"default": {
+			expected: "rosetta-cli/" + httpclient.Version,
+		},
+		"configured": {
+			template: "validator/{run_id}",
+			expected: "validator/run",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests int32
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&requests, 1)
+					assert.Equal(t, test.expected, r.Header.Get("User-Agent"))
+
+					w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+					w.WriteHeader(http.StatusOK)
+					assert.NoError(t, json.NewEncoder(w).Encode(&types.NetworkListResponse{
+						NetworkIdentifiers: []*types.NetworkIdentifier{
+							{Blockchain: "bitcoin", Network: "mainnet"},
+						},
+					}))
+				}),
+			)
+			defer ts.Close()
+
+			config := configuration.DefaultConfiguration()
+			config.OnlineURL = ts.URL
+			config.UserAgent = test.template
+			config.Construction = &configuration.ConstructionConfiguration{
+				OfflineURL:            ts.URL,
+				MaxOfflineConnections: 1,
+				HTTPTimeout:           configuration.DefaultTimeout,
+			}
+			clients := NewClients(config, "run", metrics.NewRequestDurations())
+
+			onlineFetcher, err := clients.NewOnlineFetcher(config.HTTPTimeout)
+			assert.NoError(t, err)
+			offlineFetcher, err := clients.NewOfflineFetcher(nil)
+			assert.NoError(t, err)
+
+			ctx := context.Background()
+			for _, f := range []*fetcher.Fetcher{onlineFetcher, offlineFetcher} {
+				_, fetchErr := f.NetworkList(ctx, nil)
+				assert.Nil(t, fetchErr)
+			}
+
+			assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestNewSecondaryFetcherTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/network/list", r.URL.Path)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			assert.NoError(t, json.NewEncoder(w).Encode(&types.NetworkListResponse{
+				NetworkIdentifiers: []*types.NetworkIdentifier{network},
+			}))
+		}),
+	)
+	defer ts.Close()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	// The certificate of the test server is only trusted
+	// if the TLS configuration is used.
+	caFile := path.Join(dir, "ca.pem")
+	assert.NoError(t, ioutil.WriteFile(
+		caFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}),
+		0600,
+	))
+
+	var tests = map[string]struct {
+		url func(config *configuration.Configuration) string
+	}{
+		"comparison_url": {
+			url: func(config *configuration.Configuration) string {
+				config.ComparisonURL = ts.URL
+				return config.ComparisonURL
+			},
+		},
+		"archive_url": {
+			url: func(config *configuration.Configuration) string {
+				config.Data.ArchiveURL = ts.URL
+				return config.Data.ArchiveURL
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := configuration.DefaultConfiguration()
+			config.TLS = &configuration.TLSConfiguration{CAFile: caFile}
+			clients := NewClients(config, "run", metrics.NewRequestDurations())
+
+			f, err := clients.NewSecondaryFetcher(test.url(config), nil)
+			assert.NoError(t, err)
+
+			networkList, fetchErr := f.NetworkList(context.Background(), nil)
+			assert.Nil(t, fetchErr)
+			assert.Equal(t, []*types.NetworkIdentifier{network}, networkList.NetworkIdentifiers)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/neilotoole/errgroup"
+)
+
+const (
+	// OwnerMismatch is the Reason of a CoinDiscrepancy where a coin
+	// is spent by an account that does not own it.
+	OwnerMismatch = "owner mismatch"
+
+	// DuplicateCoin is the Reason of a CoinDiscrepancy where a coin
+	// is created that is already tracked.
+	DuplicateCoin = "duplicate coin"
+
+	missing = "<missing>"
+)
+
+// CoinGetter returns tracked coins.
+type CoinGetter interface {
+	GetCoinTransactional(
+		ctx context.Context,
+		dbTx database.Transaction,
+		coinIdentifier *types.CoinIdentifier,
+	) (*types.Coin, *types.AccountIdentifier, error)
+}
+
+// OperationChecker determines if an operation was successful.
+type OperationChecker interface {
+	OperationSuccessful(operation *types.Operation) (bool, error)
+}
+
+var _ modules.BlockWorker = (*CoinTracker)(nil)
+
+// CoinTracker checks each coin spent in a block added to storage
+// against the tracked coin (owner and amount) and each coin
+// created against the coins already tracked. It must run
+// before *modules.CoinStorage updates the tracked coins.
+//
+// Coins that are not tracked when spent (i.e. coins created
+// before syncing started) are not checked.
+type CoinTracker struct {
+	coins      CoinGetter
+	operations OperationChecker
+
+	lock        sync.Mutex
+	checked     int64
+	discrepancy *results.CoinDiscrepancy
+}
+
+// NewCoinTracker returns a new *CoinTracker.
+func NewCoinTracker(coins CoinGetter, operations OperationChecker) *CoinTracker {
+	return &CoinTracker{
+		coins:      coins,
+		operations: operations,
+	}
+}
+
+// checkOperation returns a *CoinDiscrepancy if operation is
+// inconsistent with the tracked coins (or nil if it is not).
+func (t *CoinTracker) checkOperation(
+	ctx context.Context,
+	dbTx database.Transaction,
+	block *types.BlockIdentifier,
+	operation *types.Operation,
+) (*results.CoinDiscrepancy, error) {
+	coinIdentifier := operation.CoinChange.CoinIdentifier
+	coin, owner, err := t.coins.GetCoinTransactional(ctx, dbTx, coinIdentifier)
+	switch {
+	case errors.Is(err, storageErrs.ErrCoinNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("%w: unable to get coin %s", err, coinIdentifier.Identifier)
+	}
+
+	discrepancy := &results.CoinDiscrepancy{
+		BlockIdentifier: block,
+		CoinIdentifier:  coinIdentifier,
+		Account:         operation.Account,
+	}
+
+	if operation.CoinChange.CoinAction == types.CoinCreated {
+		discrepancy.Reason = DuplicateCoin
+		discrepancy.Expected = missing
+		discrepancy.Actual = fmt.Sprintf("%s owned by %s", coin.Amount.Value, types.PrintStruct(owner))
+		return discrepancy, nil
+	}
+
+	if types.Hash(owner) != types.Hash(operation.Account) {
+		discrepancy.Reason = OwnerMismatch
+		discrepancy.Expected = types.PrintStruct(owner)
+		discrepancy.Actual = types.PrintStruct(operation.Account)
+		return discrepancy, nil
+	}
+
+	expected, err := types.NegateValue(coin.Amount.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid amount of coin %s", err, coinIdentifier.Identifier)
+	}
+
+	if expected != operation.Amount.Value ||
+		types.Hash(coin.Amount.Currency) != types.Hash(operation.Amount.Currency) {
+		discrepancy.Reason = results.AmountMismatch
+		discrepancy.Expected = types.PrintStruct(&types.Amount{
+			Value:    expected,
+			Currency: coin.Amount.Currency,
+		})
+		discrepancy.Actual = types.PrintStruct(operation.Amount)
+		return discrepancy, nil
+	}
+
+	return nil, nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (t *CoinTracker) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	for _, tx := range block.Transactions {
+		for _, operation := range tx.Operations {
+			if operation.CoinChange == nil || operation.Amount == nil {
+				continue
+			}
+
+			success, err := t.operations.OperationSuccessful(operation)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to parse operation status", err)
+			}
+
+			if !success {
+				continue
+			}
+
+			discrepancy, err := t.checkOperation(
+				ctx,
+				transaction,
+				block.BlockIdentifier,
+				operation,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			t.lock.Lock()
+			t.checked++
+			if discrepancy != nil && t.discrepancy == nil {
+				t.discrepancy = discrepancy
+			}
+			t.lock.Unlock()
+
+			if discrepancy != nil {
+				return nil, fmt.Errorf("%w: %s", results.ErrCoinDiscrepancy, discrepancy)
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+func (t *CoinTracker) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	return nil, nil
+}
+
+// CoinTrackingResults returns the *CoinTrackingResults of all coin operations checked.
+func (t *CoinTracker) Results() *results.CoinTrackingResults {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return &results.CoinTrackingResults{
+		CoinsChecked: t.checked,
+		Discrepancy:  t.discrepancy,
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	coinsBtc = &types.Currency{Symbol: "BTC", Decimals: 8}
+
+	coinsBlock = &types.BlockIdentifier{Index: 10, Hash: "block 10"}
+)
+
+type trackedCoin struct {
+	coin  *types.Coin
+	owner *types.AccountIdentifier
+}
+
+type mockCoinGetter struct {
+	coins map[string]*trackedCoin
+}
+
+func (m *mockCoinGetter) GetCoinTransactional(
+	ctx context.Context,
+	dbTx database.Transaction,
+	coinIdentifier *types.CoinIdentifier,
+) (*types.Coin, *types.AccountIdentifier, error) {
+	tracked, ok := m.coins[coinIdentifier.Identifier]
+	if !ok {
+		return nil, nil, storageErrs.ErrCoinNotFound
+	}
+
+	return tracked.coin, tracked.owner, nil
+}
+
+type mockOperationChecker struct{}
+
+func (m *mockOperationChecker) OperationSuccessful(operation *types.Operation) (bool, error) {
+	return *operation.Status == "SUCCESS", nil
+}
+
+func coinOperation(
+	address string,
+	value string,
+	coin string,
+	action types.CoinAction,
+) *types.Operation {
+	return &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{Index: 0},
+		Type:                "utxo",
+		Status:              types.String("SUCCESS"),
+		Account:             &types.AccountIdentifier{Address: address},
+		Amount:              &types.Amount{Value: value, Currency: coinsBtc},
+		CoinChange: &types.CoinChange{
+			CoinIdentifier: &types.CoinIdentifier{Identifier: coin},
+			CoinAction:     action,
+		},
+	}
+}
+
+func TestCoinTracker(t *testing.T) {
+	getter := &mockCoinGetter{
+		coins: map[string]*trackedCoin{
+			"coin1": {
+				coin: &types.Coin{
+					CoinIdentifier: &types.CoinIdentifier{Identifier: "coin1"},
+					Amount:         &types.Amount{Value: "100", Currency: coinsBtc},
+				},
+				owner: &types.AccountIdentifier{Address: "addr1"},
+			},
+		},
+	}
+
+	var tests = map[string]struct {
+		operation *types.Operation
+
+		expectedChecked     int64
+		expectedDiscrepancy *results.CoinDiscrepancy
+	}{
+		"spend": {
+			operation:       coinOperation("addr1", "-100", "coin1", types.CoinSpent),
+			expectedChecked: 1,
+		},
+		"untracked coin": {
+			operation:       coinOperation("addr2", "-5", "coin2", types.CoinSpent),
+			expectedChecked: 1,
+		},
+		"create": {
+			operation:       coinOperation("addr2", "5", "coin2", types.CoinCreated),
+			expectedChecked: 1,
+		},
+		"failed operation": {
+			operation: func() *types.Operation {
+				op := coinOperation("addr2", "-100", "coin1", types.CoinSpent)
+				op.Status = types.String("FAILURE")
+				return op
+			}(),
+		},
+		"owner mismatch": {
+			operation:       coinOperation("addr2", "-100", "coin1", types.CoinSpent),
+			expectedChecked: 1,
+			expectedDiscrepancy: &results.CoinDiscrepancy{
+				BlockIdentifier: coinsBlock,
+				CoinIdentifier:  &types.CoinIdentifier{Identifier: "coin1"},
+				Account:         &types.AccountIdentifier{Address: "addr2"},
+				Reason:          OwnerMismatch,
+				Expected:        `{"address":"addr1"}`,
+				Actual:          `{"address":"addr2"}`,
+			},
+		},
+		"amount mismatch": {
+			operation:       coinOperation("addr1", "-90", "coin1", types.CoinSpent),
+			expectedChecked: 1,
+			expectedDiscrepancy: &results.CoinDiscrepancy{
+				BlockIdentifier: coinsBlock,
+				CoinIdentifier:  &types.CoinIdentifier{Identifier: "coin1"},
+				Account:         &types.AccountIdentifier{Address: "addr1"},
+				Reason:          results.AmountMismatch,
+				Expected:        `{"value":"-100","currency":{"symbol":"BTC","decimals":8}}`,
+				Actual:          `{"value":"-90","currency":{"symbol":"BTC","decimals":8}}`,
+			},
+		},
+		"duplicate coin": {
+			operation:       coinOperation("addr1", "100", "coin1", types.CoinCreated),
+			expectedChecked: 1,
+			expectedDiscrepancy: &results.CoinDiscrepancy{
+				BlockIdentifier: coinsBlock,
+				CoinIdentifier:  &types.CoinIdentifier{Identifier: "coin1"},
+				Account:         &types.AccountIdentifier{Address: "addr1"},
+				Reason:          DuplicateCoin,
+				Expected:        "<missing>",
+				Actual:          `100 owned by {"address":"addr1"}`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := NewCoinTracker(getter, &mockOperationChecker{})
+			_, err := tracker.AddingBlock(
+				context.Background(),
+				nil,
+				&types.Block{
+					BlockIdentifier: coinsBlock,
+					Transactions: []*types.Transaction{
+						{
+							TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx1"},
+							Operations:            []*types.Operation{test.operation},
+						},
+					},
+				},
+				nil,
+			)
+
+			coinResults := tracker.Results()
+			assert.Equal(t, test.expectedChecked, coinResults.CoinsChecked)
+			assert.Equal(t, test.expectedDiscrepancy, coinResults.Discrepancy)
+
+			var b bytes.Buffer
+			coinResults.Fprint(&b)
+			if test.expectedDiscrepancy == nil {
+				assert.NoError(t, err)
+				assert.Contains(t, b.String(), "none")
+				return
+			}
+
+			assert.True(t, errors.Is(err, results.ErrCoinDiscrepancy))
+			assert.Contains(t, err.Error(), "coin1")
+			assert.Contains(t, b.String(), "[COIN TRACKING] "+test.expectedDiscrepancy.Reason)
+			assert.Contains(t, b.String(), test.expectedDiscrepancy.Expected)
+			assert.Contains(t, b.String(), test.expectedDiscrepancy.Actual)
+		})
+	}
+}
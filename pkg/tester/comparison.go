@@ -0,0 +1,228 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/neilotoole/errgroup"
+)
+
+var (
+	// ErrBlockDivergence is returned when a block served by the
+	// online implementation differs from the same block served
+	// by the comparison implementation.
+	ErrBlockDivergence = errors.New("block differs from comparison implementation")
+)
+
+// ComparisonBlockFetcher fetches blocks from the comparison implementation.
+type ComparisonBlockFetcher interface {
+	BlockRetry(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		blockIdentifier *types.PartialBlockIdentifier,
+	) (*types.Block, *fetcher.Error)
+}
+
+// BalanceChangeParser computes the balance changes of a block.
+type BalanceChangeParser interface {
+	BalanceChanges(
+		ctx context.Context,
+		block *types.Block,
+		blockRemoved bool,
+	) ([]*parser.BalanceChange, error)
+}
+
+// body is the part of a block that is compared. Block and
+// transaction metadata (and the block timestamp) are not
+// compared because they often legitimately differ between
+// implementations.
+type body struct {
+	ParentBlockIdentifier *types.BlockIdentifier  `json:"parent_block_identifier"`
+	Transactions          []*transactionBody      `json:"transactions"`
+	BalanceChanges        []*parser.BalanceChange `json:"balance_changes"`
+}
+
+type transactionBody struct {
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	Operations            []*types.Operation           `json:"operations"`
+}
+
+var _ modules.BlockWorker = (*Comparer)(nil)
+
+// Comparer fetches each block added to storage from the
+// comparison implementation (by block identifier) and
+// returns ErrBlockDivergence if its operations or balance
+// changes differ from the block served by the online
+// implementation.
+type Comparer struct {
+	network *types.NetworkIdentifier
+	fetcher ComparisonBlockFetcher
+	parser  BalanceChangeParser
+
+	lock       sync.Mutex
+	compared   int64
+	divergence *results.ComparisonDivergence
+}
+
+// NewComparer returns a new *Comparer.
+func NewComparer(
+	network *types.NetworkIdentifier,
+	fetcher ComparisonBlockFetcher,
+	parser BalanceChangeParser,
+) *Comparer {
+	return &Comparer{
+		network: network,
+		fetcher: fetcher,
+		parser:  parser,
+	}
+}
+
+// blockBody returns the compared body of block.
+func (c *Comparer) blockBody(ctx context.Context, block *types.Block) (*body, error) {
+	balanceChanges, err := c.parser.BalanceChanges(ctx, block, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to compute balance changes", err)
+	}
+
+	sort.Slice(balanceChanges, func(i, j int) bool {
+		return types.Hash(balanceChanges[i]) < types.Hash(balanceChanges[j])
+	})
+
+	transactions := make([]*transactionBody, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		transactions[i] = &transactionBody{
+			TransactionIdentifier: tx.TransactionIdentifier,
+			Operations:            tx.Operations,
+		}
+	}
+
+	return &body{
+		ParentBlockIdentifier: block.ParentBlockIdentifier,
+		Transactions:          transactions,
+		BalanceChanges:        balanceChanges,
+	}, nil
+}
+
+// Compare returns the fields of block (served by the online
+// implementation) that differ from the same block served by
+// the comparison implementation.
+func (c *Comparer) Compare(ctx context.Context, block *types.Block) ([]*results.ComparisonFieldDiff, error) {
+	comparisonBlock, fetchErr := c.fetcher.BlockRetry(
+		ctx,
+		c.network,
+		types.ConstructPartialBlockIdentifier(block.BlockIdentifier),
+	)
+	if fetchErr != nil {
+		return nil, fmt.Errorf(
+			"%w: unable to fetch block %d from comparison implementation",
+			fetchErr.Err,
+			block.BlockIdentifier.Index,
+		)
+	}
+
+	onlineBody, err := c.blockBody(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+
+	comparisonBody, err := c.blockBody(ctx, comparisonBlock)
+	if err != nil {
+		return nil, fmt.Errorf("%w: comparison block %d", err, block.BlockIdentifier.Index)
+	}
+
+	diffs, err := DiffFields(onlineBody, comparisonBody)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to compare block %d", err, block.BlockIdentifier.Index)
+	}
+
+	fieldDiffs := make([]*results.ComparisonFieldDiff, len(diffs))
+	for i, diff := range diffs {
+		fieldDiffs[i] = &results.ComparisonFieldDiff{
+			Path:       diff.Path,
+			Online:     diff.Stored,
+			Comparison: diff.Fetched,
+		}
+	}
+
+	return fieldDiffs, nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (c *Comparer) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	diffs, err := c.Compare(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.compared++
+	if len(diffs) == 0 {
+		return nil, nil
+	}
+
+	if c.divergence == nil {
+		c.divergence = &results.ComparisonDivergence{
+			BlockIdentifier: block.BlockIdentifier,
+			Diff:            diffs,
+		}
+	}
+
+	return nil, fmt.Errorf(
+		"%w: block %s (%d fields differ)",
+		ErrBlockDivergence,
+		types.PrintStruct(block.BlockIdentifier),
+		len(diffs),
+	)
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+func (c *Comparer) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	return nil, nil
+}
+
+// ComparisonResults returns the *ComparisonResults of all blocks compared.
+func (c *Comparer) Results() *results.ComparisonResults {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return &results.ComparisonResults{
+		BlocksCompared: c.compared,
+		Divergence:     c.divergence,
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	comparisonNetwork = &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+)
+
+type comparisonMockFetcher struct {
+	blocks map[int64]*types.Block
+}
+
+func (m *comparisonMockFetcher) BlockRetry(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, *fetcher.Error) {
+	block, ok := m.blocks[*blockIdentifier.Index]
+	if !ok || block.BlockIdentifier.Hash != *blockIdentifier.Hash {
+		return nil, &fetcher.Error{Err: fetcher.ErrExhaustedRetries}
+	}
+
+	return block, nil
+}
+
+func comparisonTestBlock(index int64, value string, metadata map[string]interface{}) *types.Block {
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: index - 1,
+			Hash:  fmt.Sprintf("block %d", index-1),
+		},
+		Timestamp: 1000 + index,
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: fmt.Sprintf("tx %d", index),
+				},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "transfer",
+						Status:              types.String("SUCCESS"),
+						Account:             &types.AccountIdentifier{Address: "addr1"},
+						Amount: &types.Amount{
+							Value:    value,
+							Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+						},
+					},
+				},
+				Metadata: metadata,
+			},
+		},
+	}
+}
+
+// fetchers returns mock fetchers for the online and comparison
+// implementations that serve blocks 1 to 3. The comparison
+// implementation serves divergent in place of the online block
+// with the same index.
+func fetchers(divergent *types.Block) (*comparisonMockFetcher, *comparisonMockFetcher) {
+	online := &comparisonMockFetcher{blocks: map[int64]*types.Block{}}
+	comparison := &comparisonMockFetcher{blocks: map[int64]*types.Block{}}
+	for i := int64(1); i <= 3; i++ {
+		online.blocks[i] = comparisonTestBlock(i, "100", nil)
+		comparison.blocks[i] = comparisonTestBlock(i, "100", nil)
+	}
+
+	if divergent != nil {
+		comparison.blocks[divergent.BlockIdentifier.Index] = divergent
+	}
+
+	return online, comparison
+}
+
+func TestComparer(t *testing.T) {
+	var tests = map[string]struct {
+		divergent *types.Block
+
+		expectedCompared   int64
+		expectedDivergence *results.ComparisonDivergence
+		expectedErr        error
+	}{
+		"no divergence": {
+			expectedCompared: 3,
+		},
+		"metadata divergence": {
+			divergent:        comparisonTestBlock(2, "100", map[string]interface{}{"size": 10}),
+			expectedCompared: 3,
+		},
+		"operation divergence": {
+			divergent:        comparisonTestBlock(2, "200", nil),
+			expectedCompared: 2,
+			expectedDivergence: &results.ComparisonDivergence{
+				BlockIdentifier: comparisonTestBlock(2, "100", nil).BlockIdentifier,
+				Diff: []*results.ComparisonFieldDiff{
+					{
+						Path:       "balance_changes[0].difference",
+						Online:     `"100"`,
+						Comparison: `"200"`,
+					},
+					{
+						Path:       "transactions[0].operations[0].amount.value",
+						Online:     `"100"`,
+						Comparison: `"200"`,
+					},
+				},
+			},
+			expectedErr: ErrBlockDivergence,
+		},
+		"missing operation": {
+			divergent: func() *types.Block {
+				block := comparisonTestBlock(3, "100", nil)
+				block.Transactions[0].Operations = []*types.Operation{}
+				return block
+			}(),
+			expectedCompared: 3,
+			expectedDivergence: &results.ComparisonDivergence{
+				BlockIdentifier: comparisonTestBlock(3, "100", nil).BlockIdentifier,
+				Diff: []*results.ComparisonFieldDiff{
+					{
+						Path: "balance_changes[0]",
+						Online: `{"account_identifier":{"address":"addr1"},` +
+							`"block_identifier":{"hash":"block 3","index":3},` +
+							`"currency":{"decimals":8,"symbol":"BTC"},"difference":"100"}`,
+						Comparison: "<missing>",
+					},
+					{
+						Path: "transactions[0].operations[0]",
+						Online: `{"account":{"address":"addr1"},"amount":{"currency":` +
+							`{"decimals":8,"symbol":"BTC"},"value":"100"},` +
+							`"operation_identifier":{"index":0},"status":"SUCCESS","type":"transfer"}`,
+						Comparison: "<missing>",
+					},
+				},
+			},
+			expectedErr: ErrBlockDivergence,
+		},
+	}
+
+	blockAsserter, err := asserter.NewClientWithOptions(
+		comparisonNetwork,
+		&types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		[]string{"transfer"},
+		[]*types.OperationStatus{{Status: "SUCCESS", Successful: true}},
+		[]*types.Error{},
+		nil,
+		&asserter.Validations{Enabled: false},
+	)
+	assert.NoError(t, err)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			online, comparisonFetcher := fetchers(test.divergent)
+			comparer := NewComparer(comparisonNetwork, comparisonFetcher, parser.New(blockAsserter, nil, nil))
+
+			for i := int64(1); i <= 3; i++ {
+				block, fetchErr := online.BlockRetry(
+					ctx,
+					comparisonNetwork,
+					types.ConstructPartialBlockIdentifier(comparisonTestBlock(i, "100", nil).BlockIdentifier),
+				)
+				assert.Nil(t, fetchErr)
+
+				_, err = comparer.AddingBlock(ctx, nil, block, nil)
+				if err != nil {
+					break
+				}
+			}
+
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			results := comparer.Results()
+			assert.Equal(t, test.expectedCompared, results.BlocksCompared)
+			assert.Equal(t, test.expectedDivergence, results.Divergence)
+
+			var b bytes.Buffer
+			results.Fprint(&b)
+			if test.expectedDivergence != nil {
+				assert.Contains(t, b.String(), "differs from comparison implementation")
+			} else {
+				assert.Contains(t, b.String(), "none")
+			}
+		})
+	}
+}
+
+func TestComparerFetchFailure(t *testing.T) {
+	comparer := NewComparer(comparisonNetwork, &comparisonMockFetcher{}, nil)
+	_, err := comparer.AddingBlock(context.Background(), nil, comparisonTestBlock(1, "100", nil), nil)
+	assert.True(t, errors.Is(err, fetcher.ErrExhaustedRetries))
+	assert.Equal(t, int64(0), comparer.Results().BlocksCompared)
+}
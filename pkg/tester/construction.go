@@ -21,10 +21,12 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"path"
 	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
 	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
 	"github.com/coinbase/rosetta-cli/pkg/processor"
 	"github.com/coinbase/rosetta-cli/pkg/results"
 
@@ -39,6 +41,7 @@ import (
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/coinbase/rosetta-sdk-go/utils"
 	"github.com/fatih/color"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -48,10 +51,11 @@ const (
 	constructionCmdName = "check-construction"
 
 	endConditionsCheckInterval = 10 * time.Second
-	tipWaitInterval            = 10 * time.Second
+	faucetCheckInterval        = 10 * time.Second
 )
 
 var _ http.Handler = (*ConstructionTester)(nil)
+var _ metrics.Collector = (*ConstructionTester)(nil)
 
 // ConstructionTester coordinates the `check:construction` test.
 type ConstructionTester struct {
@@ -61,23 +65,42 @@ type ConstructionTester struct {
 	syncer           *statefulsyncer.StatefulSyncer
 	logger           *logger.Logger
 	onlineFetcher    *fetcher.Fetcher
+	skewMonitor      *processor.SkewMonitor
 	broadcastStorage *modules.BroadcastStorage
 	blockStorage     *modules.BlockStorage
+	keyStorage       *modules.KeyStorage
+	balanceStorage   *modules.BalanceStorage
 	jobStorage       *modules.JobStorage
 	counterStorage   *modules.CounterStorage
+	lineageStorage   *processor.LineageStorage
+	confirmations    *processor.ConfirmationTracker
+	endpoints        *results.EndpointTracker
 	coordinator      *coordinator.Coordinator
+	faucet           *Faucet
 	cancel           context.CancelFunc
 	signalReceived   *bool
+	dataPath         string
 
 	reachedEndConditions bool
+	durationExceeded     bool
+}
+
+// ConstructionDataPath returns the path where check:construction
+// stores data for network within dataDirectory.
+func ConstructionDataPath(dataDirectory string, network *types.NetworkIdentifier) string {
+	return path.Join(dataDirectory, constructionCmdName, types.Hash(network))
 }
 
 // InitializeConstruction initiates the construction API tester.
+// faucetClient is only used if a faucet is configured.
 func InitializeConstruction(
 	ctx context.Context,
 	config *configuration.Configuration,
 	network *types.NetworkIdentifier,
 	onlineFetcher *fetcher.Fetcher,
+	offlineFetcher *fetcher.Fetcher,
+	skewMonitor *processor.SkewMonitor,
+	faucetClient *http.Client,
 	cancel context.CancelFunc,
 	signalReceived *bool,
 ) (*ConstructionTester, error) {
@@ -119,6 +142,8 @@ func InitializeConstruction(
 		false,
 		false,
 		false,
+		config.LogLevel,
+		config.LogFormat,
 		logger.Construction,
 		network,
 	)
@@ -141,6 +166,7 @@ func InitializeConstruction(
 		true,
 		networkOptions.Allow.BalanceExemptions,
 		config.Construction.InitialBalanceFetchDisabled,
+		nil,
 	)
 
 	balanceStorageHandler := processor.NewBalanceStorageHandler(
@@ -149,47 +175,69 @@ func InitializeConstruction(
 		counterStorage,
 		false,
 		nil,
+		nil,
+		nil,
+		nil,
 	)
 
 	balanceStorage.Initialize(balanceStorageHelper, balanceStorageHandler)
 
+	// Deposits are only tracked for interesting accounts,
+	// like balances.
+	confirmationTracker := processor.NewConfirmationTracker(
+		config.Construction.MinimumBalanceConfirmations,
+		onlineFetcher.Asserter,
+		balanceStorageHelper.ExemptFunc(),
+	)
+
+	// The tip delay is corrected for clock skew by the
+	// BroadcastStorageHelper each time it is used so that
+	// periodic skew estimates are applied.
 	broadcastStorage := modules.NewBroadcastStorage(
 		localStore,
 		config.Construction.StaleDepth,
 		config.Construction.BroadcastLimit,
-		config.TipDelay,
+		config.Construction.TipDelay,
 		config.Construction.BroadcastBehindTip,
 		config.Construction.BlockBroadcastLimit,
 	)
 
 	parser := parser.New(onlineFetcher.Asserter, nil, networkOptions.Allow.BalanceExemptions)
+	endpointTracker := results.NewEndpointTracker()
 	broadcastHelper := processor.NewBroadcastStorageHelper(
 		network,
 		blockStorage,
 		onlineFetcher,
+		skewMonitor,
+		config.Construction.BroadcastTPS,
+		endpointTracker,
 	)
 
-	fetcherOpts := []fetcher.Option{
-		fetcher.WithMaxConnections(config.Construction.MaxOfflineConnections),
-		fetcher.WithAsserter(onlineFetcher.Asserter),
-		fetcher.WithTimeout(time.Duration(config.HTTPTimeout) * time.Second),
-		fetcher.WithMaxRetries(config.MaxRetries),
-	}
-	if config.Construction.ForceRetry {
-		fetcherOpts = append(fetcherOpts, fetcher.WithForceRetry())
-	}
-
-	offlineFetcher := fetcher.New(
-		config.Construction.OfflineURL,
-		fetcherOpts...,
+	// Import prefunded account and save to database
+	importedAccounts := make(
+		[]*modules.PrefundedAccount,
+		len(config.Construction.PrefundedAccounts),
 	)
+	for i, prefundedAcc := range config.Construction.PrefundedAccounts {
+		importedAccounts[i] = &prefundedAcc.PrefundedAccount
+	}
 
-	// Import prefunded account and save to database
-	err = keyStorage.ImportAccounts(ctx, config.Construction.PrefundedAccounts)
+	err = keyStorage.ImportAccounts(ctx, importedAccounts)
 	if err != nil {
 		return nil, err
 	}
 
+	jobStorage := modules.NewJobStorage(localStore)
+	lineageStorage := processor.NewLineageStorage(localStore, jobStorage, parser)
+	prefundedAccounts := make([]*types.AccountIdentifier, len(config.Construction.PrefundedAccounts))
+	for i, prefundedAcc := range config.Construction.PrefundedAccounts {
+		prefundedAccounts[i] = prefundedAcc.AccountIdentifier
+	}
+
+	if err := lineageStorage.ImportPrefunded(ctx, prefundedAccounts); err != nil {
+		return nil, fmt.Errorf("%w: unable to record prefunded account lineage", err)
+	}
+
 	// Load all accounts for network
 	accounts, err := keyStorage.GetAllAccounts(ctx)
 	if err != nil {
@@ -263,7 +311,7 @@ func InitializeConstruction(
 	// ---------------------- End of adding account coins -----------------------
 	// --------------------------------------------------------------------------
 
-	jobStorage := modules.NewJobStorage(localStore)
+	coordinatorJobStorage := processor.NewLineageJobStorage(lineageStorage)
 	coordinatorHelper := processor.NewCoordinatorHelper(
 		offlineFetcher,
 		onlineFetcher,
@@ -275,14 +323,20 @@ func InitializeConstruction(
 		broadcastStorage,
 		balanceStorageHelper,
 		counterStorage,
-		config.Construction.Quiet,
+		lineageStorage,
+		coordinatorJobStorage,
+		config.Construction.Quiet || !config.LogLevel.Enabled(zapcore.InfoLevel),
+		config.Construction.DryRun,
+		config.Construction.AllowedSigners,
+		confirmationTracker,
+		endpointTracker,
 	)
 
 	coordinatorHandler := processor.NewCoordinatorHandler(
 		counterStorage,
 	)
 	coordinator, err := coordinator.New(
-		jobStorage,
+		coordinatorJobStorage,
 		coordinatorHelper,
 		coordinatorHandler,
 		parser,
@@ -302,6 +356,17 @@ func InitializeConstruction(
 
 	broadcastStorage.Initialize(broadcastHelper, broadcastHandler)
 
+	blockWorkers := []modules.BlockWorker{
+		counterStorage,
+		balanceStorage,
+		coinStorage,
+		lineageStorage,
+		broadcastStorage,
+	}
+	if confirmationTracker != nil {
+		blockWorkers = append(blockWorkers, confirmationTracker)
+	}
+
 	syncer := statefulsyncer.New(
 		ctx,
 		network,
@@ -310,13 +375,29 @@ func InitializeConstruction(
 		counterStorage,
 		logger,
 		cancel,
-		[]modules.BlockWorker{counterStorage, balanceStorage, coinStorage, broadcastStorage},
+		blockWorkers,
 		statefulsyncer.WithCacheSize(syncer.DefaultCacheSize),
 		statefulsyncer.WithMaxConcurrency(config.MaxSyncConcurrency),
 		statefulsyncer.WithPastBlockLimit(config.MaxReorgDepth),
 		statefulsyncer.WithSeenConcurrency(int64(config.SeenBlockWorkers)),
 	)
 
+	if config.Construction.DryRun {
+		color.Yellow("dry run enabled: signed transactions will not be broadcast")
+	}
+
+	var constructionFaucet *Faucet
+	if config.Construction.Faucet != nil {
+		constructionFaucet, err = NewFaucet(
+			config.Construction.Faucet,
+			faucetClient,
+			counterStorage,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to initialize faucet", err)
+		}
+	}
+
 	return &ConstructionTester{
 		network:          network,
 		database:         localStore,
@@ -324,13 +405,21 @@ func InitializeConstruction(
 		syncer:           syncer,
 		logger:           logger,
 		coordinator:      coordinator,
+		faucet:           constructionFaucet,
 		broadcastStorage: broadcastStorage,
 		blockStorage:     blockStorage,
+		keyStorage:       keyStorage,
+		balanceStorage:   balanceStorage,
 		jobStorage:       jobStorage,
 		counterStorage:   counterStorage,
+		lineageStorage:   lineageStorage,
+		confirmations:    confirmationTracker,
+		endpoints:        endpointTracker,
 		onlineFetcher:    onlineFetcher,
+		skewMonitor:      skewMonitor,
 		cancel:           cancel,
 		signalReceived:   signalReceived,
+		dataPath:         dataPath,
 	}, nil
 }
 
@@ -341,6 +430,34 @@ func (t *ConstructionTester) CloseDatabase(ctx context.Context) {
 	}
 }
 
+// confirmationStatus returns the pending and available balances
+// of all accounts in KeyStorage or nil if minimum balance
+// confirmations are not required.
+func (t *ConstructionTester) confirmationStatus(ctx context.Context) *results.ConfirmationStatus {
+	if t.confirmations == nil {
+		return nil
+	}
+
+	accounts, err := t.keyStorage.GetAllAccounts(ctx)
+	if err != nil {
+		log.Printf("%s: unable to load accounts for confirmation status\n", err.Error())
+		return nil
+	}
+
+	currencies := []*types.Currency{}
+	for _, account := range t.config.Construction.PrefundedAccounts {
+		currencies = append(currencies, account.Currency)
+	}
+
+	status, err := t.confirmations.Status(ctx, t.balanceStorage, accounts, currencies)
+	if err != nil {
+		log.Printf("%s: unable to compute confirmation status\n", err.Error())
+		return nil
+	}
+
+	return status
+}
+
 // StartPeriodicLogger prints out periodic
 // stats about a run of `check:construction`.
 func (t *ConstructionTester) StartPeriodicLogger(
@@ -360,6 +477,8 @@ func (t *ConstructionTester) StartPeriodicLogger(
 				t.counterStorage,
 				t.broadcastStorage,
 				t.jobStorage,
+				t.skewMonitor.Current(),
+				t.confirmationStatus(ctx),
 			)
 			t.logger.LogConstructionStatus(ctx, status)
 		}
@@ -370,7 +489,7 @@ func (t *ConstructionTester) checkTip(ctx context.Context) (int64, error) {
 	atTip, blockIdentifier, err := utils.CheckNetworkTip(
 		ctx,
 		t.network,
-		t.config.TipDelay,
+		t.skewMonitor.AdjustTipDelay(t.config.Construction.TipDelay),
 		t.onlineFetcher,
 	)
 	if err != nil {
@@ -386,7 +505,10 @@ func (t *ConstructionTester) checkTip(ctx context.Context) (int64, error) {
 
 // waitForTip loops until the Rosetta implementation is at tip.
 func (t *ConstructionTester) waitForTip(ctx context.Context) (int64, error) {
-	tc := time.NewTicker(tipWaitInterval)
+	interval := tipPollInterval(t.config)
+	color.Cyan("checking if at tip every %s", interval)
+
+	tc := time.NewTicker(interval)
 	defer tc.Stop()
 
 	for {
@@ -452,6 +574,17 @@ func (t *ConstructionTester) StartConstructor(
 	return t.coordinator.Process(ctx)
 }
 
+// StartFaucet requests funds from the faucet for accounts
+// that request_funds jobs are waiting on. If no faucet is
+// configured, it returns immediately.
+func (t *ConstructionTester) StartFaucet(ctx context.Context) error {
+	if t.faucet == nil {
+		return nil
+	}
+
+	return t.faucet.Watch(ctx, t.jobStorage, faucetCheckInterval)
+}
+
 // ServeHTTP serves a CheckDataStatus response on all paths.
 func (t *ConstructionTester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
@@ -463,6 +596,8 @@ func (t *ConstructionTester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		t.counterStorage,
 		t.broadcastStorage,
 		t.jobStorage,
+		t.skewMonitor.Current(),
+		t.confirmationStatus(r.Context()),
 	)
 
 	if err := json.NewEncoder(w).Encode(status); err != nil {
@@ -470,6 +605,27 @@ func (t *ConstructionTester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// CollectMetrics implements the metrics.Collector interface.
+func (t *ConstructionTester) CollectMetrics(ctx context.Context) ([]*metrics.Sample, error) {
+	samples, err := metrics.CounterSamples(ctx, t.counterStorage, metrics.ConstructionCounters)
+	if err != nil {
+		return nil, err
+	}
+
+	syncSamples, err := syncSamples(
+		ctx,
+		t.blockStorage,
+		t.onlineFetcher,
+		t.network,
+		t.dataPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(samples, syncSamples...), nil
+}
+
 // PerformBroadcasts attempts to rebroadcast all pending transactions
 // if the RebroadcastAll configuration is set to true.
 func (t *ConstructionTester) PerformBroadcasts(ctx context.Context) error {
@@ -486,36 +642,61 @@ func (t *ConstructionTester) PerformBroadcasts(ctx context.Context) error {
 	return nil
 }
 
+// endConditionsMet returns a boolean indicating if all
+// end conditions are met (provided workflows are executed
+// at least minOccurences).
+func (t *ConstructionTester) endConditionsMet(ctx context.Context) (bool, error) {
+	endConditions := t.config.Construction.EndConditions
+	if endConditions == nil {
+		return false, nil
+	}
+
+	for workflow, minOccurences := range endConditions {
+		completed, err := t.jobStorage.Completed(ctx, workflow)
+		if err != nil {
+			return false, fmt.Errorf("%w: unable to fetch completed %s", err, workflow)
+		}
+
+		if len(completed) < minOccurences {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 // WatchEndConditions cancels check:construction once
-// all end conditions are met (provided workflows
-// are executed at least minOccurences).
+// all end conditions are met or once the configured
+// duration has elapsed (whichever comes first).
 func (t *ConstructionTester) WatchEndConditions(
 	ctx context.Context,
 ) error {
 	endConditions := t.config.Construction.EndConditions
-	if endConditions == nil {
+	duration := t.config.Construction.Duration
+	if endConditions == nil && duration == 0 {
 		return nil
 	}
 
 	tc := time.NewTicker(endConditionsCheckInterval)
 	defer tc.Stop()
 
+	// A nil channel is never ready, so the duration
+	// is ignored if it is not configured.
+	var deadline <-chan time.Time
+	if duration > 0 {
+		timer := time.NewTimer(time.Duration(duration) * time.Second)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-tc.C:
-			conditionsMet := true
-			for workflow, minOccurences := range endConditions {
-				completed, err := t.jobStorage.Completed(ctx, workflow)
-				if err != nil {
-					return fmt.Errorf("%w: unable to fetch completed %s", err, workflow)
-				}
-
-				if len(completed) < minOccurences {
-					conditionsMet = false
-					break
-				}
+			conditionsMet, err := t.endConditionsMet(ctx)
+			if err != nil {
+				return err
 			}
 
 			if conditionsMet {
@@ -523,6 +704,22 @@ func (t *ConstructionTester) WatchEndConditions(
 				t.cancel()
 				return nil
 			}
+		case <-deadline:
+			// End conditions reached since the last
+			// check take precedence over the duration.
+			conditionsMet, err := t.endConditionsMet(ctx)
+			if err != nil {
+				return err
+			}
+
+			if conditionsMet {
+				t.reachedEndConditions = true
+			} else {
+				t.durationExceeded = true
+			}
+
+			t.cancel()
+			return nil
 		}
 	}
 }
@@ -579,11 +776,44 @@ func (t *ConstructionTester) HandleErr(
 			t.counterStorage,
 			t.jobStorage,
 			errors.New("check halted"),
+			&results.ExitConstructionOptions{
+				Lineage:   t.lineageStorage,
+				ClockSkew: t.skewMonitor.Current(),
+				Endpoints: t.endpoints.Results(),
+			},
+		)
+	}
+
+	if t.durationExceeded {
+		return results.ExitConstruction(
+			t.config,
+			t.counterStorage,
+			t.jobStorage,
+			fmt.Errorf(
+				"%w: %d seconds elapsed",
+				results.ErrDurationExceeded,
+				t.config.Construction.Duration,
+			),
+			&results.ExitConstructionOptions{
+				Lineage:   t.lineageStorage,
+				ClockSkew: t.skewMonitor.Current(),
+				Endpoints: t.endpoints.Results(),
+			},
 		)
 	}
 
 	if !t.reachedEndConditions {
-		return results.ExitConstruction(t.config, t.counterStorage, t.jobStorage, err)
+		return results.ExitConstruction(
+			t.config,
+			t.counterStorage,
+			t.jobStorage,
+			err,
+			&results.ExitConstructionOptions{
+				Lineage:   t.lineageStorage,
+				ClockSkew: t.skewMonitor.Current(),
+				Endpoints: t.endpoints.Results(),
+			},
+		)
 	}
 
 	// We optimistically run the ReturnFunds function on the coordinator
@@ -594,5 +824,15 @@ func (t *ConstructionTester) HandleErr(
 		sigListeners,
 	)
 
-	return results.ExitConstruction(t.config, t.counterStorage, t.jobStorage, nil)
+	return results.ExitConstruction(
+		t.config,
+		t.counterStorage,
+		t.jobStorage,
+		nil,
+		&results.ExitConstructionOptions{
+			Lineage:   t.lineageStorage,
+			ClockSkew: t.skewMonitor.Current(),
+			Endpoints: t.endpoints.Results(),
+		},
+	)
 }
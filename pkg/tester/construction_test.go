@@ -0,0 +1,91 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/constructor/job"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstructionTesterWatchEndConditions(t *testing.T) {
+	var tests = map[string]struct {
+		completed int
+
+		expectedReachedEndConditions bool
+		expectedDurationExceeded     bool
+	}{
+		"duration exceeded": {
+			completed:                0,
+			expectedDurationExceeded: true,
+		},
+		"end conditions take precedence": {
+			completed:                    2,
+			expectedReachedEndConditions: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			jobStorage := modules.NewJobStorage(db)
+			dbTx := db.Transaction(ctx)
+			for i := 0; i < test.completed; i++ {
+				_, err := jobStorage.Update(ctx, dbTx, &job.Job{
+					Workflow:  "transfer",
+					Scenarios: []*job.Scenario{{Name: "transfer"}},
+					Index:     1,
+					Status:    job.Completed,
+					State:     "{}",
+				})
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, dbTx.Commit(ctx))
+
+			config := configuration.DefaultConfiguration()
+			config.Construction = &configuration.ConstructionConfiguration{
+				EndConditions: map[string]int{"transfer": 2},
+				Duration:      1,
+			}
+
+			tester := &ConstructionTester{
+				config:     config,
+				jobStorage: jobStorage,
+				cancel:     cancel,
+			}
+			assert.NoError(t, tester.WatchEndConditions(ctx))
+			assert.Equal(t, test.expectedReachedEndConditions, tester.reachedEndConditions)
+			assert.Equal(t, test.expectedDurationExceeded, tester.durationExceeded)
+			assert.Error(t, ctx.Err())
+		})
+	}
+}
@@ -22,10 +22,17 @@ import (
 	"log"
 	"math/big"
 	"net/http"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/httpclient"
 	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
 	"github.com/coinbase/rosetta-cli/pkg/processor"
 	"github.com/coinbase/rosetta-cli/pkg/results"
 
@@ -62,34 +69,99 @@ const (
 	// to the terminal.
 	PeriodicLoggingFrequency = periodicLoggingSeconds * time.Second
 
+	// ThroughputWindow is the window over which the
+	// current rate of blocks synced is computed.
+	ThroughputWindow = time.Minute
+
 	// EndAtTipCheckInterval is the frequency that EndAtTip condition
 	// is evaludated
 	EndAtTipCheckInterval = 10 * time.Second
+
+	// DataDirectorySizeCheckInterval is the frequency that
+	// the size of the data directory is measured (if
+	// max_data_directory_size_mb is populated).
+	DataDirectorySizeCheckInterval = 30 * time.Second
+
+	// CheckpointInterval is the frequency that the last
+	// processed block and reconciliation cursor are
+	// written to the checkpoint file.
+	CheckpointInterval = 30 * time.Second
 )
 
 var _ http.Handler = (*DataTester)(nil)
 var _ statefulsyncer.PruneHelper = (*DataTester)(nil)
+var _ HealthChecker = (*DataTester)(nil)
+var _ ConfigurationApplier = (*DataTester)(nil)
+var _ metrics.Collector = (*DataTester)(nil)
 
 // DataTester coordinates the `check:data` test.
 type DataTester struct {
 	network                     *types.NetworkIdentifier
 	database                    database.Database
+	batchingStore               *BatchingDatabase
 	config                      *configuration.Configuration
 	syncer                      *statefulsyncer.StatefulSyncer
 	reconciler                  *reconciler.Reconciler
+	reconcilerWorkers           *reconcilerWorkers
 	logger                      *logger.Logger
 	balanceStorage              *modules.BalanceStorage
 	blockStorage                *modules.BlockStorage
 	counterStorage              *modules.CounterStorage
 	reconcilerHandler           *processor.ReconcilerHandler
 	fetcher                     *fetcher.Fetcher
+	balanceFetcher              *fetcher.Fetcher
+	skewMonitor                 *processor.SkewMonitor
 	signalReceived              *bool
 	genesisBlock                *types.BlockIdentifier
 	cancel                      context.CancelFunc
 	historicalBalanceEnabled    bool
 	parser                      *parser.Parser
 	forceInactiveReconciliation *bool
-
+	pruneHelper                 *processor.PruneHelper
+	healthMonitor               *HealthMonitor
+	overrideWorker              *OverridesWorker
+	failureHook                 *processor.FailureHook
+	casingChecker               *CurrencyCasingChecker
+	comparer                    *Comparer
+	coinTracker                 *CoinTracker
+	reconciliationFailures      *processor.DiscrepancyTracker
+	nonFatalErrors              *nonfatal.Tracker
+	throughput                  *ThroughputTracker
+	interestingWatcher          *processor.InterestingAccountsWatcher
+	endpointOverrides           *httpclient.EndpointOverrides
+	diskMonitor                 *DiskMonitor
+	dataPath                    string
+	blockWorkers                []modules.BlockWorker
+	tipPoller                   *TipPoller
+	blockTimer                  *BlockTimer
+	checkpointWorker            *CheckpointWorker
+	blockHashWatcher            *BlockHashWatcher
+
+	// startTime is when this run started and resumedElapsed
+	// is how long the runs it resumed from ran (so duration
+	// end conditions continue instead of starting over).
+	startTime      time.Time
+	resumedElapsed time.Duration
+
+	// coverage is the last estimate of reconciliation
+	// coverage (stored with each checkpoint).
+	coverage     float64
+	coverageLock sync.Mutex
+
+	// storageEstimate is only populated if
+	// --estimate-storage is provided.
+	storageEstimate *results.StorageEstimate
+
+	// monitor is only populated if monitor_after_end_conditions
+	// is enabled. monitorResults are the results written when
+	// entering monitor mode and monitorErr is populated if the
+	// end of run checks failed when entering monitor mode.
+	monitor        *processor.FindingsMonitor
+	monitorOnce    sync.Once
+	monitorResults *results.CheckDataResults
+	monitorErr     error
+
+	endConditions      *EndConditionsTracker
 	endCondition       configuration.CheckDataEndCondition
 	endConditionDetail string
 }
@@ -106,9 +178,44 @@ func shouldReconcile(config *configuration.Configuration) bool {
 	return true
 }
 
+// DataPath returns the path where check:data
+// stores data for network within dataDirectory.
+func DataPath(dataDirectory string, network *types.NetworkIdentifier) string {
+	return path.Join(dataDirectory, dataCmdName, types.Hash(network))
+}
+
+// ExemptAccounts returns the accounts exempted from balance
+// tracking by the ExemptAccounts file and ExemptAccountsList
+// and the currencies exempted from reconciliation on all
+// accounts (entries without an account).
+func ExemptAccounts(
+	config *configuration.DataConfiguration,
+) ([]*types.AccountCurrency, []*types.Currency, error) {
+	exemptAccounts, err := loadAccounts(config.ExemptAccounts, configuration.AssertExemptAccounts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accounts := []*types.AccountCurrency{}
+	currencies := []*types.Currency{}
+	for _, account := range mergeAccounts(exemptAccounts, config.ExemptAccountsList) {
+		if account.Account == nil {
+			currencies = append(currencies, account.Currency)
+			continue
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, currencies, nil
+}
+
 // loadAccounts is a utility function to parse the []*types.AccountCurrency
-// in a file.
-func loadAccounts(filePath string) ([]*types.AccountCurrency, error) {
+// in a file (validated with assert).
+func loadAccounts(
+	filePath string,
+	assert func([]*types.AccountCurrency) error,
+) ([]*types.AccountCurrency, error) {
 	if len(filePath) == 0 {
 		return []*types.AccountCurrency{}, nil
 	}
@@ -118,6 +225,10 @@ func loadAccounts(filePath string) ([]*types.AccountCurrency, error) {
 		return nil, fmt.Errorf("%w: unable to open account file", err)
 	}
 
+	if err := assert(accounts); err != nil {
+		return nil, fmt.Errorf("%w: invalid account file %s", err, filePath)
+	}
+
 	log.Printf(
 		"Found %d accounts at %s: %s\n",
 		len(accounts),
@@ -128,27 +239,128 @@ func loadAccounts(filePath string) ([]*types.AccountCurrency, error) {
 	return accounts, nil
 }
 
+// mergeAccounts returns the accounts in lists
+// without any duplicates, preserving order.
+func mergeAccounts(lists ...[]*types.AccountCurrency) []*types.AccountCurrency {
+	seen := map[string]struct{}{}
+	merged := []*types.AccountCurrency{}
+	for _, accounts := range lists {
+		for _, account := range accounts {
+			key := types.Hash(account)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+			merged = append(merged, account)
+		}
+	}
+
+	return merged
+}
+
+// loadBootstrapBalances returns the balances in all filePaths,
+// erroring if an account and currency appears more than once.
+func loadBootstrapBalances(filePaths []string) ([]*modules.BootstrapBalance, error) {
+	balances := []*modules.BootstrapBalance{}
+	seen := map[string]string{}
+	for _, filePath := range filePaths {
+		fileBalances := []*modules.BootstrapBalance{}
+		if err := utils.LoadAndParse(filePath, &fileBalances); err != nil {
+			return nil, fmt.Errorf("%w: unable to load bootstrap balances from %s", err, filePath)
+		}
+
+		for _, balance := range fileBalances {
+			key := types.Hash(&types.AccountCurrency{
+				Account:  balance.Account,
+				Currency: balance.Currency,
+			})
+			if firstPath, ok := seen[key]; ok {
+				return nil, fmt.Errorf(
+					"%s %s is bootstrapped in both %s and %s",
+					types.PrintStruct(balance.Account),
+					types.PrintStruct(balance.Currency),
+					firstPath,
+					filePath,
+				)
+			}
+
+			seen[key] = filePath
+			balances = append(balances, balance)
+		}
+	}
+
+	return balances, nil
+}
+
+// bootstrapBalances bootstraps balances from the BootstrapBalances
+// files or, if they are not populated, from BootstrapBalancesList
+// (using BootstrapBalancesConcurrency workers).
+func bootstrapBalances(
+	ctx context.Context,
+	config *configuration.DataConfiguration,
+	db database.Database,
+	balanceStorageHelper modules.BalanceStorageHelper,
+	counterStorage *modules.CounterStorage,
+	genesisBlock *types.BlockIdentifier,
+) error {
+	balances := config.BootstrapBalancesList
+	if len(config.BootstrapBalances) > 0 {
+		var err error
+		balances, err = loadBootstrapBalances(config.BootstrapBalances)
+		if err != nil {
+			return err
+		}
+	}
+
+	return BulkLoadBalances(
+		ctx,
+		db,
+		balanceStorageHelper,
+		counterStorage,
+		balances,
+		genesisBlock,
+		int(config.BootstrapBalancesConcurrency),
+	)
+}
+
 // CloseDatabase closes the database used by DataTester.
-func (t *DataTester) CloseDatabase(ctx context.Context) {
+func (t *DataTester) CloseDatabase(ctx context.Context) error {
 	if err := t.database.Close(ctx); err != nil {
-		log.Fatalf("%s: error closing database", err.Error())
+		return fmt.Errorf("%w: error closing database", err)
 	}
+
+	return nil
 }
 
-// InitializeData returns a new *DataTester.
+// InitializeData returns a new *DataTester. The database
+// of the *DataTester must be closed with CloseDatabase.
 func InitializeData(
 	ctx context.Context,
 	config *configuration.Configuration,
 	network *types.NetworkIdentifier,
 	fetcher *fetcher.Fetcher,
+	skewMonitor *processor.SkewMonitor,
 	cancel context.CancelFunc,
 	genesisBlock *types.BlockIdentifier,
 	interestingAccount *types.AccountCurrency,
 	signalReceived *bool,
-) *DataTester {
+	failureHook *processor.FailureHook,
+	endpointOverrides *httpclient.EndpointOverrides,
+	comparisonFetcher *fetcher.Fetcher,
+	archiveFetcher *fetcher.Fetcher,
+	nonFatalErrors *nonfatal.Tracker,
+) (*DataTester, error) {
+	if config.Data.Fresh {
+		color.Yellow("--fresh provided, discarding the checkpoint and data directory")
+		if err := os.RemoveAll(DataPath(config.DataDirectory, network)); err != nil {
+			return nil, fmt.Errorf("%w: unable to remove data directory", err)
+		}
+	}
+
 	dataPath, err := utils.CreateCommandPath(config.DataDirectory, dataCmdName, network)
 	if err != nil {
-		log.Fatalf("%s: cannot create command path", err.Error())
+		return nil, fmt.Errorf("%w: cannot create command path", err)
 	}
 
 	opts := []database.BadgerOption{}
@@ -164,68 +376,140 @@ func InitializeData(
 
 	localStore, err := database.NewBadgerDatabase(ctx, dataPath, opts...)
 	if err != nil {
-		log.Fatalf("%s: unable to initialize database", err.Error())
+		return nil, fmt.Errorf("%w: unable to initialize database", err)
 	}
 
-	exemptAccounts, err := loadAccounts(config.Data.ExemptAccounts)
+	// The database is only closed here if an error is
+	// returned (otherwise, it is closed by CloseDatabase).
+	initialized := false
+	defer func() {
+		if initialized {
+			return
+		}
+
+		if err := localStore.Close(ctx); err != nil {
+			log.Printf("%s: unable to close database\n", err.Error())
+		}
+	}()
+
+	exemptAccounts, exemptCurrencies, err := ExemptAccounts(config.Data)
 	if err != nil {
-		log.Fatalf("%s: unable to load exempt accounts", err.Error())
+		return nil, fmt.Errorf("%w: unable to load exempt accounts", err)
 	}
 
-	interestingAccounts, err := loadAccounts(config.Data.InterestingAccounts)
+	if config.LogConfiguration {
+		log.Printf(
+			"Exempting %d accounts and %d currencies\n",
+			len(exemptAccounts),
+			len(exemptCurrencies),
+		)
+	}
+
+	interestingAccounts, err := loadAccounts(
+		config.Data.InterestingAccounts,
+		configuration.AssertAccounts,
+	)
 	if err != nil {
-		log.Fatalf("%s: unable to load interesting accounts", err.Error())
+		return nil, fmt.Errorf("%w: unable to load interesting accounts", err)
 	}
 
-	counterStorage := modules.NewCounterStorage(localStore)
-	blockStorage := modules.NewBlockStorage(localStore, config.SerialBlockWorkers)
-	balanceStorage := modules.NewBalanceStorage(localStore)
+	// Accounts added to the interesting accounts file during the
+	// run are checked on subsequent blocks (when not finding
+	// missing operations for a single interesting account).
+	var interestingWatcher *processor.InterestingAccountsWatcher
+	if len(config.Data.InterestingAccounts) > 0 && interestingAccount == nil {
+		interestingWatcher = processor.NewInterestingAccountsWatcher(
+			config.Data.InterestingAccounts,
+			time.Duration(config.Data.InterestingAccountsReloadInterval)*time.Second,
+			interestingAccounts,
+		)
+	}
+
+	// All storage is accessed through a wrapper of localStore
+	// so that block commits can be batched when storage is slow
+	// without hiding batched blocks from other modules.
+	var batchingStore *BatchingDatabase
+	blockStore := localStore
+	if config.Storage != nil && config.Storage.MaxCommitBatchBlocks > 1 {
+		batchingStore = NewBatchingDatabase(
+			localStore,
+			config.Storage.MaxCommitBatchBlocks,
+			time.Duration(config.Storage.CommitLatencyThresholdMs)*time.Millisecond,
+		)
+		blockStore = batchingStore
+	}
 
+	counterStorage := modules.NewCounterStorage(blockStore)
+	blockStorage := modules.NewBlockStorage(blockStore, config.SerialBlockWorkers)
+	balanceStorage := modules.NewBalanceStorage(blockStore)
+
+	logCategories := configuration.DataLogCategories(config)
 	logger, err := logger.NewLogger(
 		dataPath,
-		config.Data.LogBlocks,
-		config.Data.LogTransactions,
-		config.Data.LogBalanceChanges,
-		config.Data.LogReconciliations,
+		logCategories.Blocks,
+		logCategories.Transactions,
+		logCategories.BalanceChanges,
+		logCategories.Reconciliations,
+		config.LogLevel,
+		config.LogFormat,
 		logger.Data,
 		network,
 	)
 	if err != nil {
-		log.Fatalf(fmt.Sprintf("unable to initialize logger with error: %s", err.Error()))
-		return nil
+		return nil, fmt.Errorf("%w: unable to initialize logger", err)
 	}
+	logger.SetTransactionOpTypes(config.Data.LogTransactionOpTypes)
 
 	var forceInactiveReconciliation bool
+	balanceFetcher := historicalBalanceFetcher(fetcher, archiveFetcher)
 	reconcilerHelper := processor.NewReconcilerHelper(
 		config,
 		network,
-		fetcher,
-		localStore,
+		balanceFetcher,
+		skewMonitor,
+		blockStore,
 		blockStorage,
 		balanceStorage,
+		counterStorage,
 		&forceInactiveReconciliation,
 	)
 
+	var dataMonitor *processor.FindingsMonitor
+	if config.Data.MonitorAfterEndConditions {
+		dataMonitor = processor.NewFindingsMonitor(config.Data.MonitorResultsOutputFile)
+	}
+
+	reconciliationFailures := processor.NewDiscrepancyTracker(
+		config.Data.ReconciliationFailureThreshold,
+		config.Data.ReconciliationFailureRate,
+	)
 	reconcilerHandler := processor.NewReconcilerHandler(
 		logger,
 		counterStorage,
 		balanceStorage,
 		!config.Data.IgnoreReconciliationError,
+		failureHook,
+		dataMonitor,
+		config.Data.AcceptableDiscrepancies,
+		config.Data.BalanceExemptions,
+		config.Data.BalanceExemptionsCoverage,
+		reconciliationFailures,
+		nonFatalErrors,
 	)
 
 	// Get all previously seen accounts
 	seenAccounts, err := balanceStorage.GetAllAccountCurrency(ctx)
 	if err != nil {
-		log.Fatalf("%s: unable to get previously seen accounts", err.Error())
+		return nil, fmt.Errorf("%w: unable to get previously seen accounts", err)
 	}
 
 	networkOptions, fetchErr := fetcher.NetworkOptionsRetry(ctx, network, nil)
-	if err != nil {
-		log.Fatalf("%s: unable to get network options", fetchErr.Err.Error())
+	if fetchErr != nil {
+		return nil, fmt.Errorf("%w: unable to get network options", fetchErr.Err)
 	}
 
 	if len(networkOptions.Allow.BalanceExemptions) > 0 && config.Data.InitialBalanceFetchDisabled {
-		log.Fatal("found balance exemptions but initial balance fetch disabled")
+		return nil, errors.New("found balance exemptions but initial balance fetch disabled")
 	}
 
 	parser := parser.New(
@@ -236,18 +520,34 @@ func InitializeData(
 
 	// Determine if we should perform historical balance lookups
 	var historicalBalanceEnabled bool
-	if config.Data.HistoricalBalanceDisabled != nil {
+	if archiveFetcher != nil {
+		historicalBalanceEnabled = true
+	} else if config.Data.HistoricalBalanceDisabled != nil {
 		historicalBalanceEnabled = !*config.Data.HistoricalBalanceDisabled
 	} else { // we must look it up
 		historicalBalanceEnabled = networkOptions.Allow.HistoricalBalanceLookup
 	}
 
+	// Only accounts with some currencies may be reconciled
+	// (balances of all currencies are still tracked).
+	reconciliationCurrencies := processor.NewCurrencyFilter(config.Data.ReconciliationCurrencies)
+
+	// Currencies exempt on all accounts are never reconciled
+	// (balances of them are still tracked).
+	currencyExemptions := processor.NewExemptCurrencies(exemptCurrencies)
+
 	rOpts := []reconciler.Option{
 		reconciler.WithActiveConcurrency(int(config.Data.ActiveReconciliationConcurrency)),
 		reconciler.WithInactiveConcurrency(int(config.Data.InactiveReconciliationConcurrency)),
-		reconciler.WithInterestingAccounts(interestingAccounts),
-		reconciler.WithSeenAccounts(seenAccounts),
-		reconciler.WithInactiveFrequency(int64(config.Data.InactiveReconciliationFrequency)),
+		reconciler.WithInterestingAccounts(
+			currencyExemptions.Accounts(
+				reconciliationCurrencies.Accounts(processor.ConcreteAccounts(interestingAccounts)),
+			),
+		),
+		reconciler.WithSeenAccounts(
+			currencyExemptions.Accounts(reconciliationCurrencies.Accounts(seenAccounts)),
+		),
+		reconciler.WithInactiveFrequency(processor.InactiveFrequency),
 		reconciler.WithBalancePruning(),
 	}
 	if config.Data.ReconcilerActiveBacklog != nil {
@@ -256,7 +556,7 @@ func InitializeData(
 	if historicalBalanceEnabled {
 		rOpts = append(rOpts, reconciler.WithLookupBalanceByBlock())
 	}
-	if config.Data.LogReconciliations {
+	if logCategories.Reconciliations {
 		rOpts = append(rOpts, reconciler.WithDebugLogging())
 	}
 
@@ -266,18 +566,75 @@ func InitializeData(
 		parser,
 		rOpts...,
 	)
+	reconcilerWorkers := newReconcilerWorkers(
+		int(config.Data.ActiveReconciliationConcurrency),
+		int(config.Data.InactiveReconciliationConcurrency),
+		func(ctx context.Context, active int, inactive int) error {
+			r.ActiveConcurrency = active
+			r.InactiveConcurrency = inactive
+			return r.Reconcile(ctx)
+		},
+	)
+
+	// The block workers below call into the returned
+	// *DataTester (which is only used once syncing
+	// starts).
+	var dataTester *DataTester
+
+	var overrideWorker *OverridesWorker
+	blockWorkers := []modules.BlockWorker{}
+
+	// Blocks are verified against trusted checkpoints
+	// before any other worker processes them.
+	if len(config.Data.CheckpointsFile) > 0 {
+		verifier, err := LoadCheckpointVerifier(config.Data.CheckpointsFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load trusted checkpoints", err)
+		}
+
+		log.Printf(
+			"Verifying synced blocks against %d trusted checkpoints from %s\n",
+			verifier.Len(),
+			config.Data.CheckpointsFile,
+		)
+		blockWorkers = append(blockWorkers, verifier)
+	}
+
+	var blockHashWatcher *BlockHashWatcher
+	if config.Data.EndConditions != nil && config.Data.EndConditions.BlockHash != nil {
+		blockHashWatcher = NewBlockHashWatcher(
+			*config.Data.EndConditions.BlockHash,
+			config.Data.EndConditions.BlockHashIndex,
+			func(ctx context.Context, block *types.BlockIdentifier) {
+				dataTester.blockHashChanged(ctx, block)
+			},
+		)
+		blockWorkers = append(blockWorkers, blockHashWatcher)
+	}
+
+	// The checkpoint is stored in the same transaction as
+	// each block (the returned *DataTester is only used
+	// once syncing starts).
+	checkpointWorker := NewCheckpointWorker(blockStore, func() *Checkpoint {
+		return dataTester.progress()
+	})
+
+	blockWorkers = append(blockWorkers, counterStorage, checkpointWorker)
+	if batchingStore != nil {
+		blockWorkers = append(blockWorkers, batchingStore)
+	}
 
-	blockWorkers := []modules.BlockWorker{counterStorage}
 	if !config.Data.BalanceTrackingDisabled {
 		balanceStorageHelper := processor.NewBalanceStorageHelper(
 			network,
-			fetcher,
+			balanceFetcher,
 			counterStorage,
 			historicalBalanceEnabled,
 			exemptAccounts,
 			false,
 			networkOptions.Allow.BalanceExemptions,
 			config.Data.InitialBalanceFetchDisabled,
+			config.Data.ExcludedOperationTypes,
 		)
 
 		balanceStorageHandler := processor.NewBalanceStorageHandler(
@@ -286,48 +643,99 @@ func InitializeData(
 			counterStorage,
 			shouldReconcile(config),
 			interestingAccount,
+			interestingWatcher,
+			reconciliationCurrencies,
+			currencyExemptions,
 		)
 
 		balanceStorage.Initialize(balanceStorageHelper, balanceStorageHandler)
 
-		blockWorkers = append(blockWorkers, balanceStorage)
+		// Overridden transactions are rewritten before balance
+		// changes are computed so that reconciliation uses the
+		// corrected balance changes.
+		var balanceWorker modules.BlockWorker = balanceStorage
+		if len(config.Data.TransactionOverrides) > 0 {
+			transactionOverrides, err := LoadTransactionOverrides(config.Data.TransactionOverrides)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to load transaction overrides", err)
+			}
+
+			overrideWorker, err = NewOverridesWorker(
+				balanceStorage,
+				blockStore,
+				counterStorage,
+				fetcher.Asserter,
+				transactionOverrides,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to initialize transaction overrides", err)
+			}
+
+			color.Red(
+				"[WARNING] overriding balance changes of %d transaction(s) from %s",
+				len(transactionOverrides),
+				config.Data.TransactionOverrides,
+			)
+			balanceWorker = overrideWorker
+		}
+
+		blockWorkers = append(blockWorkers, balanceWorker)
 
 		// Bootstrap balances, if provided. We need to do before initializing
 		// the reconciler otherwise we won't reconcile bootstrapped accounts
 		// until rosetta-cli restart.
 		//
-		// We need to do this after instantiating the balance storage handler
-		// because it is invoked within BootstrapBalances.
-		if len(config.Data.BootstrapBalances) > 0 {
+		// We need to do this after instantiating the balance storage helper
+		// because it is used to bootstrap balances.
+		if len(config.Data.BootstrapBalances) > 0 || len(config.Data.BootstrapBalancesList) > 0 {
 			_, err := blockStorage.GetHeadBlockIdentifier(ctx)
 			switch {
 			case err == storageErrs.ErrHeadBlockNotFound:
-				err = balanceStorage.BootstrapBalances(
+				err = bootstrapBalances(
 					ctx,
-					config.Data.BootstrapBalances,
+					config.Data,
+					blockStore,
+					balanceStorageHelper,
+					counterStorage,
 					genesisBlock,
 				)
 				if err != nil {
-					log.Fatalf("%s: unable to bootstrap balances", err.Error())
+					return nil, fmt.Errorf("%w: unable to bootstrap balances", err)
 				}
 			case err != nil:
-				log.Fatalf("%s: unable to get head block identifier", err.Error())
+				return nil, fmt.Errorf("%w: unable to get head block identifier", err)
 			default:
 				log.Println("Skipping balance bootstrapping because already started syncing")
 			}
 		}
 	}
 
+	var coinTracker *CoinTracker
 	if !config.Data.CoinTrackingDisabled {
 		coinStorageHelper := processor.NewCoinStorageHelper(blockStorage)
-		coinStorage := modules.NewCoinStorage(localStore, coinStorageHelper, fetcher.Asserter)
+		coinStorage := modules.NewCoinStorage(blockStore, coinStorageHelper, fetcher.Asserter)
+
+		// The tracker must check coins before they
+		// are updated by coin storage.
+		coinTracker = NewCoinTracker(coinStorage, fetcher.Asserter)
+		blockWorkers = append(blockWorkers, coinTracker, coinStorage)
+	}
+
+	var casingChecker *CurrencyCasingChecker
+	if config.Data.CurrencyCasingCheck {
+		casingChecker = NewCurrencyCasingChecker(nonFatalErrors)
+		blockWorkers = append(blockWorkers, casingChecker)
+	}
 
-		blockWorkers = append(blockWorkers, coinStorage)
+	var comparer *Comparer
+	if comparisonFetcher != nil {
+		comparer = NewComparer(network, comparisonFetcher, parser)
+		blockWorkers = append(blockWorkers, comparer)
 	}
 
 	statefulSyncerOptions := []statefulsyncer.Option{
-		statefulsyncer.WithCacheSize(syncer.DefaultCacheSize),
-		statefulsyncer.WithMaxConcurrency(config.MaxSyncConcurrency),
+		statefulsyncer.WithCacheSize(blockBufferSize(config)),
+		statefulsyncer.WithMaxConcurrency(blockFetchConcurrency(config)),
 		statefulsyncer.WithPastBlockLimit(config.MaxReorgDepth),
 		statefulsyncer.WithSeenConcurrency(int64(config.SeenBlockWorkers)),
 	}
@@ -350,25 +758,264 @@ func InitializeData(
 		statefulSyncerOptions...,
 	)
 
-	return &DataTester{
+	tipPollerOptions := []TipPollerOption{
+		WithLimiter(processor.NewRateLimiter(config.MaxBlocksPerSecond)),
+	}
+	if config.Data.TipLongPoll {
+		tipPollerOptions = append(tipPollerOptions, WithLongPoll(
+			config.Data.TipLongPollNotFoundCodes,
+			time.Duration(config.Data.TipLongPollDelayMs)*time.Millisecond,
+			time.Duration(config.Data.TipLongPollTimeout)*time.Second,
+		))
+	}
+	if config.Data.HaltOnReorg {
+		tipPollerOptions = append(tipPollerOptions, WithHaltOnReorg())
+	}
+	if config.Data.StaticTip != nil {
+		tipPollerOptions = append(tipPollerOptions, WithStaticTip(*config.Data.StaticTip))
+	}
+
+	// Blocks are timed after they are returned by the tip
+	// poller so that time spent waiting for new blocks at
+	// tip is not counted.
+	var blockTimer *BlockTimer
+	var timedSyncer TipPollerSyncer = syncer
+	if config.Data.SlowBlockThreshold > 0 {
+		blockTimer = NewBlockTimer(
+			syncer,
+			time.Duration(config.Data.SlowBlockThreshold)*time.Millisecond,
+		)
+		timedSyncer = blockTimer
+	}
+	tipPoller := NewTipPoller(network, fetcher, timedSyncer, tipPollerOptions...)
+
+	pruningDepth := int64(config.MaxReorgDepth)
+	if config.Data.PruningDepth != nil {
+		pruningDepth = *config.Data.PruningDepth
+	}
+
+	var pruningBlockInterval int64
+	if config.Data.PruningBlockInterval != nil {
+		pruningBlockInterval = *config.Data.PruningBlockInterval
+	}
+
+	pruneHelper := processor.NewPruneHelper(
+		pruningDepth,
+		pruningBlockInterval,
+		int64(config.MaxReorgDepth),
+	)
+
+	var diskMonitor *DiskMonitor
+	if config.Data.MaxDataDirectorySizeMB > 0 {
+		var escalator DiskEscalator
+		if !config.Data.PruningDisabled {
+			escalator = pruneHelper
+		}
+
+		diskMonitor = NewDiskMonitor(dataPath, config.Data.MaxDataDirectorySizeMB, escalator)
+	}
+
+	dataTester = &DataTester{
 		network:                     network,
-		database:                    localStore,
+		database:                    blockStore,
+		batchingStore:               batchingStore,
 		config:                      config,
 		syncer:                      syncer,
 		cancel:                      cancel,
 		reconciler:                  r,
+		reconcilerWorkers:           reconcilerWorkers,
 		logger:                      logger,
 		balanceStorage:              balanceStorage,
 		blockStorage:                blockStorage,
 		counterStorage:              counterStorage,
 		reconcilerHandler:           reconcilerHandler,
 		fetcher:                     fetcher,
+		balanceFetcher:              balanceFetcher,
+		skewMonitor:                 skewMonitor,
 		signalReceived:              signalReceived,
 		genesisBlock:                genesisBlock,
 		historicalBalanceEnabled:    historicalBalanceEnabled,
 		parser:                      parser,
 		forceInactiveReconciliation: &forceInactiveReconciliation,
+		pruneHelper:                 pruneHelper,
+		healthMonitor: NewHealthMonitor(
+			time.Duration(config.TipDelay)*time.Second,
+			PeriodicLoggingFrequency,
+		),
+		overrideWorker:         overrideWorker,
+		failureHook:            failureHook,
+		casingChecker:          casingChecker,
+		comparer:               comparer,
+		coinTracker:            coinTracker,
+		reconciliationFailures: reconciliationFailures,
+		nonFatalErrors:         nonFatalErrors,
+		throughput:             NewThroughputTracker(ThroughputWindow),
+		interestingWatcher:     interestingWatcher,
+		endpointOverrides:      endpointOverrides,
+		diskMonitor:            diskMonitor,
+		dataPath:               dataPath,
+		blockWorkers:           blockWorkers,
+		tipPoller:              tipPoller,
+		blockTimer:             blockTimer,
+		checkpointWorker:       checkpointWorker,
+		blockHashWatcher:       blockHashWatcher,
+		startTime:              time.Now(),
+		monitor:                dataMonitor,
+		endConditions:          NewEndConditionsTracker(config.Data.EndConditions),
+	}
+
+	if err := dataTester.restoreCheckpoint(ctx); err != nil {
+		return nil, fmt.Errorf("%w: unable to restore checkpoint", err)
+	}
+
+	initialized = true
+	return dataTester, nil
+}
+
+// blockFetchConcurrency returns the maximum number
+// of blocks fetched concurrently while syncing.
+func blockFetchConcurrency(config *configuration.Configuration) int64 {
+	if config.Data.BlockFetchConcurrency > 0 {
+		return config.Data.BlockFetchConcurrency
+	}
+
+	return config.MaxSyncConcurrency
+}
+
+// blockBufferSize returns the size (in bytes) of fetched
+// blocks the syncer buffers before they are processed.
+func blockBufferSize(config *configuration.Configuration) int {
+	if config.Data.BlockBufferSize > 0 {
+		return int(config.Data.BlockBufferSize) << 20 // nolint:gomnd
+	}
+
+	return syncer.DefaultCacheSize
+}
+
+// syncStartIndex returns the index to start syncing from. If
+// no StartIndex is configured, syncing starts from the last saved
+// block (-1) or, if no blocks have been saved, from GenesisIndex.
+func syncStartIndex(
+	ctx context.Context,
+	config *configuration.DataConfiguration,
+	blockStorage *modules.BlockStorage,
+) (int64, error) {
+	if config.StartIndex != nil {
+		return *config.StartIndex, nil
+	}
+
+	if config.GenesisIndex == nil {
+		return -1, nil
+	}
+
+	_, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	switch {
+	case err == nil:
+		return -1, nil
+	case errors.Is(err, storageErrs.ErrHeadBlockNotFound):
+		return *config.GenesisIndex, nil
+	default:
+		return -1, fmt.Errorf("%w: unable to get head block", err)
+	}
+}
+
+// EstimateStorage estimates the storage required to sync the
+// remaining blocks of the run (if --estimate-storage is provided)
+// and compares it with the available disk space. If --strict is
+// provided, an insufficient estimate halts the run.
+func (t *DataTester) EstimateStorage(ctx context.Context) error {
+	if !t.config.Data.EstimateStorage {
+		return nil
+	}
+
+	startIndex, err := syncStartIndex(ctx, t.config.Data, t.blockStorage)
+	if err != nil {
+		return err
+	}
+
+	if startIndex == -1 {
+		head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+		switch {
+		case err == nil:
+			startIndex = head.Index + 1
+		case errors.Is(err, storageErrs.ErrHeadBlockNotFound):
+			startIndex = t.genesisBlock.Index
+		default:
+			return fmt.Errorf("%w: unable to get head block", err)
+		}
+	}
+
+	// Blocks produced while syncing to tip are
+	// not included in the estimate.
+	var endIndex int64
+	if t.config.Data.EndConditions != nil && t.config.Data.EndConditions.Index != nil {
+		endIndex = *t.config.Data.EndConditions.Index
+	} else {
+		status, fetchErr := t.fetcher.NetworkStatusRetry(ctx, t.network, nil)
+		if fetchErr != nil {
+			return fmt.Errorf("%w: unable to get network status", fetchErr.Err)
+		}
+
+		endIndex = status.CurrentBlockIdentifier.Index
+	}
+
+	samples := DefaultEstimateSamples
+	if t.config.Data.StorageEstimateSamples > 0 {
+		samples = t.config.Data.StorageEstimateSamples
+	}
+
+	color.Cyan("Estimating storage of blocks %d to %d...", startIndex, endIndex)
+	inputs, err := SampleEstimateInputs(
+		ctx,
+		t.fetcher,
+		t.network,
+		startIndex,
+		endIndex,
+		samples,
+		!t.config.CompressionDisabled,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to estimate storage", err)
+	}
+
+	storageEstimate := NewStorageEstimate(inputs)
+	available, err := AvailableBytes(t.dataPath)
+	if err != nil {
+		color.Yellow("[WARNING] %s: unable to measure available disk space", err.Error())
+	} else {
+		storageEstimate.AvailableBytes = &available
+	}
+
+	t.storageEstimate = storageEstimate
+	storageEstimate.Print()
+
+	if err := storageEstimate.Check(); err != nil {
+		if t.config.Data.StrictStorageEstimate {
+			return err
+		}
+
+		color.Yellow("[WARNING] %s", err.Error())
+	}
+
+	return nil
+}
+
+// storageEstimateResults returns the storage estimate of the
+// run (if one was made) with the size of the data directory
+// when the run completed.
+func (t *DataTester) storageEstimateResults() *results.StorageEstimate {
+	if t.storageEstimate == nil {
+		return nil
+	}
+
+	actual, err := DirectorySize(t.dataPath)
+	if err != nil {
+		log.Printf("%s: unable to measure data directory\n", err.Error())
+		return t.storageEstimate
 	}
+
+	t.storageEstimate.ActualBytes = &actual
+	return t.storageEstimate
 }
 
 // StartSyncing syncs from startIndex to endIndex.
@@ -378,17 +1025,268 @@ func InitializeData(
 func (t *DataTester) StartSyncing(
 	ctx context.Context,
 ) error {
-	startIndex := int64(-1)
-	if t.config.Data.StartIndex != nil {
-		startIndex = *t.config.Data.StartIndex
+	startIndex, err := syncStartIndex(ctx, t.config.Data, t.blockStorage)
+	if err != nil {
+		return err
 	}
 
+	if t.config.Data.Resume {
+		startIndex, err = t.resumeStartIndex(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// When all end conditions must be met, syncing
+	// continues past the end index.
 	endIndex := int64(-1)
-	if t.config.Data.EndConditions != nil && t.config.Data.EndConditions.Index != nil {
+	if t.config.Data.EndConditions != nil && t.config.Data.EndConditions.Index != nil &&
+		!t.endConditions.RequireAll() {
 		endIndex = *t.config.Data.EndConditions.Index
 	}
 
-	return t.syncer.Sync(ctx, startIndex, endIndex)
+	// The syncer is created here (instead of calling
+	// StatefulSyncer.Sync) so that it syncs through
+	// the tip poller.
+	t.blockStorage.Initialize(t.blockWorkers)
+	if startIndex != -1 {
+		if err := t.blockStorage.SetNewStartIndex(ctx, startIndex); err != nil {
+			return fmt.Errorf("%w: unable to set new start index", err)
+		}
+	} else if head, err := t.blockStorage.GetHeadBlockIdentifier(ctx); err == nil {
+		startIndex = head.Index + 1
+	}
+
+	if startIndex != -1 {
+		t.tipPoller.SetHead(startIndex - 1)
+	}
+
+	s := syncer.New(
+		t.network,
+		t.tipPoller,
+		t.tipPoller,
+		t.cancel,
+		syncer.WithPastBlocks(t.blockStorage.CreateBlockCache(ctx, t.config.MaxReorgDepth)),
+		syncer.WithCacheSize(blockBufferSize(t.config)),
+		syncer.WithMaxConcurrency(blockFetchConcurrency(t.config)),
+	)
+
+	return s.Sync(ctx, startIndex, endIndex)
+}
+
+// storedCheckpoint returns the checkpoint stored with the
+// last committed block or, if there is none, the checkpoint
+// file in the data directory.
+func (t *DataTester) storedCheckpoint(ctx context.Context) (*Checkpoint, error) {
+	if t.checkpointWorker != nil {
+		cp, err := t.checkpointWorker.Stored(ctx)
+		if !errors.Is(err, ErrCheckpointNotFound) {
+			return cp, err
+		}
+	}
+
+	return ReadCheckpoint(path.Join(t.dataPath, CheckpointFileName))
+}
+
+// restoreCheckpoint detects if the previous run did not shut
+// down cleanly and, if so (or if --resume is provided), continues
+// its elapsed duration and end condition progress.
+func (t *DataTester) restoreCheckpoint(ctx context.Context) error {
+	if t.checkpointWorker == nil {
+		return nil
+	}
+
+	cp, err := t.checkpointWorker.Stored(ctx)
+	if errors.Is(err, ErrCheckpointNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if cp.Clean && !t.config.Data.Resume {
+		return nil
+	}
+
+	if !cp.Clean {
+		color.Yellow(
+			"previous run did not shut down cleanly, resuming after block %d (%s)",
+			cp.Index,
+			cp.Hash,
+		)
+	}
+
+	t.resumedElapsed = time.Duration(cp.Elapsed) * time.Millisecond
+	t.coverage = cp.Coverage
+	t.endConditions.Restore(cp.EndConditions)
+	color.Cyan(
+		"resuming after %s (reconciled through %d, coverage %f%%, end conditions met: %d)",
+		t.resumedElapsed,
+		cp.ReconciledIndex,
+		cp.Coverage*utils.OneHundred,
+		len(cp.EndConditions),
+	)
+
+	return nil
+}
+
+// progress returns the fields of a *Checkpoint
+// that are not derived from the last processed block.
+func (t *DataTester) progress() *Checkpoint {
+	reconciledIndex := int64(-1)
+	if t.reconciler != nil {
+		reconciledIndex = t.reconciler.LastIndexReconciled()
+	}
+
+	t.coverageLock.Lock()
+	coverage := t.coverage
+	t.coverageLock.Unlock()
+
+	return &Checkpoint{
+		ReconciledIndex: reconciledIndex,
+		Elapsed:         t.elapsed().Milliseconds(),
+		Coverage:        coverage,
+		EndConditions:   t.endConditions.Met(),
+	}
+}
+
+// elapsed returns how long check:data has run,
+// including the runs it resumed from.
+func (t *DataTester) elapsed() time.Duration {
+	if t.startTime.IsZero() {
+		return t.resumedElapsed
+	}
+
+	return t.resumedElapsed + time.Since(t.startTime)
+}
+
+// markClean records that check:data shut down gracefully
+// so the next run does not report an unclean shutdown.
+func (t *DataTester) markClean(ctx context.Context) {
+	if t.checkpointWorker == nil {
+		return
+	}
+
+	if err := t.checkpointWorker.MarkClean(ctx); err != nil {
+		log.Printf("%s: unable to mark clean shutdown\n", err.Error())
+	}
+}
+
+// resumeStartIndex returns the index to resume syncing from
+// using the checkpoint stored with the last committed block
+// (or the checkpoint file in the data directory). If the
+// index is after the head block, -1 is returned.
+func (t *DataTester) resumeStartIndex(ctx context.Context) (int64, error) {
+	cp, err := t.storedCheckpoint(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to resume", err)
+	}
+
+	block, err := t.blockStorage.GetBlock(
+		ctx,
+		&types.PartialBlockIdentifier{Index: &cp.Index},
+	)
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to get checkpoint block %d", err, cp.Index)
+	}
+
+	if block.BlockIdentifier.Hash != cp.Hash {
+		return -1, fmt.Errorf(
+			"%w: checkpoint block %d is %s but stored block is %s",
+			ErrCheckpointMismatch,
+			cp.Index,
+			cp.Hash,
+			block.BlockIdentifier.Hash,
+		)
+	}
+
+	startIndex := cp.ResumeIndex()
+	oldest, err := t.blockStorage.GetOldestBlockIndex(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to get oldest block index", err)
+	}
+
+	if startIndex < oldest {
+		color.Yellow(
+			"blocks %d-%d were pruned, their balance changes will not be reconciled",
+			startIndex,
+			oldest-1,
+		)
+		startIndex = oldest
+	}
+
+	color.Cyan(
+		"resuming from checkpoint at block %d (reconciled through %d), syncing from %d",
+		cp.Index,
+		cp.ReconciledIndex,
+		startIndex,
+	)
+
+	head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	// Blocks after startIndex are removed from
+	// storage (and their balance changes reverted)
+	// before syncing.
+	if startIndex > head.Index {
+		return -1, nil
+	}
+
+	return startIndex, nil
+}
+
+// StartCheckpointing periodically writes the last processed
+// block and reconciliation cursor to the checkpoint file
+// (so that the run can be resumed with --resume) and
+// updates the reconciliation coverage estimate stored
+// with each block.
+func (t *DataTester) StartCheckpointing(
+	ctx context.Context,
+) error {
+	tc := time.NewTicker(CheckpointInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tc.C:
+			if t.balanceStorage != nil {
+				coverage, err := t.balanceStorage.EstimatedReconciliationCoverage(ctx)
+				if err != nil {
+					log.Printf("%s: unable to estimate reconciliation coverage\n", err.Error())
+				} else {
+					t.coverageLock.Lock()
+					t.coverage = coverage
+					t.coverageLock.Unlock()
+				}
+			}
+
+			if err := t.writeCheckpoint(ctx); err != nil {
+				log.Printf("%s: unable to write checkpoint\n", err.Error())
+			}
+		}
+	}
+}
+
+// writeCheckpoint writes the current *Checkpoint.
+func (t *DataTester) writeCheckpoint(ctx context.Context) error {
+	head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, storageErrs.ErrHeadBlockNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	cp := t.progress()
+	cp.Index = head.Index
+	cp.Hash = head.Hash
+	cp.Timestamp = utils.Milliseconds()
+
+	return WriteCheckpoint(path.Join(t.dataPath, CheckpointFileName), cp)
 }
 
 // StartPruning attempts to prune block storage
@@ -403,6 +1301,32 @@ func (t *DataTester) StartPruning(
 	return t.syncer.Prune(ctx, t)
 }
 
+// StartInterestingAccountsWatcher reloads the interesting
+// accounts file on SIGHUP (and every
+// interesting_accounts_reload_interval, if populated).
+func (t *DataTester) StartInterestingAccountsWatcher(
+	ctx context.Context,
+) error {
+	if t.interestingWatcher == nil {
+		return nil
+	}
+
+	return t.interestingWatcher.Start(ctx)
+}
+
+// StartDiskMonitor periodically measures the data
+// directory (if max_data_directory_size_mb is populated)
+// and halts check:data if it exceeds the limit.
+func (t *DataTester) StartDiskMonitor(
+	ctx context.Context,
+) error {
+	if t.diskMonitor == nil {
+		return nil
+	}
+
+	return t.diskMonitor.Start(ctx, DataDirectorySizeCheckInterval)
+}
+
 // StartReconcilerCountUpdater attempts to periodically
 // write cached reconciler count updates to storage.
 func (t *DataTester) StartReconcilerCountUpdater(
@@ -417,11 +1341,7 @@ func (t *DataTester) PruneableIndex(
 	ctx context.Context,
 	headIndex int64,
 ) (int64, error) {
-	// We don't need blocks to exist to reconcile
-	// balances at their index.
-	//
-	// It is ok if the returned value here is negative.
-	return headIndex - int64(t.config.MaxReorgDepth), nil
+	return t.pruneHelper.PruneableIndex(ctx, headIndex)
 }
 
 // StartReconciler starts the reconciler if
@@ -433,7 +1353,7 @@ func (t *DataTester) StartReconciler(
 		return nil
 	}
 
-	return t.reconciler.Reconcile(ctx)
+	return t.reconcilerWorkers.Run(ctx)
 }
 
 // StartPeriodicLogger prints out periodic
@@ -457,6 +1377,9 @@ func (t *DataTester) StartPeriodicLogger(
 				big.NewInt(periodicLoggingSeconds),
 			)
 
+			tip := t.observeHealth(ctx)
+			t.observeThroughput(ctx, tip)
+
 			status := results.ComputeCheckDataStatus(
 				ctx,
 				t.blockStorage,
@@ -465,14 +1388,23 @@ func (t *DataTester) StartPeriodicLogger(
 				t.fetcher,
 				t.config.Network,
 				t.reconciler,
+				t.skewMonitor.Current(),
+				t.commitBatchSize(),
 			)
+			status.Reconciliations = t.reconciliationsProgress(ctx)
 			t.logger.LogDataStatus(ctx, status)
 		}
 	}
 }
 
-// ServeHTTP serves a CheckDataStatus response on all paths.
+// ServeHTTP serves a CheckDataStatus response on all paths
+// except processor.MonitorStopPath.
 func (t *DataTester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == processor.MonitorStopPath {
+		t.stopMonitor(w, r)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 
@@ -484,20 +1416,227 @@ func (t *DataTester) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		t.fetcher,
 		t.network,
 		t.reconciler,
+		t.skewMonitor.Current(),
+		t.commitBatchSize(),
 	)
+	status.Monitor = t.monitor.Results()
+	status.DataDirectorySizeMB = t.diskMonitor.SizeMB()
+	status.BlockFetchConcurrency = blockFetchConcurrency(t.config)
+	status.BlocksInFlight = t.tipPoller.InFlight()
+	status.Reconciliations = t.reconciliationsProgress(r.Context())
+	status.NonFatalErrors = t.nonFatalErrors.Results()
+	status.Throughput = t.throughput.Progress()
 
 	if err := json.NewEncoder(w).Encode(status); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+// CollectMetrics implements the metrics.Collector interface.
+func (t *DataTester) CollectMetrics(ctx context.Context) ([]*metrics.Sample, error) {
+	samples, err := metrics.CounterSamples(ctx, t.counterStorage, metrics.DataCounters)
+	if err != nil {
+		return nil, err
+	}
+
+	syncSamples, err := syncSamples(ctx, t.blockStorage, t.fetcher, t.network, t.dataPath)
+	if err != nil {
+		return nil, err
+	}
+	samples = append(samples, syncSamples...)
+
+	if t.blockTimer != nil {
+		samples = append(
+			samples,
+			&metrics.Sample{Name: metrics.SlowBlocks, Value: float64(t.blockTimer.SlowBlocks())},
+			&metrics.Sample{
+				Name:  metrics.SlowestBlockDuration,
+				Value: t.blockTimer.Slowest().Seconds(),
+			},
+		)
+	}
+
+	return samples, nil
+}
+
+// ApplyConfiguration applies the logging booleans, tip_delay, and
+// reconciler settings of a reloaded configuration to the running
+// check:data. The log_level of the running check:data is kept.
+func (t *DataTester) ApplyConfiguration(config *configuration.Configuration) {
+	t.logger.SetLogCategories(configuration.DataLogCategories(&configuration.Configuration{
+		LogLevel: t.config.LogLevel,
+		Data:     config.Data,
+	}))
+
+	atomic.StoreInt64(&t.config.TipDelay, config.TipDelay)
+	t.healthMonitor.SetStallThreshold(time.Duration(config.TipDelay) * time.Second)
+
+	atomic.StoreUint64(
+		&t.config.Data.InactiveReconciliationFrequency,
+		config.Data.InactiveReconciliationFrequency,
+	)
+	t.reconcilerWorkers.SetConcurrency(
+		int(config.Data.ActiveReconciliationConcurrency),
+		int(config.Data.InactiveReconciliationConcurrency),
+	)
+}
+
+// stopMonitor stops check:data if it is in monitor mode.
+func (t *DataTester) stopMonitor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !t.monitor.Active() || !t.monitor.RequestStop() {
+		http.Error(w, "check:data is not in monitor mode", http.StatusConflict)
+		return
+	}
+
+	color.Cyan("monitor mode stop requested")
+	t.cancel()
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(t.monitor.Results()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// commitBatchSize returns the number of blocks grouped into
+// each storage commit (or 0 if commit batching is disabled).
+func (t *DataTester) commitBatchSize() int {
+	if t.batchingStore == nil {
+		return 0
+	}
+
+	return t.batchingStore.BatchSize()
+}
+
+// observeHealth records the current head block index, tip,
+// and reconciler queue size with the health monitor and
+// returns the observed tip (or -1 if it could not be fetched).
+func (t *DataTester) observeHealth(ctx context.Context) int64 {
+	var tip int64 = -1
+	if staticTip := t.config.Data.StaticTip; staticTip != nil {
+		tip = *staticTip
+		t.healthMonitor.ObserveTip(tip)
+	} else {
+		networkStatus, fetchErr := t.fetcher.NetworkStatus(ctx, t.network, nil)
+		if fetchErr == nil {
+			tip = networkStatus.CurrentBlockIdentifier.Index
+			t.healthMonitor.ObserveTip(tip)
+		}
+	}
+
+	if t.reconciler != nil {
+		t.healthMonitor.ObserveQueue(t.reconciler.QueueSize())
+	}
+
+	head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+	if err != nil {
+		// We still record an observation so that the main
+		// loop is not considered stalled before the first
+		// block is synced.
+		t.healthMonitor.Observe(-1)
+		return tip
+	}
+
+	t.healthMonitor.Observe(head.Index)
+	return tip
+}
+
+// observeThroughput records the current head block index
+// and sync target with the throughput tracker.
+func (t *DataTester) observeThroughput(ctx context.Context, tip int64) {
+	head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+	if err != nil {
+		// No rate can be computed until the
+		// first block is synced.
+		return
+	}
+
+	t.throughput.Observe(time.Now(), head.Index, t.syncTarget(tip))
+}
+
+// syncTarget returns the index check:data is syncing
+// to (or -1 if no index or tip end condition is
+// configured or the tip is unknown).
+func (t *DataTester) syncTarget(tip int64) int64 {
+	endConditions := t.config.Data.EndConditions
+	if endConditions == nil {
+		return -1
+	}
+
+	if endConditions.Index != nil {
+		return *endConditions.Index
+	}
+
+	if endConditions.Tip != nil && *endConditions.Tip {
+		return tip
+	}
+
+	return -1
+}
+
+// StartProgressLogger prints a one-line summary of the
+// throughput of check:data every progress_log_interval.
+func (t *DataTester) StartProgressLogger(
+	ctx context.Context,
+) error {
+	tc := time.NewTicker(
+		time.Duration(t.config.Data.ProgressLogInterval) * time.Second,
+	)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tc.C:
+			t.logger.LogDataProgress(ctx, t.throughput.Progress())
+		}
+	}
+}
+
+// Liveness implements the HealthChecker interface.
+func (t *DataTester) Liveness(ctx context.Context) *HealthStatus {
+	return t.healthMonitor.Liveness()
+}
+
+// Readiness implements the HealthChecker interface.
+func (t *DataTester) Readiness(ctx context.Context) *HealthStatus {
+	var (
+		reachErr error
+		synced   bool
+	)
+
+	_, fetchErr := t.fetcher.NetworkStatus(ctx, t.network, nil)
+	if fetchErr != nil {
+		reachErr = fetchErr.Err
+	} else {
+		atTip, _, err := t.syncedStatus(ctx)
+		synced = err == nil && atTip
+	}
+
+	return t.healthMonitor.Readiness(
+		reachErr,
+		synced,
+		shouldReconcile(t.config),
+	)
+}
+
 // syncedStatus returns a boolean indicating if we are synced to tip and
 // the last synced block.
 func (t *DataTester) syncedStatus(ctx context.Context) (bool, int64, error) {
+	if staticTip := t.config.Data.StaticTip; staticTip != nil {
+		return t.staticTipStatus(ctx, *staticTip)
+	}
+
 	atTip, blockIdentifier, err := utils.CheckStorageTip(
 		ctx,
 		t.network,
-		t.config.TipDelay,
+		t.skewMonitor.AdjustTipDelay(atomic.LoadInt64(&t.config.TipDelay)),
 		t.fetcher,
 		t.blockStorage,
 	)
@@ -514,9 +1653,65 @@ func (t *DataTester) syncedStatus(ctx context.Context) (bool, int64, error) {
 	return atTip, blockIndex, nil
 }
 
-// EndAtTipLoop runs a loop that evaluates end condition EndAtTip
-func (t *DataTester) EndAtTipLoop(
+// staticTipStatus returns a boolean indicating if we have synced
+// staticTip and the last synced block.
+func (t *DataTester) staticTipStatus(ctx context.Context, staticTip int64) (bool, int64, error) {
+	head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, storageErrs.ErrHeadBlockNotFound) {
+		return false, -1, nil
+	}
+	if err != nil {
+		return false, -1, err
+	}
+
+	return head.Index >= staticTip, head.Index, nil
+}
+
+// EndAtTipLoop runs a loop that evaluates end condition EndAtTip
+func (t *DataTester) EndAtTipLoop(
+	ctx context.Context,
+) {
+	tc := time.NewTicker(tipPollInterval(t.config))
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-tc.C:
+			atTip, blockIndex, err := t.syncedStatus(ctx)
+			if err != nil {
+				log.Printf(
+					"%s: unable to evaluate if syncer is at tip",
+					err.Error(),
+				)
+				continue
+			}
+
+			if !atTip {
+				t.endConditions.Unmet(configuration.TipEndCondition)
+				continue
+			}
+
+			if t.endConditionMet(
+				ctx,
+				configuration.TipEndCondition,
+				fmt.Sprintf("Tip: %d", blockIndex),
+				blockIndex,
+			) {
+				return
+			}
+		}
+	}
+}
+
+// EndIndexLoop runs a loop that evaluates end condition
+// EndIndex when the syncer does not stop at the end index
+// (because all end conditions must be met).
+func (t *DataTester) EndIndexLoop(
 	ctx context.Context,
+	endIndex int64,
 ) {
 	tc := time.NewTicker(EndAtTipCheckInterval)
 	defer tc.Stop()
@@ -527,34 +1722,56 @@ func (t *DataTester) EndAtTipLoop(
 			return
 
 		case <-tc.C:
-			atTip, blockIndex, err := t.syncedStatus(ctx)
+			head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
 			if err != nil {
-				log.Printf(
-					"%s: unable to evaluate if syncer is at tip",
-					err.Error(),
-				)
+				if !errors.Is(err, storageErrs.ErrHeadBlockNotFound) {
+					log.Printf("%s: unable to get head block", err.Error())
+				}
+
 				continue
 			}
 
-			if atTip {
-				t.endCondition = configuration.TipEndCondition
-				t.endConditionDetail = fmt.Sprintf(
-					"Tip: %d",
-					blockIndex,
-				)
-				t.cancel()
+			if head.Index < endIndex {
+				continue
+			}
+
+			if t.endConditionMet(
+				ctx,
+				configuration.IndexEndCondition,
+				fmt.Sprintf("Index: %d", endIndex),
+				head.Index,
+			) {
 				return
 			}
 		}
 	}
 }
 
+// reconciliationCoverageIndex returns the index at or above which
+// reconciliations are counted towards reconciliation coverage: the
+// greater of firstTipIndex (if FromTip is enabled) and Index.
+func reconciliationCoverageIndex(
+	reconciliationCoverage *configuration.ReconciliationCoverage,
+	firstTipIndex int64,
+) int64 {
+	coverageIndex := int64(0)
+	if reconciliationCoverage.FromTip {
+		coverageIndex = firstTipIndex
+	}
+
+	if reconciliationCoverage.Index != nil && *reconciliationCoverage.Index > coverageIndex {
+		coverageIndex = *reconciliationCoverage.Index
+	}
+
+	return coverageIndex
+}
+
 // EndReconciliationCoverage runs a loop that evaluates ReconciliationEndCondition
 func (t *DataTester) EndReconciliationCoverage( // nolint:gocognit
 	ctx context.Context,
 	reconciliationCoverage *configuration.ReconciliationCoverage,
 ) {
-	tc := time.NewTicker(EndAtTipCheckInterval)
+	tc := time.NewTicker(tipPollInterval(t.config))
 	defer tc.Stop()
 
 	firstTipIndex := int64(-1)
@@ -581,6 +1798,7 @@ func (t *DataTester) EndReconciliationCoverage( // nolint:gocognit
 				if !atTip {
 					disableForceReconciliation = true
 					firstTipIndex = int64(-1)
+					t.endConditions.Unmet(configuration.ReconciliationCoverageEndCondition)
 					continue
 				}
 
@@ -606,7 +1824,8 @@ func (t *DataTester) EndReconciliationCoverage( // nolint:gocognit
 
 			// Check if at required minimum index
 			if reconciliationCoverage.Index != nil {
-				if *reconciliationCoverage.Index < blockIndex {
+				if blockIndex < *reconciliationCoverage.Index {
+					t.endConditions.Unmet(configuration.ReconciliationCoverageEndCondition)
 					continue
 				}
 
@@ -636,15 +1855,18 @@ func (t *DataTester) EndReconciliationCoverage( // nolint:gocognit
 				}
 
 				if int64(len(allAccounts)) < *reconciliationCoverage.AccountCount {
+					t.endConditions.Unmet(configuration.ReconciliationCoverageEndCondition)
+					color.Cyan(
+						"[END CONDITIONS] Waiting for %d accounts (%d seen) "+
+							"before evaluating reconciliation coverage",
+						*reconciliationCoverage.AccountCount,
+						len(allAccounts),
+					)
 					continue
 				}
 			}
 
-			coverageIndex := int64(0)
-			if reconciliationCoverage.FromTip {
-				coverageIndex = firstTipIndex
-			}
-
+			coverageIndex := reconciliationCoverageIndex(reconciliationCoverage, firstTipIndex)
 			coverage, err := t.balanceStorage.ReconciliationCoverage(ctx, coverageIndex)
 			if err != nil {
 				log.Printf(
@@ -655,18 +1877,23 @@ func (t *DataTester) EndReconciliationCoverage( // nolint:gocognit
 			}
 
 			if coverage >= reconciliationCoverage.Coverage {
-				t.endCondition = configuration.ReconciliationCoverageEndCondition
-				t.endConditionDetail = fmt.Sprintf(
-					"Coverage: %f%%",
-					coverage*utils.OneHundred,
-				)
-				t.cancel()
-				return
+				if t.endConditionMet(
+					ctx,
+					configuration.ReconciliationCoverageEndCondition,
+					fmt.Sprintf("Coverage: %f%%", coverage*utils.OneHundred),
+					blockIndex,
+				) {
+					return
+				}
+
+				continue
 			}
 
+			t.endConditions.Unmet(configuration.ReconciliationCoverageEndCondition)
+
 			color.Cyan(fmt.Sprintf(
 				"[END CONDITIONS] Waiting for reconciliation coverage after block %d (%f%%) to surpass requirement (%f%%)",
-				firstTipIndex,
+				coverageIndex,
 				coverage*utils.OneHundred,
 				reconciliationCoverage.Coverage*utils.OneHundred,
 			))
@@ -675,11 +1902,12 @@ func (t *DataTester) EndReconciliationCoverage( // nolint:gocognit
 }
 
 // EndDurationLoop runs a loop that evaluates end condition EndDuration.
+// The duration includes the time run before check:data was resumed.
 func (t *DataTester) EndDurationLoop(
 	ctx context.Context,
 	duration time.Duration,
 ) {
-	timer := time.NewTimer(duration)
+	timer := time.NewTimer(duration - t.resumedElapsed)
 	defer timer.Stop()
 
 	for {
@@ -688,17 +1916,271 @@ func (t *DataTester) EndDurationLoop(
 			return
 
 		case <-timer.C:
-			t.endCondition = configuration.DurationEndCondition
-			t.endConditionDetail = fmt.Sprintf(
-				"Seconds: %d",
-				int(duration.Seconds()),
+			blockIndex := int64(-1)
+			if head, err := t.blockStorage.GetHeadBlockIdentifier(ctx); err == nil {
+				blockIndex = head.Index
+			}
+
+			t.endConditionMet(
+				ctx,
+				configuration.DurationEndCondition,
+				fmt.Sprintf("Seconds: %d", int(duration.Seconds())),
+				blockIndex,
 			)
-			t.cancel()
 			return
 		}
 	}
 }
 
+// reconciliationsProgress returns the progress of the reconciliations
+// end condition (or nil if it is not configured).
+func (t *DataTester) reconciliationsProgress(ctx context.Context) *results.ReconciliationsProgress {
+	endConds := t.config.Data.EndConditions
+	if endConds == nil || endConds.Reconciliations == nil || t.reconcilerHandler == nil {
+		return nil
+	}
+
+	succeeded, err := t.reconcilerHandler.Succeeded(ctx)
+	if err != nil {
+		log.Printf("%s: unable to get successful reconciliations\n", err.Error())
+		return nil
+	}
+
+	return &results.ReconciliationsProgress{
+		Succeeded: succeeded,
+		Target:    *endConds.Reconciliations,
+	}
+}
+
+// EndReconciliationsLoop runs a loop that evaluates
+// end condition Reconciliations.
+func (t *DataTester) EndReconciliationsLoop(
+	ctx context.Context,
+	reconciliations uint64,
+) {
+	tc := time.NewTicker(EndAtTipCheckInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-tc.C:
+			progress := t.reconciliationsProgress(ctx)
+			if progress == nil || progress.Succeeded < int64(reconciliations) {
+				continue
+			}
+
+			blockIndex := int64(-1)
+			if head, err := t.blockStorage.GetHeadBlockIdentifier(ctx); err == nil {
+				blockIndex = head.Index
+			}
+
+			if t.endConditionMet(
+				ctx,
+				configuration.ReconciliationsEndCondition,
+				fmt.Sprintf("Reconciliations: %d", progress.Succeeded),
+				blockIndex,
+			) {
+				return
+			}
+		}
+	}
+}
+
+// findBlockHash evaluates the block hash end condition
+// against the blocks already in storage.
+func (t *DataTester) findBlockHash(ctx context.Context, hash string, index *int64) error {
+	block, err := t.blockStorage.GetBlockLazy(ctx, &types.PartialBlockIdentifier{Hash: &hash})
+	if err == nil {
+		t.blockHashWatcher.Match(ctx, block.Block.BlockIdentifier)
+		return nil
+	}
+	if !errors.Is(err, storageErrs.ErrBlockNotFound) {
+		return fmt.Errorf("%w: unable to get block %s", err, hash)
+	}
+
+	if index == nil {
+		return nil
+	}
+
+	block, err = t.blockStorage.GetBlockLazy(ctx, &types.PartialBlockIdentifier{Index: index})
+	if errors.Is(err, storageErrs.ErrBlockNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: unable to get block %d", err, *index)
+	}
+
+	return t.blockHashWatcher.Check(block.Block.BlockIdentifier)
+}
+
+// blockHashChanged is called when the block of the block
+// hash end condition is synced (or nil if it is orphaned).
+func (t *DataTester) blockHashChanged(ctx context.Context, block *types.BlockIdentifier) {
+	if block == nil {
+		t.endConditions.Unmet(configuration.BlockHashEndCondition)
+		return
+	}
+
+	// The end condition is evaluated outside of the block
+	// commit because entering monitor mode waits for
+	// reconciliation to complete.
+	go t.endConditionMet(
+		ctx,
+		configuration.BlockHashEndCondition,
+		fmt.Sprintf("Block: %d (%s)", block.Index, block.Hash),
+		block.Index,
+	)
+}
+
+// endConditionMet records that an end condition was met at index
+// and stops check:data if no other end conditions must be met. It
+// returns a boolean indicating if check:data was stopped.
+func (t *DataTester) endConditionMet(
+	ctx context.Context,
+	endCondition configuration.CheckDataEndCondition,
+	endConditionDetail string,
+	index int64,
+) bool {
+	if !t.endConditions.Reached(endCondition, endConditionDetail, index) {
+		color.Cyan(
+			"[END CONDITIONS] %s met at block %d, waiting for %v",
+			endCondition,
+			index,
+			t.endConditions.Waiting(),
+		)
+		return false
+	}
+
+	t.endConditionReached(ctx, endCondition, endConditionDetail)
+	return true
+}
+
+// endConditionReached is called when an end condition is met. check:data
+// is stopped unless monitor_after_end_conditions is enabled, in which
+// case monitor mode is entered instead.
+func (t *DataTester) endConditionReached(
+	ctx context.Context,
+	endCondition configuration.CheckDataEndCondition,
+	endConditionDetail string,
+) {
+	if t.monitor == nil {
+		t.endCondition = endCondition
+		t.endConditionDetail = endConditionDetail
+		t.cancel()
+		return
+	}
+
+	t.monitorOnce.Do(func() {
+		t.enterMonitorMode(ctx, endCondition, endConditionDetail)
+	})
+}
+
+// enterMonitorMode waits for the reconciler backlog to drain, runs the
+// end of run checks, and writes the passing results before entering
+// monitor mode. If any of these fail, check:data is stopped instead.
+func (t *DataTester) enterMonitorMode(
+	ctx context.Context,
+	endCondition configuration.CheckDataEndCondition,
+	endConditionDetail string,
+) {
+	if shouldReconcile(t.config) && t.reconciler.QueueSize() > 0 &&
+		!t.config.Data.ReconciliationDrainDisabled {
+		color.Cyan("draining reconciler backlog before entering monitor mode")
+
+		// If a reconciliation fails while draining, check:data
+		// is stopped and the failure is handled by HandleErr.
+		if err := t.WaitForEmptyQueue(ctx); err != nil {
+			return
+		}
+	}
+
+	var failureHook *results.FailureHookStats
+	if t.failureHook != nil {
+		failureHook = t.failureHook.Stats()
+	}
+
+	blockAudit, err := t.endOfRunChecks(ctx)
+	if err != nil {
+		t.monitorResults, t.monitorErr = results.ExitData(
+			t.config,
+			t.counterStorage,
+			t.balanceStorage,
+			err,
+			"",
+			"",
+			&results.ExitDataOptions{
+				ClockSkew:              t.skewMonitor.Current(),
+				BlockAudit:             blockAudit,
+				TransactionOverrides:   t.overrideResults(ctx),
+				FailureHook:            failureHook,
+				CurrencyCasing:         t.casingResults(),
+				EndpointOverrides:      t.endpointOverrides.Results(),
+				StorageEstimate:        t.storageEstimateResults(),
+				TipPolling:             t.tipPoller.Results(),
+				EndConditionsMet:       t.endConditions.Results(),
+				Comparison:             t.comparisonResults(),
+				CoinTracking:           t.coinTrackingResults(),
+				ReconciliationFailures: t.reconciliationFailures.Results(),
+				BlockTiming:            t.blockTimer.Results(),
+				EndBlock:               t.blockHashWatcher.Matched(),
+				Duration:               t.elapsed(),
+				NonFatalErrors:         t.nonFatalErrors.Results(),
+			},
+		)
+		t.cancel()
+		return
+	}
+
+	t.endCondition = endCondition
+	t.endConditionDetail = endConditionDetail
+	t.monitorResults, _ = results.ExitData(
+		t.config,
+		t.counterStorage,
+		t.balanceStorage,
+		nil,
+		t.endCondition,
+		t.endConditionDetail,
+		&results.ExitDataOptions{
+			ClockSkew:              t.skewMonitor.Current(),
+			BlockAudit:             blockAudit,
+			TransactionOverrides:   t.overrideResults(ctx),
+			FailureHook:            failureHook,
+			CurrencyCasing:         t.casingResults(),
+			EndpointOverrides:      t.endpointOverrides.Results(),
+			StorageEstimate:        t.storageEstimateResults(),
+			TipPolling:             t.tipPoller.Results(),
+			EndConditionsMet:       t.endConditions.Results(),
+			Comparison:             t.comparisonResults(),
+			CoinTracking:           t.coinTrackingResults(),
+			ReconciliationFailures: t.reconciliationFailures.Results(),
+			BlockTiming:            t.blockTimer.Results(),
+			EndBlock:               t.blockHashWatcher.Matched(),
+			Duration:               t.elapsed(),
+			NonFatalErrors:         t.nonFatalErrors.Results(),
+		},
+	)
+
+	t.monitor.Start()
+}
+
+// exitMonitorMode writes the final monitor results once check:data
+// stops in monitor mode. The results of the passing check are never
+// modified.
+func (t *DataTester) exitMonitorMode(err error) error {
+	if *t.signalReceived || t.monitor.StopRequested() {
+		err = nil
+	}
+
+	monitorResults := t.monitor.Stop(err)
+	fmt.Printf("\n")
+	monitorResults.Print()
+
+	return err
+}
+
 // WatchEndConditions starts go routines to watch the end conditions
 func (t *DataTester) WatchEndConditions(
 	ctx context.Context,
@@ -708,6 +2190,10 @@ func (t *DataTester) WatchEndConditions(
 		return nil
 	}
 
+	if (endConds.Tip != nil && *endConds.Tip) || endConds.ReconciliationCoverage != nil {
+		color.Cyan("checking if at tip every %s", tipPollInterval(t.config))
+	}
+
 	if endConds.Tip != nil && *endConds.Tip {
 		// runs a go routine that ends when reaching tip
 		go t.EndAtTipLoop(ctx)
@@ -722,6 +2208,23 @@ func (t *DataTester) WatchEndConditions(
 		go t.EndReconciliationCoverage(ctx, endConds.ReconciliationCoverage)
 	}
 
+	if endConds.Reconciliations != nil {
+		go t.EndReconciliationsLoop(ctx, *endConds.Reconciliations)
+	}
+
+	if endConds.BlockHash != nil {
+		// The block may have been synced
+		// by a previous run.
+		if err := t.findBlockHash(ctx, *endConds.BlockHash, endConds.BlockHashIndex); err != nil {
+			return err
+		}
+	}
+
+	if endConds.Index != nil && t.endConditions.RequireAll() {
+		// runs a go routine that records when the end index is synced
+		go t.EndIndexLoop(ctx, *endConds.Index)
+	}
+
 	return nil
 }
 
@@ -754,7 +2257,7 @@ func (t *DataTester) CompleteReconciliations(ctx context.Context) (int64, error)
 		skippedReconciliations.Int64(), nil
 }
 
-// WaitForEmptyQueue exits once the active reconciler
+// WaitForEmptyQueue returns once the active reconciler
 // queue is empty and all reconciler goroutines are idle.
 func (t *DataTester) WaitForEmptyQueue(
 	ctx context.Context,
@@ -797,7 +2300,6 @@ func (t *DataTester) WaitForEmptyQueue(
 			completed := nowComplete - startingComplete
 			remaining := int64(startingRemaining) - completed
 			if remaining <= 0 {
-				t.cancel()
 				return nil
 			}
 
@@ -823,14 +2325,19 @@ func (t *DataTester) DrainReconcilerQueue(
 	*sigListeners = append(*sigListeners, cancel)
 
 	// Disable inactive lookups
-	t.reconciler.InactiveConcurrency = 0
+	t.reconcilerWorkers.DisableInactive()
 
 	g, ctx := errgroup.WithContext(ctx)
 	g.Go(func() error {
 		return t.StartReconciler(ctx)
 	})
 	g.Go(func() error {
-		return t.WaitForEmptyQueue(ctx)
+		if err := t.WaitForEmptyQueue(ctx); err != nil {
+			return err
+		}
+
+		cancel()
+		return nil
 	})
 
 	err := g.Wait()
@@ -847,22 +2354,263 @@ func (t *DataTester) DrainReconcilerQueue(
 	return err
 }
 
-// HandleErr is called when `check:data` returns an error.
-// If historical balance lookups are enabled, HandleErr will attempt to
-// automatically find any missing balance-changing operations.
-func (t *DataTester) HandleErr(err error, sigListeners *[]context.CancelFunc) error {
+// AuditBlocks re-fetches a random sample of stored blocks and
+// compares them against the blocks stored during the run. If any
+// sampled block diverges, results.ErrBlockAuditFailure is returned.
+func (t *DataTester) AuditBlocks(ctx context.Context) (*results.BlockAuditResults, error) {
+	sampleCount := t.config.Data.EndOfRunBlockAudit.SampleCount
+	color.Cyan("auditing %d randomly sampled stored blocks", sampleCount)
+
+	auditor := NewBlockAuditor(
+		t.network,
+		t.fetcher,
+		t.blockStorage,
+		t.fetcher.Asserter,
+		sampleCount,
+	)
+	auditResults, err := auditor.Audit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to audit stored blocks", err)
+	}
+
+	if !auditResults.Passed() {
+		return auditResults, fmt.Errorf(
+			"%w: %d of %d sampled blocks diverged",
+			results.ErrBlockAuditFailure,
+			len(auditResults.Divergences),
+			len(auditResults.SampledIndexes),
+		)
+	}
+
+	color.Cyan("audited %d stored blocks", len(auditResults.SampledIndexes))
+	return auditResults, nil
+}
+
+// overrideResults returns the *results.TransactionOverridesResults of
+// the run or nil if no transactions are overridden.
+func (t *DataTester) overrideResults(ctx context.Context) *results.TransactionOverridesResults {
+	if t.overrideWorker == nil {
+		return nil
+	}
+
+	overrideResults, err := t.overrideWorker.Results(ctx)
+	if err != nil {
+		log.Printf("%s: unable to compute transaction override results\n", err.Error())
+		return nil
+	}
+
+	return overrideResults
+}
+
+// failureHookStats waits for all reconciliation failure hook
+// invocations to complete and returns the *results.FailureHookStats of the
+// run or nil if no hook is configured.
+func (t *DataTester) failureHookStats() *results.FailureHookStats {
+	if t.failureHook == nil {
+		return nil
+	}
+
+	return t.failureHook.Wait()
+}
+
+// WatchNonFatalErrors halts check:data once more than
+// max_errors non-fatal errors occur.
+func (t *DataTester) WatchNonFatalErrors(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-t.nonFatalErrors.Exceeded():
+		return t.nonFatalErrors.Err()
+	}
+}
+
+// historicalBalanceFetcher returns the *fetcher.Fetcher used to
+// look up balances (archiveFetcher, if populated).
+func historicalBalanceFetcher(
+	fetcher *fetcher.Fetcher,
+	archiveFetcher *fetcher.Fetcher,
+) *fetcher.Fetcher {
+	if archiveFetcher != nil {
+		return archiveFetcher
+	}
+
+	return fetcher
+}
+
+// comparisonResults returns the *results.ComparisonResults of the
+// run (or nil if comparison_url is not populated).
+func (t *DataTester) comparisonResults() *results.ComparisonResults {
+	if t.comparer == nil {
+		return nil
+	}
+
+	return t.comparer.Results()
+}
+
+// coinTrackingResults returns the *results.CoinTrackingResults of the
+// run (or nil if coin tracking is disabled).
+func (t *DataTester) coinTrackingResults() *results.CoinTrackingResults {
+	if t.coinTracker == nil {
+		return nil
+	}
+
+	return t.coinTracker.Results()
+}
+
+// casingResults returns the *results.CurrencyCasingResults of the run
+// or nil if currency_casing_check is disabled.
+func (t *DataTester) casingResults() *results.CurrencyCasingResults {
+	if t.casingChecker == nil {
+		return nil
+	}
+
+	return t.casingChecker.Results()
+}
+
+// endOfRunChecks runs the checks performed once an end condition is
+// reached (the block audit, if configured, and warnings_as_errors).
+func (t *DataTester) endOfRunChecks(ctx context.Context) (*results.BlockAuditResults, error) {
+	var blockAudit *results.BlockAuditResults
+	if t.config.Data.EndOfRunBlockAudit != nil {
+		var err error
+		blockAudit, err = t.AuditBlocks(ctx)
+		if err != nil {
+			return blockAudit, err
+		}
+	}
+
+	currencyCasing := t.casingResults()
+	if t.config.Data.WarningsAsErrors && currencyCasing != nil &&
+		len(currencyCasing.Variants) > 0 {
+		return blockAudit, fmt.Errorf(
+			"%w: %d currency variant(s) found with warnings_as_errors enabled",
+			ErrCurrencyVariants,
+			len(currencyCasing.Variants),
+		)
+	}
+
+	return blockAudit, nil
+}
+
+// writeFailureReport writes a *FailureReport for err to the
+// data directory and returns its path (or an empty string if
+// it could not be written).
+func (t *DataTester) writeFailureReport(ctx context.Context, err error) string {
+	operations := DefaultReportOperations
+	if t.config.Data.FailureReportOperations > 0 {
+		operations = t.config.Data.FailureReportOperations
+	}
+
+	// Storage and the reconciler are not initialized
+	// if check:data halted before syncing started.
+	var storage ReportBlockStorage
+	if t.blockStorage != nil {
+		storage = t.blockStorage
+	}
+
+	var failure *results.DiscrepancyFailure
+	if t.reconcilerHandler != nil {
+		failure = t.reconcilerHandler.HaltFailure
+	}
+
+	r := NewFailureReport(ctx, storage, err, failure, operations)
+	filePath, writeErr := r.Write(t.dataPath)
+	if writeErr != nil {
+		log.Printf("%s\n", writeErr.Error())
+		return ""
+	}
+
+	color.Yellow("Failure report written to %s", filePath)
+	return filePath
+}
+
+// HandleErr is called when `check:data` returns an error and returns
+// the results of check:data. If historical balance lookups are enabled,
+// HandleErr will attempt to automatically find any missing
+// balance-changing operations.
+func (t *DataTester) HandleErr(
+	err error,
+	sigListeners *[]context.CancelFunc,
+) (*results.CheckDataResults, error) {
 	// Initialize new context because calling context
 	// will no longer be usable when after termination.
 	ctx := context.Background()
 
+	// Syncing has stopped, so the stored checkpoint
+	// is the last block check:data will process.
+	t.markClean(ctx)
+
+	if t.monitorErr != nil {
+		return t.monitorResults, t.monitorErr
+	}
+
+	if t.monitor.Active() {
+		return t.monitorResults, t.exitMonitorMode(err)
+	}
+
 	if *t.signalReceived {
 		return results.ExitData(
 			t.config,
 			t.counterStorage,
 			t.balanceStorage,
 			errors.New("check halted"),
-			"",
-			"",
+			configuration.InterruptedEndCondition,
+			"signal received",
+			&results.ExitDataOptions{
+				ClockSkew:              t.skewMonitor.Current(),
+				TransactionOverrides:   t.overrideResults(ctx),
+				FailureHook:            t.failureHookStats(),
+				CurrencyCasing:         t.casingResults(),
+				EndpointOverrides:      t.endpointOverrides.Results(),
+				StorageEstimate:        t.storageEstimateResults(),
+				TipPolling:             t.tipPoller.Results(),
+				EndConditionsMet:       t.endConditions.Results(),
+				Comparison:             t.comparisonResults(),
+				CoinTracking:           t.coinTrackingResults(),
+				ReconciliationFailures: t.reconciliationFailures.Results(),
+				BlockTiming:            t.blockTimer.Results(),
+				EndBlock:               t.blockHashWatcher.Matched(),
+				Duration:               t.elapsed(),
+				NonFatalErrors:         t.nonFatalErrors.Results(),
+			},
+		)
+	}
+
+	// The syncer does not wrap the errors of its handler, so
+	// a halt on a reorg is determined using the tip poller.
+	if orphanedBlock := t.tipPoller.OrphanedBlock(); orphanedBlock != nil {
+		orphanedErr := fmt.Errorf(
+			"%w: block %d (%s)",
+			results.ErrOrphanedBlock,
+			orphanedBlock.Index,
+			orphanedBlock.Hash,
+		)
+		return results.ExitData(
+			t.config,
+			t.counterStorage,
+			t.balanceStorage,
+			orphanedErr,
+			configuration.ReorgEndCondition,
+			fmt.Sprintf("block %d (%s) orphaned", orphanedBlock.Index, orphanedBlock.Hash),
+			&results.ExitDataOptions{
+				ClockSkew:              t.skewMonitor.Current(),
+				TransactionOverrides:   t.overrideResults(ctx),
+				FailureHook:            t.failureHookStats(),
+				CurrencyCasing:         t.casingResults(),
+				EndpointOverrides:      t.endpointOverrides.Results(),
+				StorageEstimate:        t.storageEstimateResults(),
+				TipPolling:             t.tipPoller.Results(),
+				EndConditionsMet:       t.endConditions.Results(),
+				Comparison:             t.comparisonResults(),
+				CoinTracking:           t.coinTrackingResults(),
+				OrphanedBlock:          orphanedBlock,
+				ReconciliationFailures: t.reconciliationFailures.Results(),
+				FailureReport:          t.writeFailureReport(ctx, orphanedErr),
+				BlockTiming:            t.blockTimer.Results(),
+				EndBlock:               t.blockHashWatcher.Matched(),
+				Duration:               t.elapsed(),
+				NonFatalErrors:         t.nonFatalErrors.Results(),
+			},
 		)
 	}
 
@@ -896,11 +2644,60 @@ func (t *DataTester) HandleErr(err error, sigListeners *[]context.CancelFunc) er
 						drainErr,
 						"",
 						"",
+						&results.ExitDataOptions{
+							ClockSkew:              t.skewMonitor.Current(),
+							TransactionOverrides:   t.overrideResults(ctx),
+							FailureHook:            t.failureHookStats(),
+							CurrencyCasing:         t.casingResults(),
+							EndpointOverrides:      t.endpointOverrides.Results(),
+							StorageEstimate:        t.storageEstimateResults(),
+							TipPolling:             t.tipPoller.Results(),
+							EndConditionsMet:       t.endConditions.Results(),
+							Comparison:             t.comparisonResults(),
+							CoinTracking:           t.coinTrackingResults(),
+							ReconciliationFailures: t.reconciliationFailures.Results(),
+							FailureReport:          t.writeFailureReport(ctx, drainErr),
+							BlockTiming:            t.blockTimer.Results(),
+							EndBlock:               t.blockHashWatcher.Matched(),
+							Duration:               t.elapsed(),
+							NonFatalErrors:         t.nonFatalErrors.Results(),
+						},
 					)
 				}
 			}
 		}
 
+		blockAudit, checkErr := t.endOfRunChecks(ctx)
+		if checkErr != nil {
+			return results.ExitData(
+				t.config,
+				t.counterStorage,
+				t.balanceStorage,
+				checkErr,
+				"",
+				"",
+				&results.ExitDataOptions{
+					ClockSkew:              t.skewMonitor.Current(),
+					BlockAudit:             blockAudit,
+					TransactionOverrides:   t.overrideResults(ctx),
+					FailureHook:            t.failureHookStats(),
+					CurrencyCasing:         t.casingResults(),
+					EndpointOverrides:      t.endpointOverrides.Results(),
+					StorageEstimate:        t.storageEstimateResults(),
+					TipPolling:             t.tipPoller.Results(),
+					EndConditionsMet:       t.endConditions.Results(),
+					Comparison:             t.comparisonResults(),
+					CoinTracking:           t.coinTrackingResults(),
+					ReconciliationFailures: t.reconciliationFailures.Results(),
+					FailureReport:          t.writeFailureReport(ctx, checkErr),
+					BlockTiming:            t.blockTimer.Results(),
+					EndBlock:               t.blockHashWatcher.Matched(),
+					Duration:               t.elapsed(),
+					NonFatalErrors:         t.nonFatalErrors.Results(),
+				},
+			)
+		}
+
 		return results.ExitData(
 			t.config,
 			t.counterStorage,
@@ -908,10 +2705,29 @@ func (t *DataTester) HandleErr(err error, sigListeners *[]context.CancelFunc) er
 			nil,
 			t.endCondition,
 			t.endConditionDetail,
+			&results.ExitDataOptions{
+				ClockSkew:              t.skewMonitor.Current(),
+				BlockAudit:             blockAudit,
+				TransactionOverrides:   t.overrideResults(ctx),
+				FailureHook:            t.failureHookStats(),
+				CurrencyCasing:         t.casingResults(),
+				EndpointOverrides:      t.endpointOverrides.Results(),
+				StorageEstimate:        t.storageEstimateResults(),
+				TipPolling:             t.tipPoller.Results(),
+				EndConditionsMet:       t.endConditions.Results(),
+				Comparison:             t.comparisonResults(),
+				CoinTracking:           t.coinTrackingResults(),
+				ReconciliationFailures: t.reconciliationFailures.Results(),
+				BlockTiming:            t.blockTimer.Results(),
+				EndBlock:               t.blockHashWatcher.Matched(),
+				Duration:               t.elapsed(),
+				NonFatalErrors:         t.nonFatalErrors.Results(),
+			},
 		)
 	}
 
 	fmt.Printf("\n")
+	failureReport := t.writeFailureReport(ctx, err)
 	if t.reconcilerHandler.InactiveFailure == nil {
 		return results.ExitData(
 			t.config,
@@ -920,12 +2736,31 @@ func (t *DataTester) HandleErr(err error, sigListeners *[]context.CancelFunc) er
 			err,
 			"",
 			"",
+			&results.ExitDataOptions{
+				ClockSkew:              t.skewMonitor.Current(),
+				TransactionOverrides:   t.overrideResults(ctx),
+				FailureHook:            t.failureHookStats(),
+				CurrencyCasing:         t.casingResults(),
+				EndpointOverrides:      t.endpointOverrides.Results(),
+				StorageEstimate:        t.storageEstimateResults(),
+				TipPolling:             t.tipPoller.Results(),
+				EndConditionsMet:       t.endConditions.Results(),
+				Comparison:             t.comparisonResults(),
+				CoinTracking:           t.coinTrackingResults(),
+				ReconciliationFailures: t.reconciliationFailures.Results(),
+				FailureReport:          failureReport,
+				BlockTiming:            t.blockTimer.Results(),
+				EndBlock:               t.blockHashWatcher.Matched(),
+				Duration:               t.elapsed(),
+				NonFatalErrors:         t.nonFatalErrors.Results(),
+			},
 		)
 	}
 
 	if !t.historicalBalanceEnabled {
 		color.Yellow(
-			"Can't find the block missing operations automatically, please enable historical balance lookup",
+			"Can't find the block missing operations automatically, please enable " +
+				"historical balance lookup (or populate archive_url)",
 		)
 		return results.ExitData(
 			t.config,
@@ -934,6 +2769,24 @@ func (t *DataTester) HandleErr(err error, sigListeners *[]context.CancelFunc) er
 			err,
 			"",
 			"",
+			&results.ExitDataOptions{
+				ClockSkew:              t.skewMonitor.Current(),
+				TransactionOverrides:   t.overrideResults(ctx),
+				FailureHook:            t.failureHookStats(),
+				CurrencyCasing:         t.casingResults(),
+				EndpointOverrides:      t.endpointOverrides.Results(),
+				StorageEstimate:        t.storageEstimateResults(),
+				TipPolling:             t.tipPoller.Results(),
+				EndConditionsMet:       t.endConditions.Results(),
+				Comparison:             t.comparisonResults(),
+				CoinTracking:           t.coinTrackingResults(),
+				ReconciliationFailures: t.reconciliationFailures.Results(),
+				FailureReport:          failureReport,
+				BlockTiming:            t.blockTimer.Results(),
+				EndBlock:               t.blockHashWatcher.Matched(),
+				Duration:               t.elapsed(),
+				NonFatalErrors:         t.nonFatalErrors.Results(),
+			},
 		)
 	}
 
@@ -946,20 +2799,41 @@ func (t *DataTester) HandleErr(err error, sigListeners *[]context.CancelFunc) er
 			err,
 			"",
 			"",
+			&results.ExitDataOptions{
+				ClockSkew:              t.skewMonitor.Current(),
+				TransactionOverrides:   t.overrideResults(ctx),
+				FailureHook:            t.failureHookStats(),
+				CurrencyCasing:         t.casingResults(),
+				EndpointOverrides:      t.endpointOverrides.Results(),
+				StorageEstimate:        t.storageEstimateResults(),
+				TipPolling:             t.tipPoller.Results(),
+				EndConditionsMet:       t.endConditions.Results(),
+				Comparison:             t.comparisonResults(),
+				CoinTracking:           t.coinTrackingResults(),
+				ReconciliationFailures: t.reconciliationFailures.Results(),
+				FailureReport:          failureReport,
+				BlockTiming:            t.blockTimer.Results(),
+				EndBlock:               t.blockHashWatcher.Matched(),
+				Duration:               t.elapsed(),
+				NonFatalErrors:         t.nonFatalErrors.Results(),
+			},
 		)
 	}
 
-	return t.FindMissingOps(ctx, err, sigListeners)
+	return t.FindMissingOps(ctx, err, sigListeners, failureReport)
 }
 
 // FindMissingOps logs the types.BlockIdentifier of a block
 // that is missing balance-changing operations for a
-// *types.AccountCurrency.
+// *types.AccountCurrency and returns the results of
+// check:data. failureReport is the path of the failure
+// report of originalErr (if any).
 func (t *DataTester) FindMissingOps(
 	ctx context.Context,
 	originalErr error,
 	sigListeners *[]context.CancelFunc,
-) error {
+	failureReport string,
+) (*results.CheckDataResults, error) {
 	color.Cyan("Searching for block with missing operations...hold tight")
 	badBlock, err := t.recursiveOpSearch(
 		ctx,
@@ -977,6 +2851,24 @@ func (t *DataTester) FindMissingOps(
 			originalErr,
 			"",
 			"",
+			&results.ExitDataOptions{
+				ClockSkew:              t.skewMonitor.Current(),
+				TransactionOverrides:   t.overrideResults(ctx),
+				FailureHook:            t.failureHookStats(),
+				CurrencyCasing:         t.casingResults(),
+				EndpointOverrides:      t.endpointOverrides.Results(),
+				StorageEstimate:        t.storageEstimateResults(),
+				TipPolling:             t.tipPoller.Results(),
+				EndConditionsMet:       t.endConditions.Results(),
+				Comparison:             t.comparisonResults(),
+				CoinTracking:           t.coinTrackingResults(),
+				ReconciliationFailures: t.reconciliationFailures.Results(),
+				FailureReport:          failureReport,
+				BlockTiming:            t.blockTimer.Results(),
+				EndBlock:               t.blockHashWatcher.Matched(),
+				Duration:               t.elapsed(),
+				NonFatalErrors:         t.nonFatalErrors.Results(),
+			},
 		)
 	}
 
@@ -994,6 +2886,24 @@ func (t *DataTester) FindMissingOps(
 		originalErr,
 		"",
 		"",
+		&results.ExitDataOptions{
+			ClockSkew:              t.skewMonitor.Current(),
+			TransactionOverrides:   t.overrideResults(ctx),
+			FailureHook:            t.failureHookStats(),
+			CurrencyCasing:         t.casingResults(),
+			EndpointOverrides:      t.endpointOverrides.Results(),
+			StorageEstimate:        t.storageEstimateResults(),
+			TipPolling:             t.tipPoller.Results(),
+			EndConditionsMet:       t.endConditions.Results(),
+			Comparison:             t.comparisonResults(),
+			CoinTracking:           t.coinTrackingResults(),
+			ReconciliationFailures: t.reconciliationFailures.Results(),
+			FailureReport:          failureReport,
+			BlockTiming:            t.blockTimer.Results(),
+			EndBlock:               t.blockHashWatcher.Matched(),
+			Duration:               t.elapsed(),
+			NonFatalErrors:         t.nonFatalErrors.Results(),
+		},
 	)
 }
 
@@ -1030,6 +2940,8 @@ func (t *DataTester) recursiveOpSearch(
 		false,
 		false,
 		false,
+		t.config.LogLevel,
+		t.config.LogFormat,
 		logger.Data,
 		t.network,
 	)
@@ -1042,10 +2954,12 @@ func (t *DataTester) recursiveOpSearch(
 	reconcilerHelper := processor.NewReconcilerHelper(
 		t.config,
 		t.network,
-		t.fetcher,
+		t.balanceFetcher,
+		t.skewMonitor,
 		localStore,
 		blockStorage,
 		balanceStorage,
+		counterStorage,
 		t.forceInactiveReconciliation,
 	)
 
@@ -1054,6 +2968,13 @@ func (t *DataTester) recursiveOpSearch(
 		counterStorage,
 		balanceStorage,
 		true, // halt on reconciliation error
+		nil,
+		nil,
+		t.config.Data.AcceptableDiscrepancies,
+		t.config.Data.BalanceExemptions,
+		t.config.Data.BalanceExemptionsCoverage,
+		nil,
+		nil,
 	)
 
 	r := reconciler.New(
@@ -1075,13 +2996,14 @@ func (t *DataTester) recursiveOpSearch(
 
 	balanceStorageHelper := processor.NewBalanceStorageHelper(
 		t.network,
-		t.fetcher,
+		t.balanceFetcher,
 		counterStorage,
 		t.historicalBalanceEnabled,
 		nil,
 		false,
 		t.parser.BalanceExemptions,
 		false, // we will need to perform an initial balance fetch when finding issues
+		t.config.Data.ExcludedOperationTypes,
 	)
 
 	balanceStorageHandler := processor.NewBalanceStorageHandler(
@@ -1090,6 +3012,9 @@ func (t *DataTester) recursiveOpSearch(
 		counterStorage,
 		true,
 		accountCurrency,
+		nil,
+		nil,
+		nil,
 	)
 
 	balanceStorage.Initialize(balanceStorageHelper, balanceStorageHandler)
@@ -1103,8 +3028,8 @@ func (t *DataTester) recursiveOpSearch(
 		logger,
 		cancel,
 		[]modules.BlockWorker{balanceStorage},
-		statefulsyncer.WithCacheSize(syncer.DefaultCacheSize),
-		statefulsyncer.WithMaxConcurrency(t.config.MaxSyncConcurrency),
+		statefulsyncer.WithCacheSize(blockBufferSize(t.config)),
+		statefulsyncer.WithMaxConcurrency(blockFetchConcurrency(t.config)),
 		statefulsyncer.WithPastBlockLimit(t.config.MaxReorgDepth),
 		statefulsyncer.WithSeenConcurrency(int64(t.config.SeenBlockWorkers)),
 	)
@@ -0,0 +1,1430 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/nonfatal"
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/statefulsyncer"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	stallThreshold = 20 * time.Millisecond
+)
+
+var (
+	network = &types.NetworkIdentifier{
+		Blockchain: "bitcoin",
+		Network:    "mainnet",
+	}
+)
+
+func testBlock(index int64) *types.Block {
+	parent := index - 1
+	if parent < 0 {
+		parent = 0
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Index: index,
+			Hash:  fmt.Sprintf("block %d", index),
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: parent,
+			Hash:  fmt.Sprintf("block %d", parent),
+		},
+		Timestamp: utils.Milliseconds(),
+	}
+}
+
+// newHealthDataTester returns a *DataTester with the state used
+// to compute health verdicts. The implementation reports tip as
+// its current block.
+func newHealthDataTester(
+	ctx context.Context,
+	t *testing.T,
+	tip *int64,
+) (*DataTester, func()) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			index := atomic.LoadInt64(tip)
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			assert.NoError(t, json.NewEncoder(w).Encode(&types.NetworkStatusResponse{
+				CurrentBlockIdentifier: testBlock(index).BlockIdentifier,
+				CurrentBlockTimestamp:  utils.Milliseconds(),
+				GenesisBlockIdentifier: testBlock(0).BlockIdentifier,
+				Peers:                  []*types.Peer{},
+			}))
+		}),
+	)
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+
+	blockStorage := modules.NewBlockStorage(db, 1)
+	blockStorage.Initialize([]modules.BlockWorker{})
+
+	// Reconciliation workers are not started, so
+	// queued changes are never dequeued.
+	r := reconciler.New(nil, nil, nil)
+	r.ActiveConcurrency = 0
+	r.InactiveConcurrency = 0
+	go func() {
+		_ = r.Reconcile(ctx)
+	}()
+
+	config := configuration.DefaultConfiguration()
+	config.Network = network
+
+	tester := &DataTester{
+		network:      network,
+		config:       config,
+		blockStorage: blockStorage,
+		reconciler:   r,
+		fetcher:      fetcher.New(ts.URL, fetcher.WithMaxRetries(0)),
+		skewMonitor: processor.NewSkewMonitor(
+			ts.URL,
+			network,
+			http.DefaultClient,
+			false,
+			time.Minute,
+		),
+		healthMonitor: NewHealthMonitor(stallThreshold, time.Hour),
+	}
+
+	return tester, func() {
+		ts.Close()
+		assert.NoError(t, db.Close(ctx))
+		utils.RemoveTempDir(dir)
+	}
+}
+
+func addBlocks(ctx context.Context, t *testing.T, tester *DataTester, start int64, end int64) {
+	for i := start; i <= end; i++ {
+		assert.NoError(t, tester.blockStorage.SeeBlock(ctx, testBlock(i)))
+		assert.NoError(t, tester.blockStorage.AddBlock(ctx, testBlock(i)))
+	}
+}
+
+func failedChecks(status *HealthStatus) []string {
+	failed := []string{}
+	for _, check := range status.Checks {
+		if !check.Passed {
+			failed = append(failed, check.Name)
+		}
+	}
+
+	return failed
+}
+
+func TestDataTesterLiveness(t *testing.T) {
+	var tests = map[string]struct {
+		tip     int64
+		advance bool
+
+		expectedFailed []string
+	}{
+		"syncing": {
+			tip:            10,
+			advance:        true,
+			expectedFailed: []string{},
+		},
+		"at tip": {
+			tip:            2,
+			expectedFailed: []string{},
+		},
+		"stalled behind tip": {
+			tip:            10,
+			expectedFailed: []string{"sync_progress"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tip := test.tip
+			tester, closeTester := newHealthDataTester(ctx, t, &tip)
+			defer closeTester()
+
+			addBlocks(ctx, t, tester, 0, 2)
+			tester.observeHealth(ctx)
+
+			// The tip is learned by the periodic loop, so
+			// liveness does not depend on readiness probes.
+			time.Sleep(2 * stallThreshold)
+			if test.advance {
+				addBlocks(ctx, t, tester, 3, 3)
+			}
+			tester.observeHealth(ctx)
+
+			assert.Equal(t, test.expectedFailed, failedChecks(tester.Liveness(ctx)))
+		})
+	}
+}
+
+func TestDataTesterReadiness(t *testing.T) {
+	var tests = map[string]struct {
+		queued []int
+
+		expectedFailed []string
+	}{
+		"draining": {
+			queued:         []int{2, 2, 0, 1},
+			expectedFailed: []string{},
+		},
+		"backlogged": {
+			queued:         []int{2, 2, 1, 1},
+			expectedFailed: []string{"reconciler_backlog"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tip := int64(2)
+			tester, closeTester := newHealthDataTester(ctx, t, &tip)
+			defer closeTester()
+
+			addBlocks(ctx, t, tester, 0, 2)
+
+			// The reconciler queue grows by the number of
+			// changes queued between each observation.
+			size := 0
+			for i, queued := range test.queued {
+				changes := []*parser.BalanceChange{}
+				for j := 0; j < queued; j++ {
+					changes = append(changes, &parser.BalanceChange{
+						Account: &types.AccountIdentifier{
+							Address: fmt.Sprintf("addr %d %d", i, j),
+						},
+						Currency:   &types.Currency{Symbol: "BTC", Decimals: 8},
+						Block:      testBlock(2).BlockIdentifier,
+						Difference: "1",
+					})
+				}
+
+				block := testBlock(2).BlockIdentifier
+				assert.NoError(t, tester.reconciler.QueueChanges(ctx, block, changes))
+				size += queued
+				assert.Eventually(t, func() bool {
+					return tester.reconciler.QueueSize() == size
+				}, time.Second, time.Millisecond)
+
+				tester.observeHealth(ctx)
+			}
+
+			status := tester.Readiness(ctx)
+			assert.Equal(t, test.expectedFailed, failedChecks(status))
+		})
+	}
+}
+
+func TestDataTesterStopMonitor(t *testing.T) {
+	var tests = map[string]struct {
+		monitor bool
+		started bool
+		method  string
+
+		expectedCode     int
+		expectedCanceled bool
+	}{
+		"monitor mode disabled": {
+			method:       http.MethodPost,
+			expectedCode: http.StatusConflict,
+		},
+		"monitor mode not started": {
+			monitor:      true,
+			method:       http.MethodPost,
+			expectedCode: http.StatusConflict,
+		},
+		"invalid method": {
+			monitor:      true,
+			started:      true,
+			method:       http.MethodGet,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		"stop": {
+			monitor:          true,
+			started:          true,
+			method:           http.MethodPost,
+			expectedCode:     http.StatusOK,
+			expectedCanceled: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tester := &DataTester{cancel: cancel}
+			if test.monitor {
+				tester.monitor = processor.NewFindingsMonitor("")
+			}
+			if test.started {
+				tester.monitor.Start()
+			}
+
+			w := httptest.NewRecorder()
+			tester.ServeHTTP(w, httptest.NewRequest(test.method, processor.MonitorStopPath, nil))
+			assert.Equal(t, test.expectedCode, w.Code)
+			assert.Equal(t, test.expectedCanceled, ctx.Err() != nil)
+			if test.expectedCanceled {
+				assert.True(t, tester.monitor.StopRequested())
+			}
+		})
+	}
+}
+
+func TestDataTesterEndConditionMet(t *testing.T) {
+	index := int64(100)
+	tip := true
+
+	var tests = map[string]struct {
+		requireAll bool
+
+		expectedStopAtTip bool
+	}{
+		"any": {
+			expectedStopAtTip: true,
+		},
+		"require all": {
+			requireAll: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tester := &DataTester{
+				cancel: cancel,
+				endConditions: NewEndConditionsTracker(&configuration.DataEndConditions{
+					Index:      &index,
+					Tip:        &tip,
+					RequireAll: test.requireAll,
+				}),
+			}
+
+			stopped := tester.endConditionMet(ctx, configuration.TipEndCondition, "Tip: 50", 50)
+			assert.Equal(t, test.expectedStopAtTip, stopped)
+			assert.Equal(t, test.expectedStopAtTip, ctx.Err() != nil)
+			if stopped {
+				assert.Equal(t, configuration.TipEndCondition, tester.endCondition)
+				assert.Nil(t, tester.endConditions.Results())
+				return
+			}
+
+			assert.Len(t, tester.endCondition, 0)
+			assert.True(
+				t,
+				tester.endConditionMet(ctx, configuration.IndexEndCondition, "Index: 100", 100),
+			)
+			assert.Error(t, ctx.Err())
+			assert.Equal(t, configuration.IndexEndCondition, tester.endCondition)
+			assert.Equal(t, "Index: 100", tester.endConditionDetail)
+
+			results := tester.endConditions.Results()
+			assert.Len(t, results.Met, 2)
+			assert.Equal(t, configuration.IndexEndCondition, results.Last.Condition)
+			assert.Equal(t, int64(100), results.Last.Index)
+		})
+	}
+}
+
+// newBalanceServer returns an *httptest.Server that serves
+// /account/balance (at block 10) and counts the requests it
+// receives.
+func newBalanceServer(t *testing.T, requests *int64) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/account/balance", r.URL.Path)
+			atomic.AddInt64(requests, 1)
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			assert.NoError(t, json.NewEncoder(w).Encode(&types.AccountBalanceResponse{
+				BlockIdentifier: testBlock(10).BlockIdentifier,
+				Balances: []*types.Amount{
+					{
+						Value:    "100",
+						Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+					},
+				},
+			}))
+		}),
+	)
+}
+
+func TestHistoricalBalanceFetcher(t *testing.T) {
+	var tests = map[string]struct {
+		archive bool
+
+		expectedOnlineRequests  int64
+		expectedArchiveRequests int64
+	}{
+		"no archive": {
+			expectedOnlineRequests: 2,
+		},
+		"archive": {
+			archive:                 true,
+			expectedArchiveRequests: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			account := &types.AccountIdentifier{Address: "addr"}
+			currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+
+			var onlineRequests, archiveRequests int64
+			online := newBalanceServer(t, &onlineRequests)
+			defer online.Close()
+			archive := newBalanceServer(t, &archiveRequests)
+			defer archive.Close()
+
+			onlineFetcher := fetcher.New(online.URL, fetcher.WithMaxRetries(0))
+			var archiveFetcher *fetcher.Fetcher
+			if test.archive {
+				archiveFetcher = fetcher.New(archive.URL, fetcher.WithMaxRetries(0))
+			}
+			balanceFetcher := historicalBalanceFetcher(onlineFetcher, archiveFetcher)
+
+			// Reconciliation lookups
+			reconcilerHelper := processor.NewReconcilerHelper(
+				configuration.DefaultConfiguration(),
+				network,
+				balanceFetcher,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			)
+			amount, block, err := reconcilerHelper.LiveBalance(ctx, account, currency, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, "100", amount.Value)
+			assert.Equal(t, testBlock(10).BlockIdentifier, block)
+
+			// Initial balance lookups
+			balanceStorageHelper := processor.NewBalanceStorageHelper(
+				network,
+				balanceFetcher,
+				nil,
+				true,
+				nil,
+				false,
+				nil,
+				false,
+				nil,
+			)
+			amount, err = balanceStorageHelper.AccountBalance(
+				ctx,
+				account,
+				currency,
+				testBlock(10).BlockIdentifier,
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, "100", amount.Value)
+
+			assert.Equal(t, test.expectedOnlineRequests, atomic.LoadInt64(&onlineRequests))
+			assert.Equal(t, test.expectedArchiveRequests, atomic.LoadInt64(&archiveRequests))
+		})
+	}
+}
+
+func TestMergeAccounts(t *testing.T) {
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	account := func(address string) *types.AccountCurrency {
+		return &types.AccountCurrency{
+			Account:  &types.AccountIdentifier{Address: address},
+			Currency: btc,
+		}
+	}
+
+	var tests = map[string]struct {
+		file   []*types.AccountCurrency
+		inline []*types.AccountCurrency
+
+		expected []*types.AccountCurrency
+	}{
+		"empty": {
+			expected: []*types.AccountCurrency{},
+		},
+		"file only": {
+			file:     []*types.AccountCurrency{account("addr1")},
+			expected: []*types.AccountCurrency{account("addr1")},
+		},
+		"inline only": {
+			inline:   []*types.AccountCurrency{account("addr1")},
+			expected: []*types.AccountCurrency{account("addr1")},
+		},
+		"duplicates": {
+			file:   []*types.AccountCurrency{account("addr1"), account("addr2")},
+			inline: []*types.AccountCurrency{account("addr2"), account("addr3")},
+			expected: []*types.AccountCurrency{
+				account("addr1"),
+				account("addr2"),
+				account("addr3"),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, mergeAccounts(test.file, test.inline))
+		})
+	}
+}
+
+func TestExemptAccounts(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	rebasing := &types.Currency{Symbol: "RBS", Decimals: 18}
+	pair := &types.AccountCurrency{
+		Account:  &types.AccountIdentifier{Address: "addr1"},
+		Currency: btc,
+	}
+
+	filePath := path.Join(dir, "exempt_accounts.json")
+	assert.NoError(t, utils.SerializeAndWrite(filePath, []*types.AccountCurrency{
+		pair,
+		{Currency: rebasing},
+	}))
+
+	accounts, currencies, err := ExemptAccounts(&configuration.DataConfiguration{
+		ExemptAccounts: filePath,
+		ExemptAccountsList: []*types.AccountCurrency{
+			{Currency: rebasing},
+			{Currency: btc},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*types.AccountCurrency{pair}, accounts)
+	assert.Equal(t, []*types.Currency{rebasing, btc}, currencies)
+
+	// Entries without an account or currency are invalid.
+	assert.NoError(t, utils.SerializeAndWrite(filePath, []*types.AccountCurrency{
+		{Account: &types.AccountIdentifier{Address: "addr1"}},
+	}))
+	_, _, err = ExemptAccounts(&configuration.DataConfiguration{ExemptAccounts: filePath})
+	assert.Error(t, err)
+}
+
+func TestLoadBootstrapBalances(t *testing.T) {
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+	balance := func(address string, value string) *modules.BootstrapBalance {
+		return &modules.BootstrapBalance{
+			Account:  &types.AccountIdentifier{Address: address},
+			Currency: btc,
+			Value:    value,
+		}
+	}
+
+	var tests = map[string]struct {
+		files [][]*modules.BootstrapBalance
+
+		expected    []*modules.BootstrapBalance
+		expectedErr bool
+	}{
+		"two files": {
+			files: [][]*modules.BootstrapBalance{
+				{balance("addr1", "100"), balance("addr2", "200")},
+				{balance("addr3", "300")},
+			},
+			expected: []*modules.BootstrapBalance{
+				balance("addr1", "100"),
+				balance("addr2", "200"),
+				balance("addr3", "300"),
+			},
+		},
+		"duplicate across files": {
+			files: [][]*modules.BootstrapBalance{
+				{balance("addr1", "100")},
+				{balance("addr1", "200")},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			filePaths := []string{}
+			for i, balances := range test.files {
+				filePath := path.Join(dir, fmt.Sprintf("bootstrap_%d.json", i))
+				assert.NoError(t, utils.SerializeAndWrite(filePath, balances))
+				filePaths = append(filePaths, filePath)
+			}
+
+			balances, err := loadBootstrapBalances(filePaths)
+			if test.expectedErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), filePaths[0])
+				assert.Contains(t, err.Error(), filePaths[1])
+				assert.Nil(t, balances)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, balances)
+			}
+		})
+	}
+}
+
+// blockStreamLogger implements statefulsyncer.Logger.
+type blockStreamLogger struct{}
+
+func (l *blockStreamLogger) AddBlockStream(context.Context, *types.Block) error {
+	return nil
+}
+
+func (l *blockStreamLogger) RemoveBlockStream(context.Context, *types.BlockIdentifier) error {
+	return nil
+}
+
+func TestDataTesterGenesisIndex(t *testing.T) {
+	var tests = map[string]struct {
+		genesisIndex *int64
+		startIndex   *int64
+		synced       int64
+		checkpoint   *Checkpoint
+
+		expectedStartIndex int64
+		expectedErr        error
+	}{
+		"genesis index": {
+			genesisIndex:       types.Int64(2),
+			expectedStartIndex: 2,
+		},
+		"start index": {
+			genesisIndex:       types.Int64(2),
+			startIndex:         types.Int64(3),
+			expectedStartIndex: 3,
+		},
+		"blocks already synced": {
+			genesisIndex:       types.Int64(2),
+			synced:             3,
+			expectedStartIndex: 4,
+		},
+		"network status genesis": {
+			expectedStartIndex: 0,
+		},
+		"resume from checkpoint": {
+			genesisIndex: types.Int64(2),
+			synced:       4,
+			checkpoint: &Checkpoint{
+				Index:           3,
+				Hash:            "block 3",
+				ReconciledIndex: -1,
+			},
+			expectedStartIndex: 4,
+		},
+		"resume from checkpoint at head": {
+			genesisIndex: types.Int64(2),
+			synced:       4,
+			checkpoint: &Checkpoint{
+				Index:           4,
+				Hash:            "block 4",
+				ReconciledIndex: 4,
+			},
+			expectedStartIndex: 5,
+		},
+		"resume from reconciliation cursor": {
+			genesisIndex: types.Int64(2),
+			synced:       4,
+			checkpoint: &Checkpoint{
+				Index:           4,
+				Hash:            "block 4",
+				ReconciledIndex: 2,
+			},
+			expectedStartIndex: 3,
+		},
+		"resume from mismatched checkpoint": {
+			genesisIndex: types.Int64(2),
+			synced:       4,
+			checkpoint: &Checkpoint{
+				Index:           3,
+				Hash:            "orphaned block 3",
+				ReconciledIndex: -1,
+			},
+			expectedErr: ErrCheckpointMismatch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// The implementation reports 0 as its genesis index
+			// but does not serve blocks before the configured
+			// genesis index.
+			var requestedLock sync.Mutex
+			requested := []int64{}
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+					if r.URL.Path == "/network/status" {
+						assert.NoError(t, json.NewEncoder(w).Encode(&types.NetworkStatusResponse{
+							CurrentBlockIdentifier: testBlock(5).BlockIdentifier,
+							CurrentBlockTimestamp:  utils.Milliseconds(),
+							GenesisBlockIdentifier: testBlock(0).BlockIdentifier,
+							Peers:                  []*types.Peer{},
+						}))
+						return
+					}
+
+					var request types.BlockRequest
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+					index := *request.BlockIdentifier.Index
+
+					requestedLock.Lock()
+					requested = append(requested, index)
+					requestedLock.Unlock()
+
+					assert.NoError(t, json.NewEncoder(w).Encode(&types.BlockResponse{
+						Block: testBlock(index),
+					}))
+				}),
+			)
+			defer ts.Close()
+
+			blockAsserter, err := asserter.NewClientWithOptions(
+				network,
+				testBlock(0).BlockIdentifier,
+				[]string{"transfer"},
+				[]*types.OperationStatus{{Status: "SUCCESS", Successful: true}},
+				[]*types.Error{},
+				nil,
+				&asserter.Validations{Enabled: false},
+			)
+			assert.NoError(t, err)
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			blockStorage := modules.NewBlockStorage(db, 1)
+			blockStorage.Initialize([]modules.BlockWorker{})
+
+			config := configuration.DefaultConfiguration()
+			config.Network = network
+			config.Data.GenesisIndex = test.genesisIndex
+			config.Data.StartIndex = test.startIndex
+			config.Data.EndConditions = &configuration.DataEndConditions{
+				Index: types.Int64(5),
+			}
+
+			f := fetcher.New(
+				ts.URL,
+				fetcher.WithMaxRetries(0),
+				fetcher.WithAsserter(blockAsserter),
+			)
+			statefulSyncer := statefulsyncer.New(
+				ctx,
+				network,
+				f,
+				blockStorage,
+				modules.NewCounterStorage(db),
+				&blockStreamLogger{},
+				func() {},
+				[]modules.BlockWorker{},
+			)
+			tester := &DataTester{
+				network:      network,
+				config:       config,
+				blockStorage: blockStorage,
+				syncer:       statefulSyncer,
+				cancel:       func() {},
+				tipPoller:    NewTipPoller(network, f, statefulSyncer),
+				dataPath:     dir,
+			}
+
+			if test.synced > 0 {
+				for i := int64(2); i <= test.synced; i++ {
+					assert.NoError(t, blockStorage.SeeBlock(ctx, testBlock(i)))
+					assert.NoError(t, blockStorage.AddBlock(ctx, testBlock(i)))
+				}
+			}
+
+			if test.checkpoint != nil {
+				config.Data.Resume = true
+				assert.NoError(t, WriteCheckpoint(
+					path.Join(dir, CheckpointFileName),
+					test.checkpoint,
+				))
+			}
+
+			err = tester.StartSyncing(ctx)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(t, err)
+
+			requestedLock.Lock()
+			defer requestedLock.Unlock()
+			assert.NotEmpty(t, requested)
+			first := requested[0]
+			for _, index := range requested {
+				if index < first {
+					first = index
+				}
+			}
+			assert.Equal(t, test.expectedStartIndex, first)
+
+			head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), head.Index)
+
+			// A checkpoint of the synced blocks
+			// can be resumed from.
+			assert.NoError(t, tester.writeCheckpoint(ctx))
+			cp, err := ReadCheckpoint(path.Join(dir, CheckpointFileName))
+			assert.NoError(t, err)
+			assert.Equal(t, head.Index, cp.Index)
+			assert.Equal(t, head.Hash, cp.Hash)
+			assert.Equal(t, int64(-1), cp.ReconciledIndex)
+
+			tester.config.Data.Resume = true
+			startIndex, err := tester.resumeStartIndex(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(-1), startIndex)
+		})
+	}
+}
+
+func TestDataTesterRestoreCheckpoint(t *testing.T) {
+	duration := uint64(60)
+	index := int64(100)
+
+	var tests = map[string]struct {
+		clean  bool
+		resume bool
+
+		expectedElapsed time.Duration
+		expectedStop    bool
+	}{
+		"clean shutdown": {
+			clean: true,
+		},
+		"clean shutdown with resume": {
+			clean:           true,
+			resume:          true,
+			expectedElapsed: 90 * time.Second,
+			expectedStop:    true,
+		},
+		"unclean shutdown": {
+			expectedElapsed: 90 * time.Second,
+			expectedStop:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			config := configuration.DefaultConfiguration()
+			config.Data.Resume = test.resume
+			config.Data.EndConditions = &configuration.DataEndConditions{
+				Duration:   &duration,
+				Index:      &index,
+				RequireAll: true,
+			}
+
+			// The previous run met the index end condition and
+			// ran for 90 seconds before it was stopped.
+			previous := NewEndConditionsTracker(config.Data.EndConditions)
+			previous.Reached(configuration.IndexEndCondition, "Index: 100", 100)
+			worker := NewCheckpointWorker(db, func() *Checkpoint {
+				return &Checkpoint{
+					ReconciledIndex: -1,
+					Elapsed:         (90 * time.Second).Milliseconds(),
+					EndConditions:   previous.Met(),
+				}
+			})
+			blockStorage := modules.NewBlockStorage(db, 1)
+			blockStorage.Initialize([]modules.BlockWorker{worker})
+			for i := int64(0); i <= 2; i++ {
+				assert.NoError(t, blockStorage.SeeBlock(ctx, testBlock(i)))
+				assert.NoError(t, blockStorage.AddBlock(ctx, testBlock(i)))
+			}
+
+			tester := &DataTester{
+				config:           config,
+				blockStorage:     blockStorage,
+				checkpointWorker: worker,
+				cancel:           cancel,
+				dataPath:         dir,
+				startTime:        time.Now(),
+				endConditions:    NewEndConditionsTracker(config.Data.EndConditions),
+			}
+			if test.clean {
+				tester.markClean(ctx)
+			}
+
+			assert.NoError(t, tester.restoreCheckpoint(ctx))
+			assert.Equal(t, test.expectedElapsed, tester.resumedElapsed)
+			assert.True(t, tester.elapsed() >= test.expectedElapsed)
+
+			// The duration end condition continues from the
+			// elapsed duration of the previous run.
+			durationCtx, durationCancel := context.WithTimeout(ctx, time.Second)
+			defer durationCancel()
+			tester.EndDurationLoop(durationCtx, time.Duration(duration)*time.Second)
+			assert.Equal(t, test.expectedStop, ctx.Err() != nil)
+			if test.expectedStop {
+				assert.Equal(t, configuration.DurationEndCondition, tester.endCondition)
+				assert.Len(t, tester.endConditions.Results().Met, 2)
+			}
+
+			// The stored checkpoint identifies the last
+			// committed block.
+			startIndex, err := tester.resumeStartIndex(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(-1), startIndex)
+		})
+	}
+}
+
+func TestDataTesterBlockHash(t *testing.T) {
+	var tests = map[string]struct {
+		hash        string
+		index       *int64
+		synced      int64
+		beforeWatch bool
+
+		expectedMatched *types.BlockIdentifier
+		expectedErr     error
+	}{
+		"not synced": {
+			hash:   "block 5",
+			synced: 3,
+		},
+		"synced": {
+			hash:            "block 3",
+			synced:          4,
+			expectedMatched: testBlock(3).BlockIdentifier,
+		},
+		"synced by a previous run": {
+			hash:            "block 3",
+			synced:          4,
+			beforeWatch:     true,
+			expectedMatched: testBlock(3).BlockIdentifier,
+		},
+		"other block at expected index": {
+			hash:        "other block 3",
+			index:       types.Int64(3),
+			synced:      4,
+			expectedErr: results.ErrBlockHashNotFound,
+		},
+		"other block at expected index synced by a previous run": {
+			hash:        "other block 3",
+			index:       types.Int64(3),
+			synced:      4,
+			beforeWatch: true,
+			expectedErr: results.ErrBlockHashNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			config := configuration.DefaultConfiguration()
+			config.Data.EndConditions = &configuration.DataEndConditions{
+				BlockHash:      &test.hash,
+				BlockHashIndex: test.index,
+			}
+
+			tester := &DataTester{
+				config:        config,
+				cancel:        cancel,
+				endConditions: NewEndConditionsTracker(config.Data.EndConditions),
+			}
+			tester.blockHashWatcher = NewBlockHashWatcher(
+				test.hash,
+				test.index,
+				tester.blockHashChanged,
+			)
+			tester.blockStorage = modules.NewBlockStorage(db, 1)
+
+			workers := []modules.BlockWorker{tester.blockHashWatcher}
+			if test.beforeWatch {
+				workers = []modules.BlockWorker{}
+			}
+			tester.blockStorage.Initialize(workers)
+
+			for i := int64(0); i <= test.synced; i++ {
+				assert.NoError(t, tester.blockStorage.SeeBlock(ctx, testBlock(i)))
+				err = tester.blockStorage.AddBlock(ctx, testBlock(i))
+				if err != nil {
+					break
+				}
+			}
+
+			if err == nil {
+				err = tester.WatchEndConditions(ctx)
+			}
+
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+				assert.Nil(t, tester.blockHashWatcher.Matched())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedMatched, tester.blockHashWatcher.Matched())
+
+			if test.expectedMatched == nil {
+				assert.NoError(t, ctx.Err())
+				return
+			}
+
+			<-ctx.Done()
+			assert.Equal(t, configuration.BlockHashEndCondition, tester.endCondition)
+			assert.Equal(t, "Block: 3 (block 3)", tester.endConditionDetail)
+		})
+	}
+}
+
+func TestDataTesterStaticTip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	config := configuration.DefaultConfiguration()
+	config.TipPollInterval = 1
+	config.Data.StaticTip = types.Int64(3)
+	config.Data.EndConditions = &configuration.DataEndConditions{
+		Tip: types.Bool(true),
+	}
+
+	// The tester has no fetcher, so tip is never
+	// requested from /network/status.
+	tester := &DataTester{
+		config:        config,
+		cancel:        cancel,
+		endConditions: NewEndConditionsTracker(config.Data.EndConditions),
+		blockStorage:  modules.NewBlockStorage(db, 1),
+	}
+	tester.blockStorage.Initialize([]modules.BlockWorker{})
+
+	atTip, blockIndex, err := tester.syncedStatus(ctx)
+	assert.NoError(t, err)
+	assert.False(t, atTip)
+	assert.Equal(t, int64(-1), blockIndex)
+
+	// Synced blocks are within tip_delay, but
+	// the static tip has not been synced.
+	for i := int64(0); i <= 2; i++ {
+		assert.NoError(t, tester.blockStorage.SeeBlock(ctx, testBlock(i)))
+		assert.NoError(t, tester.blockStorage.AddBlock(ctx, testBlock(i)))
+	}
+
+	atTip, blockIndex, err = tester.syncedStatus(ctx)
+	assert.NoError(t, err)
+	assert.False(t, atTip)
+	assert.Equal(t, int64(2), blockIndex)
+
+	assert.NoError(t, tester.blockStorage.SeeBlock(ctx, testBlock(3)))
+	assert.NoError(t, tester.blockStorage.AddBlock(ctx, testBlock(3)))
+
+	atTip, blockIndex, err = tester.syncedStatus(ctx)
+	assert.NoError(t, err)
+	assert.True(t, atTip)
+	assert.Equal(t, int64(3), blockIndex)
+
+	tester.EndAtTipLoop(ctx)
+	assert.Error(t, ctx.Err())
+	assert.Equal(t, configuration.TipEndCondition, tester.endCondition)
+	assert.Equal(t, "Tip: 3", tester.endConditionDetail)
+}
+
+func TestDataTesterSyncTarget(t *testing.T) {
+	var tests = map[string]struct {
+		endConditions *configuration.DataEndConditions
+		tip           int64
+
+		expected int64
+	}{
+		"no end conditions": {
+			tip:      10,
+			expected: -1,
+		},
+		"index": {
+			endConditions: &configuration.DataEndConditions{
+				Index: types.Int64(5),
+			},
+			tip:      10,
+			expected: 5,
+		},
+		"tip": {
+			endConditions: &configuration.DataEndConditions{
+				Tip: types.Bool(true),
+			},
+			tip:      10,
+			expected: 10,
+		},
+		"unknown tip": {
+			endConditions: &configuration.DataEndConditions{
+				Tip: types.Bool(true),
+			},
+			tip:      -1,
+			expected: -1,
+		},
+		"tip disabled": {
+			endConditions: &configuration.DataEndConditions{
+				Tip: types.Bool(false),
+			},
+			tip:      10,
+			expected: -1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := configuration.DefaultConfiguration()
+			config.Data.EndConditions = test.endConditions
+
+			tester := &DataTester{config: config}
+			assert.Equal(t, test.expected, tester.syncTarget(test.tip))
+		})
+	}
+}
+
+func TestDataTesterWatchNonFatalErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tester := &DataTester{nonFatalErrors: nonfatal.New(2)}
+
+	watchErr := make(chan error)
+	go func() {
+		watchErr <- tester.WatchNonFatalErrors(ctx)
+	}()
+
+	tester.nonFatalErrors.Record(nonfatal.ReconciliationFailure)
+	tester.nonFatalErrors.Record(nonfatal.FetchRetry)
+	select {
+	case <-watchErr:
+		t.Fatal("halted before max_errors was exceeded")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	tester.nonFatalErrors.Record(nonfatal.ReconciliationFailure)
+	assert.True(t, errors.Is(<-watchErr, nonfatal.ErrMaxErrorsExceeded))
+
+	// Without max_errors, the watcher only stops once
+	// the context is canceled.
+	tester = &DataTester{nonFatalErrors: nonfatal.New(0)}
+	go func() {
+		watchErr <- tester.WatchNonFatalErrors(ctx)
+	}()
+
+	tester.nonFatalErrors.Record(nonfatal.ReconciliationFailure)
+	cancel()
+	assert.NoError(t, <-watchErr)
+}
+
+func TestDataTesterInterrupted(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	counterStorage := modules.NewCounterStorage(db)
+	_, err = counterStorage.Update(ctx, modules.BlockCounter, big.NewInt(10))
+	assert.NoError(t, err)
+
+	resultsPath := path.Join(dir, "results.yaml")
+	config := configuration.DefaultConfiguration()
+	config.Data.ResultsOutputFile = resultsPath
+	config.Data.ResultsOutputFormat = configuration.YAMLResultsOutputFormat
+
+	signalReceived := true
+	tester := &DataTester{
+		config:         config,
+		counterStorage: counterStorage,
+		signalReceived: &signalReceived,
+		skewMonitor: processor.NewSkewMonitor(
+			"http://localhost",
+			network,
+			http.DefaultClient,
+			false,
+			time.Minute,
+		),
+	}
+
+	exitResults, err := tester.HandleErr(context.Canceled, &[]context.CancelFunc{})
+	assert.Error(t, err)
+	assert.Equal(t, configuration.InterruptedEndCondition, exitResults.EndCondition.Type)
+
+	// The results are written in the
+	// configured format.
+	output, err := ioutil.ReadFile(resultsPath)
+	assert.NoError(t, err)
+
+	var results struct {
+		EndCondition *struct {
+			Type string `yaml:"type"`
+		} `yaml:"end_condition"`
+		Stats *struct {
+			Blocks int64 `yaml:"blocks"`
+		} `yaml:"stats"`
+	}
+	assert.NoError(t, yaml.Unmarshal(output, &results))
+	assert.Equal(t, string(configuration.InterruptedEndCondition), results.EndCondition.Type)
+	assert.Equal(t, int64(10), results.Stats.Blocks)
+}
+
+func TestDataTesterHaltOnReorg(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	counterStorage := modules.NewCounterStorage(db)
+	_, err = counterStorage.Update(ctx, modules.BlockCounter, big.NewInt(10))
+	assert.NoError(t, err)
+
+	resultsPath := path.Join(dir, "results.json")
+	config := configuration.DefaultConfiguration()
+	config.Data.HaltOnReorg = true
+	config.Data.ResultsOutputFile = resultsPath
+
+	// The syncer orphans block 9 when block 10
+	// does not build on it.
+	orphaned := &types.BlockIdentifier{Index: 9, Hash: "block 9"}
+	tipPoller := NewTipPoller(network, nil, nil, WithHaltOnReorg())
+	syncErr := tipPoller.BlockRemoved(ctx, orphaned)
+	assert.True(t, errors.Is(syncErr, results.ErrOrphanedBlock))
+
+	signalReceived := false
+	tester := &DataTester{
+		config:         config,
+		counterStorage: counterStorage,
+		signalReceived: &signalReceived,
+		tipPoller:      tipPoller,
+		dataPath:       dir,
+		skewMonitor: processor.NewSkewMonitor(
+			"http://localhost",
+			network,
+			http.DefaultClient,
+			false,
+			time.Minute,
+		),
+	}
+
+	_, err = tester.HandleErr(
+		fmt.Errorf("unable to sync to 10: %v", syncErr),
+		&[]context.CancelFunc{},
+	)
+	assert.True(t, errors.Is(err, results.ErrOrphanedBlock))
+
+	var checkResults struct {
+		EndCondition *struct {
+			Type string `json:"type"`
+		} `json:"end_condition"`
+		Tests *struct {
+			BlockSyncing *bool `json:"block_syncing"`
+		} `json:"tests"`
+		OrphanedBlock *types.BlockIdentifier `json:"orphaned_block"`
+		FailureReport string                 `json:"failure_report"`
+	}
+	output, err := ioutil.ReadFile(resultsPath)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(output, &checkResults))
+	assert.Equal(t, string(configuration.ReorgEndCondition), checkResults.EndCondition.Type)
+	assert.False(t, *checkResults.Tests.BlockSyncing)
+	assert.Equal(t, orphaned, checkResults.OrphanedBlock)
+
+	// A failure report is written to the data
+	// directory when check:data halts.
+	assert.Equal(t, path.Join(dir, FailureReportFileName), checkResults.FailureReport)
+	var failureReport FailureReport
+	assert.NoError(t, utils.LoadAndParse(checkResults.FailureReport, &failureReport))
+	assert.Contains(t, failureReport.Error, results.ErrOrphanedBlock.Error())
+	assert.Nil(t, failureReport.Account)
+}
+
+func TestBlockFetchSettings(t *testing.T) {
+	var tests = map[string]struct {
+		concurrency int64
+		bufferSize  uint64
+
+		expectedConcurrency int64
+		expectedBufferSize  int
+	}{
+		"defaults": {
+			expectedConcurrency: configuration.DefaultMaxSyncConcurrency,
+			expectedBufferSize:  syncer.DefaultCacheSize,
+		},
+		"configured": {
+			concurrency:         512,
+			bufferSize:          100,
+			expectedConcurrency: 512,
+			expectedBufferSize:  100 << 20,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := configuration.DefaultConfiguration()
+			config.Data.BlockFetchConcurrency = test.concurrency
+			config.Data.BlockBufferSize = test.bufferSize
+
+			assert.Equal(t, test.expectedConcurrency, blockFetchConcurrency(config))
+			assert.Equal(t, test.expectedBufferSize, blockBufferSize(config))
+		})
+	}
+}
+
+func TestReconciliationCoverageIndex(t *testing.T) {
+	var tests = map[string]struct {
+		reconciliationCoverage *configuration.ReconciliationCoverage
+		firstTipIndex          int64
+
+		expected int64
+	}{
+		"coverage only": {
+			reconciliationCoverage: &configuration.ReconciliationCoverage{Coverage: 0.95},
+			firstTipIndex:          100,
+			expected:               0,
+		},
+		"from tip": {
+			reconciliationCoverage: &configuration.ReconciliationCoverage{
+				Coverage: 0.95,
+				FromTip:  true,
+			},
+			firstTipIndex: 100,
+			expected:      100,
+		},
+		"index": {
+			reconciliationCoverage: &configuration.ReconciliationCoverage{
+				Coverage: 0.95,
+				Index:    types.Int64(50),
+			},
+			firstTipIndex: 100,
+			expected:      50,
+		},
+		"index above tip": {
+			reconciliationCoverage: &configuration.ReconciliationCoverage{
+				Coverage: 0.95,
+				FromTip:  true,
+				Index:    types.Int64(150),
+			},
+			firstTipIndex: 100,
+			expected:      150,
+		},
+		"tip above index": {
+			reconciliationCoverage: &configuration.ReconciliationCoverage{
+				Coverage: 0.95,
+				FromTip:  true,
+				Index:    types.Int64(50),
+			},
+			firstTipIndex: 100,
+			expected:      100,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(
+				t,
+				test.expected,
+				reconciliationCoverageIndex(test.reconciliationCoverage, test.firstTipIndex),
+			)
+		})
+	}
+}
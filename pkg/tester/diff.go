@@ -0,0 +1,154 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+)
+
+const (
+	// MaxFieldDiffs is the maximum number of field differences
+	// reported for a single pair of values.
+	MaxFieldDiffs = 25
+
+	// missingField is used to represent a field that
+	// is not present in one of the compared values.
+	missingField = "<missing>"
+)
+
+// DiffFields returns the fields that differ between the JSON
+// representations of stored and fetched (at most MaxFieldDiffs).
+// Comparing JSON representations ensures fields omitted during
+// serialization (i.e. empty slices vs nil) do not cause spurious
+// differences.
+func DiffFields(stored interface{}, fetched interface{}) ([]*results.FieldDiff, error) {
+	storedValue, err := normalize(stored)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to normalize stored value", err)
+	}
+
+	fetchedValue, err := normalize(fetched)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to normalize fetched value", err)
+	}
+
+	diffs := []*results.FieldDiff{}
+	diffValues("", storedValue, fetchedValue, &diffs)
+
+	return diffs, nil
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+func render(v interface{}) string {
+	if v == nil {
+		return missingField
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(raw)
+}
+
+func joinPath(path string, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func diffValues(path string, stored interface{}, fetched interface{}, diffs *[]*results.FieldDiff) {
+	if len(*diffs) >= MaxFieldDiffs {
+		return
+	}
+
+	storedMap, storedIsMap := stored.(map[string]interface{})
+	fetchedMap, fetchedIsMap := fetched.(map[string]interface{})
+	if storedIsMap && fetchedIsMap {
+		keys := map[string]struct{}{}
+		for k := range storedMap {
+			keys[k] = struct{}{}
+		}
+		for k := range fetchedMap {
+			keys[k] = struct{}{}
+		}
+
+		sortedKeys := make([]string, 0, len(keys))
+		for k := range keys {
+			sortedKeys = append(sortedKeys, k)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, k := range sortedKeys {
+			diffValues(joinPath(path, k), storedMap[k], fetchedMap[k], diffs)
+		}
+
+		return
+	}
+
+	storedSlice, storedIsSlice := stored.([]interface{})
+	fetchedSlice, fetchedIsSlice := fetched.([]interface{})
+	if storedIsSlice && fetchedIsSlice {
+		length := len(storedSlice)
+		if len(fetchedSlice) > length {
+			length = len(fetchedSlice)
+		}
+
+		for i := 0; i < length; i++ {
+			var storedItem, fetchedItem interface{}
+			if i < len(storedSlice) {
+				storedItem = storedSlice[i]
+			}
+			if i < len(fetchedSlice) {
+				fetchedItem = fetchedSlice[i]
+			}
+
+			diffValues(fmt.Sprintf("%s[%d]", path, i), storedItem, fetchedItem, diffs)
+		}
+
+		return
+	}
+
+	if reflect.DeepEqual(stored, fetched) {
+		return
+	}
+
+	*diffs = append(*diffs, &results.FieldDiff{
+		Path:    path,
+		Stored:  render(stored),
+		Fetched: render(fetched),
+	})
+}
@@ -0,0 +1,199 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// bytesInMB is the number of bytes in a MB.
+	diskBytesInMB = 1024 * 1024
+
+	// warningThreshold is the fraction of the limit
+	// at which pruning is escalated.
+	warningThreshold = 0.9
+)
+
+// ErrDataDirectorySizeExceeded is returned when the data
+// directory exceeds its size limit and nothing more
+// can be pruned.
+var ErrDataDirectorySizeExceeded = errors.New("data directory size limit exceeded")
+
+// DiskEscalator tightens pruning when the data
+// directory approaches its size limit.
+type DiskEscalator interface {
+	// Escalate tightens pruning. It returns false if
+	// pruning was already escalated.
+	Escalate() bool
+
+	// PrunedSinceEscalation returns a boolean indicating
+	// if a prune pass has run since pruning was escalated.
+	PrunedSinceEscalation() bool
+}
+
+// DirectorySize returns the total size (in bytes) of
+// all files in path.
+func DirectorySize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Files may be removed by compaction
+			// while we are walking.
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !info.IsDir() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, fmt.Errorf("%w: unable to measure size of %s", err, path)
+	}
+
+	return size, nil
+}
+
+// DiskMonitor periodically measures the size of a
+// data directory and escalates pruning when it
+// approaches its limit.
+type DiskMonitor struct {
+	path      string
+	limit     int64
+	escalator DiskEscalator
+
+	lock     sync.Mutex
+	size     int64
+	measured bool
+}
+
+// NewDiskMonitor returns a new *DiskMonitor for path. If escalator
+// is nil (pruning is disabled), the run is halted as soon
+// as the limit is exceeded.
+func NewDiskMonitor(path string, limitMB uint64, escalator DiskEscalator) *DiskMonitor {
+	return &DiskMonitor{
+		path:      path,
+		limit:     int64(limitMB) * diskBytesInMB,
+		escalator: escalator,
+	}
+}
+
+// SizeMB returns the last measured size of the data
+// directory in MB (or nil if the *DiskMonitor is nil or
+// has not measured the directory yet).
+func (m *DiskMonitor) SizeMB() *int64 {
+	if m == nil {
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.measured {
+		return nil
+	}
+
+	size := m.size / diskBytesInMB
+	return &size
+}
+
+// Check measures the data directory and escalates pruning
+// if it is approaching the limit. It returns
+// ErrDataDirectorySizeExceeded if the limit is exceeded
+// and nothing more can be pruned.
+func (m *DiskMonitor) Check() error {
+	size, err := DirectorySize(m.path)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	previous, measured := m.size, m.measured
+	m.size, m.measured = size, true
+	m.lock.Unlock()
+
+	if float64(size) < float64(m.limit)*warningThreshold {
+		return nil
+	}
+
+	sizeMB, limitMB := size/diskBytesInMB, m.limit/diskBytesInMB
+	if m.escalator != nil && m.escalator.Escalate() {
+		color.Yellow(
+			"[WARNING] data directory is %d MB (limit %d MB): escalating pruning",
+			sizeMB,
+			limitMB,
+		)
+		return nil
+	}
+
+	if size <= m.limit {
+		return nil
+	}
+
+	// Pruned data is only reclaimed once badger garbage
+	// collects its value log (which rosetta-sdk-go does
+	// periodically), so we only give up once the directory
+	// stayed over the limit without shrinking.
+	reclaiming := !measured || previous <= m.limit || size < previous
+	if m.escalator != nil && (!m.escalator.PrunedSinceEscalation() || reclaiming) {
+		color.Yellow(
+			"[WARNING] data directory is %d MB (limit %d MB): waiting for pruning",
+			sizeMB,
+			limitMB,
+		)
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: %s is %d MB (limit %d MB) and nothing more can be pruned",
+		ErrDataDirectorySizeExceeded,
+		m.path,
+		sizeMB,
+		limitMB,
+	)
+}
+
+// Start calls Check every interval until ctx is
+// canceled or the limit is exceeded.
+func (m *DiskMonitor) Start(ctx context.Context, interval time.Duration) error {
+	tc := time.NewTicker(interval)
+	defer tc.Stop()
+
+	for {
+		if err := m.Check(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tc.C:
+		}
+	}
+}
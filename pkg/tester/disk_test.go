@@ -0,0 +1,138 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEscalator struct {
+	escalated bool
+	pruned    bool
+}
+
+func (e *mockEscalator) Escalate() bool {
+	if e.escalated {
+		return false
+	}
+
+	e.escalated = true
+	return true
+}
+
+func (e *mockEscalator) PrunedSinceEscalation() bool {
+	return e.pruned
+}
+
+func writeFile(t *testing.T, dir string, size int) {
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, "data.vlog"), make([]byte, size), 0600))
+}
+
+func TestDirectorySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.Mkdir(path.Join(dir, "nested"), 0700))
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, "a"), make([]byte, 10), 0600))
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, "nested", "b"), make([]byte, 5), 0600))
+
+	size, err := DirectorySize(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), size)
+
+	_, err = DirectorySize(path.Join(dir, "missing"))
+	assert.NoError(t, err)
+}
+
+func TestDiskMonitor(t *testing.T) {
+	var tests = map[string]struct {
+		escalator bool
+		pruned    bool
+
+		sizes       []int
+		expectedErr []bool
+	}{
+		"below threshold": {
+			escalator:   true,
+			sizes:       []int{diskBytesInMB / 2, diskBytesInMB / 2},
+			expectedErr: []bool{false, false},
+		},
+		"escalate then prune": {
+			escalator:   true,
+			pruned:      true,
+			sizes:       []int{diskBytesInMB - 10, diskBytesInMB / 2},
+			expectedErr: []bool{false, false},
+		},
+		"waiting for prune pass": {
+			escalator:   true,
+			sizes:       []int{diskBytesInMB - 10, diskBytesInMB + 10, diskBytesInMB + 10},
+			expectedErr: []bool{false, false, false},
+		},
+		"still shrinking": {
+			escalator:   true,
+			pruned:      true,
+			sizes:       []int{diskBytesInMB - 10, diskBytesInMB + 20, diskBytesInMB + 10},
+			expectedErr: []bool{false, false, false},
+		},
+		"nothing more to prune": {
+			escalator:   true,
+			pruned:      true,
+			sizes:       []int{diskBytesInMB - 10, diskBytesInMB + 10, diskBytesInMB + 10},
+			expectedErr: []bool{false, false, true},
+		},
+		"pruning disabled": {
+			sizes:       []int{diskBytesInMB - 10, diskBytesInMB + 10},
+			expectedErr: []bool{false, true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			var escalator DiskEscalator
+			if test.escalator {
+				escalator = &mockEscalator{pruned: test.pruned}
+			}
+
+			monitor := NewDiskMonitor(dir, 1, escalator)
+			assert.Nil(t, monitor.SizeMB())
+
+			for i, size := range test.sizes {
+				writeFile(t, dir, size)
+				err := monitor.Check()
+				if test.expectedErr[i] {
+					assert.True(t, errors.Is(err, ErrDataDirectorySizeExceeded))
+				} else {
+					assert.NoError(t, err)
+				}
+
+				assert.Equal(t, int64(size/diskBytesInMB), *monitor.SizeMB())
+			}
+		})
+	}
+
+	var nilMonitor *DiskMonitor
+	assert.Nil(t, nilMonitor.SizeMB())
+}
@@ -0,0 +1,225 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+)
+
+// EndConditionsTracker determines when check:data should stop
+// given the end conditions that have been met.
+// Without require_all, check:data stops once any
+// end condition is met. With require_all, it stops
+// once all configured end conditions are met at
+// the same time.
+type EndConditionsTracker struct {
+	requireAll bool
+	configured []configuration.CheckDataEndCondition
+
+	// met is ordered by when each
+	// condition was met.
+	met  []*results.EndConditionStatus
+	last *results.EndConditionStatus
+	lock sync.Mutex
+}
+
+// NewEndConditionsTracker returns a *EndConditionsTracker for endConditions.
+func NewEndConditionsTracker(endConditions *configuration.DataEndConditions) *EndConditionsTracker {
+	t := &EndConditionsTracker{}
+	if endConditions == nil {
+		return t
+	}
+
+	t.requireAll = endConditions.RequireAll
+	t.configured = ConfiguredEndConditions(endConditions)
+	return t
+}
+
+// ConfiguredEndConditions returns the end conditions
+// populated in endConditions.
+func ConfiguredEndConditions(
+	endConditions *configuration.DataEndConditions,
+) []configuration.CheckDataEndCondition {
+	configured := []configuration.CheckDataEndCondition{}
+	if endConditions.Index != nil {
+		configured = append(configured, configuration.IndexEndCondition)
+	}
+
+	if endConditions.Tip != nil && *endConditions.Tip {
+		configured = append(configured, configuration.TipEndCondition)
+	}
+
+	if endConditions.Duration != nil && *endConditions.Duration != 0 {
+		configured = append(configured, configuration.DurationEndCondition)
+	}
+
+	if endConditions.ReconciliationCoverage != nil {
+		configured = append(configured, configuration.ReconciliationCoverageEndCondition)
+	}
+
+	if endConditions.BlockHash != nil {
+		configured = append(configured, configuration.BlockHashEndCondition)
+	}
+
+	if endConditions.Reconciliations != nil {
+		configured = append(configured, configuration.ReconciliationsEndCondition)
+	}
+
+	return configured
+}
+
+// RequireAll returns a boolean indicating if all configured
+// end conditions must be met for check:data to stop (and
+// there is more than one of them).
+func (t *EndConditionsTracker) RequireAll() bool {
+	return t != nil && t.requireAll && len(t.configured) > 1
+}
+
+// Reached records that condition was met at index and
+// returns a boolean indicating if check:data should stop.
+func (t *EndConditionsTracker) Reached(
+	condition configuration.CheckDataEndCondition,
+	detail string,
+	index int64,
+) bool {
+	if !t.RequireAll() {
+		return true
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Conditions that remain met are recorded
+	// when they were first met.
+	if t.find(condition) < 0 {
+		t.last = &results.EndConditionStatus{
+			Condition: condition,
+			Detail:    detail,
+			Index:     index,
+			Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+		}
+		t.met = append(t.met, t.last)
+	}
+
+	return len(t.met) == len(t.configured)
+}
+
+// Unmet records that a previously met condition
+// (like being at tip) is no longer met.
+func (t *EndConditionsTracker) Unmet(condition configuration.CheckDataEndCondition) {
+	if !t.RequireAll() {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if i := t.find(condition); i >= 0 {
+		t.met = append(t.met[:i], t.met[i+1:]...)
+	}
+}
+
+// find returns the position of condition
+// in t.met (or -1 if it is not met).
+func (t *EndConditionsTracker) find(condition configuration.CheckDataEndCondition) int {
+	for i, met := range t.met {
+		if met.Condition == condition {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Waiting returns the configured end
+// conditions that are not met.
+func (t *EndConditionsTracker) Waiting() []configuration.CheckDataEndCondition {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	waiting := []configuration.CheckDataEndCondition{}
+	for _, condition := range t.configured {
+		if t.find(condition) < 0 {
+			waiting = append(waiting, condition)
+		}
+	}
+
+	return waiting
+}
+
+// Met returns the end conditions met so far (or
+// nil if require_all is not enabled).
+func (t *EndConditionsTracker) Met() []*results.EndConditionStatus {
+	if !t.RequireAll() {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return append([]*results.EndConditionStatus{}, t.met...)
+}
+
+// Restore records the end conditions met by a
+// previous run (as returned by Met). Conditions
+// that are no longer configured are ignored.
+func (t *EndConditionsTracker) Restore(met []*results.EndConditionStatus) {
+	if !t.RequireAll() {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, condition := range met {
+		if t.find(condition.Condition) >= 0 {
+			continue
+		}
+
+		for _, configured := range t.configured {
+			if configured == condition.Condition {
+				t.met = append(t.met, condition)
+				t.last = condition
+				break
+			}
+		}
+	}
+}
+
+// EndConditionsResults returns the *EndConditionsResults of t (or nil
+// if require_all is not enabled).
+func (t *EndConditionsTracker) Results() *results.EndConditionsResults {
+	if !t.RequireAll() {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	conditionsResults := &results.EndConditionsResults{Met: append([]*results.EndConditionStatus{}, t.met...)}
+	if len(conditionsResults.Met) == len(t.configured) {
+		conditionsResults.Last = t.last
+	}
+
+	return conditionsResults
+}
@@ -0,0 +1,181 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	condition configuration.CheckDataEndCondition
+	unmet     bool
+	index     int64
+}
+
+func TestEndConditionsTracker(t *testing.T) {
+	index := int64(100)
+	tip := true
+
+	var tests = map[string]struct {
+		endConditions *configuration.DataEndConditions
+		events        []*event
+
+		expectedRequireAll bool
+		expectedStop       []bool
+		expectedLast       configuration.CheckDataEndCondition
+	}{
+		"any": {
+			endConditions: &configuration.DataEndConditions{
+				Index: &index,
+				Tip:   &tip,
+			},
+			events: []*event{
+				{condition: configuration.TipEndCondition, index: 50},
+			},
+			expectedStop: []bool{true},
+		},
+		"require all with one end condition": {
+			endConditions: &configuration.DataEndConditions{
+				Index:      &index,
+				RequireAll: true,
+			},
+			events: []*event{
+				{condition: configuration.IndexEndCondition, index: 100},
+			},
+			expectedStop: []bool{true},
+		},
+		"require all": {
+			endConditions: &configuration.DataEndConditions{
+				Index:      &index,
+				Tip:        &tip,
+				RequireAll: true,
+			},
+			events: []*event{
+				{condition: configuration.TipEndCondition, index: 50},
+				{condition: configuration.TipEndCondition, index: 60},
+				{condition: configuration.IndexEndCondition, index: 100},
+			},
+			expectedRequireAll: true,
+			expectedStop:       []bool{false, false, true},
+			expectedLast:       configuration.IndexEndCondition,
+		},
+		"require all with fall behind tip": {
+			endConditions: &configuration.DataEndConditions{
+				Index:      &index,
+				Tip:        &tip,
+				RequireAll: true,
+			},
+			events: []*event{
+				{condition: configuration.TipEndCondition, index: 50},
+				{condition: configuration.TipEndCondition, unmet: true},
+				{condition: configuration.IndexEndCondition, index: 100},
+				{condition: configuration.TipEndCondition, index: 110},
+			},
+			expectedRequireAll: true,
+			expectedStop:       []bool{false, false, false, true},
+			expectedLast:       configuration.TipEndCondition,
+		},
+		"require all not met": {
+			endConditions: &configuration.DataEndConditions{
+				Index:      &index,
+				Tip:        &tip,
+				RequireAll: true,
+			},
+			events: []*event{
+				{condition: configuration.IndexEndCondition, index: 100},
+			},
+			expectedRequireAll: true,
+			expectedStop:       []bool{false},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := NewEndConditionsTracker(test.endConditions)
+			assert.Equal(t, test.expectedRequireAll, tracker.RequireAll())
+
+			stop := []bool{}
+			for _, e := range test.events {
+				if e.unmet {
+					tracker.Unmet(e.condition)
+					stop = append(stop, false)
+					continue
+				}
+
+				stop = append(stop, tracker.Reached(e.condition, string(e.condition), e.index))
+			}
+			assert.Equal(t, test.expectedStop, stop)
+
+			results := tracker.Results()
+			if !test.expectedRequireAll {
+				assert.Nil(t, results)
+				return
+			}
+
+			if len(test.expectedLast) == 0 {
+				assert.Nil(t, results.Last)
+				assert.Len(t, tracker.Waiting(), 1)
+				return
+			}
+
+			assert.Len(t, tracker.Waiting(), 0)
+			assert.Len(t, results.Met, 2)
+			assert.Equal(t, test.expectedLast, results.Last.Condition)
+			assert.Equal(t, results.Last, results.Met[len(results.Met)-1])
+
+			var b bytes.Buffer
+			results.Fprint(&b)
+			assert.Contains(t, b.String(), "(last)")
+		})
+	}
+}
+
+func TestEndConditionsTrackerRestore(t *testing.T) {
+	index := int64(100)
+	tip := true
+	endConditions := &configuration.DataEndConditions{
+		Index:      &index,
+		Tip:        &tip,
+		RequireAll: true,
+	}
+
+	previous := NewEndConditionsTracker(endConditions)
+	assert.False(t, previous.Reached(configuration.IndexEndCondition, "Index: 100", 100))
+	met := previous.Met()
+	assert.Len(t, met, 1)
+
+	// Conditions that are no longer configured
+	// are not restored.
+	tracker := NewEndConditionsTracker(endConditions)
+	tracker.Restore(append(met, &results.EndConditionStatus{Condition: configuration.DurationEndCondition}))
+	assert.Equal(t, []configuration.CheckDataEndCondition{
+		configuration.TipEndCondition,
+	}, tracker.Waiting())
+	assert.Equal(t, met, tracker.Met())
+
+	assert.True(t, tracker.Reached(configuration.TipEndCondition, "Tip", 110))
+	assert.Equal(t, configuration.TipEndCondition, tracker.Results().Last.Condition)
+
+	// Without require_all, nothing is tracked.
+	anyTracker := NewEndConditionsTracker(&configuration.DataEndConditions{Index: &index})
+	anyTracker.Restore(met)
+	assert.Nil(t, anyTracker.Met())
+}
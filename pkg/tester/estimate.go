@@ -0,0 +1,194 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage/encoder"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// DefaultEstimateSamples is the number of blocks sampled if
+	// storage_estimate_samples is not populated.
+	DefaultEstimateSamples = 20
+
+	// blockNamespace is the namespace used when
+	// encoding sampled blocks.
+	blockNamespace = "block"
+
+	// indexOverhead is the approximate fraction of block
+	// storage used for block and transaction hash indexes.
+	indexOverhead = 0.1
+
+	// balanceEntryBytes is the approximate storage used for
+	// each balance change (the current balance of the account
+	// and an entry for its historical balance).
+	balanceEntryBytes = 200
+
+	// coinEntryBytes is the approximate storage used
+	// for each coin created or spent.
+	coinEntryBytes = 150
+)
+
+// EstimateSampleIndexes returns up to samples indexes
+// evenly spread across [startIndex, endIndex].
+func EstimateSampleIndexes(startIndex int64, endIndex int64, samples int) []int64 {
+	indexes := []int64{}
+	if samples <= 0 || endIndex < startIndex {
+		return indexes
+	}
+
+	blocks := endIndex - startIndex + 1
+	if blocks <= int64(samples) {
+		for i := startIndex; i <= endIndex; i++ {
+			indexes = append(indexes, i)
+		}
+
+		return indexes
+	}
+
+	if samples == 1 {
+		return []int64{startIndex}
+	}
+
+	for i := int64(0); i < int64(samples); i++ {
+		indexes = append(indexes, startIndex+i*(blocks-1)/int64(samples-1))
+	}
+
+	return indexes
+}
+
+// SampleEstimateInputs fetches blocks across [startIndex, endIndex]
+// and measures the EstimateInputs of an StorageEstimate.
+func SampleEstimateInputs(
+	ctx context.Context,
+	f *fetcher.Fetcher,
+	network *types.NetworkIdentifier,
+	startIndex int64,
+	endIndex int64,
+	samples int,
+	compress bool,
+) (*results.EstimateInputs, error) {
+	indexes := EstimateSampleIndexes(startIndex, endIndex, samples)
+	blocks := make([]*types.Block, len(indexes))
+	for i, index := range indexes {
+		blockIndex := index
+		block, fetchErr := f.BlockRetry(
+			ctx,
+			network,
+			&types.PartialBlockIdentifier{Index: &blockIndex},
+		)
+		if fetchErr != nil {
+			return nil, fmt.Errorf("%w: unable to fetch block %d", fetchErr.Err, index)
+		}
+
+		blocks[i] = block
+	}
+
+	inputs, err := MeasureEstimateInputs(blocks, compress)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs.StartIndex = startIndex
+	inputs.EndIndex = endIndex
+	return inputs, nil
+}
+
+// MeasureEstimateInputs returns the EstimateInputs of an StorageEstimate
+// measured from sampled blocks.
+func MeasureEstimateInputs(blocks []*types.Block, compress bool) (*results.EstimateInputs, error) {
+	blockEncoder, err := encoder.NewEncoder(nil, encoder.NewBufferPool(), compress)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to initialize encoder", err)
+	}
+
+	inputs := &results.EstimateInputs{SampledBlocks: []int64{}, Compressed: compress}
+	var serializedBytes, encodedBytes, accounts, coinChanges int64
+	for _, block := range blocks {
+		if block == nil { // omitted blocks are not stored
+			continue
+		}
+
+		serialized := types.PrintStruct(block)
+		encoded, err := blockEncoder.Encode(blockNamespace, block)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to encode block %d", err, block.BlockIdentifier.Index)
+		}
+
+		serializedBytes += int64(len(serialized))
+		encodedBytes += int64(len(encoded))
+
+		changed := map[string]struct{}{}
+		for _, tx := range block.Transactions {
+			for _, op := range tx.Operations {
+				if op.CoinChange != nil {
+					coinChanges++
+				}
+
+				if op.Account == nil || op.Amount == nil {
+					continue
+				}
+
+				changed[types.Hash(&types.AccountCurrency{
+					Account:  op.Account,
+					Currency: op.Amount.Currency,
+				})] = struct{}{}
+			}
+		}
+
+		accounts += int64(len(changed))
+		inputs.SampledBlocks = append(inputs.SampledBlocks, block.BlockIdentifier.Index)
+	}
+
+	sampled := int64(len(inputs.SampledBlocks))
+	if sampled == 0 {
+		return inputs, nil
+	}
+
+	inputs.AverageSerializedBlockBytes = serializedBytes / sampled
+	inputs.AverageEncodedBlockBytes = encodedBytes / sampled
+	inputs.AccountsPerBlock = float64(accounts) / float64(sampled)
+	inputs.CoinChangesPerBlock = float64(coinChanges) / float64(sampled)
+	return inputs, nil
+}
+
+// NewStorageEstimate returns the *StorageEstimate extrapolated from inputs.
+func NewStorageEstimate(inputs *results.EstimateInputs) *results.StorageEstimate {
+	blocks := float64(inputs.EndIndex - inputs.StartIndex + 1)
+	if blocks < 0 {
+		blocks = 0
+	}
+
+	estimate := &results.StorageEstimate{
+		Inputs: inputs,
+		BlockStorageBytes: int64(
+			blocks * float64(inputs.AverageEncodedBlockBytes) * (1 + indexOverhead),
+		),
+		BalanceStorageBytes: int64(blocks * inputs.AccountsPerBlock * balanceEntryBytes),
+		CoinStorageBytes:    int64(blocks * inputs.CoinChangesPerBlock * coinEntryBytes),
+	}
+	estimate.TotalBytes = estimate.BlockStorageBytes +
+		estimate.BalanceStorageBytes +
+		estimate.CoinStorageBytes
+
+	return estimate
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateSampleIndexes(t *testing.T) {
+	var tests = map[string]struct {
+		startIndex int64
+		endIndex   int64
+		samples    int
+
+		expected []int64
+	}{
+		"no samples": {
+			startIndex: 0,
+			endIndex:   100,
+			expected:   []int64{},
+		},
+		"empty range": {
+			startIndex: 10,
+			endIndex:   9,
+			samples:    5,
+			expected:   []int64{},
+		},
+		"fewer blocks than samples": {
+			startIndex: 10,
+			endIndex:   12,
+			samples:    5,
+			expected:   []int64{10, 11, 12},
+		},
+		"single sample": {
+			startIndex: 10,
+			endIndex:   100,
+			samples:    1,
+			expected:   []int64{10},
+		},
+		"spread across range": {
+			startIndex: 0,
+			endIndex:   100,
+			samples:    5,
+			expected:   []int64{0, 25, 50, 75, 100},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(
+				t,
+				test.expected,
+				EstimateSampleIndexes(test.startIndex, test.endIndex, test.samples),
+			)
+		})
+	}
+}
+
+func estimateBlock(index int64, accounts int, coins int) *types.Block {
+	currency := &types.Currency{Symbol: "BTC", Decimals: 8}
+	ops := []*types.Operation{}
+	for i := 0; i < accounts; i++ {
+		op := &types.Operation{
+			OperationIdentifier: &types.OperationIdentifier{Index: int64(len(ops))},
+			Type:                "Transfer",
+			Status:              types.String("Success"),
+			Account:             &types.AccountIdentifier{Address: fmt.Sprintf("addr%d", i)},
+			Amount:              &types.Amount{Value: "100", Currency: currency},
+		}
+		if i < coins {
+			op.CoinChange = &types.CoinChange{
+				CoinIdentifier: &types.CoinIdentifier{Identifier: fmt.Sprintf("coin%d", i)},
+				CoinAction:     types.CoinCreated,
+			}
+		}
+
+		// Multiple operations on the same account
+		// only change its balance once.
+		ops = append(ops, op, &types.Operation{
+			OperationIdentifier: &types.OperationIdentifier{Index: int64(len(ops) + 1)},
+			Type:                "Fee",
+			Status:              types.String("Success"),
+			Account:             op.Account,
+			Amount:              &types.Amount{Value: "-1", Currency: currency},
+		})
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Index: index, Hash: fmt.Sprintf("block %d", index)},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: index - 1,
+			Hash:  fmt.Sprintf("block %d", index-1),
+		},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx"},
+				Operations:            ops,
+			},
+		},
+	}
+}
+
+func TestStorageEstimate(t *testing.T) {
+	blocks := []*types.Block{estimateBlock(0, 2, 2), nil, estimateBlock(100, 4, 0)}
+
+	inputs, err := MeasureEstimateInputs(blocks, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0, 100}, inputs.SampledBlocks)
+	assert.False(t, inputs.Compressed)
+	assert.Equal(t, 3.0, inputs.AccountsPerBlock)
+	assert.Equal(t, 1.0, inputs.CoinChangesPerBlock)
+	assert.Greater(t, inputs.AverageEncodedBlockBytes, int64(0))
+	assert.Greater(t, inputs.AverageSerializedBlockBytes, int64(0))
+
+	compressed, err := MeasureEstimateInputs(blocks, true)
+	assert.NoError(t, err)
+	assert.True(t, compressed.Compressed)
+	assert.Less(t, compressed.AverageEncodedBlockBytes, inputs.AverageEncodedBlockBytes)
+
+	inputs.StartIndex = 0
+	inputs.EndIndex = 999
+	estimate := NewStorageEstimate(inputs)
+	assert.Equal(
+		t,
+		int64(1000*float64(inputs.AverageEncodedBlockBytes)*(1+indexOverhead)),
+		estimate.BlockStorageBytes,
+	)
+	assert.Equal(t, int64(1000*3*balanceEntryBytes), estimate.BalanceStorageBytes)
+	assert.Equal(t, int64(1000*coinEntryBytes), estimate.CoinStorageBytes)
+	assert.Equal(
+		t,
+		estimate.BlockStorageBytes+estimate.BalanceStorageBytes+estimate.CoinStorageBytes,
+		estimate.TotalBytes,
+	)
+
+	// Without a measurement of available disk
+	// space, an estimate is never insufficient.
+	assert.NoError(t, estimate.Check())
+
+	available := estimate.TotalBytes
+	estimate.AvailableBytes = &available
+	assert.NoError(t, estimate.Check())
+
+	available = estimate.TotalBytes - 1
+	assert.True(t, errors.Is(estimate.Check(), results.ErrInsufficientStorage))
+
+	estimate.ActualBytes = &available
+	estimate.Print() // make sure doesn't panic
+}
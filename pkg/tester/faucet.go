@@ -0,0 +1,236 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/constructor/job"
+	"github.com/coinbase/rosetta-sdk-go/constructor/worker"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+const (
+	// addressPlaceholder is replaced with the address
+	// to fund in the request template.
+	addressPlaceholder = "{{address}}"
+)
+
+var (
+	// ErrUnexpectedFaucetResponse is returned when a faucet
+	// response does not indicate success.
+	ErrUnexpectedFaucetResponse = errors.New("unexpected faucet response")
+)
+
+// Faucet requests funds from an HTTP faucet for
+// accounts used in check:construction.
+type Faucet struct {
+	config   *configuration.FaucetConfiguration
+	client   *http.Client
+	matcher  *regexp.Regexp
+	counters *modules.CounterStorage
+
+	// lastRequest is the time funds were last
+	// requested for each address.
+	lastRequest map[string]time.Time
+}
+
+// NewFaucet returns a new *Faucet. The client is expected to
+// retry failed requests.
+func NewFaucet(
+	config *configuration.FaucetConfiguration,
+	client *http.Client,
+	counters *modules.CounterStorage,
+) (*Faucet, error) {
+	matcher, err := regexp.Compile(config.ResponseMatcher)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to compile response matcher", err)
+	}
+
+	return &Faucet{
+		config:      config,
+		client:      client,
+		matcher:     matcher,
+		counters:    counters,
+		lastRequest: map[string]time.Time{},
+	}, nil
+}
+
+// Request requests funds for address unless funds were already
+// requested for address within the cooldown. It returns a boolean
+// indicating if a request was made.
+func (f *Faucet) Request(ctx context.Context, address string) (bool, error) {
+	cooldown := time.Duration(f.config.Cooldown) * time.Second
+	if last, ok := f.lastRequest[address]; ok && time.Since(last) < cooldown {
+		return false, nil
+	}
+	f.lastRequest[address] = time.Now()
+
+	if err := f.send(ctx, address); err != nil {
+		return true, err
+	}
+
+	if _, err := f.counters.Update(ctx, results.FaucetCallsCounter, big.NewInt(1)); err != nil {
+		return true, fmt.Errorf("%w: unable to update faucet calls counter", err)
+	}
+
+	if f.config.Amount != nil {
+		value, err := types.BigInt(f.config.Amount.Value)
+		if err != nil {
+			return true, fmt.Errorf("%w: unable to parse faucet amount", err)
+		}
+
+		if _, err := f.counters.Update(ctx, results.FaucetFundsCounter, value); err != nil {
+			return true, fmt.Errorf("%w: unable to update faucet funds counter", err)
+		}
+	}
+
+	return true, nil
+}
+
+// send makes a single faucet request for address.
+func (f *Faucet) send(ctx context.Context, address string) error {
+	body := strings.ReplaceAll(f.config.RequestTemplate, addressPlaceholder, address)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		f.config.URL,
+		strings.NewReader(body),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to create faucet request", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	if len(f.config.AuthHeader) > 0 {
+		req.Header.Set("Authorization", f.config.AuthHeader)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: unable to request funds", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: unable to read faucet response", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: status %d: %s", ErrUnexpectedFaucetResponse, resp.StatusCode, respBody)
+	}
+
+	if !f.matcher.Match(respBody) {
+		return fmt.Errorf("%w: %s does not match %s", ErrUnexpectedFaucetResponse, respBody, f.matcher)
+	}
+
+	return nil
+}
+
+// WaitingAccounts returns the accounts that a request_funds
+// job is waiting on funds for. These are the accounts required by
+// any find_balance action in the job's current scenario with a
+// non-zero minimum balance.
+func WaitingAccounts(j *job.Job) []*types.AccountIdentifier {
+	accounts := []*types.AccountIdentifier{}
+	if j.Workflow != string(job.RequestFunds) || j.Index >= len(j.Scenarios) {
+		return accounts
+	}
+
+	for _, action := range j.Scenarios[j.Index].Actions {
+		if action.Type != job.FindBalance {
+			continue
+		}
+
+		// Inputs that depend on the output of earlier actions
+		// in the scenario can't be populated yet.
+		input, err := worker.PopulateInput(j.State, action.Input)
+		if err != nil {
+			continue
+		}
+
+		var findBalance job.FindBalanceInput
+		if err := job.UnmarshalInput([]byte(input), &findBalance); err != nil {
+			continue
+		}
+
+		if findBalance.AccountIdentifier == nil ||
+			findBalance.MinimumBalance == nil ||
+			findBalance.MinimumBalance.Value == "0" {
+			continue
+		}
+
+		accounts = append(accounts, findBalance.AccountIdentifier)
+	}
+
+	return accounts
+}
+
+// Watch requests funds for all accounts that request_funds
+// jobs in jobs are waiting on every interval. Failed faucet
+// requests are logged as warnings.
+func (f *Faucet) Watch(
+	ctx context.Context,
+	jobs *modules.JobStorage,
+	interval time.Duration,
+) error {
+	tc := time.NewTicker(interval)
+	defer tc.Stop()
+
+	for {
+		processing, err := jobs.AllProcessing(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: unable to get processing jobs", err)
+		}
+
+		for _, j := range processing {
+			for _, account := range WaitingAccounts(j) {
+				requested, err := f.Request(ctx, account.Address)
+				if err != nil {
+					color.Yellow(
+						"[WARNING] faucet request for %s failed: %s",
+						account.Address,
+						err.Error(),
+					)
+					continue
+				}
+
+				if requested {
+					log.Printf("requested funds for %s from faucet\n", account.Address)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tc.C:
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/constructor/job"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest(t *testing.T) {
+	amount := &types.Amount{
+		Value:    "100",
+		Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+	}
+
+	var tests = map[string]struct {
+		status   int
+		response string
+		matcher  string
+		amount   *types.Amount
+		requests int
+
+		expectedRequested []bool
+		expectedErr       error
+		expectedCalls     int64
+		expectedFunds     int64
+	}{
+		"success": {
+			status:            http.StatusOK,
+			response:          `{"status":"sent"}`,
+			matcher:           `"sent"`,
+			amount:            amount,
+			requests:          1,
+			expectedRequested: []bool{true},
+			expectedCalls:     1,
+			expectedFunds:     100,
+		},
+		"success without amount": {
+			status:            http.StatusOK,
+			response:          `{}`,
+			requests:          1,
+			expectedRequested: []bool{true},
+			expectedCalls:     1,
+		},
+		"cooldown": {
+			status:            http.StatusOK,
+			response:          `{}`,
+			amount:            amount,
+			requests:          2,
+			expectedRequested: []bool{true, false},
+			expectedCalls:     1,
+			expectedFunds:     100,
+		},
+		"unmatched response": {
+			status:            http.StatusOK,
+			response:          `{"status":"rate limited"}`,
+			matcher:           `"sent"`,
+			amount:            amount,
+			requests:          1,
+			expectedRequested: []bool{true},
+			expectedErr:       ErrUnexpectedFaucetResponse,
+		},
+		"error status": {
+			status:            http.StatusInternalServerError,
+			response:          `{}`,
+			amount:            amount,
+			requests:          1,
+			expectedRequested: []bool{true},
+			expectedErr:       ErrUnexpectedFaucetResponse,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, err := ioutil.ReadAll(r.Body)
+					assert.NoError(t, err)
+					assert.Equal(t, `{"address":"addr1"}`, string(body))
+					assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+					w.WriteHeader(test.status)
+					_, err = w.Write([]byte(test.response))
+					assert.NoError(t, err)
+				}),
+			)
+			defer ts.Close()
+
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			db, err := database.NewBadgerDatabase(ctx, dir)
+			assert.NoError(t, err)
+			defer db.Close(ctx)
+
+			counters := modules.NewCounterStorage(db)
+			f, err := NewFaucet(
+				&configuration.FaucetConfiguration{
+					URL:             ts.URL,
+					RequestTemplate: `{"address":"{{address}}"}`,
+					AuthHeader:      "Bearer token",
+					ResponseMatcher: test.matcher,
+					Cooldown:        60,
+					Amount:          test.amount,
+				},
+				http.DefaultClient,
+				counters,
+			)
+			assert.NoError(t, err)
+
+			for i := 0; i < test.requests; i++ {
+				requested, err := f.Request(ctx, "addr1")
+				assert.Equal(t, test.expectedRequested[i], requested)
+				if test.expectedErr != nil {
+					assert.ErrorIs(t, err, test.expectedErr)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+
+			calls, err := counters.Get(ctx, results.FaucetCallsCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedCalls, calls.Int64())
+
+			funds, err := counters.Get(ctx, results.FaucetFundsCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedFunds, funds.Int64())
+		})
+	}
+}
+
+func TestWaitingAccounts(t *testing.T) {
+	scenarios := []*job.Scenario{
+		{
+			Name: "find_account",
+			Actions: []*job.Action{
+				{
+					Type:       job.FindBalance,
+					Input:      `{"minimum_balance":{"value":"0","currency":{"symbol":"BTC","decimals":8}},"create_limit":1}`, // nolint:lll
+					OutputPath: "random_account",
+				},
+			},
+		},
+		{
+			Name: "request",
+			Actions: []*job.Action{
+				{
+					Type:       job.FindBalance,
+					Input:      `{"account_identifier":{{random_account.account_identifier}},"minimum_balance":{"value":"100","currency":{"symbol":"BTC","decimals":8}}}`, // nolint:lll
+					OutputPath: "loaded_account",
+				},
+			},
+		},
+	}
+	state := `{"random_account":{"account_identifier":{"address":"addr1"}}}`
+
+	var tests = map[string]struct {
+		job *job.Job
+
+		expected []*types.AccountIdentifier
+	}{
+		"waiting on funds": {
+			job: &job.Job{
+				Workflow:  string(job.RequestFunds),
+				Scenarios: scenarios,
+				Index:     1,
+				State:     state,
+			},
+			expected: []*types.AccountIdentifier{{Address: "addr1"}},
+		},
+		"zero minimum balance": {
+			job: &job.Job{
+				Workflow:  string(job.RequestFunds),
+				Scenarios: scenarios,
+				Index:     0,
+				State:     state,
+			},
+			expected: []*types.AccountIdentifier{},
+		},
+		"missing state": {
+			job: &job.Job{
+				Workflow:  string(job.RequestFunds),
+				Scenarios: scenarios,
+				Index:     1,
+				State:     `{}`,
+			},
+			expected: []*types.AccountIdentifier{},
+		},
+		"other workflow": {
+			job: &job.Job{
+				Workflow:  "transfer",
+				Scenarios: scenarios,
+				Index:     1,
+				State:     state,
+			},
+			expected: []*types.AccountIdentifier{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, WaitingAccounts(test.job))
+		})
+	}
+}
@@ -16,12 +16,22 @@ package tester
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/coinbase/rosetta-cli/configuration"
 	"github.com/coinbase/rosetta-cli/pkg/logger"
+	"github.com/coinbase/rosetta-cli/pkg/metrics"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
 )
 
 const (
@@ -30,6 +40,33 @@ const (
 	MemoryLoggingFrequency = 10 * time.Second
 )
 
+// tipPollInterval returns how often /network/status is
+// checked to determine if the Rosetta implementation is at tip.
+func tipPollInterval(config *configuration.Configuration) time.Duration {
+	if config.TipPollInterval <= 0 {
+		return configuration.DefaultTipPollInterval * time.Second
+	}
+
+	return time.Duration(config.TipPollInterval) * time.Second
+}
+
+// EnsureDataDirectoryExists populates the data directory of
+// config with a temporary directory (whose contents are deleted
+// when execution is complete) if it is not specified.
+func EnsureDataDirectoryExists(config *configuration.Configuration) error {
+	if len(config.DataDirectory) > 0 {
+		return nil
+	}
+
+	tmpDir, err := utils.CreateTempDir()
+	if err != nil {
+		return fmt.Errorf("%w: unable to create temporary directory", err)
+	}
+
+	config.DataDirectory = tmpDir
+	return nil
+}
+
 // LogMemoryLoop runs a loop that logs memory usage.
 func LogMemoryLoop(
 	ctx context.Context,
@@ -50,25 +87,36 @@ func LogMemoryLoop(
 
 // StartServer stats a server at a port with a particular handler.
 // This is often used to support a status endpoint for a particular test.
+// If port is 0, no server is started. An error is returned if the port
+// cannot be bound.
 func StartServer(
 	ctx context.Context,
 	name string,
 	handler http.Handler,
 	port uint,
 ) error {
+	if port == 0 {
+		log.Printf("%s server disabled\n", name)
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("%w: unable to start %s server on port %d", err, name, port)
+	}
+
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
 		Handler: handler,
 	}
 
 	go func() {
 		log.Printf("%s server running on port %d\n", name, port)
-		_ = server.ListenAndServe()
+		_ = server.Serve(listener)
 	}()
 
 	go func() {
 		// If we don't shutdown server, it will
-		// never stop because server.ListenAndServe doesn't
+		// never stop because server.Serve doesn't
 		// take any context.
 		<-ctx.Done()
 		log.Printf("%s server shutting down", name)
@@ -78,3 +126,44 @@ func StartServer(
 
 	return ctx.Err()
 }
+
+// syncSamples returns the CurrentBlockIndex, TipBlockIndex,
+// and DatabaseSize samples of a check. CurrentBlockIndex is
+// omitted if no block has been synced and TipBlockIndex is
+// omitted if the network status cannot be fetched.
+func syncSamples(
+	ctx context.Context,
+	blockStorage *modules.BlockStorage,
+	fetcher *fetcher.Fetcher,
+	network *types.NetworkIdentifier,
+	dataPath string,
+) ([]*metrics.Sample, error) {
+	samples := []*metrics.Sample{}
+	head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	switch {
+	case err == nil:
+		samples = append(samples, &metrics.Sample{
+			Name:  metrics.CurrentBlockIndex,
+			Value: float64(head.Index),
+		})
+	case !errors.Is(err, storageErrs.ErrHeadBlockNotFound):
+		return nil, fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	if status, fetchErr := fetcher.NetworkStatus(ctx, network, nil); fetchErr == nil {
+		samples = append(samples, &metrics.Sample{
+			Name:  metrics.TipBlockIndex,
+			Value: float64(status.CurrentBlockIdentifier.Index),
+		})
+	}
+
+	size, err := DirectorySize(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(samples, &metrics.Sample{
+		Name:  metrics.DatabaseSize,
+		Value: float64(size),
+	}), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Occupy a port so that binding to it fails.
+	listener, err := net.Listen("tcp", ":0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	port := uint(listener.Addr().(*net.TCPAddr).Port)
+
+	var tests = map[string]struct {
+		port uint
+
+		expectedErr string
+	}{
+		"disabled": {
+			port: 0,
+		},
+		"port in use": {
+			port:        port,
+			expectedErr: fmt.Sprintf("unable to start test server on port %d", port),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := StartServer(ctx, "test", http.NotFoundHandler(), test.port)
+			if len(test.expectedErr) > 0 {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// LivenessPath is the path of the liveness endpoint.
+	LivenessPath = "/healthz"
+
+	// ReadinessPath is the path of the readiness endpoint.
+	ReadinessPath = "/readyz"
+
+	// missedObservations is the number of consecutive observations
+	// that can be missed before the main loop is considered stalled.
+	missedObservations = 3
+
+	// backlogObservations is the number of consecutive observations
+	// in which the reconciler queue must grow before the reconciler
+	// is considered backlogged.
+	backlogObservations = 3
+)
+
+// HealthCheck is the result of a single health check.
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// HealthStatus is the aggregate verdict of
+// a collection of checks.
+type HealthStatus struct {
+	Healthy bool           `json:"healthy"`
+	Checks  []*HealthCheck `json:"checks"`
+}
+
+// newStatus returns a *HealthStatus that is only
+// healthy if all checks passed.
+func newStatus(checks ...*HealthCheck) *HealthStatus {
+	healthy := true
+	for _, check := range checks {
+		if !check.Passed {
+			healthy = false
+		}
+	}
+
+	return &HealthStatus{
+		Healthy: healthy,
+		Checks:  checks,
+	}
+}
+
+// HealthChecker computes liveness and readiness verdicts.
+type HealthChecker interface {
+	Liveness(ctx context.Context) *HealthStatus
+	Readiness(ctx context.Context) *HealthStatus
+}
+
+// HealthHandler serves liveness and readiness verdicts from checker on
+// LivenessPath and ReadinessPath and forwards all other requests
+// to next.
+func HealthHandler(checker HealthChecker, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status *HealthStatus
+		switch r.URL.Path {
+		case LivenessPath:
+			status = checker.Liveness(r.Context())
+		case ReadinessPath:
+			status = checker.Readiness(r.Context())
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if status.Healthy {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// HealthMonitor tracks the progress of a run to determine
+// if it is alive and ready.
+type HealthMonitor struct {
+	observationInterval time.Duration
+	now                 func() time.Time
+
+	mutex          sync.Mutex
+	stallThreshold time.Duration
+	lastObserved   time.Time
+	head           int64
+	tip            int64
+	lastProgress   time.Time
+
+	// queueSizes are the reconciler queue sizes of the most
+	// recent observations (oldest first).
+	queueSizes []int
+}
+
+// NewHealthMonitor returns a new *HealthMonitor. The run is considered stalled
+// if the head block does not advance for stallThreshold while behind
+// tip or if the main loop does not record an observation for a few
+// observationIntervals. The reconciler is considered backlogged if
+// its queue grows in each of the last few observations.
+func NewHealthMonitor(
+	stallThreshold time.Duration,
+	observationInterval time.Duration,
+) *HealthMonitor {
+	now := time.Now()
+	return &HealthMonitor{
+		stallThreshold:      stallThreshold,
+		observationInterval: observationInterval,
+		now:                 time.Now,
+		lastObserved:        now,
+		head:                -1,
+		tip:                 -1,
+		lastProgress:        now,
+	}
+}
+
+// SetStallThreshold changes the duration the head block may
+// not advance for while behind tip before the run is stalled.
+func (m *HealthMonitor) SetStallThreshold(stallThreshold time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.stallThreshold = stallThreshold
+}
+
+// Observe records the current head block index. It should
+// be called by the main loop every observationInterval.
+func (m *HealthMonitor) Observe(head int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+	m.lastObserved = now
+	if head > m.head {
+		m.head = head
+		m.lastProgress = now
+	}
+}
+
+// ObserveTip records the most recent tip
+// reported by the implementation.
+func (m *HealthMonitor) ObserveTip(tip int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.tip = tip
+}
+
+// ObserveQueue records the current size of the reconciler
+// queue. It should be called by the main loop every
+// observationInterval.
+func (m *HealthMonitor) ObserveQueue(size int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.queueSizes = append(m.queueSizes, size)
+	if len(m.queueSizes) > backlogObservations+1 {
+		m.queueSizes = m.queueSizes[1:]
+	}
+}
+
+// queueGrowing returns a boolean indicating if the reconciler
+// queue grew in each of the last backlogObservations.
+func (m *HealthMonitor) queueGrowing() bool {
+	if len(m.queueSizes) <= backlogObservations {
+		return false
+	}
+
+	for i := 1; i < len(m.queueSizes); i++ {
+		if m.queueSizes[i] <= m.queueSizes[i-1] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Liveness returns a *HealthStatus indicating if the main
+// loop is running and syncing is making progress.
+func (m *HealthMonitor) Liveness() *HealthStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+
+	mainLoop := &HealthCheck{Name: "main_loop", Passed: true}
+	maxObservationAge := missedObservations * m.observationInterval
+	if age := now.Sub(m.lastObserved); age > maxObservationAge {
+		mainLoop.Passed = false
+		mainLoop.Detail = fmt.Sprintf(
+			"no progress recorded in %s (expected every %s)",
+			age.Round(time.Second),
+			m.observationInterval,
+		)
+	}
+
+	syncProgress := &HealthCheck{Name: "sync_progress", Passed: true}
+	if stalled := now.Sub(m.lastProgress); m.tip > m.head && stalled > m.stallThreshold {
+		syncProgress.Passed = false
+		syncProgress.Detail = fmt.Sprintf(
+			"head block %d has not advanced in %s (tip is %d)",
+			m.head,
+			stalled.Round(time.Second),
+			m.tip,
+		)
+	}
+
+	return newStatus(mainLoop, syncProgress)
+}
+
+// Readiness returns a *HealthStatus indicating if the implementation
+// is reachable, syncing is at tip, and the reconciler queue is
+// draining. If reconciling is false, the reconciler is not checked.
+func (m *HealthMonitor) Readiness(
+	reachErr error,
+	synced bool,
+	reconciling bool,
+) *HealthStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	reachable := &HealthCheck{Name: "endpoint_reachable", Passed: reachErr == nil}
+	if reachErr != nil {
+		reachable.Detail = reachErr.Error()
+	}
+
+	atTip := &HealthCheck{Name: "synced_to_tip", Passed: reachErr == nil && synced}
+	if !atTip.Passed {
+		atTip.Detail = fmt.Sprintf("last synced block is more than %s behind tip", m.stallThreshold)
+	}
+	if reachErr != nil {
+		atTip.Detail = "unable to determine tip"
+	}
+
+	checks := []*HealthCheck{reachable, atTip}
+	if reconciling {
+		draining := &HealthCheck{Name: "reconciler_backlog", Passed: !m.queueGrowing()}
+		if !draining.Passed {
+			draining.Detail = fmt.Sprintf(
+				"reconciler queue grew from %d to %d in the last %d observations",
+				m.queueSizes[0],
+				m.queueSizes[len(m.queueSizes)-1],
+				backlogObservations,
+			)
+		}
+
+		checks = append(checks, draining)
+	}
+
+	return newStatus(checks...)
+}
@@ -0,0 +1,226 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	start = time.Unix(1600000000, 0)
+)
+
+// monitorChecker implements HealthChecker using a *HealthMonitor
+// and fixed readiness inputs.
+type monitorChecker struct {
+	monitor     *HealthMonitor
+	reachErr    error
+	synced      bool
+	reconciling bool
+}
+
+func (c *monitorChecker) Liveness(ctx context.Context) *HealthStatus {
+	return c.monitor.Liveness()
+}
+
+func (c *monitorChecker) Readiness(ctx context.Context) *HealthStatus {
+	return c.monitor.Readiness(c.reachErr, c.synced, c.reconciling)
+}
+
+func newTestMonitor(now *time.Time) *HealthMonitor {
+	m := NewHealthMonitor(time.Minute, 10*time.Second)
+	m.now = func() time.Time { return *now }
+	m.lastObserved = start
+	m.lastProgress = start
+
+	return m
+}
+
+func serve(t *testing.T, handler http.Handler, path string) (int, *HealthStatus) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var status HealthStatus
+	assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&status))
+
+	return recorder.Code, &status
+}
+
+func TestLiveness(t *testing.T) {
+	var tests = map[string]struct {
+		setup   func(m *HealthMonitor, now *time.Time)
+		healthy bool
+		failed  string
+	}{
+		"just started": {
+			setup:   func(m *HealthMonitor, now *time.Time) {},
+			healthy: true,
+		},
+		"syncing": {
+			setup: func(m *HealthMonitor, now *time.Time) {
+				m.ObserveTip(100)
+				*now = start.Add(50 * time.Second)
+				m.Observe(10)
+				*now = start.Add(100 * time.Second)
+				m.Observe(20)
+			},
+			healthy: true,
+		},
+		"at tip without new blocks": {
+			setup: func(m *HealthMonitor, now *time.Time) {
+				m.Observe(100)
+				m.ObserveTip(100)
+				for i := 1; i <= 30; i++ {
+					*now = start.Add(time.Duration(i) * 10 * time.Second)
+					m.Observe(100)
+				}
+			},
+			healthy: true,
+		},
+		"main loop stalled": {
+			setup: func(m *HealthMonitor, now *time.Time) {
+				m.Observe(10)
+				*now = start.Add(31 * time.Second)
+			},
+			healthy: false,
+			failed:  "main_loop",
+		},
+		"sync stalled behind tip": {
+			setup: func(m *HealthMonitor, now *time.Time) {
+				m.Observe(10)
+				m.ObserveTip(100)
+				for i := 1; i <= 7; i++ {
+					*now = start.Add(time.Duration(i) * 10 * time.Second)
+					m.Observe(10)
+				}
+			},
+			healthy: false,
+			failed:  "sync_progress",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			now := start
+			m := newTestMonitor(&now)
+			test.setup(m, &now)
+
+			handler := HealthHandler(&monitorChecker{monitor: m}, http.NotFoundHandler())
+			code, status := serve(t, handler, LivenessPath)
+			assert.Equal(t, test.healthy, status.Healthy)
+			if test.healthy {
+				assert.Equal(t, http.StatusOK, code)
+				return
+			}
+
+			assert.Equal(t, http.StatusServiceUnavailable, code)
+			for _, check := range status.Checks {
+				assert.Equal(t, check.Name != test.failed, check.Passed)
+				if !check.Passed {
+					assert.NotEmpty(t, check.Detail)
+				}
+			}
+		})
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	var tests = map[string]struct {
+		checker    *monitorChecker
+		queueSizes []int
+		healthy    bool
+		failed     []string
+	}{
+		"ready": {
+			checker:    &monitorChecker{synced: true, reconciling: true},
+			queueSizes: []int{10, 20, 30},
+			healthy:    true,
+		},
+		"ready without reconciliation": {
+			checker:    &monitorChecker{synced: true},
+			queueSizes: []int{10, 20, 30, 40, 50},
+			healthy:    true,
+		},
+		"reconciler draining": {
+			checker:    &monitorChecker{synced: true, reconciling: true},
+			queueSizes: []int{10, 20, 30, 25, 1000},
+			healthy:    true,
+		},
+		"unreachable": {
+			checker: &monitorChecker{reachErr: errors.New("connection refused"), synced: true},
+			healthy: false,
+			failed:  []string{"endpoint_reachable", "synced_to_tip"},
+		},
+		"behind tip": {
+			checker: &monitorChecker{synced: false},
+			healthy: false,
+			failed:  []string{"synced_to_tip"},
+		},
+		"reconciler backlogged": {
+			checker:    &monitorChecker{synced: true, reconciling: true},
+			queueSizes: []int{100, 10, 20, 30, 40},
+			healthy:    false,
+			failed:     []string{"reconciler_backlog"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			now := start
+			test.checker.monitor = newTestMonitor(&now)
+			for _, size := range test.queueSizes {
+				test.checker.monitor.ObserveQueue(size)
+			}
+
+			handler := HealthHandler(test.checker, http.NotFoundHandler())
+			code, status := serve(t, handler, ReadinessPath)
+			assert.Equal(t, test.healthy, status.Healthy)
+			if test.healthy {
+				assert.Equal(t, http.StatusOK, code)
+			} else {
+				assert.Equal(t, http.StatusServiceUnavailable, code)
+			}
+
+			failed := []string{}
+			for _, check := range status.Checks {
+				if !check.Passed {
+					failed = append(failed, check.Name)
+					assert.NotEmpty(t, check.Detail)
+				}
+			}
+			assert.ElementsMatch(t, test.failed, failed)
+		})
+	}
+}
+
+func TestHealthHandlerForwards(t *testing.T) {
+	now := start
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	handler := HealthHandler(&monitorChecker{monitor: newTestMonitor(&now)}, next)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTeapot, recorder.Code)
+}
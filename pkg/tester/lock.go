@@ -0,0 +1,48 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package tester
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockDirectory acquires the lock Badger acquires when
+// opening the store in directory (so the store cannot be
+// opened until it is unlocked). It fails if the store is
+// already open.
+func lockDirectory(directory string) (func(), error) {
+	f, err := os.Open(directory)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to open %s", err, directory)
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, fmt.Errorf(
+			"%w: store %s is in use (stop any command using it before backing it up)",
+			err,
+			directory,
+		)
+	}
+
+	return func() {
+		f.Close()
+	}, nil
+}
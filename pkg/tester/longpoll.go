@@ -0,0 +1,487 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+)
+
+// TipPollerSyncer is the syncer.Handler and syncer.Helper
+// wrapped by a *TipPoller (i.e. a *statefulsyncer.StatefulSyncer).
+type TipPollerSyncer interface {
+	syncer.Handler
+	syncer.Helper
+}
+
+// TipPollerBlockFetcher fetches a block without retrying
+// (i.e. a *fetcher.Fetcher).
+type TipPollerBlockFetcher interface {
+	Block(
+		context.Context,
+		*types.NetworkIdentifier,
+		*types.PartialBlockIdentifier,
+	) (*types.Block, *fetcher.Error)
+}
+
+// TipPollerOption is used to configure a *TipPoller.
+type TipPollerOption func(p *TipPoller)
+
+// WithLongPoll enables long polling for the next block once
+// the *TipPoller has synced to tip. Responses with an error code
+// in notFoundCodes are re-requested after delay (with up to
+// 100% jitter) until timeout elapses.
+func WithLongPoll(notFoundCodes []int32, delay time.Duration, timeout time.Duration) TipPollerOption {
+	return func(p *TipPoller) {
+		p.enabled = true
+		p.delay = delay
+		p.timeout = timeout
+		for _, code := range notFoundCodes {
+			p.notFoundCodes[code] = struct{}{}
+		}
+	}
+}
+
+// WithLimiter limits the rate of blocks fetched (by the
+// syncer and while long polling) to the rate of limiter.
+func WithLimiter(limiter *processor.RateLimiter) TipPollerOption {
+	return func(p *TipPoller) {
+		p.limiter = limiter
+	}
+}
+
+// WithStaticTip treats the block at index as the current block
+// (instead of polling /network/status for it). /network/status is
+// only requested once (to get the genesis block) and long polling
+// is disabled.
+func WithStaticTip(index int64) TipPollerOption {
+	return func(p *TipPoller) {
+		p.staticTip = &index
+	}
+}
+
+// WithHaltOnReorg stops syncing (instead of removing the
+// block) when the syncer orphans a block.
+func WithHaltOnReorg() TipPollerOption {
+	return func(p *TipPoller) {
+		p.haltOnReorg = true
+	}
+}
+
+// TipPoller wraps the TipPollerSyncer used to sync blocks. Once syncing has
+// reached tip, it measures the latency of each new block (from its
+// timestamp to when it is added) and the requests made to find it.
+// If long polling is enabled, it requests the next block from /block
+// (instead of waiting to poll /network/status again) and reports
+// it as the current block when it exists.
+type TipPoller struct {
+	// inFlight is the number of blocks being fetched by
+	// the syncer (first for 64-bit atomic alignment).
+	inFlight int64
+
+	network *types.NetworkIdentifier
+	fetcher TipPollerBlockFetcher
+	syncer  TipPollerSyncer
+	limiter *processor.RateLimiter
+
+	haltOnReorg bool
+
+	staticTip    *int64
+	staticStatus *types.NetworkStatusResponse
+
+	enabled       bool
+	notFoundCodes map[int32]struct{}
+	delay         time.Duration
+	timeout       time.Duration
+
+	lock     sync.Mutex
+	rand     *rand.Rand
+	head     int64
+	pending  map[int64]*types.Block
+	fellBack bool
+
+	// orphanedBlock is the first block orphaned
+	// when halting on reorgs.
+	orphanedBlock *types.BlockIdentifier
+
+	// tipStart is when syncing first reached tip.
+	// Only requests and blocks after tipStart
+	// are measured.
+	tipStart         time.Time
+	statusRequests   int64
+	blockRequests    int64
+	notFound         int64
+	longPolledBlocks int64
+	blocks           int64
+	totalLatencyMs   int64
+	maxLatencyMs     int64
+}
+
+// NewTipPoller returns a new *TipPoller.
+func NewTipPoller(
+	network *types.NetworkIdentifier,
+	fetcher TipPollerBlockFetcher,
+	syncer TipPollerSyncer,
+	options ...TipPollerOption,
+) *TipPoller {
+	p := &TipPoller{
+		network:       network,
+		fetcher:       fetcher,
+		syncer:        syncer,
+		notFoundCodes: map[int32]struct{}{},
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())), // nolint:gosec
+		head:          -1,
+		pending:       map[int64]*types.Block{},
+	}
+
+	for _, opt := range options {
+		opt(p)
+	}
+
+	return p
+}
+
+// SetHead sets the index of the last synced block
+// (before the first block is added).
+func (p *TipPoller) SetHead(index int64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = index
+}
+
+// BlockSeen is called by the syncer when a block is seen.
+func (p *TipPoller) BlockSeen(ctx context.Context, block *types.Block) error {
+	return p.syncer.BlockSeen(ctx, block)
+}
+
+// BlockAdded is called by the syncer when a block is added.
+func (p *TipPoller) BlockAdded(ctx context.Context, block *types.Block) error {
+	if err := p.syncer.BlockAdded(ctx, block); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = block.BlockIdentifier.Index
+	if p.tipStart.IsZero() {
+		return nil
+	}
+
+	latency := time.Now().UnixNano()/int64(time.Millisecond) - block.Timestamp
+	if latency < 0 { // the block timestamp is ahead of our clock
+		latency = 0
+	}
+
+	p.blocks++
+	p.totalLatencyMs += latency
+	if latency > p.maxLatencyMs {
+		p.maxLatencyMs = latency
+	}
+
+	return nil
+}
+
+// BlockRemoved is called by the syncer when a block is removed.
+func (p *TipPoller) BlockRemoved(
+	ctx context.Context,
+	blockIdentifier *types.BlockIdentifier,
+) error {
+	if p.haltOnReorg {
+		p.lock.Lock()
+		if p.orphanedBlock == nil {
+			p.orphanedBlock = blockIdentifier
+		}
+		p.lock.Unlock()
+
+		return fmt.Errorf(
+			"%w: block %d (%s)",
+			results.ErrOrphanedBlock,
+			blockIdentifier.Index,
+			blockIdentifier.Hash,
+		)
+	}
+
+	if err := p.syncer.BlockRemoved(ctx, blockIdentifier); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = blockIdentifier.Index - 1
+	p.pending = map[int64]*types.Block{}
+	return nil
+}
+
+// NetworkStatus is called by the syncer to get the current
+// network status. If long polling is enabled and syncing has
+// reached tip, it returns once the next block exists (as the
+// current block) or the long poll times out.
+func (p *TipPoller) NetworkStatus(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+) (*types.NetworkStatusResponse, error) {
+	if p.staticTip != nil {
+		return p.staticNetworkStatus(ctx, network)
+	}
+
+	status, err := p.syncer.NetworkStatus(ctx, network)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	atTip := p.head >= 0 && p.head >= status.CurrentBlockIdentifier.Index
+	if atTip && p.tipStart.IsZero() {
+		p.tipStart = time.Now()
+	}
+	if !p.tipStart.IsZero() {
+		p.statusRequests++
+	}
+	longPoll := atTip && p.enabled && !p.fellBack
+	next := p.head + 1
+	p.lock.Unlock()
+
+	if !longPoll {
+		return status, nil
+	}
+
+	block := p.longPoll(ctx, network, next)
+	if block == nil {
+		return status, nil
+	}
+
+	return &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: block.BlockIdentifier,
+		CurrentBlockTimestamp:  block.Timestamp,
+		GenesisBlockIdentifier: status.GenesisBlockIdentifier,
+		OldestBlockIdentifier:  status.OldestBlockIdentifier,
+		SyncStatus:             status.SyncStatus,
+		Peers:                  status.Peers,
+	}, nil
+}
+
+// staticNetworkStatus returns the network status (requested
+// once) with the static tip as the current block.
+func (p *TipPoller) staticNetworkStatus(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+) (*types.NetworkStatusResponse, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.staticStatus == nil {
+		status, err := p.syncer.NetworkStatus(ctx, network)
+		if err != nil {
+			return nil, err
+		}
+
+		p.staticStatus = status
+	}
+
+	return &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: &types.BlockIdentifier{Index: *p.staticTip},
+		GenesisBlockIdentifier: p.staticStatus.GenesisBlockIdentifier,
+		OldestBlockIdentifier:  p.staticStatus.OldestBlockIdentifier,
+		SyncStatus:             p.staticStatus.SyncStatus,
+		Peers:                  p.staticStatus.Peers,
+	}, nil
+}
+
+// longPoll requests the block at index until it exists, the
+// implementation responds with an unexpected error, or the
+// long poll times out. The block is returned (and stored so
+// the syncer doesn't fetch it again) if it exists.
+func (p *TipPoller) longPoll(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	index int64,
+) *types.Block {
+	deadline := time.Now().Add(p.timeout)
+	for {
+		if err := p.limiter.Wait(ctx); err != nil {
+			return nil
+		}
+
+		p.lock.Lock()
+		p.blockRequests++
+		p.lock.Unlock()
+
+		block, fetchErr := p.fetcher.Block(
+			ctx,
+			network,
+			&types.PartialBlockIdentifier{Index: &index},
+		)
+		if fetchErr == nil {
+			if block == nil { // omitted blocks are skipped by the syncer
+				return nil
+			}
+
+			p.lock.Lock()
+			p.pending[index] = block
+			p.longPolledBlocks++
+			p.lock.Unlock()
+
+			return block
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if fetchErr.ClientErr == nil {
+			// Transport failures are retried by
+			// polling /network/status.
+			return nil
+		}
+
+		if _, ok := p.notFoundCodes[fetchErr.ClientErr.Code]; !ok {
+			p.lock.Lock()
+			p.fellBack = true
+			p.lock.Unlock()
+
+			color.Yellow(
+				"unexpected error code %d while long polling for block %d, falling back to polling: %s",
+				fetchErr.ClientErr.Code,
+				index,
+				fetchErr.ClientErr.Message,
+			)
+			return nil
+		}
+
+		p.lock.Lock()
+		p.notFound++
+		delay := p.delay + time.Duration(p.rand.Int63n(int64(p.delay)+1))
+		p.lock.Unlock()
+
+		if time.Now().Add(delay).After(deadline) {
+			return nil
+		}
+
+		if err := utils.ContextSleep(ctx, delay); err != nil {
+			return nil
+		}
+	}
+}
+
+// Block is called by the syncer to fetch a block. Blocks
+// found while long polling are not fetched again.
+func (p *TipPoller) Block(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, error) {
+	p.lock.Lock()
+	if blockIdentifier.Index != nil {
+		block, ok := p.pending[*blockIdentifier.Index]
+		delete(p.pending, *blockIdentifier.Index)
+		if ok && (blockIdentifier.Hash == nil ||
+			*blockIdentifier.Hash == block.BlockIdentifier.Hash) {
+			p.lock.Unlock()
+			return block, nil
+		}
+	}
+
+	if !p.tipStart.IsZero() {
+		p.blockRequests++
+	}
+	p.lock.Unlock()
+
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&p.inFlight, 1)
+	defer atomic.AddInt64(&p.inFlight, -1)
+
+	return p.syncer.Block(ctx, network, blockIdentifier)
+}
+
+// InFlight returns the number of blocks
+// currently being fetched by the syncer.
+func (p *TipPoller) InFlight() int64 {
+	if p == nil {
+		return 0
+	}
+
+	return atomic.LoadInt64(&p.inFlight)
+}
+
+// OrphanedBlock returns the block that was orphaned
+// when halting on reorgs (or nil if no block was
+// orphaned or the *TipPoller is nil).
+func (p *TipPoller) OrphanedBlock() *types.BlockIdentifier {
+	if p == nil {
+		return nil
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.orphanedBlock
+}
+
+// TipPollingResults returns the *TipPollingResults of the *TipPoller. If syncing
+// never reached tip, nil is returned.
+func (p *TipPoller) Results() *results.TipPollingResults {
+	if p == nil {
+		return nil
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.tipStart.IsZero() {
+		return nil
+	}
+
+	elapsed := time.Since(p.tipStart)
+	pollingResults := &results.TipPollingResults{
+		LongPoll:          p.enabled,
+		FellBack:          p.fellBack,
+		TipSeconds:        int64(elapsed.Seconds()),
+		StatusRequests:    p.statusRequests,
+		BlockRequests:     p.blockRequests,
+		NotFoundResponses: p.notFound,
+		Blocks:            p.blocks,
+		LongPolledBlocks:  p.longPolledBlocks,
+		MaxLatencyMs:      p.maxLatencyMs,
+	}
+
+	if elapsed > 0 {
+		pollingResults.RequestsPerMinute = float64(p.statusRequests+p.blockRequests) / elapsed.Minutes()
+	}
+
+	if p.blocks > 0 {
+		pollingResults.AverageLatencyMs = p.totalLatencyMs / p.blocks
+	}
+
+	return pollingResults
+}
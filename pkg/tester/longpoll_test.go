@@ -0,0 +1,411 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const notFoundCode = 404
+
+var longpollNetwork = &types.NetworkIdentifier{Blockchain: "blockchain", Network: "network"}
+
+func longpollTestBlock(index int64) *types.Block {
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{Index: index, Hash: fmt.Sprintf("block %d", index)},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Index: index - 1,
+			Hash:  fmt.Sprintf("block %d", index-1),
+		},
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+}
+
+type longpollMockSyncer struct {
+	tip int64
+
+	lock           sync.Mutex
+	blocks         int
+	statusRequests int
+	removed        []*types.BlockIdentifier
+}
+
+func (m *longpollMockSyncer) BlockSeen(context.Context, *types.Block) error { return nil }
+
+func (m *longpollMockSyncer) BlockAdded(context.Context, *types.Block) error { return nil }
+
+func (m *longpollMockSyncer) BlockRemoved(_ context.Context, block *types.BlockIdentifier) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.removed = append(m.removed, block)
+	return nil
+}
+
+func (m *longpollMockSyncer) NetworkStatus(
+	context.Context,
+	*types.NetworkIdentifier,
+) (*types.NetworkStatusResponse, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.statusRequests++
+	return &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: longpollTestBlock(m.tip).BlockIdentifier,
+		GenesisBlockIdentifier: longpollTestBlock(0).BlockIdentifier,
+	}, nil
+}
+
+func (m *longpollMockSyncer) Block(
+	_ context.Context,
+	_ *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.blocks++
+	return longpollTestBlock(*blockIdentifier.Index), nil
+}
+
+// mockFetcher responds with errs (in order)
+// before returning the requested block.
+type longpollMockFetcher struct {
+	errs     []*types.Error
+	requests int
+}
+
+func (m *longpollMockFetcher) Block(
+	_ context.Context,
+	_ *types.NetworkIdentifier,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, *fetcher.Error) {
+	m.requests++
+	if len(m.errs) > 0 {
+		clientErr := m.errs[0]
+		m.errs = m.errs[1:]
+		if clientErr == nil {
+			return nil, &fetcher.Error{Err: errors.New("connection refused")}
+		}
+
+		return nil, &fetcher.Error{Err: errors.New(clientErr.Message), ClientErr: clientErr}
+	}
+
+	return longpollTestBlock(*blockIdentifier.Index), nil
+}
+
+func notFound() *types.Error {
+	return &types.Error{Code: notFoundCode, Message: "block not found"}
+}
+
+func TestNetworkStatus(t *testing.T) {
+	var tests = map[string]struct {
+		head    int64
+		enabled bool
+		timeout time.Duration
+		errs    []*types.Error
+
+		expectedTip      int64
+		expectedRequests int
+		expectedFellBack bool
+	}{
+		"not at tip": {
+			head:        5,
+			enabled:     true,
+			timeout:     time.Second,
+			expectedTip: 10,
+		},
+		"long poll disabled": {
+			head:        10,
+			timeout:     time.Second,
+			expectedTip: 10,
+		},
+		"next block exists": {
+			head:             10,
+			enabled:          true,
+			timeout:          time.Second,
+			expectedTip:      11,
+			expectedRequests: 1,
+		},
+		"next block found after not found responses": {
+			head:             10,
+			enabled:          true,
+			timeout:          time.Second,
+			errs:             []*types.Error{notFound(), notFound()},
+			expectedTip:      11,
+			expectedRequests: 3,
+		},
+		"timeout": {
+			head:             10,
+			enabled:          true,
+			timeout:          time.Millisecond,
+			errs:             []*types.Error{notFound(), notFound()},
+			expectedTip:      10,
+			expectedRequests: 1,
+		},
+		"transport failure": {
+			head:             10,
+			enabled:          true,
+			timeout:          time.Second,
+			errs:             []*types.Error{nil},
+			expectedTip:      10,
+			expectedRequests: 1,
+		},
+		"unexpected error": {
+			head:             10,
+			enabled:          true,
+			timeout:          time.Second,
+			errs:             []*types.Error{{Code: 1, Message: "unexpected"}},
+			expectedTip:      10,
+			expectedRequests: 1,
+			expectedFellBack: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &longpollMockSyncer{tip: 10}
+			f := &longpollMockFetcher{errs: test.errs}
+			options := []TipPollerOption{}
+			if test.enabled {
+				options = append(
+					options,
+					WithLongPoll([]int32{notFoundCode}, time.Millisecond, test.timeout),
+				)
+			}
+
+			p := NewTipPoller(longpollNetwork, f, s, options...)
+			p.SetHead(test.head)
+
+			status, err := p.NetworkStatus(ctx, longpollNetwork)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedTip, status.CurrentBlockIdentifier.Index)
+			assert.Equal(t, test.expectedRequests, f.requests)
+
+			// Blocks found while long polling are
+			// not fetched again.
+			block, err := p.Block(ctx, longpollNetwork, &types.PartialBlockIdentifier{
+				Index: &status.CurrentBlockIdentifier.Index,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, status.CurrentBlockIdentifier, block.BlockIdentifier)
+			assert.Equal(t, int64(0), p.InFlight())
+			if test.expectedTip > s.tip {
+				assert.Equal(t, 0, s.blocks)
+			} else {
+				assert.Equal(t, 1, s.blocks)
+			}
+
+			results := p.Results()
+			if test.head < s.tip {
+				assert.Nil(t, results)
+				return
+			}
+
+			assert.Equal(t, test.enabled, results.LongPoll)
+			assert.Equal(t, test.expectedFellBack, results.FellBack)
+			assert.Equal(t, int64(1), results.StatusRequests)
+		})
+	}
+}
+
+func TestStaticTip(t *testing.T) {
+	ctx := context.Background()
+	s := &longpollMockSyncer{tip: 10}
+	f := &longpollMockFetcher{}
+	p := NewTipPoller(
+		longpollNetwork,
+		f,
+		s,
+		WithLongPoll([]int32{notFoundCode}, time.Millisecond, time.Second),
+		WithStaticTip(5),
+	)
+
+	for head := int64(-1); head <= 6; head++ {
+		p.SetHead(head)
+		status, err := p.NetworkStatus(ctx, longpollNetwork)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), status.CurrentBlockIdentifier.Index)
+		assert.Equal(t, longpollTestBlock(0).BlockIdentifier, status.GenesisBlockIdentifier)
+	}
+
+	// /network/status is only requested once and
+	// the next block is never long polled.
+	assert.Equal(t, 1, s.statusRequests)
+	assert.Equal(t, 0, f.requests)
+}
+
+func TestFallBack(t *testing.T) {
+	ctx := context.Background()
+	s := &longpollMockSyncer{tip: 10}
+	f := &longpollMockFetcher{errs: []*types.Error{{Code: 1, Message: "unexpected"}}}
+	p := NewTipPoller(longpollNetwork, f, s, WithLongPoll([]int32{notFoundCode}, time.Millisecond, time.Second))
+	p.SetHead(10)
+
+	status, err := p.NetworkStatus(ctx, longpollNetwork)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), status.CurrentBlockIdentifier.Index)
+	assert.Equal(t, 1, f.requests)
+
+	// Once fallen back, the next block is
+	// never long polled for again.
+	status, err = p.NetworkStatus(ctx, longpollNetwork)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), status.CurrentBlockIdentifier.Index)
+	assert.Equal(t, 1, f.requests)
+	assert.True(t, p.Results().FellBack)
+}
+
+func TestLatency(t *testing.T) {
+	ctx := context.Background()
+	s := &longpollMockSyncer{tip: 10}
+	p := NewTipPoller(longpollNetwork, &longpollMockFetcher{}, s)
+
+	// Blocks added before reaching tip
+	// are not measured.
+	old := longpollTestBlock(9)
+	old.Timestamp -= 60000
+	assert.NoError(t, p.BlockAdded(ctx, old))
+	assert.Nil(t, p.Results())
+
+	assert.NoError(t, p.BlockAdded(ctx, longpollTestBlock(10)))
+	_, err := p.NetworkStatus(ctx, longpollNetwork)
+	assert.NoError(t, err)
+
+	late := longpollTestBlock(11)
+	late.Timestamp -= 2000
+	assert.NoError(t, p.BlockAdded(ctx, late))
+
+	results := p.Results()
+	assert.Equal(t, int64(1), results.Blocks)
+	assert.GreaterOrEqual(t, results.AverageLatencyMs, int64(2000))
+	assert.Less(t, results.MaxLatencyMs, int64(60000))
+	results.Print() // make sure doesn't panic
+
+	// Orphaned blocks must be synced again
+	// before reaching tip.
+	assert.NoError(t, p.BlockRemoved(ctx, late.BlockIdentifier))
+	s.tip = 11
+	status, err := p.NetworkStatus(ctx, longpollNetwork)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), status.CurrentBlockIdentifier.Index)
+}
+
+func TestLimiter(t *testing.T) {
+	const (
+		concurrency        = 8
+		maxBlocksPerSecond = 20
+		window             = time.Second
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), window)
+	defer cancel()
+
+	s := &longpollMockSyncer{tip: 1000}
+	p := NewTipPoller(longpollNetwork, &longpollMockFetcher{}, s, WithLimiter(processor.NewRateLimiter(maxBlocksPerSecond)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(index int64) {
+			defer wg.Done()
+			for ; ; index += concurrency {
+				idx := index
+				if _, err := p.Block(
+					ctx,
+					longpollNetwork,
+					&types.PartialBlockIdentifier{Index: &idx},
+				); err != nil {
+					assert.True(t, errors.Is(err, context.DeadlineExceeded))
+					return
+				}
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	// The first block is fetched immediately and each
+	// subsequent block is fetched 1/maxBlocksPerSecond
+	// after the last, regardless of concurrency.
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	assert.LessOrEqual(t, s.blocks, maxBlocksPerSecond+1)
+	assert.Greater(t, s.blocks, maxBlocksPerSecond/2)
+}
+
+func TestHaltOnReorg(t *testing.T) {
+	// Block 2 was synced with a different hash than the
+	// parent of block 3, so it is orphaned.
+	stale := &types.BlockIdentifier{Index: 2, Hash: "stale block 2"}
+
+	var tests = map[string]struct {
+		options []TipPollerOption
+
+		expectedErr     error
+		expectedOrphan  *types.BlockIdentifier
+		expectedRemoved []*types.BlockIdentifier
+	}{
+		"reorg handled": {
+			expectedRemoved: []*types.BlockIdentifier{stale},
+		},
+		"halt on reorg": {
+			options:        []TipPollerOption{WithHaltOnReorg()},
+			expectedErr:    results.ErrOrphanedBlock,
+			expectedOrphan: stale,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			m := &longpollMockSyncer{tip: 5}
+			p := NewTipPoller(longpollNetwork, &longpollMockFetcher{}, m, test.options...)
+			s := syncer.New(
+				longpollNetwork,
+				p,
+				p,
+				cancel,
+				syncer.WithPastBlocks([]*types.BlockIdentifier{stale}),
+			)
+
+			err := s.Sync(ctx, 3, 5)
+			if test.expectedErr != nil {
+				// The syncer does not wrap handler errors.
+				assert.Contains(t, err.Error(), test.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expectedOrphan, p.OrphanedBlock())
+			assert.Equal(t, test.expectedRemoved, m.removed)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+var (
+	// ErrOfflineURLUnreachable is returned when the offline
+	// node does not respond to /network/list.
+	ErrOfflineURLUnreachable = errors.New("offline_url is unreachable")
+
+	// ErrOfflineURLNotDistinct is returned when verify_offline_mode
+	// is enabled and offline_url is the same as online_url.
+	ErrOfflineURLNotDistinct = errors.New("offline_url is the same as online_url")
+
+	// ErrOfflineURLServesBlocks is returned when verify_offline_mode
+	// is enabled and the offline node serves /block.
+	ErrOfflineURLServesBlocks = errors.New("offline_url serves /block")
+)
+
+// sameURL returns a boolean indicating if a and b refer
+// to the same endpoint (ignoring case and trailing slashes).
+func sameURL(a string, b string) bool {
+	return strings.EqualFold(strings.TrimRight(a, "/"), strings.TrimRight(b, "/"))
+}
+
+// VerifyOfflineNode ensures the node at construction.offline_url
+// is reachable before any workflows are run. If
+// construction.verify_offline_mode is enabled, it also ensures
+// offline_url differs from online_url and that the offline node
+// rejects a request for the genesis block (an online-only endpoint).
+// Otherwise, a shared URL only results in a warning.
+func VerifyOfflineNode(
+	ctx context.Context,
+	config *configuration.Configuration,
+	network *types.NetworkIdentifier,
+	offlineFetcher *fetcher.Fetcher,
+	genesisBlock *types.BlockIdentifier,
+) error {
+	if _, fetchErr := offlineFetcher.NetworkList(ctx, nil); fetchErr != nil {
+		return fmt.Errorf(
+			"%w: %s: %v",
+			ErrOfflineURLUnreachable,
+			config.Construction.OfflineURL,
+			fetchErr.Err,
+		)
+	}
+
+	if sameURL(config.Construction.OfflineURL, config.OnlineURL) {
+		if config.Construction.VerifyOfflineMode {
+			return fmt.Errorf("%w: %s", ErrOfflineURLNotDistinct, config.OnlineURL)
+		}
+
+		color.Yellow(
+			"[WARNING] construction.offline_url is the same as online_url (%s), so offline construction is not exercised",
+			config.OnlineURL,
+		)
+		return nil
+	}
+
+	if !config.Construction.VerifyOfflineMode {
+		return nil
+	}
+
+	if _, fetchErr := offlineFetcher.Block(
+		ctx,
+		network,
+		types.ConstructPartialBlockIdentifier(genesisBlock),
+	); fetchErr == nil {
+		return fmt.Errorf(
+			"%w: %s returned block %d, so it does not appear to be in offline mode",
+			ErrOfflineURLServesBlocks,
+			config.Construction.OfflineURL,
+			genesisBlock.Index,
+		)
+	}
+
+	return nil
+}
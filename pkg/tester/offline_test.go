@@ -0,0 +1,147 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyOfflineNode(t *testing.T) {
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	genesis := &types.BlockIdentifier{Index: 0, Hash: "block 0"}
+	offlineAsserter, err := asserter.NewClientWithOptions(
+		network,
+		genesis,
+		[]string{"transfer"},
+		[]*types.OperationStatus{{Status: "SUCCESS", Successful: true}},
+		[]*types.Error{},
+		nil,
+		&asserter.Validations{Enabled: false},
+	)
+	assert.NoError(t, err)
+
+	var tests = map[string]struct {
+		unreachable       bool
+		sameURL           bool
+		servesBlocks      bool
+		verifyOfflineMode bool
+
+		expectedErr error
+	}{
+		"offline": {
+			verifyOfflineMode: true,
+		},
+		"unreachable": {
+			unreachable: true,
+			expectedErr: ErrOfflineURLUnreachable,
+		},
+		"same url": {
+			sameURL: true,
+		},
+		"same url verified": {
+			sameURL:           true,
+			verifyOfflineMode: true,
+			expectedErr:       ErrOfflineURLNotDistinct,
+		},
+		"serves blocks": {
+			servesBlocks: true,
+		},
+		"serves blocks verified": {
+			servesBlocks:      true,
+			verifyOfflineMode: true,
+			expectedErr:       ErrOfflineURLServesBlocks,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var response interface{}
+					status := http.StatusOK
+					switch {
+					case r.URL.Path == "/network/list":
+						response = &types.NetworkListResponse{
+							NetworkIdentifiers: []*types.NetworkIdentifier{network},
+						}
+					case r.URL.Path == "/block" && test.servesBlocks:
+						response = &types.BlockResponse{
+							Block: &types.Block{
+								BlockIdentifier:       genesis,
+								ParentBlockIdentifier: genesis,
+								Timestamp:             1600000000000,
+							},
+						}
+					default:
+						status = http.StatusInternalServerError
+						response = &types.Error{Code: 1, Message: "unavailable in offline mode"}
+					}
+
+					w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+					w.WriteHeader(status)
+					assert.NoError(t, json.NewEncoder(w).Encode(response))
+				}),
+			)
+			defer ts.Close()
+
+			offlineURL := ts.URL
+			if test.unreachable {
+				ts.Close()
+			}
+
+			onlineURL := "http://online"
+			if test.sameURL {
+				onlineURL = offlineURL + "/"
+			}
+
+			config := &configuration.Configuration{
+				OnlineURL: onlineURL,
+				Construction: &configuration.ConstructionConfiguration{
+					OfflineURL:        offlineURL,
+					VerifyOfflineMode: test.verifyOfflineMode,
+				},
+			}
+
+			err := VerifyOfflineNode(
+				context.Background(),
+				config,
+				network,
+				fetcher.New(
+					offlineURL,
+					fetcher.WithAsserter(offlineAsserter),
+					fetcher.WithMaxRetries(0),
+				),
+				genesis,
+			)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+)
+
+// entry is a pending write (or delete)
+// of a single key.
+type entry struct {
+	key     string
+	value   []byte
+	reclaim bool
+	deleted bool
+}
+
+// overlay is a set of pending writes that have not
+// been committed to the underlying database.
+type overlay struct {
+	lock    sync.RWMutex
+	entries map[string]*entry
+	bytes   int64
+}
+
+func newOverlay() *overlay {
+	return &overlay{entries: map[string]*entry{}}
+}
+
+func (o *overlay) set(key []byte, value []byte, reclaim bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.entries[string(key)] = &entry{key: string(key), value: value, reclaim: reclaim}
+	o.bytes += int64(len(key) + len(value))
+}
+
+func (o *overlay) delete(key []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.entries[string(key)] = &entry{key: string(key), deleted: true}
+	o.bytes += int64(len(key))
+}
+
+func (o *overlay) get(key []byte) (*entry, bool) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	e, ok := o.entries[string(key)]
+	return e, ok
+}
+
+// merge adds all entries in src to o, replacing
+// any existing entries with the same key.
+func (o *overlay) merge(src *overlay) {
+	src.lock.RLock()
+	defer src.lock.RUnlock()
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for key, e := range src.entries {
+		o.entries[key] = e
+	}
+	o.bytes += src.bytes
+}
+
+// update replaces the entries in o that have the same key
+// as an entry in src. Other entries in src are ignored.
+func (o *overlay) update(src *overlay) {
+	src.lock.RLock()
+	defer src.lock.RUnlock()
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for key, e := range src.entries {
+		if _, ok := o.entries[key]; ok {
+			o.entries[key] = e
+		}
+	}
+}
+
+// scan returns the entries that would be visited by a
+// database.Transaction.Scan with the same arguments,
+// in the order they would be visited.
+func (o *overlay) scan(prefix []byte, seekStart []byte, reverse bool) []*entry {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	entries := []*entry{}
+	for key, e := range o.entries {
+		if !strings.HasPrefix(key, string(prefix)) {
+			continue
+		}
+
+		// When iterating in reverse, seeking starts at
+		// the largest key <= seekStart.
+		if len(seekStart) > 0 && key != string(seekStart) &&
+			before(key, string(seekStart), reverse) {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return before(entries[i].key, entries[j].key, reverse)
+	})
+
+	return entries
+}
+
+// apply writes all entries to txn.
+func (o *overlay) apply(ctx context.Context, txn database.Transaction) error {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	for key, e := range o.entries {
+		if e.deleted {
+			if err := txn.Delete(ctx, []byte(key)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := txn.Set(ctx, []byte(key), e.value, e.reclaim); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// before returns a boolean indicating if a is
+// visited before b when scanning.
+func before(a string, b string, reverse bool) bool {
+	if reverse {
+		return a > b
+	}
+
+	return a < b
+}
@@ -0,0 +1,313 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/fatih/color"
+	"github.com/neilotoole/errgroup"
+)
+
+const (
+	// OverridesAppliedCounter is the number of times an
+	// override has been applied to a block.
+	OverridesAppliedCounter = "transaction_overrides_applied"
+
+	// observedNamespace prefixes the keys of
+	// transactions that have been overridden.
+	observedNamespace = "transaction_override"
+)
+
+var (
+	// ErrNoSuccessfulStatus is returned when the implementation
+	// does not support any successful operation status.
+	ErrNoSuccessfulStatus = errors.New("no successful operation status")
+)
+
+// OverrideBalanceChange is a corrected balance change
+// of an account in an overridden transaction.
+type OverrideBalanceChange struct {
+	Account    *types.AccountIdentifier `json:"account_identifier"`
+	Currency   *types.Currency          `json:"currency"`
+	Difference string                   `json:"difference"`
+}
+
+// TransactionOverride replaces the balance changes derived from
+// the operations of a transaction.
+type TransactionOverride struct {
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	BalanceChanges        []*OverrideBalanceChange     `json:"balance_changes"`
+}
+
+// LoadTransactionOverrides returns the overrides in filePath after
+// asserting they are valid.
+func LoadTransactionOverrides(filePath string) ([]*TransactionOverride, error) {
+	overrides := []*TransactionOverride{}
+	if err := utils.LoadAndParse(filePath, &overrides); err != nil {
+		return nil, fmt.Errorf("%w: unable to open transaction overrides file", err)
+	}
+
+	seen := map[string]struct{}{}
+	for _, override := range overrides {
+		if override == nil {
+			return nil, errors.New("override cannot be nil")
+		}
+
+		if err := asserter.TransactionIdentifier(override.TransactionIdentifier); err != nil {
+			return nil, fmt.Errorf("%w: invalid transaction identifier", err)
+		}
+
+		hash := override.TransactionIdentifier.Hash
+		if _, ok := seen[hash]; ok {
+			return nil, fmt.Errorf("duplicate override for transaction %s", hash)
+		}
+		seen[hash] = struct{}{}
+
+		for _, change := range override.BalanceChanges {
+			if err := assertBalanceChange(change); err != nil {
+				return nil, fmt.Errorf("%w: invalid balance change for transaction %s", err, hash)
+			}
+		}
+	}
+
+	return overrides, nil
+}
+
+func assertBalanceChange(change *OverrideBalanceChange) error {
+	if change == nil {
+		return errors.New("balance change cannot be nil")
+	}
+
+	if err := asserter.AccountIdentifier(change.Account); err != nil {
+		return err
+	}
+
+	if err := asserter.Currency(change.Currency); err != nil {
+		return err
+	}
+
+	if _, err := types.BigInt(change.Difference); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// successfulStatus returns an operation status
+// the implementation considers successful.
+func successfulStatus(blockAsserter *asserter.Asserter) (string, error) {
+	config, err := blockAsserter.ClientConfiguration()
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to get asserter configuration", err)
+	}
+
+	for _, status := range config.AllowedOperationStatuses {
+		if status.Successful {
+			return status.Status, nil
+		}
+	}
+
+	return "", ErrNoSuccessfulStatus
+}
+
+var _ modules.BlockWorker = (*OverridesWorker)(nil)
+
+// OverridesWorker wraps a modules.BlockWorker (i.e. balance storage) and
+// replaces the operations of overridden transactions with operations
+// that result in the corrected balance changes before the block is
+// passed to the wrapped worker.
+type OverridesWorker struct {
+	worker   modules.BlockWorker
+	db       database.Database
+	counters *modules.CounterStorage
+	status   string
+
+	overrides []*TransactionOverride
+	byHash    map[string]*TransactionOverride
+}
+
+// NewOverridesWorker returns a new *OverridesWorker.
+func NewOverridesWorker(
+	worker modules.BlockWorker,
+	db database.Database,
+	counters *modules.CounterStorage,
+	blockAsserter *asserter.Asserter,
+	overrides []*TransactionOverride,
+) (*OverridesWorker, error) {
+	status, err := successfulStatus(blockAsserter)
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := map[string]*TransactionOverride{}
+	for _, override := range overrides {
+		byHash[override.TransactionIdentifier.Hash] = override
+	}
+
+	return &OverridesWorker{
+		worker:    worker,
+		db:        db,
+		counters:  counters,
+		status:    status,
+		overrides: overrides,
+		byHash:    byHash,
+	}, nil
+}
+
+func observedKey(transactionIdentifier *types.TransactionIdentifier) []byte {
+	return []byte(fmt.Sprintf("%s/%s", observedNamespace, transactionIdentifier.Hash))
+}
+
+// operations returns the operations that result
+// in the balance changes of override.
+func (w *OverridesWorker) operations(override *TransactionOverride) []*types.Operation {
+	operations := make([]*types.Operation, len(override.BalanceChanges))
+	for i, change := range override.BalanceChanges {
+		operations[i] = &types.Operation{
+			OperationIdentifier: &types.OperationIdentifier{Index: int64(i)},
+			Type:                "override",
+			Status:              types.String(w.status),
+			Account:             change.Account,
+			Amount: &types.Amount{
+				Value:    change.Difference,
+				Currency: change.Currency,
+			},
+		}
+	}
+
+	return operations
+}
+
+// apply returns a copy of block with the operations of all
+// overridden transactions replaced and the overrides that
+// were applied. If no overrides apply, block is returned.
+func (w *OverridesWorker) apply(block *types.Block) (*types.Block, []*TransactionOverride) {
+	applied := []*TransactionOverride{}
+	transactions := make([]*types.Transaction, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		transactions[i] = tx
+
+		override, ok := w.byHash[tx.TransactionIdentifier.Hash]
+		if !ok {
+			continue
+		}
+
+		overridden := *tx
+		overridden.Operations = w.operations(override)
+		transactions[i] = &overridden
+		applied = append(applied, override)
+	}
+
+	if len(applied) == 0 {
+		return block, applied
+	}
+
+	overriddenBlock := *block
+	overriddenBlock.Transactions = transactions
+
+	return &overriddenBlock, applied
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (w *OverridesWorker) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	overriddenBlock, applied := w.apply(block)
+	for _, override := range applied {
+		color.Yellow(
+			"[OVERRIDE] replacing balance changes of transaction %s in block %s",
+			override.TransactionIdentifier.Hash,
+			types.PrintStruct(block.BlockIdentifier),
+		)
+
+		key := observedKey(override.TransactionIdentifier)
+		if err := transaction.Set(ctx, key, []byte{}, true); err != nil {
+			return nil, fmt.Errorf("%w: unable to store observed override", err)
+		}
+
+		if _, err := w.counters.UpdateTransactional(
+			ctx,
+			transaction,
+			OverridesAppliedCounter,
+			big.NewInt(1),
+		); err != nil {
+			return nil, fmt.Errorf("%w: unable to update applied overrides counter", err)
+		}
+	}
+
+	return w.worker.AddingBlock(ctx, g, overriddenBlock, transaction)
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+func (w *OverridesWorker) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	overriddenBlock, applied := w.apply(block)
+	for _, override := range applied {
+		color.Yellow(
+			"[OVERRIDE] reverting balance changes of transaction %s in orphaned block %s",
+			override.TransactionIdentifier.Hash,
+			types.PrintStruct(block.BlockIdentifier),
+		)
+	}
+
+	return w.worker.RemovingBlock(ctx, g, overriddenBlock, transaction)
+}
+
+// TransactionOverridesResults returns the *TransactionOverridesResults of all overrides.
+func (w *OverridesWorker) Results(ctx context.Context) (*results.TransactionOverridesResults, error) {
+	applied, err := w.counters.Get(ctx, OverridesAppliedCounter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get applied overrides counter", err)
+	}
+
+	dbTx := w.db.ReadTransaction(ctx)
+	defer dbTx.Discard(ctx)
+
+	unobserved := []*types.TransactionIdentifier{}
+	for _, override := range w.overrides {
+		exists, _, err := dbTx.Get(ctx, observedKey(override.TransactionIdentifier))
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to get observed override", err)
+		}
+
+		if !exists {
+			unobserved = append(unobserved, override.TransactionIdentifier)
+		}
+	}
+
+	return &results.TransactionOverridesResults{
+		Configured: len(w.overrides),
+		Applied:    applied.Int64(),
+		Unobserved: unobserved,
+	}, nil
+}
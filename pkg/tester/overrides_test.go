@@ -0,0 +1,273 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/neilotoole/errgroup"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	overridesBtc = &types.Currency{Symbol: "BTC", Decimals: 8}
+
+	override = &TransactionOverride{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx1"},
+		BalanceChanges: []*OverrideBalanceChange{
+			{
+				Account:    &types.AccountIdentifier{Address: "addr1"},
+				Currency:   overridesBtc,
+				Difference: "-10",
+			},
+			{
+				Account:    &types.AccountIdentifier{Address: "addr2"},
+				Currency:   overridesBtc,
+				Difference: "10",
+			},
+		},
+	}
+)
+
+func TestLoadTransactionOverrides(t *testing.T) {
+	var tests = map[string]struct {
+		overrides []*TransactionOverride
+
+		expectedErr bool
+	}{
+		"valid": {
+			overrides: []*TransactionOverride{override},
+		},
+		"missing transaction identifier": {
+			overrides: []*TransactionOverride{
+				{
+					BalanceChanges: override.BalanceChanges,
+				},
+			},
+			expectedErr: true,
+		},
+		"duplicate transaction": {
+			overrides:   []*TransactionOverride{override, override},
+			expectedErr: true,
+		},
+		"invalid difference": {
+			overrides: []*TransactionOverride{
+				{
+					TransactionIdentifier: override.TransactionIdentifier,
+					BalanceChanges: []*OverrideBalanceChange{
+						{
+							Account:    &types.AccountIdentifier{Address: "addr1"},
+							Currency:   overridesBtc,
+							Difference: "ten",
+						},
+					},
+				},
+			},
+			expectedErr: true,
+		},
+		"missing currency": {
+			overrides: []*TransactionOverride{
+				{
+					TransactionIdentifier: override.TransactionIdentifier,
+					BalanceChanges: []*OverrideBalanceChange{
+						{
+							Account:    &types.AccountIdentifier{Address: "addr1"},
+							Difference: "10",
+						},
+					},
+				},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			filePath := path.Join(dir, "overrides.json")
+			assert.NoError(t, utils.SerializeAndWrite(filePath, test.overrides))
+
+			overrides, err := LoadTransactionOverrides(filePath)
+			if test.expectedErr {
+				assert.Error(t, err)
+				assert.Nil(t, overrides)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.overrides, overrides)
+			}
+		})
+	}
+}
+
+// recordingWorker records the last block passed
+// to it.
+type recordingWorker struct {
+	added   *types.Block
+	removed *types.Block
+}
+
+func (r *recordingWorker) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	r.added = block
+	return nil, nil
+}
+
+func (r *recordingWorker) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	r.removed = block
+	return nil, nil
+}
+
+func TestOverridesWorker(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	blockAsserter, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		&types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		[]string{"transfer", "override"},
+		[]*types.OperationStatus{
+			{Status: "SUCCESS", Successful: true},
+			{Status: "FAILURE", Successful: false},
+		},
+		[]*types.Error{},
+		nil,
+		&asserter.Validations{Enabled: false},
+	)
+	assert.NoError(t, err)
+
+	unobserved := &TransactionOverride{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx3"},
+		BalanceChanges:        []*OverrideBalanceChange{},
+	}
+
+	counters := modules.NewCounterStorage(db)
+	recorder := &recordingWorker{}
+	w, err := NewOverridesWorker(
+		recorder,
+		db,
+		counters,
+		blockAsserter,
+		[]*TransactionOverride{override, unobserved},
+	)
+	assert.NoError(t, err)
+
+	untouched := &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx2"},
+		Operations: []*types.Operation{
+			{
+				OperationIdentifier: &types.OperationIdentifier{Index: 0},
+				Type:                "transfer",
+				Status:              types.String("SUCCESS"),
+				Account:             &types.AccountIdentifier{Address: "addr3"},
+				Amount:              &types.Amount{Value: "5", Currency: overridesBtc},
+			},
+		},
+	}
+	block := &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: 1, Hash: "block 1"},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx1"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "transfer",
+						Status:              types.String("SUCCESS"),
+						Account:             &types.AccountIdentifier{Address: "addr1"},
+						Amount:              &types.Amount{Value: "-100", Currency: overridesBtc},
+					},
+				},
+			},
+			untouched,
+		},
+	}
+
+	dbTx := db.Transaction(ctx)
+	_, err = w.AddingBlock(ctx, nil, block, dbTx)
+	assert.NoError(t, err)
+	assert.NoError(t, dbTx.Commit(ctx))
+
+	// The original block is not modified.
+	assert.Len(t, block.Transactions[0].Operations, 1)
+
+	p := parser.New(blockAsserter, nil, nil)
+	changes, err := p.BalanceChanges(ctx, recorder.added, false)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*parser.BalanceChange{
+		{
+			Account:    &types.AccountIdentifier{Address: "addr1"},
+			Currency:   overridesBtc,
+			Block:      block.BlockIdentifier,
+			Difference: "-10",
+		},
+		{
+			Account:    &types.AccountIdentifier{Address: "addr2"},
+			Currency:   overridesBtc,
+			Block:      block.BlockIdentifier,
+			Difference: "10",
+		},
+		{
+			Account:    &types.AccountIdentifier{Address: "addr3"},
+			Currency:   overridesBtc,
+			Block:      block.BlockIdentifier,
+			Difference: "5",
+		},
+	}, changes)
+	assert.Equal(t, untouched, recorder.added.Transactions[1])
+
+	dbTx = db.Transaction(ctx)
+	_, err = w.RemovingBlock(ctx, nil, block, dbTx)
+	assert.NoError(t, err)
+	dbTx.Discard(ctx)
+	assert.Equal(t, recorder.added, recorder.removed)
+
+	overridesResults, err := w.Results(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &results.TransactionOverridesResults{
+		Configured: 2,
+		Applied:    1,
+		Unobserved: []*types.TransactionIdentifier{unobserved.TransactionIdentifier},
+	}, overridesResults)
+}
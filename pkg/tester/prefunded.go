@@ -0,0 +1,191 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+var (
+	// ErrPrefundedAccountUnfunded is returned when a prefunded
+	// account has no balance of its configured currency.
+	ErrPrefundedAccountUnfunded = errors.New("prefunded account is not funded")
+
+	// ErrPrefundedCurrencyMismatch is returned when the currency of a
+	// prefunded account is not returned by /account/balance but a
+	// currency with the same symbol (or the only returned currency)
+	// is returned instead.
+	ErrPrefundedCurrencyMismatch = errors.New("prefunded account currency mismatch")
+)
+
+// prefundedBalance is the balance of the configured
+// currency of a prefunded account.
+type prefundedBalance struct {
+	address  string
+	currency *types.Currency
+	balance  string
+}
+
+// printPrefundedBalances writes a table of prefunded
+// account balances to w.
+func printPrefundedBalances(w io.Writer, balances []*prefundedBalance) {
+	table := tablewriter.NewWriter(w)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Prefunded Account", "Currency", "Balance"})
+	for _, balance := range balances {
+		table.Append([]string{
+			balance.address,
+			types.PrintStruct(balance.currency),
+			balance.balance,
+		})
+	}
+
+	table.Render()
+}
+
+// verifyPrefundedAccount returns the balance of the configured
+// currency of account (as returned by the online node).
+func verifyPrefundedAccount(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	onlineFetcher *fetcher.Fetcher,
+	account *configuration.PrefundedAccount,
+) (*prefundedBalance, error) {
+	address := account.AccountIdentifier.Address
+	_, amounts, _, fetchErr := onlineFetcher.AccountBalanceRetry(
+		ctx,
+		network,
+		account.AccountIdentifier,
+		nil,
+		nil,
+	)
+	if fetchErr != nil {
+		return nil, fmt.Errorf(
+			"%w: unable to fetch balance of prefunded account %s",
+			fetchErr.Err,
+			address,
+		)
+	}
+
+	configuredHash := types.Hash(account.Currency)
+	var mismatched *types.Currency
+	for _, amount := range amounts {
+		if types.Hash(amount.Currency) == configuredHash {
+			balance := &prefundedBalance{
+				address:  address,
+				currency: account.Currency,
+				balance:  amount.Value,
+			}
+
+			value, err := types.BigInt(amount.Value)
+			if err != nil {
+				return balance, fmt.Errorf(
+					"%w: invalid balance of prefunded account %s",
+					err,
+					address,
+				)
+			}
+
+			if value.Sign() <= 0 {
+				return balance, fmt.Errorf(
+					"%w: %s has a %s balance of %s",
+					ErrPrefundedAccountUnfunded,
+					address,
+					types.PrintStruct(account.Currency),
+					amount.Value,
+				)
+			}
+
+			return balance, nil
+		}
+
+		if strings.EqualFold(amount.Currency.Symbol, account.Currency.Symbol) {
+			mismatched = amount.Currency
+		}
+	}
+
+	if mismatched == nil && len(amounts) == 1 {
+		mismatched = amounts[0].Currency
+	}
+
+	balance := &prefundedBalance{
+		address:  address,
+		currency: account.Currency,
+		balance:  "<missing>",
+	}
+	if mismatched != nil {
+		return balance, fmt.Errorf(
+			"%w: %s is configured with currency %s but /account/balance returned %s",
+			ErrPrefundedCurrencyMismatch,
+			address,
+			types.PrintStruct(account.Currency),
+			types.PrintStruct(mismatched),
+		)
+	}
+
+	return balance, fmt.Errorf(
+		"%w: %s has no %s balance",
+		ErrPrefundedAccountUnfunded,
+		address,
+		types.PrintStruct(account.Currency),
+	)
+}
+
+// VerifyPrefundedAccounts ensures each prefunded account has a
+// non-zero balance of its configured currency on the online
+// node before any workflows are run (so a typo'd address or
+// currency does not leave check:construction waiting for funds
+// forever). The balance of each prefunded account is printed.
+// If construction.skip_prefunded_verification is enabled, no
+// balances are fetched.
+func VerifyPrefundedAccounts(
+	ctx context.Context,
+	config *configuration.Configuration,
+	network *types.NetworkIdentifier,
+	onlineFetcher *fetcher.Fetcher,
+) error {
+	if config.Construction.SkipPrefundedVerification ||
+		len(config.Construction.PrefundedAccounts) == 0 {
+		return nil
+	}
+
+	var verifyErr error
+	balances := []*prefundedBalance{}
+	for _, account := range config.Construction.PrefundedAccounts {
+		balance, err := verifyPrefundedAccount(ctx, network, onlineFetcher, account)
+		if balance != nil {
+			balances = append(balances, balance)
+		}
+
+		if err != nil && verifyErr == nil {
+			verifyErr = err
+		}
+	}
+
+	printPrefundedBalances(color.Output, balances)
+	return verifyErr
+}
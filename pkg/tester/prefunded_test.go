@@ -0,0 +1,154 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPrefundedAccounts(t *testing.T) {
+	btc := &types.Currency{Symbol: "BTC", Decimals: 8}
+
+	var tests = map[string]struct {
+		balances []*types.Amount
+		skip     bool
+
+		expectedRequests int64
+		expectedErr      error
+		expectedMessage  string
+	}{
+		"funded": {
+			balances: []*types.Amount{
+				{Value: "0", Currency: &types.Currency{Symbol: "ETH", Decimals: 18}},
+				{Value: "100", Currency: btc},
+			},
+			expectedRequests: 1,
+		},
+		"zero balance": {
+			balances:         []*types.Amount{{Value: "0", Currency: btc}},
+			expectedRequests: 1,
+			expectedErr:      ErrPrefundedAccountUnfunded,
+			expectedMessage:  "has a {\"symbol\":\"BTC\",\"decimals\":8} balance of 0",
+		},
+		"no balances": {
+			balances:         []*types.Amount{},
+			expectedRequests: 1,
+			expectedErr:      ErrPrefundedAccountUnfunded,
+		},
+		"wrong decimals": {
+			balances: []*types.Amount{
+				{Value: "0", Currency: &types.Currency{Symbol: "ETH", Decimals: 18}},
+				{Value: "100", Currency: &types.Currency{Symbol: "BTC", Decimals: 6}},
+			},
+			expectedRequests: 1,
+			expectedErr:      ErrPrefundedCurrencyMismatch,
+			expectedMessage: "configured with currency {\"symbol\":\"BTC\",\"decimals\":8} " +
+				"but /account/balance returned {\"symbol\":\"BTC\",\"decimals\":6}",
+		},
+		"wrong symbol": {
+			balances: []*types.Amount{
+				{Value: "100", Currency: &types.Currency{Symbol: "XBT", Decimals: 8}},
+			},
+			expectedRequests: 1,
+			expectedErr:      ErrPrefundedCurrencyMismatch,
+			expectedMessage:  "returned {\"symbol\":\"XBT\",\"decimals\":8}",
+		},
+		"skipped": {
+			balances: []*types.Amount{},
+			skip:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests int64
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "/account/balance", r.URL.Path)
+					atomic.AddInt64(&requests, 1)
+
+					var request types.AccountBalanceRequest
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+					assert.Equal(t, "addr1", request.AccountIdentifier.Address)
+
+					w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+					assert.NoError(t, json.NewEncoder(w).Encode(&types.AccountBalanceResponse{
+						BlockIdentifier: &types.BlockIdentifier{Index: 10, Hash: "block 10"},
+						Balances:        test.balances,
+					}))
+				}),
+			)
+			defer ts.Close()
+
+			config := configuration.DefaultConfiguration()
+			config.Construction = &configuration.ConstructionConfiguration{
+				SkipPrefundedVerification: test.skip,
+				PrefundedAccounts: []*configuration.PrefundedAccount{
+					{
+						PrefundedAccount: modules.PrefundedAccount{
+							AccountIdentifier: &types.AccountIdentifier{Address: "addr1"},
+							CurveType:         types.Secp256k1,
+							Currency:          btc,
+						},
+					},
+				},
+			}
+
+			err := VerifyPrefundedAccounts(
+				context.Background(),
+				config,
+				network,
+				fetcher.New(ts.URL, fetcher.WithMaxRetries(0)),
+			)
+			assert.Equal(t, test.expectedRequests, atomic.LoadInt64(&requests))
+			if test.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, test.expectedErr))
+			assert.Contains(t, err.Error(), "addr1")
+			assert.Contains(t, err.Error(), test.expectedMessage)
+		})
+	}
+}
+
+func TestPrintPrefundedBalances(t *testing.T) {
+	var b bytes.Buffer
+	printPrefundedBalances(&b, []*prefundedBalance{
+		{
+			address:  "addr1",
+			currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+			balance:  "100",
+		},
+	})
+
+	assert.Contains(t, b.String(), "addr1")
+	assert.Contains(t, b.String(), "100")
+}
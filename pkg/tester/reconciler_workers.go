@@ -0,0 +1,129 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// reconcileFunc runs a reconciler with the provided
+// active and inactive concurrency until ctx is done.
+type reconcileFunc func(ctx context.Context, active int, inactive int) error
+
+// reconcilerWorkers runs a reconciler and restarts it
+// whenever its concurrency changes. Reconciliations
+// interrupted by a restart are queued again by the
+// reconciler, so no accounts are skipped.
+type reconcilerWorkers struct {
+	reconcile reconcileFunc
+
+	lock             sync.Mutex
+	active           int
+	inactive         int
+	inactiveDisabled bool
+	restart          chan struct{}
+}
+
+// newReconcilerWorkers returns a new *reconcilerWorkers
+// that calls reconcile with the provided concurrency.
+func newReconcilerWorkers(
+	active int,
+	inactive int,
+	reconcile reconcileFunc,
+) *reconcilerWorkers {
+	return &reconcilerWorkers{
+		reconcile: reconcile,
+		active:    active,
+		inactive:  inactive,
+		restart:   make(chan struct{}),
+	}
+}
+
+// SetConcurrency changes the active and inactive
+// concurrency of a running reconciler.
+func (w *reconcilerWorkers) SetConcurrency(active int, inactive int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.active == active && w.inactive == inactive {
+		return
+	}
+
+	w.active = active
+	w.inactive = inactive
+	close(w.restart)
+	w.restart = make(chan struct{})
+}
+
+// DisableInactive stops inactive reconciliation
+// (regardless of the inactive concurrency).
+func (w *reconcilerWorkers) DisableInactive() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.inactiveDisabled {
+		return
+	}
+
+	w.inactiveDisabled = true
+	close(w.restart)
+	w.restart = make(chan struct{})
+}
+
+// concurrency returns the active and inactive concurrency
+// to run the reconciler with and a channel that is closed
+// when it changes.
+func (w *reconcilerWorkers) concurrency() (int, int, chan struct{}) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	inactive := w.inactive
+	if w.inactiveDisabled {
+		inactive = 0
+	}
+
+	return w.active, inactive, w.restart
+}
+
+// Run runs the reconciler until ctx is done or
+// it returns an error.
+func (w *reconcilerWorkers) Run(ctx context.Context) error {
+	for {
+		active, inactive, restart := w.concurrency()
+
+		runCtx, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		go func() {
+			errs <- w.reconcile(runCtx, active, inactive)
+		}()
+
+		select {
+		case err := <-errs:
+			cancel()
+			return err
+		case <-restart:
+			cancel()
+			if err := <-errs; err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// concurrency is the active and inactive concurrency
+// a reconciler was run with.
+type concurrency struct {
+	active   int
+	inactive int
+}
+
+func TestReconcilerWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := make(chan concurrency)
+	workers := newReconcilerWorkers(
+		2,
+		1,
+		func(ctx context.Context, active int, inactive int) error {
+			runs <- concurrency{active: active, inactive: inactive}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- workers.Run(ctx)
+	}()
+	assert.Equal(t, concurrency{active: 2, inactive: 1}, <-runs)
+
+	// The reconciler is restarted when the concurrency changes.
+	workers.SetConcurrency(4, 2)
+	assert.Equal(t, concurrency{active: 4, inactive: 2}, <-runs)
+
+	// Inactive reconciliation stays disabled when the
+	// concurrency changes.
+	workers.DisableInactive()
+	assert.Equal(t, concurrency{active: 4, inactive: 0}, <-runs)
+	workers.SetConcurrency(8, 2)
+	assert.Equal(t, concurrency{active: 8, inactive: 0}, <-runs)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func TestReconcilerWorkersError(t *testing.T) {
+	errReconcile := errors.New("reconciliation failed")
+	workers := newReconcilerWorkers(
+		1,
+		1,
+		func(ctx context.Context, active int, inactive int) error {
+			return errReconcile
+		},
+	)
+
+	assert.True(t, errors.Is(workers.Run(context.Background()), errReconcile))
+}
@@ -0,0 +1,326 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// ReloadPath is the path of the status server that
+	// reloads the configuration file (on POST).
+	ReloadPath = "/config/reload"
+
+	// unset is the value of a field that
+	// is not populated.
+	unset = "null"
+)
+
+var (
+	// mutableFields are applied to a running check:data
+	// when the configuration file is reloaded.
+	mutableFields = map[string]struct{}{
+		"tip_delay":                {},
+		"data.log_blocks":          {},
+		"data.log_transactions":    {},
+		"data.log_balance_changes": {},
+		"data.log_reconciliations": {},
+
+		"data.active_reconciliation_concurrency":   {},
+		"data.inactive_reconciliation_concurrency": {},
+		"data.inactive_reconciliation_frequency":   {},
+	}
+)
+
+// ConfigurationChange is a change to a configuration field
+// (identified by its dot-separated JSON path).
+type ConfigurationChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ReloadResults are the changes found when the
+// configuration file is reloaded.
+type ReloadResults struct {
+	// Applied are the changes applied to the running check.
+	Applied []*ConfigurationChange `json:"applied"`
+
+	// Ignored are the changes to fields that cannot
+	// be changed during a run.
+	Ignored []*ConfigurationChange `json:"ignored,omitempty"`
+}
+
+// Print logs each ConfigurationChange in ReloadResults to the console.
+func (r *ReloadResults) Print() {
+	for _, change := range r.Applied {
+		color.Cyan("reloaded %s: %s -> %s", change.Field, change.Old, change.New)
+	}
+
+	for _, change := range r.Ignored {
+		color.Yellow(
+			"[WARNING] %s cannot be changed during a run (ignoring change from %s to %s)",
+			change.Field,
+			change.Old,
+			change.New,
+		)
+	}
+
+	if len(r.Applied) == 0 && len(r.Ignored) == 0 {
+		color.Cyan("reloaded configuration file: no changes")
+	}
+}
+
+// ConfigurationSnapshot is the JSON value of each field of a
+// configuration (keyed by dot-separated JSON path).
+// Arrays are a single value.
+type ConfigurationSnapshot map[string]string
+
+// NewConfigurationSnapshot returns the ConfigurationSnapshot of config (with
+// sensitive values redacted so that changes can be logged).
+func NewConfigurationSnapshot(config *configuration.Configuration) (ConfigurationSnapshot, error) {
+	b, err := json.Marshal(configuration.RedactedConfiguration(config))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal configuration", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode configuration", err)
+	}
+
+	snapshot := ConfigurationSnapshot{}
+	if err := snapshot.add("", fields); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// add adds each (nested) field in fields to the
+// ConfigurationSnapshot with the provided prefix.
+func (s ConfigurationSnapshot) add(prefix string, fields map[string]interface{}) error {
+	for key, value := range fields {
+		field := prefix + key
+		if nested, ok := value.(map[string]interface{}); ok {
+			if err := s.add(field+".", nested); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("%w: unable to marshal %s", err, field)
+		}
+
+		s[field] = string(b)
+	}
+
+	return nil
+}
+
+// value returns the value of field (or unset
+// if it is not populated).
+func (s ConfigurationSnapshot) value(field string) string {
+	value, ok := s[field]
+	if !ok {
+		return unset
+	}
+
+	return value
+}
+
+// DiffSnapshots returns the changes from previous to next,
+// sorted by field.
+func DiffSnapshots(previous ConfigurationSnapshot, next ConfigurationSnapshot) *ReloadResults {
+	fields := map[string]struct{}{}
+	for field := range previous {
+		fields[field] = struct{}{}
+	}
+	for field := range next {
+		fields[field] = struct{}{}
+	}
+
+	sortedFields := make([]string, 0, len(fields))
+	for field := range fields {
+		sortedFields = append(sortedFields, field)
+	}
+	sort.Strings(sortedFields)
+
+	results := &ReloadResults{Applied: []*ConfigurationChange{}}
+	for _, field := range sortedFields {
+		previousValue, nextValue := previous.value(field), next.value(field)
+		if previousValue == nextValue {
+			continue
+		}
+
+		change := &ConfigurationChange{Field: field, Old: previousValue, New: nextValue}
+		if _, ok := mutableFields[field]; ok {
+			results.Applied = append(results.Applied, change)
+			continue
+		}
+
+		results.Ignored = append(results.Ignored, change)
+	}
+
+	return results
+}
+
+// ConfigurationApplier applies the mutable fields of a
+// reloaded configuration to a running check.
+type ConfigurationApplier interface {
+	ApplyConfiguration(config *configuration.Configuration)
+}
+
+// Reloader reloads a configuration file (on SIGHUP or when
+// requested) and applies changes to mutable fields. Changes
+// to other fields are logged and ignored.
+type Reloader struct {
+	filePath string
+	profile  string
+	applier  ConfigurationApplier
+
+	lock     sync.Mutex
+	snapshot ConfigurationSnapshot
+}
+
+// NewReloader returns a new *Reloader of the configuration file
+// at filePath (with profile applied), where snapshot is
+// the ConfigurationSnapshot of the configuration when it was loaded.
+func NewReloader(
+	filePath string,
+	profile string,
+	snapshot ConfigurationSnapshot,
+	applier ConfigurationApplier,
+) *Reloader {
+	return &Reloader{
+		filePath: filePath,
+		profile:  profile,
+		applier:  applier,
+		snapshot: snapshot,
+	}
+}
+
+// Reload loads the configuration file, applies any changes
+// to mutable fields, and logs each change found.
+func (r *Reloader) Reload(ctx context.Context) (*ReloadResults, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	config, err := configuration.LoadConfigurationProfile(ctx, r.filePath, r.profile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to reload configuration file", err)
+	}
+
+	snapshot, err := NewConfigurationSnapshot(config)
+	if err != nil {
+		return nil, err
+	}
+
+	results := DiffSnapshots(r.snapshot, snapshot)
+	if len(results.Applied) > 0 {
+		r.applier.ApplyConfiguration(config)
+	}
+
+	// Only applied changes are recorded so that ignored
+	// changes are reported again on the next reload.
+	for _, change := range results.Applied {
+		if change.New == unset {
+			delete(r.snapshot, change.Field)
+			continue
+		}
+
+		r.snapshot[change.Field] = change.New
+	}
+
+	results.Print()
+	return results, nil
+}
+
+// Start reloads the configuration file whenever SIGHUP
+// is received until ctx is done. If r is nil (no
+// configuration file was loaded), Start returns nil.
+func (r *Reloader) Start(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sigs:
+			if _, err := r.Reload(ctx); err != nil {
+				color.Red("%s (received SIGHUP)", err.Error())
+			}
+		}
+	}
+}
+
+// ReloadHandler reloads the configuration file of reloader on a
+// POST to ReloadPath (responding with the *ReloadResults) and forwards
+// all other requests to next.
+func ReloadHandler(reloader *Reloader, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != ReloadPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if reloader == nil {
+			http.Error(w, "no configuration file was loaded", http.StatusConflict)
+			return
+		}
+
+		results, err := reloader.Reload(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
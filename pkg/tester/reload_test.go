@@ -0,0 +1,247 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockApplier struct {
+	applied []*configuration.Configuration
+}
+
+func (m *mockApplier) ApplyConfiguration(config *configuration.Configuration) {
+	m.applied = append(m.applied, config)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	var tests = map[string]struct {
+		modify func(config *configuration.Configuration)
+
+		expected *ReloadResults
+	}{
+		"no changes": {
+			modify:   func(config *configuration.Configuration) {},
+			expected: &ReloadResults{Applied: []*ConfigurationChange{}},
+		},
+		"mutable fields": {
+			modify: func(config *configuration.Configuration) {
+				config.TipDelay = 60
+				config.Data.LogBalanceChanges = true
+			},
+			expected: &ReloadResults{
+				Applied: []*ConfigurationChange{
+					{Field: "data.log_balance_changes", Old: "false", New: "true"},
+					{Field: "tip_delay", Old: "300", New: "60"},
+				},
+			},
+		},
+		"reconciler fields": {
+			modify: func(config *configuration.Configuration) {
+				config.Data.ActiveReconciliationConcurrency = 32
+				config.Data.InactiveReconciliationFrequency = 100
+			},
+			expected: &ReloadResults{
+				Applied: []*ConfigurationChange{
+					{Field: "data.active_reconciliation_concurrency", Old: "16", New: "32"},
+					{Field: "data.inactive_reconciliation_frequency", Old: "250", New: "100"},
+				},
+			},
+		},
+		"immutable fields": {
+			modify: func(config *configuration.Configuration) {
+				config.OnlineURL = "http://other:8080"
+				config.Network.Network = "Testnet"
+				config.DataDirectory = "other"
+				config.Data.LogBlocks = true
+			},
+			expected: &ReloadResults{
+				Applied: []*ConfigurationChange{
+					{Field: "data.log_blocks", Old: "false", New: "true"},
+				},
+				Ignored: []*ConfigurationChange{
+					{Field: "data_directory", Old: `""`, New: `"other"`},
+					{Field: "network.network", Old: `"Ropsten"`, New: `"Testnet"`},
+					{Field: "online_url", Old: `"http://localhost:8080"`, New: `"http://other:8080"`},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			previous, err := NewConfigurationSnapshot(configuration.DefaultConfiguration())
+			assert.NoError(t, err)
+
+			config := configuration.DefaultConfiguration()
+			test.modify(config)
+			next, err := NewConfigurationSnapshot(config)
+			assert.NoError(t, err)
+
+			assert.Equal(t, test.expected, DiffSnapshots(previous, next))
+		})
+	}
+}
+
+func TestNewConfigurationSnapshotRedacted(t *testing.T) {
+	config := configuration.DefaultConfiguration()
+	config.Headers = map[string]string{"Authorization": "Bearer secret"}
+
+	snapshot, err := NewConfigurationSnapshot(config)
+	assert.NoError(t, err)
+	assert.NotContains(t, snapshot["headers.Authorization"], "secret")
+}
+
+// writeConfiguration writes config to filePath.
+func writeConfiguration(t *testing.T, filePath string, config *configuration.Configuration) {
+	assert.NoError(t, utils.SerializeAndWrite(filePath, config))
+}
+
+func TestReloader(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	filePath := path.Join(dir, "config.json")
+	writeConfiguration(t, filePath, configuration.DefaultConfiguration())
+	config, err := configuration.LoadConfiguration(ctx, filePath)
+	assert.NoError(t, err)
+
+	snapshot, err := NewConfigurationSnapshot(config)
+	assert.NoError(t, err)
+
+	applier := &mockApplier{}
+	reloader := NewReloader(filePath, "", snapshot, applier)
+
+	// Reloading an unchanged file applies nothing.
+	results, err := reloader.Reload(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &ReloadResults{Applied: []*ConfigurationChange{}}, results)
+	assert.Len(t, applier.applied, 0)
+
+	changed := configuration.DefaultConfiguration()
+	changed.TipDelay = 60
+	changed.OnlineURL = "http://other:8080"
+	writeConfiguration(t, filePath, changed)
+
+	results, err = reloader.Reload(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ConfigurationChange{{Field: "tip_delay", Old: "300", New: "60"}}, results.Applied)
+	assert.Equal(t, []*ConfigurationChange{
+		{Field: "online_url", Old: `"http://localhost:8080"`, New: `"http://other:8080"`},
+	}, results.Ignored)
+	assert.Len(t, applier.applied, 1)
+	assert.Equal(t, int64(60), applier.applied[0].TipDelay)
+
+	// Applied changes are not applied again but ignored
+	// changes are reported on each reload.
+	results, err = reloader.Reload(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, results.Applied, 0)
+	assert.Len(t, results.Ignored, 1)
+	assert.Len(t, applier.applied, 1)
+
+	// An invalid configuration file is not applied.
+	assert.NoError(t, ioutil.WriteFile(
+		filePath,
+		[]byte("{"),
+		os.FileMode(utils.DefaultFilePermissions),
+	))
+	results, err = reloader.Reload(ctx)
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.Len(t, applier.applied, 1)
+}
+
+func TestReloadHandler(t *testing.T) {
+	ctx := context.Background()
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	filePath := path.Join(dir, "config.json")
+	writeConfiguration(t, filePath, configuration.DefaultConfiguration())
+	config, err := configuration.LoadConfiguration(ctx, filePath)
+	assert.NoError(t, err)
+
+	snapshot, err := NewConfigurationSnapshot(config)
+	assert.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	var tests = map[string]struct {
+		reloader *Reloader
+		method   string
+		path     string
+
+		expectedStatus int
+	}{
+		"reload": {
+			reloader:       NewReloader(filePath, "", snapshot, &mockApplier{}),
+			method:         http.MethodPost,
+			path:           ReloadPath,
+			expectedStatus: http.StatusOK,
+		},
+		"wrong method": {
+			reloader:       NewReloader(filePath, "", snapshot, &mockApplier{}),
+			method:         http.MethodGet,
+			path:           ReloadPath,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		"no configuration file": {
+			method:         http.MethodPost,
+			path:           ReloadPath,
+			expectedStatus: http.StatusConflict,
+		},
+		"forwarded": {
+			reloader:       NewReloader(filePath, "", snapshot, &mockApplier{}),
+			method:         http.MethodGet,
+			path:           "/",
+			expectedStatus: http.StatusTeapot,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ReloadHandler(test.reloader, next).ServeHTTP(
+				w,
+				httptest.NewRequest(test.method, test.path, nil),
+			)
+			assert.Equal(t, test.expectedStatus, w.Code)
+
+			if test.expectedStatus == http.StatusOK {
+				var results ReloadResults
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&results))
+				assert.Len(t, results.Applied, 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/coinbase/rosetta-cli/pkg/processor"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// ReplayStep is the effect of a single balance change
+// on the balance of an account.
+type ReplayStep struct {
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+
+	// OperationIdentifier is nil if the balance change
+	// comes from a transaction override.
+	OperationIdentifier *types.OperationIdentifier `json:"operation_identifier,omitempty"`
+
+	Account    *types.AccountIdentifier `json:"account_identifier"`
+	Currency   *types.Currency          `json:"currency"`
+	Before     string                   `json:"before"`
+	Difference string                   `json:"difference"`
+	After      string                   `json:"after"`
+}
+
+// ReplayBalance is the replayed balance of an account
+// compared with the balance in storage.
+type ReplayBalance struct {
+	Account  *types.AccountIdentifier `json:"account_identifier"`
+	Currency *types.Currency          `json:"currency"`
+	Parent   string                   `json:"parent"`
+	Computed string                   `json:"computed"`
+
+	// Stored is not populated if the account
+	// is not tracked in storage.
+	Stored    string `json:"stored,omitempty"`
+	Untracked bool   `json:"untracked,omitempty"`
+	Diverged  bool   `json:"diverged"`
+}
+
+// ReplayResult contains all steps of a block
+// replay and the resulting balances.
+type ReplayResult struct {
+	BlockIdentifier *types.BlockIdentifier `json:"block_identifier"`
+
+	// Fetched is true if the block was not in
+	// storage and was fetched from the node.
+	Fetched bool `json:"fetched"`
+
+	Steps       []*ReplayStep    `json:"steps"`
+	Balances    []*ReplayBalance `json:"balances"`
+	Divergences int              `json:"divergences"`
+}
+
+// Print logs ReplayResult to the console.
+func (r *ReplayResult) Print() {
+	source := "storage"
+	if r.Fetched {
+		source = "node"
+	}
+
+	color.Cyan(
+		"Replaying block %d (%s) loaded from %s",
+		r.BlockIdentifier.Index,
+		r.BlockIdentifier.Hash,
+		source,
+	)
+
+	for _, step := range r.Steps {
+		operation := "override"
+		if step.OperationIdentifier != nil {
+			operation = "operation " + strconv.FormatInt(step.OperationIdentifier.Index, 10)
+		}
+
+		fmt.Printf(
+			"transaction %s %s: %s %s %s -> %s (%s)\n",
+			step.TransactionIdentifier.Hash,
+			operation,
+			types.PrintStruct(step.Account),
+			types.PrintStruct(step.Currency),
+			step.Before,
+			step.After,
+			step.Difference,
+		)
+	}
+
+	fmt.Printf("\n")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetRowLine(true)
+	table.SetRowSeparator("-")
+	table.SetHeader([]string{"Account", "Currency", "Parent", "Computed", "Stored", "Result"})
+	for _, balance := range r.Balances {
+		result := "match"
+		switch {
+		case balance.Untracked:
+			result = "untracked"
+		case balance.Diverged:
+			result = "DIVERGED"
+		}
+
+		table.Append([]string{
+			types.PrintStruct(balance.Account),
+			types.PrintStruct(balance.Currency),
+			balance.Parent,
+			balance.Computed,
+			balance.Stored,
+			result,
+		})
+	}
+	table.Render()
+
+	fmt.Printf("\n")
+	if r.Divergences > 0 {
+		color.Red("%d balances diverged from storage", r.Divergences)
+		return
+	}
+
+	color.Green("All tracked balances match storage")
+}
+
+// ReplayBlock applies the balance changes of block to the balances of
+// each affected account at the parent block and compares the result
+// with the balances stored at block. Like balance storage, unsuccessful
+// operations and operations on accounts matching exemptAccounts are
+// skipped and the operations of transactions in transactionOverrides
+// are replaced.
+//
+// Replay only reads from balanceStorage.
+func ReplayBlock(
+	ctx context.Context,
+	block *types.Block,
+	fetched bool,
+	blockAsserter *asserter.Asserter,
+	balanceStorage *modules.BalanceStorage,
+	exemptAccounts []*types.AccountCurrency,
+	transactionOverrides []*TransactionOverride,
+) (*ReplayResult, error) {
+	exempt := processor.NewAccountMatcher(exemptAccounts)
+
+	overridden := map[string]*TransactionOverride{}
+	for _, override := range transactionOverrides {
+		overridden[override.TransactionIdentifier.Hash] = override
+	}
+
+	r := &replayer{
+		ctx:            ctx,
+		block:          block,
+		balanceStorage: balanceStorage,
+		balances:       map[string]*ReplayBalance{},
+		result: &ReplayResult{
+			BlockIdentifier: block.BlockIdentifier,
+			Fetched:         fetched,
+			Steps:           []*ReplayStep{},
+			Balances:        []*ReplayBalance{},
+		},
+	}
+
+	for _, tx := range block.Transactions {
+		if override, ok := overridden[tx.TransactionIdentifier.Hash]; ok {
+			for _, change := range override.BalanceChanges {
+				if err := r.apply(
+					tx.TransactionIdentifier,
+					nil,
+					change.Account,
+					change.Currency,
+					change.Difference,
+				); err != nil {
+					return nil, err
+				}
+			}
+
+			continue
+		}
+
+		for _, op := range tx.Operations {
+			if op.Account == nil || op.Amount == nil {
+				continue
+			}
+
+			successful, err := blockAsserter.OperationSuccessful(op)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"%w: unable to determine if operation %d of transaction %s is successful",
+					err,
+					op.OperationIdentifier.Index,
+					tx.TransactionIdentifier.Hash,
+				)
+			}
+
+			if !successful {
+				continue
+			}
+
+			if exempt.Match(op.Account, op.Amount.Currency) {
+				continue
+			}
+
+			if err := r.apply(
+				tx.TransactionIdentifier,
+				op.OperationIdentifier,
+				op.Account,
+				op.Amount.Currency,
+				op.Amount.Value,
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := r.compare(); err != nil {
+		return nil, err
+	}
+
+	return r.result, nil
+}
+
+type replayer struct {
+	ctx            context.Context
+	block          *types.Block
+	balanceStorage *modules.BalanceStorage
+
+	balances map[string]*ReplayBalance
+	result   *ReplayResult
+}
+
+// storedBalance returns the balance of account stored at index
+// or nil if the account is not tracked in storage.
+func (r *replayer) storedBalance(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	index int64,
+) (*types.Amount, error) {
+	amount, err := r.balanceStorage.GetBalance(r.ctx, account, currency, index)
+	if errors.Is(err, storageErrs.ErrAccountMissing) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%w: unable to get balance of %s at block %d",
+			err,
+			types.PrintStruct(account),
+			index,
+		)
+	}
+
+	return amount, nil
+}
+
+// balance returns the replayed *ReplayBalance of account, initializing
+// it with the balance stored at the parent block.
+func (r *replayer) balance(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) (*ReplayBalance, error) {
+	key := types.Hash(&types.AccountCurrency{Account: account, Currency: currency})
+	if balance, ok := r.balances[key]; ok {
+		return balance, nil
+	}
+
+	balance := &ReplayBalance{
+		Account:  account,
+		Currency: currency,
+		Parent:   "0",
+	}
+
+	// The genesis block is its own parent, so there
+	// is no balance before it.
+	if r.block.ParentBlockIdentifier.Index != r.block.BlockIdentifier.Index {
+		parent, err := r.storedBalance(account, currency, r.block.ParentBlockIdentifier.Index)
+		if err != nil {
+			return nil, err
+		}
+
+		if parent == nil {
+			balance.Untracked = true
+		} else {
+			balance.Parent = parent.Value
+		}
+	}
+
+	balance.Computed = balance.Parent
+	r.balances[key] = balance
+	r.result.Balances = append(r.result.Balances, balance)
+
+	return balance, nil
+}
+
+// apply adds difference to the replayed balance
+// of account and records the step.
+func (r *replayer) apply(
+	transactionIdentifier *types.TransactionIdentifier,
+	operationIdentifier *types.OperationIdentifier,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	difference string,
+) error {
+	balance, err := r.balance(account, currency)
+	if err != nil {
+		return err
+	}
+
+	after, err := types.AddValues(balance.Computed, difference)
+	if err != nil {
+		return fmt.Errorf(
+			"%w: unable to add %s to balance of %s in transaction %s",
+			err,
+			difference,
+			types.PrintStruct(account),
+			transactionIdentifier.Hash,
+		)
+	}
+
+	r.result.Steps = append(r.result.Steps, &ReplayStep{
+		TransactionIdentifier: transactionIdentifier,
+		OperationIdentifier:   operationIdentifier,
+		Account:               account,
+		Currency:              currency,
+		Before:                balance.Computed,
+		Difference:            difference,
+		After:                 after,
+	})
+	balance.Computed = after
+
+	return nil
+}
+
+// compare populates the stored balance of each
+// replayed balance and flags divergences.
+func (r *replayer) compare() error {
+	for _, balance := range r.result.Balances {
+		stored, err := r.storedBalance(
+			balance.Account,
+			balance.Currency,
+			r.block.BlockIdentifier.Index,
+		)
+		if err != nil {
+			return err
+		}
+
+		if stored == nil {
+			balance.Untracked = true
+			continue
+		}
+
+		balance.Stored = stored.Value
+		difference, err := types.SubtractValues(balance.Computed, balance.Stored)
+		if err != nil {
+			return fmt.Errorf(
+				"%w: unable to compare balances of %s",
+				err,
+				types.PrintStruct(balance.Account),
+			)
+		}
+
+		if difference != "0" {
+			balance.Diverged = true
+			r.result.Divergences++
+		}
+	}
+
+	return nil
+}
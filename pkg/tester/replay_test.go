@@ -0,0 +1,291 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	replayBtc = &types.Currency{Symbol: "BTC", Decimals: 8}
+	addr1     = &types.AccountIdentifier{Address: "addr1"}
+	addr2     = &types.AccountIdentifier{Address: "addr2"}
+	addr3     = &types.AccountIdentifier{Address: "addr3"}
+
+	genesis = &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		ParentBlockIdentifier: &types.BlockIdentifier{Index: 0, Hash: "block 0"},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx1"},
+				Operations: []*types.Operation{
+					operation(0, addr1, "100", "SUCCESS"),
+				},
+			},
+		},
+	}
+
+	replayBlock = &types.Block{
+		BlockIdentifier:       &types.BlockIdentifier{Index: 1, Hash: "block 1"},
+		ParentBlockIdentifier: genesis.BlockIdentifier,
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx2"},
+				Operations: []*types.Operation{
+					operation(0, addr1, "-10", "SUCCESS"),
+					operation(1, addr2, "10", "SUCCESS"),
+					operation(2, addr1, "-50", "FAILURE"),
+					operation(3, addr3, "7", "SUCCESS"),
+				},
+			},
+		},
+	}
+)
+
+func operation(
+	index int64,
+	account *types.AccountIdentifier,
+	value string,
+	status string,
+) *types.Operation {
+	return &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{Index: index},
+		Type:                "transfer",
+		Status:              types.String(status),
+		Account:             account,
+		Amount:              &types.Amount{Value: value, Currency: replayBtc},
+	}
+}
+
+// storageHelper implements modules.BalanceStorageHelper.
+// Balance changes of addr3 are exempt.
+type storageHelper struct {
+	asserter *asserter.Asserter
+}
+
+func (s *storageHelper) AccountBalance(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	block *types.BlockIdentifier,
+) (*types.Amount, error) {
+	return &types.Amount{Value: "0", Currency: currency}, nil
+}
+
+func (s *storageHelper) ExemptFunc() parser.ExemptOperation {
+	return func(op *types.Operation) bool {
+		return op.Account.Address == addr3.Address
+	}
+}
+
+func (s *storageHelper) BalanceExemptions() []*types.BalanceExemption {
+	return nil
+}
+
+func (s *storageHelper) Asserter() *asserter.Asserter {
+	return s.asserter
+}
+
+func (s *storageHelper) AccountsReconciled(
+	ctx context.Context,
+	dbTx database.Transaction,
+) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (s *storageHelper) AccountsSeen(
+	ctx context.Context,
+	dbTx database.Transaction,
+) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+// handler implements modules.BalanceStorageHandler.
+type handler struct{}
+
+func (h *handler) BlockAdded(
+	ctx context.Context,
+	block *types.Block,
+	changes []*parser.BalanceChange,
+) error {
+	return nil
+}
+
+func (h *handler) BlockRemoved(
+	ctx context.Context,
+	block *types.Block,
+	changes []*parser.BalanceChange,
+) error {
+	return nil
+}
+
+func (h *handler) AccountsReconciled(
+	ctx context.Context,
+	dbTx database.Transaction,
+	count int,
+) error {
+	return nil
+}
+
+func (h *handler) AccountsSeen(
+	ctx context.Context,
+	dbTx database.Transaction,
+	count int,
+) error {
+	return nil
+}
+
+func TestReplayBlock(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	blockAsserter, err := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		genesis.BlockIdentifier,
+		[]string{"transfer"},
+		[]*types.OperationStatus{
+			{Status: "SUCCESS", Successful: true},
+			{Status: "FAILURE", Successful: false},
+		},
+		[]*types.Error{},
+		nil,
+		&asserter.Validations{Enabled: false},
+	)
+	assert.NoError(t, err)
+
+	// Sync the blocks like check:data.
+	db, err := database.NewBadgerDatabase(ctx, dir)
+	assert.NoError(t, err)
+
+	helper := &storageHelper{asserter: blockAsserter}
+	balanceStorage := modules.NewBalanceStorage(db)
+	balanceStorage.Initialize(helper, &handler{})
+	blockStorage := modules.NewBlockStorage(db, 1)
+	blockStorage.Initialize([]modules.BlockWorker{balanceStorage})
+	for _, b := range []*types.Block{genesis, replayBlock} {
+		assert.NoError(t, blockStorage.SeeBlock(ctx, b))
+		assert.NoError(t, blockStorage.AddBlock(ctx, b))
+	}
+	assert.NoError(t, db.Close(ctx))
+
+	// Replay from a read-only copy of storage.
+	badgerOptions := database.DefaultBadgerOptions(dir)
+	badgerOptions.ReadOnly = true
+	db, err = database.NewBadgerDatabase(ctx, dir, database.WithCustomSettings(badgerOptions))
+	assert.NoError(t, err)
+	defer db.Close(ctx)
+
+	var tests = map[string]struct {
+		block          *types.Block
+		exemptAccounts []*types.AccountCurrency
+		overrides      []*TransactionOverride
+
+		expectedSteps       int
+		expectedBalances    []*ReplayBalance
+		expectedDivergences int
+	}{
+		"genesis": {
+			block:         genesis,
+			expectedSteps: 1,
+			expectedBalances: []*ReplayBalance{
+				{Account: addr1, Currency: replayBtc, Parent: "0", Computed: "100", Stored: "100"},
+			},
+		},
+		"match": {
+			block: replayBlock,
+			exemptAccounts: []*types.AccountCurrency{
+				{Account: addr3, Currency: replayBtc},
+			},
+			expectedSteps: 2,
+			expectedBalances: []*ReplayBalance{
+				{Account: addr1, Currency: replayBtc, Parent: "100", Computed: "90", Stored: "90"},
+				{Account: addr2, Currency: replayBtc, Parent: "0", Computed: "10", Stored: "10"},
+			},
+		},
+		"untracked": {
+			block:         replayBlock,
+			expectedSteps: 3,
+			expectedBalances: []*ReplayBalance{
+				{Account: addr1, Currency: replayBtc, Parent: "100", Computed: "90", Stored: "90"},
+				{Account: addr2, Currency: replayBtc, Parent: "0", Computed: "10", Stored: "10"},
+				{Account: addr3, Currency: replayBtc, Parent: "0", Computed: "7", Untracked: true},
+			},
+		},
+		"divergence": {
+			block: replayBlock,
+			overrides: []*TransactionOverride{
+				{
+					TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx2"},
+					BalanceChanges: []*OverrideBalanceChange{
+						{Account: addr1, Currency: replayBtc, Difference: "-5"},
+						{Account: addr2, Currency: replayBtc, Difference: "5"},
+					},
+				},
+			},
+			expectedSteps: 2,
+			expectedBalances: []*ReplayBalance{
+				{
+					Account:  addr1,
+					Currency: replayBtc,
+					Parent:   "100",
+					Computed: "95",
+					Stored:   "90",
+					Diverged: true,
+				},
+				{
+					Account:  addr2,
+					Currency: replayBtc,
+					Parent:   "0",
+					Computed: "5",
+					Stored:   "10",
+					Diverged: true,
+				},
+			},
+			expectedDivergences: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := ReplayBlock(
+				ctx,
+				test.block,
+				false,
+				blockAsserter,
+				modules.NewBalanceStorage(db),
+				test.exemptAccounts,
+				test.overrides,
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, test.block.BlockIdentifier, result.BlockIdentifier)
+			assert.Len(t, result.Steps, test.expectedSteps)
+			assert.Equal(t, test.expectedBalances, result.Balances)
+			assert.Equal(t, test.expectedDivergences, result.Divergences)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+)
+
+const (
+	// FailureReportFileName is the name of the failure report
+	// written to the data directory.
+	FailureReportFileName = "failure_report.json"
+
+	// DefaultReportOperations is the number of recent operations
+	// included in a report if none is configured.
+	DefaultReportOperations = 10
+
+	// FailureReportLookback is the maximum number of blocks searched
+	// for recent operations of the failing account.
+	FailureReportLookback = 1000
+)
+
+// ReportBlockStorage is the storage used to
+// populate a *FailureReport.
+type ReportBlockStorage interface {
+	GetHeadBlockIdentifier(ctx context.Context) (*types.BlockIdentifier, error)
+	GetOldestBlockIndex(ctx context.Context) (int64, error)
+	GetBlock(ctx context.Context, blockIdentifier *types.PartialBlockIdentifier) (*types.Block, error)
+}
+
+// ReportOperation is a balance-changing operation
+// of the failing account and currency.
+type ReportOperation struct {
+	Block       *types.BlockIdentifier       `json:"block_identifier"`
+	Transaction *types.TransactionIdentifier `json:"transaction_identifier"`
+	Operation   *types.Operation             `json:"operation"`
+}
+
+// FailureReport describes why check:data halted. The reconciliation
+// failure fields are only populated if check:data halted
+// on a reconciliation failure.
+type FailureReport struct {
+	Error     string                 `json:"error"`
+	HeadBlock *types.BlockIdentifier `json:"head_block_identifier,omitempty"`
+
+	ReconciliationType string                   `json:"reconciliation_type,omitempty"`
+	Account            *types.AccountIdentifier `json:"account_identifier,omitempty"`
+	Currency           *types.Currency          `json:"currency,omitempty"`
+	ComputedBalance    string                   `json:"computed_balance,omitempty"`
+	LiveBalance        string                   `json:"live_balance,omitempty"`
+	Block              *types.BlockIdentifier   `json:"block_identifier,omitempty"`
+
+	// RecentOperations are the most recent balance-changing
+	// operations of Account and Currency at or before Block
+	// (most recent first) that are still in storage.
+	RecentOperations []*ReportOperation `json:"recent_operations,omitempty"`
+}
+
+// NewFailureReport returns a *FailureReport for err. If failure is not nil, the
+// most recent operations (up to maxOperations) of the failing
+// account and currency are pulled from storage.
+func NewFailureReport(
+	ctx context.Context,
+	storage ReportBlockStorage,
+	err error,
+	failure *results.DiscrepancyFailure,
+	maxOperations int,
+) *FailureReport {
+	r := &FailureReport{}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	// Storage is not available if check:data
+	// halted before it was initialized.
+	if storage == nil {
+		return r
+	}
+
+	head, headErr := storage.GetHeadBlockIdentifier(ctx)
+	if headErr == nil {
+		r.HeadBlock = head
+	}
+
+	if failure == nil {
+		return r
+	}
+
+	r.ReconciliationType = failure.ReconciliationType
+	r.Account = failure.Account
+	r.Currency = failure.Currency
+	r.ComputedBalance = failure.ComputedBalance
+	r.LiveBalance = failure.LiveBalance
+	r.Block = failure.Block
+	if failure.Block != nil {
+		r.RecentOperations = recentOperations(ctx, storage, failure, maxOperations)
+	}
+
+	return r
+}
+
+// recentOperations searches storage backwards from the failure
+// block for the most recent operations of the failing account
+// and currency. The search stops at the oldest block in storage
+// (blocks may be pruned) or after FailureReportLookback blocks.
+func recentOperations(
+	ctx context.Context,
+	storage ReportBlockStorage,
+	failure *results.DiscrepancyFailure,
+	maxOperations int,
+) []*ReportOperation {
+	operations := []*ReportOperation{}
+
+	oldest, err := storage.GetOldestBlockIndex(ctx)
+	if err != nil {
+		return operations
+	}
+
+	if oldest < failure.Block.Index-FailureReportLookback+1 {
+		oldest = failure.Block.Index - FailureReportLookback + 1
+	}
+
+	accountKey := types.Hash(failure.Account)
+	currencyKey := types.Hash(failure.Currency)
+	for index := failure.Block.Index; index >= oldest; index-- {
+		i := index
+		block, err := storage.GetBlock(ctx, &types.PartialBlockIdentifier{Index: &i})
+		if err != nil {
+			break
+		}
+
+		// Operations are appended in reverse so
+		// that the most recent is first.
+		for j := len(block.Transactions) - 1; j >= 0; j-- {
+			transaction := block.Transactions[j]
+			for k := len(transaction.Operations) - 1; k >= 0; k-- {
+				op := transaction.Operations[k]
+				if op.Account == nil || op.Amount == nil ||
+					types.Hash(op.Account) != accountKey ||
+					types.Hash(op.Amount.Currency) != currencyKey {
+					continue
+				}
+
+				operations = append(operations, &ReportOperation{
+					Block:       block.BlockIdentifier,
+					Transaction: transaction.TransactionIdentifier,
+					Operation:   op,
+				})
+				if len(operations) >= maxOperations {
+					return operations
+				}
+			}
+		}
+	}
+
+	return operations
+}
+
+// Write writes the *FailureReport to FailureReportFileName in
+// dataPath and returns the path it was written to.
+func (r *FailureReport) Write(dataPath string) (string, error) {
+	filePath := path.Join(dataPath, FailureReportFileName)
+	if err := utils.SerializeAndWrite(filePath, r); err != nil {
+		return "", fmt.Errorf("%w: unable to write failure report", err)
+	}
+
+	return filePath, nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	storageErrs "github.com/coinbase/rosetta-sdk-go/storage/errors"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	account   = &types.AccountIdentifier{Address: "addr1"}
+	other     = &types.AccountIdentifier{Address: "addr2"}
+	reportBtc = &types.Currency{Symbol: "BTC", Decimals: 8}
+	reportEth = &types.Currency{Symbol: "ETH", Decimals: 18}
+)
+
+type reportMockStorage struct {
+	blocks map[int64]*types.Block
+	oldest int64
+	head   int64
+}
+
+func (m *reportMockStorage) GetHeadBlockIdentifier(ctx context.Context) (*types.BlockIdentifier, error) {
+	block, ok := m.blocks[m.head]
+	if !ok {
+		return nil, storageErrs.ErrHeadBlockNotFound
+	}
+
+	return block.BlockIdentifier, nil
+}
+
+func (m *reportMockStorage) GetOldestBlockIndex(ctx context.Context) (int64, error) {
+	return m.oldest, nil
+}
+
+func (m *reportMockStorage) GetBlock(
+	ctx context.Context,
+	blockIdentifier *types.PartialBlockIdentifier,
+) (*types.Block, error) {
+	block, ok := m.blocks[*blockIdentifier.Index]
+	if !ok {
+		return nil, storageErrs.ErrBlockNotFound
+	}
+
+	return block, nil
+}
+
+func reportBlockIdentifier(index int64) *types.BlockIdentifier {
+	return &types.BlockIdentifier{Index: index, Hash: fmt.Sprintf("block %d", index)}
+}
+
+// block returns a block at index with a single transaction
+// containing an operation of addr1 (BTC), addr2 (BTC), and
+// addr1 (ETH).
+func reportBlock(index int64) *types.Block {
+	op := func(i int64, account *types.AccountIdentifier, currency *types.Currency) *types.Operation {
+		return &types.Operation{
+			OperationIdentifier: &types.OperationIdentifier{Index: i},
+			Type:                "transfer",
+			Account:             account,
+			Amount:              &types.Amount{Value: fmt.Sprintf("%d", index), Currency: currency},
+		}
+	}
+
+	return &types.Block{
+		BlockIdentifier: reportBlockIdentifier(index),
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: fmt.Sprintf("tx %d", index),
+				},
+				Operations: []*types.Operation{
+					op(0, account, reportBtc),
+					op(1, other, reportBtc),
+					op(2, account, reportEth),
+				},
+			},
+		},
+	}
+}
+
+func TestNewFailureReport(t *testing.T) {
+	storage := &reportMockStorage{blocks: map[int64]*types.Block{}, oldest: 5, head: 12}
+	for i := int64(5); i <= 12; i++ {
+		storage.blocks[i] = reportBlock(i)
+	}
+
+	runErr := errors.New("reconciliation failure")
+	failure := &results.DiscrepancyFailure{
+		ReconciliationType: "active",
+		Account:            account,
+		Currency:           reportBtc,
+		ComputedBalance:    "100",
+		LiveBalance:        "90",
+		Block:              reportBlockIdentifier(10),
+	}
+
+	var tests = map[string]struct {
+		storage       ReportBlockStorage
+		failure       *results.DiscrepancyFailure
+		maxOperations int
+
+		expectedOperations []int64
+		expected           *FailureReport
+	}{
+		"no storage": {
+			expected: &FailureReport{Error: runErr.Error()},
+		},
+		"no reconciliation failure": {
+			storage:  storage,
+			expected: &FailureReport{Error: runErr.Error(), HeadBlock: reportBlockIdentifier(12)},
+		},
+		"reconciliation failure": {
+			storage:            storage,
+			failure:            failure,
+			maxOperations:      3,
+			expectedOperations: []int64{10, 9, 8},
+		},
+		"reconciliation failure with pruned blocks": {
+			storage:            storage,
+			failure:            failure,
+			maxOperations:      10,
+			expectedOperations: []int64{10, 9, 8, 7, 6, 5},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := NewFailureReport(context.Background(), test.storage, runErr, test.failure, test.maxOperations)
+			if test.expected != nil {
+				assert.Equal(t, test.expected, r)
+				return
+			}
+
+			assert.Equal(t, runErr.Error(), r.Error)
+			assert.Equal(t, reportBlockIdentifier(12), r.HeadBlock)
+			assert.Equal(t, account, r.Account)
+			assert.Equal(t, reportBtc, r.Currency)
+			assert.Equal(t, "100", r.ComputedBalance)
+			assert.Equal(t, "90", r.LiveBalance)
+			assert.Equal(t, reportBlockIdentifier(10), r.Block)
+
+			indexes := []int64{}
+			for _, op := range r.RecentOperations {
+				assert.Equal(t, account, op.Operation.Account)
+				assert.Equal(t, reportBtc, op.Operation.Amount.Currency)
+				assert.Equal(t, fmt.Sprintf("tx %d", op.Block.Index), op.Transaction.Hash)
+				indexes = append(indexes, op.Block.Index)
+			}
+			assert.Equal(t, test.expectedOperations, indexes)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	r := &FailureReport{
+		Error:     "reconciliation failure",
+		HeadBlock: reportBlockIdentifier(12),
+		Account:   account,
+		Currency:  reportBtc,
+	}
+	filePath, err := r.Write(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(dir, FailureReportFileName), filePath)
+
+	var written FailureReport
+	assert.NoError(t, utils.LoadAndParse(filePath, &written))
+	assert.Equal(t, r, &written)
+
+	_, err = r.Write(path.Join(dir, "missing"))
+	assert.Error(t, err)
+}
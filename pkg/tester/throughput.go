@@ -0,0 +1,131 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+)
+
+// sample is the head and target (-1
+// if unknown) observed at a time.
+type sample struct {
+	at     time.Time
+	head   int64
+	target int64
+}
+
+// ThroughputTracker records the head synced over time to compute
+// the current (rolling) and average rate of blocks synced
+// and the time remaining until a target is synced. The
+// target may move forward during syncing (i.e. tip), so
+// its rate is subtracted from the current rate.
+type ThroughputTracker struct {
+	window time.Duration
+
+	lock  sync.Mutex
+	first *sample
+
+	// samples are all observations in the window (and
+	// the last observation before it).
+	samples []*sample
+}
+
+// NewThroughputTracker returns a new *ThroughputTracker that computes the
+// current rate over window.
+func NewThroughputTracker(window time.Duration) *ThroughputTracker {
+	return &ThroughputTracker{window: window}
+}
+
+// Observe records head and target (-1 if check:data
+// is not syncing to a target) at time at.
+func (t *ThroughputTracker) Observe(at time.Time, head int64, target int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := &sample{at: at, head: head, target: target}
+	if t.first == nil {
+		t.first = s
+	}
+
+	t.samples = append(t.samples, s)
+	windowStart := at.Add(-t.window)
+	for len(t.samples) > 2 && !t.samples[1].at.After(windowStart) {
+		t.samples = t.samples[1:]
+	}
+}
+
+// rate returns the change from a to b per second (or 0 if
+// no time elapsed between a and b).
+func rate(a int64, b int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(b-a) / elapsed.Seconds()
+}
+
+// ThroughputProgress returns the *ThroughputProgress of the last
+// observation (or nil if nothing was observed).
+func (t *ThroughputTracker) Progress() *results.ThroughputProgress {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.samples) == 0 {
+		return nil
+	}
+
+	oldest := t.samples[0]
+	last := t.samples[len(t.samples)-1]
+	progress := &results.ThroughputProgress{
+		Head:        last.head,
+		CurrentRate: rate(oldest.head, last.head, last.at.Sub(oldest.at)),
+		AverageRate: rate(t.first.head, last.head, last.at.Sub(t.first.at)),
+	}
+
+	if last.target < 0 {
+		return progress
+	}
+
+	remaining := last.target - last.head
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	progress.Target = &last.target
+	progress.BlocksRemaining = &remaining
+	if remaining == 0 {
+		progress.ETA = time.Duration(0).String()
+		return progress
+	}
+
+	// The target rate is only known if the target
+	// was observed at the start of the window.
+	targetRate := float64(0)
+	if oldest.target >= 0 {
+		targetRate = rate(oldest.target, last.target, last.at.Sub(oldest.at))
+	}
+
+	netRate := progress.CurrentRate - targetRate
+	if netRate <= 0 {
+		return progress
+	}
+
+	eta := time.Duration(float64(remaining) / netRate * float64(time.Second))
+	progress.ETA = eta.Round(time.Second).String()
+	return progress
+}
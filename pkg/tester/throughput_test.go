@@ -0,0 +1,126 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThroughputTracker(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	var tests = map[string]struct {
+		// observations are head and target pairs
+		// (observed 10 seconds apart).
+		observations [][2]int64
+
+		expected *results.ThroughputProgress
+	}{
+		"no observations": {},
+		"single observation": {
+			observations: [][2]int64{{100, 1000}},
+			expected: &results.ThroughputProgress{
+				Head:            100,
+				Target:          types.Int64(1000),
+				BlocksRemaining: types.Int64(900),
+			},
+		},
+		"no target": {
+			observations: [][2]int64{{100, -1}, {200, -1}},
+			expected: &results.ThroughputProgress{
+				Head:        200,
+				CurrentRate: 10,
+				AverageRate: 10,
+			},
+		},
+		"fixed target": {
+			observations: [][2]int64{{100, 1000}, {200, 1000}},
+			expected: &results.ThroughputProgress{
+				Head:            200,
+				CurrentRate:     10,
+				AverageRate:     10,
+				Target:          types.Int64(1000),
+				BlocksRemaining: types.Int64(800),
+				ETA:             "1m20s",
+			},
+		},
+		"moving target": {
+			observations: [][2]int64{{100, 1000}, {200, 1050}},
+			expected: &results.ThroughputProgress{
+				Head:            200,
+				CurrentRate:     10,
+				AverageRate:     10,
+				Target:          types.Int64(1050),
+				BlocksRemaining: types.Int64(850),
+				ETA:             "2m50s",
+			},
+		},
+		"target moving faster than sync": {
+			observations: [][2]int64{{100, 1000}, {200, 1200}},
+			expected: &results.ThroughputProgress{
+				Head:            200,
+				CurrentRate:     10,
+				AverageRate:     10,
+				Target:          types.Int64(1200),
+				BlocksRemaining: types.Int64(1000),
+			},
+		},
+		"target synced": {
+			observations: [][2]int64{{100, 150}, {200, 150}},
+			expected: &results.ThroughputProgress{
+				Head:            200,
+				CurrentRate:     10,
+				AverageRate:     10,
+				Target:          types.Int64(150),
+				BlocksRemaining: types.Int64(0),
+				ETA:             "0s",
+			},
+		},
+		"rolling window": {
+			observations: [][2]int64{
+				{0, -1},
+				{1000, -1},
+				{1000, -1},
+				{1000, -1},
+				{1100, -1},
+			},
+			expected: &results.ThroughputProgress{
+				Head:        1100,
+				CurrentRate: 5,
+				AverageRate: 27.5,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := NewThroughputTracker(20 * time.Second)
+			for i, observation := range test.observations {
+				tracker.Observe(
+					start.Add(time.Duration(i)*10*time.Second),
+					observation[0],
+					observation[1],
+				)
+			}
+
+			assert.Equal(t, test.expected, tracker.Progress())
+		})
+	}
+}
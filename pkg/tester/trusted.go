@@ -0,0 +1,137 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/storage/database"
+	"github.com/coinbase/rosetta-sdk-go/storage/modules"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/neilotoole/errgroup"
+)
+
+var _ modules.BlockWorker = (*CheckpointVerifier)(nil)
+
+// CheckpointVerifier asserts that each synced block at the index of a
+// trusted checkpoint has the hash of the checkpoint. Blocks at
+// other indexes are not checked.
+type CheckpointVerifier struct {
+	checkpoints map[int64]string
+
+	lock     sync.Mutex
+	verified int
+}
+
+// NewCheckpointVerifier returns a new *CheckpointVerifier for checkpoints. Multiple
+// checkpoints may not have the same index.
+func NewCheckpointVerifier(checkpoints []*types.BlockIdentifier) (*CheckpointVerifier, error) {
+	hashes := map[int64]string{}
+	for _, checkpoint := range checkpoints {
+		if err := asserter.BlockIdentifier(checkpoint); err != nil {
+			return nil, fmt.Errorf("%w: invalid checkpoint", err)
+		}
+
+		if _, ok := hashes[checkpoint.Index]; ok {
+			return nil, fmt.Errorf("duplicate checkpoint at index %d", checkpoint.Index)
+		}
+
+		hashes[checkpoint.Index] = checkpoint.Hash
+	}
+
+	return &CheckpointVerifier{checkpoints: hashes}, nil
+}
+
+// LoadCheckpointVerifier returns a new *CheckpointVerifier for the checkpoints in
+// the file at filePath (a list of block identifiers).
+func LoadCheckpointVerifier(filePath string) (*CheckpointVerifier, error) {
+	checkpoints := []*types.BlockIdentifier{}
+	if err := utils.LoadAndParse(filePath, &checkpoints); err != nil {
+		return nil, fmt.Errorf("%w: unable to load checkpoints file %s", err, filePath)
+	}
+
+	v, err := NewCheckpointVerifier(checkpoints)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid checkpoints file %s", err, filePath)
+	}
+
+	return v, nil
+}
+
+// Len returns the number of trusted checkpoints.
+func (v *CheckpointVerifier) Len() int {
+	return len(v.checkpoints)
+}
+
+// Verified returns the number of synced blocks
+// that matched a trusted checkpoint.
+func (v *CheckpointVerifier) Verified() int {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	return v.verified
+}
+
+// Verify returns ErrTrustedCheckpointMismatch if there is a trusted checkpoint
+// at the index of block with a different hash.
+func (v *CheckpointVerifier) Verify(block *types.BlockIdentifier) error {
+	hash, ok := v.checkpoints[block.Index]
+	if !ok {
+		return nil
+	}
+
+	if hash != block.Hash {
+		return fmt.Errorf(
+			"%w: block %d is %s but trusted checkpoint is %s",
+			results.ErrTrustedCheckpointMismatch,
+			block.Index,
+			block.Hash,
+			hash,
+		)
+	}
+
+	v.lock.Lock()
+	v.verified++
+	v.lock.Unlock()
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+// Returning an error stops the block from being stored.
+func (v *CheckpointVerifier) AddingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	return nil, v.Verify(block.BlockIdentifier)
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+func (v *CheckpointVerifier) RemovingBlock(
+	ctx context.Context,
+	g *errgroup.Group,
+	block *types.Block,
+	transaction database.Transaction,
+) (database.CommitWorker, error) {
+	return nil, nil
+}
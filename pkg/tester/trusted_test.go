@@ -0,0 +1,148 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/pkg/results"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/coinbase/rosetta-sdk-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var checkpoints = []*types.BlockIdentifier{
+	{Index: 10, Hash: "block 10"},
+	{Index: 20, Hash: "block 20"},
+}
+
+func TestNewCheckpointVerifier(t *testing.T) {
+	var tests = map[string]struct {
+		checkpoints []*types.BlockIdentifier
+
+		expectedErr string
+	}{
+		"no checkpoints": {
+			checkpoints: []*types.BlockIdentifier{},
+		},
+		"valid checkpoints": {
+			checkpoints: checkpoints,
+		},
+		"invalid checkpoint": {
+			checkpoints: []*types.BlockIdentifier{{Index: 10}},
+			expectedErr: "invalid checkpoint",
+		},
+		"duplicate checkpoint": {
+			checkpoints: []*types.BlockIdentifier{
+				{Index: 10, Hash: "block 10"},
+				{Index: 10, Hash: "other block 10"},
+			},
+			expectedErr: "duplicate checkpoint at index 10",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			v, err := NewCheckpointVerifier(test.checkpoints)
+			if len(test.expectedErr) > 0 {
+				assert.Contains(t, err.Error(), test.expectedErr)
+				assert.Nil(t, v)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.checkpoints), v.Len())
+		})
+	}
+}
+
+func TestLoadCheckpointVerifier(t *testing.T) {
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	filePath := path.Join(dir, "checkpoints.json")
+	assert.NoError(t, utils.SerializeAndWrite(filePath, checkpoints))
+
+	v, err := LoadCheckpointVerifier(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v.Len())
+
+	v, err = LoadCheckpointVerifier(path.Join(dir, "missing.json"))
+	assert.Error(t, err)
+	assert.Nil(t, v)
+}
+
+func TestCheckpointVerifierAddingBlock(t *testing.T) {
+	var tests = map[string]struct {
+		blocks []*types.BlockIdentifier
+
+		expectedVerified int
+		expectedErr      bool
+	}{
+		"blocks without checkpoints": {
+			blocks: []*types.BlockIdentifier{
+				{Index: 9, Hash: "block 9"},
+				{Index: 11, Hash: "block 11"},
+			},
+		},
+		"matching checkpoints": {
+			blocks: []*types.BlockIdentifier{
+				{Index: 10, Hash: "block 10"},
+				{Index: 11, Hash: "block 11"},
+				{Index: 20, Hash: "block 20"},
+			},
+			expectedVerified: 2,
+		},
+		"mismatching checkpoint": {
+			blocks: []*types.BlockIdentifier{
+				{Index: 10, Hash: "block 10"},
+				{Index: 20, Hash: "orphaned block 20"},
+			},
+			expectedVerified: 1,
+			expectedErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			v, err := NewCheckpointVerifier(checkpoints)
+			assert.NoError(t, err)
+
+			var addErr error
+			for _, block := range test.blocks {
+				_, addErr = v.AddingBlock(
+					context.Background(),
+					nil,
+					&types.Block{BlockIdentifier: block},
+					nil,
+				)
+				if addErr != nil {
+					break
+				}
+			}
+
+			if test.expectedErr {
+				assert.ErrorIs(t, addErr, results.ErrTrustedCheckpointMismatch)
+			} else {
+				assert.NoError(t, addErr)
+			}
+			assert.Equal(t, test.expectedVerified, v.Verified())
+		})
+	}
+}